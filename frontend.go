@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// frontendHandler serves the embedded SPA with caching headers. Vite emits
+// content-hashed files under assets/, which never change for a given URL and
+// are cached for a year; everything else (index.html, favicon) is revalidated
+// on every load so a new build is picked up immediately.
+//
+// ETags are content hashes rather than the build version: source builds all
+// report "dev", so a version tag would keep serving a stale index.html.
+type frontendHandler struct {
+	content fs.FS
+	modTime time.Time // embedded files carry no mtime; use process start
+
+	mu    sync.Mutex
+	etags map[string]string
+}
+
+func newFrontendHandler(content fs.FS) *frontendHandler {
+	return &frontendHandler{
+		content: content,
+		modTime: time.Now().Truncate(time.Second),
+		etags:   make(map[string]string),
+	}
+}
+
+func (h *frontendHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if name == "" {
+		name = "index.html"
+	}
+	if fi, err := fs.Stat(h.content, name); err != nil || fi.IsDir() {
+		// SPA fallback: unknown paths are client-side routes.
+		name = "index.html"
+	}
+	if !h.serveFile(w, r, name) {
+		http.NotFound(w, r)
+	}
+}
+
+func (h *frontendHandler) serveFile(w http.ResponseWriter, r *http.Request, name string) bool {
+	f, err := h.content.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		return false
+	}
+	etag, err := h.etag(name, rs)
+	if err != nil {
+		return false
+	}
+
+	if strings.HasPrefix(name, "assets/") {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.Header().Set("ETag", etag)
+	// ServeContent answers HEAD, If-None-Match and If-Modified-Since (304).
+	http.ServeContent(w, r, name, h.modTime, rs)
+	return true
+}
+
+// etag returns the cached content hash of name, computing it on first use
+// and rewinding rs afterwards.
+func (h *frontendHandler) etag(name string, rs io.ReadSeeker) (string, error) {
+	h.mu.Lock()
+	tag, ok := h.etags[name]
+	h.mu.Unlock()
+	if ok {
+		return tag, nil
+	}
+	sum := sha256.New()
+	if _, err := io.Copy(sum, rs); err != nil {
+		return "", err
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	tag = `"` + hex.EncodeToString(sum.Sum(nil)[:12]) + `"`
+	h.mu.Lock()
+	h.etags[name] = tag
+	h.mu.Unlock()
+	return tag, nil
+}
@@ -818,6 +818,7 @@ function App() {
         setContractScanCompleted(true);
       } else if (currentTab === "radius") {
         let meta: StationCacheMeta | undefined;
+        let provisional: FlipResult[] = [];
         const results = await scan(
           params,
           setProgress,
@@ -825,6 +826,10 @@ function App() {
           (m) => {
             meta = m;
           },
+          (rows) => {
+            provisional = provisional.concat(rows);
+            setRadiusResults(provisional);
+          },
         );
         setRadiusResults(results);
         setRadiusCacheMeta(meta ?? null);
@@ -877,6 +882,7 @@ function App() {
       } else {
         // Keep old behavior for any legacy tab alias.
         let meta: StationCacheMeta | undefined;
+        let provisional: FlipResult[] = [];
         const results = await scanMultiRegion(
           params,
           setProgress,
@@ -884,6 +890,10 @@ function App() {
           (m) => {
             meta = m;
           },
+          (rows) => {
+            provisional = provisional.concat(rows);
+            setRegionResults(provisional);
+          },
         );
         setRegionResults(results);
         setRegionCacheMeta(meta ?? null);
@@ -1100,7 +1110,7 @@ function App() {
                     )}
                   </button>
                   <button
-                    onClick={handleLogin}
+                    onClick={() => handleLogin()}
                     disabled={loginPolling}
                     className="ml-1 p-1 text-eve-dim hover:text-eve-accent hover:bg-eve-dark/50 rounded-sm transition-colors disabled:opacity-60"
                     title={t("charAddCharacter")}
@@ -1134,7 +1144,7 @@ function App() {
                 </>
               ) : (
                 <button
-                  onClick={handleLogin}
+                  onClick={() => handleLogin()}
                   disabled={loginPolling}
                   className="text-eve-accent hover:text-eve-accent-hover transition-colors disabled:opacity-60"
                 >
@@ -1226,7 +1236,7 @@ function App() {
                   )}
                 </button>
                 <button
-                  onClick={handleLogin}
+                  onClick={() => handleLogin()}
                   disabled={loginPolling}
                   className="ml-1 p-1 text-eve-dim hover:text-eve-accent disabled:opacity-60"
                   title={t("charAddCharacter")}
@@ -1267,7 +1277,7 @@ function App() {
               </>
             ) : (
               <button
-                onClick={handleLogin}
+                onClick={() => handleLogin()}
                 disabled={loginPolling}
                 className="text-eve-accent disabled:opacity-60"
               >
@@ -1696,6 +1706,7 @@ function App() {
                 "route_target_system_name",
                 "route_min_isk_per_jump",
                 "route_allow_empty_hops",
+                "route_lowsec_jump_penalty",
               ];
               const filtered: Record<string, unknown> = {};
               for (const k of safeKeys) {
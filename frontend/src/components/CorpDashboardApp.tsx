@@ -11,6 +11,7 @@ import {
   MarketSection,
   type CorpTab,
 } from "./corp-dashboard/CorpDashboardSections";
+import type { CorpMode } from "./corp-dashboard/types";
 
 export function CorpDashboardApp() {
   const { t } = useI18n();
@@ -19,17 +20,19 @@ export function CorpDashboardApp() {
   const [error, setError] = useState<string | null>(null);
   const [tab, setTab] = useState<CorpTab>("overview");
 
-  // Read mode from URL search params
-  const mode = new URLSearchParams(window.location.search).get("mode") === "live" ? "live" : "demo";
+  // Read mode from URL search params; "auto" resolves to whichever data the server served.
+  const urlMode = new URLSearchParams(window.location.search).get("mode");
+  const requestedMode = urlMode === "live" || urlMode === "auto" ? urlMode : "demo";
+  const mode: CorpMode = requestedMode === "auto" ? (dashboard?.is_demo === false ? "live" : "demo") : requestedMode;
 
   useEffect(() => {
     setLoading(true);
     setError(null);
-    getCorpDashboard(mode)
+    getCorpDashboard(requestedMode)
       .then(setDashboard)
       .catch((e) => setError(e.message))
       .finally(() => setLoading(false));
-  }, [mode]);
+  }, [requestedMode]);
 
   const formatIsk = (value: number) => {
     if (Math.abs(value) >= 1e12) return `${(value / 1e12).toFixed(2)}T`;
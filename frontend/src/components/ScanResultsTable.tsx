@@ -204,6 +204,13 @@ const baseColumnDefs: ColumnDef[] = [
     width: "min-w-[110px]",
     numeric: true,
   },
+  {
+    key: "ISKPerJump",
+    labelKey: "colIskPerJumpReal",
+    width: "min-w-[110px]",
+    numeric: true,
+    tooltipKey: "colIskPerJumpRealHint",
+  },
   {
     key: "TotalJumps",
     labelKey: "colJumps",
@@ -662,6 +669,9 @@ function rowS2BBfSRatio(row: FlipResult): number {
 
 function getCellValue(row: FlipResult, key: SortKey): unknown {
   if (key === "IskPerM3") {
+    if (row.ISKPerM3 != null && Number.isFinite(row.ISKPerM3)) {
+      return row.ISKPerM3;
+    }
     if (row.IskPerM3 != null && Number.isFinite(row.IskPerM3)) {
       return row.IskPerM3;
     }
@@ -708,6 +718,7 @@ function fmtCell(col: ColumnDef, row: FlipResult): string {
     col.key === "SellPrice" ||
     col.key === "TotalProfit" ||
     col.key === "ProfitPerJump" ||
+    col.key === "ISKPerJump" ||
     col.key === "DailyProfit" ||
     col.key === "IskPerM3"
   ) {
@@ -86,9 +86,12 @@ function buildHistoryForRequest(messages: ChatMessage[]): StationAIHistoryMessag
     }));
 }
 
+type StationAIProvider = "openrouter" | "openai" | "anthropic" | "ollama";
+
 type StationAIConfig = {
-  provider: "openrouter";
+  provider: StationAIProvider;
   apiKey: string;
+  providerBaseUrl: string;
   model: string;
   useCustomModel: boolean;
   customModel: string;
@@ -117,9 +120,26 @@ const OPENROUTER_MODELS = [
   "meta-llama/llama-3.3-70b-instruct",
 ];
 
+const PROVIDER_MODELS: Record<StationAIProvider, string[]> = {
+  openrouter: OPENROUTER_MODELS,
+  openai: ["gpt-4o-mini", "gpt-4o"],
+  anthropic: ["claude-3-5-sonnet-latest", "claude-3-5-haiku-latest"],
+  ollama: ["llama3.1", "qwen2.5", "mistral"],
+};
+
+const OLLAMA_DEFAULT_URL = "http://localhost:11434/v1/chat/completions";
+
+const PROVIDER_LABELS: Record<StationAIProvider, string> = {
+  openrouter: "OpenRouter",
+  openai: "OpenAI",
+  anthropic: "Anthropic",
+  ollama: "Ollama (local)",
+};
+
 const DEFAULT_CONFIG: StationAIConfig = {
   provider: "openrouter",
   apiKey: "",
+  providerBaseUrl: OLLAMA_DEFAULT_URL,
   model: OPENROUTER_MODELS[0],
   useCustomModel: false,
   customModel: "",
@@ -207,7 +227,10 @@ function loadAIConfig(): StationAIConfig {
     return {
       ...DEFAULT_CONFIG,
       ...parsed,
-      provider: "openrouter",
+      provider:
+        parsed.provider && parsed.provider in PROVIDER_MODELS
+          ? parsed.provider
+          : DEFAULT_CONFIG.provider,
       temperature: Number.isFinite(parsed.temperature)
         ? Math.max(0, Math.min(2, Number(parsed.temperature)))
         : DEFAULT_CONFIG.temperature,
@@ -1297,7 +1320,7 @@ export function StationAIAssistant({
   const sendMessage = async (text: string) => {
     const content = text.trim();
     if (!content || thinking || disabled || sendInFlightRef.current) return;
-    if (!cfg.apiKey.trim()) {
+    if (!cfg.apiKey.trim() && cfg.provider !== "ollama") {
       setConfigOpen(true);
       addToast(t("aiErrorNoKey"), "error", 2800);
       return;
@@ -1359,7 +1382,9 @@ export function StationAIAssistant({
     try {
       const res = await stationAIChatStream(
         {
-          provider: "openrouter",
+          provider: cfg.provider,
+          provider_base_url:
+            cfg.provider === "ollama" ? cfg.providerBaseUrl.trim() : undefined,
           api_key: cfg.apiKey.trim(),
           model: effectiveModel,
           temperature: cfg.temperature,
@@ -1891,13 +1916,40 @@ export function StationAIAssistant({
             <div className="mt-3 grid grid-cols-1 sm:grid-cols-2 gap-3">
               <label className="text-xs text-eve-dim">
                 <span className="block mb-1">{t("aiProvider")}</span>
-                <input
-                  value="OpenRouter"
-                  disabled
-                  className="w-full h-8 rounded-sm border border-eve-border bg-eve-dark px-2 text-eve-dim"
-                />
+                <select
+                  value={cfg.provider}
+                  onChange={(e) => {
+                    const provider = e.target.value as StationAIProvider;
+                    setCfg((prev) => ({
+                      ...prev,
+                      provider,
+                      model: PROVIDER_MODELS[provider][0],
+                    }));
+                  }}
+                  className="w-full h-8 rounded-sm border border-eve-border bg-eve-input px-2 text-eve-text"
+                >
+                  {(Object.keys(PROVIDER_MODELS) as StationAIProvider[]).map((p) => (
+                    <option key={p} value={p}>
+                      {PROVIDER_LABELS[p]}
+                    </option>
+                  ))}
+                </select>
               </label>
 
+              {cfg.provider === "ollama" && (
+                <label className="text-xs text-eve-dim">
+                  <span className="block mb-1">{t("aiProviderBaseUrl")}</span>
+                  <input
+                    value={cfg.providerBaseUrl}
+                    onChange={(e) =>
+                      setCfg((prev) => ({ ...prev, providerBaseUrl: e.target.value }))
+                    }
+                    placeholder={OLLAMA_DEFAULT_URL}
+                    className="w-full h-8 rounded-sm border border-eve-border bg-eve-input px-2 text-eve-text"
+                  />
+                </label>
+              )}
+
               <label className="text-xs text-eve-dim sm:col-span-2">
                 <span className="block mb-1">{t("aiApiKey")}</span>
                 <input
@@ -1920,7 +1972,7 @@ export function StationAIAssistant({
                   }
                   className="w-full h-8 rounded-sm border border-eve-border bg-eve-input px-2 text-eve-text"
                 >
-                  {OPENROUTER_MODELS.map((m) => (
+                  {PROVIDER_MODELS[cfg.provider].map((m) => (
                     <option key={m} value={m}>
                       {m}
                     </option>
@@ -25,7 +25,7 @@ export function IndustrySection({
 
   useEffect(() => {
     setLoading(true);
-    getCorpIndustryJobs(mode).then(setJobs).catch(() => setJobs([])).finally(() => setLoading(false));
+    getCorpIndustryJobs(mode).then((r) => setJobs(r.items)).catch(() => setJobs([])).finally(() => setLoading(false));
   }, [mode]);
 
   const toggleSort = (key: typeof sortKey) => {
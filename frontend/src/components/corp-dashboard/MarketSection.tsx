@@ -24,7 +24,7 @@ export function MarketSection({
 
   useEffect(() => {
     setLoading(true);
-    getCorpOrders(mode).then(setOrders).catch(() => setOrders([])).finally(() => setLoading(false));
+    getCorpOrders(mode).then((r) => setOrders(r.items)).catch(() => setOrders([])).finally(() => setLoading(false));
   }, [mode]);
 
   const toggleSort = (key: typeof sortKey) => {
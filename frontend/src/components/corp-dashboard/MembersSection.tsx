@@ -25,7 +25,7 @@ export function MembersSection({
   useEffect(() => {
     setMembersLoading(true);
     getCorpMembers(mode)
-      .then(setMembers)
+      .then((r) => setMembers(r.items))
       .catch(() => setMembers([]))
       .finally(() => setMembersLoading(false));
   }, [mode]);
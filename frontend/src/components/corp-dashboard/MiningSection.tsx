@@ -25,7 +25,7 @@ export function MiningSection({
 
   useEffect(() => {
     setLoading(true);
-    getCorpMiningLedger(mode).then(setEntries).catch(() => setEntries([])).finally(() => setLoading(false));
+    getCorpMiningLedger(mode).then((r) => setEntries(r.items)).catch(() => setEntries([])).finally(() => setLoading(false));
   }, [mode]);
 
   const cutoff = useMemo(() => {
@@ -27,7 +27,7 @@ export function WalletsSection({
     setExpandedDiv(div);
     setJournalLoading(true);
     getCorpJournal(mode, div, 30)
-      .then(setJournal)
+      .then((r) => setJournal(r.items))
       .catch(() => setJournal([]))
       .finally(() => setJournalLoading(false));
   };
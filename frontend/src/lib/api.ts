@@ -1,27 +1,48 @@
 import type {
   AlertHistoryEntry,
+  ApiErrorCode,
   AppConfig,
   AppStatus,
   AuthStatus,
   CharacterInfo,
   CharacterRoles,
+  ConfigHistoryEntry,
+  ConfigProfile,
+  CTSProfile,
+  FeeBreakdown,
+  MarketDisabledList,
+  ScanTemplate,
+  ScanTemplateTab,
+  TypeSuggestion,
+  TypeInfo,
+  NetWorth,
+  ResolvedLocation,
+  CTSWeights,
+  ConfigValidation,
   ContractDetails,
   ContractResult,
   CorpDashboard,
+  CorpWalletSummary,
   CorpIndustryJob,
   CorpJournalEntry,
   CorpMarketOrderDetail,
+  CorpList,
   CorpMember,
   CorpMiningEntry,
+  CustomFittingDemandResponse,
   DemandRegionResponse,
   DemandRegionsResponse,
   ExecutionPlanResult,
   FlipResult,
   HotZonesResponse,
+  HubBasketItem,
+  HubCompareResponse,
+  IndustryAssetLocations,
   IndustryJob,
   IndustryJobStatus,
   IndustryLedger,
   IndustryMaterialPlanRecord,
+  IndustryPlanDiff,
   IndustryPlanPatch,
   IndustryPlanPreview,
   IndustryPlanSummary,
@@ -30,18 +51,24 @@ import type {
   IndustryTaskRecord,
   IndustryTaskStatus,
   OptimizerDiagnostic,
+  OpenPositionsResponse,
   OrderDeskResponse,
   PLEXDashboard,
   PortfolioPnL,
   PortfolioOptimization,
+  RealizedPnLReport,
   RegionOpportunities,
   RouteResult,
   ScanParams,
   ScanRecord,
   StationAIChatRequest,
   StationAIChatResponse,
+  StationAIConversation,
+  StationAIConversationDetail,
+  StationAIUsageSummary,
   StationAIStreamMessage,
   StationCacheMeta,
+  CacheStatusResponse,
   StationCommandResponse,
   StationInfo,
   StationsResponse,
@@ -49,22 +76,78 @@ import type {
   StationTradeState,
   StationTradeStateMode,
   UndercutStatus,
+  WatchlistHistory,
   WatchlistItem,
 } from "./types";
 
 const BASE = import.meta.env.VITE_API_URL || "";
 
+const API_KEY_STORAGE = "eveflipper_api_key";
+
+function storedApiKey(): string {
+  try {
+    return localStorage.getItem(API_KEY_STORAGE) ?? "";
+  } catch {
+    return "";
+  }
+}
+
+function storeApiKey(key: string) {
+  try {
+    localStorage.setItem(API_KEY_STORAGE, key);
+  } catch {
+    // Storage unavailable (private mode); the cookie still covers this session.
+  }
+  // Browser navigations (SSO login) cannot send headers, so mirror it in a cookie.
+  document.cookie = `${API_KEY_STORAGE}=${encodeURIComponent(key)}; path=/; SameSite=Strict`;
+}
+
+// fetch wrapper that sends the server API key (EVEFLIPPER_API_KEY) when one is
+// stored, and asks for it once when the server rejects a request for lacking it.
+async function apiFetch(input: string, init?: RequestInit, retried = false): Promise<Response> {
+  const key = storedApiKey();
+  const headers = new Headers(init?.headers);
+  if (key) headers.set("X-API-Key", key);
+  const res = await fetch(input, { ...init, headers });
+  if (res.status === 401 && !retried && res.headers.get("WWW-Authenticate")?.startsWith("Bearer")) {
+    const entered = window.prompt("This EVE Flipper server requires an API key:")?.trim();
+    if (entered) {
+      storeApiKey(entered);
+      return apiFetch(input, init, true);
+    }
+  }
+  return res;
+}
+
 // Helper to handle HTTP errors consistently
+/** Error thrown for non-2xx API responses; `code` is the server's machine-readable error code. */
+export class ApiError extends Error {
+  constructor(
+    message: string,
+    readonly status: number,
+    readonly code?: ApiErrorCode,
+  ) {
+    super(message);
+    this.name = "ApiError";
+  }
+}
+
+async function responseError(res: Response, fallback: string): Promise<ApiError> {
+  let message = fallback;
+  let code: ApiErrorCode | undefined;
+  try {
+    const err = await res.json();
+    message = err.error || err.message || message;
+    code = err.code;
+  } catch {
+    // Response body is not JSON
+  }
+  return new ApiError(message, res.status, code);
+}
+
 async function handleResponse<T>(res: Response): Promise<T> {
   if (!res.ok) {
-    let errorMessage = `HTTP ${res.status}`;
-    try {
-      const err = await res.json();
-      errorMessage = err.error || err.message || errorMessage;
-    } catch {
-      // Response body is not JSON
-    }
-    throw new Error(errorMessage);
+    throw await responseError(res, `HTTP ${res.status}`);
   }
   return res.json();
 }
@@ -72,7 +155,9 @@ async function handleResponse<T>(res: Response): Promise<T> {
 // Generic NDJSON message type
 type NdjsonGenericMessage<T> =
   | { type: "progress"; message: string }
-  | { type: "result"; data: T[]; count?: number; scan_id?: number; cache_meta?: StationCacheMeta }
+  | { type: "result"; data: T[]; count?: number; scan_id?: number; cache_meta?: StationCacheMeta; partial?: boolean }
+  /** Provisional rows sent while a flip scan runs; the result frame replaces them. */
+  | { type: "partial"; data: T[]; count?: number }
   | { type: "error"; message: string };
 
 // Generic NDJSON streaming helper to eliminate code duplication
@@ -82,9 +167,10 @@ async function streamNdjson<T>(
   onProgress: (msg: string) => void,
   signal?: AbortSignal,
   errorMessage = "Request failed",
-  onResult?: (msg: Extract<NdjsonGenericMessage<T>, { type: "result" }>) => void
+  onResult?: (msg: Extract<NdjsonGenericMessage<T>, { type: "result" }>) => void,
+  onPartial?: (rows: T[]) => void
 ): Promise<T[]> {
-  const res = await fetch(url, {
+  const res = await apiFetch(url, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(body),
@@ -92,14 +178,7 @@ async function streamNdjson<T>(
   });
 
   if (!res.ok) {
-    let errMsg = errorMessage;
-    try {
-      const err = await res.json();
-      errMsg = err.error || err.message || errMsg;
-    } catch {
-      // Response body is not JSON
-    }
-    throw new Error(errMsg);
+    throw await responseError(res, errorMessage);
   }
 
   if (!res.body) {
@@ -123,6 +202,8 @@ async function streamNdjson<T>(
       const msg = JSON.parse(line) as NdjsonGenericMessage<T>;
       if (msg.type === "progress") {
         onProgress(msg.message);
+      } else if (msg.type === "partial") {
+        onPartial?.(msg.data ?? []);
       } else if (msg.type === "result") {
         results = msg.data ?? [];
         onResult?.(msg);
@@ -145,18 +226,71 @@ async function streamNdjson<T>(
   return results;
 }
 
+export type WebSocketScanType = "radius" | "multi_region" | "contracts";
+
+/**
+ * Runs a scan over GET /api/ws/scan. Frames match the NDJSON endpoints;
+ * aborting the signal closes the socket, which cancels the scan server-side.
+ */
+export function scanOverWebSocket<T>(
+  scan: WebSocketScanType,
+  body: object,
+  onProgress: (msg: string) => void,
+  signal?: AbortSignal,
+  onResult?: (msg: Extract<NdjsonGenericMessage<T>, { type: "result" }>) => void,
+  onPartial?: (rows: T[]) => void
+): Promise<T[]> {
+  return new Promise<T[]>((resolve, reject) => {
+    const url = new URL(`${BASE}/api/ws/scan`, window.location.href);
+    url.protocol = url.protocol === "https:" ? "wss:" : "ws:";
+    const ws = new WebSocket(url);
+    let settled = false;
+    const finish = (err?: Error, results?: T[]) => {
+      if (settled) return;
+      settled = true;
+      signal?.removeEventListener("abort", onAbort);
+      if (err) reject(err);
+      else resolve(results ?? []);
+      ws.close();
+    };
+    const onAbort = () => finish(new DOMException("Aborted", "AbortError"));
+    if (signal?.aborted) {
+      onAbort();
+      return;
+    }
+    signal?.addEventListener("abort", onAbort);
+
+    ws.onopen = () => ws.send(JSON.stringify({ ...body, scan }));
+    ws.onmessage = (ev) => {
+      const msg = JSON.parse(String(ev.data)) as NdjsonGenericMessage<T>;
+      if (msg.type === "progress") {
+        onProgress(msg.message);
+      } else if (msg.type === "partial") {
+        onPartial?.(msg.data ?? []);
+      } else if (msg.type === "result") {
+        onResult?.(msg);
+        finish(undefined, msg.data ?? []);
+      } else if (msg.type === "error") {
+        finish(new Error(msg.message));
+      }
+    };
+    ws.onerror = () => finish(new Error("WebSocket scan failed"));
+    ws.onclose = () => finish(new Error("WebSocket closed before the scan finished"));
+  });
+}
+
 export async function getStatus(): Promise<AppStatus> {
-  const res = await fetch(`${BASE}/api/status`);
+  const res = await apiFetch(`${BASE}/api/status`);
   return handleResponse<AppStatus>(res);
 }
 
 export async function getConfig(): Promise<AppConfig> {
-  const res = await fetch(`${BASE}/api/config`);
+  const res = await apiFetch(`${BASE}/api/config`);
   return handleResponse<AppConfig>(res);
 }
 
 export async function updateConfig(patch: Partial<AppConfig>): Promise<AppConfig> {
-  const res = await fetch(`${BASE}/api/config`, {
+  const res = await apiFetch(`${BASE}/api/config`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(patch),
@@ -164,8 +298,123 @@ export async function updateConfig(patch: Partial<AppConfig>): Promise<AppConfig
   return handleResponse<AppConfig>(res);
 }
 
+/** Shows how a patch would be clamped without saving it. */
+export async function validateConfig(patch: Partial<AppConfig>): Promise<ConfigValidation> {
+  const res = await apiFetch(`${BASE}/api/config/validate`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(patch),
+  });
+  const data = await handleResponse<ConfigValidation>(res);
+  return { config: data.config, adjustments: Array.isArray(data.adjustments) ? data.adjustments : [] };
+}
+
+export async function getConfigHistory(limit = 20): Promise<ConfigHistoryEntry[]> {
+  const res = await apiFetch(`${BASE}/api/config/history?limit=${limit}`);
+  const data = await handleResponse<{ history: ConfigHistoryEntry[] }>(res);
+  return Array.isArray(data.history) ? data.history : [];
+}
+
+export async function revertConfig(id: number): Promise<AppConfig> {
+  const res = await apiFetch(`${BASE}/api/config/history/${id}/revert`, { method: "POST" });
+  return handleResponse<AppConfig>(res);
+}
+
+export async function getConfigProfiles(): Promise<ConfigProfile[]> {
+  const res = await apiFetch(`${BASE}/api/config/profiles`);
+  const data = await handleResponse<{ profiles: ConfigProfile[] }>(res);
+  return Array.isArray(data.profiles) ? data.profiles : [];
+}
+
+/** Saves a named profile; omit config to snapshot the active config. */
+export async function saveConfigProfile(name: string, config?: Partial<AppConfig>): Promise<ConfigProfile> {
+  const res = await apiFetch(`${BASE}/api/config/profiles`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ name, config }),
+  });
+  return handleResponse<ConfigProfile>(res);
+}
+
+export async function deleteConfigProfile(name: string): Promise<void> {
+  const res = await apiFetch(`${BASE}/api/config/profiles/${encodeURIComponent(name)}`, { method: "DELETE" });
+  await handleResponse<{ ok: boolean }>(res);
+}
+
+export async function activateConfigProfile(name: string): Promise<AppConfig> {
+  const res = await apiFetch(`${BASE}/api/config/profiles/${encodeURIComponent(name)}/activate`, { method: "POST" });
+  return handleResponse<AppConfig>(res);
+}
+
+export async function getCTSProfiles(): Promise<{ builtin: { name: string; weights: CTSWeights }[]; profiles: CTSProfile[] }> {
+  const res = await apiFetch(`${BASE}/api/cts/profiles`);
+  const data = await handleResponse<{ builtin: { name: string; weights: CTSWeights }[]; profiles: CTSProfile[] }>(res);
+  return {
+    builtin: Array.isArray(data.builtin) ? data.builtin : [],
+    profiles: Array.isArray(data.profiles) ? data.profiles : [],
+  };
+}
+
+export async function saveCTSProfile(name: string, weights: CTSWeights): Promise<CTSProfile> {
+  const res = await apiFetch(`${BASE}/api/cts/profiles`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ name, weights }),
+  });
+  return handleResponse<CTSProfile>(res);
+}
+
+export async function deleteCTSProfile(name: string): Promise<void> {
+  const res = await apiFetch(`${BASE}/api/cts/profiles/${encodeURIComponent(name)}`, { method: "DELETE" });
+  await handleResponse<{ ok: boolean }>(res);
+}
+
+export async function listScanTemplates(tab?: ScanTemplateTab): Promise<ScanTemplate[]> {
+  const qs = tab ? `?tab=${tab}` : "";
+  const res = await apiFetch(`${BASE}/api/scan/templates${qs}`);
+  const data = await handleResponse<ScanTemplate[]>(res);
+  return Array.isArray(data) ? data : [];
+}
+
+export async function saveScanTemplate(
+  tab: ScanTemplateTab,
+  name: string,
+  params: Record<string, unknown>,
+): Promise<ScanTemplate> {
+  const res = await apiFetch(`${BASE}/api/scan/templates`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ tab, name, params }),
+  });
+  return handleResponse<ScanTemplate>(res);
+}
+
+export async function deleteScanTemplate(id: number): Promise<void> {
+  const res = await apiFetch(`${BASE}/api/scan/templates/${id}`, { method: "DELETE" });
+  await handleResponse<{ ok: boolean }>(res);
+}
+
+export async function getMarketDisabled(): Promise<MarketDisabledList> {
+  const res = await apiFetch(`${BASE}/api/market-disabled`);
+  return handleResponse<MarketDisabledList>(res);
+}
+
+export async function setMarketDisabledOverride(typeId: number, action: "add"): Promise<MarketDisabledList> {
+  const res = await apiFetch(`${BASE}/api/market-disabled/overrides`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ type_id: typeId, action }),
+  });
+  return handleResponse<MarketDisabledList>(res);
+}
+
+export async function deleteMarketDisabledOverride(typeId: number): Promise<MarketDisabledList> {
+  const res = await apiFetch(`${BASE}/api/market-disabled/overrides/${typeId}`, { method: "DELETE" });
+  return handleResponse<MarketDisabledList>(res);
+}
+
 export async function testAlertChannels(message?: string): Promise<{ sent: string[]; failed?: Record<string, string> }> {
-  const res = await fetch(`${BASE}/api/alerts/test`, {
+  const res = await apiFetch(`${BASE}/api/alerts/test`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify({ message: message ?? "" }),
@@ -174,22 +423,94 @@ export async function testAlertChannels(message?: string): Promise<{ sent: strin
 }
 
 export async function autocomplete(query: string): Promise<string[]> {
-  const res = await fetch(`${BASE}/api/systems/autocomplete?q=${encodeURIComponent(query)}`);
+  const res = await apiFetch(`${BASE}/api/systems/autocomplete?q=${encodeURIComponent(query)}`);
   const data = await handleResponse<{ systems?: string[] }>(res);
   return data.systems ?? [];
 }
 
 export async function autocompleteRegion(query: string): Promise<string[]> {
-  const res = await fetch(`${BASE}/api/regions/autocomplete?q=${encodeURIComponent(query)}`);
+  const res = await apiFetch(`${BASE}/api/regions/autocomplete?q=${encodeURIComponent(query)}`);
   const data = await handleResponse<{ regions?: string[] }>(res);
   return data.regions ?? [];
 }
 
+export async function autocompleteType(query: string): Promise<TypeSuggestion[]> {
+  const res = await apiFetch(`${BASE}/api/types/autocomplete?q=${encodeURIComponent(query)}`);
+  const data = await handleResponse<{ types?: TypeSuggestion[] }>(res);
+  return data.types ?? [];
+}
+
+export async function getTypeInfo(typeID: number): Promise<TypeInfo> {
+  const res = await apiFetch(`${BASE}/api/types/${typeID}`);
+  return handleResponse<TypeInfo>(res);
+}
+
+export async function resolveLocations(locationIDs: number[]): Promise<ResolvedLocation[]> {
+  const res = await apiFetch(`${BASE}/api/locations/resolve`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ location_ids: locationIDs }),
+  });
+  const data = await handleResponse<{ locations?: ResolvedLocation[] }>(res);
+  return data.locations ?? [];
+}
+
+export interface FeeSimulationRequest {
+  buy_price: number;
+  sell_price: number;
+  quantity?: number;
+  /** m3 per unit; shipping needs volume, jumps and a rate. */
+  volume?: number;
+  jumps?: number;
+  shipping_cost_per_m3_jump?: number;
+  /** Derive sales tax and broker fee from this character's skills. */
+  character_id?: number;
+  sales_tax_percent?: number;
+  broker_fee?: number;
+  split_trade_fees?: boolean;
+  buy_broker_fee_percent?: number;
+  sell_broker_fee_percent?: number;
+  buy_sales_tax_percent?: number;
+  sell_sales_tax_percent?: number;
+}
+
+export interface FeeSimulation {
+  quantity: number;
+  buy_cost: number;
+  sell_revenue: number;
+  gross_profit: number;
+  buy_broker: number;
+  buy_tax: number;
+  sell_broker: number;
+  sell_tax: number;
+  total_fees: number;
+  shipping_cost: number;
+  net_profit: number;
+  margin_percent: number;
+  per_unit: FeeBreakdown;
+  sales_tax_percent: number;
+  broker_fee_percent: number;
+  sales_tax_source: "request" | "skills" | "config";
+  broker_fee_source: "request" | "skills" | "config";
+  warnings: string[];
+}
+
+/** Prices a hypothetical trade with the same fee math as the scanners. */
+export async function simulateFees(req: FeeSimulationRequest): Promise<FeeSimulation> {
+  const res = await apiFetch(`${BASE}/api/fees/simulate`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(req),
+  });
+  return handleResponse<FeeSimulation>(res);
+}
+
 export async function scan(
   params: ScanParams,
   onProgress: (msg: string) => void,
   signal?: AbortSignal,
-  onMeta?: (meta: StationCacheMeta | undefined) => void
+  onMeta?: (meta: StationCacheMeta | undefined) => void,
+  onPartial?: (rows: FlipResult[]) => void
 ): Promise<FlipResult[]> {
   return streamNdjson<FlipResult>(
     `${BASE}/api/scan`,
@@ -198,6 +519,7 @@ export async function scan(
     signal,
     "Scan failed",
     (msg) => onMeta?.(msg.cache_meta),
+    onPartial,
   );
 }
 
@@ -205,7 +527,8 @@ export async function scanMultiRegion(
   params: ScanParams,
   onProgress: (msg: string) => void,
   signal?: AbortSignal,
-  onMeta?: (meta: StationCacheMeta | undefined) => void
+  onMeta?: (meta: StationCacheMeta | undefined) => void,
+  onPartial?: (rows: FlipResult[]) => void
 ): Promise<FlipResult[]> {
   return streamNdjson<FlipResult>(
     `${BASE}/api/scan/multi-region`,
@@ -214,6 +537,7 @@ export async function scanMultiRegion(
     signal,
     "Multi-region scan failed",
     (msg) => onMeta?.(msg.cache_meta),
+    onPartial,
   );
 }
 
@@ -289,6 +613,7 @@ export async function findRoutes(
       min_route_security: params.min_route_security,
       allow_empty_hops: params.route_allow_empty_hops,
       include_structures: params.include_structures,
+      security_penalty_per_lowsec_jump: params.route_lowsec_jump_penalty,
     },
     onProgress,
     signal,
@@ -299,7 +624,7 @@ export async function findRoutes(
 // --- Watchlist ---
 
 export async function getWatchlist(): Promise<WatchlistItem[]> {
-  const res = await fetch(`${BASE}/api/watchlist`);
+  const res = await apiFetch(`${BASE}/api/watchlist`);
   return handleResponse<WatchlistItem[]>(res);
 }
 
@@ -309,7 +634,7 @@ export interface AddWatchlistResult {
 }
 
 export async function addToWatchlist(typeId: number, typeName: string, alertMinMargin: number = 0): Promise<AddWatchlistResult> {
-  const res = await fetch(`${BASE}/api/watchlist`, {
+  const res = await apiFetch(`${BASE}/api/watchlist`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify({
@@ -324,8 +649,38 @@ export async function addToWatchlist(typeId: number, typeName: string, alertMinM
   return handleResponse<AddWatchlistResult>(res);
 }
 
+export interface BulkAddWatchlistResult {
+  items: WatchlistItem[];
+  inserted_count: number;
+}
+
+export async function bulkAddToWatchlist(
+  typeIds: number[],
+  alertMetric: "margin_percent" | "total_profit" | "profit_per_unit" | "daily_volume" = "margin_percent",
+  alertThreshold: number = 0,
+): Promise<BulkAddWatchlistResult> {
+  const res = await apiFetch(`${BASE}/api/watchlist/bulk`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({
+      type_ids: typeIds,
+      alert_metric: alertMetric,
+      alert_threshold: alertThreshold,
+    }),
+  });
+  return handleResponse<BulkAddWatchlistResult>(res);
+}
+
+export async function getWatchlistHistory(typeId: number, regionId?: number, days: number = 30): Promise<WatchlistHistory> {
+  const params = new URLSearchParams();
+  if (regionId) params.set("region_id", String(regionId));
+  params.set("days", String(days));
+  const res = await apiFetch(`${BASE}/api/watchlist/${typeId}/history?${params}`);
+  return handleResponse<WatchlistHistory>(res);
+}
+
 export async function removeFromWatchlist(typeId: number): Promise<WatchlistItem[]> {
-  const res = await fetch(`${BASE}/api/watchlist/${typeId}`, { method: "DELETE" });
+  const res = await apiFetch(`${BASE}/api/watchlist/${typeId}`, { method: "DELETE" });
   return handleResponse<WatchlistItem[]>(res);
 }
 
@@ -334,8 +689,10 @@ export async function updateWatchlistItem(typeId: number, patch: {
   alert_enabled?: boolean;
   alert_metric?: "margin_percent" | "total_profit" | "profit_per_unit" | "daily_volume";
   alert_threshold?: number;
+  region_id?: number;
+  demand_alert_threshold?: number;
 }): Promise<WatchlistItem[]> {
-  const res = await fetch(`${BASE}/api/watchlist/${typeId}`, {
+  const res = await apiFetch(`${BASE}/api/watchlist/${typeId}`, {
     method: "PUT",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(patch),
@@ -343,25 +700,39 @@ export async function updateWatchlistItem(typeId: number, patch: {
   return handleResponse<WatchlistItem[]>(res);
 }
 
-export async function getAlertHistory(typeId?: number, limit?: number, offset?: number): Promise<AlertHistoryEntry[]> {
+export interface AlertHistoryFilters {
+  since?: string; // RFC3339, inclusive
+  until?: string; // RFC3339, exclusive
+  channel?: "telegram" | "discord" | "desktop";
+}
+
+export async function getAlertHistory(
+  typeId?: number,
+  limit?: number,
+  offset?: number,
+  filters?: AlertHistoryFilters,
+): Promise<AlertHistoryEntry[]> {
   const params = new URLSearchParams();
   if (typeId) params.set("type_id", String(typeId));
   if (limit) params.set("limit", String(limit));
   if (offset && offset > 0) params.set("offset", String(offset));
+  if (filters?.since) params.set("since", filters.since);
+  if (filters?.until) params.set("until", filters.until);
+  if (filters?.channel) params.set("channel", filters.channel);
   const query = params.toString();
-  const res = await fetch(`${BASE}/api/alerts/history${query ? `?${query}` : ""}`);
+  const res = await apiFetch(`${BASE}/api/alerts/history${query ? `?${query}` : ""}`);
   return handleResponse<AlertHistoryEntry[]>(res);
 }
 
 // --- Station Trading ---
 
 export async function getStations(systemName: string, signal?: AbortSignal): Promise<StationsResponse> {
-  const res = await fetch(`${BASE}/api/stations?system=${encodeURIComponent(systemName)}`, { signal });
+  const res = await apiFetch(`${BASE}/api/stations?system=${encodeURIComponent(systemName)}`, { signal });
   return handleResponse<StationsResponse>(res);
 }
 
 export async function getStructures(systemId: number, regionId: number, signal?: AbortSignal): Promise<StationInfo[]> {
-  const res = await fetch(`${BASE}/api/auth/structures?system_id=${systemId}&region_id=${regionId}`, { signal });
+  const res = await apiFetch(`${BASE}/api/auth/structures?system_id=${systemId}&region_id=${regionId}`, { signal });
   return handleResponse<StationInfo[]>(res);
 }
 
@@ -375,7 +746,7 @@ export async function getExecutionPlan(params: {
   impact_days?: number;
   signal?: AbortSignal;
 }): Promise<ExecutionPlanResult> {
-  const res = await fetch(`${BASE}/api/execution/plan`, {
+  const res = await apiFetch(`${BASE}/api/execution/plan`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     signal: params.signal,
@@ -391,6 +762,20 @@ export async function getExecutionPlan(params: {
   return handleResponse<ExecutionPlanResult>(res);
 }
 
+/** Ranks hub systems by the net proceeds of selling a basket into their buy orders. */
+export async function compareHubs(
+  items: HubBasketItem[],
+  systems: string[],
+  salesTaxPercent?: number,
+): Promise<HubCompareResponse> {
+  const res = await apiFetch(`${BASE}/api/scan/hub-compare`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ items, systems, sales_tax_percent: salesTaxPercent }),
+  });
+  return handleResponse<HubCompareResponse>(res);
+}
+
 export async function scanStation(
   params: {
     station_id?: number;
@@ -412,6 +797,7 @@ export async function scanStation(
     min_demand_per_day?: number;
     min_s2b_per_day?: number;
     min_bfs_per_day?: number;
+    min_isk_per_hour?: number;
     // Risk Profile
     avg_price_period?: number;
     min_period_roi?: number;
@@ -421,6 +807,7 @@ export async function scanStation(
     max_sds?: number;
     limit_buy_to_price_low?: boolean;
     flag_extreme_prices?: boolean;
+    restock_days?: number;
     // Player structures
     include_structures?: boolean;
     structure_ids?: number[];
@@ -455,7 +842,7 @@ export async function getStationTradeStates(params?: {
     qp.set("current_revision", String(params.currentRevision));
   }
   const qs = qp.toString();
-  const res = await fetch(`${BASE}/api/auth/station/trade-states${qs ? `?${qs}` : ""}`);
+  const res = await apiFetch(`${BASE}/api/auth/station/trade-states${qs ? `?${qs}` : ""}`);
   const data = await handleResponse<StationTradeStatesResponse>(res);
   return {
     ...data,
@@ -471,7 +858,7 @@ export async function setStationTradeState(params: {
   mode: StationTradeStateMode;
   until_revision?: number;
 }): Promise<{ ok: boolean }> {
-  const res = await fetch(`${BASE}/api/auth/station/trade-states/set`, {
+  const res = await apiFetch(`${BASE}/api/auth/station/trade-states/set`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(params),
@@ -487,7 +874,7 @@ export async function deleteStationTradeStates(params: {
     region_id?: number;
   }>;
 }): Promise<{ ok: boolean; deleted: number }> {
-  const res = await fetch(`${BASE}/api/auth/station/trade-states/delete`, {
+  const res = await apiFetch(`${BASE}/api/auth/station/trade-states/delete`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(params),
@@ -499,7 +886,7 @@ export async function clearStationTradeStates(params?: {
   tab?: string;
   mode?: StationTradeStateMode;
 }): Promise<{ ok: boolean; deleted: number }> {
-  const res = await fetch(`${BASE}/api/auth/station/trade-states/clear`, {
+  const res = await apiFetch(`${BASE}/api/auth/station/trade-states/clear`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(params ?? {}),
@@ -507,12 +894,18 @@ export async function clearStationTradeStates(params?: {
   return handleResponse<{ ok: boolean; deleted: number }>(res);
 }
 
+export async function getCacheStatus(regionIDs?: number[]): Promise<CacheStatusResponse> {
+  const qs = regionIDs && regionIDs.length > 0 ? `?region_ids=${regionIDs.join(",")}` : "";
+  const res = await apiFetch(`${BASE}/api/cache/status${qs}`);
+  return handleResponse<CacheStatusResponse>(res);
+}
+
 export async function rebootStationCache(): Promise<{
   ok: boolean;
   cleared: number;
   rebooted_at?: string;
 }> {
-  const res = await fetch(`${BASE}/api/auth/station/cache/reboot`, {
+  const res = await apiFetch(`${BASE}/api/auth/station/cache/reboot`, {
     method: "POST",
   });
   return handleResponse<{ ok: boolean; cleared: number; rebooted_at?: string }>(res);
@@ -533,7 +926,7 @@ export async function getAuthIndustryProjects(params?: {
   if (params?.status) qp.set("status", params.status);
   if (params?.limit != null && params.limit > 0) qp.set("limit", String(params.limit));
   const qs = qp.toString();
-  const res = await fetch(`${BASE}/api/auth/industry/projects${qs ? `?${qs}` : ""}`);
+  const res = await apiFetch(`${BASE}/api/auth/industry/projects${qs ? `?${qs}` : ""}`);
   const data = await handleResponse<IndustryProjectsResponse>(res);
   return {
     projects: Array.isArray(data.projects) ? data.projects : [],
@@ -557,7 +950,7 @@ export interface IndustryProjectCreateResponse {
 export async function createAuthIndustryProject(
   payload: IndustryProjectCreatePayload
 ): Promise<IndustryProjectCreateResponse> {
-  const res = await fetch(`${BASE}/api/auth/industry/projects`, {
+  const res = await apiFetch(`${BASE}/api/auth/industry/projects`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(payload),
@@ -568,7 +961,7 @@ export async function createAuthIndustryProject(
 export async function getAuthIndustryProjectSnapshot(
   projectID: number
 ): Promise<IndustryProjectSnapshot> {
-  const res = await fetch(`${BASE}/api/auth/industry/projects/${projectID}/snapshot`);
+  const res = await apiFetch(`${BASE}/api/auth/industry/projects/${projectID}/snapshot`);
   const data = await handleResponse<IndustryProjectSnapshot>(res);
   return {
     ...data,
@@ -580,16 +973,49 @@ export async function getAuthIndustryProjectSnapshot(
   };
 }
 
+export interface IndustryBOMLine {
+  type_id: number;
+  type_name: string;
+  quantity: number;
+  volume_m3: number;
+  unit_cost_isk: number;
+  total_cost_isk: number;
+}
+
+export interface IndustryProjectBOM {
+  lines: IndustryBOMLine[];
+  in_house_type_ids: number[];
+  skipped_type_ids: number[];
+  total_quantity: number;
+  total_volume_m3: number;
+  total_cost_isk: number;
+}
+
+/** Raw material shopping list with in-house components left to their own tasks. */
+export async function getAuthIndustryProjectBOM(projectID: number): Promise<IndustryProjectBOM> {
+  const res = await apiFetch(`${BASE}/api/auth/industry/projects/${projectID}/bom`);
+  const data = await handleResponse<{ project_id: number; bom: IndustryProjectBOM }>(res);
+  return data.bom;
+}
+
+export function getAuthIndustryProjectBOMCsvUrl(projectID: number): string {
+  return `${BASE}/api/auth/industry/projects/${projectID}/bom?export=csv`;
+}
+
 export interface IndustryProjectPlanResponse {
   ok: boolean;
   summary: IndustryPlanSummary;
+  /** Present when the plan was applied with echoDiff */
+  diff?: IndustryPlanDiff;
 }
 
 export async function planAuthIndustryProject(
   projectID: number,
-  patch: IndustryPlanPatch
+  patch: IndustryPlanPatch,
+  echoDiff = false
 ): Promise<IndustryProjectPlanResponse> {
-  const res = await fetch(`${BASE}/api/auth/industry/projects/${projectID}/plan`, {
+  const qs = echoDiff ? "?diff=1" : "";
+  const res = await apiFetch(`${BASE}/api/auth/industry/projects/${projectID}/plan${qs}`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(patch),
@@ -601,7 +1027,7 @@ export async function previewAuthIndustryProjectPlan(
   projectID: number,
   patch: IndustryPlanPatch
 ): Promise<IndustryPlanPreview> {
-  const res = await fetch(`${BASE}/api/auth/industry/projects/${projectID}/plan/preview`, {
+  const res = await apiFetch(`${BASE}/api/auth/industry/projects/${projectID}/plan/preview`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(patch),
@@ -612,6 +1038,11 @@ export async function previewAuthIndustryProjectPlan(
     tasks: Array.isArray(data.tasks) ? data.tasks : [],
     jobs: Array.isArray(data.jobs) ? data.jobs : [],
     warnings: Array.isArray(data.warnings) ? data.warnings : [],
+    diff: {
+      ...data.diff,
+      materials: Array.isArray(data.diff?.materials) ? data.diff.materials : [],
+      blueprints: Array.isArray(data.diff?.blueprints) ? data.diff.blueprints : [],
+    },
   };
 }
 
@@ -649,7 +1080,7 @@ export async function rebalanceAuthIndustryProjectMaterials(
   projectID: number,
   payload: IndustryProjectMaterialRebalancePayload
 ): Promise<IndustryProjectMaterialRebalanceResponse> {
-  const res = await fetch(`${BASE}/api/auth/industry/projects/${projectID}/materials/rebalance`, {
+  const res = await apiFetch(`${BASE}/api/auth/industry/projects/${projectID}/materials/rebalance`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(payload ?? {}),
@@ -691,7 +1122,7 @@ export async function syncAuthIndustryProjectBlueprintPool(
   projectID: number,
   payload: IndustryProjectBlueprintSyncPayload
 ): Promise<IndustryProjectBlueprintSyncResponse> {
-  const res = await fetch(`${BASE}/api/auth/industry/projects/${projectID}/blueprints/sync`, {
+  const res = await apiFetch(`${BASE}/api/auth/industry/projects/${projectID}/blueprints/sync`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(payload ?? {}),
@@ -715,7 +1146,7 @@ export interface IndustryJobStatusUpdateResponse {
 export async function updateAuthIndustryJobStatus(
   payload: IndustryJobStatusUpdatePayload
 ): Promise<IndustryJobStatusUpdateResponse> {
-  const res = await fetch(`${BASE}/api/auth/industry/jobs/status`, {
+  const res = await apiFetch(`${BASE}/api/auth/industry/jobs/status`, {
     method: "PATCH",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(payload),
@@ -737,7 +1168,7 @@ export interface IndustryTaskStatusUpdateResponse {
 export async function updateAuthIndustryTaskStatus(
   payload: IndustryTaskStatusUpdatePayload
 ): Promise<IndustryTaskStatusUpdateResponse> {
-  const res = await fetch(`${BASE}/api/auth/industry/tasks/status`, {
+  const res = await apiFetch(`${BASE}/api/auth/industry/tasks/status`, {
     method: "PATCH",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(payload),
@@ -760,7 +1191,7 @@ export interface IndustryTaskBulkStatusUpdateResponse {
 export async function updateAuthIndustryTaskStatusBulk(
   payload: IndustryTaskBulkStatusUpdatePayload
 ): Promise<IndustryTaskBulkStatusUpdateResponse> {
-  const res = await fetch(`${BASE}/api/auth/industry/tasks/status/bulk`, {
+  const res = await apiFetch(`${BASE}/api/auth/industry/tasks/status/bulk`, {
     method: "PATCH",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(payload),
@@ -786,7 +1217,7 @@ export interface IndustryTaskPriorityUpdateResponse {
 export async function updateAuthIndustryTaskPriority(
   payload: IndustryTaskPriorityUpdatePayload
 ): Promise<IndustryTaskPriorityUpdateResponse> {
-  const res = await fetch(`${BASE}/api/auth/industry/tasks/priority`, {
+  const res = await apiFetch(`${BASE}/api/auth/industry/tasks/priority`, {
     method: "PATCH",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(payload),
@@ -808,7 +1239,7 @@ export interface IndustryTaskBulkPriorityUpdateResponse {
 export async function updateAuthIndustryTaskPriorityBulk(
   payload: IndustryTaskBulkPriorityUpdatePayload
 ): Promise<IndustryTaskBulkPriorityUpdateResponse> {
-  const res = await fetch(`${BASE}/api/auth/industry/tasks/priority/bulk`, {
+  const res = await apiFetch(`${BASE}/api/auth/industry/tasks/priority/bulk`, {
     method: "PATCH",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(payload),
@@ -838,7 +1269,7 @@ export interface IndustryJobBulkStatusUpdateResponse {
 export async function updateAuthIndustryJobStatusBulk(
   payload: IndustryJobBulkStatusUpdatePayload
 ): Promise<IndustryJobBulkStatusUpdateResponse> {
-  const res = await fetch(`${BASE}/api/auth/industry/jobs/status/bulk`, {
+  const res = await apiFetch(`${BASE}/api/auth/industry/jobs/status/bulk`, {
     method: "PATCH",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(payload),
@@ -861,7 +1292,7 @@ export async function getAuthIndustryLedger(params?: {
   if (params?.status) qp.set("status", params.status);
   if (params?.limit != null && params.limit > 0) qp.set("limit", String(params.limit));
   const qs = qp.toString();
-  const res = await fetch(`${BASE}/api/auth/industry/ledger${qs ? `?${qs}` : ""}`);
+  const res = await apiFetch(`${BASE}/api/auth/industry/ledger${qs ? `?${qs}` : ""}`);
   const data = await handleResponse<IndustryLedger>(res);
   return {
     ...data,
@@ -869,10 +1300,48 @@ export async function getAuthIndustryLedger(params?: {
   };
 }
 
+export async function getAuthIndustryLocations(params?: {
+  character_id?: number;
+  scope?: "single" | "all";
+}): Promise<IndustryAssetLocations> {
+  const qp = new URLSearchParams();
+  if (params?.character_id != null && params.character_id > 0) qp.set("character_id", String(params.character_id));
+  if (params?.scope) qp.set("scope", params.scope);
+  const qs = qp.toString();
+  const res = await apiFetch(`${BASE}/api/auth/industry/locations${qs ? `?${qs}` : ""}`);
+  const data = await handleResponse<IndustryAssetLocations>(res);
+  return {
+    ...data,
+    locations: Array.isArray(data.locations) ? data.locations : [],
+  };
+}
+
+export async function getAuthNetWorth(params?: {
+  character_id?: number;
+  scope?: "single" | "all";
+  valuation?: "sell" | "buy";
+  refresh?: boolean;
+}): Promise<NetWorth> {
+  const qp = new URLSearchParams();
+  if (params?.character_id != null && params.character_id > 0) qp.set("character_id", String(params.character_id));
+  if (params?.scope) qp.set("scope", params.scope);
+  if (params?.valuation) qp.set("valuation", params.valuation);
+  if (params?.refresh) qp.set("refresh", "1");
+  const qs = qp.toString();
+  const res = await apiFetch(`${BASE}/api/auth/networth${qs ? `?${qs}` : ""}`);
+  const data = await handleResponse<NetWorth>(res);
+  return {
+    ...data,
+    categories: Array.isArray(data.categories) ? data.categories : [],
+    characters: Array.isArray(data.characters) ? data.characters : [],
+    warnings: Array.isArray(data.warnings) ? data.warnings : [],
+  };
+}
+
 export async function stationAIChat(
   payload: StationAIChatRequest,
 ): Promise<StationAIChatResponse> {
-  const res = await fetch(`${BASE}/api/auth/station/ai/chat`, {
+  const res = await apiFetch(`${BASE}/api/auth/station/ai/chat`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(payload),
@@ -880,6 +1349,31 @@ export async function stationAIChat(
   return handleResponse<StationAIChatResponse>(res);
 }
 
+export async function listStationAIConversations(): Promise<StationAIConversation[]> {
+  const res = await apiFetch(`${BASE}/api/auth/station/ai/conversations`);
+  const data = await handleResponse<{ conversations: StationAIConversation[] }>(res);
+  return Array.isArray(data.conversations) ? data.conversations : [];
+}
+
+export async function createStationAIConversation(title = ""): Promise<StationAIConversation> {
+  const res = await apiFetch(`${BASE}/api/auth/station/ai/conversations`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ title }),
+  });
+  return handleResponse<StationAIConversation>(res);
+}
+
+export async function getStationAIConversation(id: number): Promise<StationAIConversationDetail> {
+  const res = await apiFetch(`${BASE}/api/auth/station/ai/conversations/${id}`);
+  return handleResponse<StationAIConversationDetail>(res);
+}
+
+export async function getStationAIUsage(days = 30): Promise<StationAIUsageSummary> {
+  const res = await apiFetch(`${BASE}/api/auth/station/ai/usage?days=${days}`);
+  return handleResponse<StationAIUsageSummary>(res);
+}
+
 export async function stationAIChatStream(
   payload: StationAIChatRequest,
   handlers: {
@@ -890,7 +1384,7 @@ export async function stationAIChatStream(
   },
   signal?: AbortSignal,
 ): Promise<StationAIChatResponse> {
-  const res = await fetch(`${BASE}/api/auth/station/ai/chat/stream`, {
+  const res = await apiFetch(`${BASE}/api/auth/station/ai/chat/stream`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(payload),
@@ -898,14 +1392,7 @@ export async function stationAIChatStream(
   });
 
   if (!res.ok) {
-    let errMsg = "Station AI stream failed";
-    try {
-      const err = await res.json();
-      errMsg = err.error || err.message || errMsg;
-    } catch {
-      // ignore non-json error body
-    }
-    throw new Error(errMsg);
+    throw await responseError(res, "Station AI stream failed");
   }
   if (!res.body) {
     throw new Error("Response body is null");
@@ -917,7 +1404,8 @@ export async function stationAIChatStream(
   let finalResult: StationAIChatResponse | null = null;
 
   const handleLine = (line: string) => {
-    if (!line.trim()) return;
+    // Blank lines and ": keepalive" heartbeats carry no message.
+    if (!line.trim() || line.startsWith(":")) return;
     const msg = JSON.parse(line) as StationAIStreamMessage;
     if (msg.type === "progress") {
       handlers.onProgress?.(msg);
@@ -976,29 +1464,127 @@ export async function stationAIChatStream(
 // --- Scan History ---
 
 export async function getScanHistory(limit: number = 50): Promise<ScanRecord[]> {
-  const res = await fetch(`${BASE}/api/scan/history?limit=${limit}`);
+  const res = await apiFetch(`${BASE}/api/scan/history?limit=${limit}`);
   return handleResponse<ScanRecord[]>(res);
 }
 
 export async function getScanHistoryById(id: number): Promise<ScanRecord> {
-  const res = await fetch(`${BASE}/api/scan/history/${id}`);
+  const res = await apiFetch(`${BASE}/api/scan/history/${id}`);
   return handleResponse<ScanRecord>(res);
 }
 
-export async function getScanHistoryResults(id: number): Promise<{ scan: ScanRecord; results: unknown[] }> {
-  const res = await fetch(`${BASE}/api/scan/history/${id}/results`);
-  return handleResponse<{ scan: ScanRecord; results: unknown[] }>(res);
+export interface ScanHistoryResultsQuery {
+  sort?: string;
+  order?: "asc" | "desc";
+  minMargin?: number;
+  action?: "execute";
+  limit?: number;
+  offset?: number;
+}
+
+export async function getScanHistoryResults(
+  id: number,
+  q?: ScanHistoryResultsQuery,
+): Promise<{ scan: ScanRecord; results: unknown[]; total: number; limit: number; offset: number }> {
+  const qp = new URLSearchParams();
+  if (q?.sort) qp.set("sort", q.sort);
+  if (q?.order) qp.set("order", q.order);
+  if (q?.minMargin != null) qp.set("min_margin", String(q.minMargin));
+  if (q?.action) qp.set("action", q.action);
+  if (q?.limit) qp.set("limit", String(q.limit));
+  if (q?.offset) qp.set("offset", String(q.offset));
+  const qs = qp.toString();
+  const res = await apiFetch(`${BASE}/api/scan/history/${id}/results${qs ? `?${qs}` : ""}`);
+  return handleResponse<{ scan: ScanRecord; results: unknown[]; total: number; limit: number; offset: number }>(res);
+}
+
+export interface ScanDiffRow {
+  key: string;
+  type_id?: number;
+  name: string;
+  location: string;
+  margin_percent: number;
+  daily_profit: number;
+}
+
+export interface ScanDiffChange {
+  key: string;
+  type_id?: number;
+  name: string;
+  location: string;
+  before_margin_percent: number;
+  after_margin_percent: number;
+  before_daily_profit: number;
+  after_daily_profit: number;
+  daily_profit_delta: number;
+}
+
+export interface ScanDiffResponse {
+  from: ScanRecord;
+  to: ScanRecord;
+  tab: string;
+  diff: { added: ScanDiffRow[]; removed: ScanDiffRow[]; changed: ScanDiffChange[] };
+}
+
+export async function getScanDiff(fromId: number, toId: number): Promise<ScanDiffResponse> {
+  const res = await apiFetch(`${BASE}/api/scan/diff?from=${fromId}&to=${toId}`);
+  return handleResponse<ScanDiffResponse>(res);
 }
 
 export async function deleteScanHistory(id: number): Promise<void> {
-  const res = await fetch(`${BASE}/api/scan/history/${id}`, { method: "DELETE" });
+  const res = await apiFetch(`${BASE}/api/scan/history/${id}`, { method: "DELETE" });
   if (!res.ok) {
     throw new Error("Delete failed");
   }
 }
 
+export interface ScanBundle {
+  scan: ScanRecord;
+  results: unknown[];
+}
+
+export async function exportScanHistory(id: number): Promise<ScanBundle> {
+  const res = await apiFetch(`${BASE}/api/scan/history/${id}/export?format=json`);
+  return handleResponse<ScanBundle>(res);
+}
+
+export async function importScanHistory(bundle: ScanBundle): Promise<ScanRecord> {
+  const res = await apiFetch(`${BASE}/api/scan/import`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(bundle),
+  });
+  return handleResponse<ScanRecord>(res);
+}
+
+export interface ScanShareLink {
+  token: string;
+  url: string;
+  scan_id: number;
+  expires_at: string;
+}
+
+export async function createScanShareLink(id: number, ttlHours?: number): Promise<ScanShareLink> {
+  const res = await apiFetch(`${BASE}/api/scan/history/${id}/share`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(ttlHours ? { ttl_hours: ttlHours } : {}),
+  });
+  return handleResponse<ScanShareLink>(res);
+}
+
+export async function revokeScanShareLinks(id: number): Promise<{ status: string; revoked: number }> {
+  const res = await apiFetch(`${BASE}/api/scan/history/${id}/share`, { method: "DELETE" });
+  return handleResponse<{ status: string; revoked: number }>(res);
+}
+
+export async function getSharedScan(token: string): Promise<ScanBundle & { expires_at: string }> {
+  const res = await fetch(`${BASE}/api/shared/${encodeURIComponent(token)}`);
+  return handleResponse<ScanBundle & { expires_at: string }>(res);
+}
+
 export async function clearScanHistory(olderThanDays: number = 7): Promise<{ deleted: number }> {
-  const res = await fetch(`${BASE}/api/scan/history/clear`, {
+  const res = await apiFetch(`${BASE}/api/scan/history/clear`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify({ older_than_days: olderThanDays }),
@@ -1006,10 +1592,50 @@ export async function clearScanHistory(olderThanDays: number = 7): Promise<{ del
   return handleResponse<{ deleted: number }>(res);
 }
 
+/** Database snapshot download; the server only serves it to localhost. */
+export function getBackupUrl(): string {
+  return `${BASE}/api/admin/backup`;
+}
+
+export interface MaintenanceResult {
+  scans_deleted: number;
+  orphaned_rows_deleted: number;
+  bytes_reclaimed: number;
+  db_size_bytes_before: number;
+  db_size_bytes: number;
+}
+
+/** Prunes scan history older than the retention and compacts the database (localhost only). */
+export async function runMaintenance(olderThanDays: number): Promise<MaintenanceResult> {
+  const res = await apiFetch(`${BASE}/api/admin/maintenance`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ older_than_days: olderThanDays }),
+  });
+  return handleResponse<MaintenanceResult>(res);
+}
+
+export interface SDEReloadResult {
+  ok: boolean;
+  systems: number;
+  types: number;
+  regions: number;
+  duration_ms: number;
+}
+
+/** Re-reads the SDE from disk without restarting the server (localhost only). */
+export async function reloadSDE(): Promise<SDEReloadResult> {
+  const res = await apiFetch(`${BASE}/api/admin/sde/reload`, { method: "POST" });
+  return handleResponse<SDEReloadResult>(res);
+}
+
 // --- Auth ---
 
-export function getLoginUrl(): string {
-  return `${BASE}/api/auth/login`;
+/** "basic" requests only location, character orders and wallet scopes. */
+export type LoginMode = "full" | "basic";
+
+export function getLoginUrl(mode?: LoginMode): string {
+  return mode === "basic" ? `${BASE}/api/auth/login?mode=basic` : `${BASE}/api/auth/login`;
 }
 
 export type CharacterScope = number | "all";
@@ -1024,19 +1650,24 @@ function appendCharacterScope(params: URLSearchParams, characterId?: CharacterSc
 }
 
 export async function getAuthStatus(): Promise<AuthStatus> {
-  const res = await fetch(`${BASE}/api/auth/status`);
+  const res = await apiFetch(`${BASE}/api/auth/status`);
   return handleResponse<AuthStatus>(res);
 }
 
-export async function logout(): Promise<void> {
-  const res = await fetch(`${BASE}/api/auth/logout`, { method: "POST" });
-  if (!res.ok) {
-    throw new Error("Logout failed");
-  }
+/** Logs out the active character; another logged-in character becomes active. */
+export async function logout(): Promise<AuthStatus> {
+  const res = await apiFetch(`${BASE}/api/auth/logout`, { method: "POST" });
+  return handleResponse<AuthStatus>(res);
+}
+
+/** Logs out every character for this browser/user. */
+export async function logoutAll(): Promise<AuthStatus> {
+  const res = await apiFetch(`${BASE}/api/auth/logout/all`, { method: "POST" });
+  return handleResponse<AuthStatus>(res);
 }
 
 export async function selectAuthCharacter(characterId: number): Promise<AuthStatus> {
-  const res = await fetch(`${BASE}/api/auth/character/select`, {
+  const res = await apiFetch(`${BASE}/api/auth/character/select`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify({ character_id: characterId }),
@@ -1045,7 +1676,16 @@ export async function selectAuthCharacter(characterId: number): Promise<AuthStat
 }
 
 export async function deleteAuthCharacter(characterId: number): Promise<AuthStatus> {
-  const res = await fetch(`${BASE}/api/auth/characters/${characterId}`, { method: "DELETE" });
+  const res = await apiFetch(`${BASE}/api/auth/characters/${characterId}`, { method: "DELETE" });
+  return handleResponse<AuthStatus>(res);
+}
+
+export async function setAuthCharacterLabel(characterId: number, label: string): Promise<AuthStatus> {
+  const res = await apiFetch(`${BASE}/api/auth/characters/${characterId}/label`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ label }),
+  });
   return handleResponse<AuthStatus>(res);
 }
 
@@ -1053,7 +1693,7 @@ export async function getCharacterInfo(characterId?: CharacterScope): Promise<Ch
   const params = new URLSearchParams();
   appendCharacterScope(params, characterId);
   const query = params.toString();
-  const res = await fetch(`${BASE}/api/auth/character${query ? `?${query}` : ""}`);
+  const res = await apiFetch(`${BASE}/api/auth/character${query ? `?${query}` : ""}`);
   return handleResponse<CharacterInfo>(res);
 }
 
@@ -1068,7 +1708,7 @@ export async function getCharacterLocation(characterId?: number): Promise<Charac
   const params = new URLSearchParams();
   appendCharacterScope(params, characterId);
   const query = params.toString();
-  const res = await fetch(`${BASE}/api/auth/location${query ? `?${query}` : ""}`);
+  const res = await apiFetch(`${BASE}/api/auth/location${query ? `?${query}` : ""}`);
   return handleResponse<CharacterLocation>(res);
 }
 
@@ -1076,7 +1716,7 @@ export async function getUndercuts(characterId?: CharacterScope): Promise<Underc
   const params = new URLSearchParams();
   appendCharacterScope(params, characterId);
   const query = params.toString();
-  const res = await fetch(`${BASE}/api/auth/undercuts${query ? `?${query}` : ""}`);
+  const res = await apiFetch(`${BASE}/api/auth/undercuts${query ? `?${query}` : ""}`);
   return handleResponse<UndercutStatus[]>(res);
 }
 
@@ -1094,7 +1734,7 @@ export async function getOrderDesk(params?: OrderDeskParams): Promise<OrderDeskR
   if (params?.targetEtaDays != null) qp.set("target_eta_days", String(params.targetEtaDays));
   appendCharacterScope(qp, params?.characterId);
   const qs = qp.toString();
-  const res = await fetch(`${BASE}/api/auth/orders/desk${qs ? `?${qs}` : ""}`);
+  const res = await apiFetch(`${BASE}/api/auth/orders/desk${qs ? `?${qs}` : ""}`);
   return handleResponse<OrderDeskResponse>(res);
 }
 
@@ -1138,7 +1778,7 @@ export async function getStationCommand(params: StationCommandParams): Promise<S
   const qp = new URLSearchParams();
   appendCharacterScope(qp, characterId);
   const qs = qp.toString();
-  const res = await fetch(`${BASE}/api/auth/station/command${qs ? `?${qs}` : ""}`, {
+  const res = await apiFetch(`${BASE}/api/auth/station/command${qs ? `?${qs}` : ""}`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(body),
@@ -1160,10 +1800,28 @@ export async function getPortfolioPnL(days: number = 30, params?: PortfolioPnLPa
   if (params?.brokerFee != null) qp.set("broker_fee", String(params.brokerFee));
   if (params?.ledgerLimit != null) qp.set("ledger_limit", String(params.ledgerLimit));
   appendCharacterScope(qp, params?.characterId);
-  const res = await fetch(`${BASE}/api/auth/portfolio?${qp.toString()}`);
+  const res = await apiFetch(`${BASE}/api/auth/portfolio?${qp.toString()}`);
   return handleResponse<PortfolioPnL>(res);
 }
 
+export async function getOpenPositions(lookbackDays: number = 180, characterId?: CharacterScope): Promise<OpenPositionsResponse> {
+  const qp = new URLSearchParams();
+  qp.set("lookback_days", String(lookbackDays));
+  appendCharacterScope(qp, characterId);
+  const res = await apiFetch(`${BASE}/api/auth/positions?${qp.toString()}`);
+  return handleResponse<OpenPositionsResponse>(res);
+}
+
+export async function getRealizedPnL(days: number = 90, params?: PortfolioPnLParams): Promise<RealizedPnLReport> {
+  const qp = new URLSearchParams();
+  qp.set("days", String(days));
+  if (params?.salesTax != null) qp.set("sales_tax", String(params.salesTax));
+  if (params?.brokerFee != null) qp.set("broker_fee", String(params.brokerFee));
+  appendCharacterScope(qp, params?.characterId);
+  const res = await apiFetch(`${BASE}/api/auth/pnl?${qp.toString()}`);
+  return handleResponse<RealizedPnLReport>(res);
+}
+
 export type OptimizerResult =
   | { ok: true; data: PortfolioOptimization }
   | { ok: false; diagnostic: OptimizerDiagnostic | null };
@@ -1172,7 +1830,7 @@ export async function getPortfolioOptimization(days: number = 90, characterId?:
   const qp = new URLSearchParams();
   qp.set("days", String(days));
   appendCharacterScope(qp, characterId);
-  const res = await fetch(`${BASE}/api/auth/portfolio/optimize?${qp.toString()}`);
+  const res = await apiFetch(`${BASE}/api/auth/portfolio/optimize?${qp.toString()}`);
   if (res.ok) {
     const data: PortfolioOptimization = await res.json();
     return { ok: true, data };
@@ -1195,7 +1853,7 @@ export async function analyzeIndustry(
   onProgress: (msg: string) => void,
   signal?: AbortSignal
 ): Promise<IndustryAnalysis> {
-  const res = await fetch(`${BASE}/api/industry/analyze`, {
+  const res = await apiFetch(`${BASE}/api/industry/analyze`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(params),
@@ -1203,14 +1861,7 @@ export async function analyzeIndustry(
   });
 
   if (!res.ok) {
-    let errMsg = "Analysis failed";
-    try {
-      const err = await res.json();
-      errMsg = err.error || err.message || errMsg;
-    } catch {
-      // Response body is not JSON
-    }
-    throw new Error(errMsg);
+    throw await responseError(res, "Analysis failed");
   }
 
   if (!res.body) {
@@ -1257,49 +1908,72 @@ export async function analyzeIndustry(
 }
 
 export async function searchBuildableItems(query: string, limit = 20, signal?: AbortSignal): Promise<BuildableItem[]> {
-  const res = await fetch(`${BASE}/api/industry/search?q=${encodeURIComponent(query)}&limit=${limit}`, { signal });
+  const res = await apiFetch(`${BASE}/api/industry/search?q=${encodeURIComponent(query)}&limit=${limit}`, { signal });
   return handleResponse<BuildableItem[]>(res);
 }
 
 export async function getIndustrySystems(): Promise<IndustrySystem[]> {
-  const res = await fetch(`${BASE}/api/industry/systems`);
+  const res = await apiFetch(`${BASE}/api/industry/systems`);
   return handleResponse<IndustrySystem[]>(res);
 }
 
 // --- Demand / War Tracker API ---
 
 export async function getDemandRegions(): Promise<DemandRegionsResponse> {
-  const res = await fetch(`${BASE}/api/demand/regions`);
+  const res = await apiFetch(`${BASE}/api/demand/regions`);
   return handleResponse<DemandRegionsResponse>(res);
 }
 
 export async function getHotZones(limit = 20): Promise<HotZonesResponse> {
-  const res = await fetch(`${BASE}/api/demand/hotzones?limit=${limit}`);
+  const res = await apiFetch(`${BASE}/api/demand/hotzones?limit=${limit}`);
   return handleResponse<HotZonesResponse>(res);
 }
 
 export async function getDemandRegion(regionId: number): Promise<DemandRegionResponse> {
-  const res = await fetch(`${BASE}/api/demand/region/${regionId}`);
+  const res = await apiFetch(`${BASE}/api/demand/region/${regionId}`);
   return handleResponse<DemandRegionResponse>(res);
 }
 
 export async function getRegionOpportunities(regionId: number): Promise<RegionOpportunities> {
-  const res = await fetch(`${BASE}/api/demand/opportunities/${regionId}`);
+  const res = await apiFetch(`${BASE}/api/demand/opportunities/${regionId}`);
   return handleResponse<RegionOpportunities>(res);
 }
 
 export async function getRegionFittings(regionId: number): Promise<{ region_id: number; items: unknown[]; count: number; from_cache: boolean }> {
-  const res = await fetch(`${BASE}/api/demand/fittings/${regionId}`);
+  const res = await apiFetch(`${BASE}/api/demand/fittings/${regionId}`);
   return handleResponse<{ region_id: number; items: unknown[]; count: number; from_cache: boolean }>(res);
 }
 
-export async function refreshDemandData(onProgress?: (msg: string) => void): Promise<void> {
-  const res = await fetch(`${BASE}/api/demand/refresh`, { method: "POST" });
+export async function analyzeCustomFitting(regionId: number, eft: string): Promise<CustomFittingDemandResponse> {
+  const res = await apiFetch(`${BASE}/api/demand/fittings/custom?region_id=${regionId}`, {
+    method: "POST",
+    headers: { "Content-Type": "text/plain" },
+    body: eft,
+  });
+  return handleResponse<CustomFittingDemandResponse>(res);
+}
+
+export interface DemandRefreshResult {
+  regions: number;
+  invalidated: number;
+  next_refresh_at: string;
+  region_id?: number;
+}
+
+export async function refreshDemandData(
+  onProgress?: (msg: string) => void,
+  regionId?: number,
+): Promise<DemandRefreshResult | null> {
+  const qs = regionId ? `?region_id=${regionId}` : "";
+  const res = await apiFetch(`${BASE}/api/demand/refresh${qs}`, { method: "POST" });
   if (!res.ok) {
     let errMsg = "Refresh failed";
     try {
       const err = await res.json();
       errMsg = err.error || err.message || errMsg;
+      if (err.next_refresh_at) {
+        errMsg += ` (next refresh at ${new Date(err.next_refresh_at).toLocaleTimeString()})`;
+      }
     } catch { /* not JSON */ }
     throw new Error(errMsg);
   }
@@ -1310,6 +1984,7 @@ export async function refreshDemandData(onProgress?: (msg: string) => void): Pro
   const reader = res.body.getReader();
   const decoder = new TextDecoder();
   let buffer = "";
+  let result: DemandRefreshResult | null = null;
 
   while (true) {
     const { done, value } = await reader.read();
@@ -1321,9 +1996,11 @@ export async function refreshDemandData(onProgress?: (msg: string) => void): Pro
 
     for (const line of lines) {
       if (!line.trim()) continue;
-      const msg = JSON.parse(line) as { type: string; message?: string; status?: string };
+      const msg = JSON.parse(line) as { type: string; message?: string; status?: string } & Partial<DemandRefreshResult>;
       if (msg.type === "progress" && msg.message) {
         onProgress?.(msg.message);
+      } else if (msg.type === "result") {
+        result = msg as DemandRefreshResult;
       } else if (msg.type === "error") {
         throw new Error(msg.message || "Refresh failed");
       }
@@ -1331,9 +2008,11 @@ export async function refreshDemandData(onProgress?: (msg: string) => void): Pro
   }
 
   if (buffer.trim()) {
-    const msg = JSON.parse(buffer) as { type: string; message?: string };
+    const msg = JSON.parse(buffer) as { type: string; message?: string } & Partial<DemandRefreshResult>;
     if (msg.type === "error") throw new Error(msg.message || "Refresh failed");
+    if (msg.type === "result") result = msg as DemandRefreshResult;
   }
+  return result;
 }
 
 // --- PLEX+ ---
@@ -1354,54 +2033,107 @@ export async function getPLEXDashboard(p?: PLEXDashboardParams, signal?: AbortSi
   if (p?.nesOmega != null && p.nesOmega > 0) params.set("nes_omega", p.nesOmega.toString());
   if (p?.omegaUSD != null && p.omegaUSD > 0) params.set("omega_usd", p.omegaUSD.toString());
   const qs = params.toString();
-  const res = await fetch(`${BASE}/api/plex/dashboard${qs ? "?" + qs : ""}`, { signal });
+  const res = await apiFetch(`${BASE}/api/plex/dashboard${qs ? "?" + qs : ""}`, { signal });
   return handleResponse<PLEXDashboard>(res);
 }
 
+export interface PLEXNESRatio {
+  name: string;
+  plex_cost: number;
+  isk_per_plex: number;
+  roi: number;
+}
+
+export interface PLEXHistoryPoint {
+  recorded_at: string;
+  plex_buy: number;
+  plex_sell: number;
+  spread_pct: number;
+  volume_24h: number;
+  nes_ratios: PLEXNESRatio[];
+}
+
+/** Hourly points recorded whenever the PLEX dashboard is rebuilt. */
+export async function getPLEXHistory(days = 30, signal?: AbortSignal): Promise<PLEXHistoryPoint[]> {
+  const res = await apiFetch(`${BASE}/api/plex/history?days=${days}`, { signal });
+  const data = await handleResponse<{ days: number; points: PLEXHistoryPoint[] }>(res);
+  return data.points ?? [];
+}
+
+export interface StationTrendPoint {
+  region_id: number;
+  station_id: number;
+  type_id: number;
+  date: string; // YYYY-MM-DD (UTC)
+  cts: number;
+  daily_profit: number;
+  margin: number;
+}
+
+/** Daily snapshots of one item from station scans; needs station_trends_enabled. */
+export async function getStationTrends(
+  typeID: number,
+  days = 14,
+  stationID = 0,
+  signal?: AbortSignal,
+): Promise<{ enabled: boolean; points: StationTrendPoint[] }> {
+  const params = new URLSearchParams({ type_id: String(typeID), days: String(days) });
+  if (stationID > 0) params.set("station_id", String(stationID));
+  const res = await apiFetch(`${BASE}/api/station/trends?${params}`, { signal });
+  const data = await handleResponse<{ enabled: boolean; points: StationTrendPoint[] }>(res);
+  return { enabled: data.enabled, points: data.points ?? [] };
+}
+
 // --- Corporation ---
 
 export async function getCharacterRoles(signal?: AbortSignal, characterId?: number): Promise<CharacterRoles> {
   const qp = new URLSearchParams();
   appendCharacterScope(qp, characterId);
   const qs = qp.toString();
-  const res = await fetch(`${BASE}/api/auth/roles${qs ? `?${qs}` : ""}`, { signal });
+  const res = await apiFetch(`${BASE}/api/auth/roles${qs ? `?${qs}` : ""}`, { signal });
   return handleResponse<CharacterRoles>(res);
 }
 
-export async function getCorpDashboard(mode: "demo" | "live" = "demo", signal?: AbortSignal): Promise<CorpDashboard> {
-  const res = await fetch(`${BASE}/api/corp/dashboard?mode=${mode}`, { signal });
+/** "auto" uses live ESI data for directors and demo data otherwise (see CorpDashboard.is_demo). */
+export async function getCorpDashboard(mode: "demo" | "live" | "auto" = "demo", signal?: AbortSignal): Promise<CorpDashboard> {
+  const res = await apiFetch(`${BASE}/api/corp/dashboard?mode=${mode}`, { signal });
   return handleResponse<CorpDashboard>(res);
 }
 
-export async function getCorpJournal(mode: "demo" | "live" = "demo", division = 1, days = 90, signal?: AbortSignal): Promise<CorpJournalEntry[]> {
-  const res = await fetch(`${BASE}/api/corp/journal?mode=${mode}&division=${division}&days=${days}`, { signal });
-  return handleResponse<CorpJournalEntry[]>(res);
+export async function getCorpWalletSummary(mode: "demo" | "live" | "auto" = "demo", days = 30, signal?: AbortSignal): Promise<CorpWalletSummary> {
+  const res = await apiFetch(`${BASE}/api/corp/wallets?mode=${mode}&days=${days}`, { signal });
+  return handleResponse<CorpWalletSummary>(res);
 }
 
-export async function getCorpMembers(mode: "demo" | "live" = "demo", signal?: AbortSignal): Promise<CorpMember[]> {
-  const res = await fetch(`${BASE}/api/corp/members?mode=${mode}`, { signal });
-  return handleResponse<CorpMember[]>(res);
+export async function getCorpJournal(mode: "demo" | "live" = "demo", division = 1, days = 90, signal?: AbortSignal): Promise<CorpList<CorpJournalEntry>> {
+  const res = await apiFetch(`${BASE}/api/corp/journal?mode=${mode}&division=${division}&days=${days}`, { signal });
+  return handleResponse<CorpList<CorpJournalEntry>>(res);
 }
 
-export async function getCorpOrders(mode: "demo" | "live" = "demo", signal?: AbortSignal): Promise<CorpMarketOrderDetail[]> {
-  const res = await fetch(`${BASE}/api/corp/orders?mode=${mode}`, { signal });
-  return handleResponse<CorpMarketOrderDetail[]>(res);
+export async function getCorpMembers(mode: "demo" | "live" = "demo", signal?: AbortSignal): Promise<CorpList<CorpMember>> {
+  const res = await apiFetch(`${BASE}/api/corp/members?mode=${mode}`, { signal });
+  return handleResponse<CorpList<CorpMember>>(res);
 }
 
-export async function getCorpIndustryJobs(mode: "demo" | "live" = "demo", signal?: AbortSignal): Promise<CorpIndustryJob[]> {
-  const res = await fetch(`${BASE}/api/corp/industry?mode=${mode}`, { signal });
-  return handleResponse<CorpIndustryJob[]>(res);
+export async function getCorpOrders(mode: "demo" | "live" = "demo", signal?: AbortSignal): Promise<CorpList<CorpMarketOrderDetail>> {
+  const res = await apiFetch(`${BASE}/api/corp/orders?mode=${mode}`, { signal });
+  return handleResponse<CorpList<CorpMarketOrderDetail>>(res);
 }
 
-export async function getCorpMiningLedger(mode: "demo" | "live" = "demo", signal?: AbortSignal): Promise<CorpMiningEntry[]> {
-  const res = await fetch(`${BASE}/api/corp/mining?mode=${mode}`, { signal });
-  return handleResponse<CorpMiningEntry[]>(res);
+export async function getCorpIndustryJobs(mode: "demo" | "live" = "demo", signal?: AbortSignal): Promise<CorpList<CorpIndustryJob>> {
+  const res = await apiFetch(`${BASE}/api/corp/industry?mode=${mode}`, { signal });
+  return handleResponse<CorpList<CorpIndustryJob>>(res);
+}
+
+export async function getCorpMiningLedger(mode: "demo" | "live" = "demo", signal?: AbortSignal): Promise<CorpList<CorpMiningEntry>> {
+  const res = await apiFetch(`${BASE}/api/corp/mining?mode=${mode}`, { signal });
+  return handleResponse<CorpList<CorpMiningEntry>>(res);
 }
 
 // --- UI Operations (in-game actions) ---
 
 export async function openMarketInGame(typeID: number): Promise<void> {
-  const res = await fetch(`${BASE}/api/ui/open-market`, {
+  const res = await apiFetch(`${BASE}/api/ui/open-market`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify({ type_id: typeID }),
@@ -1412,8 +2144,24 @@ export async function openMarketInGame(typeID: number): Promise<void> {
   }
 }
 
+/** Opens up to 10 market windows in a row; per-type failures are reported, not thrown. */
+export async function openMarketBatchInGame(
+  typeIDs: number[],
+): Promise<{ success: boolean; results: { type_id: number; ok: boolean; error?: string }[] }> {
+  const res = await apiFetch(`${BASE}/api/ui/open-market/batch`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ type_ids: typeIDs }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({ error: "Unknown error" }));
+    throw new Error(err.error || "Failed to open market windows");
+  }
+  return res.json();
+}
+
 export async function setWaypointInGame(solarSystemID: number, clearOther = true, addToBeginning = false): Promise<void> {
-  const res = await fetch(`${BASE}/api/ui/set-waypoint`, {
+  const res = await apiFetch(`${BASE}/api/ui/set-waypoint`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify({
@@ -1428,8 +2176,31 @@ export async function setWaypointInGame(solarSystemID: number, clearOther = true
   }
 }
 
+export interface RouteWaypointResult {
+  location_id: number;
+  ok: boolean;
+  error?: string;
+}
+
+/** Plots an ordered route in the EVE client; failed hops are reported, not thrown. */
+export async function setRouteInGame(
+  locationIDs: number[],
+  clearExisting = true,
+): Promise<{ success: boolean; hops: RouteWaypointResult[] }> {
+  const res = await apiFetch(`${BASE}/api/ui/set-route`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ location_ids: locationIDs, clear_existing: clearExisting }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({ error: "Unknown error" }));
+    throw new Error(err.error || "Failed to set route");
+  }
+  return res.json();
+}
+
 export async function openContractInGame(contractID: number): Promise<void> {
-  const res = await fetch(`${BASE}/api/ui/open-contract`, {
+  const res = await apiFetch(`${BASE}/api/ui/open-contract`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify({ contract_id: contractID }),
@@ -1441,9 +2212,43 @@ export async function openContractInGame(contractID: number): Promise<void> {
 }
 
 export async function getContractDetails(contractID: number): Promise<ContractDetails> {
-  const res = await fetch(`${BASE}/api/contracts/${contractID}/items`);
+  const res = await apiFetch(`${BASE}/api/contracts/${contractID}/items`);
   if (!res.ok) {
     throw new Error("Failed to fetch contract details");
   }
   return res.json();
 }
+
+export interface RegionTypeActivity {
+  type_id: number;
+  type_name: string;
+  order_count: number;
+  buy_orders: number;
+  sell_orders: number;
+  buy_isk: number;
+  sell_isk: number;
+}
+
+export interface RegionMarketSummary {
+  region_id: number;
+  region_name: string;
+  total_orders: number;
+  buy_orders: number;
+  sell_orders: number;
+  buy_isk: number;
+  sell_isk: number;
+  type_count: number;
+  top_types: RegionTypeActivity[];
+  generated_at: string;
+  cached: boolean;
+}
+
+/** Order-book pulse of a region (cached ~2 min server-side); top is 1..50. */
+export async function getRegionMarketSummary(
+  regionID: number,
+  top = 10,
+  signal?: AbortSignal,
+): Promise<RegionMarketSummary> {
+  const res = await apiFetch(`${BASE}/api/market/region/${regionID}/summary?top=${top}`, { signal });
+  return handleResponse<RegionMarketSummary>(res);
+}
@@ -141,6 +141,8 @@ export const en = {
     colDailyProfit: "Daily Profit",
     colProfitPerUnit: "Profit/Unit",
     colProfitPerJump: "ISK/Jump",
+    colIskPerJumpReal: "Real ISK/Jump",
+    colIskPerJumpRealHint: "Depth-aware real profit divided by route jumps. Same-system trades count as one jump.",
     variantChip: "⎇{index}/{total}",
     variantChipHint: "Alternative trade option for the same item",
     colJumps: "Jumps",
@@ -1607,7 +1609,8 @@ export const en = {
     aiSend: "Send",
     aiAssistantName: "Assistant name",
     aiProvider: "Provider",
-    aiApiKey: "OpenRouter API key",
+    aiProviderBaseUrl: "Local endpoint URL",
+    aiApiKey: "API key",
     aiModel: "Model",
     aiCustomModel: "Custom model",
     aiUseCustomModel: "Use custom model",
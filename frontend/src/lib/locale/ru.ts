@@ -141,6 +141,8 @@ export const ru = {
     colDailyProfit: "Дн. прибыль",
     colProfitPerUnit: "Прибыль/шт",
     colProfitPerJump: "ISK/прыжок",
+    colIskPerJumpReal: "Реал. ISK/прыжок",
+    colIskPerJumpRealHint: "Реальный профит с учётом глубины стакана, делённый на число прыжков. Сделка в одной системе считается за один прыжок.",
     variantChip: "⎇{index}/{total}",
     variantChipHint: "Альтернативный вариант сделки для того же предмета",
     colJumps: "Прыжки",
@@ -1607,7 +1609,8 @@ export const ru = {
     aiSend: "Отправить",
     aiAssistantName: "Имя помощника",
     aiProvider: "Провайдер",
-    aiApiKey: "OpenRouter API ключ",
+    aiProviderBaseUrl: "Адрес локального сервера",
+    aiApiKey: "API ключ",
     aiModel: "Модель",
     aiCustomModel: "Кастомная модель",
     aiUseCustomModel: "Использовать кастомную модель",
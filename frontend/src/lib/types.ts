@@ -1,8 +1,30 @@
+/** Per-unit fees applied to a trade (percent rates + ISK amounts). */
+export interface FeeBreakdown {
+  buy_broker_percent: number;
+  buy_tax_percent: number;
+  sell_broker_percent: number;
+  sell_tax_percent: number;
+  buy_broker: number;
+  buy_tax: number;
+  sell_broker: number;
+  sell_tax: number;
+  total_fees: number;
+  net_profit_per_unit: number;
+}
+
 export interface FlipResult {
   TypeID: number;
   TypeName: string;
   Volume: number;
   IskPerM3?: number;
+  /** Server-side depth-aware profit per m3 hauled. */
+  ISKPerM3?: number;
+  /** Server-side depth-aware profit per route jump (same-system = 1 jump). */
+  ISKPerJump?: number;
+  /** Units that fit in the scan's cargo capacity. */
+  CargoLimitedQty?: number;
+  /** True when the cargo hold, not book depth or budget, bound UnitsToBuy. */
+  CargoLimited?: boolean;
   BuyPrice: number;
   BestAskPrice?: number;
   BestAskQty?: number;
@@ -42,6 +64,8 @@ export interface FlipResult {
   BuyCompetitors: number;
   SellCompetitors: number;
   DailyProfit: number;
+  /** Per-unit profit of selling straight into the best buy order, net of sales tax only. */
+  InstantSellProfit?: number;
   /** Expected fill prices from execution plan (order book depth) */
   ExpectedBuyPrice?: number;
   ExpectedSellPrice?: number;
@@ -51,6 +75,8 @@ export interface FlipResult {
   CanFill?: boolean;
   SlippageBuyPct?: number;
   SlippageSellPct?: number;
+  /** Per-unit fee components behind ProfitPerUnit */
+  fee_breakdown?: FeeBreakdown;
   // Regional day-trader enrichments (for EveGuru-style regional view in ScanResultsTable)
   DaySecurity?: number;
   DaySourceUnits?: number;
@@ -147,6 +173,7 @@ export interface RegionalDayTradeHub {
 
 export interface ContractResult {
   ContractID: number;
+  ContractType?: "item_exchange" | "auction";
   Title: string;
   Price: number;
   MarketValue: number;
@@ -227,6 +254,16 @@ export interface RouteResult {
   HopCount: number;
   TargetSystemName?: string;
   TargetJumps?: number;
+  /** Round-trip mode: outbound totals and the return leg back toward the origin */
+  OutboundProfit?: number;
+  OutboundJumps?: number;
+  ReturnHops?: RouteHop[];
+  ReturnProfit?: number;
+  ReturnJumps?: number;
+  /** Jumps below 0.5 security over the whole trip (set when a lowsec penalty is used) */
+  LowsecJumps?: number;
+  /** TotalProfit minus the lowsec penalty; routes are ranked by this */
+  RiskAdjustedProfit: number;
 }
 
 export type NdjsonRouteMessage =
@@ -242,6 +279,21 @@ export interface WatchlistItem {
   alert_enabled?: boolean;
   alert_metric?: "margin_percent" | "total_profit" | "profit_per_unit" | "daily_volume";
   alert_threshold?: number;
+  region_id?: number;
+  demand_alert_threshold?: number;
+}
+
+export interface WatchlistHistoryPoint {
+  date: string;
+  average: number;
+  volume: number;
+}
+
+export interface WatchlistHistory {
+  type_id: number;
+  region_id: number;
+  days: number;
+  points: WatchlistHistoryPoint[];
 }
 
 export interface AlertHistoryEntry {
@@ -256,6 +308,7 @@ export interface AlertHistoryEntry {
   channels_failed?: Record<string, string>;
   sent_at: string;
   scan_id?: number;
+  source: "price" | "demand";
 }
 
 export interface ScanRecord {
@@ -268,6 +321,14 @@ export interface ScanRecord {
   total_profit: number;
   duration_ms: number;
   params: Record<string, unknown>;
+  /** Scan was canceled before finishing; results are incomplete. */
+  partial?: boolean;
+  /** Loaded from a bundle exported elsewhere (read-only). */
+  imported?: boolean;
+  /** Scan engine logic version; empty for scans saved before it was recorded. */
+  engine_version?: string;
+  /** Engine parameters the request resolved to (single-scan lookups only). */
+  resolved_params?: Record<string, unknown>;
 }
 
 export interface StationTrade {
@@ -286,6 +347,10 @@ export interface StationTrade {
   SellVolume: number;
   TotalProfit: number;
   DailyProfit?: number;
+  /** Per-unit result of dumping into the bid instead of listing at the ask. */
+  InstantSellProfit?: number;
+  /** Cycle profit spread over the estimated buy + sell fill time. */
+  EstimatedISKPerHour?: number;
   TheoreticalDailyProfit?: number;
   RealizableDailyProfit?: number;
   ConfidenceScore?: number;
@@ -296,6 +361,8 @@ export interface StationTrade {
   StationID: number;
   SystemID?: number;
   RegionID?: number;
+  /** True when the row comes from a player-owned structure market. */
+  IsStructure?: boolean;
   // EVE Guru style metrics
   CapitalRequired: number;
   NowROI: number;
@@ -320,6 +387,7 @@ export interface StationTrade {
   PriceLow: number;
   IsExtremePriceFlag: boolean;
   IsHighRiskFlag: boolean;
+  RestockSignal?: boolean;
   /** Expected fill prices from execution plan (order book depth) */
   ExpectedBuyPrice?: number;
   ExpectedSellPrice?: number;
@@ -329,6 +397,10 @@ export interface StationTrade {
   CanFill?: boolean;
   SlippageBuyPct?: number;
   SlippageSellPct?: number;
+  /** Per-unit fee components behind ProfitPerUnit */
+  fee_breakdown?: FeeBreakdown;
+  /** "esi" or "fuzzwork" when prices came from the aggregate fallback */
+  PriceSource?: string;
 }
 
 export type NdjsonStationMessage =
@@ -393,6 +465,8 @@ export interface ExecutionPlanResult {
 }
 
 export interface ScanParams {
+  /** Stored template to start from; fields set here override it. */
+  template_id?: number;
   system_name: string;
   cargo_capacity: number;
   buy_radius: number;
@@ -422,12 +496,20 @@ export interface ScanParams {
   min_route_security?: number;
   /** Optional source-region scope for regional trade (empty = buy radius from System). */
   source_regions?: string[];
+  /** Source region IDs; replace source_regions when non-empty. */
+  source_region_ids?: number[];
   /** Target region name for regional arbitrage (empty = search all by radius) */
   target_region?: string;
+  /** Target region ID; replaces target_region when set. */
+  target_region_id?: number;
   /** Optional destination marketplace system for regional day trader. */
   target_market_system?: string;
   /** Optional destination marketplace location_id (station/structure). */
   target_market_location_id?: number;
+  /** Extra regional day-trader destinations scanned in the same pass (max 5 in total). */
+  target_market_systems?: string[];
+  /** Refetch assets and orders instead of reusing the cached inventory snapshot. */
+  force_inventory_refresh?: boolean;
   // Contract-specific filters
   min_contract_price?: number;
   max_contract_margin?: number;
@@ -437,17 +519,29 @@ export interface ScanParams {
   contract_hold_days?: number;
   contract_target_confidence?: number;
   exclude_rigs_with_ship?: boolean;
+  /** Contract types to evaluate; default item_exchange. Auctions are priced at buyout. */
+  contract_types?: ("item_exchange" | "auction")[];
+  /** Skip contracts issued by these character or corporation IDs. */
+  exclude_issuer_ids?: number[];
   route_min_hops?: number;
   route_max_hops?: number;
   route_target_system_name?: string;
   route_min_isk_per_jump?: number;
   route_allow_empty_hops?: boolean;
+  /** ISK charged per jump below 0.5 security when ranking routes; 0 = rank by raw profit */
+  route_lowsec_jump_penalty?: number;
   // Player structures
   include_structures?: boolean;
   /** Category filter for regional day trader. Empty = all. */
   category_ids?: number[];
   /** When true, use lowest sell order at destination as revenue price instead of highest buy order. */
   sell_order_mode?: boolean;
+  /** Drop NPC-seeded orders (365-day duration) from profit calculations. */
+  exclude_npc_orders?: boolean;
+  /** Extra type IDs to drop from this scan (saved exclusions always apply). */
+  exclude_type_ids?: number[];
+  /** Extra market groups (with all sub-groups) to drop from this scan. */
+  exclude_market_group_ids?: number[];
 }
 
 export interface AppConfig {
@@ -483,17 +577,178 @@ export interface AppConfig {
   target_market_location_id?: number;
   category_ids?: number[];
   sell_order_mode?: boolean;
+  /** Type IDs dropped from every scan. */
+  exclude_type_ids?: number[];
+  /** Market group IDs (with all sub-groups) dropped from every scan. */
+  exclude_market_group_ids?: number[];
   alert_telegram: boolean;
   alert_discord: boolean;
   alert_desktop: boolean;
   alert_telegram_token: string;
   alert_telegram_chat_id: string;
   alert_discord_webhook: string;
+  /** Remind through Telegram/Discord when open orders are about to expire. */
+  alert_order_expiry?: boolean;
+  /** Ping through Telegram/Discord when the active character's orders get undercut. */
+  alert_undercut?: boolean;
   opacity: number;
   window_x: number;
   window_y: number;
   window_w: number;
   window_h: number;
+  ai_number_locale?: "" | "en-US" | "de-DE" | "ru-RU";
+  ai_isk_format?: "full" | "compact";
+  /** Wiki pages read by the assistant's fallback retrieval. */
+  ai_wiki_pages?: string[];
+  /** Lets the assistant run allowlisted tools for trading questions. */
+  ai_tools_enabled?: boolean;
+  ai_tool_allowlist?: ("run_station_scan" | "open_market")[];
+  price_fallback_enabled?: boolean;
+  /** Record daily per-item CTS/profit snapshots after station scans. */
+  station_trends_enabled?: boolean;
+  esi_max_retries?: number;
+  demand_cache_minutes?: number;
+  inventory_cache_minutes?: number;
+  history_retention_days?: number;
+  market_history_retention_days?: number;
+  share_link_ttl_hours?: number;
+}
+
+export interface ConfigProfile {
+  name: string;
+  config: AppConfig;
+  created_at: string;
+  updated_at: string;
+}
+
+/** Relative Composite Trading Score weights; normalized to sum to 1 when scoring. */
+export interface CTSWeights {
+  spread_roi: number;
+  obds: number;
+  drvi: number;
+  ci: number;
+  sds: number;
+  volume: number;
+}
+
+export interface HubBasketItem {
+  type_id: number;
+  quantity: number;
+}
+
+export interface HubItemProceeds {
+  type_id: number;
+  type_name: string;
+  quantity: number;
+  filled_qty: number;
+  best_price: number;
+  expected_price: number;
+  gross_value: number;
+  can_fill: boolean;
+}
+
+/** Selling a basket into one hub's buy orders, net of sales tax. */
+export interface HubComparison {
+  system_id: number;
+  system_name: string;
+  region_id: number;
+  gross_value: number;
+  sales_tax: number;
+  net_proceeds: number;
+  filled_items: number;
+  unsold_units: number;
+  coverage_pct: number;
+  items: HubItemProceeds[];
+}
+
+export interface HubCompareResponse {
+  sales_tax_percent: number;
+  hubs: HubComparison[];
+}
+
+/** User-defined CTS weighting, usable as a station scan cts_profile. */
+export interface CTSProfile {
+  name: string;
+  weights: CTSWeights;
+  created_at: string;
+  updated_at: string;
+}
+
+export type ScanTemplateTab = "radius" | "region" | "regional_day" | "contracts" | "route" | "station";
+
+/** Saved scan request for one tab; pass its id as template_id to a scan. */
+export interface ScanTemplate {
+  id: number;
+  tab: ScanTemplateTab;
+  name: string;
+  params: Record<string, unknown>;
+  created_at: string;
+  updated_at: string;
+}
+
+/** Type-name autocomplete match. */
+export interface TypeSuggestion {
+  type_id: number;
+  name: string;
+}
+
+/** SDE metadata of one type (GET /api/types/{typeID}). */
+export interface TypeInfo {
+  type_id: number;
+  name: string;
+  /** Packaged volume in m³. */
+  volume: number;
+  group_id: number;
+  group_name: string;
+  category_id: number;
+  category_name: string;
+  market_group_id: number;
+  /** Root first. */
+  market_group_path: { market_group_id: number; name: string }[];
+  market_disabled: boolean;
+}
+
+export interface ResolvedLocation {
+  location_id: number;
+  name: string;
+  kind: "station" | "structure" | "unknown";
+  system_id?: number;
+  system_name?: string;
+  region_id?: number;
+  region_name?: string;
+  resolved: boolean;
+}
+
+/** Built-in market-disabled type. Always filtered. */
+export interface MarketDisabledEntry {
+  type_id: number;
+  type_name: string;
+}
+
+/** Type a user added to the market-disabled list. */
+export interface MarketDisabledOverride {
+  type_id: number;
+  type_name: string;
+  created_at: string;
+}
+
+export interface MarketDisabledList {
+  builtin: MarketDisabledEntry[];
+  overrides: MarketDisabledOverride[];
+}
+
+/** Config as it was after one save; summary lists the changed keys. */
+export interface ConfigHistoryEntry {
+  id: number;
+  config: AppConfig;
+  summary: string;
+  created_at: string;
+}
+
+/** Result of a dry-run POST /api/config/validate. */
+export interface ConfigValidation {
+  config: AppConfig;
+  adjustments: string[];
 }
 
 export interface AppStatus {
@@ -502,6 +757,13 @@ export interface AppStatus {
   sde_types: number;
   esi_ok: boolean;
   esi_last_ok?: number; // Unix timestamp of last successful ESI check
+  esi_error_limit_remain?: number; // X-ESI-Error-Limit-Remain of the last response
+  esi_error_limit_reset?: number; // Unix timestamp when the error window resets
+  server_online?: boolean; // Tranquility up; absent when its state is unknown (ESI unreachable or non-200)
+  player_count?: number;
+  vip?: boolean; // TQ in VIP mode (developers only, usually right after downtime)
+  history_retention_days?: number;
+  market_history_retention_days?: number;
 }
 
 export type NdjsonMessage =
@@ -512,13 +774,17 @@ export type NdjsonMessage =
 export interface AuthCharacter {
   character_id: number;
   character_name: string;
+  label?: string;
   active: boolean;
+  /** Granted ESI scopes; absent for sessions from before scopes were recorded. */
+  scopes?: string[];
 }
 
 export interface AuthStatus {
   logged_in: boolean;
   character_id?: number;
   character_name?: string;
+  character_label?: string;
   characters?: AuthCharacter[];
   auth_revision?: number;
 }
@@ -527,6 +793,8 @@ export interface CharacterInfo {
   character_id: number;
   character_name: string;
   wallet: number;
+  /** Balance served from the server's 30 s cache rather than a fresh ESI call. */
+  wallet_cached?: boolean;
   orders: CharacterOrder[];
   order_history: HistoricalOrder[];
   transactions: WalletTransaction[];
@@ -545,10 +813,49 @@ export interface CharacterOrder {
   is_buy_order: boolean;
   duration: number;
   issued: string;
+  /** ISK still held by a buy order. */
+  escrow?: number;
   type_name?: string;
   location_name?: string;
 }
 
+export interface NetWorthCategory {
+  category_id: number;
+  category_name: string;
+  value_isk: number;
+  /** Packaged m³. */
+  volume_m3: number;
+  type_count: number;
+}
+
+export interface NetWorthCharacter {
+  character_id: number;
+  character_name: string;
+  wallet_isk: number;
+  buy_escrow_isk: number;
+  sell_orders_isk: number;
+  assets_isk: number;
+  total_isk: number;
+}
+
+/** GET /api/auth/networth — assets appraised at Jita 4-4. */
+export interface NetWorth {
+  total_isk: number;
+  wallet_isk: number;
+  buy_escrow_isk: number;
+  sell_orders_isk: number;
+  assets_isk: number;
+  /** Asset value by SDE category, largest first. */
+  categories: NetWorthCategory[];
+  characters: NetWorthCharacter[];
+  unpriced_types: number;
+  valuation: "sell" | "buy";
+  price_source: string;
+  warnings: string[];
+  generated_at: string;
+  cached: boolean;
+}
+
 export interface HistoricalOrder {
   order_id: number;
   type_id: number;
@@ -629,11 +936,26 @@ export interface OrderDeskSummary {
   unknown_eta_count: number;
 }
 
+export type OrderDeskFeeSource = "request" | "skills" | "config" | "default";
+
+/** Fees implied by a character's Accounting and Broker Relations skills. */
+export interface TradeFees {
+  sales_tax_percent: number;
+  broker_fee_percent: number;
+  accounting_level: number;
+  broker_relations_level: number;
+  /** Broker fee ignores faction/corporation standings (none were fetched). */
+  neutral_standings: boolean;
+}
+
 export interface OrderDeskSettings {
   sales_tax_percent: number;
   broker_fee_percent: number;
   target_eta_days: number;
   warn_expiry_days: number;
+  sales_tax_source?: OrderDeskFeeSource;
+  broker_fee_source?: OrderDeskFeeSource;
+  skill_fees?: TradeFees;
 }
 
 export interface OrderDeskOrder {
@@ -673,6 +995,7 @@ export interface OrderDeskResponse {
   summary: OrderDeskSummary;
   orders: OrderDeskOrder[];
   settings: OrderDeskSettings;
+  currency: string;
 }
 
 export type StationCommandAction = "new_entry" | "reprice" | "hold" | "cancel";
@@ -729,6 +1052,21 @@ export interface StationCacheMeta {
   stale: boolean;
 }
 
+export interface CacheStatus extends StationCacheMeta {
+  oldest_refresh_at?: string;
+  oldest_entry_age_sec: number;
+}
+
+export interface RegionCacheStatus extends CacheStatus {
+  region_id: number;
+  region_name?: string;
+}
+
+export interface CacheStatusResponse {
+  overall: CacheStatus;
+  regions: RegionCacheStatus[];
+}
+
 export interface StationCommandResponse {
   generated_at: string;
   scope: "single" | "all";
@@ -800,6 +1138,7 @@ export interface StationAIScanSnapshot {
   sell_broker_fee_percent: number;
   buy_sales_tax_percent: number;
   sell_sales_tax_percent: number;
+  /** balanced | aggressive | defensive, or the name of a custom CTS profile. */
   cts_profile: string;
   min_daily_volume: number;
   min_item_profit: number;
@@ -841,7 +1180,9 @@ export interface StationAIHistoryMessage {
 }
 
 export interface StationAIChatRequest {
-  provider: "openrouter";
+  provider: "openrouter" | "openai" | "anthropic" | "ollama";
+  /** Ollama only; must point to localhost. */
+  provider_base_url?: string;
   api_key: string;
   model: string;
   planner_model?: string;
@@ -849,15 +1190,68 @@ export interface StationAIChatRequest {
   max_tokens: number;
   assistant_name: string;
   locale: "ru" | "en";
+  number_locale?: "en-US" | "de-DE" | "ru-RU";
+  isk_format?: "full" | "compact";
   user_message: string;
   enable_wiki_context?: boolean;
   enable_web_research?: boolean;
   enable_planner?: boolean;
   wiki_repo?: string;
+  /** Overrides the user's ai_wiki_pages for this request. */
+  wiki_pages?: string[];
+  /** Server-side conversation; stored history is merged with `history`. */
+  conversation_id?: number;
   history?: StationAIHistoryMessage[];
   context: StationAIChatContext;
 }
 
+export interface StationAIConversation {
+  id: number;
+  title: string;
+  message_count: number;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface StationAIConversationMessage {
+  id: number;
+  role: "user" | "assistant";
+  content: string;
+  created_at: string;
+}
+
+export interface StationAIConversationDetail {
+  conversation: StationAIConversation;
+  messages: StationAIConversationMessage[];
+}
+
+export interface StationAIUsageBucket {
+  day?: string;
+  model?: string;
+  requests: number;
+  prompt_tokens: number;
+  completion_tokens: number;
+  total_tokens: number;
+  /** USD; only populated for providers that report cost (OpenRouter). */
+  cost_usd: number;
+}
+
+export interface StationAIUsageSummary {
+  days: number;
+  since: string;
+  totals: StationAIUsageBucket;
+  by_day: StationAIUsageBucket[];
+  by_model: StationAIUsageBucket[];
+}
+
+export interface StationAIToolCallResult {
+  tool: "run_station_scan" | "open_market";
+  arguments?: Record<string, unknown>;
+  ok: boolean;
+  error?: string;
+  result?: unknown;
+}
+
 export interface StationAIChatResponse {
   answer: string;
   provider: string;
@@ -870,6 +1264,10 @@ export interface StationAIChatResponse {
     response_mode?: string;
     context_level?: string;
     agents?: string[];
+    /** Set when web research ran; true if its snippets came from the server cache. */
+    web_cache_hit?: boolean;
+    /** Tools the server ran for this answer, when ai_tools_enabled is on. */
+    tool_calls?: StationAIToolCallResult[];
   };
   warnings?: string[];
   provider_id?: string;
@@ -918,6 +1316,7 @@ export interface IndustryParams {
   me: number; // Material Efficiency 0-10
   te: number; // Time Efficiency 0-20
   system_name: string;
+  system_id?: number; // Takes precedence over system_name
   station_id?: number; // Optional station/structure ID for price lookup
   facility_tax: number;
   structure_bonus: number;
@@ -927,6 +1326,10 @@ export interface IndustryParams {
   own_blueprint?: boolean;
   blueprint_cost?: number;
   blueprint_is_bpo?: boolean;
+  include_invention?: boolean;
+  decryptor?: number; // Decryptor type ID, 0 = none
+  reaction_structure_bonus?: number; // Refinery reaction material bonus %
+  reaction_facility_tax?: number; // Defaults to facility_tax
 }
 
 export interface BlueprintInfo {
@@ -946,11 +1349,43 @@ export interface MaterialNode {
   build_cost: number;
   should_build: boolean;
   job_cost: number;
+  job_type?: "manufacturing" | "reaction";
   children: MaterialNode[] | null;
   blueprint: BlueprintInfo | null;
+  invention?: InventionStep;
   depth: number;
 }
 
+export interface InventionMaterial {
+  type_id: number;
+  type_name: string;
+  quantity: number; // Per attempt
+  unit_price: number;
+}
+
+/** Invention costs exclude the T1 BPC (assumed copied from an owned BPO). */
+export interface InventionStep {
+  t1_blueprint_type_id: number;
+  t1_blueprint_name: string;
+  datacores: InventionMaterial[] | null;
+  decryptor?: InventionMaterial;
+  base_probability: number;
+  probability: number;
+  runs_per_bpc: number;
+  result_me: number;
+  result_te: number;
+  time: number;
+  bpcs_needed: number;
+  expected_attempts: number;
+  datacore_cost_per_attempt: number;
+  decryptor_cost_per_attempt: number;
+  job_cost_per_attempt: number;
+  datacore_cost_per_run: number;
+  cost_per_run: number;
+  cost_per_unit: number;
+  total_cost: number;
+}
+
 export interface FlatMaterial {
   type_id: number;
   type_name: string;
@@ -978,7 +1413,10 @@ export interface IndustryAnalysis {
   total_job_cost: number;
   material_tree: MaterialNode;
   flat_materials: FlatMaterial[];
-  system_cost_index: number;
+  system_id: number; // System whose cost indices were applied (0 = none)
+  system_cost_index: number; // Manufacturing
+  reaction_cost_index: number;
+  invention_cost_index: number;
   region_id: number;
   region_name?: string;
   blueprint_cost_included: number;
@@ -1146,6 +1584,59 @@ export interface IndustryPlanPreview {
   tasks: IndustryTaskPreview[];
   jobs: IndustryJobPlanInput[];
   warnings: string[];
+  /** What applying the patch would change */
+  diff: IndustryPlanDiff;
+}
+
+export type IndustryPlanChange = "added" | "removed" | "changed";
+
+export interface IndustryPlanMaterialState {
+  required_qty: number;
+  available_qty: number;
+  buy_qty: number;
+  build_qty: number;
+  unit_cost_isk: number;
+  source: string;
+  /** buy_qty * unit_cost_isk */
+  cost_isk: number;
+}
+
+export interface IndustryPlanMaterialChange {
+  change: IndustryPlanChange;
+  task_id: number;
+  type_id: number;
+  type_name: string;
+  before?: IndustryPlanMaterialState;
+  after?: IndustryPlanMaterialState;
+  cost_delta_isk: number;
+}
+
+export interface IndustryPlanBlueprintState {
+  quantity: number;
+  me: number;
+  te: number;
+  available_runs: number;
+}
+
+export interface IndustryPlanBlueprintChange {
+  change: IndustryPlanChange;
+  blueprint_type_id: number;
+  blueprint_name: string;
+  location_id: number;
+  is_bpo: boolean;
+  before?: IndustryPlanBlueprintState;
+  after?: IndustryPlanBlueprintState;
+}
+
+export interface IndustryPlanDiff {
+  materials: IndustryPlanMaterialChange[];
+  blueprints: IndustryPlanBlueprintChange[];
+  material_cost_before_isk: number;
+  material_cost_after_isk: number;
+  job_cost_before_isk: number;
+  job_cost_after_isk: number;
+  /** Change in remaining material purchases plus job costs */
+  cost_delta_isk: number;
 }
 
 export interface IndustryJob {
@@ -1202,6 +1693,19 @@ export interface IndustryLedger {
   entries: IndustryLedgerEntry[];
 }
 
+/** Station or structure where the selected characters hold assets. */
+export interface IndustryAssetLocation extends ResolvedLocation {
+  type_count: number;
+  total_quantity: number;
+  character_ids: number[];
+}
+
+export interface IndustryAssetLocations {
+  locations: IndustryAssetLocation[];
+  characters_used: number;
+  scope: "single" | "all";
+}
+
 export interface IndustryTaskRecord {
   id: number;
   user_id: string;
@@ -1375,6 +1879,64 @@ export interface OpenPosition {
   oldest_lot_date: string;
 }
 
+export interface RealizedItemPnL {
+  type_id: number;
+  type_name: string;
+  quantity: number;
+  trades: number;
+  buy_volume: number;
+  sell_volume: number;
+  fees: number;
+  taxes: number;
+  realized_pnl: number;
+  margin_percent: number;
+}
+
+/** GET /api/auth/pnl: realized profit per type and per day. */
+export interface RealizedPnLReport {
+  items: RealizedItemPnL[];
+  daily: DailyPnLEntry[];
+  totals: {
+    types: number;
+    quantity: number;
+    trades: number;
+    buy_volume: number;
+    sell_volume: number;
+    fees: number;
+    taxes: number;
+    net_pnl: number;
+    roi_percent: number;
+    profit_days: number;
+    loss_days: number;
+    trading_days: number;
+  };
+  coverage: MatchingCoverage;
+  settings: PortfolioSettings;
+}
+
+/** Open position marked to the lowest current sell (location first, then region). */
+export interface MarkedOpenPosition extends OpenPosition {
+  has_market_price: boolean;
+  current_price: number;
+  market_value: number;
+  unrealized_pnl: number;
+  unrealized_pct: number;
+}
+
+export interface OpenPositionsResponse {
+  scope: "active" | "character" | "all";
+  lookback_days: number;
+  positions: MarkedOpenPosition[];
+  totals: {
+    positions: number;
+    quantity: number;
+    cost_basis: number;
+    market_value: number;
+    unrealized_pnl: number;
+    unpriced: number;
+  };
+}
+
 export interface MatchingCoverage {
   total_sell_qty: number;
   matched_sell_qty: number;
@@ -1531,6 +2093,31 @@ export interface RegionOpportunities {
   total_potential: number;
 }
 
+export interface FitItemDemand {
+  type_id: number;
+  type_name: string;
+  category?: string;
+  quantity_in_fit: number;
+  est_daily_demand: number;
+  killmail_count: number;
+  fits_per_day: number;
+}
+
+export interface CustomFittingDemandResponse {
+  region_id: number;
+  fit: {
+    ship_type_id: number;
+    ship_name: string;
+    fit_name: string;
+    items: { type_id: number; name: string; quantity: number }[];
+  };
+  items: FitItemDemand[];
+  sampled_kills: number;
+  total_kills_24h: number;
+  from_cache: boolean;
+  warnings: string[];
+}
+
 // --- PLEX+ Types ---
 
 export interface PLEXGlobalPrice {
@@ -1692,6 +2279,32 @@ export interface OmegaComparison {
   isk_per_usd: number;
 }
 
+export interface PLEXHubPrice {
+  hub: string;
+  region_id: number;
+  buy_price: number;
+  sell_price: number;
+  buy_orders: number;
+  sell_orders: number;
+}
+
+export interface PLEXHubSpread {
+  buy_hub: string;
+  sell_hub: string;
+  buy_price: number;
+  sell_price: number;
+  instant_profit_isk: number;
+  listed_profit_isk: number;
+  roi: number;
+  viable: boolean;
+}
+
+export interface PLEXHubMatrix {
+  hubs: PLEXHubPrice[];
+  arbitrage: PLEXHubSpread[];
+  note?: string; // Set when no hub has PLEX orders (global market)
+}
+
 export interface CrossHubArbitrage {
   item_name: string;
   type_id: number;
@@ -1704,6 +2317,7 @@ export interface CrossHubArbitrage {
 }
 
 export interface PLEXDashboard {
+  currency: string;
   plex_price: PLEXGlobalPrice;
   arbitrage: ArbitragePath[];
   sp_farm: SPFarmResult;
@@ -1716,6 +2330,7 @@ export interface PLEXDashboard {
   injection_tiers?: InjectionTier[] | null;
   omega_comparison?: OmegaComparison | null;
   cross_hub?: CrossHubArbitrage[] | null;
+  hub_matrix?: PLEXHubMatrix;
 }
 
 // ============================================================
@@ -1875,6 +2490,48 @@ export interface CorpMiningEntry {
   quantity: number;
 }
 
+export interface WalletCategoryFlow {
+  category: string;
+  label: string;
+  income: number;
+  expenses: number;
+  net: number;
+  entries: number;
+}
+
+export interface CorpDivisionFlow {
+  division: number;
+  name: string;
+  balance: number;
+  start_balance: number;
+  balance_delta: number;
+  income: number;
+  expenses: number;
+  entries: number;
+  categories: WalletCategoryFlow[];
+}
+
+/** GET /api/corp/wallets: per-division net flow over the last `days` days. */
+export interface CorpWalletSummary {
+  is_demo: boolean;
+  days: number;
+  since: string;
+  total_balance: number;
+  total_income: number;
+  total_expenses: number;
+  net_flow: number;
+  divisions: CorpDivisionFlow[];
+  categories: WalletCategoryFlow[];
+  /** Divisions whose journal failed to load and are missing from the totals. */
+  warnings?: string[];
+}
+
+/** Corp list endpoint response; demo is true when synthetic data was served. */
+export interface CorpList<T> {
+  demo: boolean;
+  items: T[];
+}
+
 export interface CorpDashboard {
   info: {
     corporation_id: number;
@@ -1899,3 +2556,32 @@ export interface CorpDashboard {
   mining_summary: MiningSummary;
   market_summary: MarketSummary;
 }
+
+/** Machine-readable `code` of an API error response; the `error` field stays human-readable. */
+export type ApiErrorCode =
+  | "bad_request"
+  | "unauthorized"
+  | "forbidden"
+  | "not_found"
+  | "conflict"
+  | "payload_too_large"
+  | "rate_limited"
+  | "request_canceled"
+  | "internal_error"
+  | "upstream_error"
+  | "unavailable"
+  | "invalid_json"
+  | "sde_not_ready"
+  | "database_unavailable"
+  | "not_logged_in"
+  | "invalid_api_key"
+  | "region_not_found"
+  | "system_not_found"
+  | "type_not_found"
+  | "esi_error"
+  | "missing_scope"
+  | "invalid_type_id"
+  | "too_many_type_ids"
+  | "invalid_solar_system_id"
+  | "invalid_location_ids"
+  | "invalid_contract_id";
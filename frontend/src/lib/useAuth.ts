@@ -1,5 +1,5 @@
 import { useCallback, useEffect, useRef, useState } from "react";
-import { deleteAuthCharacter, getAuthStatus, getLoginUrl, logout as apiLogout, selectAuthCharacter } from "./api";
+import { deleteAuthCharacter, getAuthStatus, getLoginUrl, logout as apiLogout, logoutAll as apiLogoutAll, selectAuthCharacter, type LoginMode } from "./api";
 import type { AuthStatus } from "./types";
 
 interface UseAuthReturn {
@@ -7,6 +7,7 @@ interface UseAuthReturn {
   loginPolling: boolean;
   handleLogin: () => Promise<void>;
   handleLogout: () => Promise<void>;
+  handleLogoutAll: () => Promise<void>;
   handleSelectCharacter: (characterId: number) => Promise<void>;
   handleDeleteCharacter: (characterId: number) => Promise<void>;
   refreshAuthStatus: () => Promise<void>;
@@ -69,7 +70,12 @@ export function useAuth(): UseAuthReturn {
   }, []);
 
   const handleLogout = useCallback(async () => {
-    await apiLogout();
+    const status = await apiLogout();
+    setAuthStatus(normalizeAuthStatus(status));
+  }, []);
+
+  const handleLogoutAll = useCallback(async () => {
+    await apiLogoutAll();
     setAuthStatus({ logged_in: false, characters: [] });
   }, []);
 
@@ -89,17 +95,17 @@ export function useAuth(): UseAuthReturn {
   }, []);
 
   // Open EVE SSO login in system browser (Tauri) or same window (web)
-  const handleLogin = useCallback(async () => {
+  const handleLogin = useCallback(async (mode?: LoginMode) => {
     const baseline = normalizeAuthStatus(authStatus);
     const baselineFingerprint = authFingerprint(baseline);
     const wasLoggedIn = baseline.logged_in;
-    const baseUrl = getLoginUrl();
+    const baseUrl = getLoginUrl(mode);
     // Detect Tauri runtime
     const isTauri = !!(window as unknown as { __TAURI_INTERNALS__?: unknown }).__TAURI_INTERNALS__;
     if (isTauri) {
       // Pass ?desktop=1 so the backend knows to show a "close tab" page
       // instead of redirecting back to /
-      const url = baseUrl + "?desktop=1";
+      const url = baseUrl + (baseUrl.includes("?") ? "&" : "?") + "desktop=1";
       try {
         const { openUrl } = await import("@tauri-apps/plugin-opener");
         await openUrl(url);
@@ -144,6 +150,7 @@ export function useAuth(): UseAuthReturn {
     loginPolling,
     handleLogin,
     handleLogout,
+    handleLogoutAll,
     handleSelectCharacter,
     handleDeleteCharacter,
     refreshAuthStatus,
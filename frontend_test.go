@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFrontendHandlerCaching(t *testing.T) {
+	h := newFrontendHandler(fstest.MapFS{
+		"index.html":           {Data: []byte("<html>app</html>")},
+		"assets/app-3f9a1c.js": {Data: []byte("console.log(1)")},
+	})
+	get := func(method, path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	asset := get("GET", "/assets/app-3f9a1c.js", nil)
+	if asset.Code != 200 || asset.Header().Get("Cache-Control") != "public, max-age=31536000, immutable" {
+		t.Fatalf("asset = %d cache-control %q", asset.Code, asset.Header().Get("Cache-Control"))
+	}
+	etag := asset.Header().Get("ETag")
+	if etag == "" || asset.Header().Get("Last-Modified") == "" {
+		t.Fatalf("asset validators missing: etag %q last-modified %q", etag, asset.Header().Get("Last-Modified"))
+	}
+	if rec := get("GET", "/assets/app-3f9a1c.js", http.Header{"If-None-Match": {etag}}); rec.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match = %d, want 304", rec.Code)
+	}
+	lastModified := asset.Header().Get("Last-Modified")
+	if rec := get("GET", "/assets/app-3f9a1c.js", http.Header{"If-Modified-Since": {lastModified}}); rec.Code != http.StatusNotModified {
+		t.Fatalf("If-Modified-Since = %d, want 304", rec.Code)
+	}
+
+	index := get("GET", "/", nil)
+	if index.Code != 200 || index.Body.String() != "<html>app</html>" || index.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("index = %d %q cache-control %q", index.Code, index.Body.String(), index.Header().Get("Cache-Control"))
+	}
+
+	// Client-side routes still get index.html, with the same validators.
+	route := get("GET", "/station/trading", nil)
+	if route.Code != 200 || route.Body.String() != "<html>app</html>" || route.Header().Get("ETag") != index.Header().Get("ETag") {
+		t.Fatalf("SPA fallback = %d %q etag %q", route.Code, route.Body.String(), route.Header().Get("ETag"))
+	}
+
+	head := get("HEAD", "/", nil)
+	if head.Code != 200 || head.Body.Len() != 0 || head.Header().Get("Content-Length") != "16" {
+		t.Fatalf("HEAD = %d body %d length %q", head.Code, head.Body.Len(), head.Header().Get("Content-Length"))
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"eve-flipper/internal/logger"
+)
+
+// accessLogEnv turns on one log line per API request when truthy.
+const accessLogEnv = "EVEFLIPPER_ACCESS_LOG"
+
+func accessLogEnabled() bool {
+	v, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(accessLogEnv)))
+	return err == nil && v
+}
+
+// accessLogWriter records what a handler wrote. userScopeMiddleware fills in
+// userID, since the resolved user only exists on the inner request.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+	userID string
+}
+
+func (w *accessLogWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush keeps NDJSON scan streams working behind the wrapper.
+func (w *accessLogWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack keeps the live scan WebSocket working behind the wrapper.
+func (w *accessLogWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	conn, rw, err := hj.Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+func (w *accessLogWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// accessLogLine formats one request. The query string is left out: it can
+// carry share tokens and character IDs that have no place in logs. Share
+// links carry their token in the path, so it is redacted there.
+func accessLogLine(r *http.Request, w *accessLogWriter, elapsed time.Duration) string {
+	path := r.URL.Path
+	if isSharedScanPath(path) {
+		path = sharedScanPathPrefix + "<redacted>"
+	}
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	userID := w.userID
+	if userID == "" {
+		userID = "-"
+	}
+	return fmt.Sprintf("%s %s %d %dB %s user=%s",
+		r.Method, path, status, w.bytes, elapsed.Round(time.Microsecond), userID)
+}
+
+// accessLogMiddleware logs method, path, status, size, latency and user for
+// every request when s.accessLog is set; otherwise it is a no-op.
+func (s *Server) accessLogMiddleware(next http.Handler) http.Handler {
+	if !s.accessLog {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		lw := &accessLogWriter{ResponseWriter: w}
+		next.ServeHTTP(lw, r)
+		logger.Info("HTTP", accessLogLine(r, lw, time.Since(start)))
+	})
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessLogMiddlewareDisabledIsNoop(t *testing.T) {
+	srv := &Server{}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, wrapped := w.(*accessLogWriter); wrapped {
+			t.Error("writer wrapped while access log is off")
+		}
+	})
+	srv.accessLogMiddleware(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/status", nil))
+}
+
+func TestAccessLogWriterRecordsResponse(t *testing.T) {
+	srv := &Server{accessLog: true}
+	var lw *accessLogWriter
+	handler := srv.userScopeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lw = w.(*accessLogWriter)
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("wrapper hides http.Flusher")
+		}
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}))
+	rec := httptest.NewRecorder()
+	srv.accessLogMiddleware(handler).ServeHTTP(rec, httptest.NewRequest("GET", "/api/status?token=secret", nil))
+
+	if rec.Code != http.StatusTeapot || rec.Body.String() != "short and stout" {
+		t.Fatalf("response = %d %q", rec.Code, rec.Body.String())
+	}
+	if lw.status != http.StatusTeapot || lw.bytes != 15 || lw.userID == "" {
+		t.Fatalf("recorded status=%d bytes=%d user=%q", lw.status, lw.bytes, lw.userID)
+	}
+
+	line := accessLogLine(httptest.NewRequest("GET", "/api/status?token=secret", nil), lw, 1500*time.Microsecond)
+	if want := "GET /api/status 418 15B 1.5ms user=" + lw.userID; line != want {
+		t.Fatalf("line = %q, want %q", line, want)
+	}
+	if strings.Contains(line, "secret") {
+		t.Error("query string leaked into the access log")
+	}
+}
+
+func TestAccessLogLineDefaults(t *testing.T) {
+	line := accessLogLine(httptest.NewRequest("POST", "/api/scan", nil), &accessLogWriter{}, time.Millisecond)
+	if line != "POST /api/scan 200 0B 1ms user=-" {
+		t.Fatalf("line = %q", line)
+	}
+}
+
+func TestAccessLogLineRedactsShareToken(t *testing.T) {
+	line := accessLogLine(httptest.NewRequest("GET", "/api/shared/abc123token", nil), &accessLogWriter{}, time.Millisecond)
+	if line != "GET /api/shared/<redacted> 200 0B 1ms user=-" {
+		t.Fatalf("line = %q", line)
+	}
+	if strings.Contains(line, "abc123token") {
+		t.Error("share token leaked into the access log")
+	}
+}
@@ -0,0 +1,251 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"eve-flipper/internal/esi"
+)
+
+// isLoopbackRequest reports whether the client connected from localhost.
+func isLoopbackRequest(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// handleAdminBackup streams an online snapshot of the SQLite database as a
+// file download. Loopback clients only.
+func (s *Server) handleAdminBackup(w http.ResponseWriter, r *http.Request) {
+	if !isLoopbackRequest(r) {
+		writeError(w, 403, "backup is only available from localhost")
+		return
+	}
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	dir, err := os.MkdirTemp("", "eve-flipper-backup-")
+	if err != nil {
+		writeError(w, 500, "failed to create backup")
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "backup.db")
+	if err := s.db.BackupTo(path); err != nil {
+		log.Printf("[API] Backup failed: %v", err)
+		writeError(w, 500, "failed to create backup")
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		writeError(w, 500, "failed to read backup")
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		writeError(w, 500, "failed to read backup")
+		return
+	}
+
+	name := fmt.Sprintf("eve-flipper-%s.db", time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := io.Copy(w, f); err != nil {
+		log.Printf("[API] Backup download interrupted: %v", err)
+	}
+}
+
+// handleAdminMaintenance prunes scan history older than older_than_days,
+// drops orphaned result rows and VACUUMs the database. Loopback clients only.
+func (s *Server) handleAdminMaintenance(w http.ResponseWriter, r *http.Request) {
+	if !isLoopbackRequest(r) {
+		writeError(w, 403, "maintenance is only available from localhost")
+		return
+	}
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	var req struct {
+		OlderThanDays int `json:"older_than_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+		return
+	}
+	if req.OlderThanDays < 1 {
+		writeError(w, 400, "older_than_days must be at least 1")
+		return
+	}
+
+	sizeBefore, err := s.db.Size()
+	if err != nil {
+		writeError(w, 500, "failed to read database size")
+		return
+	}
+	scans, err := s.db.ClearHistory(req.OlderThanDays)
+	if err != nil {
+		writeError(w, 500, "clear history failed: "+err.Error())
+		return
+	}
+	orphans, err := s.db.DeleteOrphanedResults()
+	if err != nil {
+		writeError(w, 500, "orphan cleanup failed: "+err.Error())
+		return
+	}
+	start := time.Now()
+	if _, err := s.db.Vacuum(); err != nil {
+		writeError(w, 500, "vacuum failed: "+err.Error())
+		return
+	}
+	sizeAfter, err := s.db.Size()
+	if err != nil {
+		writeError(w, 500, "failed to read database size")
+		return
+	}
+	log.Printf("[API] Maintenance: %d scans, %d orphaned rows removed, %d bytes reclaimed (vacuum %s)",
+		scans, orphans, sizeBefore-sizeAfter, time.Since(start).Round(time.Millisecond))
+
+	writeJSON(w, map[string]interface{}{
+		"scans_deleted":         scans,
+		"orphaned_rows_deleted": orphans,
+		"bytes_reclaimed":       max(sizeBefore-sizeAfter, 0),
+		"db_size_bytes_before":  sizeBefore,
+		"db_size_bytes":         sizeAfter,
+	})
+}
+
+// handleAdminReloadSDE re-reads the SDE and swaps in a fresh scanner and
+// analyzers without a restart. A failed load leaves the current data in
+// place. Loopback clients only.
+func (s *Server) handleAdminReloadSDE(w http.ResponseWriter, r *http.Request) {
+	if !isLoopbackRequest(r) {
+		writeError(w, 403, "SDE reload is only available from localhost")
+		return
+	}
+	if !s.isReady() {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+	s.mu.RLock()
+	load := s.sdeLoader
+	s.mu.RUnlock()
+	if load == nil {
+		writeError(w, 503, "SDE reload is not configured")
+		return
+	}
+	if !s.sdeReloading.CompareAndSwap(false, true) {
+		writeError(w, 409, "SDE reload already in progress")
+		return
+	}
+	defer s.sdeReloading.Store(false)
+
+	start := time.Now()
+	data, err := load()
+	if err != nil {
+		log.Printf("[API] SDE reload failed, keeping current data: %v", err)
+		writeError(w, 500, "SDE reload failed: "+err.Error())
+		return
+	}
+	s.SetSDE(data)
+	log.Printf("[API] SDE reloaded in %s: %d systems, %d types",
+		time.Since(start).Round(time.Millisecond), len(data.Systems), len(data.Types))
+
+	writeJSON(w, map[string]interface{}{
+		"ok":          true,
+		"systems":     len(data.Systems),
+		"types":       len(data.Types),
+		"regions":     len(data.Regions),
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+}
+
+// cacheAgeSeconds is how old a cache filled at t is, or nil when it is empty.
+func cacheAgeSeconds(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return int64(time.Since(t).Seconds())
+}
+
+// handleAdminDebug reports the state of the in-memory caches, for working
+// out why a request was slow. Read-only. Loopback clients only, since it
+// exposes character IDs and internal cache keys.
+func (s *Server) handleAdminDebug(w http.ResponseWriter, r *http.Request) {
+	if !isLoopbackRequest(r) {
+		writeError(w, 403, "debug info is only available from localhost")
+		return
+	}
+
+	orderCache := map[string]interface{}{"entries": []esi.OrderCacheEntryStats{}}
+	if s.esi != nil {
+		entries := s.esi.OrderCacheStats()
+		if entries == nil {
+			entries = []esi.OrderCacheEntryStats{}
+		}
+		regions := s.esi.OrderCacheRegionIDs()
+		window := s.esi.OrderCacheWindow(regions, "all")
+		orderCache = map[string]interface{}{
+			"entries": entries,
+			"regions": len(regions),
+			"window": map[string]interface{}{
+				"entries":           window.Entries,
+				"last_refresh_at":   window.LastRefreshAt,
+				"oldest_refresh_at": window.OldestRefreshAt,
+				"next_expiry_at":    window.NextExpiryAt,
+				"min_ttl_seconds":   window.MinTTLSeconds,
+				"max_ttl_seconds":   window.MaxTTLSeconds,
+				"stale":             window.Stale,
+			},
+		}
+	}
+
+	s.txnCacheMu.RLock()
+	walletTxn := map[string]interface{}{
+		"age_seconds":  cacheAgeSeconds(s.txnCacheTime),
+		"character_id": s.txnCacheCharacterID,
+		"transactions": len(s.txnCache),
+	}
+	s.txnCacheMu.RUnlock()
+
+	s.plexCacheMu.RLock()
+	plex := map[string]interface{}{
+		"age_seconds": cacheAgeSeconds(s.plexCacheTime),
+		"key":         s.plexCacheKey,
+		"cached":      s.plexCache != nil,
+	}
+	s.plexCacheMu.RUnlock()
+
+	s.ssoStatesMu.Lock()
+	ssoStates := len(s.ssoStates)
+	s.ssoStatesMu.Unlock()
+
+	s.authRevisionMu.Lock()
+	authRevisions := len(s.authRevision)
+	s.authRevisionMu.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"esi_order_cache":     orderCache,
+		"wallet_txn_cache":    walletTxn,
+		"plex_cache":          plex,
+		"sso_states":          ssoStates,
+		"auth_revision_users": authRevisions,
+		"generated_at":        time.Now().UTC(),
+	})
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"eve-flipper/internal/sde"
+)
+
+func TestHandleAdminBackup(t *testing.T) {
+	srv := &Server{db: openAPITestDB(t)}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/backup", nil)
+	rec := httptest.NewRecorder()
+	srv.handleAdminBackup(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("remote status = %d, want 403", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/backup", nil)
+	req.RemoteAddr = "127.0.0.1:51234"
+	rec = httptest.NewRecorder()
+	srv.handleAdminBackup(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, `attachment; filename="eve-flipper-`) {
+		t.Fatalf("Content-Disposition = %q", cd)
+	}
+	if !bytes.HasPrefix(rec.Body.Bytes(), []byte("SQLite format 3\x00")) {
+		t.Fatalf("body is not a SQLite file (%d bytes)", rec.Body.Len())
+	}
+}
+
+func TestHandleAdminMaintenance(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+	database.InsertHistory("radius", "Jita", 0, 0)
+
+	post := func(body, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/maintenance", strings.NewReader(body))
+		if remote != "" {
+			req.RemoteAddr = remote
+		}
+		rec := httptest.NewRecorder()
+		srv.handleAdminMaintenance(rec, req)
+		return rec
+	}
+	if rec := post(`{"older_than_days":30}`, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("remote status = %d, want 403", rec.Code)
+	}
+	if rec := post(`{}`, "[::1]:40000"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing retention status = %d, want 400", rec.Code)
+	}
+	rec := post(`{"older_than_days":30}`, "[::1]:40000")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		ScansDeleted int64 `json:"scans_deleted"`
+		DBSizeBytes  int64 `json:"db_size_bytes"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.ScansDeleted != 0 || resp.DBSizeBytes <= 0 {
+		t.Fatalf("resp = %+v; recent scan must survive and size must be reported", resp)
+	}
+	if history := database.GetHistory(10); len(history) != 1 {
+		t.Fatalf("history len = %d, want 1", len(history))
+	}
+}
+
+func TestHandleAdminReloadSDE(t *testing.T) {
+	srv := &Server{}
+	old := &sde.Data{
+		Systems: map[int32]*sde.SolarSystem{30000142: {ID: 30000142, Name: "Jita"}},
+		Types:   map[int32]*sde.ItemType{34: {ID: 34, Name: "Tritanium"}},
+	}
+	srv.SetSDE(old)
+	oldScanner := srv.scanner
+
+	fresh := &sde.Data{
+		Systems: map[int32]*sde.SolarSystem{
+			30000142: {ID: 30000142, Name: "Jita"},
+			30002187: {ID: 30002187, Name: "Amarr"},
+		},
+		Types: map[int32]*sde.ItemType{34: {ID: 34, Name: "Tritanium"}},
+	}
+	var loadErr error
+	srv.SetSDELoader(func() (*sde.Data, error) {
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		return fresh, nil
+	})
+
+	post := func(remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/sde/reload", nil)
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		srv.handleAdminReloadSDE(rec, req)
+		return rec
+	}
+
+	if rec := post("192.0.2.10:4000"); rec.Code != http.StatusForbidden {
+		t.Fatalf("remote status = %d, want 403", rec.Code)
+	}
+
+	loadErr = errors.New("sde.zip is corrupt")
+	if rec := post("127.0.0.1:4000"); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("failed reload status = %d, want 500", rec.Code)
+	}
+	if srv.sdeData != old || srv.scanner != oldScanner {
+		t.Fatal("failed reload replaced the current SDE")
+	}
+
+	loadErr = nil
+	rec := post("127.0.0.1:4000")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var out struct {
+		Systems int `json:"systems"`
+		Types   int `json:"types"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.Systems != 2 || out.Types != 1 {
+		t.Errorf("counts = %+v, want 2 systems and 1 type", out)
+	}
+	if srv.sdeData != fresh || srv.scanner == oldScanner || srv.scanner.SDE != fresh {
+		t.Fatal("reload did not swap in the new SDE and scanner")
+	}
+	if oldScanner.SDE != old {
+		t.Error("in-flight scanner lost its original SDE")
+	}
+	if srv.scanner.ContractItemsCache != oldScanner.ContractItemsCache {
+		t.Error("contract items cache was not carried over")
+	}
+}
+
+func TestHandleAdminDebug(t *testing.T) {
+	srv := &Server{
+		ssoStates:    map[string]ssoStateEntry{"a": {}, "b": {}},
+		authRevision: map[string]int64{"u1": 3},
+		plexCacheKey: "3.6_1.5",
+		txnCacheTime: time.Now().Add(-90 * time.Second),
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handleAdminDebug(rec, httptest.NewRequest(http.MethodGet, "/api/admin/debug", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("remote status = %d, want 403", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/debug", nil)
+	req.RemoteAddr = "127.0.0.1:51234"
+	rec = httptest.NewRecorder()
+	srv.handleAdminDebug(rec, req)
+	var body struct {
+		SSOStates         int `json:"sso_states"`
+		AuthRevisionUsers int `json:"auth_revision_users"`
+		WalletTxnCache    struct {
+			AgeSeconds *int64 `json:"age_seconds"`
+		} `json:"wallet_txn_cache"`
+		PLEXCache struct {
+			AgeSeconds *int64 `json:"age_seconds"`
+			Key        string `json:"key"`
+		} `json:"plex_cache"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.SSOStates != 2 || body.AuthRevisionUsers != 1 || body.PLEXCache.Key != "3.6_1.5" {
+		t.Fatalf("body = %+v", body)
+	}
+	if body.WalletTxnCache.AgeSeconds == nil || *body.WalletTxnCache.AgeSeconds < 90 {
+		t.Fatalf("wallet age = %v, want ~90s", body.WalletTxnCache.AgeSeconds)
+	}
+	if body.PLEXCache.AgeSeconds != nil {
+		t.Fatalf("empty PLEX cache age = %d, want null", *body.PLEXCache.AgeSeconds)
+	}
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleGetAlertHistory_ValidatesFilters(t *testing.T) {
+	srv := &Server{db: openAPITestDB(t)}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusOK},
+		{"?since=2026-03-01T00:00:00Z&until=2026-03-02T00:00:00%2B02:00&channel=Discord", http.StatusOK},
+		{"?type_id=34&channel=desktop&limit=10&offset=5", http.StatusOK},
+		{"?since=2026-03-01", http.StatusBadRequest},
+		{"?until=yesterday", http.StatusBadRequest},
+		{"?since=2026-03-02T00:00:00Z&until=2026-03-02T00:00:00Z", http.StatusBadRequest},
+		{"?channel=email", http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		srv.handleGetAlertHistory(rr, httptest.NewRequest(http.MethodGet, "/api/alerts/history"+tc.query, nil))
+		if rr.Code != tc.want {
+			t.Errorf("%q: status = %d, want %d (%s)", tc.query, rr.Code, tc.want, rr.Body.String())
+		}
+	}
+}
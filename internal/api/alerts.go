@@ -26,6 +26,7 @@ type AlertCheckResult struct {
 	Message        string
 	CooldownActive bool
 	LastAlertAt    time.Time
+	Source         string // db.AlertSourcePrice (default) or db.AlertSourceDemand
 }
 
 // CheckWatchlistAlerts evaluates watchlist items against scan results and determines which alerts to fire.
@@ -98,10 +99,16 @@ func (s *Server) CheckWatchlistAlerts(userID string, results interface{}) []Aler
 	return alerts
 }
 
+// hasExternalAlertChannels reports whether cfg enables a channel the backend
+// delivers to. Desktop notifications are handled on frontend; backend
+// processes only external channels.
+func hasExternalAlertChannels(cfg *config.Config) bool {
+	return cfg != nil && (cfg.AlertTelegram || cfg.AlertDiscord)
+}
+
 // processWatchlistAlerts evaluates alerts for a result set and sends all triggered alerts.
 func (s *Server) processWatchlistAlerts(userID string, cfg *config.Config, results interface{}, scanID *int64) {
-	// Desktop notifications are handled on frontend; backend processes only external channels.
-	if cfg == nil || (!cfg.AlertTelegram && !cfg.AlertDiscord) {
+	if !hasExternalAlertChannels(cfg) {
 		return
 	}
 	alerts := s.CheckWatchlistAlerts(userID, results)
@@ -135,6 +142,7 @@ func (s *Server) SendAlert(userID string, cfg *config.Config, alert AlertCheckRe
 		ChannelsFailed:  channelsFailed,
 		SentAt:          time.Now().UTC().Format(time.RFC3339),
 		ScanID:          scanID,
+		Source:          alert.Source,
 	}
 
 	if err := s.db.SaveAlertHistoryForUser(userID, entry); err != nil {
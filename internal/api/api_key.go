@@ -0,0 +1,61 @@
+package api
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// apiKeyEnv enables API-key authentication for /api/ routes when set.
+const apiKeyEnv = "EVEFLIPPER_API_KEY"
+
+// apiKeyCookieName carries the key on browser navigations (SSO login
+// redirect) that cannot attach headers. The web UI sets it alongside the
+// header once the user enters the key.
+const apiKeyCookieName = "eveflipper_api_key"
+
+// apiKeyExemptPaths are reachable without the key. The SSO callback is
+// requested by CCP's redirect, which cannot carry our credentials.
+var apiKeyExemptPaths = map[string]bool{
+	"/api/auth/callback": true,
+}
+
+// apiKeyMiddleware requires s.apiKey on every /api/ request when it is set.
+// The key is accepted as "Authorization: Bearer <key>", "X-API-Key: <key>"
+// or the eveflipper_api_key cookie. With no key configured it is a no-op.
+func (s *Server) apiKeyMiddleware(next http.Handler) http.Handler {
+	if s.apiKey == "" {
+		return next
+	}
+	want := sha256.Sum256([]byte(s.apiKey))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Shared scan links are exempt too: their token is the credential.
+		if !strings.HasPrefix(r.URL.Path, "/api/") || apiKeyExemptPaths[r.URL.Path] || isSharedScanPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		got := sha256.Sum256([]byte(requestAPIKey(r)))
+		// Hashing first keeps the comparison constant-time regardless of key length.
+		if subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="eve-flipper"`)
+			writeErrorCode(w, 401, errCodeInvalidAPIKey, "invalid or missing api key")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestAPIKey extracts the client-supplied API key, if any.
+func requestAPIKey(r *http.Request) string {
+	if auth := strings.TrimSpace(r.Header.Get("Authorization")); len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key
+	}
+	if c, err := r.Cookie(apiKeyCookieName); err == nil {
+		return strings.TrimSpace(c.Value)
+	}
+	return ""
+}
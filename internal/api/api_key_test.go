@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	open := (&Server{}).apiKeyMiddleware(ok)
+	rec := httptest.NewRecorder()
+	open.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("no key configured: status = %d", rec.Code)
+	}
+
+	guarded := (&Server{apiKey: "s3cret"}).apiKeyMiddleware(ok)
+	cases := []struct {
+		name   string
+		path   string
+		header string
+		value  string
+		want   int
+	}{
+		{"missing", "/api/config", "", "", http.StatusUnauthorized},
+		{"wrong", "/api/config", "X-API-Key", "nope", http.StatusUnauthorized},
+		{"bearer", "/api/config", "Authorization", "Bearer s3cret", http.StatusNoContent},
+		{"x-api-key", "/api/scan", "X-API-Key", "s3cret", http.StatusNoContent},
+		{"sso callback exempt", "/api/auth/callback", "", "", http.StatusNoContent},
+		{"non-api path", "/index.html", "", "", http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.header != "" {
+			req.Header.Set(tc.header, tc.value)
+		}
+		rec := httptest.NewRecorder()
+		guarded.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Fatalf("%s: status = %d, want %d", tc.name, rec.Code, tc.want)
+		}
+		if tc.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Fatalf("%s: missing WWW-Authenticate", tc.name)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/login", nil)
+	req.AddCookie(&http.Cookie{Name: apiKeyCookieName, Value: "s3cret"})
+	rec = httptest.NewRecorder()
+	guarded.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("cookie: status = %d", rec.Code)
+	}
+}
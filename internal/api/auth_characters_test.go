@@ -0,0 +1,91 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"eve-flipper/internal/auth"
+)
+
+func TestHandleAuthCharacterLabel(t *testing.T) {
+	database := openAPITestDB(t)
+	const userID = "user-character-label"
+	srv := newAuthedIndustryTestServer(t, database, userID)
+
+	post := func(characterID, body string) *httptest.ResponseRecorder {
+		req := requestWithUserID(http.MethodPost, "/api/auth/characters/"+characterID+"/label", strings.NewReader(body), userID)
+		req.SetPathValue("characterID", characterID)
+		rec := httptest.NewRecorder()
+		srv.handleAuthCharacterLabel(rec, req)
+		return rec
+	}
+
+	long := strings.Repeat("x", 80)
+	rec := post("90000001", `{"label":"  `+long+`  "}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	payload := srv.authStatusPayload(userID)
+	chars := payload["characters"].([]authCharacterSummary)
+	if len(chars) != 1 || chars[0].Label != strings.Repeat("x", 64) {
+		t.Fatalf("characters = %+v, want label capped at 64 chars", chars)
+	}
+	if payload["character_label"] != strings.Repeat("x", 64) {
+		t.Fatalf("character_label = %v", payload["character_label"])
+	}
+
+	if rec := post("90000001", `{"label":"   "}`); rec.Code != http.StatusOK {
+		t.Fatalf("clear status = %d", rec.Code)
+	}
+	chars = srv.authStatusPayload(userID)["characters"].([]authCharacterSummary)
+	if chars[0].Label != "" {
+		t.Fatalf("label not cleared: %+v", chars[0])
+	}
+
+	if rec := post("12345", `{"label":"alt"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("foreign character status = %d, want 404", rec.Code)
+	}
+}
+
+func TestHandleAuthLogout_ActiveOnlyThenAll(t *testing.T) {
+	database := openAPITestDB(t)
+	const userID = "user-logout-split"
+	srv := newAuthedIndustryTestServer(t, database, userID)
+	if err := srv.sessions.SaveAndActivateForUser(userID, &auth.Session{
+		CharacterID:   90000002,
+		CharacterName: "Second Pilot",
+		AccessToken:   "test-access-token-2",
+		RefreshToken:  "test-refresh-token-2",
+		ExpiresAt:     time.Now().Add(2 * time.Hour),
+	}); err != nil {
+		t.Fatalf("SaveAndActivateForUser: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handleAuthLogout(rec, requestWithUserID(http.MethodPost, "/api/auth/logout", nil, userID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("logout status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	active := srv.sessions.GetForUser(userID)
+	if active == nil || active.CharacterID != 90000001 {
+		t.Fatalf("active after logout = %+v, want remaining character promoted", active)
+	}
+	if got := srv.authRevisionForUser(userID); got != 1 {
+		t.Fatalf("auth revision = %d, want 1", got)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleAuthLogoutAll(rec, requestWithUserID(http.MethodPost, "/api/auth/logout/all", nil, userID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("logout/all status = %d", rec.Code)
+	}
+	if srv.sessions.GetForUser(userID) != nil || len(srv.sessions.ListForUser(userID)) != 0 {
+		t.Fatal("sessions remain after logout/all")
+	}
+	if !strings.Contains(rec.Body.String(), `"logged_in":false`) {
+		t.Fatalf("logout/all body = %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"eve-flipper/internal/auth"
+)
+
+// missingScopeError reports that a character was logged in without an ESI
+// scope an endpoint needs, typically after a ?mode=basic login.
+type missingScopeError struct {
+	CharacterName string
+	Scope         string
+}
+
+func (e *missingScopeError) Error() string {
+	return fmt.Sprintf("%s did not grant the ESI scope %s; log in again with full access", e.CharacterName, e.Scope)
+}
+
+// requireScope returns a *missingScopeError when sess lacks scope.
+func requireScope(sess *auth.Session, scope string) error {
+	if sess.HasScope(scope) {
+		return nil
+	}
+	name := "character"
+	if sess != nil && sess.CharacterName != "" {
+		name = sess.CharacterName
+	}
+	return &missingScopeError{CharacterName: name, Scope: scope}
+}
+
+// writeScopeError writes a 403 naming the missing scope when err is a
+// *missingScopeError and reports whether it did.
+func writeScopeError(w http.ResponseWriter, err error) bool {
+	var scopeErr *missingScopeError
+	if !errors.As(err, &scopeErr) {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":          scopeErr.Error(),
+		"code":           errCodeMissingScope,
+		"required_scope": scopeErr.Scope,
+	})
+	return true
+}
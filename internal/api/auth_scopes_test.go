@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"eve-flipper/internal/auth"
+)
+
+func TestHandleAuthLogin_BasicModeRequestsReducedScopes(t *testing.T) {
+	srv := &Server{
+		sso:       &auth.SSOConfig{ClientID: "cid", Scopes: "esi-assets.read_assets.v1 " + auth.BasicScopes},
+		ssoStates: make(map[string]ssoStateEntry),
+	}
+
+	for mode, want := range map[string]string{
+		"":      srv.sso.Scopes,
+		"full":  srv.sso.Scopes,
+		"basic": auth.BasicScopes,
+	} {
+		rec := httptest.NewRecorder()
+		srv.handleAuthLogin(rec, httptest.NewRequest(http.MethodGet, "/api/auth/login?mode="+mode, nil))
+		if rec.Code != http.StatusTemporaryRedirect {
+			t.Fatalf("mode %q: status = %d", mode, rec.Code)
+		}
+		loc, err := url.Parse(rec.Header().Get("Location"))
+		if err != nil {
+			t.Fatalf("mode %q: parse redirect: %v", mode, err)
+		}
+		if got := loc.Query().Get("scope"); got != want {
+			t.Fatalf("mode %q: scope = %q, want %q", mode, got, want)
+		}
+		if entry := srv.ssoStates[loc.Query().Get("state")]; entry.Scopes != want {
+			t.Fatalf("mode %q: state scopes = %q, want %q", mode, entry.Scopes, want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handleAuthLogin(rec, httptest.NewRequest(http.MethodGet, "/api/auth/login?mode=everything", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown mode: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestBasicSessionGetsScopeSpecific403(t *testing.T) {
+	database := openAPITestDB(t)
+	const userID = "user-basic-scopes"
+	srv := newAuthedIndustryTestServer(t, database, userID)
+	if err := srv.sessions.SaveAndActivateForUser(userID, &auth.Session{
+		CharacterID:   90000001,
+		CharacterName: "Test Pilot",
+		AccessToken:   "test-access-token",
+		RefreshToken:  "test-refresh-token",
+		ExpiresAt:     time.Now().Add(2 * time.Hour),
+		Scopes:        auth.BasicScopes,
+	}); err != nil {
+		t.Fatalf("SaveAndActivateForUser: %v", err)
+	}
+
+	for path, tc := range map[string]struct {
+		h     http.HandlerFunc
+		scope string
+	}{
+		"/api/ui/set-route":   {srv.handleUISetRoute, auth.ScopeWriteWaypoint},
+		"/api/ui/open-market": {srv.handleUIOpenMarket, auth.ScopeOpenWindow},
+		"/api/auth/roles":     {srv.handleAuthRoles, auth.ScopeReadCorporationRoles},
+	} {
+		rec := httptest.NewRecorder()
+		tc.h(rec, requestWithUserID(http.MethodPost, path, strings.NewReader(`{}`), userID))
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("%s: status = %d, want 403 (body %s)", path, rec.Code, rec.Body.String())
+		}
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: decode: %v", path, err)
+		}
+		if body["required_scope"] != tc.scope || !strings.Contains(body["error"], tc.scope) {
+			t.Fatalf("%s: body = %v, want required_scope %s", path, body, tc.scope)
+		}
+	}
+
+	chars := srv.authStatusPayload(userID)["characters"].([]authCharacterSummary)
+	if len(chars) != 1 || strings.Join(chars[0].Scopes, " ") != auth.BasicScopes {
+		t.Fatalf("status characters = %+v, want basic scopes", chars)
+	}
+}
+
+func TestScopedESIHandlersReturn403(t *testing.T) {
+	database := openAPITestDB(t)
+	const userID = "user-skills-only"
+	srv := newAuthedIndustryTestServer(t, database, userID)
+	if err := srv.sessions.SaveAndActivateForUser(userID, &auth.Session{
+		CharacterID:   90000002,
+		CharacterName: "Skills Pilot",
+		AccessToken:   "test-access-token",
+		RefreshToken:  "test-refresh-token",
+		ExpiresAt:     time.Now().Add(2 * time.Hour),
+		Scopes:        auth.ScopeReadSkills,
+	}); err != nil {
+		t.Fatalf("SaveAndActivateForUser: %v", err)
+	}
+
+	for path, tc := range map[string]struct {
+		h     http.HandlerFunc
+		scope string
+	}{
+		"/api/auth/location":    {srv.handleAuthLocation, auth.ScopeReadLocation},
+		"/api/auth/undercuts":   {srv.handleAuthUndercuts, auth.ScopeReadCharacterOrders},
+		"/api/auth/orders/desk": {srv.handleAuthOrderDesk, auth.ScopeReadCharacterOrders},
+		"/api/auth/positions":   {srv.handleAuthPositions, auth.ScopeReadCharacterWallet},
+		"/api/auth/pnl":         {srv.handleAuthPnL, auth.ScopeReadCharacterWallet},
+		"/api/auth/portfolio":   {srv.handleAuthPortfolio, auth.ScopeReadCharacterWallet},
+	} {
+		rec := httptest.NewRecorder()
+		tc.h(rec, requestWithUserID(http.MethodGet, path, nil, userID))
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("%s: status = %d, want 403 (body %s)", path, rec.Code, rec.Body.String())
+		}
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: decode: %v", path, err)
+		}
+		if body["required_scope"] != tc.scope {
+			t.Fatalf("%s: body = %v, want required_scope %s", path, body, tc.scope)
+		}
+	}
+}
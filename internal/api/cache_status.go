@@ -0,0 +1,99 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheStatus is the order-cache freshness of a set of regions, plus the age
+// of the least recently refreshed entry.
+type cacheStatus struct {
+	stationCacheMeta
+	OldestRefreshAt   string `json:"oldest_refresh_at,omitempty"`
+	OldestEntryAgeSec int64  `json:"oldest_entry_age_sec"`
+}
+
+type regionCacheStatus struct {
+	RegionID   int32  `json:"region_id"`
+	RegionName string `json:"region_name,omitempty"`
+	cacheStatus
+}
+
+// handleCacheStatus reports order-cache freshness without running a scan:
+// GET /api/cache/status?region_ids=10000002,10000043. Without region_ids it
+// covers every region currently in the cache.
+func (s *Server) handleCacheStatus(w http.ResponseWriter, r *http.Request) {
+	if s.esi == nil {
+		writeError(w, 503, "esi client unavailable")
+		return
+	}
+	regionIDs, err := parseRegionIDList(r.URL.Query().Get("region_ids"))
+	if err != nil {
+		writeError(w, 400, err.Error())
+		return
+	}
+	if len(regionIDs) == 0 {
+		regionIDs = s.esi.OrderCacheRegionIDs()
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+
+	now := time.Now()
+	regions := make([]regionCacheStatus, 0, len(regionIDs))
+	for _, id := range regionIDs {
+		row := regionCacheStatus{
+			RegionID:    id,
+			cacheStatus: s.orderCacheStatus([]int32{id}, now),
+		}
+		if sdeData != nil {
+			if region, ok := sdeData.Regions[id]; ok {
+				row.RegionName = region.Name
+			}
+		}
+		regions = append(regions, row)
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"overall": s.orderCacheStatus(regionIDs, now),
+		"regions": regions,
+	})
+}
+
+func (s *Server) orderCacheStatus(regionIDs []int32, now time.Time) cacheStatus {
+	window := s.esi.OrderCacheWindow(regionIDs, "all")
+	status := cacheStatus{stationCacheMeta: stationCacheMetaFromWindow(window)}
+	if !window.OldestRefreshAt.IsZero() {
+		status.OldestRefreshAt = window.OldestRefreshAt.UTC().Format(time.RFC3339)
+		if age := now.Sub(window.OldestRefreshAt); age > 0 {
+			status.OldestEntryAgeSec = int64(age.Seconds())
+		}
+	}
+	return status
+}
+
+// parseRegionIDList parses a comma-separated list of region IDs, dropping
+// duplicates. An empty string yields nil.
+func parseRegionIDList(raw string) ([]int32, error) {
+	var ids []int32
+	seen := make(map[int32]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 32)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid region id %q", part)
+		}
+		if !seen[int32(id)] {
+			seen[int32(id)] = true
+			ids = append(ids, int32(id))
+		}
+	}
+	return ids, nil
+}
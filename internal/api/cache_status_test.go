@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/esi"
+)
+
+func TestHandleCacheStatus_ReportsRequestedRegions(t *testing.T) {
+	srv := NewServer(config.Default(), esi.NewClient(nil), nil, nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/cache/status?region_ids=10000002,10000043,10000002", nil)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body.String())
+	}
+
+	var out struct {
+		Overall cacheStatus         `json:"overall"`
+		Regions []regionCacheStatus `json:"regions"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(out.Regions) != 2 || out.Regions[0].RegionID != 10000002 || out.Regions[1].RegionID != 10000043 {
+		t.Fatalf("regions = %+v, want 10000002 and 10000043 once each", out.Regions)
+	}
+	if out.Overall.Regions != 2 || out.Overall.Entries != 0 || out.Overall.OldestEntryAgeSec != 0 {
+		t.Errorf("overall = %+v, want 2 regions and no entries", out.Overall)
+	}
+}
+
+func TestHandleCacheStatus_RejectsBadRegionID(t *testing.T) {
+	srv := NewServer(config.Default(), esi.NewClient(nil), nil, nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/cache/status?region_ids=10000002,jita", nil)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
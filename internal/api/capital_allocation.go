@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"eve-flipper/internal/auth"
+	"eve-flipper/internal/db"
+	"eve-flipper/internal/engine"
+	"eve-flipper/internal/sde"
+)
+
+const (
+	// defaultCapitalAllocationRegionID is The Forge (Jita).
+	defaultCapitalAllocationRegionID int32 = 10000002
+	capitalAllocationProjectLimit          = 50
+)
+
+// handleAuthCapitalAllocation compares the best station trades in a region
+// with the user's open industry projects and suggests how to split capital
+// between the two. Capital defaults to the active character's wallet balance.
+func (s *Server) handleAuthCapitalAllocation(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromRequest(r)
+	if !s.isReady() {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+
+	q := r.URL.Query()
+	regionID := defaultCapitalAllocationRegionID
+	if raw := strings.TrimSpace(q.Get("region_id")); raw != "" {
+		v, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || v <= 0 {
+			writeError(w, 400, "invalid region_id")
+			return
+		}
+		regionID = int32(v)
+	}
+	var stationID int64
+	if raw := strings.TrimSpace(q.Get("station_id")); raw != "" {
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || v < 0 {
+			writeError(w, 400, "invalid station_id")
+			return
+		}
+		stationID = v
+	}
+	var capital float64
+	if raw := strings.TrimSpace(q.Get("capital")); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 {
+			writeError(w, 400, "invalid capital")
+			return
+		}
+		capital = v
+	}
+
+	sess := s.sessions.GetForUser(userID)
+	if sess == nil {
+		writeErrorCode(w, 401, errCodeNotLoggedIn, "not logged in")
+		return
+	}
+
+	s.mu.RLock()
+	scanner := s.scanner
+	sdeData := s.sdeData
+	ia := s.industryAnalyzer
+	s.mu.RUnlock()
+	if scanner == nil || sdeData == nil {
+		writeError(w, 503, "station scanner not ready")
+		return
+	}
+	if _, ok := sdeData.Regions[regionID]; !ok {
+		writeErrorCode(w, 400, errCodeRegionNotFound, "unknown region")
+		return
+	}
+
+	capitalSource := "request"
+	if capital <= 0 {
+		if err := requireScope(sess, auth.ScopeReadCharacterWallet); err != nil {
+			writeScopeError(w, err)
+			return
+		}
+		token, err := s.sessions.EnsureValidTokenForUser(s.sso, userID)
+		if err != nil {
+			writeError(w, 401, err.Error())
+			return
+		}
+		balance, err := s.esi.GetWalletBalance(sess.CharacterID, token)
+		if err != nil {
+			writeError(w, 500, "failed to fetch wallet balance: "+err.Error())
+			return
+		}
+		capital = balance
+		capitalSource = "wallet"
+	}
+
+	cfg := s.loadConfigForUser(userID)
+	params := engine.StationTradeParams{
+		RegionID:             regionID,
+		MinMargin:            cfg.MinMargin,
+		SalesTaxPercent:      cfg.SalesTaxPercent,
+		BrokerFee:            cfg.BrokerFeePercent,
+		SplitTradeFees:       cfg.SplitTradeFees,
+		BuyBrokerFeePercent:  cfg.BuyBrokerFeePercent,
+		SellBrokerFeePercent: cfg.SellBrokerFeePercent,
+		BuySalesTaxPercent:   cfg.BuySalesTaxPercent,
+		SellSalesTaxPercent:  cfg.SellSalesTaxPercent,
+		MinDailyVolume:       cfg.MinDailyVolume,
+		MinItemProfit:        cfg.MinItemProfit,
+		Ctx:                  r.Context(),
+	}
+	if stationID > 0 {
+		params.StationIDs = map[int64]bool{stationID: true}
+	}
+	trades, err := scanner.ScanStationTrades(params, func(string) {})
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			writeError(w, 499, "request canceled")
+			return
+		}
+		writeError(w, 500, err.Error())
+		return
+	}
+	trades = filterStationTradesExcludeStructures(trades)
+	trades = s.filterStationTradesMarketDisabled(userID, trades)
+	opportunities := engine.StationTradeOpportunities(trades)
+
+	warnings := make([]string, 0, 2)
+	if s.db != nil {
+		var prices map[int32]float64
+		if ia != nil {
+			prices, err = s.esi.GetCachedMarketPrices(ia.IndustryCache, regionID)
+			if err != nil {
+				log.Printf("[API] capital-allocation market prices: %v", err)
+			}
+		}
+		if len(prices) == 0 {
+			warnings = append(warnings, "market prices unavailable; industry projects not evaluated")
+		} else {
+			sellFeeFactor := 1 - (cfg.SalesTaxPercent+cfg.BrokerFeePercent)/100
+			if cfg.SplitTradeFees {
+				sellFeeFactor = 1 - (cfg.SellSalesTaxPercent+cfg.SellBrokerFeePercent)/100
+			}
+			industryOpps, projectErr := s.industryCapitalOpportunities(userID, sdeData, prices, sellFeeFactor)
+			if projectErr != nil {
+				log.Printf("[API] capital-allocation industry projects: %v", projectErr)
+				warnings = append(warnings, "failed to load industry projects")
+			}
+			opportunities = append(opportunities, industryOpps...)
+		}
+	}
+
+	writeJSON(w, struct {
+		GeneratedAt   string                   `json:"generated_at"`
+		RegionID      int32                    `json:"region_id"`
+		CapitalSource string                   `json:"capital_source"`
+		Warnings      []string                 `json:"warnings"`
+		CacheMeta     stationCacheMeta         `json:"cache_meta"`
+		Allocation    engine.CapitalAllocation `json:"allocation"`
+	}{
+		GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+		RegionID:      regionID,
+		CapitalSource: capitalSource,
+		Warnings:      warnings,
+		CacheMeta:     s.stationCacheMetaForRegions(map[int32]bool{regionID: true}),
+		Allocation:    engine.AllocateCapital(capital, opportunities),
+	})
+}
+
+// industryCapitalOpportunities values each open industry project by the ISK
+// still to be spent (material buys and unstarted job fees) against the sale
+// value of its root products. Money already sunk into a project is ignored,
+// since only outstanding spend competes with station trading for capital.
+func (s *Server) industryCapitalOpportunities(userID string, sdeData *sde.Data, prices map[int32]float64, sellFeeFactor float64) ([]engine.CapitalOpportunity, error) {
+	projects, err := s.db.ListIndustryProjectsForUser(userID, "", capitalAllocationProjectLimit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]engine.CapitalOpportunity, 0, len(projects))
+	for _, p := range projects {
+		if p.Status == db.IndustryProjectStatusCompleted || p.Status == db.IndustryProjectStatusArchived {
+			continue
+		}
+		snapshot, err := s.db.GetIndustryProjectSnapshotForUser(userID, p.ID)
+		if err != nil {
+			return out, fmt.Errorf("project %d: %w", p.ID, err)
+		}
+		cost, revenue, duration := industryProjectEconomics(snapshot, sdeData, prices, sellFeeFactor)
+		if opp, ok := engine.IndustryProjectOpportunity(p.Name, p.ID, cost, revenue, duration); ok {
+			out = append(out, opp)
+		}
+	}
+	return out, nil
+}
+
+// industryProjectEconomics returns outstanding cost, net revenue and expected
+// duration (seconds) for one project snapshot.
+func industryProjectEconomics(snapshot db.IndustryProjectSnapshot, sdeData *sde.Data, prices map[int32]float64, sellFeeFactor float64) (cost, revenue, durationSeconds float64) {
+	for _, m := range snapshot.Materials {
+		cost += float64(m.BuyQty) * m.UnitCostISK
+	}
+	for _, j := range snapshot.Jobs {
+		switch j.Status {
+		case db.IndustryJobStatusPlanned, db.IndustryJobStatusQueued:
+			cost += j.CostISK
+		}
+		switch j.Status {
+		case db.IndustryJobStatusCompleted, db.IndustryJobStatusFailed, db.IndustryJobStatusCancelled:
+		default:
+			durationSeconds += float64(j.DurationSeconds)
+		}
+	}
+
+	var windowStart, windowEnd time.Time
+	var blueprintSeconds float64
+	for _, t := range snapshot.Tasks {
+		if start, err := time.Parse(time.RFC3339, t.PlannedStart); err == nil && (windowStart.IsZero() || start.Before(windowStart)) {
+			windowStart = start
+		}
+		if end, err := time.Parse(time.RFC3339, t.PlannedEnd); err == nil && end.After(windowEnd) {
+			windowEnd = end
+		}
+		if t.ParentTaskID != 0 || t.Status == db.IndustryTaskStatusCancelled {
+			continue
+		}
+		runs := float64(t.TargetRuns)
+		if runs <= 0 {
+			runs = 1
+		}
+		perRun := 1.0
+		if sdeData.Industry != nil {
+			if bpID, ok := sdeData.Industry.ProductToBlueprint[t.ProductTypeID]; ok {
+				if bp := sdeData.Industry.Blueprints[bpID]; bp != nil {
+					if bp.ProductQuantity > 0 {
+						perRun = float64(bp.ProductQuantity)
+					}
+					blueprintSeconds += float64(bp.Time) * runs
+				}
+			}
+		}
+		revenue += prices[t.ProductTypeID] * perRun * runs * sellFeeFactor
+	}
+
+	if !windowStart.IsZero() && windowEnd.After(windowStart) {
+		durationSeconds = windowEnd.Sub(windowStart).Seconds()
+	} else if durationSeconds <= 0 {
+		durationSeconds = blueprintSeconds
+	}
+	return cost, revenue, durationSeconds
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"math"
+	"testing"
+
+	"eve-flipper/internal/db"
+	"eve-flipper/internal/sde"
+)
+
+func TestIndustryProjectEconomics(t *testing.T) {
+	industry := sde.NewIndustryData()
+	industry.Blueprints[691] = &sde.Blueprint{BlueprintTypeID: 691, ProductTypeID: 587, ProductQuantity: 1, Time: 6000}
+	industry.ProductToBlueprint[587] = 691
+	sdeData := &sde.Data{Industry: industry}
+
+	snapshot := db.IndustryProjectSnapshot{
+		Tasks: []db.IndustryTask{
+			{ID: 1, ProductTypeID: 587, TargetRuns: 10},
+			{ID: 2, ParentTaskID: 1, ProductTypeID: 34, TargetRuns: 5},
+		},
+		Jobs: []db.IndustryJob{
+			{Status: db.IndustryJobStatusPlanned, CostISK: 1000, DurationSeconds: 3600},
+			{Status: db.IndustryJobStatusActive, CostISK: 5000, DurationSeconds: 7200},
+			{Status: db.IndustryJobStatusCompleted, CostISK: 9000, DurationSeconds: 9999},
+		},
+		Materials: []db.IndustryMaterialPlan{
+			{TypeID: 34, BuyQty: 100, UnitCostISK: 5},
+			{TypeID: 35, BuyQty: 0, UnitCostISK: 10},
+		},
+	}
+	prices := map[int32]float64{587: 400_000, 34: 5}
+
+	cost, revenue, duration := industryProjectEconomics(snapshot, sdeData, prices, 0.9)
+	// Only the planned job fee and the material buys are still to be paid.
+	if cost != 1500 {
+		t.Fatalf("cost = %v, want 1500", cost)
+	}
+	// Only root tasks produce sellable output.
+	if math.Abs(revenue-3_600_000) > 1e-6 {
+		t.Fatalf("revenue = %v, want 3600000", revenue)
+	}
+	if duration != 10800 {
+		t.Fatalf("duration = %v, want outstanding job time 10800", duration)
+	}
+}
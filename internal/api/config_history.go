@@ -0,0 +1,69 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/db"
+)
+
+func (s *Server) handleGetConfigHistory(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, 400, "invalid limit")
+			return
+		}
+		limit = min(n, db.ConfigHistoryKeep)
+	}
+	entries, err := s.db.ListConfigHistoryForUser(userIDFromRequest(r), limit)
+	if err != nil {
+		writeError(w, 500, "failed to load config history")
+		return
+	}
+	writeJSON(w, map[string]interface{}{"history": entries})
+}
+
+// handleRevertConfig restores a config_history snapshot as the active
+// config. The revert itself is recorded as a new snapshot.
+func (s *Server) handleRevertConfig(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, 400, "invalid history id")
+		return
+	}
+	userID := userIDFromRequest(r)
+	entry, err := s.db.GetConfigHistoryEntryForUser(userID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, 404, "history entry not found")
+			return
+		}
+		writeError(w, 500, "failed to load config history")
+		return
+	}
+	// As with profiles, window geometry stays where it is now.
+	active := s.loadConfigForUser(userID)
+	cfg, _ := config.Clamp(entry.Config)
+	cfg.Opacity = active.Opacity
+	cfg.WindowX, cfg.WindowY = active.WindowX, active.WindowY
+	cfg.WindowW, cfg.WindowH = active.WindowW, active.WindowH
+	if err := s.saveConfigForUser(userID, cfg); err != nil {
+		writeError(w, 500, "failed to save config")
+		return
+	}
+	s.applyServerConfig(userID, cfg)
+	writeJSON(w, cfg)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestConfigHistoryRevert(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+	const userID = "user-config-history"
+
+	cfg := database.LoadConfigForUser(userID)
+	cfg.BuyRadius = 7
+	if err := database.SaveConfigForUser(userID, cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	cfg.BuyRadius = 20
+	cfg.WindowW = 1280
+	if err := database.SaveConfigForUser(userID, cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handleGetConfigHistory(rec, requestWithUserID(http.MethodGet, "/api/config/history?limit=20", nil, userID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var list struct {
+		History []struct {
+			ID      int64  `json:"id"`
+			Summary string `json:"summary"`
+		} `json:"history"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.History) != 2 {
+		t.Fatalf("history len = %d, want 2", len(list.History))
+	}
+
+	revert := func(id string) *httptest.ResponseRecorder {
+		req := requestWithUserID(http.MethodPost, "/api/config/history/"+id+"/revert", nil, userID)
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		srv.handleRevertConfig(rec, req)
+		return rec
+	}
+	if rec := revert(strconv.FormatInt(list.History[1].ID, 10)); rec.Code != http.StatusOK {
+		t.Fatalf("revert status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if got := database.LoadConfigForUser(userID); got.BuyRadius != 7 || got.WindowW != 1280 {
+		t.Fatalf("after revert buy_radius=%d window_w=%d, want 7 and 1280", got.BuyRadius, got.WindowW)
+	}
+	if rec := revert("999999"); rec.Code != http.StatusNotFound {
+		t.Fatalf("revert missing status = %d", rec.Code)
+	}
+	if rec := revert("abc"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("revert bad id status = %d", rec.Code)
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"eve-flipper/internal/config"
+)
+
+const maxConfigProfileNameLength = 64
+
+func normalizeConfigProfileName(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxConfigProfileNameLength {
+		return "", false
+	}
+	return name, true
+}
+
+func (s *Server) handleListConfigProfiles(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	profiles, err := s.db.ListConfigProfilesForUser(userIDFromRequest(r))
+	if err != nil {
+		writeError(w, 500, "failed to list config profiles")
+		return
+	}
+	writeJSON(w, map[string]interface{}{"profiles": profiles})
+}
+
+// handleSaveConfigProfile stores a named profile. Without a "config" field
+// it snapshots the active config; a partial config is layered over the
+// active config, like a POST /api/config patch.
+func (s *Server) handleSaveConfigProfile(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	var body struct {
+		Name   string          `json:"name"`
+		Config json.RawMessage `json:"config"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+		return
+	}
+	name, ok := normalizeConfigProfileName(body.Name)
+	if !ok {
+		writeError(w, 400, "profile name must be 1-64 characters")
+		return
+	}
+	userID := userIDFromRequest(r)
+	cfg := s.loadConfigForUser(userID)
+	if len(body.Config) > 0 && string(body.Config) != "null" {
+		if err := json.Unmarshal(body.Config, cfg); err != nil {
+			writeError(w, 400, "invalid config")
+			return
+		}
+	}
+	cfg, _ = config.Clamp(cfg)
+
+	profile, err := s.db.SaveConfigProfileForUser(userID, name, cfg)
+	if err != nil {
+		writeError(w, 500, "failed to save config profile")
+		return
+	}
+	writeJSON(w, profile)
+}
+
+func (s *Server) handleDeleteConfigProfile(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	name, ok := normalizeConfigProfileName(r.PathValue("name"))
+	if !ok {
+		writeError(w, 400, "invalid profile name")
+		return
+	}
+	deleted, err := s.db.DeleteConfigProfileForUser(userIDFromRequest(r), name)
+	if err != nil {
+		writeError(w, 500, "failed to delete config profile")
+		return
+	}
+	if !deleted {
+		writeError(w, 404, "profile not found")
+		return
+	}
+	writeJSON(w, map[string]bool{"ok": true})
+}
+
+// handleActivateConfigProfile copies a profile into the user's active config.
+func (s *Server) handleActivateConfigProfile(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	name, ok := normalizeConfigProfileName(r.PathValue("name"))
+	if !ok {
+		writeError(w, 400, "invalid profile name")
+		return
+	}
+	userID := userIDFromRequest(r)
+	profile, err := s.db.GetConfigProfileForUser(userID, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, 404, "profile not found")
+			return
+		}
+		writeError(w, 500, "failed to load config profile")
+		return
+	}
+	// Window geometry is UI state, not strategy: keep the current values.
+	active := s.loadConfigForUser(userID)
+	cfg, _ := config.Clamp(profile.Config)
+	cfg.Opacity = active.Opacity
+	cfg.WindowX, cfg.WindowY = active.WindowX, active.WindowY
+	cfg.WindowW, cfg.WindowH = active.WindowW, active.WindowH
+	if err := s.saveConfigForUser(userID, cfg); err != nil {
+		writeError(w, 500, "failed to save config")
+		return
+	}
+	s.applyServerConfig(userID, cfg)
+	writeJSON(w, cfg)
+}
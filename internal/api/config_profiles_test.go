@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestConfigProfilesSaveActivateDelete(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+	const userID = "user-config-profiles"
+
+	save := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.handleSaveConfigProfile(rec, requestWithUserID(http.MethodPost, "/api/config/profiles", strings.NewReader(body), userID))
+		return rec
+	}
+	if rec := save(`{"name":"  "}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("blank name status = %d", rec.Code)
+	}
+	if rec := save(`{"name":"lowsec arbitrage","config":{"buy_radius":80,"min_margin":12}}`); rec.Code != http.StatusOK {
+		t.Fatalf("save status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	profile, err := database.GetConfigProfileForUser(userID, "lowsec arbitrage")
+	if err != nil {
+		t.Fatalf("get profile: %v", err)
+	}
+	if profile.Config.BuyRadius != 50 || profile.Config.MinMargin != 12 {
+		t.Fatalf("stored profile buy_radius=%d min_margin=%v, want clamped 50 and 12", profile.Config.BuyRadius, profile.Config.MinMargin)
+	}
+
+	activate := func(name string) *httptest.ResponseRecorder {
+		req := requestWithUserID(http.MethodPost, "/api/config/profiles/"+url.PathEscape(name)+"/activate", nil, userID)
+		req.SetPathValue("name", name)
+		rec := httptest.NewRecorder()
+		srv.handleActivateConfigProfile(rec, req)
+		return rec
+	}
+	rec := activate("lowsec arbitrage")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("activate status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var active struct {
+		BuyRadius int     `json:"buy_radius"`
+		MinMargin float64 `json:"min_margin"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &active); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if cfg := database.LoadConfigForUser(userID); cfg.BuyRadius != 50 || cfg.MinMargin != 12 || active.BuyRadius != 50 {
+		t.Fatalf("active config buy_radius=%d min_margin=%v", cfg.BuyRadius, cfg.MinMargin)
+	}
+	if rec := activate("missing"); rec.Code != http.StatusNotFound {
+		t.Fatalf("activate missing status = %d", rec.Code)
+	}
+
+	del := requestWithUserID(http.MethodDelete, "/api/config/profiles/lowsec%20arbitrage", nil, userID)
+	del.SetPathValue("name", "lowsec arbitrage")
+	rec = httptest.NewRecorder()
+	srv.handleDeleteConfigProfile(rec, del)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete status = %d", rec.Code)
+	}
+	if profiles, _ := database.ListConfigProfilesForUser(userID); len(profiles) != 0 {
+		t.Fatalf("profiles after delete = %+v", profiles)
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/db"
+)
+
+// serverConfigKeys are config fields that drive state shared by every user
+// (the ESI client, the demand analyzer, the database). They are read from
+// the default user's config only, the one main.go applies at startup; other
+// users' patches drop them.
+var serverConfigKeys = []string{
+	"esi_max_retries",
+	"demand_cache_minutes",
+	"history_retention_days",
+	"market_history_retention_days",
+}
+
+// stripServerConfigKeys removes server-level keys from a patch sent by any
+// user but the default one. It reports whether the patch touched them.
+func stripServerConfigKeys(userID string, patch map[string]json.RawMessage) (touched bool) {
+	for _, key := range serverConfigKeys {
+		if _, ok := patch[key]; ok {
+			touched = true
+			if userID != db.DefaultUserID {
+				delete(patch, key)
+			}
+		}
+	}
+	return touched
+}
+
+// applyServerConfig pushes the server-level settings of cfg to the shared
+// state when cfg belongs to the default user; otherwise it does nothing.
+func (s *Server) applyServerConfig(userID string, cfg *config.Config) {
+	if userID != db.DefaultUserID {
+		return
+	}
+	if s.esi != nil {
+		s.esi.SetMaxRetries(cfg.ESIMaxRetries)
+	}
+	s.applyDemandCacheTTL(cfg.DemandCacheMinutes)
+	if s.db != nil {
+		s.db.SetHistoryRetention(cfg.HistoryRetentionDays, cfg.MarketHistoryRetentionDays)
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/db"
+	"eve-flipper/internal/sde"
+)
+
+func TestSetConfigServerKeysDefaultUserOnly(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+
+	post := func(userID, body string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		srv.handleSetConfig(rec, requestWithUserID(http.MethodPost, "/api/config", strings.NewReader(body), userID))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body = %s", userID, rec.Code, rec.Body.String())
+		}
+	}
+
+	post("user-a", `{"history_retention_days":5,"market_history_retention_days":40,"esi_max_retries":0}`)
+	if scan, market := database.HistoryRetention(); scan == 5 || market == 40 {
+		t.Errorf("retention = %d/%d after another user's patch, want unchanged", scan, market)
+	}
+	if cfg := database.LoadConfigForUser("user-a"); cfg.HistoryRetentionDays == 5 || cfg.ESIMaxRetries == 0 {
+		t.Errorf("server-level keys stored in user-a's config: %+v", cfg)
+	}
+
+	post(db.DefaultUserID, `{"history_retention_days":7,"market_history_retention_days":60}`)
+	if scan, market := database.HistoryRetention(); scan != 7 || market != 60 {
+		t.Errorf("retention = %d/%d, want 7/60 from the default user", scan, market)
+	}
+}
+
+func TestDemandCacheTTLSurvivesSDEReload(t *testing.T) {
+	srv := &Server{}
+	data := &sde.Data{Types: map[int32]*sde.ItemType{34: {ID: 34, Name: "Tritanium"}}}
+	srv.SetSDE(data)
+	srv.applyServerConfig(db.DefaultUserID, &config.Config{DemandCacheMinutes: 45})
+
+	srv.SetSDE(data)
+	if got := srv.demandAnalyzer.CacheTTL(); got != 45*time.Minute {
+		t.Errorf("analyzer TTL after reload = %v, want 45m", got)
+	}
+	if got := srv.demandCacheTTL(); got != 45*time.Minute {
+		t.Errorf("demandCacheTTL = %v, want 45m", got)
+	}
+
+	srv.applyServerConfig("user-a", &config.Config{DemandCacheMinutes: 5})
+	if got := srv.demandCacheTTL(); got != 45*time.Minute {
+		t.Errorf("demandCacheTTL = %v after another user's config, want 45m", got)
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleValidateConfigDoesNotPersist(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+	const userID = "user-config-validate"
+	before := database.LoadConfigForUser(userID)
+
+	rec := httptest.NewRecorder()
+	srv.handleValidateConfig(rec, requestWithUserID(http.MethodPost, "/api/config/validate", strings.NewReader(`{"buy_radius":80,"min_margin":7}`), userID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Config struct {
+			BuyRadius int     `json:"buy_radius"`
+			MinMargin float64 `json:"min_margin"`
+		} `json:"config"`
+		Adjustments []string `json:"adjustments"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Config.BuyRadius != 50 || resp.Config.MinMargin != 7 {
+		t.Fatalf("config buy_radius=%d min_margin=%v, want 50 and 7", resp.Config.BuyRadius, resp.Config.MinMargin)
+	}
+	found := false
+	for _, note := range resp.Adjustments {
+		if note == "buy_radius reduced from 80 to 50" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("adjustments = %q, want buy_radius note", resp.Adjustments)
+	}
+	if after := database.LoadConfigForUser(userID); after.BuyRadius != before.BuyRadius || after.MinMargin != before.MinMargin {
+		t.Fatalf("validate persisted config: buy_radius=%d min_margin=%v", after.BuyRadius, after.MinMargin)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleValidateConfig(rec, requestWithUserID(http.MethodPost, "/api/config/validate", strings.NewReader(`{`), userID))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid json status = %d", rec.Code)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"strconv"
 	"strings"
 
-	"eve-flipper/internal/engine"
 	"eve-flipper/internal/esi"
 )
 
@@ -60,7 +59,7 @@ func (s *Server) handleGetContractItems(w http.ResponseWriter, r *http.Request)
 	contractIDStr := r.PathValue("contract_id")
 	contractID, err := strconv.ParseInt(contractIDStr, 10, 32)
 	if err != nil {
-		http.Error(w, `{"error":"invalid_contract_id"}`, http.StatusBadRequest)
+		writeErrorCode(w, http.StatusBadRequest, errCodeInvalidContractID, "invalid contract_id")
 		return
 	}
 
@@ -81,7 +80,7 @@ func (s *Server) handleGetContractItems(w http.ResponseWriter, r *http.Request)
 		fetched, err := s.esi.FetchContractItems(int32(contractID))
 		if err != nil {
 			log.Printf("[API] FetchContractItems error: contract_id=%d, err=%v", contractID, err)
-			http.Error(w, `{"error":"esi_error"}`, http.StatusInternalServerError)
+			writeErrorCode(w, http.StatusInternalServerError, errCodeESI, "ESI request failed")
 			return
 		}
 		items = fetched
@@ -102,8 +101,9 @@ func (s *Server) handleGetContractItems(w http.ResponseWriter, r *http.Request)
 		return name
 	}
 
+	userID := userIDFromRequest(r)
 	for _, item := range items {
-		if item.Quantity > 0 && engine.IsMarketDisabledTypeID(item.TypeID) {
+		if item.Quantity > 0 && s.isMarketDisabledForUser(userID, item.TypeID) {
 			continue
 		}
 		typeNameSDE := ""
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"eve-flipper/internal/corp"
+)
+
+func TestCorpProviderAutoFallsBackToDemo(t *testing.T) {
+	srv := &Server{demoCorpProvider: corp.NewDemoCorpProvider()}
+
+	for _, target := range []string{"/api/corp/wallets?mode=auto", "/api/corp/wallets", "/api/corp/wallets?mode=demo"} {
+		rec := httptest.NewRecorder()
+		srv.handleCorpWallets(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body = %s", target, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("X-Corp-Demo"); got != "true" {
+			t.Fatalf("%s: X-Corp-Demo = %q, want true", target, got)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handleCorpMembers(rec, httptest.NewRequest(http.MethodGet, "/api/corp/members", nil))
+	var list struct {
+		Demo  bool              `json:"demo"`
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("decode members: %v", err)
+	}
+	if !list.Demo || len(list.Items) == 0 {
+		t.Fatalf("members = demo %v with %d items, want demo list", list.Demo, len(list.Items))
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleCorpWallets(rec, httptest.NewRequest(http.MethodGet, "/api/corp/wallets?mode=live", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("live without login: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestHasCorpDirectorRole(t *testing.T) {
+	if hasCorpDirectorRole([]string{"Accountant", "Trader"}) {
+		t.Fatal("Accountant/Trader should not count as director")
+	}
+	if !hasCorpDirectorRole([]string{"Accountant", "Director"}) {
+		t.Fatal("Director should count")
+	}
+}
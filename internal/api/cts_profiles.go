@@ -0,0 +1,121 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"eve-flipper/internal/engine"
+)
+
+// handleListCTSProfiles returns the built-in CTS presets and the user's
+// custom profiles.
+func (s *Server) handleListCTSProfiles(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	profiles, err := s.db.ListCTSProfilesForUser(userIDFromRequest(r))
+	if err != nil {
+		writeError(w, 500, "failed to list CTS profiles")
+		return
+	}
+	builtin := make([]map[string]interface{}, 0, 3)
+	for _, name := range []string{engine.CTSProfileBalanced, engine.CTSProfileAggressive, engine.CTSProfileDefensive} {
+		builtin = append(builtin, map[string]interface{}{
+			"name":    name,
+			"weights": engine.CTSWeightsForProfile(name),
+		})
+	}
+	writeJSON(w, map[string]interface{}{
+		"builtin":  builtin,
+		"profiles": profiles,
+	})
+}
+
+// handleSaveCTSProfile creates or replaces a custom profile. Weights are
+// relative; they are normalized to sum to 1 when scoring.
+func (s *Server) handleSaveCTSProfile(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	var body struct {
+		Name    string             `json:"name"`
+		Weights *engine.CTSWeights `json:"weights"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+		return
+	}
+	name, ok := normalizeConfigProfileName(body.Name)
+	if !ok {
+		writeError(w, 400, "profile name must be 1-64 characters")
+		return
+	}
+	if engine.IsBuiltinCTSProfile(name) {
+		writeError(w, 400, fmt.Sprintf("%q is a built-in profile", name))
+		return
+	}
+	if body.Weights == nil {
+		writeError(w, 400, "weights are required")
+		return
+	}
+	if err := engine.ValidateCTSWeights(*body.Weights); err != nil {
+		writeError(w, 400, err.Error())
+		return
+	}
+
+	profile, err := s.db.SaveCTSProfileForUser(userIDFromRequest(r), name, *body.Weights)
+	if err != nil {
+		writeError(w, 500, "failed to save CTS profile")
+		return
+	}
+	writeJSON(w, profile)
+}
+
+func (s *Server) handleDeleteCTSProfile(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	name, ok := normalizeConfigProfileName(r.PathValue("name"))
+	if !ok {
+		writeError(w, 400, "invalid profile name")
+		return
+	}
+	deleted, err := s.db.DeleteCTSProfileForUser(userIDFromRequest(r), name)
+	if err != nil {
+		writeError(w, 500, "failed to delete CTS profile")
+		return
+	}
+	if !deleted {
+		writeError(w, 404, "profile not found")
+		return
+	}
+	writeJSON(w, map[string]bool{"ok": true})
+}
+
+// resolveCTSProfile maps a scan's cts_profile to explicit weights. Built-in
+// presets (and an empty name) return nil so the engine applies the preset;
+// any other name must be one of the user's custom profiles.
+func (s *Server) resolveCTSProfile(userID, name string) (*engine.CTSWeights, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || engine.IsBuiltinCTSProfile(name) {
+		return nil, nil
+	}
+	if s.db == nil {
+		return nil, fmt.Errorf("unknown cts_profile %q", name)
+	}
+	profile, err := s.db.GetCTSProfileForUser(userID, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("unknown cts_profile %q", name)
+		}
+		return nil, fmt.Errorf("failed to load cts_profile %q", name)
+	}
+	return &profile.Weights, nil
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"eve-flipper/internal/engine"
+)
+
+func TestCTSProfilesSaveResolveDelete(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+	const userID = "user-cts-profiles"
+
+	save := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.handleSaveCTSProfile(rec, requestWithUserID(http.MethodPost, "/api/cts/profiles", strings.NewReader(body), userID))
+		return rec
+	}
+	for _, body := range []string{
+		`{"name":"  ","weights":{"spread_roi":1}}`,
+		`{"name":"Aggressive","weights":{"spread_roi":1}}`,
+		`{"name":"mine"}`,
+		`{"name":"mine","weights":{"spread_roi":1,"sds":-1}}`,
+		`{"name":"mine","weights":{}}`,
+	} {
+		if rec := save(body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("save %s status = %d, want 400", body, rec.Code)
+		}
+	}
+	if rec := save(`{"name":"spread hunter","weights":{"spread_roi":4,"sds":1}}`); rec.Code != http.StatusOK {
+		t.Fatalf("save status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	weights, err := srv.resolveCTSProfile(userID, " spread hunter ")
+	if err != nil || weights == nil {
+		t.Fatalf("resolve custom = %v, %v", weights, err)
+	}
+	if *weights != (engine.CTSWeights{SpreadROI: 4, SDS: 1}) {
+		t.Fatalf("weights = %+v", *weights)
+	}
+	if weights, err := srv.resolveCTSProfile(userID, "Defensive"); err != nil || weights != nil {
+		t.Fatalf("built-in should resolve to nil weights, got %v, %v", weights, err)
+	}
+	if _, err := srv.resolveCTSProfile("someone-else", "spread hunter"); err == nil {
+		t.Fatal("another user's profile must not resolve")
+	}
+
+	del := func(name string) int {
+		req := requestWithUserID(http.MethodDelete, "/api/cts/profiles/x", nil, userID)
+		req.SetPathValue("name", name)
+		rec := httptest.NewRecorder()
+		srv.handleDeleteCTSProfile(rec, req)
+		return rec.Code
+	}
+	if code := del("spread hunter"); code != http.StatusOK {
+		t.Fatalf("delete status = %d", code)
+	}
+	if code := del("spread hunter"); code != http.StatusNotFound {
+		t.Fatalf("second delete status = %d", code)
+	}
+}
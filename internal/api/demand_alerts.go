@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"eve-flipper/internal/db"
+	"eve-flipper/internal/zkillboard"
+)
+
+const (
+	// demandAlertInterval is how often watchlist demand alerts are evaluated.
+	demandAlertInterval = 15 * time.Minute
+	// demandAlertMetric is the alert_metric recorded for demand-spike alerts.
+	demandAlertMetric = "demand_per_day"
+)
+
+// startDemandAlertLoop runs checkDemandAlerts on a ticker. It is started
+// once, when the demand analyzer first becomes available.
+func (s *Server) startDemandAlertLoop() {
+	s.demandAlertsOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(demandAlertInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				s.checkDemandAlerts()
+			}
+		}()
+	})
+}
+
+// checkDemandAlerts compares each watchlist demand alert with the latest
+// killmail fitting profile of its region and sends the ones that reach their
+// threshold through the user's external alert channels. Returns the number
+// of alerts sent.
+func (s *Server) checkDemandAlerts() int {
+	if s.db == nil {
+		return 0
+	}
+	watches, err := s.db.ListWatchlistDemandAlerts()
+	if err != nil {
+		log.Printf("[ALERT] Failed to list demand alerts: %v", err)
+		return 0
+	}
+	if len(watches) == 0 {
+		return 0
+	}
+
+	s.mu.RLock()
+	analyzer := s.demandAnalyzer
+	esiClient := s.esi
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+
+	profiles := make(map[int32]*zkillboard.RegionDemandProfile)
+	profileFor := func(regionID int32) *zkillboard.RegionDemandProfile {
+		if p, ok := profiles[regionID]; ok {
+			return p
+		}
+		p := s.cachedFittingProfile(regionID)
+		if p == nil && analyzer != nil && esiClient != nil && sdeData != nil {
+			fresh, err := analyzer.AnalyzeRegionFittings(regionID, esiClient, sdeData, 100)
+			if err != nil {
+				log.Printf("[ALERT] Demand analysis failed for region %d: %v", regionID, err)
+			} else {
+				s.saveFittingProfile(regionID, fresh)
+				p = fresh
+			}
+		}
+		profiles[regionID] = p
+		return p
+	}
+
+	sent := 0
+	for _, watch := range watches {
+		item := watch.Item
+		profile := profileFor(item.RegionID)
+		if profile == nil {
+			continue
+		}
+		demand, ok := profile.Items[item.TypeID]
+		if !ok || demand.EstDailyDemand < item.DemandAlertThreshold {
+			continue
+		}
+
+		cfg := s.loadConfigForUser(watch.UserID)
+		if !hasExternalAlertChannels(cfg) {
+			continue
+		}
+		last, err := s.db.GetLastAlertTimeForUser(watch.UserID, item.TypeID, demandAlertMetric, item.DemandAlertThreshold)
+		if err != nil {
+			log.Printf("[ALERT] Error checking last demand alert for type %d: %v", item.TypeID, err)
+			continue
+		}
+		if !last.IsZero() && time.Since(last) < DefaultAlertCooldown {
+			continue
+		}
+
+		regionName := fmt.Sprintf("region %d", item.RegionID)
+		if sdeData != nil {
+			if r, ok := sdeData.Regions[item.RegionID]; ok {
+				regionName = r.Name
+			}
+		}
+		alert := AlertCheckResult{
+			ShouldAlert:  true,
+			TypeID:       item.TypeID,
+			TypeName:     item.TypeName,
+			Metric:       demandAlertMetric,
+			Threshold:    item.DemandAlertThreshold,
+			CurrentValue: demand.EstDailyDemand,
+			Message: fmt.Sprintf("%s: demand in %s %.1f/day >= %.1f/day",
+				item.TypeName, regionName, demand.EstDailyDemand, item.DemandAlertThreshold),
+			LastAlertAt: last,
+			Source:      db.AlertSourceDemand,
+		}
+		if err := s.SendAlert(watch.UserID, cfg, alert, nil); err != nil {
+			log.Printf("[ALERT] Failed sending demand alert for type %d: %v", item.TypeID, err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/db"
+	"eve-flipper/internal/sde"
+	"eve-flipper/internal/zkillboard"
+)
+
+func TestHandleDemandCustomFitting_CachedProfile(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{
+		db:    database,
+		ready: true,
+		sdeData: &sde.Data{
+			Regions: map[int32]*sde.Region{10000002: {ID: 10000002, Name: "The Forge"}},
+			Types: map[int32]*sde.ItemType{
+				587:  {ID: 587, Name: "Rifter"},
+				2048: {ID: 2048, Name: "Damage Control II"},
+			},
+		},
+	}
+	if err := database.SaveFittingDemandProfile(10000002, []db.FittingDemandItem{
+		{RegionID: 10000002, TypeID: 2048, TypeName: "Damage Control II", EstDailyDemand: 12, KillmailCount: 40, SampledKills: 100},
+	}); err != nil {
+		t.Fatalf("save profile: %v", err)
+	}
+
+	body := "[Rifter, test]\ndamage control ii\nUnobtainium Plate\n"
+	rec := httptest.NewRecorder()
+	srv.handleDemandCustomFitting(rec, httptest.NewRequest(http.MethodPost, "/api/demand/fittings/custom?region_id=10000002", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	var out struct {
+		FromCache bool `json:"from_cache"`
+		Items     []struct {
+			TypeID         int32   `json:"type_id"`
+			EstDailyDemand float64 `json:"est_daily_demand"`
+		} `json:"items"`
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !out.FromCache || len(out.Items) != 2 || out.Items[0].TypeID != 2048 || out.Items[0].EstDailyDemand != 12 {
+		t.Fatalf("response = %+v, want cached demand for the damage control", out)
+	}
+	if len(out.Warnings) != 1 {
+		t.Fatalf("warnings = %v, want the unknown plate", out.Warnings)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleDemandCustomFitting(rec, httptest.NewRequest(http.MethodPost, "/api/demand/fittings/custom?region_id=10000002", strings.NewReader("[Titan, x]\n")))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown ship status = %d, want 400", rec.Code)
+	}
+}
+
+func TestHandleDemandRefresh_RateLimited(t *testing.T) {
+	analyzer := zkillboard.NewDemandAnalyzer(nil)
+	srv := &Server{
+		ready:          true,
+		demandAnalyzer: analyzer,
+		sdeData:        &sde.Data{Regions: map[int32]*sde.Region{10000002: {ID: 10000002}}},
+	}
+	next, _ := analyzer.BeginRefresh(0, time.Now())
+
+	rec := httptest.NewRecorder()
+	srv.handleDemandRefresh(rec, httptest.NewRequest(http.MethodPost, "/api/demand/refresh?region_id=10000002", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429; body=%s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Fatal("Retry-After header missing")
+	}
+	var out struct {
+		NextRefreshAt string `json:"next_refresh_at"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.NextRefreshAt != next.UTC().Format(time.RFC3339) {
+		t.Fatalf("next_refresh_at = %q, want %q", out.NextRefreshAt, next.UTC().Format(time.RFC3339))
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleDemandRefresh(rec, httptest.NewRequest(http.MethodPost, "/api/demand/refresh?region_id=42", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown region status = %d, want 400", rec.Code)
+	}
+}
+
+func TestCheckDemandAlerts(t *testing.T) {
+	database := openAPITestDB(t)
+	var posts int
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hook.Close()
+
+	cfg := config.Default()
+	cfg.AlertDiscord = true
+	cfg.AlertDiscordWebhook = hook.URL
+	if err := database.SaveConfigForUser("u1", cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	database.AddWatchlistItemForUser("u1", config.WatchlistItem{
+		TypeID: 2048, TypeName: "Damage Control II", AddedAt: time.Now().Format(time.RFC3339),
+		RegionID: 10000002, DemandAlertThreshold: 10,
+	})
+	database.AddWatchlistItemForUser("u1", config.WatchlistItem{
+		TypeID: 587, TypeName: "Rifter", AddedAt: time.Now().Format(time.RFC3339),
+		RegionID: 10000002, DemandAlertThreshold: 50,
+	})
+	if err := database.SaveFittingDemandProfile(10000002, []db.FittingDemandItem{
+		{RegionID: 10000002, TypeID: 2048, TypeName: "Damage Control II", EstDailyDemand: 12},
+		{RegionID: 10000002, TypeID: 587, TypeName: "Rifter", EstDailyDemand: 3},
+	}); err != nil {
+		t.Fatalf("save profile: %v", err)
+	}
+
+	srv := &Server{
+		db:      database,
+		sdeData: &sde.Data{Regions: map[int32]*sde.Region{10000002: {ID: 10000002, Name: "The Forge"}}},
+	}
+	if n := srv.checkDemandAlerts(); n != 1 || posts != 1 {
+		t.Fatalf("sent = %d (webhook posts %d), want 1 for the damage control only", n, posts)
+	}
+	// Cooldown suppresses the repeat.
+	if n := srv.checkDemandAlerts(); n != 0 {
+		t.Fatalf("second check sent = %d, want 0", n)
+	}
+
+	history, err := database.GetAlertHistoryForUser("u1", 0, 0)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 1 || history[0].Source != db.AlertSourceDemand || history[0].AlertMetric != demandAlertMetric {
+		t.Fatalf("history = %+v, want one demand alert", history)
+	}
+	if history[0].CurrentValue != 12 || history[0].Message != "Damage Control II: demand in The Forge 12.0/day >= 10.0/day" {
+		t.Fatalf("history[0] = %+v", history[0])
+	}
+}
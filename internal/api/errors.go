@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes sent in the "code" field of error responses. They are part of
+// the API: clients branch on them, so existing values must not change.
+const (
+	errCodeBadRequest      = "bad_request"
+	errCodeUnauthorized    = "unauthorized"
+	errCodeForbidden       = "forbidden"
+	errCodeNotFound        = "not_found"
+	errCodeConflict        = "conflict"
+	errCodeTooLarge        = "payload_too_large"
+	errCodeRateLimited     = "rate_limited"
+	errCodeRequestCanceled = "request_canceled"
+	errCodeInternal        = "internal_error"
+	errCodeUpstream        = "upstream_error"
+	errCodeUnavailable     = "unavailable"
+
+	errCodeInvalidJSON         = "invalid_json"
+	errCodeSDENotReady         = "sde_not_ready"
+	errCodeDatabaseUnavailable = "database_unavailable"
+	errCodeNotLoggedIn         = "not_logged_in"
+	errCodeInvalidAPIKey       = "invalid_api_key"
+	errCodeRegionNotFound      = "region_not_found"
+	errCodeSystemNotFound      = "system_not_found"
+	errCodeTypeNotFound        = "type_not_found"
+	errCodeESI                 = "esi_error"
+	errCodeMissingScope        = "missing_scope"
+
+	// In-game UI and contract endpoints; these were the whole error body
+	// before codes existed.
+	errCodeInvalidTypeID      = "invalid_type_id"
+	errCodeTooManyTypeIDs     = "too_many_type_ids"
+	errCodeInvalidSystemID    = "invalid_solar_system_id"
+	errCodeInvalidLocationIDs = "invalid_location_ids"
+	errCodeInvalidContractID  = "invalid_contract_id"
+)
+
+// writeErrorCode writes {"error": msg, "code": code}. "error" stays the
+// human-readable message older clients display.
+func writeErrorCode(w http.ResponseWriter, status int, code, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg, "code": code})
+}
+
+// errorCodeForStatus is the code writeError uses for errors that have no
+// more specific one.
+func errorCodeForStatus(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return errCodeUnauthorized
+	case http.StatusForbidden:
+		return errCodeForbidden
+	case http.StatusNotFound:
+		return errCodeNotFound
+	case http.StatusConflict:
+		return errCodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return errCodeTooLarge
+	case http.StatusTooManyRequests:
+		return errCodeRateLimited
+	case 499:
+		return errCodeRequestCanceled
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return errCodeUpstream
+	case http.StatusServiceUnavailable:
+		return errCodeUnavailable
+	}
+	if status >= 500 {
+		return errCodeInternal
+	}
+	return errCodeBadRequest
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorResponsesCarryCodes(t *testing.T) {
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder) map[string]string {
+		t.Helper()
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode %s: %v", rec.Body.String(), err)
+		}
+		return body
+	}
+
+	rec := httptest.NewRecorder()
+	writeError(rec, http.StatusNotFound, "not found")
+	if body := decode(t, rec); body["error"] != "not found" || body["code"] != errCodeNotFound {
+		t.Errorf("writeError body = %v", body)
+	}
+
+	srv := &Server{}
+	rec = httptest.NewRecorder()
+	srv.handleResolveLocations(rec, httptest.NewRequest(http.MethodPost, "/api/locations/resolve", strings.NewReader(`{}`)))
+	if body := decode(t, rec); rec.Code != http.StatusServiceUnavailable || body["code"] != errCodeSDENotReady {
+		t.Errorf("not ready: status %d, body %v", rec.Code, body)
+	}
+
+	srv.ready = true
+	rec = httptest.NewRecorder()
+	srv.handleResolveLocations(rec, httptest.NewRequest(http.MethodPost, "/api/locations/resolve", strings.NewReader(`{`)))
+	if body := decode(t, rec); rec.Code != http.StatusBadRequest || body["code"] != errCodeInvalidJSON || body["error"] != "invalid json" {
+		t.Errorf("bad json: status %d, body %v", rec.Code, body)
+	}
+}
+
+func TestErrorCodeForStatus(t *testing.T) {
+	for status, want := range map[int]string{
+		400: errCodeBadRequest,
+		401: errCodeUnauthorized,
+		404: errCodeNotFound,
+		422: errCodeBadRequest,
+		429: errCodeRateLimited,
+		499: errCodeRequestCanceled,
+		500: errCodeInternal,
+		502: errCodeUpstream,
+		503: errCodeUnavailable,
+	} {
+		if got := errorCodeForStatus(status); got != want {
+			t.Errorf("errorCodeForStatus(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
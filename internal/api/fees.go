@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"eve-flipper/internal/auth"
+	"eve-flipper/internal/engine"
+)
+
+// feeSimulationResponse wraps engine.FeeSimulation with where the sales tax
+// and broker fee came from: "request", "skills" or "config".
+type feeSimulationResponse struct {
+	engine.FeeSimulation
+	SalesTaxPercent  float64  `json:"sales_tax_percent"`
+	BrokerFeePercent float64  `json:"broker_fee_percent"`
+	SalesTaxSource   string   `json:"sales_tax_source"`
+	BrokerFeeSource  string   `json:"broker_fee_source"`
+	Warnings         []string `json:"warnings"`
+}
+
+// handleSimulateFees prices a hypothetical trade with the scanners' fee math.
+// Each fee is taken from the request when present, then from character_id's
+// trading skills when one is given, then from the user's config. Shipping is
+// charged only when volume, jumps and a per-m3-jump rate are all known; the
+// rate defaults to the configured shipping_cost_per_m3_jump.
+func (s *Server) handleSimulateFees(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromRequest(r)
+
+	var req struct {
+		BuyPrice              float64  `json:"buy_price"`
+		SellPrice             float64  `json:"sell_price"`
+		Quantity              int64    `json:"quantity"`
+		Volume                float64  `json:"volume"` // m3 per unit
+		Jumps                 int      `json:"jumps"`
+		ShippingCostPerM3Jump *float64 `json:"shipping_cost_per_m3_jump"`
+		CharacterID           int64    `json:"character_id"`
+		SalesTaxPercent       *float64 `json:"sales_tax_percent"`
+		BrokerFee             *float64 `json:"broker_fee"`
+		SplitTradeFees        *bool    `json:"split_trade_fees"`
+		BuyBrokerFeePercent   *float64 `json:"buy_broker_fee_percent"`
+		SellBrokerFeePercent  *float64 `json:"sell_broker_fee_percent"`
+		BuySalesTaxPercent    *float64 `json:"buy_sales_tax_percent"`
+		SellSalesTaxPercent   *float64 `json:"sell_sales_tax_percent"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+		return
+	}
+	if req.BuyPrice <= 0 || req.SellPrice <= 0 {
+		writeError(w, 400, "buy_price and sell_price must be positive")
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if req.Quantity < 0 || req.Volume < 0 || req.Jumps < 0 {
+		writeError(w, 400, "quantity, volume and jumps must be non-negative")
+		return
+	}
+	if req.ShippingCostPerM3Jump != nil && *req.ShippingCostPerM3Jump < 0 {
+		writeError(w, 400, "shipping_cost_per_m3_jump must be non-negative")
+		return
+	}
+	for _, p := range []*float64{req.SalesTaxPercent, req.BrokerFee, req.BuyBrokerFeePercent,
+		req.SellBrokerFeePercent, req.BuySalesTaxPercent, req.SellSalesTaxPercent} {
+		if p != nil && (*p < 0 || *p > 100) {
+			writeError(w, 400, "fee percentages must be between 0 and 100")
+			return
+		}
+	}
+
+	cfg := s.loadConfigForUser(userID)
+	in := engine.FeeSimulationInput{
+		BuyPrice:              req.BuyPrice,
+		SellPrice:             req.SellPrice,
+		Quantity:              req.Quantity,
+		VolumeM3:              req.Volume,
+		Jumps:                 req.Jumps,
+		ShippingCostPerM3Jump: cfg.ShippingCostPerM3Jump,
+		SplitTradeFees:        cfg.SplitTradeFees,
+		BrokerFeePercent:      cfg.BrokerFeePercent,
+		SalesTaxPercent:       cfg.SalesTaxPercent,
+		BuyBrokerFeePercent:   cfg.BuyBrokerFeePercent,
+		SellBrokerFeePercent:  cfg.SellBrokerFeePercent,
+		BuySalesTaxPercent:    cfg.BuySalesTaxPercent,
+		SellSalesTaxPercent:   cfg.SellSalesTaxPercent,
+	}
+	resp := feeSimulationResponse{
+		SalesTaxSource:  "config",
+		BrokerFeeSource: "config",
+		Warnings:        []string{},
+	}
+
+	if req.CharacterID > 0 {
+		skillFees, warning, status, err := s.skillTradeFees(userID, req.CharacterID)
+		if err != nil {
+			writeError(w, status, err.Error())
+			return
+		}
+		if warning != "" {
+			resp.Warnings = append(resp.Warnings, warning)
+		}
+		if skillFees != nil {
+			// Skills set the NPC rates on both the legacy and split fields, so
+			// the result does not depend on the configured fee mode.
+			in.SalesTaxPercent = skillFees.SalesTaxPercent
+			in.SellSalesTaxPercent = skillFees.SalesTaxPercent
+			in.BrokerFeePercent = skillFees.BrokerFeePercent
+			in.BuyBrokerFeePercent = skillFees.BrokerFeePercent
+			in.SellBrokerFeePercent = skillFees.BrokerFeePercent
+			resp.SalesTaxSource, resp.BrokerFeeSource = "skills", "skills"
+		}
+	}
+
+	if req.SplitTradeFees != nil {
+		in.SplitTradeFees = *req.SplitTradeFees
+	}
+	if req.SalesTaxPercent != nil {
+		in.SalesTaxPercent = *req.SalesTaxPercent
+		resp.SalesTaxSource = "request"
+	}
+	if req.BrokerFee != nil {
+		in.BrokerFeePercent = *req.BrokerFee
+		resp.BrokerFeeSource = "request"
+	}
+	if req.BuyBrokerFeePercent != nil {
+		in.BuyBrokerFeePercent = *req.BuyBrokerFeePercent
+	}
+	if req.SellBrokerFeePercent != nil {
+		in.SellBrokerFeePercent = *req.SellBrokerFeePercent
+	}
+	if req.BuySalesTaxPercent != nil {
+		in.BuySalesTaxPercent = *req.BuySalesTaxPercent
+	}
+	if req.SellSalesTaxPercent != nil {
+		in.SellSalesTaxPercent = *req.SellSalesTaxPercent
+	}
+	if req.ShippingCostPerM3Jump != nil {
+		in.ShippingCostPerM3Jump = *req.ShippingCostPerM3Jump
+	}
+
+	resp.FeeSimulation = engine.SimulateTradeFees(in)
+	resp.SalesTaxPercent = in.SalesTaxPercent
+	resp.BrokerFeePercent = in.BrokerFeePercent
+	writeJSON(w, resp)
+}
+
+// skillTradeFees derives trade fees from a logged-in character's skills. A
+// character without the skills scope, or whose skills fail to load, yields
+// nil fees and a warning; an unknown character is an error with its status.
+func (s *Server) skillTradeFees(userID string, characterID int64) (*engine.TradeFees, string, int, error) {
+	selected, err := s.authSessionsForScope(userID, characterID, false, false)
+	if err != nil {
+		return nil, "", 401, err
+	}
+	sess := selected[0]
+	if !sess.HasScope(auth.ScopeReadSkills) {
+		return nil, "character has not granted the skills scope; using configured fees", 0, nil
+	}
+	token, err := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
+	if err != nil {
+		return nil, "", 401, err
+	}
+	sheet, err := s.esi.GetSkills(sess.CharacterID, token)
+	if err != nil {
+		log.Printf("[API] Fee simulation skills error (%s): %v", sess.CharacterName, err)
+		return nil, "failed to load character skills; using configured fees", 0, nil
+	}
+	fees := engine.EffectiveTradeFees(sheet)
+	return &fees, "", 0, nil
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"eve-flipper/internal/config"
+)
+
+func postSimulateFees(t *testing.T, srv *Server, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	srv.handleSimulateFees(rr, httptest.NewRequest(http.MethodPost, "/api/fees/simulate", strings.NewReader(body)))
+	return rr
+}
+
+func TestHandleSimulateFees(t *testing.T) {
+	cfg := config.Default()
+	cfg.SalesTaxPercent = 8
+	cfg.BrokerFeePercent = 3
+	cfg.ShippingCostPerM3Jump = 100
+	srv := &Server{cfg: cfg}
+
+	rr := postSimulateFees(t, srv, `{"buy_price":1000,"sell_price":1250,"quantity":10,"volume":0.5,"jumps":4}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp feeSimulationResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.SalesTaxSource != "config" || resp.BrokerFeeSource != "config" {
+		t.Fatalf("sources = %s/%s, want config/config", resp.SalesTaxSource, resp.BrokerFeeSource)
+	}
+	if resp.ShippingCost != 2000 || math.Abs(resp.NetProfit-(-1175)) > 1e-9 {
+		t.Fatalf("shipping/net = %v/%v, want 2000/-1175", resp.ShippingCost, resp.NetProfit)
+	}
+
+	// Explicit zeros override the config and drop shipping.
+	rr = postSimulateFees(t, srv, `{"buy_price":1000,"sell_price":1250,"sales_tax_percent":0,"broker_fee":0,"shipping_cost_per_m3_jump":0,"volume":1,"jumps":3}`)
+	resp = feeSimulationResponse{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.SalesTaxSource != "request" || resp.Quantity != 1 || resp.NetProfit != 250 || resp.ShippingCost != 0 {
+		t.Fatalf("override response = %+v", resp)
+	}
+
+	for _, body := range []string{
+		`{"buy_price":0,"sell_price":1250}`,
+		`{"buy_price":1000,"sell_price":1250,"quantity":-1}`,
+		`{"buy_price":1000,"sell_price":1250,"broker_fee":120}`,
+		`not json`,
+	} {
+		if rr := postSimulateFees(t, srv, body); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, rr.Code)
+		}
+	}
+
+	// Skill-based fees need a logged-in character.
+	if rr := postSimulateFees(t, srv, `{"buy_price":1000,"sell_price":1250,"character_id":90000001}`); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown character status = %d, want 401", rr.Code)
+	}
+}
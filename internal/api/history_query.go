@@ -0,0 +1,188 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"eve-flipper/internal/engine"
+)
+
+// maxHistoryPageSize caps ?limit= on history results.
+const maxHistoryPageSize = 5000
+
+// historyResultQuery is the optional sort/filter/page request for
+// GET /api/scan/history/{id}/results. The zero value returns every row in
+// stored order.
+type historyResultQuery struct {
+	Sort      string
+	Desc      bool
+	MinMargin *float64
+	Action    string // "" or "execute" (rows fillable at the planned quantity)
+	Limit     int    // 0 = no limit
+	Offset    int
+}
+
+// historyRowAccessors describes what one result type supports: sortable
+// numeric columns, its margin, and whether a row can be executed right now.
+type historyRowAccessors[T any] struct {
+	sortKeys map[string]func(*T) float64
+	margin   func(*T) float64 // nil = min_margin unsupported
+	execute  func(*T) bool    // nil = action unsupported
+}
+
+var stationHistoryAccessors = historyRowAccessors[engine.StationTrade]{
+	sortKeys: map[string]func(*engine.StationTrade) float64{
+		"daily_profit":     func(t *engine.StationTrade) float64 { return t.DailyProfit },
+		"total_profit":     func(t *engine.StationTrade) float64 { return t.TotalProfit },
+		"profit_per_unit":  func(t *engine.StationTrade) float64 { return t.ProfitPerUnit },
+		"margin":           func(t *engine.StationTrade) float64 { return t.MarginPercent },
+		"roi":              func(t *engine.StationTrade) float64 { return t.ROI },
+		"daily_volume":     func(t *engine.StationTrade) float64 { return float64(t.DailyVolume) },
+		"capital_required": func(t *engine.StationTrade) float64 { return t.CapitalRequired },
+		"cts":              func(t *engine.StationTrade) float64 { return t.CTS },
+	},
+	margin:  func(t *engine.StationTrade) float64 { return t.MarginPercent },
+	execute: func(t *engine.StationTrade) bool { return t.CanFill },
+}
+
+var flipHistoryAccessors = historyRowAccessors[engine.FlipResult]{
+	sortKeys: map[string]func(*engine.FlipResult) float64{
+		"daily_profit":    func(f *engine.FlipResult) float64 { return f.DailyProfit },
+		"total_profit":    func(f *engine.FlipResult) float64 { return f.TotalProfit },
+		"profit_per_unit": func(f *engine.FlipResult) float64 { return f.ProfitPerUnit },
+		"profit_per_jump": func(f *engine.FlipResult) float64 { return f.ProfitPerJump },
+		"margin":          func(f *engine.FlipResult) float64 { return f.MarginPercent },
+		"daily_volume":    func(f *engine.FlipResult) float64 { return float64(f.DailyVolume) },
+		"total_jumps":     func(f *engine.FlipResult) float64 { return float64(f.TotalJumps) },
+	},
+	margin:  func(f *engine.FlipResult) float64 { return f.MarginPercent },
+	execute: func(f *engine.FlipResult) bool { return f.CanFill },
+}
+
+var contractHistoryAccessors = historyRowAccessors[engine.ContractResult]{
+	sortKeys: map[string]func(*engine.ContractResult) float64{
+		"profit":          func(c *engine.ContractResult) float64 { return c.Profit },
+		"expected_profit": func(c *engine.ContractResult) float64 { return c.ExpectedProfit },
+		"margin":          func(c *engine.ContractResult) float64 { return c.MarginPercent },
+		"price":           func(c *engine.ContractResult) float64 { return c.Price },
+		"profit_per_jump": func(c *engine.ContractResult) float64 { return c.ProfitPerJump },
+		"jumps":           func(c *engine.ContractResult) float64 { return float64(c.Jumps) },
+	},
+	margin: func(c *engine.ContractResult) float64 { return c.MarginPercent },
+}
+
+var routeHistoryAccessors = historyRowAccessors[engine.RouteResult]{
+	sortKeys: map[string]func(*engine.RouteResult) float64{
+		"total_profit":    func(r *engine.RouteResult) float64 { return r.TotalProfit },
+		"profit_per_jump": func(r *engine.RouteResult) float64 { return r.ProfitPerJump },
+		"total_jumps":     func(r *engine.RouteResult) float64 { return float64(r.TotalJumps) },
+		"hop_count":       func(r *engine.RouteResult) float64 { return float64(r.HopCount) },
+	},
+}
+
+// parseHistoryResultQuery reads the generic paging params. Sort keys and
+// filters are validated per result type by applyHistoryResultQuery.
+func parseHistoryResultQuery(r *http.Request) (historyResultQuery, error) {
+	qv := r.URL.Query()
+	q := historyResultQuery{
+		Sort:   strings.ToLower(strings.TrimSpace(qv.Get("sort"))),
+		Desc:   true,
+		Action: strings.ToLower(strings.TrimSpace(qv.Get("action"))),
+	}
+	switch strings.ToLower(strings.TrimSpace(qv.Get("order"))) {
+	case "", "desc":
+	case "asc":
+		q.Desc = false
+	default:
+		return q, fmt.Errorf("order must be asc or desc")
+	}
+	if v := strings.TrimSpace(qv.Get("min_margin")); v != "" {
+		m, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return q, fmt.Errorf("invalid min_margin")
+		}
+		q.MinMargin = &m
+	}
+	if q.Action != "" && q.Action != "execute" {
+		return q, fmt.Errorf("action must be execute")
+	}
+	if v := strings.TrimSpace(qv.Get("limit")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("invalid limit")
+		}
+		if n > maxHistoryPageSize {
+			n = maxHistoryPageSize
+		}
+		q.Limit = n
+	}
+	if v := strings.TrimSpace(qv.Get("offset")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("invalid offset")
+		}
+		q.Offset = n
+	}
+	return q, nil
+}
+
+// applyHistoryResultQuery filters, sorts and pages rows. It returns the page
+// and the number of rows that matched the filters before paging.
+func applyHistoryResultQuery[T any](rows []T, q historyResultQuery, acc historyRowAccessors[T]) ([]T, int, error) {
+	var key func(*T) float64
+	if q.Sort != "" {
+		key = acc.sortKeys[q.Sort]
+		if key == nil {
+			keys := make([]string, 0, len(acc.sortKeys))
+			for k := range acc.sortKeys {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			return nil, 0, fmt.Errorf("invalid sort key %q (allowed: %s)", q.Sort, strings.Join(keys, ", "))
+		}
+	}
+	if q.MinMargin != nil && acc.margin == nil {
+		return nil, 0, fmt.Errorf("min_margin is not supported for these results")
+	}
+	if q.Action != "" && acc.execute == nil {
+		return nil, 0, fmt.Errorf("action is not supported for these results")
+	}
+
+	out := rows
+	if q.MinMargin != nil || q.Action != "" {
+		out = make([]T, 0, len(rows))
+		for i := range rows {
+			if q.MinMargin != nil && acc.margin(&rows[i]) < *q.MinMargin {
+				continue
+			}
+			if q.Action == "execute" && !acc.execute(&rows[i]) {
+				continue
+			}
+			out = append(out, rows[i])
+		}
+	}
+	if key != nil {
+		if len(out) > 0 && &out[0] == &rows[0] {
+			out = append([]T(nil), out...) // don't reorder the caller's slice
+		}
+		sort.SliceStable(out, func(i, j int) bool {
+			if q.Desc {
+				return key(&out[i]) > key(&out[j])
+			}
+			return key(&out[i]) < key(&out[j])
+		})
+	}
+
+	total := len(out)
+	if q.Offset >= total {
+		return out[:0], total, nil
+	}
+	out = out[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(out) {
+		out = out[:q.Limit]
+	}
+	return out, total, nil
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"eve-flipper/internal/engine"
+)
+
+func TestHandleGetHistoryResults_SortFilterPage(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+
+	scanID := database.InsertHistory("station", "Jita", 4, 0)
+	database.InsertStationResults(scanID, []engine.StationTrade{
+		{TypeID: 34, TypeName: "Tritanium", MarginPercent: 5, DailyProfit: 100, CanFill: true},
+		{TypeID: 35, TypeName: "Pyerite", MarginPercent: 12, DailyProfit: 300, CanFill: true},
+		{TypeID: 36, TypeName: "Mexallon", MarginPercent: 15, DailyProfit: 200, CanFill: false},
+		{TypeID: 37, TypeName: "Isogen", MarginPercent: 20, DailyProfit: 400, CanFill: true},
+	})
+
+	get := func(query string) (int, map[string]json.RawMessage) {
+		req := httptest.NewRequest(http.MethodGet, "/api/scan/history/x/results"+query, nil)
+		req.SetPathValue("id", strconv.FormatInt(scanID, 10))
+		rec := httptest.NewRecorder()
+		srv.handleGetHistoryResults(rec, req)
+		var out map[string]json.RawMessage
+		json.NewDecoder(rec.Body).Decode(&out)
+		return rec.Code, out
+	}
+
+	code, out := get("?sort=daily_profit&order=desc&min_margin=10&action=execute&limit=1&offset=0")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, body=%v", code, out)
+	}
+	var rows []engine.StationTrade
+	json.Unmarshal(out["results"], &rows)
+	var total int
+	json.Unmarshal(out["total"], &total)
+	// Margin >= 10 and fillable leaves Pyerite and Isogen; Isogen earns more.
+	if total != 2 || len(rows) != 1 || rows[0].TypeID != 37 {
+		t.Fatalf("total = %d, rows = %+v; want Isogen of 2", total, rows)
+	}
+
+	_, out = get("?sort=margin&order=asc&offset=1&limit=2")
+	json.Unmarshal(out["results"], &rows)
+	json.Unmarshal(out["total"], &total)
+	if total != 4 || len(rows) != 2 || rows[0].TypeID != 35 || rows[1].TypeID != 36 {
+		t.Fatalf("asc page = %+v (total %d), want Pyerite, Mexallon", rows, total)
+	}
+
+	if code, _ := get("?sort=hop_count"); code != http.StatusBadRequest {
+		t.Fatalf("route-only sort key on station tab: status = %d, want 400", code)
+	}
+	if code, _ := get("?order=sideways"); code != http.StatusBadRequest {
+		t.Fatalf("bad order: status = %d, want 400", code)
+	}
+}
+
+func TestApplyHistoryResultQuery_RouteRejectsMarginFilter(t *testing.T) {
+	m := 5.0
+	_, _, err := applyHistoryResultQuery([]engine.RouteResult{{TotalProfit: 1}}, historyResultQuery{MinMargin: &m}, routeHistoryAccessors)
+	if err == nil {
+		t.Fatal("min_margin on routes: want error")
+	}
+	rows := []engine.RouteResult{{TotalProfit: 1}, {TotalProfit: 3}}
+	page, total, err := applyHistoryResultQuery(rows, historyResultQuery{Sort: "total_profit", Desc: true}, routeHistoryAccessors)
+	if err != nil || total != 2 || page[0].TotalProfit != 3 {
+		t.Fatalf("page = %+v, total = %d, err = %v", page, total, err)
+	}
+	if rows[0].TotalProfit != 1 {
+		t.Fatal("sorting must not reorder the input slice")
+	}
+}
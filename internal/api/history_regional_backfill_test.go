@@ -134,3 +134,28 @@ func TestRebuildRegionalHistoryRows_SkipsClassicRegionRecord(t *testing.T) {
 		t.Fatalf("rows len = %d, want 0 for classic region scan", len(rows))
 	}
 }
+
+func TestRegionalDayParamsFromHistory_PrefersResolvedParams(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := newRegionalHistoryBackfillServer(database)
+
+	// The request names Jita, but the scan resolved to Amarr with a cargo
+	// limit the request did not carry; replay must use what actually ran.
+	req := map[string]interface{}{"target_market_system": "Jita", "cargo_capacity": 1000}
+	resolved := engine.ScanParams{TargetMarketSystemID: 30002187, TargetRegionID: 10000043, CargoCapacity: 2500}
+	id := database.InsertHistoryFull("region", "Jita", 0, 0, 0, 0, req, resolved)
+
+	params, ok := srv.regionalDayParamsFromHistory(database.GetHistoryByID(id))
+	if !ok {
+		t.Fatal("regionalDayParamsFromHistory returned ok=false")
+	}
+	if params.TargetMarketSystemID != 30002187 || params.CargoCapacity != 2500 {
+		t.Fatalf("params = %+v, want the resolved Amarr params", params)
+	}
+
+	legacy := database.InsertHistoryFull("region", "Jita", 0, 0, 0, 0, req, nil)
+	params, ok = srv.regionalDayParamsFromHistory(database.GetHistoryByID(legacy))
+	if !ok || params.TargetMarketSystemID != 30000142 || params.CargoCapacity != 1000 {
+		t.Fatalf("legacy params = %+v (ok=%v), want Jita re-resolved from the request", params, ok)
+	}
+}
@@ -0,0 +1,25 @@
+package api
+
+import "time"
+
+// historyRetentionInterval is how often scan and market history past the
+// configured retention are pruned. main also prunes once at startup.
+const historyRetentionInterval = 6 * time.Hour
+
+// startHistoryRetentionLoop runs the database's history cleanup on a ticker.
+// The retention windows are read on every run, so saving new values in the
+// config takes effect at the next tick.
+func (s *Server) startHistoryRetentionLoop() {
+	if s.db == nil {
+		return
+	}
+	s.retentionOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(historyRetentionInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				s.db.CleanupOldHistory()
+			}
+		}()
+	})
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"eve-flipper/internal/db"
+	"eve-flipper/internal/engine"
+)
+
+// Limits for POST /api/scan/import.
+const (
+	maxImportedScanRows = 10000
+	maxScanImportBytes  = 32 << 20
+)
+
+// scanBundle is the export format of a saved scan: the history record and
+// every result row, as handleGetHistoryResults returns them.
+type scanBundle struct {
+	Scan    db.ScanRecord   `json:"scan"`
+	Results json.RawMessage `json:"results"`
+}
+
+// handleExportHistory returns a saved scan with all of its rows so it can be
+// shared and loaded elsewhere with POST /api/scan/import.
+// GET /api/scan/history/{id}/export?format=json
+func (s *Server) handleExportHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, 400, "invalid id")
+		return
+	}
+	if format := r.URL.Query().Get("format"); format != "" && format != "json" {
+		writeError(w, 400, fmt.Sprintf("unsupported format %q", format))
+		return
+	}
+	record := s.db.GetHistoryByID(id)
+	if record == nil {
+		writeError(w, 404, "not found")
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="scan-%d.json"`, id))
+	writeJSON(w, map[string]interface{}{
+		"scan":    record,
+		"results": s.loadHistoryResults(userIDFromRequest(r), record),
+	})
+}
+
+// handleImportHistory stores an exported scan bundle as a new, read-only
+// history record flagged imported. Rows are validated against the shape of
+// the bundle's tab, and bundles in which every row is market-disabled for
+// the importing user are rejected.
+// POST /api/scan/import
+func (s *Server) handleImportHistory(w http.ResponseWriter, r *http.Request) {
+	var bundle scanBundle
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxScanImportBytes)).Decode(&bundle); err != nil {
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+		return
+	}
+	userID := userIDFromRequest(r)
+	rec := bundle.Scan
+	rec.Tab = strings.TrimSpace(rec.Tab)
+
+	var (
+		count  int
+		insert func(scanID int64)
+	)
+	switch rec.Tab {
+	case "radius", "region":
+		rows, err := decodeImportedRows[engine.FlipResult](bundle.Results, func(row engine.FlipResult) bool {
+			return row.TypeID > 0
+		})
+		if err != nil {
+			writeError(w, 400, err.Error())
+			return
+		}
+		if len(rows) > 0 && len(s.filterFlipResultsMarketDisabled(userID, append([]engine.FlipResult(nil), rows...))) == 0 {
+			writeError(w, 400, "every item in the bundle is market-disabled")
+			return
+		}
+		count = len(rows)
+		insert = func(scanID int64) {
+			if rec.Tab == "region" {
+				s.db.InsertRegionalDayResults(scanID, rows)
+			} else {
+				s.db.InsertFlipResults(scanID, rows)
+			}
+		}
+	case "station":
+		rows, err := decodeImportedRows[engine.StationTrade](bundle.Results, func(row engine.StationTrade) bool {
+			return row.TypeID > 0
+		})
+		if err != nil {
+			writeError(w, 400, err.Error())
+			return
+		}
+		if len(rows) > 0 && len(s.filterStationTradesMarketDisabled(userID, append([]engine.StationTrade(nil), rows...))) == 0 {
+			writeError(w, 400, "every item in the bundle is market-disabled")
+			return
+		}
+		count = len(rows)
+		insert = func(scanID int64) { s.db.InsertStationResults(scanID, rows) }
+	case "contracts":
+		rows, err := decodeImportedRows[engine.ContractResult](bundle.Results, func(row engine.ContractResult) bool {
+			return row.ContractID > 0
+		})
+		if err != nil {
+			writeError(w, 400, err.Error())
+			return
+		}
+		if len(rows) > 0 && len(s.filterContractResultsMarketDisabled(userID, append([]engine.ContractResult(nil), rows...))) == 0 {
+			writeError(w, 400, "every contract in the bundle is market-disabled")
+			return
+		}
+		count = len(rows)
+		insert = func(scanID int64) { s.db.InsertContractResults(scanID, rows) }
+	case "route":
+		rows, err := decodeImportedRows[engine.RouteResult](bundle.Results, func(row engine.RouteResult) bool {
+			if len(row.Hops) == 0 {
+				return false
+			}
+			for _, hop := range routeAllHops(row) {
+				if hop.TypeID <= 0 {
+					return false
+				}
+			}
+			return true
+		})
+		if err != nil {
+			writeError(w, 400, err.Error())
+			return
+		}
+		if len(rows) > 0 && len(s.filterRouteResultsMarketDisabled(userID, append([]engine.RouteResult(nil), rows...))) == 0 {
+			writeError(w, 400, "every item in the bundle is market-disabled")
+			return
+		}
+		count = len(rows)
+		insert = func(scanID int64) { s.db.InsertRouteResults(scanID, rows) }
+	case "":
+		writeError(w, 400, "scan.tab is required")
+		return
+	default:
+		writeError(w, 400, fmt.Sprintf("unsupported scan tab %q", rec.Tab))
+		return
+	}
+
+	rec.Count = count
+	scanID := s.db.InsertImportedHistory(userID, rec)
+	if scanID == 0 {
+		writeError(w, 500, "failed to save imported scan")
+		return
+	}
+	insert(scanID)
+	writeJSON(w, s.db.GetHistoryByID(scanID))
+}
+
+// decodeImportedRows decodes a bundle's results array and checks every row
+// with valid. Errors name the first offending row.
+func decodeImportedRows[T any](raw json.RawMessage, valid func(T) bool) ([]T, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return []T{}, nil
+	}
+	var rows []T
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, fmt.Errorf("results do not match the scan tab: %v", err)
+	}
+	if len(rows) > maxImportedScanRows {
+		return nil, fmt.Errorf("at most %d result rows per import", maxImportedScanRows)
+	}
+	for i, row := range rows {
+		if !valid(row) {
+			return nil, fmt.Errorf("result row %d is missing its type or contract", i)
+		}
+	}
+	return rows, nil
+}
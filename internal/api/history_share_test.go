@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"eve-flipper/internal/db"
+	"eve-flipper/internal/engine"
+)
+
+func TestScanExportImportRoundTrip(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+
+	scanID := database.InsertHistoryFull("station", "Jita", 2, 300, 500, 1200, map[string]float64{"min_margin": 5}, nil)
+	database.InsertStationResults(scanID, []engine.StationTrade{
+		{TypeID: 34, TypeName: "Tritanium", MarginPercent: 5, DailyProfit: 200},
+		{TypeID: 35, TypeName: "Pyerite", MarginPercent: 12, DailyProfit: 300},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/scan/history/x/export?format=json", nil)
+	req.SetPathValue("id", strconv.FormatInt(scanID, 10))
+	rec := httptest.NewRecorder()
+	srv.handleExportHistory(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	exported := rec.Body.Bytes()
+
+	rec = httptest.NewRecorder()
+	srv.handleImportHistory(rec, requestWithUserID(http.MethodPost, "/api/scan/import", bytes.NewReader(exported), "corpmate"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var imported db.ScanRecord
+	if err := json.NewDecoder(rec.Body).Decode(&imported); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if imported.ID == scanID || !imported.Imported || imported.Tab != "station" || imported.Count != 2 || imported.TotalProfit != 500 {
+		t.Fatalf("imported record = %+v", imported)
+	}
+	rows := database.GetStationResults(imported.ID)
+	if len(rows) != 2 || rows[0].TypeName == "" {
+		t.Fatalf("imported rows = %+v", rows)
+	}
+	if database.GetHistoryByID(scanID).Imported {
+		t.Error("original scan flagged imported")
+	}
+}
+
+func TestHandleImportHistory_Rejects(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+
+	cases := map[string]string{
+		"unknown tab":   `{"scan":{"tab":"industry"},"results":[]}`,
+		"wrong shape":   `{"scan":{"tab":"station"},"results":{"TypeID":34}}`,
+		"missing type":  `{"scan":{"tab":"station"},"results":[{"TypeName":"Tritanium"}]}`,
+		"all disabled":  `{"scan":{"tab":"station"},"results":[{"TypeID":` + strconv.Itoa(int(engine.MPTCTypeID)) + `}]}`,
+		"route no hops": `{"scan":{"tab":"route"},"results":[{"TotalProfit":5}]}`,
+	}
+	for name, body := range cases {
+		rec := httptest.NewRecorder()
+		srv.handleImportHistory(rec, httptest.NewRequest(http.MethodPost, "/api/scan/import", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400 (body %s)", name, rec.Code, rec.Body.String())
+		}
+	}
+	if got := database.GetHistory(10); len(got) != 0 {
+		t.Errorf("rejected bundles created history rows: %+v", got)
+	}
+}
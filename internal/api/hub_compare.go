@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"eve-flipper/internal/engine"
+)
+
+const (
+	maxHubCompareItems = 200
+	maxHubCompareHubs  = 10
+)
+
+// handleHubCompare ranks candidate hub systems by what a basket of items
+// would fetch when sold into their buy orders, net of sales tax.
+// POST /api/scan/hub-compare
+// Body: {"items": [{"type_id": 34, "quantity": 100000}], "systems": ["Jita", "Amarr"], "sales_tax_percent": 3.6}
+func (s *Server) handleHubCompare(w http.ResponseWriter, r *http.Request) {
+	if !s.isReady() {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+	var req struct {
+		Items           []engine.HubBasketItem `json:"items"`
+		Systems         []string               `json:"systems"`
+		SystemIDs       []int32                `json:"system_ids"`
+		SalesTaxPercent *float64               `json:"sales_tax_percent"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+		return
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+
+	// Merge repeated types so each book is walked once per hub.
+	quantities := make(map[int32]int64)
+	basket := make([]engine.HubBasketItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		if item.TypeID <= 0 || item.Quantity <= 0 {
+			writeError(w, 400, "each item needs a positive type_id and quantity")
+			return
+		}
+		if _, ok := sdeData.Types[item.TypeID]; !ok {
+			writeErrorCode(w, 400, errCodeTypeNotFound, fmt.Sprintf("unknown type_id %d", item.TypeID))
+			return
+		}
+		if _, seen := quantities[item.TypeID]; !seen {
+			basket = append(basket, engine.HubBasketItem{TypeID: item.TypeID})
+		}
+		quantities[item.TypeID] += int64(item.Quantity)
+	}
+	if len(basket) == 0 {
+		writeError(w, 400, "items is required")
+		return
+	}
+	if len(basket) > maxHubCompareItems {
+		writeError(w, 400, fmt.Sprintf("at most %d items per comparison", maxHubCompareItems))
+		return
+	}
+	for i := range basket {
+		q := quantities[basket[i].TypeID]
+		if q > 1<<31-1 {
+			q = 1<<31 - 1
+		}
+		basket[i].Quantity = int32(q)
+	}
+
+	systemIDs := append([]int32(nil), req.SystemIDs...)
+	for _, name := range req.Systems {
+		id, ok := sdeData.SystemByName[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			writeErrorCode(w, 400, errCodeSystemNotFound, fmt.Sprintf("unknown system %q", name))
+			return
+		}
+		systemIDs = append(systemIDs, id)
+	}
+	seenSystems := make(map[int32]bool)
+	hubs := make([]engine.HubCandidate, 0, len(systemIDs))
+	for _, id := range systemIDs {
+		if seenSystems[id] {
+			continue
+		}
+		seenSystems[id] = true
+		sys, ok := sdeData.Systems[id]
+		if !ok {
+			writeErrorCode(w, 400, errCodeSystemNotFound, fmt.Sprintf("unknown system_id %d", id))
+			return
+		}
+		hubs = append(hubs, engine.HubCandidate{SystemID: sys.ID, SystemName: sys.Name, RegionID: sys.RegionID})
+	}
+	if len(hubs) == 0 {
+		writeError(w, 400, "at least one hub system is required")
+		return
+	}
+	if len(hubs) > maxHubCompareHubs {
+		writeError(w, 400, fmt.Sprintf("at most %d hubs per comparison", maxHubCompareHubs))
+		return
+	}
+
+	salesTax := 0.0
+	if req.SalesTaxPercent != nil {
+		salesTax = *req.SalesTaxPercent
+	} else if cfg := s.loadConfigForUser(userIDFromRequest(r)); cfg != nil {
+		salesTax = cfg.SalesTaxPercent
+		if cfg.SplitTradeFees {
+			salesTax = cfg.SellSalesTaxPercent
+		}
+	}
+
+	pairs := make(map[regionTypeKey]bool)
+	for _, hub := range hubs {
+		for _, item := range basket {
+			pairs[regionTypeKey{hub.RegionID, item.TypeID}] = true
+		}
+	}
+	orders := s.fetchRegionTypeOrders(pairs)
+
+	ranked := engine.CompareHubs(basket, hubs, orders, salesTax, func(typeID int32) string {
+		if t, ok := sdeData.Types[typeID]; ok {
+			return t.Name
+		}
+		return ""
+	})
+	writeJSON(w, map[string]interface{}{
+		"sales_tax_percent": salesTax,
+		"hubs":              ranked,
+	})
+}
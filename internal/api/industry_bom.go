@@ -0,0 +1,115 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"eve-flipper/internal/engine"
+)
+
+// handleAuthIndustryProjectBOM returns the project's raw material shopping
+// list: every task's inputs expanded through components that no task builds,
+// aggregated per leaf type. ?export=csv returns the same lines as CSV.
+func (s *Server) handleAuthIndustryProjectBOM(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireIndustryAuthUser(w, r)
+	if !ok {
+		return
+	}
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	export := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("export")))
+	if export != "" && export != "csv" && export != "json" {
+		writeError(w, 400, "export must be csv or json")
+		return
+	}
+
+	projectID, err := strconv.ParseInt(strings.TrimSpace(r.PathValue("projectID")), 10, 64)
+	if err != nil || projectID <= 0 {
+		writeError(w, 400, "invalid project id")
+		return
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	if !s.isReady() || sdeData == nil {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+
+	snapshot, err := s.db.GetIndustryProjectSnapshotForUser(userID, projectID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || strings.Contains(strings.ToLower(err.Error()), "project not found") {
+			writeError(w, 404, "industry project not found")
+			return
+		}
+		writeError(w, 500, "failed to get industry project snapshot")
+		return
+	}
+
+	tasks := make([]engine.BOMTask, 0, len(snapshot.Tasks))
+	for _, t := range snapshot.Tasks {
+		tasks = append(tasks, engine.BOMTask{
+			ProductTypeID: t.ProductTypeID,
+			Runs:          t.TargetRuns,
+			Activity:      t.Activity,
+		})
+	}
+	// Several copies of one blueprint may be pooled; plan with the best ME.
+	blueprintME := make(map[int32]int32, len(snapshot.Blueprints))
+	for _, bp := range snapshot.Blueprints {
+		if me, ok := blueprintME[bp.BlueprintTypeID]; !ok || bp.ME > me {
+			blueprintME[bp.BlueprintTypeID] = bp.ME
+		}
+	}
+	unitCosts := make(map[int32]float64, len(snapshot.Materials))
+	for _, m := range snapshot.Materials {
+		if m.UnitCostISK > 0 {
+			unitCosts[m.TypeID] = m.UnitCostISK
+		}
+	}
+
+	bom := engine.BuildProjectBOM(sdeData, tasks, blueprintME, unitCosts)
+
+	if export == "csv" {
+		writeBOMCSV(w, projectID, bom)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"project_id": projectID,
+		"bom":        bom,
+	})
+}
+
+func writeBOMCSV(w http.ResponseWriter, projectID int64, bom *engine.ProjectBOM) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="eve-flipper-project-%d-bom.csv"`, projectID))
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"type_id", "type_name", "quantity", "volume_m3", "unit_cost_isk", "total_cost_isk"})
+	for _, line := range bom.Lines {
+		cw.Write([]string{
+			strconv.FormatInt(int64(line.TypeID), 10),
+			line.TypeName,
+			strconv.FormatInt(line.Quantity, 10),
+			strconv.FormatFloat(line.VolumeM3, 'f', 2, 64),
+			strconv.FormatFloat(line.UnitCostISK, 'f', 2, 64),
+			strconv.FormatFloat(line.TotalCostISK, 'f', 2, 64),
+		})
+	}
+	cw.Write([]string{
+		"", "TOTAL",
+		strconv.FormatInt(bom.TotalQuantity, 10),
+		strconv.FormatFloat(bom.TotalVolumeM3, 'f', 2, 64),
+		"",
+		strconv.FormatFloat(bom.TotalCostISK, 'f', 2, 64),
+	})
+	cw.Flush()
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"eve-flipper/internal/db"
+	"eve-flipper/internal/sde"
+)
+
+func TestHandleAuthIndustryProjectBOM(t *testing.T) {
+	database := openAPITestDB(t)
+	userID := "user-api-bom"
+	srv := newAuthedIndustryTestServer(t, database, userID)
+
+	ind := sde.NewIndustryData()
+	ind.Blueprints[700001] = &sde.Blueprint{
+		BlueprintTypeID: 700001, ProductTypeID: 800001, ProductQuantity: 1,
+		Materials: []sde.BlueprintMaterial{{TypeID: 34, Quantity: 100}},
+	}
+	ind.ProductToBlueprint[800001] = 700001
+	srv.ready = true
+	srv.sdeData = &sde.Data{
+		Types: map[int32]*sde.ItemType{
+			34:     {ID: 34, Name: "Tritanium", Volume: 0.01},
+			800001: {ID: 800001, Name: "Example Item"},
+		},
+		Industry: ind,
+	}
+
+	project, err := database.CreateIndustryProjectForUser(userID, db.IndustryProjectCreateInput{Name: "BOM Project"})
+	if err != nil {
+		t.Fatalf("CreateIndustryProjectForUser: %v", err)
+	}
+	_, err = database.ApplyIndustryPlanForUser(userID, project.ID, db.IndustryPlanPatch{
+		Replace: true,
+		Tasks: []db.IndustryTaskPlanInput{
+			{Name: "Build", Activity: "manufacturing", ProductTypeID: 800001, TargetRuns: 3},
+		},
+		Materials: []db.IndustryMaterialPlanInput{
+			{TypeID: 34, TypeName: "Tritanium", RequiredQty: 270, UnitCostISK: 4, Source: "market"},
+		},
+		Blueprints: []db.IndustryBlueprintPoolInput{
+			{BlueprintTypeID: 700001, Quantity: 1, ME: 10, IsBPO: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ApplyIndustryPlanForUser: %v", err)
+	}
+
+	pid := strconv.FormatInt(project.ID, 10)
+	req := requestWithUserID(http.MethodGet, "/api/auth/industry/projects/"+pid+"/bom", nil, userID)
+	req.SetPathValue("projectID", pid)
+	rec := httptest.NewRecorder()
+	srv.handleAuthIndustryProjectBOM(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body=%s", rec.Code, rec.Body.String())
+	}
+	var out struct {
+		BOM struct {
+			Lines []struct {
+				TypeID   int32 `json:"type_id"`
+				Quantity int64 `json:"quantity"`
+			} `json:"lines"`
+			TotalCostISK float64 `json:"total_cost_isk"`
+		} `json:"bom"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// ME 10 from the synced pool: 100 × 3 × 0.9 = 270.
+	if len(out.BOM.Lines) != 1 || out.BOM.Lines[0].Quantity != 270 || out.BOM.TotalCostISK != 1080 {
+		t.Fatalf("bom = %+v, want 270 Tritanium costing 1080", out.BOM)
+	}
+
+	req = requestWithUserID(http.MethodGet, "/api/auth/industry/projects/"+pid+"/bom?export=csv", nil, userID)
+	req.SetPathValue("projectID", pid)
+	rec = httptest.NewRecorder()
+	srv.handleAuthIndustryProjectBOM(rec, req)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("Content-Type = %q, want text/csv", ct)
+	}
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(rows) != 3 || rows[1][1] != "Tritanium" || rows[1][2] != "270" || rows[2][1] != "TOTAL" {
+		t.Fatalf("csv rows = %v", rows)
+	}
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"eve-flipper/internal/auth"
+	"eve-flipper/internal/esi"
+	"eve-flipper/internal/sde"
+)
+
+// industryAssetLocation is one entry of the industry location picker: a
+// station or structure where the selected characters hold assets.
+type industryAssetLocation struct {
+	resolvedLocation
+	TypeCount     int     `json:"type_count"`
+	TotalQuantity int64   `json:"total_quantity"`
+	CharacterIDs  []int64 `json:"character_ids"`
+}
+
+type assetLocationTally struct {
+	types      map[int32]struct{}
+	quantity   int64
+	characters map[int64]struct{}
+	token      string // access token of the first character seen here
+}
+
+// tallyAssetRootLocations adds one character's assets to tally, keyed by the
+// station or structure each asset ultimately sits in. Roots that are neither
+// (items in space, asset safety) are skipped: nothing can be built there.
+func tallyAssetRootLocations(
+	tally map[int64]*assetLocationTally,
+	sdeData *sde.Data,
+	characterID int64,
+	token string,
+	assets []esi.CharacterAsset,
+) {
+	byItemID := make(map[int64]esi.CharacterAsset, len(assets))
+	for _, a := range assets {
+		if a.ItemID > 0 {
+			byItemID[a.ItemID] = a
+		}
+	}
+	for _, a := range assets {
+		if a.TypeID <= 0 {
+			continue
+		}
+		root := resolveAssetRootLocationID(a.LocationID, byItemID)
+		if root <= 0 {
+			continue
+		}
+		if _, ok := sdeData.Stations[root]; !ok && !isStructureLocationID(root) {
+			continue
+		}
+		t := tally[root]
+		if t == nil {
+			t = &assetLocationTally{
+				types:      make(map[int32]struct{}),
+				characters: make(map[int64]struct{}),
+				token:      token,
+			}
+			tally[root] = t
+		}
+		t.types[a.TypeID] = struct{}{}
+		quantity := a.Quantity
+		if quantity <= 0 {
+			quantity = 1
+		}
+		t.quantity += quantity
+		t.characters[characterID] = struct{}{}
+	}
+}
+
+// handleAuthIndustryLocations lists the stations and structures where the
+// selected characters hold assets, with resolved names, so industry views
+// can offer a location picker instead of a raw location ID field.
+// GET /api/auth/industry/locations?scope=all|single&character_id=...
+func (s *Server) handleAuthIndustryLocations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireIndustryAuthUser(w, r)
+	if !ok {
+		return
+	}
+	characterID, allScope, err := parseAuthScope(r)
+	if err != nil {
+		writeError(w, 400, err.Error())
+		return
+	}
+	if scope := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("scope"))); scope != "" && scope != "single" && scope != "all" {
+		writeError(w, 400, "scope must be single or all")
+		return
+	}
+
+	selectedSessions, err := s.authSessionsForScope(userID, characterID, allScope, true)
+	if err != nil {
+		if strings.Contains(err.Error(), "not logged in") {
+			writeError(w, 401, err.Error())
+		} else {
+			writeError(w, 400, err.Error())
+		}
+		return
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	if !s.isReady() || sdeData == nil {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+
+	tally := make(map[int64]*assetLocationTally)
+	charactersUsed := 0
+	for _, sess := range selectedSessions {
+		token, tokenErr := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
+		if tokenErr != nil {
+			log.Printf("[AUTH] Industry locations token error (%s): %v", sess.CharacterName, tokenErr)
+			if !allScope {
+				writeError(w, 401, tokenErr.Error())
+				return
+			}
+			continue
+		}
+		if scopeErr := requireScope(sess, auth.ScopeReadAssets); scopeErr != nil {
+			log.Printf("[AUTH] Industry locations skipped: %v", scopeErr)
+			if !allScope {
+				writeScopeError(w, scopeErr)
+				return
+			}
+			continue
+		}
+		assets, fetchErr := s.esi.GetCharacterAssets(sess.CharacterID, token)
+		if fetchErr != nil {
+			log.Printf("[AUTH] Industry locations assets error (%s): %v", sess.CharacterName, fetchErr)
+			if !allScope {
+				writeErrorCode(w, 502, errCodeESI, "failed to fetch assets: "+fetchErr.Error())
+				return
+			}
+			continue
+		}
+		tallyAssetRootLocations(tally, sdeData, sess.CharacterID, token, assets)
+		charactersUsed++
+	}
+
+	// Structure names depend on docking access, so each structure is named
+	// with the token of a character that actually has assets there.
+	idsByToken := make(map[string][]int64)
+	for id, t := range tally {
+		idsByToken[t.token] = append(idsByToken[t.token], id)
+	}
+	locations := make([]industryAssetLocation, 0, len(tally))
+	for token, ids := range idsByToken {
+		for _, loc := range s.resolveLocationIDs(sdeData, ids, token) {
+			t := tally[loc.LocationID]
+			characterIDs := make([]int64, 0, len(t.characters))
+			for id := range t.characters {
+				characterIDs = append(characterIDs, id)
+			}
+			sort.Slice(characterIDs, func(i, j int) bool { return characterIDs[i] < characterIDs[j] })
+			locations = append(locations, industryAssetLocation{
+				resolvedLocation: loc,
+				TypeCount:        len(t.types),
+				TotalQuantity:    t.quantity,
+				CharacterIDs:     characterIDs,
+			})
+		}
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].TypeCount != locations[j].TypeCount {
+			return locations[i].TypeCount > locations[j].TypeCount
+		}
+		if locations[i].Name != locations[j].Name {
+			return locations[i].Name < locations[j].Name
+		}
+		return locations[i].LocationID < locations[j].LocationID
+	})
+
+	scope := "single"
+	if allScope {
+		scope = "all"
+	}
+	writeJSON(w, map[string]interface{}{
+		"locations":       locations,
+		"characters_used": charactersUsed,
+		"scope":           scope,
+	})
+}
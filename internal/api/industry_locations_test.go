@@ -0,0 +1,44 @@
+package api
+
+import (
+	"testing"
+
+	"eve-flipper/internal/esi"
+	"eve-flipper/internal/sde"
+)
+
+func TestTallyAssetRootLocations(t *testing.T) {
+	const (
+		jita      = int64(60003760)
+		structure = int64(1035466617946)
+		system    = int64(30000142)
+	)
+	data := &sde.Data{
+		Stations: map[int64]*sde.Station{jita: {ID: jita, SystemID: 30000142}},
+	}
+	tally := make(map[int64]*assetLocationTally)
+
+	tallyAssetRootLocations(tally, data, 1, "tok-1", []esi.CharacterAsset{
+		{ItemID: 100, TypeID: 17366, LocationID: jita, Quantity: 1}, // container in the hangar
+		{ItemID: 101, TypeID: 34, LocationID: 100, Quantity: 5000},  // inside the container
+		{ItemID: 102, TypeID: 35, LocationID: jita, Quantity: 200},
+		{ItemID: 103, TypeID: 34, LocationID: structure, Quantity: 10},
+		{ItemID: 104, TypeID: 587, LocationID: system, Quantity: 1}, // ship in space
+	})
+	tallyAssetRootLocations(tally, data, 2, "tok-2", []esi.CharacterAsset{
+		{ItemID: 200, TypeID: 34, LocationID: jita, Quantity: 1000},
+		{ItemID: 201, TypeID: 36, LocationID: structure, Quantity: -1},
+	})
+
+	if len(tally) != 2 {
+		t.Fatalf("locations = %d, want 2 (space is skipped)", len(tally))
+	}
+	j := tally[jita]
+	if len(j.types) != 3 || j.quantity != 6201 || len(j.characters) != 2 || j.token != "tok-1" {
+		t.Fatalf("jita tally = types %d qty %d chars %d token %q", len(j.types), j.quantity, len(j.characters), j.token)
+	}
+	st := tally[structure]
+	if len(st.types) != 2 || st.quantity != 11 || len(st.characters) != 2 {
+		t.Fatalf("structure tally = types %d qty %d chars %d", len(st.types), st.quantity, len(st.characters))
+	}
+}
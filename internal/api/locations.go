@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"eve-flipper/internal/sde"
+)
+
+const maxResolveLocations = 500
+
+// resolvedLocation describes one location ID from a scan result or order.
+// Kind is "station" for NPC stations, "structure" for player structures and
+// "unknown" otherwise. Unresolved IDs keep a "Location N" / "Structure N"
+// placeholder name with Resolved false.
+type resolvedLocation struct {
+	LocationID int64  `json:"location_id"`
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	SystemID   int32  `json:"system_id,omitempty"`
+	SystemName string `json:"system_name,omitempty"`
+	RegionID   int32  `json:"region_id,omitempty"`
+	RegionName string `json:"region_name,omitempty"`
+	Resolved   bool   `json:"resolved"`
+}
+
+// handleResolveLocations maps location IDs to names and their system/region.
+// NPC stations come from the SDE; player structures use the caller's SSO
+// token when one is available and the shared name caches otherwise.
+// POST /api/locations/resolve
+// Body: {"location_ids": [60003760, 1035466617946]}
+func (s *Server) handleResolveLocations(w http.ResponseWriter, r *http.Request) {
+	if !s.isReady() {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+	var req struct {
+		LocationIDs []int64 `json:"location_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+		return
+	}
+	seen := make(map[int64]bool, len(req.LocationIDs))
+	ids := make([]int64, 0, len(req.LocationIDs))
+	for _, id := range req.LocationIDs {
+		if id <= 0 {
+			writeError(w, 400, fmt.Sprintf("invalid location_id %d", id))
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		writeError(w, 400, "location_ids is required")
+		return
+	}
+	if len(ids) > maxResolveLocations {
+		writeError(w, 400, fmt.Sprintf("at most %d location_ids per request", maxResolveLocations))
+		return
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+
+	accessToken := ""
+	if s.esi != nil && s.sessions != nil {
+		if token, err := s.sessions.EnsureValidTokenForUser(s.sso, userIDFromRequest(r)); err == nil {
+			accessToken = token
+		}
+	}
+	out := s.resolveLocationIDs(sdeData, ids, accessToken)
+	writeJSON(w, map[string]interface{}{"locations": out})
+}
+
+// resolveLocationIDs resolves ids in order. Player structures are named with
+// accessToken when it is set and from the shared name caches otherwise.
+func (s *Server) resolveLocationIDs(sdeData *sde.Data, ids []int64, accessToken string) []resolvedLocation {
+	structures := make(map[int64]bool)
+	for _, id := range ids {
+		if _, ok := sdeData.Stations[id]; !ok && isStructureLocationID(id) {
+			structures[id] = true
+		}
+	}
+	if len(structures) > 0 && s.esi != nil && accessToken != "" {
+		s.esi.PrefetchStructureNames(structures, accessToken)
+	}
+
+	out := make([]resolvedLocation, 0, len(ids))
+	for _, id := range ids {
+		loc := resolveLocation(sdeData, id)
+		if structures[id] && s.esi != nil {
+			if accessToken != "" {
+				loc.Name = s.esi.StructureName(id, accessToken)
+			} else if name := s.esi.EVERefStructureName(id); name != "" {
+				loc.Name = name
+			} else {
+				loc.Name = s.esi.StationName(id)
+			}
+			loc.Resolved = !isPlaceholderLocationName(loc.Name)
+			if systemID, ok := s.esi.StructureSystemID(id); ok {
+				fillLocationSystem(sdeData, &loc, systemID)
+			}
+		}
+		out = append(out, loc)
+	}
+	return out
+}
+
+// resolveLocation answers what the SDE alone knows about a location ID.
+func resolveLocation(data *sde.Data, locationID int64) resolvedLocation {
+	loc := resolvedLocation{
+		LocationID: locationID,
+		Name:       fmt.Sprintf("Location %d", locationID),
+		Kind:       "unknown",
+	}
+	if st, ok := data.Stations[locationID]; ok {
+		loc.Kind = "station"
+		loc.Resolved = true
+		if st.Name != "" {
+			loc.Name = st.Name
+		}
+		fillLocationSystem(data, &loc, st.SystemID)
+		return loc
+	}
+	if isStructureLocationID(locationID) {
+		loc.Kind = "structure"
+		loc.Name = fmt.Sprintf("Structure %d", locationID)
+	}
+	return loc
+}
+
+func fillLocationSystem(data *sde.Data, loc *resolvedLocation, systemID int32) {
+	sys, ok := data.Systems[systemID]
+	if !ok {
+		return
+	}
+	loc.SystemID = sys.ID
+	loc.SystemName = sys.Name
+	loc.RegionID = sys.RegionID
+	if region, ok := data.Regions[sys.RegionID]; ok {
+		loc.RegionName = region.Name
+	}
+}
+
+// isStructureLocationID mirrors the ESI client's player-structure ID range.
+func isStructureLocationID(id int64) bool {
+	return id >= 100000000
+}
+
+func isPlaceholderLocationName(name string) bool {
+	return name == "" || strings.HasPrefix(name, "Structure ") || strings.HasPrefix(name, "Location ")
+}
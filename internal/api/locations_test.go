@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"eve-flipper/internal/sde"
+)
+
+func TestHandleResolveLocations(t *testing.T) {
+	srv := &Server{
+		ready: true,
+		sdeData: &sde.Data{
+			Stations: map[int64]*sde.Station{
+				60003760: {ID: 60003760, Name: "Jita IV - Moon 4 - Caldari Navy Assembly Plant", SystemID: 30000142},
+			},
+			Systems: map[int32]*sde.SolarSystem{
+				30000142: {ID: 30000142, Name: "Jita", RegionID: 10000002},
+			},
+			Regions: map[int32]*sde.Region{
+				10000002: {ID: 10000002, Name: "The Forge"},
+			},
+		},
+	}
+
+	body := `{"location_ids":[60003760,1035466617946,42,60003760]}`
+	rec := httptest.NewRecorder()
+	srv.handleResolveLocations(rec, httptest.NewRequest(http.MethodPost, "/api/locations/resolve", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Locations []resolvedLocation `json:"locations"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Locations) != 3 {
+		t.Fatalf("locations = %+v, want 3 (duplicates merged)", resp.Locations)
+	}
+
+	station := resp.Locations[0]
+	if !station.Resolved || station.Kind != "station" || station.SystemName != "Jita" || station.RegionName != "The Forge" {
+		t.Errorf("station = %+v", station)
+	}
+	structure := resp.Locations[1]
+	if structure.Resolved || structure.Kind != "structure" || structure.Name != "Structure 1035466617946" {
+		t.Errorf("structure without a session = %+v, want unresolved placeholder", structure)
+	}
+	unknown := resp.Locations[2]
+	if unknown.Resolved || unknown.Kind != "unknown" || unknown.Name != "Location 42" {
+		t.Errorf("unknown = %+v, want unresolved placeholder", unknown)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleResolveLocations(rec, httptest.NewRequest(http.MethodPost, "/api/locations/resolve", strings.NewReader(`{"location_ids":[]}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("empty list status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"eve-flipper/internal/engine"
+	"eve-flipper/internal/esi"
+)
+
+// npcOrderView is one sell order in the NPC-orders view, tagged by origin.
+type npcOrderView struct {
+	OrderID      int64   `json:"order_id"`
+	LocationID   int64   `json:"location_id"`
+	LocationName string  `json:"location_name"`
+	SystemID     int32   `json:"system_id"`
+	Price        float64 `json:"price"`
+	VolumeRemain int32   `json:"volume_remain"`
+	Duration     int32   `json:"duration"`
+	IsNPC        bool    `json:"is_npc"`
+}
+
+// handleMarketNPCOrders lists the sell side of one type in a region with NPC-seeded
+// orders separated from player orders, so users can see when an "arbitrage"
+// is really just NPC supply.
+func (s *Server) handleMarketNPCOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	regionID, err := strconv.Atoi(q.Get("region_id"))
+	if err != nil || regionID <= 0 {
+		writeError(w, 400, "invalid region_id")
+		return
+	}
+	typeID, err := strconv.Atoi(q.Get("type_id"))
+	if err != nil || typeID <= 0 {
+		writeError(w, 400, "invalid type_id")
+		return
+	}
+
+	orders, err := s.esi.FetchRegionOrdersByType(int32(regionID), int32(typeID))
+	if err != nil {
+		log.Printf("[API] market/npc-orders FetchRegionOrdersByType: %v", err)
+		writeError(w, 502, "failed to fetch market orders")
+		return
+	}
+
+	locationIDs := make(map[int64]bool)
+	npcOrders := make([]npcOrderView, 0)
+	playerOrders := make([]npcOrderView, 0)
+	for _, o := range orders {
+		if o.IsBuyOrder {
+			continue
+		}
+		locationIDs[o.LocationID] = true
+		view := npcOrderView{
+			OrderID:      o.OrderID,
+			LocationID:   o.LocationID,
+			SystemID:     o.SystemID,
+			Price:        o.Price,
+			VolumeRemain: o.VolumeRemain,
+			Duration:     o.Duration,
+			IsNPC:        engine.IsNPCSeededOrder(o),
+		}
+		if view.IsNPC {
+			npcOrders = append(npcOrders, view)
+		} else {
+			playerOrders = append(playerOrders, view)
+		}
+	}
+	s.esi.PrefetchStationNames(locationIDs)
+	for _, list := range [][]npcOrderView{npcOrders, playerOrders} {
+		for i := range list {
+			list[i].LocationName = s.esi.StationName(list[i].LocationID)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Price < list[j].Price })
+	}
+
+	typeName := ""
+	s.mu.RLock()
+	if s.sdeData != nil {
+		if t, ok := s.sdeData.Types[int32(typeID)]; ok {
+			typeName = t.Name
+		}
+	}
+	s.mu.RUnlock()
+
+	resp := struct {
+		RegionID        int32          `json:"region_id"`
+		TypeID          int32          `json:"type_id"`
+		TypeName        string         `json:"type_name"`
+		NPCOrders       []npcOrderView `json:"npc_orders"`
+		NPCCount        int            `json:"npc_count"`
+		PlayerCount     int            `json:"player_count"`
+		BestNPCPrice    float64        `json:"best_npc_price,omitempty"`
+		BestPlayerPrice float64        `json:"best_player_price,omitempty"`
+		PlayerOrders    []npcOrderView `json:"player_orders,omitempty"`
+	}{
+		RegionID:    int32(regionID),
+		TypeID:      int32(typeID),
+		TypeName:    typeName,
+		NPCOrders:   npcOrders,
+		NPCCount:    len(npcOrders),
+		PlayerCount: len(playerOrders),
+	}
+	if len(npcOrders) > 0 {
+		resp.BestNPCPrice = npcOrders[0].Price
+	}
+	if len(playerOrders) > 0 {
+		resp.BestPlayerPrice = playerOrders[0].Price
+	}
+	if q.Get("include_player") == "true" {
+		resp.PlayerOrders = playerOrders
+	}
+	writeJSON(w, resp)
+}
+
+// Region summaries are cached briefly: the order book behind them refreshes
+// every five minutes, and summing a large region's orders is not free.
+const (
+	regionSummaryCacheTTL   = 2 * time.Minute
+	regionSummaryDefaultTop = 10
+	regionSummaryMaxTop     = 50
+)
+
+// regionTypeActivity is one item's share of a region's order book.
+type regionTypeActivity struct {
+	TypeID     int32   `json:"type_id"`
+	TypeName   string  `json:"type_name"`
+	OrderCount int     `json:"order_count"`
+	BuyOrders  int     `json:"buy_orders"`
+	SellOrders int     `json:"sell_orders"`
+	BuyISK     float64 `json:"buy_isk"`
+	SellISK    float64 `json:"sell_isk"`
+}
+
+// regionMarketSummary is the market pulse of one region. ISK values are
+// price times remaining volume summed over open orders.
+type regionMarketSummary struct {
+	RegionID    int32                `json:"region_id"`
+	RegionName  string               `json:"region_name"`
+	TotalOrders int                  `json:"total_orders"`
+	BuyOrders   int                  `json:"buy_orders"`
+	SellOrders  int                  `json:"sell_orders"`
+	BuyISK      float64              `json:"buy_isk"`
+	SellISK     float64              `json:"sell_isk"`
+	TypeCount   int                  `json:"type_count"`
+	TopTypes    []regionTypeActivity `json:"top_types"`
+	GeneratedAt string               `json:"generated_at"`
+	Cached      bool                 `json:"cached"`
+}
+
+type regionSummaryEntry struct {
+	summary   regionMarketSummary
+	expiresAt time.Time
+}
+
+// summarizeRegionOrders totals the order book and keeps the regionSummaryMaxTop
+// most active types, busiest first (ties by type ID).
+func summarizeRegionOrders(regionID int32, orders []esi.MarketOrder) regionMarketSummary {
+	sum := regionMarketSummary{RegionID: regionID}
+	byType := make(map[int32]*regionTypeActivity)
+	for _, o := range orders {
+		isk := o.Price * float64(o.VolumeRemain)
+		t := byType[o.TypeID]
+		if t == nil {
+			t = &regionTypeActivity{TypeID: o.TypeID}
+			byType[o.TypeID] = t
+		}
+		t.OrderCount++
+		if o.IsBuyOrder {
+			sum.BuyOrders++
+			sum.BuyISK += isk
+			t.BuyOrders++
+			t.BuyISK += isk
+		} else {
+			sum.SellOrders++
+			sum.SellISK += isk
+			t.SellOrders++
+			t.SellISK += isk
+		}
+	}
+	sum.TotalOrders = sum.BuyOrders + sum.SellOrders
+	sum.TypeCount = len(byType)
+
+	top := make([]regionTypeActivity, 0, len(byType))
+	for _, t := range byType {
+		top = append(top, *t)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].OrderCount != top[j].OrderCount {
+			return top[i].OrderCount > top[j].OrderCount
+		}
+		return top[i].TypeID < top[j].TypeID
+	})
+	if len(top) > regionSummaryMaxTop {
+		top = top[:regionSummaryMaxTop]
+	}
+	sum.TopTypes = top
+	return sum
+}
+
+func (s *Server) cachedRegionSummary(regionID int32) (regionMarketSummary, bool) {
+	s.regionSummaryMu.Lock()
+	defer s.regionSummaryMu.Unlock()
+	entry, ok := s.regionSummaryCache[regionID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return regionMarketSummary{}, false
+	}
+	return entry.summary, true
+}
+
+func (s *Server) storeRegionSummary(summary regionMarketSummary) {
+	now := time.Now()
+	s.regionSummaryMu.Lock()
+	defer s.regionSummaryMu.Unlock()
+	if s.regionSummaryCache == nil {
+		s.regionSummaryCache = make(map[int32]regionSummaryEntry)
+	}
+	for k, entry := range s.regionSummaryCache {
+		if now.After(entry.expiresAt) {
+			delete(s.regionSummaryCache, k)
+		}
+	}
+	s.regionSummaryCache[summary.RegionID] = regionSummaryEntry{summary: summary, expiresAt: now.Add(regionSummaryCacheTTL)}
+}
+
+// handleRegionMarketSummary returns order counts, ISK on each side of the
+// book and the most active types for a region, without running a scan.
+// ?top= picks how many types to list (default 10, at most 50).
+func (s *Server) handleRegionMarketSummary(w http.ResponseWriter, r *http.Request) {
+	regionID64, err := strconv.ParseInt(r.PathValue("regionID"), 10, 32)
+	if err != nil || regionID64 <= 0 {
+		writeError(w, 400, "invalid regionID")
+		return
+	}
+	regionID := int32(regionID64)
+	top := regionSummaryDefaultTop
+	if raw := r.URL.Query().Get("top"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 || v > regionSummaryMaxTop {
+			writeError(w, 400, fmt.Sprintf("top must be between 1 and %d", regionSummaryMaxTop))
+			return
+		}
+		top = v
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	if sdeData == nil {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+	region, ok := sdeData.Regions[regionID]
+	if !ok {
+		writeErrorCode(w, 404, errCodeRegionNotFound, "region not found")
+		return
+	}
+
+	summary, cached := s.cachedRegionSummary(regionID)
+	if !cached {
+		orders, err := s.esi.FetchRegionOrders(regionID, "all")
+		if err != nil {
+			log.Printf("[API] market/region summary FetchRegionOrders(%d): %v", regionID, err)
+			writeError(w, 502, "failed to fetch market orders")
+			return
+		}
+		summary = summarizeRegionOrders(regionID, orders)
+		summary.RegionName = region.Name
+		for i := range summary.TopTypes {
+			if t, ok := sdeData.Types[summary.TopTypes[i].TypeID]; ok {
+				summary.TopTypes[i].TypeName = t.Name
+			}
+		}
+		summary.GeneratedAt = time.Now().UTC().Format(time.RFC3339)
+		s.storeRegionSummary(summary)
+	}
+
+	summary.Cached = cached
+	if len(summary.TopTypes) > top {
+		summary.TopTypes = summary.TopTypes[:top]
+	}
+	writeJSON(w, summary)
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"eve-flipper/internal/engine"
+)
+
+// marketDisabledEntry is one built-in market-disabled type as shown to a user.
+type marketDisabledEntry struct {
+	TypeID   int32  `json:"type_id"`
+	TypeName string `json:"type_name"`
+}
+
+// marketDisabledOverrideRow is a type the user added, with its name.
+type marketDisabledOverrideRow struct {
+	TypeID    int32  `json:"type_id"`
+	TypeName  string `json:"type_name"`
+	CreatedAt string `json:"created_at"`
+}
+
+// isMarketDisabledForUser reports whether typeID is hidden from the user's
+// results: the built-in list plus the types the user added. Overrides can only
+// add types; the scanners drop built-in entries before results reach the API.
+func (s *Server) isMarketDisabledForUser(userID string, typeID int32) bool {
+	if engine.IsMarketDisabledTypeID(typeID) {
+		return true
+	}
+	return s.marketDisabledOverridesForUser(userID)[typeID]
+}
+
+// marketDisabledOverridesForUser returns the user's overrides (type ID →
+// disabled), caching them until the user changes one. Load errors are logged
+// and fall back to the built-in list without caching.
+func (s *Server) marketDisabledOverridesForUser(userID string) map[int32]bool {
+	if s.db == nil {
+		return nil
+	}
+	s.marketDisabledMu.Lock()
+	defer s.marketDisabledMu.Unlock()
+	if overrides, ok := s.marketDisabledOverrides[userID]; ok {
+		return overrides
+	}
+	list, err := s.db.ListMarketDisabledOverridesForUser(userID)
+	if err != nil {
+		log.Printf("[API] market-disabled overrides for %s: %v", userID, err)
+		return nil
+	}
+	overrides := make(map[int32]bool, len(list))
+	for _, o := range list {
+		overrides[o.TypeID] = o.Disabled
+	}
+	if s.marketDisabledOverrides == nil {
+		s.marketDisabledOverrides = make(map[string]map[int32]bool)
+	}
+	s.marketDisabledOverrides[userID] = overrides
+	return overrides
+}
+
+func (s *Server) invalidateMarketDisabledOverrides(userID string) {
+	s.marketDisabledMu.Lock()
+	delete(s.marketDisabledOverrides, userID)
+	s.marketDisabledMu.Unlock()
+}
+
+// handleGetMarketDisabled returns the built-in market-disabled list with type
+// names and the user's overrides.
+// GET /api/market-disabled
+func (s *Server) handleGetMarketDisabled(w http.ResponseWriter, r *http.Request) {
+	s.writeMarketDisabled(w, userIDFromRequest(r))
+}
+
+// handleSetMarketDisabledOverride hides one more type from the user's
+// results. "add" is the only action: built-in entries are filtered inside the
+// scanners and cannot be re-enabled per user. Undo an add with DELETE.
+// POST /api/market-disabled/overrides
+// Body: {"type_id": 44992, "action": "add"}
+func (s *Server) handleSetMarketDisabledOverride(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	var body struct {
+		TypeID int32  `json:"type_id"`
+		Action string `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+		return
+	}
+	if body.TypeID <= 0 {
+		writeError(w, 400, "type_id is required")
+		return
+	}
+	if body.Action != "add" {
+		writeError(w, 400, `action must be "add"`)
+		return
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	if sdeData != nil {
+		if _, ok := sdeData.Types[body.TypeID]; !ok {
+			writeErrorCode(w, 400, errCodeTypeNotFound, fmt.Sprintf("unknown type_id %d", body.TypeID))
+			return
+		}
+	}
+
+	userID := userIDFromRequest(r)
+	var err error
+	if !engine.IsMarketDisabledTypeID(body.TypeID) {
+		err = s.db.SetMarketDisabledOverrideForUser(userID, body.TypeID, true)
+	}
+	s.invalidateMarketDisabledOverrides(userID)
+	if err != nil {
+		writeError(w, 500, "failed to save override")
+		return
+	}
+	s.writeMarketDisabled(w, userID)
+}
+
+func (s *Server) handleDeleteMarketDisabledOverride(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("typeID"), 10, 32)
+	if err != nil || id <= 0 {
+		writeError(w, 400, "invalid type_id")
+		return
+	}
+	userID := userIDFromRequest(r)
+	deleted, err := s.db.DeleteMarketDisabledOverrideForUser(userID, int32(id))
+	s.invalidateMarketDisabledOverrides(userID)
+	if err != nil {
+		writeError(w, 500, "failed to delete override")
+		return
+	}
+	if !deleted {
+		writeError(w, 404, "override not found")
+		return
+	}
+	s.writeMarketDisabled(w, userID)
+}
+
+func (s *Server) writeMarketDisabled(w http.ResponseWriter, userID string) {
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	typeName := func(typeID int32) string {
+		if sdeData != nil {
+			if t, ok := sdeData.Types[typeID]; ok {
+				return t.Name
+			}
+		}
+		return ""
+	}
+
+	builtin := make([]marketDisabledEntry, 0)
+	for _, id := range engine.MarketDisabledTypeIDs() {
+		builtin = append(builtin, marketDisabledEntry{
+			TypeID:   id,
+			TypeName: typeName(id),
+		})
+	}
+	overrides := make([]marketDisabledOverrideRow, 0)
+	if s.db != nil {
+		list, err := s.db.ListMarketDisabledOverridesForUser(userID)
+		if err != nil {
+			writeError(w, 500, "failed to list overrides")
+			return
+		}
+		for _, o := range list {
+			if !o.Disabled {
+				continue
+			}
+			overrides = append(overrides, marketDisabledOverrideRow{
+				TypeID:    o.TypeID,
+				TypeName:  typeName(o.TypeID),
+				CreatedAt: o.CreatedAt,
+			})
+		}
+	}
+	writeJSON(w, map[string]interface{}{
+		"builtin":   builtin,
+		"overrides": overrides,
+	})
+}
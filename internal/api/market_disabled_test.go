@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"eve-flipper/internal/engine"
+)
+
+func TestMarketDisabledOverrides(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+
+	post := func(userID, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.handleSetMarketDisabledOverride(rec, requestWithUserID(http.MethodPost, "/api/market-disabled/overrides", strings.NewReader(body), userID))
+		return rec
+	}
+
+	if rec := post("user-a", `{"type_id":34,"action":"add"}`); rec.Code != http.StatusOK {
+		t.Fatalf("add status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if !srv.isMarketDisabledForUser("user-a", 34) {
+		t.Error("added type not disabled for user-a")
+	}
+	if srv.isMarketDisabledForUser("user-b", 34) {
+		t.Error("user-a's override leaked to user-b")
+	}
+	rows := srv.filterFlipResultsMarketDisabled("user-a", []engine.FlipResult{{TypeID: 34}, {TypeID: 35}})
+	if len(rows) != 1 || rows[0].TypeID != 35 {
+		t.Errorf("filtered rows = %+v, want only type 35", rows)
+	}
+
+	// Built-in entries are filtered by the scanners; they cannot be removed.
+	rec := post("user-a", `{"type_id":`+strconv.Itoa(int(engine.MPTCTypeID))+`,"action":"remove"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("remove status = %d, want 400", rec.Code)
+	}
+	if !srv.isMarketDisabledForUser("user-a", engine.MPTCTypeID) {
+		t.Error("built-in entry re-enabled")
+	}
+	if rec := post("user-a", `{"type_id":34,"action":"toggle"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad action status = %d, want 400", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleGetMarketDisabled(rec, requestWithUserID(http.MethodGet, "/api/market-disabled", nil, "user-a"))
+	var list struct {
+		Builtin   []marketDisabledEntry       `json:"builtin"`
+		Overrides []marketDisabledOverrideRow `json:"overrides"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list.Overrides) != 1 || list.Overrides[0].TypeID != 34 {
+		t.Errorf("overrides = %+v, want type 34", list.Overrides)
+	}
+	if len(list.Builtin) == 0 || list.Builtin[0].TypeID != engine.MPTCTypeID {
+		t.Errorf("builtin = %+v, want MPTC", list.Builtin)
+	}
+
+	// Adding a built-in type stores nothing; deleting an add re-enables it.
+	if rec := post("user-a", `{"type_id":`+strconv.Itoa(int(engine.MPTCTypeID))+`,"action":"add"}`); rec.Code != http.StatusOK {
+		t.Fatalf("add built-in status = %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	req := requestWithUserID(http.MethodDelete, "/api/market-disabled/overrides/34", nil, "user-a")
+	req.SetPathValue("typeID", "34")
+	srv.handleDeleteMarketDisabledOverride(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete status = %d", rec.Code)
+	}
+	if srv.isMarketDisabledForUser("user-a", 34) {
+		t.Error("type 34 still disabled after delete")
+	}
+	if got, _ := database.ListMarketDisabledOverridesForUser("user-a"); len(got) != 0 {
+		t.Errorf("stored overrides = %+v, want none", got)
+	}
+}
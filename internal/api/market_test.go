@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"eve-flipper/internal/esi"
+	"eve-flipper/internal/sde"
+)
+
+func TestSummarizeRegionOrders(t *testing.T) {
+	orders := []esi.MarketOrder{
+		{TypeID: 34, Price: 5, VolumeRemain: 1000, IsBuyOrder: true},
+		{TypeID: 34, Price: 6, VolumeRemain: 500},
+		{TypeID: 34, Price: 7, VolumeRemain: 100},
+		{TypeID: 35, Price: 10, VolumeRemain: 10, IsBuyOrder: true},
+		{TypeID: 36, Price: 20, VolumeRemain: 1},
+		{TypeID: 35, Price: 12, VolumeRemain: 5},
+	}
+	sum := summarizeRegionOrders(10000002, orders)
+
+	if sum.TotalOrders != 6 || sum.BuyOrders != 2 || sum.SellOrders != 4 || sum.TypeCount != 3 {
+		t.Fatalf("counts = %+v", sum)
+	}
+	if sum.BuyISK != 5100 || sum.SellISK != 3780 {
+		t.Fatalf("isk buy/sell = %v/%v, want 5100/3780", sum.BuyISK, sum.SellISK)
+	}
+	want := []int32{34, 35, 36}
+	for i, typeID := range want {
+		if sum.TopTypes[i].TypeID != typeID {
+			t.Fatalf("top[%d] = %d, want %d (%+v)", i, sum.TopTypes[i].TypeID, typeID, sum.TopTypes)
+		}
+	}
+	if tt := sum.TopTypes[0]; tt.OrderCount != 3 || tt.BuyOrders != 1 || tt.SellOrders != 2 || tt.SellISK != 3700 {
+		t.Fatalf("tritanium activity = %+v", tt)
+	}
+
+	many := make([]esi.MarketOrder, 0, regionSummaryMaxTop+5)
+	for i := 0; i < regionSummaryMaxTop+5; i++ {
+		many = append(many, esi.MarketOrder{TypeID: int32(i + 1), Price: 1, VolumeRemain: 1})
+	}
+	if got := len(summarizeRegionOrders(1, many).TopTypes); got != regionSummaryMaxTop {
+		t.Fatalf("top types = %d, want capped at %d", got, regionSummaryMaxTop)
+	}
+}
+
+func TestHandleRegionMarketSummary(t *testing.T) {
+	srv := &Server{sdeData: &sde.Data{
+		Regions: map[int32]*sde.Region{10000002: {ID: 10000002, Name: "The Forge"}},
+	}}
+	seeded := summarizeRegionOrders(10000002, []esi.MarketOrder{
+		{TypeID: 34, Price: 5, VolumeRemain: 10},
+		{TypeID: 34, Price: 5, VolumeRemain: 10},
+		{TypeID: 35, Price: 5, VolumeRemain: 10},
+		{TypeID: 36, Price: 5, VolumeRemain: 10},
+	})
+	seeded.RegionName = "The Forge"
+	srv.storeRegionSummary(seeded)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /api/market/region/{regionID}/summary", srv.handleRegionMarketSummary)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	rr := get("/api/market/region/10000002/summary?top=2")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp regionMarketSummary
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !resp.Cached || resp.RegionName != "The Forge" || resp.TotalOrders != 4 || len(resp.TopTypes) != 2 {
+		t.Fatalf("response = %+v", resp)
+	}
+	// Trimming one response must not shrink the cached list.
+	if cached, _ := srv.cachedRegionSummary(10000002); len(cached.TopTypes) != 3 {
+		t.Fatalf("cached top types = %d, want 3", len(cached.TopTypes))
+	}
+
+	for path, want := range map[string]int{
+		"/api/market/region/10000043/summary":                                            http.StatusNotFound,
+		"/api/market/region/abc/summary":                                                 http.StatusBadRequest,
+		"/api/market/region/10000002/summary?top=0":                                      http.StatusBadRequest,
+		fmt.Sprintf("/api/market/region/10000002/summary?top=%d", regionSummaryMaxTop+1): http.StatusBadRequest,
+	} {
+		if rr := get(path); rr.Code != want {
+			t.Errorf("%s: status = %d, want %d", path, rr.Code, want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"eve-flipper/internal/metrics"
+)
+
+// metricsPublicEnv exposes /metrics to non-loopback clients when truthy.
+const metricsPublicEnv = "EVEFLIPPER_METRICS_PUBLIC"
+
+// handleMetrics serves Prometheus text-format metrics. Only loopback
+// clients are allowed unless EVEFLIPPER_METRICS_PUBLIC is set.
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if !metricsAllowed(r) {
+		writeError(w, 403, "metrics are only available from localhost")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metrics.WriteText(w)
+}
+
+func metricsAllowed(r *http.Request) bool {
+	if v, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(metricsPublicEnv))); err == nil && v {
+		return true
+	}
+	return isLoopbackRequest(r)
+}
+
+// instrumentScan counts scans of one type and records how long the handler
+// (including any streamed response) took.
+func instrumentScan(scanType string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		metrics.ScansTotal.Inc(scanType)
+		defer metrics.ScanDuration.ObserveSince(start, scanType)
+		next(w, r)
+	}
+}
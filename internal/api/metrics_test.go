@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"eve-flipper/internal/metrics"
+)
+
+func TestHandleMetricsLoopbackOnly(t *testing.T) {
+	t.Setenv(metricsPublicEnv, "")
+	handler := instrumentScan("test_scan", func(w http.ResponseWriter, r *http.Request) {})
+	before := metrics.ScansTotal.Value("test_scan")
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/scan", nil))
+	if got := metrics.ScansTotal.Value("test_scan"); got != before+1 {
+		t.Fatalf("scans = %v, want %v", got, before+1)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.RemoteAddr = "127.0.0.1:50000"
+	rec := httptest.NewRecorder()
+	handleMetrics(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("loopback status = %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `eveflipper_scan_duration_seconds_count{type="test_scan"}`) {
+		t.Fatalf("metrics body missing scan histogram:\n%s", rec.Body.String())
+	}
+
+	req.RemoteAddr = "203.0.113.7:50000"
+	rec = httptest.NewRecorder()
+	handleMetrics(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("remote status = %d, want 403", rec.Code)
+	}
+
+	t.Setenv(metricsPublicEnv, "1")
+	rec = httptest.NewRecorder()
+	handleMetrics(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("remote status with %s = %d", metricsPublicEnv, rec.Code)
+	}
+}
@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"eve-flipper/internal/auth"
+	"eve-flipper/internal/engine"
+	"eve-flipper/internal/esi"
+)
+
+// networthCacheTTL keeps repeated dashboard loads from refetching every
+// character's assets and the whole Forge order book.
+const networthCacheTTL = 5 * time.Minute
+
+// networthKey scopes a cached result like regionalInventoryKey does: another
+// set of characters or another valuation side is computed separately.
+type networthKey struct {
+	userID     string
+	characters string
+	valuation  string
+}
+
+type networthEntry struct {
+	result    networthResponse
+	expiresAt time.Time
+}
+
+type networthResponse struct {
+	engine.NetWorth
+	Valuation   string   `json:"valuation"`    // sell | buy
+	PriceSource string   `json:"price_source"` // esi | fuzzwork
+	Warnings    []string `json:"warnings"`
+	GeneratedAt string   `json:"generated_at"`
+	Cached      bool     `json:"cached"`
+}
+
+func (s *Server) cachedNetWorth(key networthKey) (networthResponse, bool) {
+	s.networthMu.Lock()
+	defer s.networthMu.Unlock()
+	entry, ok := s.networthCache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return networthResponse{}, false
+	}
+	return entry.result, true
+}
+
+func (s *Server) storeNetWorth(key networthKey, result networthResponse) {
+	now := time.Now()
+	s.networthMu.Lock()
+	defer s.networthMu.Unlock()
+	if s.networthCache == nil {
+		s.networthCache = make(map[networthKey]networthEntry)
+	}
+	for k, entry := range s.networthCache {
+		if now.After(entry.expiresAt) {
+			delete(s.networthCache, k)
+		}
+	}
+	s.networthCache[key] = networthEntry{result: result, expiresAt: now.Add(networthCacheTTL)}
+}
+
+// fetchNetWorthInput loads wallet, open orders and assets for one character
+// in parallel. A component whose scope is missing or whose fetch fails is
+// left empty and reported as a warning instead of failing the whole total.
+func (s *Server) fetchNetWorthInput(sess *auth.Session, token string) (engine.NetWorthCharacterInput, []string) {
+	in := engine.NetWorthCharacterInput{
+		CharacterID:   sess.CharacterID,
+		CharacterName: sess.CharacterName,
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		warnings []string
+	)
+	warn := func(component string, err error) {
+		log.Printf("[AUTH] Net worth %s error (%s): %v", component, sess.CharacterName, err)
+		mu.Lock()
+		warnings = append(warnings, sess.CharacterName+": "+component+" unavailable: "+err.Error())
+		mu.Unlock()
+	}
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		if err := requireScope(sess, auth.ScopeReadCharacterWallet); err != nil {
+			warn("wallet", err)
+			return
+		}
+		balance, err := s.esi.GetWalletBalance(sess.CharacterID, token)
+		if err != nil {
+			warn("wallet", err)
+			return
+		}
+		mu.Lock()
+		in.WalletISK = balance
+		mu.Unlock()
+	}()
+	go func() {
+		defer wg.Done()
+		if err := requireScope(sess, auth.ScopeReadCharacterOrders); err != nil {
+			warn("orders", err)
+			return
+		}
+		orders, err := s.esi.GetCharacterOrders(sess.CharacterID, token)
+		if err != nil {
+			warn("orders", err)
+			return
+		}
+		mu.Lock()
+		in.Orders = orders
+		mu.Unlock()
+	}()
+	go func() {
+		defer wg.Done()
+		if err := requireScope(sess, auth.ScopeReadAssets); err != nil {
+			warn("assets", err)
+			return
+		}
+		assets, err := s.esi.GetCharacterAssets(sess.CharacterID, token)
+		if err != nil {
+			warn("assets", err)
+			return
+		}
+		mu.Lock()
+		in.Assets = assets
+		mu.Unlock()
+	}()
+	wg.Wait()
+	return in, warnings
+}
+
+// jitaAppraisalPrices prices typeIDs at Jita 4-4 on the requested side of the
+// book. When ESI is unavailable it falls back to the secondary price source,
+// but only for users who enabled price_fallback_enabled.
+func (s *Server) jitaAppraisalPrices(ctx context.Context, userID string, typeIDs []int32, valuation string) (map[int32]float64, string, error) {
+	prices := make(map[int32]float64, len(typeIDs))
+	if len(typeIDs) == 0 {
+		return prices, esi.PriceSourceESI, nil
+	}
+	stationID := esi.TradeHubStations[engine.JitaRegionID]
+	var provider esi.PriceProvider = &esi.ESIPriceProvider{Client: s.esi}
+	aggs, err := provider.Aggregates(ctx, engine.JitaRegionID, stationID, typeIDs)
+	if err != nil && s.priceFallback != nil && s.loadConfigForUser(userID).PriceFallbackEnabled {
+		log.Printf("[API] Net worth: ESI prices failed, using %s: %v", s.priceFallback.Name(), err)
+		provider = s.priceFallback
+		aggs, err = provider.Aggregates(ctx, engine.JitaRegionID, stationID, typeIDs)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	for typeID, agg := range aggs {
+		price := agg.SellMin
+		if valuation == "buy" {
+			price = agg.BuyMax
+		}
+		if price > 0 {
+			prices[typeID] = price
+		}
+	}
+	return prices, provider.Name(), nil
+}
+
+// handleAuthNetWorth totals wallet ISK, buy order escrow, listed sell orders
+// and Jita-appraised assets for the selected characters.
+// GET /api/auth/networth?scope=all|single&character_id=...&valuation=sell|buy&refresh=1
+func (s *Server) handleAuthNetWorth(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromRequest(r)
+	characterID, allScope, err := parseAuthScope(r)
+	if err != nil {
+		writeError(w, 400, err.Error())
+		return
+	}
+	valuation := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("valuation")))
+	if valuation == "" {
+		valuation = "sell"
+	}
+	if valuation != "sell" && valuation != "buy" {
+		writeError(w, 400, "valuation must be sell or buy")
+		return
+	}
+
+	selectedSessions, err := s.authSessionsForScope(userID, characterID, allScope, true)
+	if err != nil {
+		if strings.Contains(err.Error(), "not logged in") {
+			writeError(w, 401, err.Error())
+		} else {
+			writeError(w, 400, err.Error())
+		}
+		return
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	if !s.isReady() || sdeData == nil {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+
+	key := networthKey{userID: userID, characters: sessionCharacterSet(selectedSessions), valuation: valuation}
+	if r.URL.Query().Get("refresh") != "1" {
+		if cached, ok := s.cachedNetWorth(key); ok {
+			cached.Cached = true
+			writeJSON(w, cached)
+			return
+		}
+	}
+
+	inputs := make([]engine.NetWorthCharacterInput, 0, len(selectedSessions))
+	warnings := []string{}
+	for _, sess := range selectedSessions {
+		token, tokenErr := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
+		if tokenErr != nil {
+			log.Printf("[AUTH] Net worth token error (%s): %v", sess.CharacterName, tokenErr)
+			if !allScope {
+				writeError(w, 401, tokenErr.Error())
+				return
+			}
+			warnings = append(warnings, sess.CharacterName+": skipped: "+tokenErr.Error())
+			continue
+		}
+		in, charWarnings := s.fetchNetWorthInput(sess, token)
+		inputs = append(inputs, in)
+		warnings = append(warnings, charWarnings...)
+	}
+	if len(inputs) == 0 {
+		writeError(w, 401, "failed to fetch character data")
+		return
+	}
+
+	prices, priceSource, err := s.jitaAppraisalPrices(r.Context(), userID, engine.NetWorthAssetTypeIDs(inputs), valuation)
+	if err != nil {
+		writeErrorCode(w, 502, errCodeESI, "failed to fetch Jita prices: "+err.Error())
+		return
+	}
+
+	result := networthResponse{
+		NetWorth:    engine.ComputeNetWorth(sdeData, inputs, prices),
+		Valuation:   valuation,
+		PriceSource: priceSource,
+		Warnings:    warnings,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	s.storeNetWorth(key, result)
+	writeJSON(w, result)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/esi"
+)
+
+func TestNetWorthCache(t *testing.T) {
+	srv := &Server{}
+	key := networthKey{userID: "user-a", characters: "1,2", valuation: "sell"}
+	srv.storeNetWorth(key, networthResponse{Valuation: "sell", PriceSource: "esi"})
+
+	got, ok := srv.cachedNetWorth(key)
+	if !ok || got.Valuation != "sell" {
+		t.Fatalf("cache miss right after store: ok=%v got=%+v", ok, got)
+	}
+	buy := key
+	buy.valuation = "buy"
+	if _, ok := srv.cachedNetWorth(buy); ok {
+		t.Error("sell valuation served for a buy request")
+	}
+
+	srv.networthCache[key] = networthEntry{expiresAt: time.Now().Add(-time.Second)}
+	if _, ok := srv.cachedNetWorth(key); ok {
+		t.Error("expired entry served")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+type fixedPriceProvider struct{ price float64 }
+
+func (p fixedPriceProvider) Name() string { return esi.PriceSourceFuzzwork }
+
+func (p fixedPriceProvider) Aggregates(_ context.Context, _ int32, _ int64, typeIDs []int32) (map[int32]esi.PriceAggregate, error) {
+	out := make(map[int32]esi.PriceAggregate, len(typeIDs))
+	for _, id := range typeIDs {
+		out[id] = esi.PriceAggregate{TypeID: id, SellMin: p.price, BuyMax: p.price}
+	}
+	return out, nil
+}
+
+func TestJitaAppraisalPricesFallbackNeedsOptIn(t *testing.T) {
+	client := esi.NewClient(nil)
+	client.SetMaxRetries(0)
+	client.SetHTTPClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusBadGateway, Body: io.NopCloser(strings.NewReader("")), Header: http.Header{}, Request: r}, nil
+	})})
+	srv := &Server{cfg: config.Default(), esi: client, priceFallback: fixedPriceProvider{price: 5}}
+
+	if _, _, err := srv.jitaAppraisalPrices(context.Background(), "user-a", []int32{34}, "sell"); err == nil {
+		t.Fatal("fell back to the secondary source without price_fallback_enabled")
+	}
+
+	srv.cfg.PriceFallbackEnabled = true
+	prices, source, err := srv.jitaAppraisalPrices(context.Background(), "user-a", []int32{34}, "sell")
+	if err != nil || source != esi.PriceSourceFuzzwork || prices[34] != 5 {
+		t.Fatalf("opted in: prices=%v source=%q err=%v", prices, source, err)
+	}
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"eve-flipper/internal/auth"
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/engine"
+	"eve-flipper/internal/esi"
+)
+
+// orderExpiryAlertInterval is how often open orders are checked for upcoming
+// expiry.
+const orderExpiryAlertInterval = time.Hour
+
+// startOrderExpiryLoop runs checkOrderExpiryAlerts on a ticker. It is started
+// once, together with the other background alert loops.
+func (s *Server) startOrderExpiryLoop() {
+	s.orderExpiryOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(orderExpiryAlertInterval)
+			defer ticker.Stop()
+			for now := range ticker.C {
+				s.checkOrderExpiryAlerts(now)
+			}
+		}()
+	})
+}
+
+// checkOrderExpiryAlerts fetches the active orders of every logged-in
+// character whose user enabled AlertOrderExpiry and warns about the ones
+// that are about to expire. Returns the number of alerts sent.
+func (s *Server) checkOrderExpiryAlerts(now time.Time) int {
+	if s.db == nil {
+		return 0
+	}
+	sent := 0
+	enabled := func(cfg *config.Config) bool { return cfg.AlertOrderExpiry }
+	s.forEachAlertCharacterOrders("Order expiry", false, enabled, func(userID string, cfg *config.Config, orders []esi.CharacterOrder) {
+		sent += s.sendOrderExpiryAlerts(userID, cfg, orders, now)
+	})
+	return sent
+}
+
+// forEachAlertCharacterOrders calls fn with the open orders of logged-in
+// characters whose user has an external alert channel and passes enabled.
+// With activeOnly only each user's active character is checked, otherwise
+// all of the user's characters. Characters without the orders scope are
+// skipped; those whose token refresh or order fetch fails are logged under
+// label and skipped too.
+func (s *Server) forEachAlertCharacterOrders(label string, activeOnly bool, enabled func(*config.Config) bool, fn func(userID string, cfg *config.Config, orders []esi.CharacterOrder)) {
+	if s.sessions == nil {
+		return
+	}
+	s.mu.RLock()
+	esiClient := s.esi
+	s.mu.RUnlock()
+	if esiClient == nil {
+		return
+	}
+
+	for _, userID := range s.sessions.ListUserIDs() {
+		cfg := s.loadConfigForUser(userID)
+		if !hasExternalAlertChannels(cfg) || !enabled(cfg) {
+			continue
+		}
+		var sessions []*auth.Session
+		if activeOnly {
+			if sess := s.sessions.GetForUser(userID); sess != nil {
+				sessions = []*auth.Session{sess}
+			}
+		} else {
+			sessions = s.sessions.ListForUser(userID)
+		}
+		for _, sess := range sessions {
+			if !sess.HasScope(auth.ScopeReadCharacterOrders) {
+				continue
+			}
+			token := strings.TrimSpace(sess.AccessToken)
+			if s.sso != nil {
+				refreshed, err := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
+				if err != nil {
+					log.Printf("[ALERT] %s: token refresh failed for %s: %v", label, sess.CharacterName, err)
+					continue
+				}
+				token = strings.TrimSpace(refreshed)
+			}
+			if token == "" {
+				continue
+			}
+			orders, err := esiClient.GetCharacterOrders(sess.CharacterID, token)
+			if err != nil {
+				log.Printf("[ALERT] %s: failed to fetch orders for %s: %v", label, sess.CharacterName, err)
+				continue
+			}
+			fn(userID, cfg, orders)
+		}
+	}
+}
+
+// sendOrderExpiryAlerts sends one message per order that expires within
+// engine.DefaultWarnExpiryDays through the user's external alert channels.
+// An order is announced at most once per UTC day. Returns the number of
+// orders announced.
+func (s *Server) sendOrderExpiryAlerts(userID string, cfg *config.Config, orders []esi.CharacterOrder, now time.Time) int {
+	day := now.UTC().Format("2006-01-02")
+	sent := 0
+	for _, o := range orders {
+		expiresAt, days, ok := engine.OrderExpiry(o, now)
+		if !ok || days > engine.DefaultWarnExpiryDays {
+			continue
+		}
+		fresh, err := s.db.MarkOrderExpiryAlertedForUser(userID, o.OrderID, day)
+		if err != nil {
+			log.Printf("[ALERT] Order expiry: failed to record order %d: %v", o.OrderID, err)
+			continue
+		}
+		if !fresh {
+			continue
+		}
+
+		message := s.orderExpiryMessage(o, expiresAt, days)
+		result := s.sendConfiguredExternalAlerts(cfg, message)
+		if len(result.Failed) > 0 {
+			log.Printf("[ALERT] Order expiry alert for order %d failed on %v", o.OrderID, result.Failed)
+		}
+		log.Printf("[ALERT] Sent order expiry alert: %s (channels: %v)", message, result.Sent)
+		sent++
+	}
+	return sent
+}
+
+// orderExpiryMessage describes an expiring order, e.g.
+// "Sell order expiring: Tritanium at Jita IV - Moon 4 expires in 1 day(s) (2026-03-11 12:00 UTC)".
+func (s *Server) orderExpiryMessage(o esi.CharacterOrder, expiresAt time.Time, days int) string {
+	typeName, location := s.characterOrderNames(o)
+	side := "Sell"
+	if o.IsBuyOrder {
+		side = "Buy"
+	}
+	when := fmt.Sprintf("in %d day(s)", days)
+	if days == 0 {
+		when = "today"
+	}
+	return fmt.Sprintf("%s order expiring: %s at %s expires %s (%s UTC)",
+		side, typeName, location, when, expiresAt.UTC().Format("2006-01-02 15:04"))
+}
+
+// characterOrderNames resolves the type and location names of an order for
+// alert messages, preferring the enriched fields and falling back to IDs.
+func (s *Server) characterOrderNames(o esi.CharacterOrder) (typeName, location string) {
+	s.mu.RLock()
+	esiClient := s.esi
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+
+	typeName = o.TypeName
+	if typeName == "" && sdeData != nil {
+		if t, ok := sdeData.Types[o.TypeID]; ok {
+			typeName = t.Name
+		}
+	}
+	if typeName == "" {
+		typeName = fmt.Sprintf("type %d", o.TypeID)
+	}
+	location = o.LocationName
+	if location == "" && esiClient != nil {
+		location = esiClient.StationName(o.LocationID)
+	}
+	if location == "" {
+		location = fmt.Sprintf("location %d", o.LocationID)
+	}
+	return typeName, location
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/esi"
+)
+
+func TestSendOrderExpiryAlerts_WarnsOncePerOrderPerDay(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+
+	cfg := config.Default()
+	cfg.AlertOrderExpiry = true
+	cfg.AlertDiscord = true // no webhook configured: nothing leaves the process
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	orders := []esi.CharacterOrder{
+		// Issued 89 days ago with a 90-day duration: expires tomorrow.
+		{OrderID: 1, TypeID: 34, TypeName: "Tritanium", LocationName: "Jita IV - Moon 4", Duration: 90,
+			Issued: now.AddDate(0, 0, -89).Format(time.RFC3339)},
+		// Plenty of time left.
+		{OrderID: 2, TypeID: 35, TypeName: "Pyerite", LocationName: "Amarr VIII", Duration: 90,
+			Issued: now.AddDate(0, 0, -10).Format(time.RFC3339)},
+		// Unparseable issue date is ignored.
+		{OrderID: 3, TypeID: 36, TypeName: "Mexallon", Duration: 90, Issued: "garbage"},
+	}
+
+	if got := srv.sendOrderExpiryAlerts("user-a", cfg, orders, now); got != 1 {
+		t.Fatalf("first pass sent %d alerts, want 1", got)
+	}
+	if got := srv.sendOrderExpiryAlerts("user-a", cfg, orders, now.Add(time.Hour)); got != 0 {
+		t.Fatalf("same-day pass sent %d alerts, want 0", got)
+	}
+	if got := srv.sendOrderExpiryAlerts("user-b", cfg, orders, now); got != 1 {
+		t.Fatalf("other user sent %d alerts, want 1", got)
+	}
+	// A new UTC day re-arms the reminder.
+	if got := srv.sendOrderExpiryAlerts("user-a", cfg, orders, now.Add(13*time.Hour)); got != 1 {
+		t.Fatalf("next-day pass sent %d alerts, want 1", got)
+	}
+}
+
+func TestOrderExpiryMessage(t *testing.T) {
+	srv := &Server{}
+	expAt := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+
+	o := esi.CharacterOrder{TypeID: 34, TypeName: "Tritanium", LocationName: "Jita IV - Moon 4"}
+	want := "Sell order expiring: Tritanium at Jita IV - Moon 4 expires in 1 day(s) (2026-03-11 12:00 UTC)"
+	if got := srv.orderExpiryMessage(o, expAt, 1); got != want {
+		t.Fatalf("message = %q, want %q", got, want)
+	}
+
+	o = esi.CharacterOrder{TypeID: 99, LocationID: 60003760, IsBuyOrder: true}
+	want = "Buy order expiring: type 99 at location 60003760 expires today (2026-03-11 12:00 UTC)"
+	if got := srv.orderExpiryMessage(o, expAt, 0); got != want {
+		t.Fatalf("message = %q, want %q", got, want)
+	}
+}
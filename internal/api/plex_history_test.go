@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"eve-flipper/internal/engine"
+)
+
+func TestHandlePLEXHistory(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+
+	dash := engine.PLEXDashboard{PLEXPrice: engine.PLEXGlobalPrice{BuyPrice: 4e6, SellPrice: 4.1e6}}
+	if err := database.RecordPLEXHistory(dash, time.Now().Add(-2*time.Hour)); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := database.RecordPLEXHistory(dash, time.Now().AddDate(0, 0, -10)); err != nil {
+		t.Fatalf("record old: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handlePLEXHistory(rec, httptest.NewRequest(http.MethodGet, "/api/plex/history?days=7", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	var out struct {
+		Days   int `json:"days"`
+		Points []struct {
+			PLEXSell float64 `json:"plex_sell"`
+		} `json:"points"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.Days != 7 || len(out.Points) != 1 || out.Points[0].PLEXSell != 4.1e6 {
+		t.Fatalf("history = %+v, want one point in the last 7 days", out)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handlePLEXHistory(rec, httptest.NewRequest(http.MethodGet, "/api/plex/history?days=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("days=0 status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"eve-flipper/internal/engine"
+	"eve-flipper/internal/esi"
+)
+
+// handleAuthPnL returns a realized profit report (per type, per day and
+// totals) built from wallet transactions:
+// GET /api/auth/pnl?scope=all&days=90.
+// Fees default to the user's settings and can be overridden with sales_tax
+// and broker_fee.
+func (s *Server) handleAuthPnL(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromRequest(r)
+
+	characterID, allScope, err := parseAuthScope(r)
+	if err != nil {
+		writeError(w, 400, err.Error())
+		return
+	}
+	selectedSessions, err := s.authSessionsForScope(userID, characterID, allScope, true)
+	if err != nil {
+		if strings.Contains(err.Error(), "not logged in") {
+			writeError(w, 401, err.Error())
+		} else {
+			writeError(w, 400, err.Error())
+		}
+		return
+	}
+
+	days := 90
+	if v := r.URL.Query().Get("days"); v != "" {
+		if d, err := strconv.Atoi(v); err == nil && d > 0 && d <= 365 {
+			days = d
+		}
+	}
+	salesTax, brokerFee := 8.0, 1.0
+	if cfg := s.loadConfigForUser(userID); cfg != nil {
+		salesTax = cfg.SalesTaxPercent
+		brokerFee = cfg.BrokerFeePercent
+	}
+	if v := r.URL.Query().Get("sales_tax"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 100 {
+			salesTax = f
+		}
+	}
+	if v := r.URL.Query().Get("broker_fee"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 100 {
+			brokerFee = f
+		}
+	}
+
+	var txns []esi.WalletTransaction
+	failed := 0
+	for _, sess := range selectedSessions {
+		part, fetchErr := s.walletTransactionsForSession(userID, sess)
+		if fetchErr != nil {
+			log.Printf("[AUTH] PnL txns error (%s): %v", sess.CharacterName, fetchErr)
+			if !allScope {
+				if !writeScopeError(w, fetchErr) {
+					writeError(w, 500, "failed to fetch transactions: "+fetchErr.Error())
+				}
+				return
+			}
+			failed++
+			continue
+		}
+		txns = append(txns, part...)
+	}
+	if failed > 0 && failed == len(selectedSessions) {
+		writeError(w, 500, "failed to fetch transactions for selected characters")
+		return
+	}
+
+	pnl := engine.ComputePortfolioPnLWithOptions(txns, engine.PortfolioPnLOptions{
+		LookbackDays:     days,
+		SalesTaxPercent:  salesTax,
+		BrokerFeePercent: brokerFee,
+		LedgerLimit:      -1, // the report aggregates the whole ledger
+	})
+	writeJSON(w, engine.BuildRealizedPnLReport(pnl))
+}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"eve-flipper/internal/auth"
+	"eve-flipper/internal/engine"
+	"eve-flipper/internal/esi"
+	"eve-flipper/internal/sde"
+)
+
+// openPositionRow is an open position marked to the current market: the
+// lowest sell order at the position's location, or in its region when
+// nothing is listed there. Values are gross of tax and broker fees.
+type openPositionRow struct {
+	engine.OpenPosition
+	HasMarketPrice bool    `json:"has_market_price"`
+	CurrentPrice   float64 `json:"current_price"`
+	MarketValue    float64 `json:"market_value"`
+	UnrealizedPnL  float64 `json:"unrealized_pnl"`
+	UnrealizedPct  float64 `json:"unrealized_pct"`
+}
+
+type openPositionTotals struct {
+	Positions     int     `json:"positions"`
+	Quantity      int64   `json:"quantity"`
+	CostBasis     float64 `json:"cost_basis"`
+	MarketValue   float64 `json:"market_value"` // priced positions only
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+	Unpriced      int     `json:"unpriced"`
+}
+
+// handleAuthPositions returns the inventory cost basis reconstructed from
+// wallet transactions:
+// GET /api/auth/positions?scope=all&lookback_days=180.
+func (s *Server) handleAuthPositions(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromRequest(r)
+
+	characterID, allScope, err := parseAuthScope(r)
+	if err != nil {
+		writeError(w, 400, err.Error())
+		return
+	}
+	selectedSessions, err := s.authSessionsForScope(userID, characterID, allScope, true)
+	if err != nil {
+		if strings.Contains(err.Error(), "not logged in") {
+			writeError(w, 401, err.Error())
+		} else {
+			writeError(w, 400, err.Error())
+		}
+		return
+	}
+
+	lookbackDays := 180
+	if v := r.URL.Query().Get("lookback_days"); v != "" {
+		if d, err := strconv.Atoi(v); err == nil && d > 0 && d <= 365 {
+			lookbackDays = d
+		}
+	}
+
+	var txns []esi.WalletTransaction
+	for _, sess := range selectedSessions {
+		part, fetchErr := s.walletTransactionsForSession(userID, sess)
+		if fetchErr != nil {
+			log.Printf("[AUTH] Positions txns error (%s): %v", sess.CharacterName, fetchErr)
+			if !allScope {
+				if !writeScopeError(w, fetchErr) {
+					writeError(w, 500, "failed to fetch transactions: "+fetchErr.Error())
+				}
+				return
+			}
+			continue
+		}
+		txns = append(txns, part...)
+	}
+
+	positions := make([]engine.OpenPosition, 0)
+	if pnl := engine.ComputePortfolioPnL(txns, lookbackDays); pnl != nil && len(pnl.OpenPositions) > 0 {
+		positions = pnl.OpenPositions
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+
+	locationIDs := make(map[int64]bool)
+	pairs := make(map[regionTypeKey]bool)
+	for i := range positions {
+		p := &positions[i]
+		if p.TypeName == "" && sdeData != nil {
+			if t, ok := sdeData.Types[p.TypeID]; ok {
+				p.TypeName = t.Name
+			}
+		}
+		if p.LocationID > 0 {
+			locationIDs[p.LocationID] = true
+		}
+		if regionID := stationRegionID(sdeData, p.LocationID); regionID > 0 {
+			pairs[regionTypeKey{regionID, p.TypeID}] = true
+		}
+	}
+	if len(locationIDs) > 0 {
+		s.esi.PrefetchStationNames(locationIDs)
+		for i := range positions {
+			if positions[i].LocationName == "" {
+				positions[i].LocationName = s.esi.StationName(positions[i].LocationID)
+			}
+		}
+	}
+
+	var book []esi.MarketOrder
+	if len(pairs) > 0 {
+		book = s.fetchRegionTypeOrders(pairs)
+	}
+	rows, totals := markOpenPositions(positions, book, func(locationID int64) int32 {
+		return stationRegionID(sdeData, locationID)
+	})
+
+	scope := "active"
+	if allScope {
+		scope = "all"
+	} else if characterID > 0 {
+		scope = "character"
+	}
+	writeJSON(w, map[string]interface{}{
+		"scope":         scope,
+		"lookback_days": lookbackDays,
+		"positions":     rows,
+		"totals":        totals,
+	})
+}
+
+// walletTransactionsForSession returns a character's wallet transactions,
+// served from the short-lived transaction cache when possible, with type
+// names filled in from the SDE. A missing wallet scope is returned as a
+// *missingScopeError.
+func (s *Server) walletTransactionsForSession(userID string, sess *auth.Session) ([]esi.WalletTransaction, error) {
+	if err := requireScope(sess, auth.ScopeReadCharacterWallet); err != nil {
+		return nil, err
+	}
+	if cached, ok := s.getWalletTxnCache(sess.CharacterID); ok {
+		return cached, nil
+	}
+	token, err := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+	txns, err := s.esi.GetWalletTransactions(sess.CharacterID, token)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	if sdeData != nil {
+		for i := range txns {
+			if t, ok := sdeData.Types[txns[i].TypeID]; ok {
+				txns[i].TypeName = t.Name
+			}
+		}
+	}
+	s.setWalletTxnCache(sess.CharacterID, txns)
+	return txns, nil
+}
+
+// stationRegionID resolves the region of an NPC station. Structures are not
+// in the SDE and return 0.
+func stationRegionID(data *sde.Data, locationID int64) int32 {
+	if data == nil {
+		return 0
+	}
+	st, ok := data.Stations[locationID]
+	if !ok {
+		return 0
+	}
+	sys, ok := data.Systems[st.SystemID]
+	if !ok {
+		return 0
+	}
+	return sys.RegionID
+}
+
+// markOpenPositions prices each position against the lowest sell order at
+// its location, falling back to the lowest sell in its region. Rows are
+// ordered by cost basis, largest first.
+func markOpenPositions(positions []engine.OpenPosition, book []esi.MarketOrder, regionOf func(int64) int32) ([]openPositionRow, openPositionTotals) {
+	type locType struct {
+		locationID int64
+		typeID     int32
+	}
+	atLocation := make(map[locType]float64)
+	inRegion := make(map[regionTypeKey]float64)
+	for _, o := range book {
+		if o.IsBuyOrder || o.Price <= 0 {
+			continue
+		}
+		lk := locType{o.LocationID, o.TypeID}
+		if best, ok := atLocation[lk]; !ok || o.Price < best {
+			atLocation[lk] = o.Price
+		}
+		if o.RegionID > 0 {
+			rk := regionTypeKey{o.RegionID, o.TypeID}
+			if best, ok := inRegion[rk]; !ok || o.Price < best {
+				inRegion[rk] = o.Price
+			}
+		}
+	}
+
+	rows := make([]openPositionRow, 0, len(positions))
+	var totals openPositionTotals
+	for _, p := range positions {
+		row := openPositionRow{OpenPosition: p}
+		price, ok := atLocation[locType{p.LocationID, p.TypeID}]
+		if !ok {
+			price, ok = inRegion[regionTypeKey{regionOf(p.LocationID), p.TypeID}]
+		}
+		if ok {
+			row.HasMarketPrice = true
+			row.CurrentPrice = price
+			row.MarketValue = price * float64(p.Quantity)
+			row.UnrealizedPnL = row.MarketValue - p.CostBasis
+			if p.CostBasis > 0 {
+				row.UnrealizedPct = row.UnrealizedPnL / p.CostBasis * 100
+			}
+			totals.MarketValue += row.MarketValue
+			totals.UnrealizedPnL += row.UnrealizedPnL
+		} else {
+			totals.Unpriced++
+		}
+		totals.Positions++
+		totals.Quantity += p.Quantity
+		totals.CostBasis += p.CostBasis
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].CostBasis > rows[j].CostBasis
+	})
+	return rows, totals
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"math"
+	"testing"
+
+	"eve-flipper/internal/engine"
+	"eve-flipper/internal/esi"
+)
+
+func TestMarkOpenPositions(t *testing.T) {
+	const (
+		jita    = int64(60003760)
+		perimtr = int64(60000001) // same region, no orders for Pyerite at the station itself
+		forge   = int32(10000002)
+	)
+	positions := []engine.OpenPosition{
+		{TypeID: 34, LocationID: jita, Quantity: 1000, AvgCost: 4, CostBasis: 4000},
+		{TypeID: 35, LocationID: perimtr, Quantity: 100, AvgCost: 10, CostBasis: 1000},
+		{TypeID: 36, LocationID: 1030000000000, Quantity: 10, AvgCost: 50, CostBasis: 500}, // structure, no book
+	}
+	book := []esi.MarketOrder{
+		{TypeID: 34, LocationID: jita, RegionID: forge, Price: 5},
+		{TypeID: 34, LocationID: jita, RegionID: forge, Price: 5.5},
+		{TypeID: 34, LocationID: jita, RegionID: forge, Price: 6, IsBuyOrder: true}, // bids are ignored
+		{TypeID: 34, LocationID: 60008494, RegionID: 10000043, Price: 3},            // other region, other station
+		{TypeID: 35, LocationID: jita, RegionID: forge, Price: 9},
+	}
+	regionOf := func(loc int64) int32 {
+		if loc == jita || loc == perimtr {
+			return forge
+		}
+		return 0
+	}
+
+	rows, totals := markOpenPositions(positions, book, regionOf)
+	if len(rows) != 3 {
+		t.Fatalf("rows = %d, want 3", len(rows))
+	}
+	if rows[0].TypeID != 34 || rows[1].TypeID != 35 || rows[2].TypeID != 36 {
+		t.Fatalf("rows not ordered by cost basis: %+v", rows)
+	}
+
+	trit := rows[0]
+	if !trit.HasMarketPrice || trit.CurrentPrice != 5 || trit.MarketValue != 5000 || trit.UnrealizedPnL != 1000 || trit.UnrealizedPct != 25 {
+		t.Fatalf("tritanium row = %+v", trit)
+	}
+	pye := rows[1]
+	if !pye.HasMarketPrice || pye.CurrentPrice != 9 || pye.UnrealizedPnL != -100 {
+		t.Fatalf("pyerite row should fall back to the regional price: %+v", pye)
+	}
+	if rows[2].HasMarketPrice || rows[2].UnrealizedPnL != 0 {
+		t.Fatalf("unpriced row = %+v", rows[2])
+	}
+
+	if totals.Positions != 3 || totals.Quantity != 1110 || totals.CostBasis != 5500 || totals.Unpriced != 1 {
+		t.Fatalf("totals = %+v", totals)
+	}
+	if math.Abs(totals.MarketValue-5900) > 1e-9 || math.Abs(totals.UnrealizedPnL-900) > 1e-9 {
+		t.Fatalf("totals = %+v", totals)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"eve-flipper/internal/auth"
+	"eve-flipper/internal/engine"
+)
+
+// maxRegionalInventoryAge bounds how long any snapshot is kept, whatever the
+// users' inventory_cache_minutes say.
+const maxRegionalInventoryAge = time.Hour
+
+// regionalInventoryKey scopes a cached snapshot: the same user with a
+// different set of logged-in characters, or another destination, refetches.
+type regionalInventoryKey struct {
+	userID     string
+	characters string
+	regionID   int32
+	systemID   int32
+	locationID int64
+}
+
+type regionalInventoryEntry struct {
+	snapshot  *engine.RegionalInventorySnapshot // nil = nothing held in scope
+	fetchedAt time.Time
+}
+
+// cachedRegionalInventory returns the snapshot stored under key when it is
+// younger than maxAge, along with its age.
+func (s *Server) cachedRegionalInventory(key regionalInventoryKey, maxAge time.Duration) (*engine.RegionalInventorySnapshot, time.Duration, bool) {
+	if maxAge <= 0 {
+		return nil, 0, false
+	}
+	s.regionalInventoryMu.Lock()
+	defer s.regionalInventoryMu.Unlock()
+	entry, ok := s.regionalInventoryCache[key]
+	if !ok {
+		return nil, 0, false
+	}
+	age := time.Since(entry.fetchedAt)
+	if age >= maxAge {
+		return nil, 0, false
+	}
+	return entry.snapshot, age, true
+}
+
+func (s *Server) storeRegionalInventory(key regionalInventoryKey, snapshot *engine.RegionalInventorySnapshot) {
+	now := time.Now()
+	s.regionalInventoryMu.Lock()
+	defer s.regionalInventoryMu.Unlock()
+	if s.regionalInventoryCache == nil {
+		s.regionalInventoryCache = make(map[regionalInventoryKey]regionalInventoryEntry)
+	}
+	for k, entry := range s.regionalInventoryCache {
+		if now.Sub(entry.fetchedAt) >= maxRegionalInventoryAge {
+			delete(s.regionalInventoryCache, k)
+		}
+	}
+	s.regionalInventoryCache[key] = regionalInventoryEntry{snapshot: snapshot, fetchedAt: now}
+}
+
+// sessionCharacterSet is a stable key for the characters behind sessions.
+func sessionCharacterSet(sessions []*auth.Session) string {
+	ids := make([]int64, 0, len(sessions))
+	for _, sess := range sessions {
+		ids = append(ids, sess.CharacterID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"eve-flipper/internal/auth"
+	"eve-flipper/internal/engine"
+)
+
+func TestRegionalInventoryCache(t *testing.T) {
+	srv := &Server{}
+	key := regionalInventoryKey{
+		userID:     "user-a",
+		characters: sessionCharacterSet([]*auth.Session{{CharacterID: 9}, {CharacterID: 3}}),
+		regionID:   10000002,
+		systemID:   30000142,
+	}
+	if key.characters != "3,9" {
+		t.Fatalf("character set = %q, want sorted \"3,9\"", key.characters)
+	}
+
+	snapshot := &engine.RegionalInventorySnapshot{AssetsByType: map[int32]int64{34: 1000}}
+	srv.storeRegionalInventory(key, snapshot)
+
+	got, age, ok := srv.cachedRegionalInventory(key, 5*time.Minute)
+	if !ok || got != snapshot || age < 0 {
+		t.Fatalf("cache miss right after store: ok=%v age=%v", ok, age)
+	}
+	if _, _, ok := srv.cachedRegionalInventory(key, 0); ok {
+		t.Error("maxAge 0 must bypass the cache")
+	}
+	other := key
+	other.systemID = 30002187
+	if _, _, ok := srv.cachedRegionalInventory(other, 5*time.Minute); ok {
+		t.Error("snapshot reused for another destination")
+	}
+
+	// Expired entries are not served.
+	srv.regionalInventoryCache[key] = regionalInventoryEntry{snapshot: snapshot, fetchedAt: time.Now().Add(-6 * time.Minute)}
+	if _, _, ok := srv.cachedRegionalInventory(key, 5*time.Minute); ok {
+		t.Error("stale snapshot served")
+	}
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"eve-flipper/internal/engine"
+)
+
+// scanDiffRow is one opportunity reduced to what a diff compares.
+// DailyProfit is the tab's headline profit: daily profit for station, flip
+// and region rows, profit for contracts and total profit for routes.
+type scanDiffRow struct {
+	Key           string  `json:"key"`
+	TypeID        int32   `json:"type_id,omitempty"`
+	Name          string  `json:"name"`
+	Location      string  `json:"location"`
+	MarginPercent float64 `json:"margin_percent"`
+	DailyProfit   float64 `json:"daily_profit"`
+}
+
+// scanDiffChange is an opportunity present in both scans whose margin or
+// profit moved.
+type scanDiffChange struct {
+	Key               string  `json:"key"`
+	TypeID            int32   `json:"type_id,omitempty"`
+	Name              string  `json:"name"`
+	Location          string  `json:"location"`
+	BeforeMargin      float64 `json:"before_margin_percent"`
+	AfterMargin       float64 `json:"after_margin_percent"`
+	BeforeDailyProfit float64 `json:"before_daily_profit"`
+	AfterDailyProfit  float64 `json:"after_daily_profit"`
+	DailyProfitDelta  float64 `json:"daily_profit_delta"`
+}
+
+type scanDiff struct {
+	Added   []scanDiffRow    `json:"added"`
+	Removed []scanDiffRow    `json:"removed"`
+	Changed []scanDiffChange `json:"changed"`
+}
+
+// handleScanDiff compares two saved scans of the same tab:
+// GET /api/scan/diff?from={id}&to={id}.
+func (s *Server) handleScanDiff(w http.ResponseWriter, r *http.Request) {
+	fromID, err := strconv.ParseInt(strings.TrimSpace(r.URL.Query().Get("from")), 10, 64)
+	if err != nil || fromID <= 0 {
+		writeError(w, 400, "invalid from id")
+		return
+	}
+	toID, err := strconv.ParseInt(strings.TrimSpace(r.URL.Query().Get("to")), 10, 64)
+	if err != nil || toID <= 0 {
+		writeError(w, 400, "invalid to id")
+		return
+	}
+
+	from := s.db.GetHistoryByID(fromID)
+	if from == nil {
+		writeError(w, 404, fmt.Sprintf("scan %d not found", fromID))
+		return
+	}
+	to := s.db.GetHistoryByID(toID)
+	if to == nil {
+		writeError(w, 404, fmt.Sprintf("scan %d not found", toID))
+		return
+	}
+	if from.Tab != to.Tab {
+		writeError(w, 400, fmt.Sprintf("cannot diff a %s scan against a %s scan", from.Tab, to.Tab))
+		return
+	}
+
+	diff := diffScanRows(
+		scanDiffRows(s.loadHistoryResults(userIDFromRequest(r), from)),
+		scanDiffRows(s.loadHistoryResults(userIDFromRequest(r), to)),
+	)
+	writeJSON(w, map[string]interface{}{
+		"from": from,
+		"to":   to,
+		"tab":  from.Tab,
+		"diff": diff,
+	})
+}
+
+// scanDiffRows keys the rows of any history result type. The key identifies
+// the same opportunity across runs: type and station for station trades,
+// type and buy/sell systems for flips, contract ID, and the hop sequence for
+// routes. Only the first row per key is kept.
+func scanDiffRows(results interface{}) []scanDiffRow {
+	var out []scanDiffRow
+	switch rows := results.(type) {
+	case []engine.StationTrade:
+		for _, t := range rows {
+			out = append(out, scanDiffRow{
+				Key:           fmt.Sprintf("%d@%d", t.TypeID, t.StationID),
+				TypeID:        t.TypeID,
+				Name:          t.TypeName,
+				Location:      t.StationName,
+				MarginPercent: t.MarginPercent,
+				DailyProfit:   t.DailyProfit,
+			})
+		}
+	case []engine.FlipResult:
+		for _, f := range rows {
+			out = append(out, scanDiffRow{
+				Key:           fmt.Sprintf("%d@%d>%d", f.TypeID, f.BuySystemID, f.SellSystemID),
+				TypeID:        f.TypeID,
+				Name:          f.TypeName,
+				Location:      f.BuySystemName + " → " + f.SellSystemName,
+				MarginPercent: f.MarginPercent,
+				DailyProfit:   f.DailyProfit,
+			})
+		}
+	case []engine.ContractResult:
+		for _, c := range rows {
+			out = append(out, scanDiffRow{
+				Key:           fmt.Sprintf("contract:%d", c.ContractID),
+				Name:          c.Title,
+				Location:      c.StationName,
+				MarginPercent: c.MarginPercent,
+				DailyProfit:   c.Profit,
+			})
+		}
+	case []engine.RouteResult:
+		for _, rt := range rows {
+			parts := make([]string, 0, len(rt.Hops))
+			names := make([]string, 0, len(rt.Hops))
+			for _, h := range rt.Hops {
+				parts = append(parts, fmt.Sprintf("%d@%d>%d", h.TypeID, h.SystemID, h.DestSystemID))
+				names = append(names, h.TypeName)
+			}
+			location := ""
+			if len(rt.Hops) > 0 {
+				location = rt.Hops[0].SystemName + " → " + rt.Hops[len(rt.Hops)-1].DestSystemName
+			}
+			out = append(out, scanDiffRow{
+				Key:         "route:" + strings.Join(parts, ","),
+				Name:        strings.Join(names, ", "),
+				Location:    location,
+				DailyProfit: rt.TotalProfit,
+			})
+		}
+	}
+	return out
+}
+
+// diffScanRows buckets rows into added, removed and changed. Changes are
+// ordered by the absolute profit delta, biggest first; added and removed rows
+// by profit.
+func diffScanRows(before, after []scanDiffRow) scanDiff {
+	diff := scanDiff{Added: []scanDiffRow{}, Removed: []scanDiffRow{}, Changed: []scanDiffChange{}}
+
+	prev := make(map[string]scanDiffRow, len(before))
+	for _, row := range before {
+		if _, dup := prev[row.Key]; !dup {
+			prev[row.Key] = row
+		}
+	}
+	seen := make(map[string]bool, len(after))
+	for _, row := range after {
+		if seen[row.Key] {
+			continue
+		}
+		seen[row.Key] = true
+		old, ok := prev[row.Key]
+		if !ok {
+			diff.Added = append(diff.Added, row)
+			continue
+		}
+		if round2(old.MarginPercent) == round2(row.MarginPercent) && round2(old.DailyProfit) == round2(row.DailyProfit) {
+			continue
+		}
+		diff.Changed = append(diff.Changed, scanDiffChange{
+			Key:               row.Key,
+			TypeID:            row.TypeID,
+			Name:              row.Name,
+			Location:          row.Location,
+			BeforeMargin:      old.MarginPercent,
+			AfterMargin:       row.MarginPercent,
+			BeforeDailyProfit: old.DailyProfit,
+			AfterDailyProfit:  row.DailyProfit,
+			DailyProfitDelta:  row.DailyProfit - old.DailyProfit,
+		})
+	}
+	for key, row := range prev {
+		if !seen[key] {
+			diff.Removed = append(diff.Removed, row)
+		}
+	}
+
+	byProfit := func(rows []scanDiffRow) {
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].DailyProfit != rows[j].DailyProfit {
+				return rows[i].DailyProfit > rows[j].DailyProfit
+			}
+			return rows[i].Key < rows[j].Key
+		})
+	}
+	byProfit(diff.Added)
+	byProfit(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		di, dj := math.Abs(diff.Changed[i].DailyProfitDelta), math.Abs(diff.Changed[j].DailyProfitDelta)
+		if di != dj {
+			return di > dj
+		}
+		return diff.Changed[i].Key < diff.Changed[j].Key
+	})
+	return diff
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"eve-flipper/internal/engine"
+)
+
+func TestHandleScanDiff(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+
+	fromID := database.InsertHistory("station", "Jita", 3, 0)
+	database.InsertStationResults(fromID, []engine.StationTrade{
+		{TypeID: 34, TypeName: "Tritanium", StationID: 60003760, MarginPercent: 5, DailyProfit: 100},
+		{TypeID: 35, TypeName: "Pyerite", StationID: 60003760, MarginPercent: 8, DailyProfit: 200},
+		{TypeID: 36, TypeName: "Mexallon", StationID: 60003760, MarginPercent: 9, DailyProfit: 50},
+	})
+	toID := database.InsertHistory("station", "Jita", 3, 0)
+	database.InsertStationResults(toID, []engine.StationTrade{
+		{TypeID: 34, TypeName: "Tritanium", StationID: 60003760, MarginPercent: 5, DailyProfit: 100},
+		{TypeID: 35, TypeName: "Pyerite", StationID: 60003760, MarginPercent: 11, DailyProfit: 450},
+		// Same type at another station is a different opportunity.
+		{TypeID: 36, TypeName: "Mexallon", StationID: 60008494, MarginPercent: 9, DailyProfit: 60},
+	})
+	flipID := database.InsertHistory("radius", "Jita", 0, 0)
+
+	get := func(from, to int64) (int, scanDiff) {
+		req := httptest.NewRequest(http.MethodGet,
+			"/api/scan/diff?from="+strconv.FormatInt(from, 10)+"&to="+strconv.FormatInt(to, 10), nil)
+		rec := httptest.NewRecorder()
+		srv.handleScanDiff(rec, req)
+		var out struct {
+			Diff scanDiff `json:"diff"`
+		}
+		json.NewDecoder(rec.Body).Decode(&out)
+		return rec.Code, out.Diff
+	}
+
+	code, diff := get(fromID, toID)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Key != "36@60008494" {
+		t.Fatalf("added = %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Key != "36@60003760" {
+		t.Fatalf("removed = %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 {
+		t.Fatalf("changed = %+v, want only Pyerite", diff.Changed)
+	}
+	c := diff.Changed[0]
+	if c.TypeID != 35 || c.BeforeMargin != 8 || c.AfterMargin != 11 || c.BeforeDailyProfit != 200 || c.AfterDailyProfit != 450 {
+		t.Fatalf("changed[0] = %+v", c)
+	}
+
+	if code, _ := get(fromID, flipID); code != http.StatusBadRequest {
+		t.Fatalf("mismatched tabs: status = %d, want 400", code)
+	}
+	if code, _ := get(fromID, toID+100); code != http.StatusNotFound {
+		t.Fatalf("missing scan: status = %d, want 404", code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/scan/diff?from=abc&to=1", nil)
+	rec := httptest.NewRecorder()
+	srv.handleScanDiff(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid from: status = %d, want 400", rec.Code)
+	}
+}
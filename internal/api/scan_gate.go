@@ -0,0 +1,79 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// scanGateRetryAfter is the Retry-After hint sent with a 429 from the scan
+// gate. Scans stream for seconds to minutes, so the client is told to poll
+// rather than given an estimate.
+const scanGateRetryAfter = 5 * time.Second
+
+type scanGateKey struct {
+	userID   string
+	scanType string
+}
+
+// userScanGate lets each user run at most one scan of a given type at a
+// time. It generalizes plexBuildSem from one global slot to one slot per
+// user and scan type. The zero value is ready to use.
+type userScanGate struct {
+	mu      sync.Mutex
+	running map[scanGateKey]time.Time
+}
+
+// tryAcquire claims the slot for userID and scanType. When the slot is taken
+// it returns ok=false and the time the running scan started.
+func (g *userScanGate) tryAcquire(userID, scanType string) (release func(), startedAt time.Time, ok bool) {
+	key := scanGateKey{userID: userID, scanType: scanType}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if started, busy := g.running[key]; busy {
+		return nil, started, false
+	}
+	if g.running == nil {
+		g.running = make(map[scanGateKey]time.Time)
+	}
+	now := time.Now()
+	g.running[key] = now
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			delete(g.running, key)
+			g.mu.Unlock()
+		})
+	}, now, true
+}
+
+// scanGateBusyMessage explains a rejected scan to the client.
+func scanGateBusyMessage(scanType string, startedAt time.Time) string {
+	return fmt.Sprintf("a %s scan is already running (started %s ago); retry when it finishes",
+		scanType, time.Since(startedAt).Round(time.Second))
+}
+
+// gatedScan wraps a heavy scan handler with the per-user scan gate and scan
+// metrics. Requests rejected by the gate are not counted as scans.
+func (s *Server) gatedScan(scanType string, next http.HandlerFunc) http.HandlerFunc {
+	return s.gated(scanType, instrumentScan(scanType, next))
+}
+
+// gated lets each user run one request of kind at a time, answering 429
+// while one is in flight. Unlike gatedScan it records no scan metrics, for
+// heavy endpoints that are not scans.
+func (s *Server) gated(kind string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		release, startedAt, ok := s.scanGate.tryAcquire(userIDFromRequest(r), kind)
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(scanGateRetryAfter/time.Second)))
+			writeErrorCode(w, http.StatusTooManyRequests, errCodeRateLimited, scanGateBusyMessage(kind, startedAt))
+			return
+		}
+		defer release()
+		next(w, r)
+	}
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/esi"
+)
+
+func TestGatedScan_OneScanPerUserAndType(t *testing.T) {
+	srv := &Server{}
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	slow := srv.gatedScan("region", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-unblock
+	})
+	fast := func(w http.ResponseWriter, r *http.Request) {}
+
+	done := make(chan struct{})
+	go func() {
+		slow(httptest.NewRecorder(), requestWithUserID(http.MethodPost, "/api/scan/regional-day", nil, "alice"))
+		close(done)
+	}()
+	<-entered
+
+	rec := httptest.NewRecorder()
+	srv.gatedScan("region", fast)(rec, requestWithUserID(http.MethodPost, "/api/scan/regional-day", nil, "alice"))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second scan status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("429 without Retry-After")
+	}
+
+	rec = httptest.NewRecorder()
+	srv.gatedScan("region", fast)(rec, requestWithUserID(http.MethodPost, "/api/scan/regional-day", nil, "bob"))
+	if rec.Code != http.StatusOK {
+		t.Errorf("other user status = %d, want 200", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	srv.gatedScan("station", fast)(rec, requestWithUserID(http.MethodPost, "/api/scan/station", nil, "alice"))
+	if rec.Code != http.StatusOK {
+		t.Errorf("other scan type status = %d, want 200", rec.Code)
+	}
+
+	close(unblock)
+	<-done
+	rec = httptest.NewRecorder()
+	srv.gatedScan("region", fast)(rec, requestWithUserID(http.MethodPost, "/api/scan/regional-day", nil, "alice"))
+	if rec.Code != http.StatusOK {
+		t.Errorf("scan after release status = %d, want 200", rec.Code)
+	}
+}
+
+func TestHeavyAuthRoutesHaveOwnGates(t *testing.T) {
+	srv := NewServer(config.Default(), esi.NewClient(nil), nil, nil, nil)
+	rec := httptest.NewRecorder()
+	userID := srv.ensureRequestUserID(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies := rec.Result().Cookies()
+	serve := func(method, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// A running station scan blocks none of them.
+	release, _, ok := srv.scanGate.tryAcquire(userID, "station")
+	if !ok {
+		t.Fatal("could not claim gate")
+	}
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/api/auth/simulate-day"},
+		{http.MethodGet, "/api/auth/capital-allocation"},
+		{http.MethodPost, "/api/auth/station/command"},
+	} {
+		if code := serve(route.method, route.path); code == http.StatusTooManyRequests {
+			t.Errorf("%s %s blocked by a station scan", route.method, route.path)
+		}
+	}
+	release()
+
+	for kind, path := range map[string]string{
+		"simulate_day":    "/api/auth/simulate-day",
+		"station_command": "/api/auth/station/command",
+	} {
+		release, _, ok := srv.scanGate.tryAcquire(userID, kind)
+		if !ok {
+			t.Fatalf("could not claim %s gate", kind)
+		}
+		if code := serve(http.MethodPost, path); code != http.StatusTooManyRequests {
+			t.Errorf("POST %s while one runs: status = %d, want 429", path, code)
+		}
+		release()
+	}
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxScanTemplateBytes bounds a stored template's params.
+const maxScanTemplateBytes = 64 << 10
+
+// scanTemplateTabs are the scan endpoints a template can be applied to.
+var scanTemplateTabs = map[string]bool{
+	"radius":       true,
+	"region":       true,
+	"regional_day": true,
+	"contracts":    true,
+	"route":        true,
+	"station":      true,
+}
+
+// scanTemplateError is a template_id that cannot be applied to a scan.
+type scanTemplateError struct {
+	status int
+	msg    string
+}
+
+func (e *scanTemplateError) Error() string { return e.msg }
+
+func (s *Server) handleListScanTemplates(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	tab := strings.TrimSpace(r.URL.Query().Get("tab"))
+	if tab != "" && !scanTemplateTabs[tab] {
+		writeError(w, 400, fmt.Sprintf("unknown tab %q", tab))
+		return
+	}
+	templates, err := s.db.ListScanTemplatesForUser(userIDFromRequest(r), tab)
+	if err != nil {
+		writeError(w, 500, "failed to list scan templates")
+		return
+	}
+	writeJSON(w, templates)
+}
+
+// handleSaveScanTemplate creates a template, or replaces the params of the
+// user's template with the same tab and name.
+// Body: {"tab": "radius", "name": "Jita 5j", "params": {"system_name": "Jita", "buy_radius": 5}}
+func (s *Server) handleSaveScanTemplate(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	var body struct {
+		Tab    string          `json:"tab"`
+		Name   string          `json:"name"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+		return
+	}
+	tab := strings.TrimSpace(body.Tab)
+	if !scanTemplateTabs[tab] {
+		writeError(w, 400, fmt.Sprintf("unknown tab %q", body.Tab))
+		return
+	}
+	name, ok := normalizeConfigProfileName(body.Name)
+	if !ok {
+		writeError(w, 400, "template name must be 1-64 characters")
+		return
+	}
+	params := bytes.TrimSpace(body.Params)
+	if len(params) == 0 || params[0] != '{' {
+		writeError(w, 400, "params must be a JSON object")
+		return
+	}
+	if len(params) > maxScanTemplateBytes {
+		writeError(w, 400, "params too large")
+		return
+	}
+
+	template, err := s.db.SaveScanTemplateForUser(userIDFromRequest(r), tab, name, params)
+	if err != nil {
+		writeError(w, 500, "failed to save scan template")
+		return
+	}
+	writeJSON(w, template)
+}
+
+func (s *Server) handleGetScanTemplate(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, 400, "invalid template id")
+		return
+	}
+	template, err := s.db.GetScanTemplateForUser(userIDFromRequest(r), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, 404, "template not found")
+		} else {
+			writeError(w, 500, "failed to load scan template")
+		}
+		return
+	}
+	writeJSON(w, template)
+}
+
+func (s *Server) handleDeleteScanTemplate(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, 400, "invalid template id")
+		return
+	}
+	deleted, err := s.db.DeleteScanTemplateForUser(userIDFromRequest(r), id)
+	if err != nil {
+		writeError(w, 500, "failed to delete scan template")
+		return
+	}
+	if !deleted {
+		writeError(w, 404, "template not found")
+		return
+	}
+	writeJSON(w, map[string]bool{"ok": true})
+}
+
+// decodeScanRequest decodes a scan body into req. When the body names a
+// template_id, the template's params are decoded first and the body is laid
+// over them, so any field present in the body wins. The template must belong
+// to the user and to tab.
+func (s *Server) decodeScanRequest(r *http.Request, tab string, req interface{}) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return &scanTemplateError{400, "invalid json"}
+	}
+	var ref struct {
+		TemplateID int64 `json:"template_id"`
+	}
+	if err := json.Unmarshal(body, &ref); err != nil {
+		return &scanTemplateError{400, "invalid json"}
+	}
+	if ref.TemplateID != 0 {
+		if s.db == nil {
+			return &scanTemplateError{503, "database unavailable"}
+		}
+		template, err := s.db.GetScanTemplateForUser(userIDFromRequest(r), ref.TemplateID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return &scanTemplateError{404, "template not found"}
+			}
+			return &scanTemplateError{500, "failed to load scan template"}
+		}
+		if template.Tab != tab {
+			return &scanTemplateError{400, fmt.Sprintf("template %q is for the %s tab, not %s", template.Name, template.Tab, tab)}
+		}
+		if err := json.Unmarshal(template.Params, req); err != nil {
+			return &scanTemplateError{500, "stored template params are invalid"}
+		}
+	}
+	if err := json.Unmarshal(body, req); err != nil {
+		return &scanTemplateError{400, "invalid json"}
+	}
+	return nil
+}
+
+// writeScanRequestError writes the response for a decodeScanRequest failure.
+func writeScanRequestError(w http.ResponseWriter, err error) {
+	var te *scanTemplateError
+	if errors.As(err, &te) {
+		writeError(w, te.status, te.msg)
+		return
+	}
+	writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+}
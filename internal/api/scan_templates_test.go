@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestScanTemplates_SaveAndApplyWithOverrides(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+
+	body := `{"tab":"radius","name":"Jita 5j","params":{"system_name":"Jita","buy_radius":5,"sell_radius":8,"min_margin":12}}`
+	rec := httptest.NewRecorder()
+	srv.handleSaveScanTemplate(rec, requestWithUserID(http.MethodPost, "/api/scan/templates", strings.NewReader(body), "user-a"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("save status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var saved struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&saved); err != nil || saved.ID == 0 {
+		t.Fatalf("decode saved template: %v (id=%d)", err, saved.ID)
+	}
+	id := strconv.FormatInt(saved.ID, 10)
+
+	var req scanRequest
+	scan := requestWithUserID(http.MethodPost, "/api/scan", strings.NewReader(`{"template_id":`+id+`,"min_margin":5}`), "user-a")
+	if err := srv.decodeScanRequest(scan, "radius", &req); err != nil {
+		t.Fatalf("decodeScanRequest: %v", err)
+	}
+	if req.SystemName != "Jita" || req.BuyRadius != 5 || req.SellRadius != 8 {
+		t.Errorf("template params not applied: %+v", req)
+	}
+	if req.MinMargin != 5 {
+		t.Errorf("MinMargin = %v, want body override 5", req.MinMargin)
+	}
+
+	// Wrong tab and another user's template are both rejected.
+	scan = requestWithUserID(http.MethodPost, "/api/scan/contracts", strings.NewReader(`{"template_id":`+id+`}`), "user-a")
+	rec = httptest.NewRecorder()
+	if err := srv.decodeScanRequest(scan, "contracts", &scanRequest{}); err == nil {
+		t.Fatal("radius template applied to the contracts tab")
+	} else {
+		writeScanRequestError(rec, err)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("wrong tab status = %d, want 400", rec.Code)
+		}
+	}
+	scan = requestWithUserID(http.MethodPost, "/api/scan", strings.NewReader(`{"template_id":`+id+`}`), "user-b")
+	rec = httptest.NewRecorder()
+	if err := srv.decodeScanRequest(scan, "radius", &scanRequest{}); err == nil {
+		t.Fatal("template leaked to another user")
+	} else {
+		writeScanRequestError(rec, err)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("other user status = %d, want 404", rec.Code)
+		}
+	}
+}
+
+func TestScanTemplates_RejectsUnknownTabAndNonObjectParams(t *testing.T) {
+	srv := &Server{db: openAPITestDB(t)}
+
+	for _, body := range []string{
+		`{"tab":"nope","name":"x","params":{}}`,
+		`{"tab":"radius","name":"x","params":[1,2]}`,
+		`{"tab":"radius","name":"","params":{}}`,
+	} {
+		rec := httptest.NewRecorder()
+		srv.handleSaveScanTemplate(rec, requestWithUserID(http.MethodPost, "/api/scan/templates", strings.NewReader(body), "user-a"))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("body %s: status = %d, want 400", body, rec.Code)
+		}
+	}
+}
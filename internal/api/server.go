@@ -20,10 +20,12 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode"
 	"unicode/utf8"
@@ -34,6 +36,8 @@ import (
 	"eve-flipper/internal/db"
 	"eve-flipper/internal/engine"
 	"eve-flipper/internal/esi"
+	"eve-flipper/internal/logger"
+	"eve-flipper/internal/metrics"
 	"eve-flipper/internal/sde"
 	"eve-flipper/internal/zkillboard"
 	"golang.org/x/sync/singleflight"
@@ -46,6 +50,11 @@ type Server struct {
 	scanner          *engine.Scanner
 	industryAnalyzer *engine.IndustryAnalyzer
 	demandAnalyzer   *zkillboard.DemandAnalyzer
+	demandTTL        time.Duration // server-level; reapplied when SetSDE rebuilds the analyzer
+	demandAlertsOnce sync.Once
+	orderExpiryOnce  sync.Once
+	undercutOnce     sync.Once
+	retentionOnce    sync.Once
 	esi              *esi.Client
 	db               *db.DB
 	sso              *auth.SSOConfig
@@ -54,6 +63,10 @@ type Server struct {
 	ready            bool
 	wikiRAG          *stationAIWikiRAG
 
+	// SDE source for admin reloads; sdeReloading rejects overlapping ones.
+	sdeLoader    func() (*sde.Data, error)
+	sdeReloading atomic.Bool
+
 	// SSO state: map of CSRF state tokens → (expiry, desktop flag).
 	// Supports concurrent login flows from multiple tabs.
 	ssoStatesMu sync.Mutex
@@ -65,6 +78,10 @@ type Server struct {
 	txnCacheTime        time.Time
 	txnCacheCharacterID int64
 
+	// Wallet balances for the character popup (TTL 30 s), by character ID.
+	walletBalanceMu    sync.Mutex
+	walletBalanceCache map[int64]walletBalanceEntry
+
 	// PLEX dashboard cache (TTL 5 min) to avoid hammering ESI with 5 concurrent requests per click.
 	plexCacheMu    sync.RWMutex
 	plexCache      *engine.PLEXDashboard
@@ -73,13 +90,50 @@ type Server struct {
 	plexBuildGroup singleflight.Group
 	plexBuildSem   chan struct{} // global limiter for heavy PLEX refreshes
 
+	// One running scan per user and scan type (see gatedScan).
+	scanGate userScanGate
+
 	// Corporation demo provider (initialized on SDE load).
 	demoCorpProvider *corp.DemoCorpProvider
 
+	// Per-character corp roles for ?mode=auto provider selection.
+	corpRolesMu    sync.Mutex
+	corpRolesCache map[int64]corpRolesCacheEntry
+
+	// Secondary price source for station scans when ESI orders are missing
+	// (used only for users with price_fallback_enabled).
+	priceFallback esi.PriceProvider
+
 	userIDCookieSecret []byte
 
+	// Required on /api/ requests when non-empty (EVEFLIPPER_API_KEY).
+	apiKey string
+
+	// Log every request (EVEFLIPPER_ACCESS_LOG).
+	accessLog bool
+
 	authRevisionMu sync.Mutex
 	authRevision   map[string]int64
+
+	// Order IDs already reported as undercut, per user (see undercut_alerts.go).
+	undercutAlertsMu sync.Mutex
+	undercutAlerted  map[string]map[int64]bool
+
+	// Regional day-trader inventory snapshots (see regional_inventory_cache.go).
+	regionalInventoryMu    sync.Mutex
+	regionalInventoryCache map[regionalInventoryKey]regionalInventoryEntry
+
+	// Per-user market-disabled overrides, loaded lazily (see market_disabled.go).
+	marketDisabledMu        sync.Mutex
+	marketDisabledOverrides map[string]map[int32]bool
+
+	// Short-lived net worth totals (see networth.go).
+	networthMu    sync.Mutex
+	networthCache map[networthKey]networthEntry
+
+	// Region market summaries (see market.go).
+	regionSummaryMu    sync.Mutex
+	regionSummaryCache map[int32]regionSummaryEntry
 }
 
 // ssoStateEntry holds metadata for a pending SSO login flow.
@@ -87,9 +141,18 @@ type ssoStateEntry struct {
 	ExpiresAt time.Time
 	Desktop   bool
 	UserID    string
+	Scopes    string // scopes requested for this login
+}
+
+// corpRolesCacheEntry remembers whether a character may read corp data.
+type corpRolesCacheEntry struct {
+	director  bool
+	expiresAt time.Time
 }
 
 const walletTxnCacheTTL = 2 * time.Minute
+const walletBalanceCacheTTL = 30 * time.Second
+const corpRolesCacheTTL = 5 * time.Minute
 const plexCacheTTL = 5 * time.Minute
 const plexStaleCacheTTL = 30 * time.Minute
 const userIDCookieName = "eveflipper_uid"
@@ -102,6 +165,7 @@ const aiWikiCacheTTL = 30 * time.Minute
 const aiWikiErrorCacheTTL = 90 * time.Second
 const stationAIWebMaxQueries = 3
 const stationAIWebMaxSnippets = 4
+const stationAIWebCacheTTL = 15 * time.Minute
 const stationAIRuntimeTopItems = 5
 const stationAIRuntimeTxnWindowDays = 30
 const stationAIHistoryMaxMessages = 16
@@ -257,6 +321,7 @@ type stationAIHistoryMessage struct {
 
 type stationAIChatRequestPayload struct {
 	Provider      string                    `json:"provider"`
+	BaseURL       string                    `json:"provider_base_url"` // ollama only; must be a loopback host
 	APIKey        string                    `json:"api_key"`
 	Model         string                    `json:"model"`
 	PlannerModel  string                    `json:"planner_model"`
@@ -264,11 +329,15 @@ type stationAIChatRequestPayload struct {
 	MaxTokens     int                       `json:"max_tokens"`
 	AssistantName string                    `json:"assistant_name"`
 	Locale        string                    `json:"locale"`
+	NumberLocale  string                    `json:"number_locale"` // optional override of the user's ai_number_locale
+	ISKFormat     string                    `json:"isk_format"`    // optional override of the user's ai_isk_format
 	UserMessage   string                    `json:"user_message"`
 	EnableWiki    *bool                     `json:"enable_wiki_context"`
 	EnableWeb     *bool                     `json:"enable_web_research"`
 	EnablePlanner *bool                     `json:"enable_planner"`
 	WikiRepo      string                    `json:"wiki_repo"`
+	WikiPages     []string                  `json:"wiki_pages"`      // optional override of the user's ai_wiki_pages
+	Conversation  int64                     `json:"conversation_id"` // optional server-side history
 	History       []stationAIHistoryMessage `json:"history"`
 	Context       stationAIContextPayload   `json:"context"`
 }
@@ -310,6 +379,14 @@ type stationAIProviderReply struct {
 
 var aiWikiPageCache sync.Map
 
+// aiWebSnippetCache holds stationAIWebCacheEntry values by stationAIWebCacheKey.
+var aiWebSnippetCache sync.Map
+
+type stationAIWebCacheEntry struct {
+	Snippets  []aiKnowledgeSnippet
+	FetchedAt time.Time
+}
+
 func (s *Server) getWalletTxnCache(characterID int64) ([]esi.WalletTransaction, bool) {
 	s.txnCacheMu.RLock()
 	defer s.txnCacheMu.RUnlock()
@@ -349,6 +426,38 @@ func (s *Server) clearWalletTxnCache() {
 	s.txnCacheMu.Unlock()
 }
 
+// walletBalanceEntry is one cached ESI wallet balance.
+type walletBalanceEntry struct {
+	balance   float64
+	fetchedAt time.Time
+}
+
+func (s *Server) getWalletBalanceCache(characterID int64) (float64, bool) {
+	s.walletBalanceMu.Lock()
+	defer s.walletBalanceMu.Unlock()
+
+	e, ok := s.walletBalanceCache[characterID]
+	if !ok || time.Since(e.fetchedAt) >= walletBalanceCacheTTL {
+		return 0, false
+	}
+	return e.balance, true
+}
+
+func (s *Server) setWalletBalanceCache(characterID int64, balance float64) {
+	s.walletBalanceMu.Lock()
+	if s.walletBalanceCache == nil {
+		s.walletBalanceCache = make(map[int64]walletBalanceEntry)
+	}
+	s.walletBalanceCache[characterID] = walletBalanceEntry{balance: balance, fetchedAt: time.Now()}
+	s.walletBalanceMu.Unlock()
+}
+
+func (s *Server) clearWalletBalanceCache() {
+	s.walletBalanceMu.Lock()
+	s.walletBalanceCache = nil
+	s.walletBalanceMu.Unlock()
+}
+
 func (s *Server) getPLEXCache(cacheKey string, maxAge time.Duration) (engine.PLEXDashboard, bool) {
 	s.plexCacheMu.RLock()
 	defer s.plexCacheMu.RUnlock()
@@ -379,9 +488,21 @@ func cloneConfig(cfg *config.Config) *config.Config {
 	if cfg.SourceRegions != nil {
 		copied.SourceRegions = append([]string(nil), cfg.SourceRegions...)
 	}
+	if cfg.AIWikiPages != nil {
+		copied.AIWikiPages = append([]string(nil), cfg.AIWikiPages...)
+	}
+	if cfg.AIToolAllowlist != nil {
+		copied.AIToolAllowlist = append([]string(nil), cfg.AIToolAllowlist...)
+	}
 	if cfg.CategoryIDs != nil {
 		copied.CategoryIDs = append([]int32(nil), cfg.CategoryIDs...)
 	}
+	if cfg.ExcludeTypeIDs != nil {
+		copied.ExcludeTypeIDs = append([]int32(nil), cfg.ExcludeTypeIDs...)
+	}
+	if cfg.ExcludeMarketGroupIDs != nil {
+		copied.ExcludeMarketGroupIDs = append([]int32(nil), cfg.ExcludeMarketGroupIDs...)
+	}
 	return &copied
 }
 
@@ -545,7 +666,11 @@ func userIDFromRequest(r *http.Request) string {
 	if r == nil {
 		return db.DefaultUserID
 	}
-	if v := r.Context().Value(userIDContextKey); v != nil {
+	return userIDFromContext(r.Context())
+}
+
+func userIDFromContext(ctx context.Context) string {
+	if v := ctx.Value(userIDContextKey); v != nil {
 		if userID, ok := v.(string); ok {
 			userID = strings.TrimSpace(userID)
 			if isValidUserID(userID) {
@@ -558,7 +683,16 @@ func userIDFromRequest(r *http.Request) string {
 
 func (s *Server) userScopeMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Share links are opened by people who are not users of this
+		// instance; they must not be handed a user cookie.
+		if isSharedScanPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
 		userID := s.ensureRequestUserID(w, r)
+		if lw, ok := w.(*accessLogWriter); ok {
+			lw.userID = userID
+		}
 		ctx := context.WithValue(r.Context(), userIDContextKey, userID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -608,6 +742,24 @@ func (s *Server) saveConfigForUser(userID string, cfg *config.Config) error {
 	return nil
 }
 
+// patchConfigForUser applies a config patch and clamps the result as one
+// read-modify-write, so patches sent concurrently from several tabs don't
+// overwrite each other's fields.
+func (s *Server) patchConfigForUser(userID string, patch map[string]json.RawMessage) (*config.Config, error) {
+	apply := func(cfg *config.Config) {
+		applyConfigPatch(cfg, patch)
+		clamped, _ := config.Clamp(cfg)
+		*cfg = *clamped
+	}
+	if s.db != nil {
+		return s.db.PatchConfigForUser(userID, apply)
+	}
+	cfg := cloneConfig(s.cfg)
+	apply(cfg)
+	s.cfg = cloneConfig(cfg)
+	return cfg, nil
+}
+
 // NewServer creates a Server with the given config, ESI client, and database.
 func NewServer(cfg *config.Config, esiClient *esi.Client, database *db.DB, ssoConfig *auth.SSOConfig, sessions *auth.SessionStore) *Server {
 	s := &Server{
@@ -617,36 +769,74 @@ func NewServer(cfg *config.Config, esiClient *esi.Client, database *db.DB, ssoCo
 		sso:                ssoConfig,
 		sessions:           sessions,
 		wikiRAG:            newStationAIWikiRAG(),
+		priceFallback:      esi.NewFuzzworkPriceProvider(),
 		ssoStates:          make(map[string]ssoStateEntry),
 		plexBuildSem:       make(chan struct{}, 1),
 		userIDCookieSecret: loadOrCreateUserCookieSecret(database),
 		authRevision:       make(map[string]int64),
+		apiKey:             strings.TrimSpace(os.Getenv(apiKeyEnv)),
+		accessLog:          accessLogEnabled(),
+	}
+	if cfg != nil {
+		s.demandTTL = time.Duration(cfg.DemandCacheMinutes) * time.Minute
+	}
+	if sessions != nil {
+		// A revoked refresh token removes the session; bump the revision so
+		// the UI notices and prompts for a new login.
+		sessions.OnRevoked(func(userID string, _ int64) {
+			s.bumpAuthRevision(userID)
+		})
 	}
 	if s.wikiRAG != nil {
 		s.wikiRAG.Start(defaultStationAIWikiRepo)
 	}
+	s.startHistoryRetentionLoop()
 	return s
 }
 
-// SetSDE is called when SDE data finishes loading.
+// SetSDE installs static data together with the scanner, industry analyzer
+// and demand analyzer built on it. The new components are constructed before
+// the lock is taken and swapped in at once, so it doubles as the reload path:
+// handlers that already copied the old scanner keep using it until they
+// return.
 func (s *Server) SetSDE(data *sde.Data) {
+	scanner := engine.NewScanner(data, s.esi)
+	scanner.History = s.db
+	industryAnalyzer := engine.NewIndustryAnalyzer(data, s.esi)
+	// Initialize demand analyzer with region names from SDE
+	demandAnalyzer := zkillboard.NewDemandAnalyzer(data.RegionNames())
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if old := s.scanner; old != nil {
+		// Contract caches hold ESI data, not SDE data; keep them warm.
+		scanner.ContractsCache = old.ContractsCache
+		scanner.ContractItemsCache = old.ContractItemsCache
+	}
 	s.sdeData = data
-	scanner := engine.NewScanner(data, s.esi)
-	scanner.History = s.db
 	s.scanner = scanner
-	s.industryAnalyzer = engine.NewIndustryAnalyzer(data, s.esi)
-
-	// Initialize demand analyzer with region names from SDE
-	s.demandAnalyzer = zkillboard.NewDemandAnalyzer(data.RegionNames())
+	s.industryAnalyzer = industryAnalyzer
+	s.demandAnalyzer = demandAnalyzer
+	s.demandAnalyzer.SetCacheTTL(s.demandTTL)
+	s.startDemandAlertLoop()
+	s.startOrderExpiryLoop()
+	s.startUndercutAlertLoop()
 
 	// Initialize corporation demo provider
-	s.demoCorpProvider = corp.NewDemoCorpProvider()
+	if s.demoCorpProvider == nil {
+		s.demoCorpProvider = corp.NewDemoCorpProvider()
+	}
 
 	s.ready = true
 }
 
+// SetSDELoader sets how POST /api/admin/sde/reload reads the SDE.
+func (s *Server) SetSDELoader(load func() (*sde.Data, error)) {
+	s.mu.Lock()
+	s.sdeLoader = load
+	s.mu.Unlock()
+}
+
 func (s *Server) isReady() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -657,37 +847,80 @@ func (s *Server) isReady() bool {
 func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /api/status", s.handleStatus)
+	mux.HandleFunc("GET /metrics", handleMetrics)
 	mux.HandleFunc("POST /api/internal/wiki/gollum", s.handleInternalWikiGollumWebhook)
 	mux.HandleFunc("GET /api/config", s.handleGetConfig)
 	mux.HandleFunc("POST /api/config", s.handleSetConfig)
+	mux.HandleFunc("POST /api/config/validate", s.handleValidateConfig)
+	mux.HandleFunc("GET /api/config/history", s.handleGetConfigHistory)
+	mux.HandleFunc("POST /api/config/history/{id}/revert", s.handleRevertConfig)
+	mux.HandleFunc("GET /api/admin/backup", s.handleAdminBackup)
+	mux.HandleFunc("POST /api/admin/maintenance", s.handleAdminMaintenance)
+	mux.HandleFunc("POST /api/admin/sde/reload", s.handleAdminReloadSDE)
+	mux.HandleFunc("GET /api/admin/debug", s.handleAdminDebug)
+	mux.HandleFunc("GET /api/config/profiles", s.handleListConfigProfiles)
+	mux.HandleFunc("POST /api/config/profiles", s.handleSaveConfigProfile)
+	mux.HandleFunc("DELETE /api/config/profiles/{name}", s.handleDeleteConfigProfile)
+	mux.HandleFunc("GET /api/cts/profiles", s.handleListCTSProfiles)
+	mux.HandleFunc("POST /api/cts/profiles", s.handleSaveCTSProfile)
+	mux.HandleFunc("DELETE /api/cts/profiles/{name}", s.handleDeleteCTSProfile)
+	mux.HandleFunc("POST /api/config/profiles/{name}/activate", s.handleActivateConfigProfile)
 	mux.HandleFunc("POST /api/alerts/test", s.handleAlertsTest)
 	mux.HandleFunc("GET /api/systems/autocomplete", s.handleAutocomplete)
 	mux.HandleFunc("GET /api/regions/autocomplete", s.handleRegionAutocomplete)
-	mux.HandleFunc("POST /api/scan", s.handleScan)
-	mux.HandleFunc("POST /api/scan/multi-region", s.handleScanMultiRegion)
-	mux.HandleFunc("POST /api/scan/regional-day", s.handleScanRegionalDay)
-	mux.HandleFunc("POST /api/scan/contracts", s.handleScanContracts)
-	mux.HandleFunc("POST /api/route/find", s.handleRouteFind)
+	mux.HandleFunc("GET /api/types/autocomplete", s.handleTypeAutocomplete)
+	mux.HandleFunc("GET /api/types/{typeID}", s.handleGetType)
+	mux.HandleFunc("POST /api/locations/resolve", s.handleResolveLocations)
+	mux.HandleFunc("POST /api/fees/simulate", s.handleSimulateFees)
+	mux.HandleFunc("GET /api/cache/status", s.handleCacheStatus)
+	mux.HandleFunc("POST /api/scan", s.gatedScan("radius", s.handleScan))
+	mux.HandleFunc("POST /api/scan/multi-region", s.gatedScan("multi_region", s.handleScanMultiRegion))
+	mux.HandleFunc("POST /api/scan/regional-day", s.gatedScan("regional_day", s.handleScanRegionalDay))
+	mux.HandleFunc("POST /api/scan/contracts", s.gatedScan("contracts", s.handleScanContracts))
+	mux.HandleFunc("POST /api/scan/hub-compare", s.gatedScan("hub_compare", s.handleHubCompare))
+	mux.HandleFunc("POST /api/route/find", s.gatedScan("route", s.handleRouteFind))
+	mux.HandleFunc("GET /api/ws/scan", s.handleWSScan)
 	mux.HandleFunc("GET /api/watchlist", s.handleGetWatchlist)
+	mux.HandleFunc("GET /api/watchlist/export", s.handleExportWatchlist)
+	mux.HandleFunc("POST /api/watchlist/import", s.handleImportWatchlist)
+	mux.HandleFunc("POST /api/watchlist/bulk", s.handleBulkAddWatchlist)
 	mux.HandleFunc("POST /api/watchlist", s.handleAddWatchlist)
 	mux.HandleFunc("DELETE /api/watchlist/{typeID}", s.handleDeleteWatchlist)
 	mux.HandleFunc("PUT /api/watchlist/{typeID}", s.handleUpdateWatchlist)
+	mux.HandleFunc("GET /api/watchlist/{typeID}/history", s.handleWatchlistHistory)
 	mux.HandleFunc("GET /api/alerts/history", s.handleGetAlertHistory)
-	mux.HandleFunc("POST /api/scan/station", s.handleScanStation)
+	mux.HandleFunc("POST /api/scan/station", s.gatedScan("station", s.handleScanStation))
 	mux.HandleFunc("GET /api/stations", s.handleGetStations)
+	mux.HandleFunc("GET /api/station/trends", s.handleStationTrends)
 	mux.HandleFunc("GET /api/scan/history", s.handleGetHistory)
 	mux.HandleFunc("GET /api/scan/history/{id}", s.handleGetHistoryByID)
 	mux.HandleFunc("GET /api/scan/history/{id}/results", s.handleGetHistoryResults)
+	mux.HandleFunc("GET /api/scan/history/{id}/export", s.handleExportHistory)
+	mux.HandleFunc("POST /api/scan/history/{id}/share", s.handleCreateShareLink)
+	mux.HandleFunc("DELETE /api/scan/history/{id}/share", s.handleRevokeShareLinks)
+	mux.HandleFunc("GET /api/shared/{token}", s.handleGetSharedScan)
+	mux.HandleFunc("POST /api/scan/import", s.handleImportHistory)
+	mux.HandleFunc("GET /api/scan/diff", s.handleScanDiff)
 	mux.HandleFunc("DELETE /api/scan/history/{id}", s.handleDeleteHistory)
 	mux.HandleFunc("POST /api/scan/history/clear", s.handleClearHistory)
+	mux.HandleFunc("GET /api/scan/templates", s.handleListScanTemplates)
+	mux.HandleFunc("POST /api/scan/templates", s.handleSaveScanTemplate)
+	mux.HandleFunc("GET /api/scan/templates/{id}", s.handleGetScanTemplate)
+	mux.HandleFunc("DELETE /api/scan/templates/{id}", s.handleDeleteScanTemplate)
+	mux.HandleFunc("GET /api/market-disabled", s.handleGetMarketDisabled)
+	mux.HandleFunc("POST /api/market-disabled/overrides", s.handleSetMarketDisabledOverride)
+	mux.HandleFunc("DELETE /api/market-disabled/overrides/{typeID}", s.handleDeleteMarketDisabledOverride)
 	// Auth
 	mux.HandleFunc("GET /api/auth/login", s.handleAuthLogin)
 	mux.HandleFunc("GET /api/auth/callback", s.handleAuthCallback)
 	mux.HandleFunc("GET /api/auth/status", s.handleAuthStatus)
 	mux.HandleFunc("POST /api/auth/logout", s.handleAuthLogout)
+	mux.HandleFunc("POST /api/auth/logout/all", s.handleAuthLogoutAll)
 	mux.HandleFunc("POST /api/auth/character/select", s.handleAuthCharacterSelect)
 	mux.HandleFunc("DELETE /api/auth/characters/{characterID}", s.handleAuthCharacterDelete)
+	mux.HandleFunc("POST /api/auth/characters/{characterID}/label", s.handleAuthCharacterLabel)
 	mux.HandleFunc("GET /api/auth/character", s.handleAuthCharacter)
+	mux.HandleFunc("GET /api/auth/networth", s.handleAuthNetWorth)
 	mux.HandleFunc("GET /api/auth/location", s.handleAuthLocation)
 	mux.HandleFunc("GET /api/auth/undercuts", s.handleAuthUndercuts)
 	mux.HandleFunc("GET /api/auth/orders/desk", s.handleAuthOrderDesk)
@@ -703,6 +936,7 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("POST /api/auth/industry/projects/{projectID}/plan", s.handleAuthPlanIndustryProject)
 	mux.HandleFunc("POST /api/auth/industry/projects/{projectID}/materials/rebalance", s.handleAuthRebalanceIndustryProjectMaterials)
 	mux.HandleFunc("POST /api/auth/industry/projects/{projectID}/blueprints/sync", s.handleAuthSyncIndustryProjectBlueprintPool)
+	mux.HandleFunc("GET /api/auth/industry/projects/{projectID}/bom", s.handleAuthIndustryProjectBOM)
 	mux.HandleFunc("PATCH /api/auth/industry/tasks/status", s.handleAuthUpdateIndustryTaskStatus)
 	mux.HandleFunc("PATCH /api/auth/industry/tasks/status/bulk", s.handleAuthBulkUpdateIndustryTaskStatus)
 	mux.HandleFunc("PATCH /api/auth/industry/tasks/priority", s.handleAuthUpdateIndustryTaskPriority)
@@ -710,15 +944,26 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("PATCH /api/auth/industry/jobs/status", s.handleAuthUpdateIndustryJobStatus)
 	mux.HandleFunc("PATCH /api/auth/industry/jobs/status/bulk", s.handleAuthBulkUpdateIndustryJobStatus)
 	mux.HandleFunc("GET /api/auth/industry/ledger", s.handleAuthIndustryLedger)
-	mux.HandleFunc("POST /api/auth/station/command", s.handleAuthStationCommand)
+	mux.HandleFunc("GET /api/auth/industry/locations", s.handleAuthIndustryLocations)
+	mux.HandleFunc("POST /api/auth/station/command", s.gated("station_command", s.handleAuthStationCommand))
+	mux.HandleFunc("POST /api/auth/simulate-day", s.gated("simulate_day", s.handleAuthSimulateDay))
+	mux.HandleFunc("GET /api/auth/capital-allocation", s.handleAuthCapitalAllocation)
 	mux.HandleFunc("POST /api/auth/station/ai/chat", s.handleAuthStationAIChat)
 	mux.HandleFunc("POST /api/auth/station/ai/chat/stream", s.handleAuthStationAIChatStream)
+	mux.HandleFunc("GET /api/auth/station/ai/conversations", s.handleAuthListStationAIConversations)
+	mux.HandleFunc("POST /api/auth/station/ai/conversations", s.handleAuthCreateStationAIConversation)
+	mux.HandleFunc("GET /api/auth/station/ai/conversations/{conversationID}", s.handleAuthGetStationAIConversation)
+	mux.HandleFunc("GET /api/auth/station/ai/usage", s.handleAuthStationAIUsage)
 	mux.HandleFunc("GET /api/auth/portfolio", s.handleAuthPortfolio)
 	mux.HandleFunc("GET /api/auth/portfolio/optimize", s.handleAuthPortfolioOptimize)
+	mux.HandleFunc("GET /api/auth/positions", s.handleAuthPositions)
+	mux.HandleFunc("GET /api/auth/pnl", s.handleAuthPnL)
 	mux.HandleFunc("GET /api/auth/structures", s.handleAuthStructures)
 	// UI operations (requires auth)
 	mux.HandleFunc("POST /api/ui/open-market", s.handleUIOpenMarket)
+	mux.HandleFunc("POST /api/ui/open-market/batch", s.handleUIOpenMarketBatch)
 	mux.HandleFunc("POST /api/ui/set-waypoint", s.handleUISetWaypoint)
+	mux.HandleFunc("POST /api/ui/set-route", s.handleUISetRoute)
 	mux.HandleFunc("POST /api/ui/open-contract", s.handleUIOpenContract)
 	// Contracts
 	mux.HandleFunc("GET /api/contracts/{contract_id}/items", s.handleGetContractItems)
@@ -728,15 +973,19 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("GET /api/industry/systems", s.handleIndustrySystems)
 	mux.HandleFunc("GET /api/industry/status", s.handleIndustryStatus)
 	mux.HandleFunc("POST /api/execution/plan", s.handleExecutionPlan)
+	mux.HandleFunc("GET /api/market/npc-orders", s.handleMarketNPCOrders)
+	mux.HandleFunc("GET /api/market/region/{regionID}/summary", s.handleRegionMarketSummary)
 	// Demand / War Tracker
 	mux.HandleFunc("GET /api/demand/regions", s.handleDemandRegions)
 	mux.HandleFunc("GET /api/demand/hotzones", s.handleDemandHotZones)
 	mux.HandleFunc("GET /api/demand/region/{regionID}", s.handleDemandRegion)
 	mux.HandleFunc("GET /api/demand/opportunities/{regionID}", s.handleDemandOpportunities)
 	mux.HandleFunc("GET /api/demand/fittings/{regionID}", s.handleDemandFittings)
+	mux.HandleFunc("POST /api/demand/fittings/custom", s.handleDemandCustomFitting)
 	mux.HandleFunc("POST /api/demand/refresh", s.handleDemandRefresh)
 	// PLEX+
 	mux.HandleFunc("GET /api/plex/dashboard", s.handlePLEXDashboard)
+	mux.HandleFunc("GET /api/plex/history", s.handlePLEXHistory)
 	// Corporation
 	mux.HandleFunc("GET /api/auth/roles", s.handleAuthRoles)
 	mux.HandleFunc("GET /api/corp/dashboard", s.handleCorpDashboard)
@@ -746,7 +995,7 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("GET /api/corp/orders", s.handleCorpOrders)
 	mux.HandleFunc("GET /api/corp/industry", s.handleCorpIndustry)
 	mux.HandleFunc("GET /api/corp/mining", s.handleCorpMining)
-	return corsMiddleware(s.userScopeMiddleware(mux))
+	return s.accessLogMiddleware(corsMiddleware(s.apiKeyMiddleware(s.userScopeMiddleware(mux))))
 }
 
 func corsMiddleware(next http.Handler) http.Handler {
@@ -757,9 +1006,10 @@ func corsMiddleware(next http.Handler) http.Handler {
 			allowedOrigin = origin
 			w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
 			w.Header().Set("Vary", "Origin")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Corp-Demo")
 		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
 		if r.Method == "OPTIONS" {
 			if origin != "" && allowedOrigin == "" {
 				w.WriteHeader(http.StatusForbidden)
@@ -818,10 +1068,10 @@ func writeJSONStatus(w http.ResponseWriter, status int, v interface{}) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
+// writeError writes an error with the generic code for its HTTP status; use
+// writeErrorCode when the client can act on a more specific one.
 func writeError(w http.ResponseWriter, code int, msg string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+	writeErrorCode(w, code, errorCodeForStatus(code), msg)
 }
 
 type stationAIWikiGollumPayload struct {
@@ -1008,13 +1258,13 @@ func filterFlipResultsExcludeStructures(results []engine.FlipResult) []engine.Fl
 	return filtered
 }
 
-func filterFlipResultsMarketDisabled(results []engine.FlipResult) []engine.FlipResult {
+func (s *Server) filterFlipResultsMarketDisabled(userID string, results []engine.FlipResult) []engine.FlipResult {
 	if len(results) == 0 {
 		return results
 	}
 	filtered := results[:0]
 	for _, r := range results {
-		if engine.IsMarketDisabledTypeID(r.TypeID) {
+		if s.isMarketDisabledForUser(userID, r.TypeID) {
 			continue
 		}
 		filtered = append(filtered, r)
@@ -1050,6 +1300,16 @@ func contractResultKPIProfit(r engine.ContractResult) float64 {
 	return r.Profit
 }
 
+// routeAllHops returns outbound hops followed by return-leg hops (round trips).
+func routeAllHops(route engine.RouteResult) []engine.RouteHop {
+	if len(route.ReturnHops) == 0 {
+		return route.Hops
+	}
+	all := make([]engine.RouteHop, 0, len(route.Hops)+len(route.ReturnHops))
+	all = append(all, route.Hops...)
+	return append(all, route.ReturnHops...)
+}
+
 func filterRouteResultsExcludeStructures(results []engine.RouteResult) []engine.RouteResult {
 	if len(results) == 0 {
 		return results
@@ -1057,7 +1317,7 @@ func filterRouteResultsExcludeStructures(results []engine.RouteResult) []engine.
 	filtered := results[:0]
 	for _, route := range results {
 		skip := false
-		for _, hop := range route.Hops {
+		for _, hop := range routeAllHops(route) {
 			if isPlayerStructure(hop.LocationID) || isPlayerStructure(hop.DestLocationID) {
 				skip = true
 				break
@@ -1070,15 +1330,15 @@ func filterRouteResultsExcludeStructures(results []engine.RouteResult) []engine.
 	return filtered
 }
 
-func filterRouteResultsMarketDisabled(results []engine.RouteResult) []engine.RouteResult {
+func (s *Server) filterRouteResultsMarketDisabled(userID string, results []engine.RouteResult) []engine.RouteResult {
 	if len(results) == 0 {
 		return results
 	}
 	filtered := results[:0]
 	for _, route := range results {
 		blocked := false
-		for _, hop := range route.Hops {
-			if engine.IsMarketDisabledTypeID(hop.TypeID) {
+		for _, hop := range routeAllHops(route) {
+			if s.isMarketDisabledForUser(userID, hop.TypeID) {
 				blocked = true
 				break
 			}
@@ -1105,13 +1365,13 @@ func filterStationTradesExcludeStructures(results []engine.StationTrade) []engin
 	return filtered
 }
 
-func filterStationTradesMarketDisabled(results []engine.StationTrade) []engine.StationTrade {
+func (s *Server) filterStationTradesMarketDisabled(userID string, results []engine.StationTrade) []engine.StationTrade {
 	if len(results) == 0 {
 		return results
 	}
 	filtered := results[:0]
 	for _, r := range results {
-		if engine.IsMarketDisabledTypeID(r.TypeID) {
+		if s.isMarketDisabledForUser(userID, r.TypeID) {
 			continue
 		}
 		filtered = append(filtered, r)
@@ -1146,7 +1406,10 @@ func (s *Server) stationCacheMetaForRegions(regionIDs map[int32]bool) stationCac
 	if s == nil || s.esi == nil {
 		return stationCacheMeta{Regions: len(regionIDs)}
 	}
-	window := s.esi.OrderCacheWindow(mapRegionIDSet(regionIDs), "all")
+	return stationCacheMetaFromWindow(s.esi.OrderCacheWindow(mapRegionIDSet(regionIDs), "all"))
+}
+
+func stationCacheMetaFromWindow(window esi.OrderCacheWindow) stationCacheMeta {
 	meta := stationCacheMeta{
 		CurrentRevision: window.CurrentRevision,
 		MinTTLSec:       window.MinTTLSeconds,
@@ -1167,7 +1430,7 @@ func (s *Server) stationCacheMetaForRegions(regionIDs map[int32]bool) stationCac
 // filterContractResultsMarketDisabled is a defense-in-depth guard:
 // even if upstream scan/history contained unsafe contracts, drop ones that include
 // market-disabled types (e.g. MPTC) before returning to UI.
-func (s *Server) filterContractResultsMarketDisabled(results []engine.ContractResult) []engine.ContractResult {
+func (s *Server) filterContractResultsMarketDisabled(userID string, results []engine.ContractResult) []engine.ContractResult {
 	if len(results) == 0 {
 		return results
 	}
@@ -1203,7 +1466,7 @@ func (s *Server) filterContractResultsMarketDisabled(results []engine.ContractRe
 
 		blocked := false
 		for _, item := range items {
-			if item.Quantity > 0 && engine.IsMarketDisabledTypeID(item.TypeID) {
+			if item.Quantity > 0 && s.isMarketDisabledForUser(userID, item.TypeID) {
 				blocked = true
 				break
 			}
@@ -1290,7 +1553,7 @@ func (s *Server) enrichRouteStructureNames(userID string, results []engine.Route
 	}
 	structureIDs := make(map[int64]bool)
 	for _, route := range results {
-		for _, hop := range route.Hops {
+		for _, hop := range routeAllHops(route) {
 			if isPlayerStructure(hop.LocationID) {
 				structureIDs[hop.LocationID] = true
 			}
@@ -1318,20 +1581,22 @@ func (s *Server) enrichRouteStructureNames(userID string, results []engine.Route
 
 	// Update names and filter out routes with unresolved structures
 	filtered := make([]engine.RouteResult, 0, len(results))
-	for i := range results {
-		skip := false
-		for j := range results[i].Hops {
-			if unresolved[results[i].Hops[j].LocationID] || unresolved[results[i].Hops[j].DestLocationID] {
-				skip = true
-				break
+	applyNames := func(hops []engine.RouteHop) bool {
+		for j := range hops {
+			if unresolved[hops[j].LocationID] || unresolved[hops[j].DestLocationID] {
+				return false
 			}
-			if name, ok := resolved[results[i].Hops[j].LocationID]; ok {
-				results[i].Hops[j].StationName = name
+			if name, ok := resolved[hops[j].LocationID]; ok {
+				hops[j].StationName = name
 			}
-			if name, ok := resolved[results[i].Hops[j].DestLocationID]; ok {
-				results[i].Hops[j].DestStationName = name
+			if name, ok := resolved[hops[j].DestLocationID]; ok {
+				hops[j].DestStationName = name
 			}
 		}
+		return true
+	}
+	for i := range results {
+		skip := !applyNames(results[i].Hops) || !applyNames(results[i].ReturnHops)
 		if !skip {
 			filtered = append(filtered, results[i])
 		}
@@ -1368,6 +1633,22 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	if !lastOK.IsZero() {
 		result["esi_last_ok"] = lastOK.Unix()
 	}
+	// TQ's own state explains empty or stale scans during downtime and VIP.
+	// Omitted when ESI is unreachable or answers non-200, since it is unknown then.
+	if tq, err := s.esi.FetchServerStatus(); err == nil {
+		result["server_online"] = tq.Online
+		result["player_count"] = tq.Players
+		result["vip"] = tq.VIP
+	}
+	if remain, resetAt, ok := s.esi.ErrorLimit(); ok {
+		result["esi_error_limit_remain"] = remain
+		result["esi_error_limit_reset"] = resetAt.Unix()
+	}
+	if s.db != nil {
+		scanDays, marketDays := s.db.HistoryRetention()
+		result["history_retention_days"] = scanDays
+		result["market_history_retention_days"] = marketDays
+	}
 
 	writeJSON(w, result)
 }
@@ -1380,14 +1661,74 @@ func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleSetConfig(w http.ResponseWriter, r *http.Request) {
 	userID := userIDFromRequest(r)
-	cfg := s.loadConfigForUser(userID)
 
 	var patch map[string]json.RawMessage
 	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+		return
+	}
+
+	serverKeys := stripServerConfigKeys(userID, patch)
+	cfg, err := s.patchConfigForUser(userID, patch)
+	if err != nil {
+		writeError(w, 500, "failed to save config")
+		return
+	}
+	if serverKeys {
+		s.applyServerConfig(userID, cfg)
+	}
+	writeJSON(w, cfg)
+}
+
+// demandCacheTTL is the configured demand freshness window.
+func (s *Server) demandCacheTTL() time.Duration {
+	s.mu.RLock()
+	ttl := s.demandTTL
+	s.mu.RUnlock()
+	if ttl <= 0 {
+		return zkillboard.DefaultDemandCacheTTL
+	}
+	return ttl
+}
+
+// applyDemandCacheTTL stores the server-level demand cache TTL and applies it
+// to the current analyzer; SetSDE applies it to the next one.
+func (s *Server) applyDemandCacheTTL(minutes int) {
+	ttl := time.Duration(minutes) * time.Minute
+	s.mu.Lock()
+	s.demandTTL = ttl
+	analyzer := s.demandAnalyzer
+	s.mu.Unlock()
+	if analyzer != nil {
+		analyzer.SetCacheTTL(ttl)
+	}
+}
+
+// handleValidateConfig applies a config patch to the current config and
+// returns the clamped result with notes on every value that was adjusted.
+// Nothing is persisted.
+func (s *Server) handleValidateConfig(w http.ResponseWriter, r *http.Request) {
+	cfg := s.loadConfigForUser(userIDFromRequest(r))
+
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
+	applyConfigPatch(cfg, patch)
+	clamped, adjustments := config.Clamp(cfg)
+	if adjustments == nil {
+		adjustments = []string{}
+	}
+	writeJSON(w, map[string]interface{}{
+		"config":      clamped,
+		"adjustments": adjustments,
+	})
+}
 
+// applyConfigPatch copies the known keys of a POST /api/config body into cfg.
+// Values are not range-checked; run config.Clamp afterwards.
+func applyConfigPatch(cfg *config.Config, patch map[string]json.RawMessage) {
 	if v, ok := patch["system_name"]; ok {
 		json.Unmarshal(v, &cfg.SystemName)
 	}
@@ -1484,6 +1825,12 @@ func (s *Server) handleSetConfig(w http.ResponseWriter, r *http.Request) {
 	if v, ok := patch["sell_order_mode"]; ok {
 		json.Unmarshal(v, &cfg.SellOrderMode)
 	}
+	if v, ok := patch["exclude_type_ids"]; ok {
+		json.Unmarshal(v, &cfg.ExcludeTypeIDs)
+	}
+	if v, ok := patch["exclude_market_group_ids"]; ok {
+		json.Unmarshal(v, &cfg.ExcludeMarketGroupIDs)
+	}
 	if v, ok := patch["alert_telegram"]; ok {
 		json.Unmarshal(v, &cfg.AlertTelegram)
 	}
@@ -1502,160 +1849,54 @@ func (s *Server) handleSetConfig(w http.ResponseWriter, r *http.Request) {
 	if v, ok := patch["alert_discord_webhook"]; ok {
 		json.Unmarshal(v, &cfg.AlertDiscordWebhook)
 	}
-	if v, ok := patch["opacity"]; ok {
-		json.Unmarshal(v, &cfg.Opacity)
-	}
-
-	// Validate bounds
-	if cfg.CargoCapacity < 0 {
-		cfg.CargoCapacity = 0
-	}
-	if cfg.BuyRadius < 0 {
-		cfg.BuyRadius = 0
-	} else if cfg.BuyRadius > 50 {
-		cfg.BuyRadius = 50
-	}
-	if cfg.SellRadius < 0 {
-		cfg.SellRadius = 0
-	} else if cfg.SellRadius > 50 {
-		cfg.SellRadius = 50
-	}
-	if cfg.MinMargin < 0 {
-		cfg.MinMargin = 0
-	} else if cfg.MinMargin > 100 {
-		cfg.MinMargin = 100
-	}
-	if cfg.SalesTaxPercent < 0 {
-		cfg.SalesTaxPercent = 0
-	} else if cfg.SalesTaxPercent > 100 {
-		cfg.SalesTaxPercent = 100
-	}
-	if cfg.BrokerFeePercent < 0 {
-		cfg.BrokerFeePercent = 0
-	} else if cfg.BrokerFeePercent > 100 {
-		cfg.BrokerFeePercent = 100
-	}
-	if cfg.BuyBrokerFeePercent < 0 {
-		cfg.BuyBrokerFeePercent = 0
-	} else if cfg.BuyBrokerFeePercent > 100 {
-		cfg.BuyBrokerFeePercent = 100
-	}
-	if cfg.SellBrokerFeePercent < 0 {
-		cfg.SellBrokerFeePercent = 0
-	} else if cfg.SellBrokerFeePercent > 100 {
-		cfg.SellBrokerFeePercent = 100
+	if v, ok := patch["alert_order_expiry"]; ok {
+		json.Unmarshal(v, &cfg.AlertOrderExpiry)
 	}
-	if cfg.BuySalesTaxPercent < 0 {
-		cfg.BuySalesTaxPercent = 0
-	} else if cfg.BuySalesTaxPercent > 100 {
-		cfg.BuySalesTaxPercent = 100
+	if v, ok := patch["alert_undercut"]; ok {
+		json.Unmarshal(v, &cfg.AlertUndercut)
 	}
-	if cfg.SellSalesTaxPercent < 0 {
-		cfg.SellSalesTaxPercent = 0
-	} else if cfg.SellSalesTaxPercent > 100 {
-		cfg.SellSalesTaxPercent = 100
+	if v, ok := patch["ai_number_locale"]; ok {
+		json.Unmarshal(v, &cfg.AINumberLocale)
 	}
-	if cfg.MinDailyVolume < 0 {
-		cfg.MinDailyVolume = 0
+	if v, ok := patch["ai_isk_format"]; ok {
+		json.Unmarshal(v, &cfg.AIISKFormat)
 	}
-	if cfg.MaxInvestment < 0 {
-		cfg.MaxInvestment = 0
+	if v, ok := patch["ai_wiki_pages"]; ok {
+		json.Unmarshal(v, &cfg.AIWikiPages)
 	}
-	if cfg.MinItemProfit < 0 {
-		cfg.MinItemProfit = 0
+	if v, ok := patch["ai_tools_enabled"]; ok {
+		json.Unmarshal(v, &cfg.AIToolsEnabled)
 	}
-	if cfg.MinS2BPerDay < 0 {
-		cfg.MinS2BPerDay = 0
+	if v, ok := patch["ai_tool_allowlist"]; ok {
+		json.Unmarshal(v, &cfg.AIToolAllowlist)
 	}
-	if cfg.MinBfSPerDay < 0 {
-		cfg.MinBfSPerDay = 0
+	if v, ok := patch["price_fallback_enabled"]; ok {
+		json.Unmarshal(v, &cfg.PriceFallbackEnabled)
 	}
-	if cfg.MinS2BBfSRatio < 0 {
-		cfg.MinS2BBfSRatio = 0
+	if v, ok := patch["station_trends_enabled"]; ok {
+		json.Unmarshal(v, &cfg.StationTrendsEnabled)
 	}
-	if cfg.MaxS2BBfSRatio < 0 {
-		cfg.MaxS2BBfSRatio = 0
+	if v, ok := patch["esi_max_retries"]; ok {
+		json.Unmarshal(v, &cfg.ESIMaxRetries)
 	}
-	if cfg.MinRouteSecurity < 0 {
-		cfg.MinRouteSecurity = 0
-	} else if cfg.MinRouteSecurity > 1 {
-		cfg.MinRouteSecurity = 1
+	if v, ok := patch["demand_cache_minutes"]; ok {
+		json.Unmarshal(v, &cfg.DemandCacheMinutes)
 	}
-	if cfg.AvgPricePeriod <= 0 {
-		cfg.AvgPricePeriod = 14
-	} else if cfg.AvgPricePeriod > 365 {
-		cfg.AvgPricePeriod = 365
-	}
-	if cfg.MinPeriodROI < 0 {
-		cfg.MinPeriodROI = 0
-	}
-	if cfg.MaxDOS < 0 {
-		cfg.MaxDOS = 0
-	}
-	if cfg.MinDemandPerDay < 0 {
-		cfg.MinDemandPerDay = 0
-	}
-	if cfg.PurchaseDemandDays < 0 {
-		cfg.PurchaseDemandDays = 0
-	}
-	if cfg.ShippingCostPerM3Jump < 0 {
-		cfg.ShippingCostPerM3Jump = 0
-	}
-	if cfg.TargetMarketLocationID < 0 {
-		cfg.TargetMarketLocationID = 0
-	}
-	cfg.TargetRegion = strings.TrimSpace(cfg.TargetRegion)
-	cfg.TargetMarketSystem = strings.TrimSpace(cfg.TargetMarketSystem)
-	{
-		clean := make([]string, 0, len(cfg.SourceRegions))
-		seen := make(map[string]bool, len(cfg.SourceRegions))
-		for _, name := range cfg.SourceRegions {
-			trimmed := strings.TrimSpace(name)
-			if trimmed == "" {
-				continue
-			}
-			key := strings.ToLower(trimmed)
-			if seen[key] {
-				continue
-			}
-			seen[key] = true
-			clean = append(clean, trimmed)
-			if len(clean) >= 32 {
-				break
-			}
-		}
-		cfg.SourceRegions = clean
+	if v, ok := patch["inventory_cache_minutes"]; ok {
+		json.Unmarshal(v, &cfg.InventoryCacheMinutes)
 	}
-	{
-		clean := make([]int32, 0, len(cfg.CategoryIDs))
-		seen := make(map[int32]bool, len(cfg.CategoryIDs))
-		for _, id := range cfg.CategoryIDs {
-			if id <= 0 || seen[id] {
-				continue
-			}
-			seen[id] = true
-			clean = append(clean, id)
-			if len(clean) >= 64 {
-				break
-			}
-		}
-		cfg.CategoryIDs = clean
+	if v, ok := patch["history_retention_days"]; ok {
+		json.Unmarshal(v, &cfg.HistoryRetentionDays)
 	}
-	if cfg.Opacity < 0 {
-		cfg.Opacity = 0
-	} else if cfg.Opacity > 100 {
-		cfg.Opacity = 100
+	if v, ok := patch["market_history_retention_days"]; ok {
+		json.Unmarshal(v, &cfg.MarketHistoryRetentionDays)
 	}
-	// Keep at least one alert channel enabled.
-	if !cfg.AlertTelegram && !cfg.AlertDiscord && !cfg.AlertDesktop {
-		cfg.AlertDesktop = true
+	if v, ok := patch["share_link_ttl_hours"]; ok {
+		json.Unmarshal(v, &cfg.ShareLinkTTLHours)
 	}
-
-	if err := s.saveConfigForUser(userID, cfg); err != nil {
-		writeError(w, 500, "failed to save config")
-		return
+	if v, ok := patch["opacity"]; ok {
+		json.Unmarshal(v, &cfg.Opacity)
 	}
-	writeJSON(w, cfg)
 }
 
 type alertSendResult struct {
@@ -1671,7 +1912,7 @@ func (s *Server) handleAlertsTest(w http.ResponseWriter, r *http.Request) {
 		Message string `json:"message"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 	msg := strings.TrimSpace(req.Message)
@@ -1843,6 +2084,65 @@ func (s *Server) handleRegionAutocomplete(w http.ResponseWriter, r *http.Request
 	writeJSON(w, map[string][]string{"regions": result})
 }
 
+// typeSuggestion is one match of the type-name autocomplete.
+type typeSuggestion struct {
+	TypeID int32  `json:"type_id"`
+	Name   string `json:"name"`
+}
+
+// handleTypeAutocomplete suggests item types by name, prefix matches first,
+// skipping types that are market-disabled for the user.
+func (s *Server) handleTypeAutocomplete(w http.ResponseWriter, r *http.Request) {
+	q := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	if q == "" || !s.isReady() {
+		writeJSON(w, map[string][]typeSuggestion{"types": {}})
+		return
+	}
+
+	s.mu.RLock()
+	types := s.sdeData.Types
+	s.mu.RUnlock()
+
+	userID := userIDFromRequest(r)
+	var prefix, contains []typeSuggestion
+	for id, t := range types {
+		lower := strings.ToLower(t.Name)
+		isPrefix := strings.HasPrefix(lower, q)
+		if !isPrefix && !strings.Contains(lower, q) {
+			continue
+		}
+		if s.isMarketDisabledForUser(userID, id) {
+			continue
+		}
+		if isPrefix {
+			prefix = append(prefix, typeSuggestion{TypeID: id, Name: t.Name})
+		} else {
+			contains = append(contains, typeSuggestion{TypeID: id, Name: t.Name})
+		}
+	}
+	// Types is a map; sort each bucket so results are stable between calls.
+	byName := func(list []typeSuggestion) {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Name != list[j].Name {
+				return list[i].Name < list[j].Name
+			}
+			return list[i].TypeID < list[j].TypeID
+		})
+	}
+	byName(prefix)
+	byName(contains)
+
+	result := append(prefix, contains...)
+	if len(result) > 15 {
+		result = result[:15]
+	}
+	if result == nil {
+		result = []typeSuggestion{}
+	}
+
+	writeJSON(w, map[string][]typeSuggestion{"types": result})
+}
+
 type scanRequest struct {
 	SystemName           string  `json:"system_name"`
 	CargoCapacity        float64 `json:"cargo_capacity"`
@@ -1872,27 +2172,92 @@ type scanRequest struct {
 	ShippingCostPerM3Jump  float64  `json:"shipping_cost_per_m3_jump"`
 	MinRouteSecurity       float64  `json:"min_route_security"`        // 0 = all; 0.45 = highsec only; 0.7 = min 0.7
 	SourceRegions          []string `json:"source_regions"`            // Optional source region names (e.g. ["The Forge","Domain"]).
+	SourceRegionIDs        []int32  `json:"source_region_ids"`         // Optional source region IDs; replace source_regions when set.
 	TargetRegion           string   `json:"target_region"`             // Empty = search all by radius; region name = search only in that region
+	TargetRegionID         int32    `json:"target_region_id"`          // Optional target region ID; replaces target_region when set.
 	TargetMarketSystem     string   `json:"target_market_system"`      // Optional destination marketplace system.
 	TargetMarketLocationID int64    `json:"target_market_location_id"` // Optional destination marketplace location_id.
+	TargetMarketSystems    []string `json:"target_market_systems"`     // Regional day trader: extra destinations scanned in the same pass.
+	ForceInventoryRefresh  bool     `json:"force_inventory_refresh"`   // Regional day trader: ignore the cached inventory snapshot.
 	// Contract-specific filters
-	MinContractPrice           float64 `json:"min_contract_price"`
-	MaxContractMargin          float64 `json:"max_contract_margin"`
-	MinPricedRatio             float64 `json:"min_priced_ratio"`
-	RequireHistory             bool    `json:"require_history"`
-	ContractInstantLiquidation bool    `json:"contract_instant_liquidation"`
-	ContractHoldDays           int     `json:"contract_hold_days"`
-	ContractTargetConfidence   float64 `json:"contract_target_confidence"`
-	ExcludeRigsWithShip        bool    `json:"exclude_rigs_with_ship"`
+	MinContractPrice           float64  `json:"min_contract_price"`
+	MaxContractMargin          float64  `json:"max_contract_margin"`
+	MinPricedRatio             float64  `json:"min_priced_ratio"`
+	RequireHistory             bool     `json:"require_history"`
+	ContractInstantLiquidation bool     `json:"contract_instant_liquidation"`
+	ContractHoldDays           int      `json:"contract_hold_days"`
+	ContractTargetConfidence   float64  `json:"contract_target_confidence"`
+	ExcludeRigsWithShip        bool     `json:"exclude_rigs_with_ship"`
+	ContractTypes              []string `json:"contract_types"`     // item_exchange, auction; empty = item_exchange
+	ExcludeIssuerIDs           []int64  `json:"exclude_issuer_ids"` // issuing characters or corporations
 	// Category filter for regional day trader (empty = all categories)
 	CategoryIDs []int32 `json:"category_ids"`
 	// Sell-order mode: use target lowest sell price instead of highest buy order price
 	SellOrderMode bool `json:"sell_order_mode"`
 	// Player structures
 	IncludeStructures bool `json:"include_structures"`
+	// Drop NPC-seeded orders from profit calculations
+	ExcludeNPCOrders bool `json:"exclude_npc_orders"`
+	// Per-scan exclusions, added to the ones saved in the user's config
+	ExcludeTypeIDs        []int32 `json:"exclude_type_ids"`
+	ExcludeMarketGroupIDs []int32 `json:"exclude_market_group_ids"`
+}
+
+// resolveScanRegions maps a scan request's source and target regions to IDs.
+// Numeric IDs win over names when both are sent, so scripts need not match
+// the SDE's English region names; either form must name a known region.
+func resolveScanRegions(sdeData *sde.Data, req scanRequest) ([]int32, int32, error) {
+	var sourceRegionIDs []int32
+	seen := make(map[int32]bool)
+	add := func(rid int32) {
+		if !seen[rid] {
+			seen[rid] = true
+			sourceRegionIDs = append(sourceRegionIDs, rid)
+		}
+	}
+	if len(req.SourceRegionIDs) > 0 {
+		for _, rid := range req.SourceRegionIDs {
+			if _, ok := sdeData.Regions[rid]; !ok {
+				return nil, 0, fmt.Errorf("source region id not found: %d", rid)
+			}
+			add(rid)
+		}
+	} else {
+		for _, sourceRegionName := range req.SourceRegions {
+			name := strings.TrimSpace(sourceRegionName)
+			if name == "" {
+				continue
+			}
+			rid, ok := sdeData.RegionByName[strings.ToLower(name)]
+			if !ok {
+				return nil, 0, fmt.Errorf("source region not found: %s", sourceRegionName)
+			}
+			add(rid)
+		}
+	}
+	if sourceRegionIDs == nil {
+		sourceRegionIDs = []int32{}
+	}
+
+	var targetRegionID int32
+	if req.TargetRegionID != 0 {
+		if _, ok := sdeData.Regions[req.TargetRegionID]; !ok {
+			return nil, 0, fmt.Errorf("target region id not found: %d", req.TargetRegionID)
+		}
+		targetRegionID = req.TargetRegionID
+	} else if name := strings.TrimSpace(req.TargetRegion); name != "" {
+		rid, ok := sdeData.RegionByName[strings.ToLower(name)]
+		if !ok {
+			return nil, 0, fmt.Errorf("region not found: %s", req.TargetRegion)
+		}
+		targetRegionID = rid
+	}
+	return sourceRegionIDs, targetRegionID, nil
 }
 
-func (s *Server) parseScanParams(req scanRequest) (engine.ScanParams, error) {
+// parseScanParams resolves a scan request for userID, whose saved type and
+// market-group exclusions are merged into the request's.
+func (s *Server) parseScanParams(userID string, req scanRequest) (engine.ScanParams, error) {
 	if !s.isReady() {
 		return engine.ScanParams{}, fmt.Errorf("SDE not loaded yet")
 	}
@@ -1900,35 +2265,12 @@ func (s *Server) parseScanParams(req scanRequest) (engine.ScanParams, error) {
 	s.mu.RLock()
 	systemID, ok := s.sdeData.SystemByName[strings.ToLower(req.SystemName)]
 
-	// Parse target region if specified.
-	var targetRegionID int32
+	// Parse source and target regions if specified.
 	var targetMarketSystemID int32
-	sourceRegionIDs := make([]int32, 0, len(req.SourceRegions))
-	sourceRegionSeen := make(map[int32]bool, len(req.SourceRegions))
-	for _, sourceRegionName := range req.SourceRegions {
-		name := strings.TrimSpace(sourceRegionName)
-		if name == "" {
-			continue
-		}
-		rid, regionOK := s.sdeData.RegionByName[strings.ToLower(name)]
-		if !regionOK {
-			s.mu.RUnlock()
-			return engine.ScanParams{}, fmt.Errorf("source region not found: %s", sourceRegionName)
-		}
-		if !sourceRegionSeen[rid] {
-			sourceRegionSeen[rid] = true
-			sourceRegionIDs = append(sourceRegionIDs, rid)
-		}
-	}
-	targetRegionName := strings.TrimSpace(req.TargetRegion)
-	if targetRegionName != "" {
-		rid, regionOK := s.sdeData.RegionByName[strings.ToLower(targetRegionName)]
-		if regionOK {
-			targetRegionID = rid
-		} else {
-			s.mu.RUnlock()
-			return engine.ScanParams{}, fmt.Errorf("region not found: %s", req.TargetRegion)
-		}
+	sourceRegionIDs, targetRegionID, err := resolveScanRegions(s.sdeData, req)
+	if err != nil {
+		s.mu.RUnlock()
+		return engine.ScanParams{}, err
 	}
 	if strings.TrimSpace(req.TargetMarketSystem) != "" {
 		sid, systemOK := s.sdeData.SystemByName[strings.ToLower(strings.TrimSpace(req.TargetMarketSystem))]
@@ -1948,6 +2290,13 @@ func (s *Server) parseScanParams(req scanRequest) (engine.ScanParams, error) {
 	if !ok {
 		return engine.ScanParams{}, fmt.Errorf("system not found: %s", req.SystemName)
 	}
+	var contractTypes []string
+	if len(req.ContractTypes) > 0 {
+		if contractTypes, err = engine.NormalizeContractTypes(req.ContractTypes); err != nil {
+			return engine.ScanParams{}, err
+		}
+	}
+	excludeTypeIDs, excludeGroupIDs := scanExclusions(s.loadConfigForUser(userID), req.ExcludeTypeIDs, req.ExcludeMarketGroupIDs)
 
 	return engine.ScanParams{
 		CurrentSystemID:            systemID,
@@ -1988,11 +2337,45 @@ func (s *Server) parseScanParams(req scanRequest) (engine.ScanParams, error) {
 		ContractHoldDays:           req.ContractHoldDays,
 		ContractTargetConfidence:   req.ContractTargetConfidence,
 		ExcludeRigsWithShip:        req.ExcludeRigsWithShip,
+		ContractTypes:              contractTypes,
+		ExcludeIssuerIDs:           req.ExcludeIssuerIDs,
 		CategoryIDs:                req.CategoryIDs,
 		SellOrderMode:              req.SellOrderMode,
+		ExcludeNPCOrders:           req.ExcludeNPCOrders,
+		ExcludeTypeIDs:             excludeTypeIDs,
+		ExcludeMarketGroupIDs:      excludeGroupIDs,
 	}, nil
 }
 
+// scanExclusions merges per-request type and market-group exclusions with
+// the ones saved in cfg.
+func scanExclusions(cfg *config.Config, typeIDs, marketGroupIDs []int32) ([]int32, []int32) {
+	if cfg == nil {
+		return typeIDs, marketGroupIDs
+	}
+	return unionInt32(cfg.ExcludeTypeIDs, typeIDs), unionInt32(cfg.ExcludeMarketGroupIDs, marketGroupIDs)
+}
+
+func unionInt32(a, b []int32) []int32 {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	seen := make(map[int32]bool, len(a)+len(b))
+	out := make([]int32, 0, len(a)+len(b))
+	for _, ids := range [][]int32{a, b} {
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				out = append(out, id)
+			}
+		}
+	}
+	return out
+}
+
 func mergeRegionSet(dst, src map[int32]bool) {
 	if dst == nil || len(src) == 0 {
 		return
@@ -2062,156 +2445,152 @@ func (s *Server) regionScopeForContractScan(params engine.ScanParams) map[int32]
 	return out
 }
 
-func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
-	userID := userIDFromRequest(r)
-	userCfg := s.loadConfigForUser(userID)
+// scanFrameSender delivers one progress/partial/result/error frame of a streamed
+// scan to the client (NDJSON line or WebSocket message).
+type scanFrameSender func(frame interface{}) error
 
-	var req scanRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
-		return
+// marshalScanFrame encodes a stream frame, substituting an error frame when
+// the payload cannot be encoded.
+func marshalScanFrame(frame interface{}) []byte {
+	line, err := json.Marshal(frame)
+	if err != nil {
+		log.Printf("[API] Scan JSON marshal error: %v", err)
+		line, _ = json.Marshal(map[string]string{"type": "error", "message": "JSON: " + err.Error()})
 	}
+	return line
+}
 
-	params, err := s.parseScanParams(req)
-	if err != nil {
-		writeError(w, 400, err.Error())
-		return
+// ndjsonScanSender writes frames as newline-delimited JSON and flushes each one.
+func ndjsonScanSender(w http.ResponseWriter, flusher http.Flusher) scanFrameSender {
+	return func(frame interface{}) error {
+		if _, err := fmt.Fprintf(w, "%s\n", marshalScanFrame(frame)); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
 	}
+}
 
+// startNDJSONScan sets streaming headers and returns the frame sender, or
+// writes an error and returns nil when the writer cannot stream.
+func startNDJSONScan(w http.ResponseWriter) scanFrameSender {
 	w.Header().Set("Content-Type", "application/x-ndjson")
 	w.Header().Set("Cache-Control", "no-cache")
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		writeError(w, 500, "streaming not supported")
-		return
+		return nil
 	}
+	return ndjsonScanSender(w, flusher)
+}
 
-	s.mu.RLock()
-	scanner := s.scanner
-	s.mu.RUnlock()
-
-	log.Printf("[API] Scan starting: system=%d, cargo=%.0f, buyR=%d, sellR=%d, margin=%.1f, tax=%.1f",
-		params.CurrentSystemID, params.CargoCapacity, params.BuyRadius, params.SellRadius, params.MinMargin, params.SalesTaxPercent)
+func isContextDone(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
 
-	startTime := time.Now()
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	var req scanRequest
+	if err := s.decodeScanRequest(r, "radius", &req); err != nil {
+		writeScanRequestError(w, err)
+		return
+	}
 
-	results, err := scanner.Scan(params, func(msg string) {
-		line, _ := json.Marshal(map[string]string{"type": "progress", "message": msg})
-		fmt.Fprintf(w, "%s\n", line)
-		flusher.Flush()
-	})
+	params, err := s.parseScanParams(userIDFromRequest(r), req)
 	if err != nil {
-		log.Printf("[API] Scan error: %v", err)
-		line, _ := json.Marshal(map[string]string{"type": "error", "message": err.Error()})
-		fmt.Fprintf(w, "%s\n", line)
-		flusher.Flush()
+		writeError(w, 400, err.Error())
 		return
 	}
 
-	durationMs := time.Since(startTime).Milliseconds()
-	log.Printf("[API] Scan complete: %d results in %dms", len(results), durationMs)
-
-	// Resolve structure names if user enabled the toggle
-	if req.IncludeStructures {
-		results = s.enrichStructureNames(userID, results)
-	} else {
-		results = filterFlipResultsExcludeStructures(results)
-	}
-	results = filterFlipResultsMarketDisabled(results)
-	regionIDs := s.regionScopeForFlipScan(params, false)
-	for _, row := range results {
-		if row.BuyRegionID > 0 {
-			regionIDs[row.BuyRegionID] = true
-		}
-		if row.SellRegionID > 0 {
-			regionIDs[row.SellRegionID] = true
-		}
-	}
-	cacheMeta := s.stationCacheMetaForRegions(regionIDs)
-
-	topProfit := 0.0
-	totalProfit := 0.0
-	for _, r := range results {
-		kpiProfit := flipResultKPIProfit(r)
-		if kpiProfit > topProfit {
-			topProfit = kpiProfit
-		}
-		totalProfit += kpiProfit
-	}
-	scanID := s.db.InsertHistoryFull("radius", req.SystemName, len(results), topProfit, totalProfit, durationMs, req)
-	go s.db.InsertFlipResults(scanID, results)
-	var scanIDPtr *int64
-	if scanID > 0 {
-		scanIDPtr = &scanID
-	}
-	go s.processWatchlistAlerts(userID, userCfg, results, scanIDPtr)
-
-	line, marshalErr := json.Marshal(map[string]interface{}{
-		"type":       "result",
-		"data":       results,
-		"count":      len(results),
-		"scan_id":    scanID,
-		"cache_meta": cacheMeta,
-	})
-	if marshalErr != nil {
-		log.Printf("[API] Scan JSON marshal error: %v", marshalErr)
-		errLine, _ := json.Marshal(map[string]string{"type": "error", "message": "JSON: " + marshalErr.Error()})
-		fmt.Fprintf(w, "%s\n", errLine)
-		flusher.Flush()
+	send := startNDJSONScan(w)
+	if send == nil {
 		return
 	}
-	fmt.Fprintf(w, "%s\n", line)
-	flusher.Flush()
+	s.runFlipScan(r.Context(), userIDFromRequest(r), req, params, false, send)
 }
 
 func (s *Server) handleScanMultiRegion(w http.ResponseWriter, r *http.Request) {
-	userID := userIDFromRequest(r)
-	userCfg := s.loadConfigForUser(userID)
-
 	var req scanRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+	if err := s.decodeScanRequest(r, "region", &req); err != nil {
+		writeScanRequestError(w, err)
 		return
 	}
 
-	params, err := s.parseScanParams(req)
+	params, err := s.parseScanParams(userIDFromRequest(r), req)
 	if err != nil {
 		writeError(w, 400, err.Error())
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/x-ndjson")
-	w.Header().Set("Cache-Control", "no-cache")
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		writeError(w, 500, "streaming not supported")
+	send := startNDJSONScan(w)
+	if send == nil {
 		return
 	}
+	s.runFlipScan(r.Context(), userIDFromRequest(r), req, params, true, send)
+}
+
+// runFlipScan runs a radius (or multi-region) flip scan and streams progress
+// and the final result through send. Results go through the same structure
+// and market filters, history, and watchlist alerting for every transport.
+// Provisional rows are streamed as "partial" frames while the scan runs; the
+// "result" frame replaces them. Canceling ctx stops the scan without a result
+// frame.
+func (s *Server) runFlipScan(ctx context.Context, userID string, req scanRequest, params engine.ScanParams, multiRegion bool, send scanFrameSender) {
+	userCfg := s.loadConfigForUser(userID)
+	params.Ctx = ctx
+	params.OnBatch = func(batch []engine.FlipResult) {
+		if ctx.Err() != nil {
+			return
+		}
+		// Structure names are resolved once, for the result frame; until
+		// then structure rows are only shown if the user asked for them.
+		if !req.IncludeStructures {
+			batch = filterFlipResultsExcludeStructures(batch)
+		}
+		batch = s.filterFlipResultsMarketDisabled(userID, batch)
+		if len(batch) == 0 {
+			return
+		}
+		send(map[string]interface{}{"type": "partial", "data": batch, "count": len(batch)})
+	}
 
 	s.mu.RLock()
 	scanner := s.scanner
 	s.mu.RUnlock()
 
-	log.Printf("[API] ScanMultiRegion starting: system=%d, cargo=%.0f, buyR=%d, sellR=%d",
-		params.CurrentSystemID, params.CargoCapacity, params.BuyRadius, params.SellRadius)
+	label, tab, scan := "Scan", "radius", scanner.Scan
+	if multiRegion {
+		label, tab, scan = "ScanMultiRegion", "region", scanner.ScanMultiRegion
+		log.Printf("[API] ScanMultiRegion starting: system=%d, cargo=%.0f, buyR=%d, sellR=%d",
+			params.CurrentSystemID, params.CargoCapacity, params.BuyRadius, params.SellRadius)
+	} else {
+		log.Printf("[API] Scan starting: system=%d, cargo=%.0f, buyR=%d, sellR=%d, margin=%.1f, tax=%.1f",
+			params.CurrentSystemID, params.CargoCapacity, params.BuyRadius, params.SellRadius, params.MinMargin, params.SalesTaxPercent)
+	}
 
 	startTime := time.Now()
 
-	results, err := scanner.ScanMultiRegion(params, func(msg string) {
-		line, _ := json.Marshal(map[string]string{"type": "progress", "message": msg})
-		fmt.Fprintf(w, "%s\n", line)
-		flusher.Flush()
+	results, err := scan(params, func(msg string) {
+		if ctx.Err() != nil {
+			return
+		}
+		send(map[string]string{"type": "progress", "message": msg})
 	})
 	if err != nil {
-		log.Printf("[API] ScanMultiRegion error: %v", err)
-		line, _ := json.Marshal(map[string]string{"type": "error", "message": err.Error()})
-		fmt.Fprintf(w, "%s\n", line)
-		flusher.Flush()
+		if isContextDone(err) {
+			log.Printf("[API] %s canceled: %v", label, err)
+			return
+		}
+		log.Printf("[API] %s error: %v", label, err)
+		send(map[string]string{"type": "error", "message": err.Error()})
+		return
+	}
+	if ctx.Err() != nil {
+		log.Printf("[API] %s canceled: %v", label, ctx.Err())
 		return
 	}
 
 	durationMs := time.Since(startTime).Milliseconds()
-	log.Printf("[API] ScanMultiRegion complete: %d results in %dms", len(results), durationMs)
+	log.Printf("[API] %s complete: %d results in %dms", label, len(results), durationMs)
 
 	// Resolve structure names if user enabled the toggle
 	if req.IncludeStructures {
@@ -2219,8 +2598,8 @@ func (s *Server) handleScanMultiRegion(w http.ResponseWriter, r *http.Request) {
 	} else {
 		results = filterFlipResultsExcludeStructures(results)
 	}
-	results = filterFlipResultsMarketDisabled(results)
-	regionIDs := s.regionScopeForFlipScan(params, true)
+	results = s.filterFlipResultsMarketDisabled(userID, results)
+	regionIDs := s.regionScopeForFlipScan(params, multiRegion)
 	for _, row := range results {
 		if row.BuyRegionID > 0 {
 			regionIDs[row.BuyRegionID] = true
@@ -2240,7 +2619,7 @@ func (s *Server) handleScanMultiRegion(w http.ResponseWriter, r *http.Request) {
 		}
 		totalProfit += kpiProfit
 	}
-	scanID := s.db.InsertHistoryFull("region", req.SystemName, len(results), topProfit, totalProfit, durationMs, req)
+	scanID := s.db.InsertHistoryFull(tab, req.SystemName, len(results), topProfit, totalProfit, durationMs, req, params)
 	go s.db.InsertFlipResults(scanID, results)
 	var scanIDPtr *int64
 	if scanID > 0 {
@@ -2248,22 +2627,14 @@ func (s *Server) handleScanMultiRegion(w http.ResponseWriter, r *http.Request) {
 	}
 	go s.processWatchlistAlerts(userID, userCfg, results, scanIDPtr)
 
-	line, marshalErr := json.Marshal(map[string]interface{}{
+	send(map[string]interface{}{
 		"type":       "result",
 		"data":       results,
 		"count":      len(results),
 		"scan_id":    scanID,
 		"cache_meta": cacheMeta,
+		"currency":   engine.Currency,
 	})
-	if marshalErr != nil {
-		log.Printf("[API] ScanMultiRegion JSON marshal error: %v", marshalErr)
-		errLine, _ := json.Marshal(map[string]string{"type": "error", "message": "JSON: " + marshalErr.Error()})
-		fmt.Fprintf(w, "%s\n", errLine)
-		flusher.Flush()
-		return
-	}
-	fmt.Fprintf(w, "%s\n", line)
-	flusher.Flush()
 }
 
 func (s *Server) handleScanRegionalDay(w http.ResponseWriter, r *http.Request) {
@@ -2271,17 +2642,22 @@ func (s *Server) handleScanRegionalDay(w http.ResponseWriter, r *http.Request) {
 	userCfg := s.loadConfigForUser(userID)
 
 	var req scanRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+	if err := s.decodeScanRequest(r, "regional_day", &req); err != nil {
+		writeScanRequestError(w, err)
 		return
 	}
 
-	params, err := s.parseScanParams(req)
+	params, err := s.parseScanParams(userIDFromRequest(r), req)
 	if err != nil {
 		writeError(w, 400, err.Error())
 		return
 	}
-	if params.TargetMarketSystemID <= 0 {
+	targets, err := s.regionalDayTargets(req, params)
+	if err != nil {
+		writeError(w, 400, err.Error())
+		return
+	}
+	if len(targets) == 0 {
 		writeError(w, 400, "target_market_system is required for regional day trader scan")
 		return
 	}
@@ -2304,12 +2680,21 @@ func (s *Server) handleScanRegionalDay(w http.ResponseWriter, r *http.Request) {
 	scanner := s.scanner
 	s.mu.RUnlock()
 
-	log.Printf("[API] ScanRegionalDay starting: system=%d, cargo=%.0f, buyR=%d, targetRegion=%d, period=%d",
-		params.CurrentSystemID, params.CargoCapacity, params.BuyRadius, params.TargetRegionID, params.AvgPricePeriod)
+	log.Printf("[API] ScanRegionalDay starting: system=%d, cargo=%.0f, buyR=%d, targets=%d, period=%d",
+		params.CurrentSystemID, params.CargoCapacity, params.BuyRadius, len(targets), params.AvgPricePeriod)
 
 	startTime := time.Now()
 
-	results, err := scanner.ScanMultiRegion(params, sendProgress)
+	inventoryMaxAge := time.Duration(userCfg.InventoryCacheMinutes) * time.Minute
+	if req.ForceInventoryRefresh {
+		inventoryMaxAge = 0
+	}
+
+	// The source books depend only on the buy scope, so they are fetched
+	// once. Each destination then fetches its own books: sell-side scope,
+	// inventory and hub rows all belong to that destination. Rows carry it
+	// as sell_system.
+	source, err := scanner.FetchSourceBooks(params, sendProgress)
 	if err != nil {
 		log.Printf("[API] ScanRegionalDay error: %v", err)
 		line, _ := json.Marshal(map[string]string{"type": "error", "message": err.Error()})
@@ -2317,23 +2702,57 @@ func (s *Server) handleScanRegionalDay(w http.ResponseWriter, r *http.Request) {
 		flusher.Flush()
 		return
 	}
+	var results []engine.FlipResult
+	var hubs []engine.RegionalDayTradeHub
+	var regionNames []string
+	totalItems, periodDays := 0, 0
+	for _, target := range targets {
+		progress := sendProgress
+		if len(targets) > 1 {
+			progress = func(msg string) { sendProgress(target.systemName + ": " + msg) }
+		}
+		targetParams := params
+		targetParams.TargetMarketSystemID = target.systemID
+		targetParams.TargetRegionID = target.regionID
+		if params.TargetMarketLocationID > 0 && !s.matchesSystemByLocationID(params.TargetMarketLocationID, target.systemID) {
+			targetParams.TargetMarketLocationID = 0
+		}
 
-	// Resolve structure names if user enabled the toggle
-	if req.IncludeStructures {
-		results = s.enrichStructureNames(userID, results)
-	} else {
-		results = filterFlipResultsExcludeStructures(results)
-	}
-	results = filterFlipResultsMarketDisabled(results)
+		targetResults, err := scanner.ScanMultiRegionFrom(source, targetParams, progress)
+		if err != nil {
+			log.Printf("[API] ScanRegionalDay error (%s): %v", target.systemName, err)
+			line, _ := json.Marshal(map[string]string{"type": "error", "message": err.Error()})
+			fmt.Fprintf(w, "%s\n", line)
+			flusher.Flush()
+			return
+		}
 
-	inventory := s.loadRegionalInventorySnapshot(
-		userID,
-		params.TargetRegionID,
-		params.TargetMarketSystemID,
-		params.TargetMarketLocationID,
-		sendProgress,
-	)
-	hubs, totalItems, targetRegionName, periodDays := scanner.BuildRegionalDayTrader(params, results, inventory, sendProgress)
+		// Resolve structure names if user enabled the toggle
+		if req.IncludeStructures {
+			targetResults = s.enrichStructureNames(userID, targetResults)
+		} else {
+			targetResults = filterFlipResultsExcludeStructures(targetResults)
+		}
+		targetResults = s.filterFlipResultsMarketDisabled(userID, targetResults)
+
+		inventory := s.loadRegionalInventorySnapshot(
+			userID,
+			targetParams.TargetRegionID,
+			targetParams.TargetMarketSystemID,
+			targetParams.TargetMarketLocationID,
+			inventoryMaxAge,
+			progress,
+		)
+		targetHubs, items, regionName, days := scanner.BuildRegionalDayTrader(targetParams, targetResults, inventory, progress)
+		results = append(results, targetResults...)
+		hubs = append(hubs, targetHubs...)
+		totalItems += items
+		periodDays = days
+		if regionName != "" && !slices.Contains(regionNames, regionName) {
+			regionNames = append(regionNames, regionName)
+		}
+	}
+	targetRegionName := strings.Join(regionNames, ", ")
 	dayRows := engine.FlattenRegionalDayHubs(hubs)
 
 	durationMs := time.Since(startTime).Milliseconds()
@@ -2379,7 +2798,7 @@ func (s *Server) handleScanRegionalDay(w http.ResponseWriter, r *http.Request) {
 	if historyCount == 0 {
 		historyCount = len(results)
 	}
-	scanID := s.db.InsertHistoryFull("region", req.SystemName, historyCount, topProfit, totalProfit, durationMs, req)
+	scanID := s.db.InsertHistoryFull("region", req.SystemName, historyCount, topProfit, totalProfit, durationMs, req, params)
 	if scanID > 0 && len(dayRows) > 0 {
 		go s.db.InsertRegionalDayResults(scanID, dayRows)
 	}
@@ -2401,6 +2820,7 @@ func (s *Server) handleScanRegionalDay(w http.ResponseWriter, r *http.Request) {
 		"cache_meta":         cacheMeta,
 		"target_region_name": targetRegionName,
 		"period_days":        periodDays,
+		"currency":           engine.Currency,
 	})
 	if marshalErr != nil {
 		log.Printf("[API] ScanRegionalDay JSON marshal error: %v", marshalErr)
@@ -2413,11 +2833,66 @@ func (s *Server) handleScanRegionalDay(w http.ResponseWriter, r *http.Request) {
 	flusher.Flush()
 }
 
+// regionalDayTarget is one destination market of a regional day-trader scan.
+type regionalDayTarget struct {
+	systemID   int32
+	systemName string
+	regionID   int32
+}
+
+// maxRegionalDayTargets caps target_market_systems; the source books are
+// shared, but every destination still fetches its own region's books.
+const maxRegionalDayTargets = 5
+
+// regionalDayTargets lists the destinations of a regional day-trader scan:
+// target_market_system (already resolved into params) followed by
+// target_market_systems, without duplicates.
+func (s *Server) regionalDayTargets(req scanRequest, params engine.ScanParams) ([]regionalDayTarget, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.sdeData == nil {
+		return nil, errSDENotLoaded
+	}
+
+	var targets []regionalDayTarget
+	seen := make(map[int32]bool)
+	add := func(systemID int32) {
+		sys, ok := s.sdeData.Systems[systemID]
+		if !ok || seen[systemID] {
+			return
+		}
+		seen[systemID] = true
+		targets = append(targets, regionalDayTarget{systemID: sys.ID, systemName: sys.Name, regionID: sys.RegionID})
+	}
+	if params.TargetMarketSystemID > 0 {
+		add(params.TargetMarketSystemID)
+	}
+	for _, name := range req.TargetMarketSystems {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		sid, ok := s.sdeData.SystemByName[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("target market system not found: %s", name)
+		}
+		add(sid)
+	}
+	if len(targets) > maxRegionalDayTargets {
+		return nil, fmt.Errorf("at most %d target market systems per scan", maxRegionalDayTargets)
+	}
+	return targets, nil
+}
+
+// loadRegionalInventorySnapshot sums the user's assets and active sell orders
+// in the target scope. A snapshot fetched less than maxAge ago for the same
+// characters and scope is reused; maxAge 0 always refetches.
 func (s *Server) loadRegionalInventorySnapshot(
 	userID string,
 	targetRegionID int32,
 	targetMarketSystemID int32,
 	targetMarketLocationID int64,
+	maxAge time.Duration,
 	progress func(string),
 ) *engine.RegionalInventorySnapshot {
 	if s.sessions == nil || s.esi == nil || s.sso == nil {
@@ -2427,6 +2902,19 @@ func (s *Server) loadRegionalInventorySnapshot(
 	if len(sessions) == 0 {
 		return nil
 	}
+	cacheKey := regionalInventoryKey{
+		userID:     userID,
+		characters: sessionCharacterSet(sessions),
+		regionID:   targetRegionID,
+		systemID:   targetMarketSystemID,
+		locationID: targetMarketLocationID,
+	}
+	if cached, age, ok := s.cachedRegionalInventory(cacheKey, maxAge); ok {
+		if progress != nil {
+			progress(fmt.Sprintf("Using inventory snapshot from %s ago", age.Round(time.Second)))
+		}
+		return cached
+	}
 	if progress != nil {
 		progress("Loading inventory and active orders...")
 	}
@@ -2445,7 +2933,12 @@ func (s *Server) loadRegionalInventorySnapshot(
 
 		gotAny := false
 
-		orders, orderErr := s.esi.GetCharacterOrders(sess.CharacterID, token)
+		// Each source is optional; characters missing its scope skip it.
+		var orders []esi.CharacterOrder
+		orderErr := requireScope(sess, auth.ScopeReadCharacterOrders)
+		if orderErr == nil {
+			orders, orderErr = s.esi.GetCharacterOrders(sess.CharacterID, token)
+		}
 		if orderErr == nil {
 			for _, o := range orders {
 				if o.TypeID <= 0 || o.IsBuyOrder || o.VolumeRemain <= 0 {
@@ -2467,7 +2960,11 @@ func (s *Server) loadRegionalInventorySnapshot(
 			gotAny = true
 		}
 
-		assets, assetsErr := s.esi.GetCharacterAssets(sess.CharacterID, token)
+		var assets []esi.CharacterAsset
+		assetsErr := requireScope(sess, auth.ScopeReadAssets)
+		if assetsErr == nil {
+			assets, assetsErr = s.esi.GetCharacterAssets(sess.CharacterID, token)
+		}
 		if assetsErr == nil {
 			assetByItemID := make(map[int64]esi.CharacterAsset, len(assets))
 			for _, a := range assets {
@@ -2507,11 +3004,18 @@ func (s *Server) loadRegionalInventorySnapshot(
 	}
 
 	if len(snapshot.AssetsByType) == 0 && len(snapshot.ActiveSellByType) == 0 {
+		snapshot = nil
+	}
+	// Only remember snapshots backed by at least one character's data.
+	if charactersUsed > 0 {
+		s.storeRegionalInventory(cacheKey, snapshot)
+	}
+	if snapshot == nil {
 		return nil
 	}
 	if progress != nil {
 		progress(fmt.Sprintf(
-			"Inventory synced: %d characters, %d asset types, %d active sell types",
+			"Inventory synced (fresh): %d characters, %d asset types, %d active sell types",
 			charactersUsed,
 			len(snapshot.AssetsByType),
 			len(snapshot.ActiveSellByType),
@@ -2606,25 +3110,27 @@ func (s *Server) matchesRegionByLocationID(locationID int64, regionID int32) boo
 
 func (s *Server) handleScanContracts(w http.ResponseWriter, r *http.Request) {
 	var req scanRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+	if err := s.decodeScanRequest(r, "contracts", &req); err != nil {
+		writeScanRequestError(w, err)
 		return
 	}
 
-	params, err := s.parseScanParams(req)
+	params, err := s.parseScanParams(userIDFromRequest(r), req)
 	if err != nil {
 		writeError(w, 400, err.Error())
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/x-ndjson")
-	w.Header().Set("Cache-Control", "no-cache")
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		writeError(w, 500, "streaming not supported")
+	send := startNDJSONScan(w)
+	if send == nil {
 		return
 	}
+	s.runContractScan(r.Context(), userIDFromRequest(r), req, params, send)
+}
 
+// runContractScan runs a contract scan and streams progress and the final
+// result through send. Canceling ctx stops the scan without a result frame.
+func (s *Server) runContractScan(ctx context.Context, userID string, req scanRequest, params engine.ScanParams, send scanFrameSender) {
 	s.mu.RLock()
 	scanner := s.scanner
 	s.mu.RUnlock()
@@ -2632,37 +3138,32 @@ func (s *Server) handleScanContracts(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[API] ScanContracts starting: system=%d, buyR=%d, margin=%.1f, tax=%.1f",
 		params.CurrentSystemID, params.BuyRadius, params.MinMargin, params.SalesTaxPercent)
 
-	ctx := r.Context()
 	startTime := time.Now()
 
 	results, err := scanner.ScanContractsWithContext(ctx, params, func(msg string) {
 		if ctx.Err() != nil {
 			return
 		}
-		line, _ := json.Marshal(map[string]string{"type": "progress", "message": msg})
-		if _, writeErr := fmt.Fprintf(w, "%s\n", line); writeErr != nil {
-			return
-		}
-		flusher.Flush()
+		send(map[string]string{"type": "progress", "message": msg})
 	})
-	if err != nil {
-		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+	partial := errors.Is(err, engine.ErrPartialScan)
+	if err != nil && !partial {
+		if isContextDone(err) {
 			log.Printf("[API] ScanContracts canceled: %v", err)
 			return
 		}
 		log.Printf("[API] ScanContracts error: %v", err)
-		line, _ := json.Marshal(map[string]string{"type": "error", "message": err.Error()})
-		fmt.Fprintf(w, "%s\n", line)
-		flusher.Flush()
-		return
-	}
-	if ctx.Err() != nil {
+		send(map[string]string{"type": "error", "message": err.Error()})
 		return
 	}
 
 	durationMs := time.Since(startTime).Milliseconds()
-	results = s.filterContractResultsMarketDisabled(results)
-	log.Printf("[API] ScanContracts complete: %d results in %dms", len(results), durationMs)
+	results = s.filterContractResultsMarketDisabled(userID, results)
+	if partial {
+		log.Printf("[API] ScanContracts canceled: keeping %d partial results after %dms", len(results), durationMs)
+	} else {
+		log.Printf("[API] ScanContracts complete: %d results in %dms", len(results), durationMs)
+	}
 	regionIDs := s.regionScopeForContractScan(params)
 	cacheMeta := s.stationCacheMetaForRegions(regionIDs)
 
@@ -2675,30 +3176,25 @@ func (s *Server) handleScanContracts(w http.ResponseWriter, r *http.Request) {
 		}
 		totalProfit += kpiProfit
 	}
-	scanID := s.db.InsertHistoryFull("contracts", req.SystemName, len(results), topProfit, totalProfit, durationMs, req)
-	if ctx.Err() == nil {
-		go s.db.InsertContractResults(scanID, results)
+	var scanID int64
+	if partial {
+		scanID = s.db.InsertPartialHistory("contracts", req.SystemName, len(results), topProfit, totalProfit, durationMs, req, params)
+	} else {
+		scanID = s.db.InsertHistoryFull("contracts", req.SystemName, len(results), topProfit, totalProfit, durationMs, req, params)
 	}
+	go s.db.InsertContractResults(scanID, results)
 
-	line, marshalErr := json.Marshal(map[string]interface{}{
+	// A partial result is still sent: the client may have gone away, but if
+	// the stream is alive it gets what was found before the cancel.
+	send(map[string]interface{}{
 		"type":       "result",
 		"data":       results,
 		"count":      len(results),
 		"scan_id":    scanID,
 		"cache_meta": cacheMeta,
+		"partial":    partial,
+		"currency":   engine.Currency,
 	})
-	if marshalErr != nil {
-		log.Printf("[API] ScanContracts JSON marshal error: %v", marshalErr)
-		errLine, _ := json.Marshal(map[string]string{"type": "error", "message": "JSON: " + marshalErr.Error()})
-		fmt.Fprintf(w, "%s\n", errLine)
-		flusher.Flush()
-		return
-	}
-	if ctx.Err() != nil {
-		return
-	}
-	fmt.Fprintf(w, "%s\n", line)
-	flusher.Flush()
 }
 
 func (s *Server) handleRouteFind(w http.ResponseWriter, r *http.Request) {
@@ -2722,13 +3218,19 @@ func (s *Server) handleRouteFind(w http.ResponseWriter, r *http.Request) {
 		MinRouteSecurity     float64 `json:"min_route_security"` // 0 = all; 0.45 = highsec only; 0.7 = min 0.7
 		AllowEmptyHops       bool    `json:"allow_empty_hops"`
 		IncludeStructures    bool    `json:"include_structures"`
+		RoundTrip            bool    `json:"round_trip"`
+		// ISK charged per jump below 0.5 security when ranking routes
+		SecurityPenaltyPerLowsecJump float64 `json:"security_penalty_per_lowsec_jump"`
+		// Per-scan exclusions, added to the saved ones
+		ExcludeTypeIDs        []int32 `json:"exclude_type_ids"`
+		ExcludeMarketGroupIDs []int32 `json:"exclude_market_group_ids"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+	if err := s.decodeScanRequest(r, "route", &req); err != nil {
+		writeScanRequestError(w, err)
 		return
 	}
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
 		return
 	}
 	req.SystemName = strings.TrimSpace(req.SystemName)
@@ -2736,6 +3238,9 @@ func (s *Server) handleRouteFind(w http.ResponseWriter, r *http.Request) {
 	if req.MinISKPerJump < 0 {
 		req.MinISKPerJump = 0
 	}
+	if req.SecurityPenaltyPerLowsecJump < 0 {
+		req.SecurityPenaltyPerLowsecJump = 0
+	}
 	if req.MinHops < 1 {
 		req.MinHops = 2
 	}
@@ -2758,24 +3263,30 @@ func (s *Server) handleRouteFind(w http.ResponseWriter, r *http.Request) {
 	scanner := s.scanner
 	s.mu.RUnlock()
 
+	excludeTypeIDs, excludeGroupIDs := scanExclusions(s.loadConfigForUser(userID), req.ExcludeTypeIDs, req.ExcludeMarketGroupIDs)
 	params := engine.RouteParams{
-		SystemName:           req.SystemName,
-		TargetSystemName:     req.TargetSystemName,
-		CargoCapacity:        req.CargoCapacity,
-		MinMargin:            req.MinMargin,
-		MinISKPerJump:        req.MinISKPerJump,
-		SalesTaxPercent:      req.SalesTaxPercent,
-		BrokerFeePercent:     req.BrokerFeePercent,
-		SplitTradeFees:       req.SplitTradeFees,
-		BuyBrokerFeePercent:  req.BuyBrokerFeePercent,
-		SellBrokerFeePercent: req.SellBrokerFeePercent,
-		BuySalesTaxPercent:   req.BuySalesTaxPercent,
-		SellSalesTaxPercent:  req.SellSalesTaxPercent,
-		MinHops:              req.MinHops,
-		MaxHops:              req.MaxHops,
-		MinRouteSecurity:     req.MinRouteSecurity,
-		AllowEmptyHops:       req.AllowEmptyHops,
-		IncludeStructures:    req.IncludeStructures,
+		SystemName:            req.SystemName,
+		TargetSystemName:      req.TargetSystemName,
+		CargoCapacity:         req.CargoCapacity,
+		MinMargin:             req.MinMargin,
+		MinISKPerJump:         req.MinISKPerJump,
+		SalesTaxPercent:       req.SalesTaxPercent,
+		BrokerFeePercent:      req.BrokerFeePercent,
+		SplitTradeFees:        req.SplitTradeFees,
+		BuyBrokerFeePercent:   req.BuyBrokerFeePercent,
+		SellBrokerFeePercent:  req.SellBrokerFeePercent,
+		BuySalesTaxPercent:    req.BuySalesTaxPercent,
+		SellSalesTaxPercent:   req.SellSalesTaxPercent,
+		MinHops:               req.MinHops,
+		MaxHops:               req.MaxHops,
+		MinRouteSecurity:      req.MinRouteSecurity,
+		AllowEmptyHops:        req.AllowEmptyHops,
+		IncludeStructures:     req.IncludeStructures,
+		RoundTrip:             req.RoundTrip,
+		ExcludeTypeIDs:        excludeTypeIDs,
+		ExcludeMarketGroupIDs: excludeGroupIDs,
+
+		SecurityPenaltyPerLowsecJump: req.SecurityPenaltyPerLowsecJump,
 	}
 
 	log.Printf(
@@ -2815,7 +3326,7 @@ func (s *Server) handleRouteFind(w http.ResponseWriter, r *http.Request) {
 	} else {
 		results = filterRouteResultsExcludeStructures(results)
 	}
-	results = filterRouteResultsMarketDisabled(results)
+	results = s.filterRouteResultsMarketDisabled(userID, results)
 	if len(results) != rawCount {
 		log.Printf("[API] RouteFind post-filter: raw=%d final=%d (include_structures=%t)", rawCount, len(results), req.IncludeStructures)
 		line, _ := json.Marshal(map[string]string{
@@ -2834,10 +3345,10 @@ func (s *Server) handleRouteFind(w http.ResponseWriter, r *http.Request) {
 		totalProfit += r.TotalProfit
 	}
 
-	scanID := s.db.InsertHistoryFull("route", req.SystemName, len(results), topProfit, totalProfit, durationMs, req)
+	scanID := s.db.InsertHistoryFull("route", req.SystemName, len(results), topProfit, totalProfit, durationMs, req, params)
 	go s.db.InsertRouteResults(scanID, results)
 
-	line, marshalErr := json.Marshal(map[string]interface{}{"type": "result", "data": results, "count": len(results), "scan_id": scanID})
+	line, marshalErr := json.Marshal(map[string]interface{}{"type": "result", "data": results, "count": len(results), "scan_id": scanID, "currency": engine.Currency})
 	if marshalErr != nil {
 		log.Printf("[API] RouteFind JSON marshal error: %v", marshalErr)
 		errLine, _ := json.Marshal(map[string]string{"type": "error", "message": "JSON: " + marshalErr.Error()})
@@ -2856,7 +3367,7 @@ func (s *Server) handleGetWatchlist(w http.ResponseWriter, r *http.Request) {
 	items := s.db.GetWatchlistForUser(userID)
 	filtered := make([]config.WatchlistItem, 0, len(items))
 	for _, it := range items {
-		if engine.IsMarketDisabledTypeID(it.TypeID) {
+		if s.isMarketDisabledForUser(userID, it.TypeID) {
 			continue
 		}
 		filtered = append(filtered, it)
@@ -2869,7 +3380,7 @@ func (s *Server) handleAddWatchlist(w http.ResponseWriter, r *http.Request) {
 
 	var item config.WatchlistItem
 	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 
@@ -2879,13 +3390,23 @@ func (s *Server) handleAddWatchlist(w http.ResponseWriter, r *http.Request) {
 	s.mu.RUnlock()
 	if sdeData != nil {
 		if _, ok := sdeData.Types[item.TypeID]; !ok {
-			writeError(w, 400, fmt.Sprintf("unknown type_id %d", item.TypeID))
+			writeErrorCode(w, 400, errCodeTypeNotFound, fmt.Sprintf("unknown type_id %d", item.TypeID))
 			return
 		}
 		// Use canonical SDE name if client didn't provide one
 		if item.TypeName == "" {
 			item.TypeName = sdeData.Types[item.TypeID].Name
 		}
+		if item.RegionID > 0 {
+			if _, ok := sdeData.Regions[item.RegionID]; !ok {
+				writeErrorCode(w, 400, errCodeRegionNotFound, fmt.Sprintf("unknown region_id %d", item.RegionID))
+				return
+			}
+		}
+	}
+	if item.DemandAlertThreshold < 0 {
+		writeError(w, 400, "demand_alert_threshold must be >= 0")
+		return
 	}
 
 	if item.AlertMetric == "" {
@@ -2894,7 +3415,7 @@ func (s *Server) handleAddWatchlist(w http.ResponseWriter, r *http.Request) {
 	if item.AlertThreshold <= 0 && item.AlertMinMargin > 0 {
 		item.AlertThreshold = item.AlertMinMargin
 	}
-	if engine.IsMarketDisabledTypeID(item.TypeID) {
+	if s.isMarketDisabledForUser(userID, item.TypeID) {
 		writeError(w, 400, "type_id is market-disabled")
 		return
 	}
@@ -2912,7 +3433,7 @@ func (s *Server) handleAddWatchlist(w http.ResponseWriter, r *http.Request) {
 	items := s.db.GetWatchlistForUser(userID)
 	filtered := make([]config.WatchlistItem, 0, len(items))
 	for _, it := range items {
-		if engine.IsMarketDisabledTypeID(it.TypeID) {
+		if s.isMarketDisabledForUser(userID, it.TypeID) {
 			continue
 		}
 		filtered = append(filtered, it)
@@ -2936,7 +3457,7 @@ func (s *Server) handleDeleteWatchlist(w http.ResponseWriter, r *http.Request) {
 	items := s.db.GetWatchlistForUser(userID)
 	filtered := make([]config.WatchlistItem, 0, len(items))
 	for _, it := range items {
-		if engine.IsMarketDisabledTypeID(it.TypeID) {
+		if s.isMarketDisabledForUser(userID, it.TypeID) {
 			continue
 		}
 		filtered = append(filtered, it)
@@ -2958,9 +3479,12 @@ func (s *Server) handleUpdateWatchlist(w http.ResponseWriter, r *http.Request) {
 		AlertEnabled   bool    `json:"alert_enabled"`
 		AlertMetric    string  `json:"alert_metric"`
 		AlertThreshold float64 `json:"alert_threshold"`
+		// Demand alert fields are optional; omitted means unchanged.
+		RegionID             *int32   `json:"region_id"`
+		DemandAlertThreshold *float64 `json:"demand_alert_threshold"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 
@@ -2975,6 +3499,21 @@ func (s *Server) handleUpdateWatchlist(w http.ResponseWriter, r *http.Request) {
 		writeError(w, 400, "alert_threshold must be >= 0")
 		return
 	}
+	if body.DemandAlertThreshold != nil && *body.DemandAlertThreshold < 0 {
+		writeError(w, 400, "demand_alert_threshold must be >= 0")
+		return
+	}
+	if body.RegionID != nil && *body.RegionID > 0 {
+		s.mu.RLock()
+		sdeData := s.sdeData
+		s.mu.RUnlock()
+		if sdeData != nil {
+			if _, ok := sdeData.Regions[*body.RegionID]; !ok {
+				writeErrorCode(w, 400, errCodeRegionNotFound, fmt.Sprintf("unknown region_id %d", *body.RegionID))
+				return
+			}
+		}
+	}
 
 	alertMetric := body.AlertMetric
 	if alertMetric == "" {
@@ -2991,10 +3530,25 @@ func (s *Server) handleUpdateWatchlist(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.db.UpdateWatchlistItemForUser(userID, int32(id), body.AlertMinMargin, alertEnabled, alertMetric, alertThreshold)
+	if body.RegionID != nil || body.DemandAlertThreshold != nil {
+		regionID, threshold := int32(0), 0.0
+		for _, it := range s.db.GetWatchlistForUser(userID) {
+			if it.TypeID == int32(id) {
+				regionID, threshold = it.RegionID, it.DemandAlertThreshold
+			}
+		}
+		if body.RegionID != nil {
+			regionID = *body.RegionID
+		}
+		if body.DemandAlertThreshold != nil {
+			threshold = *body.DemandAlertThreshold
+		}
+		s.db.UpdateWatchlistDemandAlertForUser(userID, int32(id), regionID, threshold)
+	}
 	items := s.db.GetWatchlistForUser(userID)
 	filtered := make([]config.WatchlistItem, 0, len(items))
 	for _, it := range items {
-		if engine.IsMarketDisabledTypeID(it.TypeID) {
+		if s.isMarketDisabledForUser(userID, it.TypeID) {
 			continue
 		}
 		filtered = append(filtered, it)
@@ -3002,19 +3556,56 @@ func (s *Server) handleUpdateWatchlist(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, filtered)
 }
 
+// handleGetAlertHistory lists sent alerts, newest first. All filters are
+// optional and combine with AND: type_id, since (inclusive) and until
+// (exclusive) as RFC3339 timestamps, and channel (telegram, discord or
+// desktop), which keeps only alerts actually delivered there. limit and
+// offset paginate the filtered result.
 func (s *Server) handleGetAlertHistory(w http.ResponseWriter, r *http.Request) {
 	userID := userIDFromRequest(r)
+	var filter db.AlertHistoryFilter
 
 	// Optional filter by type_id
 	typeIDStr := r.URL.Query().Get("type_id")
-	var typeID int32
 	if typeIDStr != "" {
 		id, err := strconv.Atoi(typeIDStr)
 		if err != nil {
 			writeError(w, 400, "invalid type_id")
 			return
 		}
-		typeID = int32(id)
+		filter.TypeID = int32(id)
+	}
+
+	// Optional date range
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		raw := strings.TrimSpace(r.URL.Query().Get(p.name))
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			writeError(w, 400, "invalid "+p.name+": expected RFC3339 timestamp")
+			return
+		}
+		*p.dst = t
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Until.After(filter.Since) {
+		writeError(w, 400, "until must be after since")
+		return
+	}
+
+	// Optional delivery channel
+	if ch := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("channel"))); ch != "" {
+		switch ch {
+		case "telegram", "discord", "desktop":
+			filter.Channel = ch
+		default:
+			writeError(w, 400, "invalid channel: expected telegram, discord or desktop")
+			return
+		}
 	}
 
 	// Optional limit
@@ -3043,7 +3634,7 @@ func (s *Server) handleGetAlertHistory(w http.ResponseWriter, r *http.Request) {
 		offset = o
 	}
 
-	history, err := s.db.GetAlertHistoryPageForUser(userID, typeID, limit, offset)
+	history, err := s.db.GetAlertHistoryPageForUser(userID, filter, limit, offset)
 	if err != nil {
 		log.Printf("[API] Failed to get alert history: %v", err)
 		writeError(w, 500, "failed to retrieve alert history")
@@ -3055,6 +3646,24 @@ func (s *Server) handleGetAlertHistory(w http.ResponseWriter, r *http.Request) {
 
 // --- Station Trading ---
 
+// structureMarketToken returns a valid token for the user's active character
+// and that character's ID when structures are included, or zero values.
+// Structure market orders are cached per character, so both travel together.
+func (s *Server) structureMarketToken(userID string, includeStructures bool) (string, int64) {
+	if !includeStructures || s.sessions == nil {
+		return "", 0
+	}
+	sess := s.sessions.GetForUser(userID)
+	if sess == nil {
+		return "", 0
+	}
+	token, err := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
+	if err != nil {
+		return "", 0
+	}
+	return token, sess.CharacterID
+}
+
 func (s *Server) handleScanStation(w http.ResponseWriter, r *http.Request) {
 	userID := userIDFromRequest(r)
 	userCfg := s.loadConfigForUser(userID)
@@ -3079,6 +3688,7 @@ func (s *Server) handleScanStation(w http.ResponseWriter, r *http.Request) {
 		MinDemandPerDay float64 `json:"min_demand_per_day"` // legacy alias for min_s2b_per_day
 		MinS2BPerDay    float64 `json:"min_s2b_per_day"`
 		MinBfSPerDay    float64 `json:"min_bfs_per_day"`
+		MinISKPerHour   float64 `json:"min_isk_per_hour"` // estimated ISK/hour over turnover time
 		// Risk Profile
 		AvgPricePeriod     int     `json:"avg_price_period"`
 		MinPeriodROI       float64 `json:"min_period_roi"`
@@ -3088,16 +3698,26 @@ func (s *Server) handleScanStation(w http.ResponseWriter, r *http.Request) {
 		MaxSDS             int     `json:"max_sds"`
 		LimitBuyToPriceLow bool    `json:"limit_buy_to_price_low"`
 		FlagExtremePrices  bool    `json:"flag_extreme_prices"`
+		RestockDays        float64 `json:"restock_days"` // flag rows with fewer days of stock; 0 = off
 		// Player structures
 		IncludeStructures bool    `json:"include_structures"`
 		StructureIDs      []int64 `json:"structure_ids"`
+		ExcludeNPCOrders  bool    `json:"exclude_npc_orders"`
+		// Per-scan exclusions, added to the saved ones
+		ExcludeTypeIDs        []int32 `json:"exclude_type_ids"`
+		ExcludeMarketGroupIDs []int32 `json:"exclude_market_group_ids"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+	if err := s.decodeScanRequest(r, "station", &req); err != nil {
+		writeScanRequestError(w, err)
 		return
 	}
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+	ctsWeights, err := s.resolveCTSProfile(userID, req.CTSProfile)
+	if err != nil {
+		writeError(w, 400, err.Error())
 		return
 	}
 
@@ -3116,7 +3736,11 @@ func (s *Server) handleScanStation(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
 	streamAlive := true
+	// Regions are scanned concurrently, so progress writes are serialized.
+	var streamMu sync.Mutex
 	progressFn := func(msg string) {
+		streamMu.Lock()
+		defer streamMu.Unlock()
 		if !streamAlive || ctx.Err() != nil {
 			streamAlive = false
 			return
@@ -3147,7 +3771,7 @@ func (s *Server) handleScanStation(w http.ResponseWriter, r *http.Request) {
 		// Radius-based scan: find all systems within radius, collect their stations
 		systemID, ok := sdeData.SystemByName[strings.ToLower(req.SystemName)]
 		if !ok {
-			writeError(w, 400, "unknown system")
+			writeErrorCode(w, 400, errCodeSystemNotFound, "unknown system")
 			return
 		}
 		systems := sdeData.Universe.SystemsWithinRadius(systemID, req.Radius)
@@ -3189,72 +3813,87 @@ func (s *Server) handleScanStation(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[API] ScanStation starting: stations=%d, regions=%d, margin=%.1f, tax=%.1f, broker=%.1f, cts_profile=%s",
 		len(stationIDs), len(regionIDs), req.MinMargin, req.SalesTaxPercent, req.BrokerFee, strings.TrimSpace(req.CTSProfile))
 
-	// Get auth token if available (for structure name resolution)
-	accessToken := ""
-	if req.IncludeStructures && s.sessions != nil {
-		if token, err := s.sessions.EnsureValidTokenForUser(s.sso, userID); err == nil {
-			accessToken = token
-		}
-	}
+	// Get auth token if available (structure names and structure markets)
+	accessToken, characterID := s.structureMarketToken(userID, req.IncludeStructures)
 
 	startTime := time.Now()
+	excludeTypeIDs, excludeGroupIDs := scanExclusions(userCfg, req.ExcludeTypeIDs, req.ExcludeMarketGroupIDs)
 
-	// Scan each region and merge results
-	var allResults []engine.StationTrade
+	// Scan regions on a bounded worker pool; results are merged in region ID order.
+	regionList := make([]int32, 0, len(regionIDs))
 	for regionID := range regionIDs {
-		if ctx.Err() != nil || !streamAlive {
+		regionList = append(regionList, regionID)
+	}
+	// Parameters shared by every region; scanRegion fills in the region scope.
+	// History stores this as the scan's resolved parameters.
+	baseParams := engine.StationTradeParams{
+		StationIDs:            stationIDs,
+		MinMargin:             req.MinMargin,
+		SalesTaxPercent:       req.SalesTaxPercent,
+		BrokerFee:             req.BrokerFee,
+		CTSProfile:            req.CTSProfile,
+		CTSWeights:            ctsWeights,
+		SplitTradeFees:        req.SplitTradeFees,
+		BuyBrokerFeePercent:   req.BuyBrokerFeePercent,
+		SellBrokerFeePercent:  req.SellBrokerFeePercent,
+		BuySalesTaxPercent:    req.BuySalesTaxPercent,
+		SellSalesTaxPercent:   req.SellSalesTaxPercent,
+		MinDailyVolume:        req.MinDailyVolume,
+		MinItemProfit:         req.MinItemProfit,
+		MinDemandPerDay:       req.MinDemandPerDay,
+		MinS2BPerDay:          req.MinS2BPerDay,
+		MinBfSPerDay:          req.MinBfSPerDay,
+		MinISKPerHour:         req.MinISKPerHour,
+		AvgPricePeriod:        req.AvgPricePeriod,
+		MinPeriodROI:          req.MinPeriodROI,
+		BvSRatioMin:           req.BvSRatioMin,
+		BvSRatioMax:           req.BvSRatioMax,
+		MaxPVI:                req.MaxPVI,
+		MaxSDS:                req.MaxSDS,
+		RestockDays:           req.RestockDays,
+		LimitBuyToPriceLow:    req.LimitBuyToPriceLow,
+		FlagExtremePrices:     req.FlagExtremePrices,
+		AccessToken:           accessToken,
+		CharacterID:           characterID,
+		IncludeStructures:     req.IncludeStructures,
+		ExcludeNPCOrders:      req.ExcludeNPCOrders,
+		ExcludeTypeIDs:        excludeTypeIDs,
+		ExcludeMarketGroupIDs: excludeGroupIDs,
+	}
+	if userCfg.PriceFallbackEnabled {
+		baseParams.PriceFallback = s.priceFallback
+	}
+	// In all-stations mode keep StationIDs nil so the engine evaluates full region scope.
+	if allStationsMode {
+		baseParams.StationIDs = nil
+	}
+
+	var regionsDone atomic.Int32
+	scanRegion := func(regionCtx context.Context, regionID int32) ([]engine.StationTrade, error) {
+		params := baseParams
+		params.AllowedSystems = allowedSystemsByRegion[regionID]
+		params.RegionID = regionID
+		params.Ctx = regionCtx
+
+		results, err := scanner.ScanStationTrades(params, progressFn)
+		if err != nil {
+			return nil, fmt.Errorf("region %d: %w", regionID, err)
+		}
+		if len(regionList) > 1 {
+			progressFn(fmt.Sprintf("Scanned %d/%d regions", regionsDone.Add(1), len(regionList)))
+		}
+		return results, nil
+	}
+	allResults, err := engine.ScanRegions(ctx, regionList, engine.DefaultRegionWorkers, scanRegion)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil || !streamAlive {
 			return
 		}
-		params := engine.StationTradeParams{
-			StationIDs:           stationIDs,
-			AllowedSystems:       allowedSystemsByRegion[regionID],
-			RegionID:             regionID,
-			MinMargin:            req.MinMargin,
-			SalesTaxPercent:      req.SalesTaxPercent,
-			BrokerFee:            req.BrokerFee,
-			CTSProfile:           req.CTSProfile,
-			SplitTradeFees:       req.SplitTradeFees,
-			BuyBrokerFeePercent:  req.BuyBrokerFeePercent,
-			SellBrokerFeePercent: req.SellBrokerFeePercent,
-			BuySalesTaxPercent:   req.BuySalesTaxPercent,
-			SellSalesTaxPercent:  req.SellSalesTaxPercent,
-			MinDailyVolume:       req.MinDailyVolume,
-			MinItemProfit:        req.MinItemProfit,
-			MinDemandPerDay:      req.MinDemandPerDay,
-			MinS2BPerDay:         req.MinS2BPerDay,
-			MinBfSPerDay:         req.MinBfSPerDay,
-			AvgPricePeriod:       req.AvgPricePeriod,
-			MinPeriodROI:         req.MinPeriodROI,
-			BvSRatioMin:          req.BvSRatioMin,
-			BvSRatioMax:          req.BvSRatioMax,
-			MaxPVI:               req.MaxPVI,
-			MaxSDS:               req.MaxSDS,
-			LimitBuyToPriceLow:   req.LimitBuyToPriceLow,
-			FlagExtremePrices:    req.FlagExtremePrices,
-			AccessToken:          accessToken,
-			IncludeStructures:    req.IncludeStructures,
-			Ctx:                  ctx,
-		}
-		// In all-stations mode keep StationIDs nil so the engine evaluates full region scope.
-		if allStationsMode {
-			params.StationIDs = nil
-		}
-
-		results, err := scanner.ScanStationTrades(params, progressFn)
-		if err != nil {
-			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil || !streamAlive {
-				return
-			}
-			log.Printf("[API] ScanStation error (region %d): %v", regionID, err)
-			line, _ := json.Marshal(map[string]string{"type": "error", "message": err.Error()})
-			_, _ = fmt.Fprintf(w, "%s\n", line)
-			flusher.Flush()
-			return
-		}
-		if ctx.Err() != nil || !streamAlive {
-			return
-		}
-		allResults = append(allResults, results...)
+		log.Printf("[API] ScanStation error: %v", err)
+		line, _ := json.Marshal(map[string]string{"type": "error", "message": err.Error()})
+		_, _ = fmt.Fprintf(w, "%s\n", line)
+		flusher.Flush()
+		return
 	}
 	if ctx.Err() != nil || !streamAlive {
 		return
@@ -3269,7 +3908,7 @@ func (s *Server) handleScanStation(w http.ResponseWriter, r *http.Request) {
 	if !req.IncludeStructures {
 		allResults = filterStationTradesExcludeStructures(allResults)
 	}
-	allResults = filterStationTradesMarketDisabled(allResults)
+	allResults = s.filterStationTradesMarketDisabled(userID, allResults)
 
 	// Calculate totals
 	topProfit := 0.0
@@ -3283,10 +3922,13 @@ func (s *Server) handleScanStation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Save to history with full params
-	scanID := s.db.InsertHistoryFull("station", historyLabel, len(allResults), topProfit, totalProfit, durationMs, req)
+	scanID := s.db.InsertHistoryFull("station", historyLabel, len(allResults), topProfit, totalProfit, durationMs, req, baseParams)
 	if scanID > 0 {
 		go s.db.InsertStationResults(scanID, allResults)
 	}
+	if userCfg.StationTrendsEnabled {
+		go s.recordStationTrends(userID, allResults)
+	}
 	var scanIDPtr *int64
 	if scanID > 0 {
 		scanIDPtr = &scanID
@@ -3299,6 +3941,7 @@ func (s *Server) handleScanStation(w http.ResponseWriter, r *http.Request) {
 		"count":      len(allResults),
 		"scan_id":    scanID,
 		"cache_meta": cacheMeta,
+		"currency":   engine.Currency,
 	})
 	if marshalErr != nil {
 		log.Printf("[API] ScanStation JSON marshal error: %v", marshalErr)
@@ -3390,6 +4033,10 @@ func (s *Server) handleAuthStructures(w http.ResponseWriter, r *http.Request) {
 		writeError(w, 401, err.Error())
 		return
 	}
+	if err := requireScope(s.sessions.GetForUser(userID), auth.ScopeReadStructures); err != nil {
+		writeScopeError(w, err)
+		return
+	}
 
 	systemIDStr := r.URL.Query().Get("system_id")
 	regionIDStr := r.URL.Query().Get("region_id")
@@ -3444,21 +4091,80 @@ func (s *Server) handleAuthStructures(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, result)
 }
 
+// typeIDByName finds a type by exact name, ignoring case. When several types
+// share a name the lowest ID wins so lookups are deterministic.
+func typeIDByName(data *sde.Data, name string) (int32, bool) {
+	name = strings.TrimSpace(name)
+	var found int32
+	for id, t := range data.Types {
+		if strings.EqualFold(t.Name, name) && (found == 0 || id < found) {
+			found = id
+		}
+	}
+	return found, found != 0
+}
+
+var errSDENotLoaded = errors.New("SDE not loaded yet")
+
+// resolveExecutionPlanIDs fills in type_id and region_id from type_name and
+// region_name. Numeric IDs win when both forms are given.
+func resolveExecutionPlanIDs(data *sde.Data, typeID int32, typeName string, regionID int32, regionName string) (int32, int32, error) {
+	typeName = strings.TrimSpace(typeName)
+	regionName = strings.TrimSpace(regionName)
+	if typeID == 0 && typeName != "" {
+		if data == nil {
+			return 0, 0, errSDENotLoaded
+		}
+		id, ok := typeIDByName(data, typeName)
+		if !ok {
+			return 0, 0, fmt.Errorf("unknown type_name %q", typeName)
+		}
+		typeID = id
+	}
+	if regionID == 0 && regionName != "" {
+		if data == nil {
+			return 0, 0, errSDENotLoaded
+		}
+		id, ok := data.RegionByName[strings.ToLower(regionName)]
+		if !ok {
+			return 0, 0, fmt.Errorf("unknown region_name %q", regionName)
+		}
+		regionID = id
+	}
+	return typeID, regionID, nil
+}
+
 func (s *Server) handleExecutionPlan(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		TypeID     int32 `json:"type_id"`
-		RegionID   int32 `json:"region_id"`
-		LocationID int64 `json:"location_id"` // 0 = whole region
-		Quantity   int32 `json:"quantity"`
-		IsBuy      bool  `json:"is_buy"`
-		ImpactDays int   `json:"impact_days"` // 0 = use engine default (e.g. 30); from station trading "Period (days)"
+		TypeID     int32  `json:"type_id"`
+		TypeName   string `json:"type_name"` // used when type_id is 0
+		RegionID   int32  `json:"region_id"`
+		RegionName string `json:"region_name"` // used when region_id is 0
+		LocationID int64  `json:"location_id"` // 0 = whole region
+		Quantity   int32  `json:"quantity"`
+		IsBuy      bool   `json:"is_buy"`
+		ImpactDays int    `json:"impact_days"` // 0 = use engine default (e.g. 30); from station trading "Period (days)"
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+		return
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	typeID, regionID, err := resolveExecutionPlanIDs(sdeData, req.TypeID, req.TypeName, req.RegionID, req.RegionName)
+	if err != nil {
+		if errors.Is(err, errSDENotLoaded) {
+			writeError(w, 503, err.Error())
+		} else {
+			writeError(w, 400, err.Error())
+		}
 		return
 	}
+	req.TypeID, req.RegionID = typeID, regionID
 	if req.RegionID == 0 || req.TypeID == 0 || req.Quantity <= 0 {
-		writeError(w, 400, "region_id, type_id and positive quantity required")
+		writeError(w, 400, "region_id (or region_name), type_id (or type_name) and positive quantity required")
 		return
 	}
 
@@ -3564,6 +4270,15 @@ func (s *Server) regionalDayParamsFromHistory(record *db.ScanRecord) (engine.Sca
 		return engine.ScanParams{}, false
 	}
 
+	// Scans saved with their resolved engine parameters replay exactly those
+	// instead of re-resolving the request against today's SDE.
+	if len(record.ResolvedParams) > 0 {
+		var resolved engine.ScanParams
+		if err := json.Unmarshal(record.ResolvedParams, &resolved); err == nil && resolved.TargetMarketSystemID > 0 {
+			return resolved, true
+		}
+	}
+
 	params := engine.ScanParams{
 		CargoCapacity:          req.CargoCapacity,
 		BuyRadius:              req.BuyRadius,
@@ -3601,7 +4316,9 @@ func (s *Server) regionalDayParamsFromHistory(record *db.ScanRecord) (engine.Sca
 		return params, true
 	}
 
-	if rid, ok := s.sdeData.RegionByName[strings.ToLower(strings.TrimSpace(req.TargetRegion))]; ok && rid > 0 {
+	if _, ok := s.sdeData.Regions[req.TargetRegionID]; ok && req.TargetRegionID > 0 {
+		params.TargetRegionID = req.TargetRegionID
+	} else if rid, ok := s.sdeData.RegionByName[strings.ToLower(strings.TrimSpace(req.TargetRegion))]; ok && rid > 0 {
 		params.TargetRegionID = rid
 	}
 	if sid, ok := s.sdeData.SystemByName[strings.ToLower(targetMarketSystem)]; ok && sid > 0 {
@@ -3644,6 +4361,45 @@ func (s *Server) rebuildRegionalHistoryRows(record *db.ScanRecord, raw []engine.
 	return rows
 }
 
+// loadHistoryResults loads a saved scan's rows with market-disabled types
+// removed. The concrete type depends on the tab: []engine.StationTrade,
+// []engine.ContractResult, []engine.RouteResult, or []engine.FlipResult for
+// flip and region scans.
+func (s *Server) loadHistoryResults(userID string, record *db.ScanRecord) interface{} {
+	id := record.ID
+	switch record.Tab {
+	case "station":
+		return s.filterStationTradesMarketDisabled(userID, s.db.GetStationResults(id))
+	case "region":
+		regionRows := s.filterFlipResultsMarketDisabled(userID, s.db.GetRegionalDayResults(id))
+		if len(regionRows) > 0 {
+			return regionRows
+		}
+		rawRows := s.db.GetFlipResults(id)
+		rebuilt := s.rebuildRegionalHistoryRows(record, rawRows)
+		if len(rebuilt) > 0 {
+			regionRows = s.filterFlipResultsMarketDisabled(userID, append([]engine.FlipResult(nil), rebuilt...))
+			if len(regionRows) > 0 {
+				// History is shared between users; cache the rebuild without
+				// this user's overrides applied (reads filter again anyway).
+				go s.db.InsertRegionalDayResults(id, rebuilt)
+				return regionRows
+			}
+		}
+		// Backward compatibility for scans where a deterministic rebuild is not possible.
+		return s.filterFlipResultsMarketDisabled(userID, rawRows)
+	case "contracts":
+		return s.filterContractResultsMarketDisabled(userID, s.db.GetContractResults(id))
+	case "route":
+		return s.filterRouteResultsMarketDisabled(userID, s.db.GetRouteResults(id))
+	default:
+		return s.filterFlipResultsMarketDisabled(userID, s.db.GetFlipResults(id))
+	}
+}
+
+// handleGetHistoryResults returns a saved scan and its rows. Optional
+// ?sort=&order=&min_margin=&action=execute&limit=&offset= are applied after
+// market-disabled filtering; total is the filtered row count before paging.
 func (s *Server) handleGetHistoryResults(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -3651,6 +4407,11 @@ func (s *Server) handleGetHistoryResults(w http.ResponseWriter, r *http.Request)
 		writeError(w, 400, "invalid id")
 		return
 	}
+	query, err := parseHistoryResultQuery(r)
+	if err != nil {
+		writeError(w, 400, err.Error())
+		return
+	}
 
 	record := s.db.GetHistoryByID(id)
 	if record == nil {
@@ -3659,39 +4420,28 @@ func (s *Server) handleGetHistoryResults(w http.ResponseWriter, r *http.Request)
 	}
 
 	var results interface{}
-	switch record.Tab {
-	case "station":
-		results = filterStationTradesMarketDisabled(s.db.GetStationResults(id))
-	case "region":
-		regionRows := filterFlipResultsMarketDisabled(s.db.GetRegionalDayResults(id))
-		if len(regionRows) > 0 {
-			results = regionRows
-		} else {
-			rawRows := s.db.GetFlipResults(id)
-			rebuilt := s.rebuildRegionalHistoryRows(record, rawRows)
-			if len(rebuilt) > 0 {
-				regionRows = filterFlipResultsMarketDisabled(rebuilt)
-				if len(regionRows) > 0 {
-					go s.db.InsertRegionalDayResults(id, regionRows)
-					results = regionRows
-					break
-				}
-			}
-			// Backward compatibility for scans where a deterministic rebuild is not possible.
-			results = filterFlipResultsMarketDisabled(rawRows)
-		}
-	case "contracts":
-		contractResults := s.db.GetContractResults(id)
-		results = s.filterContractResultsMarketDisabled(contractResults)
-	case "route":
-		results = filterRouteResultsMarketDisabled(s.db.GetRouteResults(id))
-	default:
-		results = filterFlipResultsMarketDisabled(s.db.GetFlipResults(id))
+	var total int
+	switch rows := s.loadHistoryResults(userIDFromRequest(r), record).(type) {
+	case []engine.StationTrade:
+		results, total, err = applyHistoryResultQuery(rows, query, stationHistoryAccessors)
+	case []engine.ContractResult:
+		results, total, err = applyHistoryResultQuery(rows, query, contractHistoryAccessors)
+	case []engine.RouteResult:
+		results, total, err = applyHistoryResultQuery(rows, query, routeHistoryAccessors)
+	case []engine.FlipResult:
+		results, total, err = applyHistoryResultQuery(rows, query, flipHistoryAccessors)
+	}
+	if err != nil {
+		writeError(w, 400, err.Error())
+		return
 	}
 
 	writeJSON(w, map[string]interface{}{
 		"scan":    record,
 		"results": results,
+		"total":   total,
+		"limit":   query.Limit,
+		"offset":  query.Offset,
 	})
 }
 
@@ -3730,9 +4480,11 @@ func (s *Server) handleClearHistory(w http.ResponseWriter, r *http.Request) {
 // --- Auth ---
 
 type authCharacterSummary struct {
-	CharacterID   int64  `json:"character_id"`
-	CharacterName string `json:"character_name"`
-	Active        bool   `json:"active"`
+	CharacterID   int64    `json:"character_id"`
+	CharacterName string   `json:"character_name"`
+	Label         string   `json:"label,omitempty"` // user-defined alias; display only
+	Active        bool     `json:"active"`
+	Scopes        []string `json:"scopes,omitempty"` // empty for sessions from before scopes were recorded
 }
 
 func parseAuthScope(r *http.Request) (characterID int64, all bool, err error) {
@@ -3787,11 +4539,11 @@ func (s *Server) authSessionsForScope(userID string, characterID int64, all bool
 func (s *Server) requireIndustryAuthUser(w http.ResponseWriter, r *http.Request) (string, bool) {
 	userID := userIDFromRequest(r)
 	if s.sessions == nil {
-		writeError(w, http.StatusUnauthorized, "not logged in")
+		writeErrorCode(w, http.StatusUnauthorized, errCodeNotLoggedIn, "not logged in")
 		return "", false
 	}
 	if s.sessions.GetForUser(userID) == nil {
-		writeError(w, http.StatusUnauthorized, "not logged in")
+		writeErrorCode(w, http.StatusUnauthorized, errCodeNotLoggedIn, "not logged in")
 		return "", false
 	}
 	return userID, true
@@ -3813,20 +4565,27 @@ func (s *Server) authStatusPayload(userID string) map[string]interface{} {
 		}
 	}
 	all := s.sessions.ListForUser(userID)
+	var labels map[int64]string
+	if s.db != nil {
+		labels = s.db.GetCharacterLabelsForUser(userID)
+	}
 	characters := make([]authCharacterSummary, 0, len(all))
 	for _, sess := range all {
 		characters = append(characters, authCharacterSummary{
 			CharacterID:   sess.CharacterID,
 			CharacterName: sess.CharacterName,
+			Label:         labels[sess.CharacterID],
 			Active:        sess.Active,
+			Scopes:        strings.Fields(sess.Scopes),
 		})
 	}
 	return map[string]interface{}{
-		"logged_in":      true,
-		"character_id":   active.CharacterID,
-		"character_name": active.CharacterName,
-		"characters":     characters,
-		"auth_revision":  revision,
+		"logged_in":       true,
+		"character_id":    active.CharacterID,
+		"character_name":  active.CharacterName,
+		"character_label": labels[active.CharacterID],
+		"characters":      characters,
+		"auth_revision":   revision,
 	}
 }
 
@@ -3839,6 +4598,15 @@ func (s *Server) handleAuthLogin(w http.ResponseWriter, r *http.Request) {
 		writeError(w, 500, "SSO not configured")
 		return
 	}
+	scopes := s.sso.Scopes
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", "full":
+	case "basic":
+		scopes = auth.BasicScopes
+	default:
+		writeError(w, 400, "mode must be basic or full")
+		return
+	}
 	state := auth.GenerateState()
 	desktop := r.URL.Query().Get("desktop") == "1"
 	userID := userIDFromRequest(r)
@@ -3855,10 +4623,11 @@ func (s *Server) handleAuthLogin(w http.ResponseWriter, r *http.Request) {
 		ExpiresAt: now.Add(10 * time.Minute),
 		Desktop:   desktop,
 		UserID:    userID,
+		Scopes:    scopes,
 	}
 	s.ssoStatesMu.Unlock()
 
-	http.Redirect(w, r, s.sso.BuildAuthURL(state), http.StatusTemporaryRedirect)
+	http.Redirect(w, r, s.sso.BuildAuthURLWithScopes(state, scopes), http.StatusTemporaryRedirect)
 }
 
 func (s *Server) handleAuthCallback(w http.ResponseWriter, r *http.Request) {
@@ -3904,12 +4673,18 @@ func (s *Server) handleAuthCallback(w http.ResponseWriter, r *http.Request) {
 		userID = userIDFromRequest(r)
 	}
 	userID = s.setUserIDCookie(w, r, userID)
+	// Record what SSO actually granted; fall back to what was requested.
+	scopes := strings.TrimSpace(info.Scopes)
+	if scopes == "" {
+		scopes = entry.Scopes
+	}
 	sess := &auth.Session{
 		CharacterID:   info.CharacterID,
 		CharacterName: info.CharacterName,
 		AccessToken:   tok.AccessToken,
 		RefreshToken:  tok.RefreshToken,
 		ExpiresAt:     time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second),
+		Scopes:        scopes,
 	}
 	if err := s.sessions.SaveAndActivateForUser(userID, sess); err != nil {
 		log.Printf("[AUTH] Save session error: %v", err)
@@ -3958,28 +4733,49 @@ func (s *Server) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
 	s.writeAuthStatus(w, userID)
 }
 
+// handleAuthLogout logs out the active character only; the next remaining
+// character (if any) becomes active.
 func (s *Server) handleAuthLogout(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromRequest(r)
+	if s.sessions != nil {
+		if active := s.sessions.GetForUser(userID); active != nil {
+			if err := s.sessions.DeleteByCharacterIDForUser(userID, active.CharacterID); err != nil {
+				writeError(w, 500, "logout failed: "+err.Error())
+				return
+			}
+			log.Printf("[AUTH] Logged out %s", active.CharacterName)
+		}
+	}
+	s.bumpAuthRevision(userID)
+	s.clearWalletTxnCache()
+	s.clearWalletBalanceCache()
+	s.writeAuthStatus(w, userID)
+}
+
+// handleAuthLogoutAll drops every character session for the user.
+func (s *Server) handleAuthLogoutAll(w http.ResponseWriter, r *http.Request) {
 	userID := userIDFromRequest(r)
 	if s.sessions != nil {
 		s.sessions.DeleteForUser(userID)
 	}
 	s.bumpAuthRevision(userID)
 	s.clearWalletTxnCache()
-	log.Println("[AUTH] Logged out")
+	s.clearWalletBalanceCache()
+	log.Println("[AUTH] Logged out all characters")
 	s.writeAuthStatus(w, userID)
 }
 
 func (s *Server) handleAuthCharacterSelect(w http.ResponseWriter, r *http.Request) {
 	userID := userIDFromRequest(r)
 	if s.sessions == nil {
-		writeError(w, 401, "not logged in")
+		writeErrorCode(w, 401, errCodeNotLoggedIn, "not logged in")
 		return
 	}
 	var req struct {
 		CharacterID int64 `json:"character_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 	if req.CharacterID <= 0 {
@@ -3992,13 +4788,14 @@ func (s *Server) handleAuthCharacterSelect(w http.ResponseWriter, r *http.Reques
 	}
 	s.bumpAuthRevision(userID)
 	s.clearWalletTxnCache()
+	s.clearWalletBalanceCache()
 	s.writeAuthStatus(w, userID)
 }
 
 func (s *Server) handleAuthCharacterDelete(w http.ResponseWriter, r *http.Request) {
 	userID := userIDFromRequest(r)
 	if s.sessions == nil {
-		writeError(w, 401, "not logged in")
+		writeErrorCode(w, 401, errCodeNotLoggedIn, "not logged in")
 		return
 	}
 	characterID, err := strconv.ParseInt(r.PathValue("characterID"), 10, 64)
@@ -4012,6 +4809,51 @@ func (s *Server) handleAuthCharacterDelete(w http.ResponseWriter, r *http.Reques
 	}
 	s.bumpAuthRevision(userID)
 	s.clearWalletTxnCache()
+	s.clearWalletBalanceCache()
+	s.writeAuthStatus(w, userID)
+}
+
+// handleAuthCharacterLabel sets or clears the cosmetic label shown for one of
+// the user's logged-in characters.
+func (s *Server) handleAuthCharacterLabel(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromRequest(r)
+	if s.sessions == nil {
+		writeErrorCode(w, 401, errCodeNotLoggedIn, "not logged in")
+		return
+	}
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	characterID, err := strconv.ParseInt(r.PathValue("characterID"), 10, 64)
+	if err != nil || characterID <= 0 {
+		writeError(w, 400, "invalid characterID")
+		return
+	}
+	var req struct {
+		Label string `json:"label"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+		return
+	}
+
+	found := false
+	for _, sess := range s.sessions.ListForUser(userID) {
+		if sess.CharacterID == characterID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		writeError(w, 404, "character not found")
+		return
+	}
+	if err := s.db.SetCharacterLabelForUser(userID, characterID, req.Label); err != nil {
+		writeError(w, 500, "failed to save label")
+		return
+	}
+	s.bumpAuthRevision(userID)
 	s.writeAuthStatus(w, userID)
 }
 
@@ -4022,6 +4864,7 @@ func (s *Server) handleAuthCharacter(w http.ResponseWriter, r *http.Request) {
 		CharacterID   int64                        `json:"character_id"`
 		CharacterName string                       `json:"character_name"`
 		Wallet        float64                      `json:"wallet"`
+		WalletCached  bool                         `json:"wallet_cached"`
 		Orders        []esi.CharacterOrder         `json:"orders"`
 		OrderHistory  []esi.HistoricalOrder        `json:"order_history"`
 		Transactions  []esi.WalletTransaction      `json:"transactions"`
@@ -4061,9 +4904,23 @@ func (s *Server) handleAuthCharacter(w http.ResponseWriter, r *http.Request) {
 
 		wgChar.Add(5)
 
+		// Each part needs its own scope; a missing one leaves that part empty,
+		// like skills on basic logins.
 		go func() {
 			defer wgChar.Done()
+			if !sess.HasScope(auth.ScopeReadCharacterWallet) {
+				return
+			}
+			// The balance rarely moves between popup opens; reuse it briefly.
+			if balance, ok := s.getWalletBalanceCache(sess.CharacterID); ok {
+				muChar.Lock()
+				result.Wallet = balance
+				result.WalletCached = true
+				muChar.Unlock()
+				return
+			}
 			if balance, fetchErr := s.esi.GetWalletBalance(sess.CharacterID, token); fetchErr == nil {
+				s.setWalletBalanceCache(sess.CharacterID, balance)
 				muChar.Lock()
 				result.Wallet = balance
 				muChar.Unlock()
@@ -4074,6 +4931,9 @@ func (s *Server) handleAuthCharacter(w http.ResponseWriter, r *http.Request) {
 
 		go func() {
 			defer wgChar.Done()
+			if !sess.HasScope(auth.ScopeReadCharacterOrders) {
+				return
+			}
 			if orders, fetchErr := s.esi.GetCharacterOrders(sess.CharacterID, token); fetchErr == nil {
 				muChar.Lock()
 				result.Orders = orders
@@ -4085,6 +4945,9 @@ func (s *Server) handleAuthCharacter(w http.ResponseWriter, r *http.Request) {
 
 		go func() {
 			defer wgChar.Done()
+			if !sess.HasScope(auth.ScopeReadCharacterOrders) {
+				return
+			}
 			if history, fetchErr := s.esi.GetOrderHistory(sess.CharacterID, token); fetchErr == nil {
 				muChar.Lock()
 				result.OrderHistory = history
@@ -4096,6 +4959,9 @@ func (s *Server) handleAuthCharacter(w http.ResponseWriter, r *http.Request) {
 
 		go func() {
 			defer wgChar.Done()
+			if !sess.HasScope(auth.ScopeReadCharacterWallet) {
+				return
+			}
 			if txns, fetchErr := s.esi.GetWalletTransactions(sess.CharacterID, token); fetchErr == nil {
 				muChar.Lock()
 				result.Transactions = txns
@@ -4107,6 +4973,11 @@ func (s *Server) handleAuthCharacter(w http.ResponseWriter, r *http.Request) {
 
 		go func() {
 			defer wgChar.Done()
+			// Basic logins do not grant skills; leave them empty rather than
+			// failing the whole popup.
+			if !sess.HasScope(auth.ScopeReadSkills) {
+				return
+			}
 			if skills, fetchErr := s.esi.GetSkills(sess.CharacterID, token); fetchErr == nil {
 				muChar.Lock()
 				result.Skills = skills
@@ -4143,9 +5014,12 @@ func (s *Server) handleAuthCharacter(w http.ResponseWriter, r *http.Request) {
 		result = charInfo{
 			CharacterID:   0,
 			CharacterName: "All Characters",
+			WalletCached:  true,
 		}
 		for _, part := range collected {
 			result.Wallet += part.Wallet
+			// Cached only when every character's balance came from the cache.
+			result.WalletCached = result.WalletCached && part.WalletCached
 			result.Orders = append(result.Orders, part.Orders...)
 			result.OrderHistory = append(result.OrderHistory, part.OrderHistory...)
 			result.Transactions = append(result.Transactions, part.Transactions...)
@@ -4226,6 +5100,10 @@ func (s *Server) handleAuthLocation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	sess := selectedSessions[0]
+	if err := requireScope(sess, auth.ScopeReadLocation); err != nil {
+		writeScopeError(w, err)
+		return
+	}
 
 	token, err := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
 	if err != nil {
@@ -4292,6 +5170,14 @@ func (s *Server) handleAuthUndercuts(w http.ResponseWriter, r *http.Request) {
 	// Fetch active orders for the selected scope.
 	var orders []esi.CharacterOrder
 	for _, sess := range selectedSessions {
+		if scopeErr := requireScope(sess, auth.ScopeReadCharacterOrders); scopeErr != nil {
+			log.Printf("[AUTH] Undercuts skipped: %v", scopeErr)
+			if !allScope {
+				writeScopeError(w, scopeErr)
+				return
+			}
+			continue
+		}
 		token, tokenErr := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
 		if tokenErr != nil {
 			log.Printf("[AUTH] Undercuts token error (%s): %v", sess.CharacterName, tokenErr)
@@ -4318,34 +5204,48 @@ func (s *Server) handleAuthUndercuts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Collect unique (region, type) pairs.
-	type regionType struct {
-		regionID int32
-		typeID   int32
-	}
-	pairs := make(map[regionType]bool)
+	allRegional := s.fetchUndercutBook(orders)
+	undercuts := engine.AnalyzeUndercuts(orders, allRegional)
+	writeJSON(w, undercuts)
+}
+
+// regionTypeKey identifies one regional order book of a single type.
+type regionTypeKey struct {
+	regionID int32
+	typeID   int32
+}
+
+// fetchUndercutBook fetches the regional order book for every (region, type)
+// pair among orders, for engine.AnalyzeUndercuts.
+func (s *Server) fetchUndercutBook(orders []esi.CharacterOrder) []esi.MarketOrder {
+	pairs := make(map[regionTypeKey]bool)
 	for _, o := range orders {
-		pairs[regionType{o.RegionID, o.TypeID}] = true
+		pairs[regionTypeKey{o.RegionID, o.TypeID}] = true
 	}
+	return s.fetchRegionTypeOrders(pairs)
+}
 
+// fetchRegionTypeOrders fetches the regional orders of each (region, type)
+// pair and flattens them into one slice. Pairs that fail to load are skipped.
+func (s *Server) fetchRegionTypeOrders(pairs map[regionTypeKey]bool) []esi.MarketOrder {
 	// Fetch regional orders for each unique type (concurrently, with semaphore).
 	// Limit concurrency to 10 to avoid ESI rate-limit issues.
 	type fetchResult struct {
 		orders []esi.MarketOrder
 		err    error
 	}
-	results := make(map[regionType]fetchResult)
+	results := make(map[regionTypeKey]fetchResult)
 	var mu sync.Mutex
 	var wg sync.WaitGroup
-	undercutSem := make(chan struct{}, 10) // limit to 10 concurrent ESI requests
+	sem := make(chan struct{}, 10) // limit to 10 concurrent ESI requests
 
 	for pair := range pairs {
 		wg.Add(1)
-		go func(rt regionType) {
+		go func(rt regionTypeKey) {
 			defer wg.Done()
-			undercutSem <- struct{}{}
+			sem <- struct{}{}
 			ro, fetchErr := s.esi.FetchRegionOrdersByType(rt.regionID, rt.typeID)
-			<-undercutSem
+			<-sem
 			mu.Lock()
 			results[rt] = fetchResult{ro, fetchErr}
 			mu.Unlock()
@@ -4360,9 +5260,7 @@ func (s *Server) handleAuthUndercuts(w http.ResponseWriter, r *http.Request) {
 			allRegional = append(allRegional, fr.orders...)
 		}
 	}
-
-	undercuts := engine.AnalyzeUndercuts(orders, allRegional)
-	writeJSON(w, undercuts)
+	return allRegional
 }
 
 func (s *Server) handleAuthGetStationTradeStates(w http.ResponseWriter, r *http.Request) {
@@ -4416,7 +5314,7 @@ func (s *Server) handleAuthGetStationTradeStates(w http.ResponseWriter, r *http.
 func (s *Server) handleAuthSetStationTradeState(w http.ResponseWriter, r *http.Request) {
 	userID := userIDFromRequest(r)
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -4429,7 +5327,7 @@ func (s *Server) handleAuthSetStationTradeState(w http.ResponseWriter, r *http.R
 		UntilRevision int64  `json:"until_revision"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 	mode := strings.ToLower(strings.TrimSpace(req.Mode))
@@ -4468,7 +5366,7 @@ func (s *Server) handleAuthSetStationTradeState(w http.ResponseWriter, r *http.R
 func (s *Server) handleAuthDeleteStationTradeStates(w http.ResponseWriter, r *http.Request) {
 	userID := userIDFromRequest(r)
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -4477,7 +5375,7 @@ func (s *Server) handleAuthDeleteStationTradeStates(w http.ResponseWriter, r *ht
 		Keys []db.TradeStateKey `json:"keys"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 	if len(req.Keys) == 0 {
@@ -4501,7 +5399,7 @@ func (s *Server) handleAuthDeleteStationTradeStates(w http.ResponseWriter, r *ht
 func (s *Server) handleAuthClearStationTradeStates(w http.ResponseWriter, r *http.Request) {
 	userID := userIDFromRequest(r)
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -4510,7 +5408,7 @@ func (s *Server) handleAuthClearStationTradeStates(w http.ResponseWriter, r *htt
 		Mode string `json:"mode"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 
@@ -4536,7 +5434,7 @@ func (s *Server) handleAuthListIndustryProjects(w http.ResponseWriter, r *http.R
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -4571,7 +5469,7 @@ func (s *Server) handleAuthCreateIndustryProject(w http.ResponseWriter, r *http.
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -4583,7 +5481,7 @@ func (s *Server) handleAuthCreateIndustryProject(w http.ResponseWriter, r *http.
 		Params   interface{} `json:"params"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 	if strings.TrimSpace(req.Name) == "" {
@@ -4618,7 +5516,7 @@ func (s *Server) handleAuthIndustryProjectSnapshot(w http.ResponseWriter, r *htt
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -4647,7 +5545,7 @@ func (s *Server) handleAuthPreviewIndustryProjectPlan(w http.ResponseWriter, r *
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -4660,7 +5558,7 @@ func (s *Server) handleAuthPreviewIndustryProjectPlan(w http.ResponseWriter, r *
 
 	var patch db.IndustryPlanPatch
 	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 
@@ -4682,7 +5580,7 @@ func (s *Server) handleAuthPlanIndustryProject(w http.ResponseWriter, r *http.Re
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -4695,10 +5593,10 @@ func (s *Server) handleAuthPlanIndustryProject(w http.ResponseWriter, r *http.Re
 
 	var patch db.IndustryPlanPatch
 	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
-	summary, err := s.db.ApplyIndustryPlanForUser(userID, projectID, patch)
+	summary, diff, err := s.db.ApplyIndustryPlanWithDiffForUser(userID, projectID, patch)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) || strings.Contains(strings.ToLower(err.Error()), "project not found") {
 			writeError(w, 404, "industry project not found")
@@ -4707,10 +5605,15 @@ func (s *Server) handleAuthPlanIndustryProject(w http.ResponseWriter, r *http.Re
 		writeError(w, 500, "failed to apply industry plan")
 		return
 	}
-	writeJSON(w, map[string]interface{}{
+	resp := map[string]interface{}{
 		"ok":      true,
 		"summary": summary,
-	})
+	}
+	// ?diff=1 echoes what the apply changed, in the preview's diff format.
+	if r.URL.Query().Get("diff") == "1" {
+		resp["diff"] = diff
+	}
+	writeJSON(w, resp)
 }
 
 func (s *Server) handleAuthRebalanceIndustryProjectMaterials(w http.ResponseWriter, r *http.Request) {
@@ -4719,7 +5622,7 @@ func (s *Server) handleAuthRebalanceIndustryProjectMaterials(w http.ResponseWrit
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -4740,7 +5643,7 @@ func (s *Server) handleAuthRebalanceIndustryProjectMaterials(w http.ResponseWrit
 	}
 	if r.Body != nil {
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
-			writeError(w, 400, "invalid json")
+			writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 			return
 		}
 	}
@@ -4804,6 +5707,9 @@ func (s *Server) handleAuthRebalanceIndustryProjectMaterials(w http.ResponseWrit
 	}
 
 	fetchTxns := func(sess *auth.Session) ([]esi.WalletTransaction, error) {
+		if err := requireScope(sess, auth.ScopeReadCharacterWallet); err != nil {
+			return nil, err
+		}
 		if cached, ok := s.getWalletTxnCache(sess.CharacterID); ok {
 			return cached, nil
 		}
@@ -4837,7 +5743,9 @@ func (s *Server) handleAuthRebalanceIndustryProjectMaterials(w http.ResponseWrit
 		if fetchErr != nil {
 			log.Printf("[AUTH] Industry material rebalance txns error (%s): %v", sess.CharacterName, fetchErr)
 			if !allScope {
-				writeError(w, 500, "failed to fetch transactions: "+fetchErr.Error())
+				if !writeScopeError(w, fetchErr) {
+					writeError(w, 500, "failed to fetch transactions: "+fetchErr.Error())
+				}
 				return
 			}
 			continue
@@ -4943,7 +5851,7 @@ func (s *Server) handleAuthSyncIndustryProjectBlueprintPool(w http.ResponseWrite
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -4962,7 +5870,7 @@ func (s *Server) handleAuthSyncIndustryProjectBlueprintPool(w http.ResponseWrite
 	}
 	if r.Body != nil {
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
-			writeError(w, 400, "invalid json")
+			writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 			return
 		}
 	}
@@ -5126,16 +6034,34 @@ func (s *Server) handleAuthSyncIndustryProjectBlueprintPool(w http.ResponseWrite
 			continue
 		}
 
+		if !sess.HasScope(auth.ScopeReadBlueprints) && !sess.HasScope(auth.ScopeReadAssets) {
+			scopeErr := requireScope(sess, auth.ScopeReadBlueprints)
+			log.Printf("[AUTH] Industry blueprint sync skipped: %v", scopeErr)
+			if !allScope {
+				writeScopeError(w, scopeErr)
+				return
+			}
+			continue
+		}
+
 		sourceOK := false
 
-		charBlueprints, bpErr := s.esi.GetCharacterBlueprints(sess.CharacterID, token)
+		var charBlueprints []esi.CharacterBlueprint
+		bpErr := requireScope(sess, auth.ScopeReadBlueprints)
+		if bpErr == nil {
+			charBlueprints, bpErr = s.esi.GetCharacterBlueprints(sess.CharacterID, token)
+		}
 		if bpErr == nil {
 			sourceOK = true
 			blueprintsEndpointCharacters++
 			blueprintRowsScanned += len(charBlueprints)
 
 			assetByItemID := map[int64]esi.CharacterAsset{}
-			assets, assetErr := s.esi.GetCharacterAssets(sess.CharacterID, token)
+			var assets []esi.CharacterAsset
+			assetErr := requireScope(sess, auth.ScopeReadAssets)
+			if assetErr == nil {
+				assets, assetErr = s.esi.GetCharacterAssets(sess.CharacterID, token)
+			}
 			if assetErr == nil {
 				assetsScanned += len(assets)
 				assetByItemID = make(map[int64]esi.CharacterAsset, len(assets))
@@ -5182,11 +6108,17 @@ func (s *Server) handleAuthSyncIndustryProjectBlueprintPool(w http.ResponseWrite
 		} else {
 			log.Printf("[AUTH] Industry blueprint sync blueprints error (%s): %v", sess.CharacterName, bpErr)
 
-			assets, fetchErr := s.esi.GetCharacterAssets(sess.CharacterID, token)
+			var assets []esi.CharacterAsset
+			fetchErr := requireScope(sess, auth.ScopeReadAssets)
+			if fetchErr == nil {
+				assets, fetchErr = s.esi.GetCharacterAssets(sess.CharacterID, token)
+			}
 			if fetchErr != nil {
 				log.Printf("[AUTH] Industry blueprint sync assets fallback error (%s): %v", sess.CharacterName, fetchErr)
 				if !allScope {
-					writeError(w, 500, "failed to fetch blueprints/assets: "+fetchErr.Error())
+					if !writeScopeError(w, fetchErr) {
+						writeError(w, 500, "failed to fetch blueprints/assets: "+fetchErr.Error())
+					}
 					return
 				}
 				continue
@@ -5323,7 +6255,7 @@ func (s *Server) handleAuthUpdateIndustryTaskStatus(w http.ResponseWriter, r *ht
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -5333,7 +6265,7 @@ func (s *Server) handleAuthUpdateIndustryTaskStatus(w http.ResponseWriter, r *ht
 		Priority *int   `json:"priority"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 	if req.TaskID <= 0 {
@@ -5367,7 +6299,7 @@ func (s *Server) handleAuthBulkUpdateIndustryTaskStatus(w http.ResponseWriter, r
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -5377,7 +6309,7 @@ func (s *Server) handleAuthBulkUpdateIndustryTaskStatus(w http.ResponseWriter, r
 		Priority *int    `json:"priority"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 	if len(req.TaskIDs) == 0 {
@@ -5414,7 +6346,7 @@ func (s *Server) handleAuthUpdateIndustryTaskPriority(w http.ResponseWriter, r *
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -5423,7 +6355,7 @@ func (s *Server) handleAuthUpdateIndustryTaskPriority(w http.ResponseWriter, r *
 		Priority *int  `json:"priority"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 	if req.TaskID <= 0 {
@@ -5465,7 +6397,7 @@ func (s *Server) handleAuthBulkUpdateIndustryTaskPriority(w http.ResponseWriter,
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -5474,7 +6406,7 @@ func (s *Server) handleAuthBulkUpdateIndustryTaskPriority(w http.ResponseWriter,
 		Priority *int    `json:"priority"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 	if len(req.TaskIDs) == 0 {
@@ -5513,7 +6445,7 @@ func (s *Server) handleAuthUpdateIndustryJobStatus(w http.ResponseWriter, r *htt
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -5525,7 +6457,7 @@ func (s *Server) handleAuthUpdateIndustryJobStatus(w http.ResponseWriter, r *htt
 		Notes      string `json:"notes"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 	if req.JobID <= 0 {
@@ -5561,7 +6493,7 @@ func (s *Server) handleAuthBulkUpdateIndustryJobStatus(w http.ResponseWriter, r
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -5573,7 +6505,7 @@ func (s *Server) handleAuthBulkUpdateIndustryJobStatus(w http.ResponseWriter, r
 		Notes      string  `json:"notes"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 	if len(req.JobIDs) == 0 {
@@ -5619,7 +6551,7 @@ func (s *Server) handleAuthIndustryLedger(w http.ResponseWriter, r *http.Request
 		return
 	}
 	if s.db == nil {
-		writeError(w, 503, "database unavailable")
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
 		return
 	}
 
@@ -5687,21 +6619,26 @@ func (s *Server) handleAuthOrderDesk(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	salesTax := 8.0
-	if cfg := s.loadConfigForUser(userID); cfg != nil {
-		salesTax = cfg.SalesTaxPercent
+	// Fees: explicit query values win, then a sales tax changed from the
+	// config default, then the character's trading skills, then the base
+	// 8% sales tax and a 1% broker fee. Skill fees assume neutral standings.
+	salesTax, salesTaxSource := engine.BaseSalesTaxPercent, "default"
+	if cfg := s.loadConfigForUser(userID); cfg != nil && cfg.SalesTaxPercent != salesTax {
+		salesTax, salesTaxSource = cfg.SalesTaxPercent, "config"
 	}
 	if v := r.URL.Query().Get("sales_tax"); v != "" {
 		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 100 {
-			salesTax = f
+			salesTax, salesTaxSource = f, "request"
 		}
 	}
-	brokerFee := 1.0
+	brokerFee, brokerFeeSource := 1.0, "default"
 	if v := r.URL.Query().Get("broker_fee"); v != "" {
 		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 100 {
-			brokerFee = f
+			brokerFee, brokerFeeSource = f, "request"
 		}
 	}
+	needSkills := salesTaxSource == "default" || brokerFeeSource == "default"
+	var skillFees *engine.TradeFees
 	targetETADays := 3.0
 	if v := r.URL.Query().Get("target_eta_days"); v != "" {
 		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 && f <= 60 {
@@ -5711,6 +6648,14 @@ func (s *Server) handleAuthOrderDesk(w http.ResponseWriter, r *http.Request) {
 
 	var orders []esi.CharacterOrder
 	for _, sess := range selectedSessions {
+		if scopeErr := requireScope(sess, auth.ScopeReadCharacterOrders); scopeErr != nil {
+			log.Printf("[AUTH] OrderDesk skipped: %v", scopeErr)
+			if !allScope {
+				writeScopeError(w, scopeErr)
+				return
+			}
+			continue
+		}
 		token, tokenErr := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
 		if tokenErr != nil {
 			log.Printf("[AUTH] OrderDesk token error (%s): %v", sess.CharacterName, tokenErr)
@@ -5720,6 +6665,16 @@ func (s *Server) handleAuthOrderDesk(w http.ResponseWriter, r *http.Request) {
 			}
 			continue
 		}
+		// With several characters the first one whose skills load sets
+		// the fees.
+		if needSkills && skillFees == nil && sess.HasScope(auth.ScopeReadSkills) {
+			if sheet, skillsErr := s.esi.GetSkills(sess.CharacterID, token); skillsErr == nil {
+				fees := engine.EffectiveTradeFees(sheet)
+				skillFees = &fees
+			} else {
+				log.Printf("[AUTH] OrderDesk skills error (%s): %v", sess.CharacterName, skillsErr)
+			}
+		}
 		charOrders, fetchErr := s.esi.GetCharacterOrders(sess.CharacterID, token)
 		if fetchErr != nil {
 			log.Printf("[AUTH] OrderDesk orders error (%s): %v", sess.CharacterName, fetchErr)
@@ -5732,13 +6687,26 @@ func (s *Server) handleAuthOrderDesk(w http.ResponseWriter, r *http.Request) {
 		orders = append(orders, charOrders...)
 	}
 
+	if skillFees != nil {
+		if salesTaxSource == "default" {
+			salesTax, salesTaxSource = skillFees.SalesTaxPercent, "skills"
+		}
+		if brokerFeeSource == "default" {
+			brokerFee, brokerFeeSource = skillFees.BrokerFeePercent, "skills"
+		}
+	}
+	deskOptions := engine.OrderDeskOptions{
+		SalesTaxPercent:  salesTax,
+		BrokerFeePercent: brokerFee,
+		TargetETADays:    targetETADays,
+		WarnExpiryDays:   2,
+		SalesTaxSource:   salesTaxSource,
+		BrokerFeeSource:  brokerFeeSource,
+		SkillFees:        skillFees,
+	}
+
 	if len(orders) == 0 {
-		writeJSON(w, engine.ComputeOrderDesk(nil, nil, nil, nil, engine.OrderDeskOptions{
-			SalesTaxPercent:  salesTax,
-			BrokerFeePercent: brokerFee,
-			TargetETADays:    targetETADays,
-			WarnExpiryDays:   2,
-		}))
+		writeJSON(w, engine.ComputeOrderDesk(nil, nil, nil, nil, deskOptions))
 		return
 	}
 
@@ -5823,19 +6791,14 @@ func (s *Server) handleAuthOrderDesk(w http.ResponseWriter, r *http.Request) {
 		unavailableBooks[engine.NewOrderDeskHistoryKey(rt.regionID, rt.typeID)] = true
 	}
 
-	result := engine.ComputeOrderDesk(orders, allRegional, history, unavailableBooks, engine.OrderDeskOptions{
-		SalesTaxPercent:  salesTax,
-		BrokerFeePercent: brokerFee,
-		TargetETADays:    targetETADays,
-		WarnExpiryDays:   2,
-	})
+	result := engine.ComputeOrderDesk(orders, allRegional, history, unavailableBooks, deskOptions)
 	writeJSON(w, result)
 }
 
 func (s *Server) handleAuthStationCommand(w http.ResponseWriter, r *http.Request) {
 	userID := userIDFromRequest(r)
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
 		return
 	}
 
@@ -5873,7 +6836,7 @@ func (s *Server) handleAuthStationCommand(w http.ResponseWriter, r *http.Request
 		MaxResults           int     `json:"max_results"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 
@@ -5891,6 +6854,16 @@ func (s *Server) handleAuthStationCommand(w http.ResponseWriter, r *http.Request
 		}
 		return
 	}
+	// Check scopes before the scan; with all characters selected the ones
+	// missing a scope are skipped below instead.
+	if !allScope {
+		for _, scope := range []string{auth.ScopeReadCharacterOrders, auth.ScopeReadCharacterWallet} {
+			if err := requireScope(selectedSessions[0], scope); err != nil {
+				writeScopeError(w, err)
+				return
+			}
+		}
+	}
 
 	s.mu.RLock()
 	scanner := s.scanner
@@ -5912,7 +6885,7 @@ func (s *Server) handleAuthStationCommand(w http.ResponseWriter, r *http.Request
 	if radiusMode {
 		systemID, ok := sdeData.SystemByName[strings.ToLower(req.SystemName)]
 		if !ok {
-			writeError(w, 400, "unknown system")
+			writeErrorCode(w, 400, errCodeSystemNotFound, "unknown system")
 			return
 		}
 		systems := sdeData.Universe.SystemsWithinRadius(systemID, req.Radius)
@@ -5948,12 +6921,7 @@ func (s *Server) handleAuthStationCommand(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	accessToken := ""
-	if req.IncludeStructures && s.sessions != nil {
-		if token, tokenErr := s.sessions.EnsureValidTokenForUser(s.sso, userID); tokenErr == nil {
-			accessToken = token
-		}
-	}
+	accessToken, characterID := s.structureMarketToken(userID, req.IncludeStructures)
 
 	userCfg := s.loadConfigForUser(userID)
 	if !req.SplitTradeFees {
@@ -5998,6 +6966,7 @@ func (s *Server) handleAuthStationCommand(w http.ResponseWriter, r *http.Request
 			LimitBuyToPriceLow:   req.LimitBuyToPriceLow,
 			FlagExtremePrices:    req.FlagExtremePrices,
 			AccessToken:          accessToken,
+			CharacterID:          characterID,
 			IncludeStructures:    req.IncludeStructures,
 			Ctx:                  r.Context(),
 		}
@@ -6020,7 +6989,7 @@ func (s *Server) handleAuthStationCommand(w http.ResponseWriter, r *http.Request
 	if !req.IncludeStructures {
 		scanResults = filterStationTradesExcludeStructures(scanResults)
 	}
-	scanResults = filterStationTradesMarketDisabled(scanResults)
+	scanResults = s.filterStationTradesMarketDisabled(userID, scanResults)
 	sort.Slice(scanResults, func(i, j int) bool {
 		if scanResults[i].CTS != scanResults[j].CTS {
 			return scanResults[i].CTS > scanResults[j].CTS
@@ -6033,6 +7002,9 @@ func (s *Server) handleAuthStationCommand(w http.ResponseWriter, r *http.Request
 
 	var activeOrders []esi.CharacterOrder
 	for _, sess := range selectedSessions {
+		if !sess.HasScope(auth.ScopeReadCharacterOrders) {
+			continue
+		}
 		token, tokenErr := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
 		if tokenErr != nil {
 			log.Printf("[AUTH] StationCommand token error (%s): %v", sess.CharacterName, tokenErr)
@@ -6144,6 +7116,9 @@ func (s *Server) handleAuthStationCommand(w http.ResponseWriter, r *http.Request
 
 	var txns []esi.WalletTransaction
 	for _, sess := range selectedSessions {
+		if !sess.HasScope(auth.ScopeReadCharacterWallet) {
+			continue
+		}
 		if !allScope {
 			if cached, ok := s.getWalletTxnCache(sess.CharacterID); ok {
 				txns = append(txns, cached...)
@@ -6290,13 +7265,24 @@ func normalizeStationAIHistory(history []stationAIHistoryMessage) []stationAIHis
 func normalizeStationAIChatRequest(req *stationAIChatRequestPayload) (bool, bool, []string, string) {
 	req.Provider = strings.TrimSpace(strings.ToLower(req.Provider))
 	if req.Provider == "" {
-		req.Provider = "openrouter"
+		req.Provider = stationAIProviderOpenRouter
+	}
+	req.BaseURL = strings.TrimSpace(req.BaseURL)
+	if req.Provider == stationAIProviderOllama {
+		if req.BaseURL == "" {
+			req.BaseURL = defaultOllamaChatURL
+		}
+		if msg := validateLocalProviderURL(req.BaseURL); msg != "" {
+			return false, false, nil, msg
+		}
+	} else {
+		req.BaseURL = ""
 	}
-	if req.Provider != "openrouter" {
+	if _, ok := stationAIProviderFor(*req); !ok {
 		return false, false, nil, "unsupported ai provider"
 	}
 	req.APIKey = strings.TrimSpace(req.APIKey)
-	if req.APIKey == "" {
+	if req.APIKey == "" && req.Provider != stationAIProviderOllama {
 		return false, false, nil, "api_key is required"
 	}
 	req.Model = strings.TrimSpace(req.Model)
@@ -6317,6 +7303,8 @@ func normalizeStationAIChatRequest(req *stationAIChatRequestPayload) (bool, bool
 	if req.AssistantName == "" {
 		req.AssistantName = "Ivy AI"
 	}
+	req.NumberLocale = normalizeStationAINumberLocale(req.NumberLocale)
+	req.ISKFormat = normalizeStationAIISKFormat(req.ISKFormat)
 
 	enableWiki := true
 	if req.EnableWiki != nil {
@@ -6327,6 +7315,7 @@ func normalizeStationAIChatRequest(req *stationAIChatRequestPayload) (bool, bool
 		enableWeb = *req.EnableWeb
 	}
 	req.WikiRepo = sanitizeWikiRepo(req.WikiRepo)
+	req.WikiPages, _ = config.SanitizeAIWikiPages(req.WikiPages)
 	req.History = normalizeStationAIHistory(req.History)
 
 	warnings := make([]string, 0, 8)
@@ -6355,14 +7344,15 @@ func normalizeStationAIChatRequest(req *stationAIChatRequestPayload) (bool, bool
 		warnings = append(warnings, "scan_snapshot.scope_mode reset to empty")
 	}
 
-	req.Context.ScanSnapshot.CTSProfile = strings.TrimSpace(strings.ToLower(req.Context.ScanSnapshot.CTSProfile))
-	switch req.Context.ScanSnapshot.CTSProfile {
-	case "", "balanced", "aggressive", "defensive":
-		// allowed
-	default:
-		req.Context.ScanSnapshot.CTSProfile = "balanced"
+	// Built-in presets are lowercased; custom profile names are kept as saved.
+	ctsProfile := strings.TrimSpace(req.Context.ScanSnapshot.CTSProfile)
+	if engine.IsBuiltinCTSProfile(ctsProfile) {
+		ctsProfile = strings.ToLower(ctsProfile)
+	} else if _, ok := normalizeConfigProfileName(ctsProfile); ctsProfile != "" && !ok {
+		ctsProfile = "balanced"
 		warnings = append(warnings, "scan_snapshot.cts_profile reset to balanced")
 	}
+	req.Context.ScanSnapshot.CTSProfile = ctsProfile
 
 	if req.Context.ScanSnapshot.StructureCount < 0 {
 		req.Context.ScanSnapshot.StructureCount = 0
@@ -6730,7 +7720,7 @@ func stationAIPreflightFailAnswer(locale string, missing []string) string {
 	return "I cannot run this analysis because required context fields are missing (" + list + "). Re-run scan and try again."
 }
 
-func stationAIValidateAnswer(answer string, intent stationAIIntentKind) (bool, string) {
+func stationAIValidateAnswer(answer string, intent stationAIIntentKind, format stationAINumberFormat) (bool, string) {
 	trimmed := strings.TrimSpace(answer)
 	if trimmed == "" {
 		return false, "empty ai answer"
@@ -6763,6 +7753,11 @@ func stationAIValidateAnswer(answer string, intent stationAIIntentKind) (bool, s
 			return false, "trading answer has no numeric evidence"
 		}
 	}
+	if intent != stationAIIntentSmallTalk {
+		if ok, issue := format.validateISKAmounts(trimmed); !ok {
+			return false, issue
+		}
+	}
 	return true, ""
 }
 
@@ -6860,72 +7855,32 @@ func (s *Server) stationAIResolvePlan(ctx context.Context, req stationAIChatRequ
 }
 
 func (s *Server) stationAIPlannerPass(ctx context.Context, req stationAIChatRequestPayload, fallback stationAIPlannerPlan) (stationAIPlannerPlan, []string) {
-	model := stationAIResolvePlannerModel(req)
-
-	payload := map[string]interface{}{
-		"model":       model,
-		"temperature": 0.0,
-		"max_tokens":  220,
-		"messages": []map[string]string{
-			{"role": "system", "content": stationAIPlannerSystemPrompt(req.Locale)},
-			{
-				"role": "user",
-				"content": stationAIPlannerUserPrompt(
-					req.Locale,
-					req.UserMessage,
-					req.History,
-					req.Context,
-					fallback,
-				),
-			},
+	plannerReq := req
+	plannerReq.Model = stationAIResolvePlannerModel(req)
+	plannerReq.Temperature = 0
+	plannerReq.MaxTokens = 220
+	messages := []map[string]string{
+		{"role": "system", "content": stationAIPlannerSystemPrompt(req.Locale)},
+		{
+			"role": "user",
+			"content": stationAIPlannerUserPrompt(
+				req.Locale,
+				req.UserMessage,
+				req.History,
+				req.Context,
+				fallback,
+			),
 		},
 	}
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return fallback, []string{"planner: failed to encode planner request"}
-	}
 
-	httpReq, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		"https://openrouter.ai/api/v1/chat/completions",
-		bytes.NewReader(body),
-	)
-	if err != nil {
-		return fallback, []string{"planner: failed to create planner request"}
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+req.APIKey)
-	httpReq.Header.Set("HTTP-Referer", "http://localhost:1420")
-	httpReq.Header.Set("X-Title", "EVE Flipper Station AI Planner")
-
-	client := &http.Client{Timeout: 35 * time.Second}
-	resp, err := client.Do(httpReq)
+	plannerCtx, cancel := context.WithTimeout(ctx, 35*time.Second)
+	defer cancel()
+	reply, err := s.stationAIChatOnce(plannerCtx, plannerReq, messages)
 	if err != nil {
 		return fallback, []string{"planner unavailable, using fallback intent routing"}
 	}
-	defer resp.Body.Close()
-
-	rawResp, err := io.ReadAll(io.LimitReader(resp.Body, 1_000_000))
-	if err != nil {
-		return fallback, []string{"planner read failed, using fallback intent routing"}
-	}
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fallback, []string{"planner provider error, using fallback intent routing"}
-	}
-
-	var orResp struct {
-		Choices []struct {
-			Message struct {
-				Content json.RawMessage `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
-	if err := json.Unmarshal(rawResp, &orResp); err != nil || len(orResp.Choices) == 0 {
-		return fallback, []string{"planner invalid response, using fallback intent routing"}
-	}
-	content := extractAIContent(orResp.Choices[0].Message.Content)
-	jsonBlock := aiExtractJSONObject(content)
+	s.recordStationAIUsage(userIDFromContext(ctx), plannerReq, "planner", reply.Model, reply.Usage)
+	jsonBlock := aiExtractJSONObject(reply.Answer)
 	if jsonBlock == "" {
 		return fallback, []string{"planner did not return json, using fallback intent routing"}
 	}
@@ -7169,6 +8124,19 @@ func (s *Server) stationAIBuildRuntimeContext(ctx context.Context, userID, local
 		return runtime, warnings
 	}
 
+	// A scope the character did not grant leaves that part out with a note.
+	hasScope := func(scope string) bool {
+		if sess.HasScope(scope) {
+			return true
+		}
+		addWarning(stationAIRuntimeLocaleText(
+			locale,
+			"runtime context: character has not granted "+scope,
+			"runtime-контекст: персонаж не выдал scope "+scope,
+		))
+		return false
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(3)
 
@@ -7178,6 +8146,9 @@ func (s *Server) stationAIBuildRuntimeContext(ctx context.Context, userID, local
 			addWarning(cancelWarn)
 			return
 		}
+		if !hasScope(auth.ScopeReadCharacterWallet) {
+			return
+		}
 		balance, fetchErr := s.esi.GetWalletBalance(sess.CharacterID, token)
 		if fetchErr != nil {
 			addWarning(stationAIRuntimeLocaleText(
@@ -7199,6 +8170,9 @@ func (s *Server) stationAIBuildRuntimeContext(ctx context.Context, userID, local
 			addWarning(cancelWarn)
 			return
 		}
+		if !hasScope(auth.ScopeReadCharacterOrders) {
+			return
+		}
 		orders, fetchErr := s.esi.GetCharacterOrders(sess.CharacterID, token)
 		if fetchErr != nil {
 			addWarning(stationAIRuntimeLocaleText(
@@ -7234,6 +8208,9 @@ func (s *Server) stationAIBuildRuntimeContext(ctx context.Context, userID, local
 			addWarning(cancelWarn)
 			return
 		}
+		if !hasScope(auth.ScopeReadCharacterWallet) {
+			return
+		}
 		txns, ok := s.getWalletTxnCache(sess.CharacterID)
 		if !ok {
 			freshTxns, fetchErr := s.esi.GetWalletTransactions(sess.CharacterID, token)
@@ -7309,97 +8286,23 @@ func buildStationAIMessages(systemPrompt string, history []stationAIHistoryMessa
 	return messages
 }
 
-func (s *Server) stationAIOpenRouterChatOnce(
+// stationAIChatOnce sends messages to the provider selected by req.Provider.
+func (s *Server) stationAIChatOnce(
 	ctx context.Context,
 	req stationAIChatRequestPayload,
 	messages []map[string]string,
 ) (stationAIProviderReply, error) {
-	payload := map[string]interface{}{
-		"model":       req.Model,
-		"temperature": req.Temperature,
-		"max_tokens":  req.MaxTokens,
-		"messages":    messages,
-	}
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return stationAIProviderReply{}, fmt.Errorf("failed to encode ai request: %w", err)
-	}
-
-	httpReq, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		"https://openrouter.ai/api/v1/chat/completions",
-		bytes.NewReader(body),
-	)
-	if err != nil {
-		return stationAIProviderReply{}, fmt.Errorf("failed to create ai request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+req.APIKey)
-	httpReq.Header.Set("HTTP-Referer", "http://localhost:1420")
-	httpReq.Header.Set("X-Title", "EVE Flipper Station AI")
-
-	client := &http.Client{Timeout: 90 * time.Second}
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return stationAIProviderReply{}, fmt.Errorf("ai provider request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	rawResp, readErr := io.ReadAll(resp.Body)
-	if readErr != nil {
-		return stationAIProviderReply{}, fmt.Errorf("failed to read ai provider response: %w", readErr)
-	}
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		errMsg := "ai provider error"
-		var errBody struct {
-			Error struct {
-				Message string `json:"message"`
-			} `json:"error"`
-		}
-		if json.Unmarshal(rawResp, &errBody) == nil && strings.TrimSpace(errBody.Error.Message) != "" {
-			errMsg = strings.TrimSpace(errBody.Error.Message)
-		}
-		return stationAIProviderReply{}, errors.New(errMsg)
-	}
-
-	var orResp struct {
-		ID      string `json:"id"`
-		Model   string `json:"model"`
-		Choices []struct {
-			Message struct {
-				Content json.RawMessage `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-		Usage map[string]interface{} `json:"usage"`
-	}
-	if err := json.Unmarshal(rawResp, &orResp); err != nil {
-		return stationAIProviderReply{}, fmt.Errorf("invalid ai provider response: %w", err)
-	}
-	if len(orResp.Choices) == 0 {
-		return stationAIProviderReply{}, errors.New("empty ai response")
-	}
-
-	answer := strings.TrimSpace(extractAIContent(orResp.Choices[0].Message.Content))
-	if answer == "" {
-		return stationAIProviderReply{}, errors.New("empty ai answer")
-	}
-	model := strings.TrimSpace(orResp.Model)
-	if model == "" {
-		model = req.Model
+	provider, ok := stationAIProviderFor(req)
+	if !ok {
+		return stationAIProviderReply{}, errors.New("unsupported ai provider")
 	}
-	return stationAIProviderReply{
-		Answer:     answer,
-		Model:      model,
-		ProviderID: strings.TrimSpace(orResp.ID),
-		Usage:      orResp.Usage,
-	}, nil
+	return provider.Chat(ctx, req, messages)
 }
 
 func (s *Server) handleAuthStationAIChat(w http.ResponseWriter, r *http.Request) {
 	var req stationAIChatRequestPayload
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 
@@ -7408,6 +8311,11 @@ func (s *Server) handleAuthStationAIChat(w http.ResponseWriter, r *http.Request)
 		writeError(w, 400, validationErr)
 		return
 	}
+	if status, msg := s.stationAILoadConversation(userIDFromRequest(r), &req); status != 0 {
+		writeError(w, status, msg)
+		return
+	}
+	metrics.AIChatCallsTotal.Inc(req.Provider, "chat")
 
 	plan, plannerEnabled, plannerWarnings := s.stationAIResolvePlan(r.Context(), req)
 	warnings = append(warnings, plannerWarnings...)
@@ -7435,6 +8343,7 @@ func (s *Server) handleAuthStationAIChat(w http.ResponseWriter, r *http.Request)
 	}
 	if plan.AskClarification && strings.TrimSpace(plan.Clarification) != "" {
 		warnings = append(warnings, "planner asked for clarification")
+		s.stationAIPersistTurn(userIDFromRequest(r), req, plan.Clarification)
 		writeJSON(w, map[string]interface{}{
 			"answer":    strings.TrimSpace(plan.Clarification),
 			"provider":  req.Provider,
@@ -7469,6 +8378,7 @@ func (s *Server) handleAuthStationAIChat(w http.ResponseWriter, r *http.Request)
 	if preflight.Status == "fail" {
 		warnings = append(warnings, "preflight failed: missing "+strings.Join(preflight.Missing, ", "))
 		answer := stationAIPreflightFailAnswer(req.Locale, preflight.Missing)
+		s.stationAIPersistTurn(userIDFromRequest(r), req, answer)
 		writeJSON(w, map[string]interface{}{
 			"answer":    answer,
 			"provider":  req.Provider,
@@ -7489,7 +8399,8 @@ func (s *Server) handleAuthStationAIChat(w http.ResponseWriter, r *http.Request)
 	wikiSnippets := make([]aiKnowledgeSnippet, 0, 4)
 	webSnippets := make([]aiKnowledgeSnippet, 0, 4)
 	if useWiki {
-		ws, ww := s.stationAIWikiSnippets(r.Context(), req.Locale, req.UserMessage, req.WikiRepo, intent)
+		wikiPages := s.stationAIWikiPagesForRequest(userIDFromRequest(r), req)
+		ws, ww := s.stationAIWikiSnippets(r.Context(), req.Locale, req.UserMessage, req.WikiRepo, wikiPages, intent)
 		wikiSnippets = ws
 		warnings = append(warnings, ww...)
 	} else if enableWiki {
@@ -7500,16 +8411,20 @@ func (s *Server) handleAuthStationAIChat(w http.ResponseWriter, r *http.Request)
 		}
 	}
 	if useWeb {
-		ws, ww := stationAIWebSnippets(r.Context(), req.Locale, req.UserMessage, intent)
+		ws, ww, cached := stationAIWebSnippets(r.Context(), req.Locale, req.UserMessage, intent)
 		webSnippets = ws
 		warnings = append(warnings, ww...)
+		pipeline["web_cache_hit"] = cached
 	} else if enableWeb {
 		log.Printf("[AI][CHAT] mode=sync web skipped for smalltalk intent")
 	}
+	toolBlock, toolWarnings := s.stationAIApplyTools(r.Context(), userIDFromRequest(r), req, intent, pipeline)
+	warnings = append(warnings, toolWarnings...)
 	knowledgeBlock := buildStationAIKnowledgeBlock(req.Locale, wikiSnippets, webSnippets)
 	agentBlock := buildStationAIAgentBlock(req.Locale, plan, contextForPrompt, wikiSnippets, webSnippets)
 
-	systemPrompt := stationAISystemPrompt(req.Locale, req.AssistantName, plan)
+	numberFormat := s.stationAINumberFormatForRequest(userIDFromRequest(r), req)
+	systemPrompt := stationAISystemPrompt(req.Locale, req.AssistantName, plan, numberFormat)
 	userPrompt := stationAIUserPrompt(req.Locale, req.UserMessage, contextJSON, plan)
 	if caveatBlock := stationAIPreflightCaveatBlock(req.Locale, preflight); caveatBlock != "" {
 		userPrompt += "\n\n" + caveatBlock
@@ -7520,15 +8435,19 @@ func (s *Server) handleAuthStationAIChat(w http.ResponseWriter, r *http.Request)
 	if knowledgeBlock != "" {
 		userPrompt += "\n\n" + knowledgeBlock
 	}
+	if toolBlock != "" {
+		userPrompt += "\n\n" + toolBlock
+	}
 	messages := buildStationAIMessages(systemPrompt, req.History, userPrompt)
 
-	reply, err := s.stationAIOpenRouterChatOnce(r.Context(), req, messages)
+	reply, err := s.stationAIChatOnce(r.Context(), req, messages)
 	if err != nil {
 		writeError(w, 502, err.Error())
 		return
 	}
+	s.recordStationAIUsage(userIDFromRequest(r), req, "chat", reply.Model, reply.Usage)
 
-	if valid, issue := stationAIValidateAnswer(reply.Answer, intent); !valid {
+	if valid, issue := stationAIValidateAnswer(reply.Answer, intent, numberFormat); !valid {
 		warnings = append(warnings, "server validation requested retry: "+issue)
 		retryMessages := make([]map[string]string, 0, len(messages)+2)
 		retryMessages = append(retryMessages, messages...)
@@ -7536,16 +8455,20 @@ func (s *Server) handleAuthStationAIChat(w http.ResponseWriter, r *http.Request)
 			map[string]string{"role": "assistant", "content": reply.Answer},
 			map[string]string{"role": "user", "content": stationAIRetryCorrectionPrompt(req.Locale, issue)},
 		)
-		retryReply, retryErr := s.stationAIOpenRouterChatOnce(r.Context(), req, retryMessages)
+		retryReply, retryErr := s.stationAIChatOnce(r.Context(), req, retryMessages)
+		if retryErr == nil {
+			s.recordStationAIUsage(userIDFromRequest(r), req, "chat", retryReply.Model, retryReply.Usage)
+		}
 		if retryErr != nil {
 			warnings = append(warnings, "retry failed: "+retryErr.Error())
-		} else if validRetry, retryIssue := stationAIValidateAnswer(retryReply.Answer, intent); validRetry {
+		} else if validRetry, retryIssue := stationAIValidateAnswer(retryReply.Answer, intent, numberFormat); validRetry {
 			reply = retryReply
 		} else {
 			warnings = append(warnings, "retry rejected: "+retryIssue)
 		}
 	}
 
+	s.stationAIPersistTurn(userIDFromRequest(r), req, reply.Answer)
 	writeJSON(w, map[string]interface{}{
 		"answer":         reply.Answer,
 		"provider":       req.Provider,
@@ -7772,7 +8695,20 @@ func (s *Server) fetchAIWikiMarkdown(ctx context.Context, repo, page string) (st
 	return content, urlStr, nil
 }
 
-func (s *Server) stationAIWikiSnippets(ctx context.Context, locale, userMessage, repo string, intent stationAIIntentKind) ([]aiKnowledgeSnippet, []string) {
+// stationAIWikiPagesForRequest picks the pages searched by the fallback wiki
+// retrieval: the request's wiki_pages, then the user's ai_wiki_pages, then
+// the project wiki's own pages.
+func (s *Server) stationAIWikiPagesForRequest(userID string, req stationAIChatRequestPayload) []string {
+	if len(req.WikiPages) > 0 {
+		return req.WikiPages
+	}
+	if cfg := s.loadConfigForUser(userID); cfg != nil && len(cfg.AIWikiPages) > 0 {
+		return cfg.AIWikiPages
+	}
+	return config.DefaultAIWikiPages()
+}
+
+func (s *Server) stationAIWikiSnippets(ctx context.Context, locale, userMessage, repo string, pages []string, intent stationAIIntentKind) ([]aiKnowledgeSnippet, []string) {
 	repo = sanitizeWikiRepo(repo)
 	warnings := make([]string, 0, 3)
 	if s.wikiRAG != nil {
@@ -7801,19 +8737,8 @@ func (s *Server) stationAIWikiSnippets(ctx context.Context, locale, userMessage,
 	}
 
 	terms := aiKeywordTerms(userMessage)
-	pages := []string{
-		"Home",
-		"Station-Trading",
-		"Execution-Plan",
-		"Radius-Scan",
-		"Region-Arbitrage",
-		"Route-Trading",
-		"Contract-Scanner",
-		"Industry-Chain-Optimizer",
-		"Getting-Started",
-		"API-Reference",
-		"PLEX-Dashboard",
-		"War-Tracker",
+	if len(pages) == 0 {
+		pages = config.DefaultAIWikiPages()
 	}
 	type wikiPageDoc struct {
 		Title string
@@ -7909,10 +8834,34 @@ func (s *Server) stationAIWikiSnippets(ctx context.Context, locale, userMessage,
 	return candidates, warnings
 }
 
-func stationAIWebSnippets(ctx context.Context, locale, userMessage string, intent stationAIIntentKind) ([]aiKnowledgeSnippet, []string) {
+// stationAIWebCacheKey identifies a research request by locale, intent and
+// its keyword terms in sorted order, so rephrasings of the same question
+// within a conversation share one search.
+func stationAIWebCacheKey(locale, userMessage string, intent stationAIIntentKind) string {
+	terms := aiKeywordTerms(userMessage)
+	if len(terms) == 0 {
+		terms = strings.Fields(strings.ToLower(userMessage))
+	}
+	sorted := append([]string(nil), terms...)
+	sort.Strings(sorted)
+	return locale + "|" + string(intent) + "|" + strings.Join(sorted, " ")
+}
+
+// stationAIWebSnippets runs web research for a chat turn. Complete results
+// are cached for stationAIWebCacheTTL; the third return value reports
+// whether the snippets came from that cache.
+func stationAIWebSnippets(ctx context.Context, locale, userMessage string, intent stationAIIntentKind) ([]aiKnowledgeSnippet, []string, bool) {
 	userMessage = strings.TrimSpace(userMessage)
 	if userMessage == "" {
-		return nil, nil
+		return nil, nil, false
+	}
+	cacheKey := stationAIWebCacheKey(locale, userMessage, intent)
+	if raw, ok := aiWebSnippetCache.Load(cacheKey); ok {
+		if entry, ok := raw.(stationAIWebCacheEntry); ok && time.Since(entry.FetchedAt) <= stationAIWebCacheTTL {
+			log.Printf("[AI][WEB] cache hit snippets=%d", len(entry.Snippets))
+			return append([]aiKnowledgeSnippet(nil), entry.Snippets...), nil, true
+		}
+		aiWebSnippetCache.Delete(cacheKey)
 	}
 	unavailableWarn := "web research unavailable"
 	noDataWarn := "web research returned no snippets"
@@ -7925,7 +8874,7 @@ func stationAIWebSnippets(ctx context.Context, locale, userMessage string, inten
 
 	queries := stationAIWebQueryVariants(locale, userMessage, intent)
 	if len(queries) == 0 {
-		return nil, []string{noDataWarn}
+		return nil, []string{noDataWarn}, false
 	}
 
 	client := &http.Client{Timeout: 10 * time.Second}
@@ -7961,14 +8910,30 @@ func stationAIWebSnippets(ctx context.Context, locale, userMessage string, inten
 
 	if len(out) == 0 {
 		if hadErrors {
-			return nil, []string{unavailableWarn}
+			return nil, []string{unavailableWarn}, false
 		}
-		return nil, []string{noDataWarn}
+		return nil, []string{noDataWarn}, false
 	}
 	if hadErrors {
-		return out, []string{partialWarn}
+		// Partial results are not cached so the next turn retries the failed queries.
+		return out, []string{partialWarn}, false
 	}
-	return out, nil
+	storeStationAIWebCache(cacheKey, out)
+	return out, nil, false
+}
+
+func storeStationAIWebCache(key string, snippets []aiKnowledgeSnippet) {
+	now := time.Now()
+	aiWebSnippetCache.Range(func(k, v interface{}) bool {
+		if entry, ok := v.(stationAIWebCacheEntry); !ok || now.Sub(entry.FetchedAt) > stationAIWebCacheTTL {
+			aiWebSnippetCache.Delete(k)
+		}
+		return true
+	})
+	aiWebSnippetCache.Store(key, stationAIWebCacheEntry{
+		Snippets:  append([]aiKnowledgeSnippet(nil), snippets...),
+		FetchedAt: now,
+	})
 }
 
 func stationAIWebQueryVariants(locale, userMessage string, intent stationAIIntentKind) []string {
@@ -8281,16 +9246,13 @@ func (s *Server) handleAuthStationAIChatStream(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	stream := &stationAIStreamWriter{w: w, flusher: flusher}
 	writeMsg := func(msg map[string]interface{}) bool {
 		line, err := json.Marshal(msg)
 		if err != nil {
 			return false
 		}
-		if _, err := fmt.Fprintf(w, "%s\n", line); err != nil {
-			return false
-		}
-		flusher.Flush()
-		return true
+		return stream.writeLine(line) == nil
 	}
 	writeErr := func(message string) {
 		_ = writeMsg(map[string]interface{}{
@@ -8298,6 +9260,10 @@ func (s *Server) handleAuthStationAIChatStream(w http.ResponseWriter, r *http.Re
 			"message": message,
 		})
 	}
+	// Keep proxies from timing out the connection while the planner, tools
+	// and provider are still thinking; the first answer token ends it.
+	stopHeartbeat := stream.startHeartbeat(stationAIStreamHeartbeatInterval)
+	defer stopHeartbeat()
 
 	var req stationAIChatRequestPayload
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -8310,6 +9276,11 @@ func (s *Server) handleAuthStationAIChatStream(w http.ResponseWriter, r *http.Re
 		writeErr(validationErr)
 		return
 	}
+	if status, msg := s.stationAILoadConversation(userIDFromRequest(r), &req); status != 0 {
+		writeErr(msg)
+		return
+	}
+	metrics.AIChatCallsTotal.Inc(req.Provider, "stream")
 	plan, plannerEnabled, plannerWarnings := s.stationAIResolvePlan(r.Context(), req)
 	warnings = append(warnings, plannerWarnings...)
 	intent := plan.Intent
@@ -8337,14 +9308,15 @@ func (s *Server) handleAuthStationAIChatStream(w http.ResponseWriter, r *http.Re
 
 	prepareMsg := "Preparing context..."
 	plannerMsg := "Planner pass complete"
-	sendMsg := "Sending request to OpenRouter..."
+	providerName := stationAIProviderName(req.Provider)
+	sendMsg := "Sending request to " + providerName + "..."
 	streamMsg := "Streaming model output..."
 	retryMsg := "Final answer failed validation, retrying..."
 	doneMsg := "Done"
 	if req.Locale == "ru" {
 		prepareMsg = "Подготавливаю контекст..."
 		plannerMsg = "Планировщик определил режим ответа"
-		sendMsg = "Отправляю запрос в OpenRouter..."
+		sendMsg = "Отправляю запрос в " + providerName + "..."
 		streamMsg = "Получаю ответ модели..."
 		retryMsg = "Финальный ответ не прошел валидацию, выполняю retry..."
 		doneMsg = "Готово"
@@ -8368,6 +9340,7 @@ func (s *Server) handleAuthStationAIChatStream(w http.ResponseWriter, r *http.Re
 	}
 	if plan.AskClarification && strings.TrimSpace(plan.Clarification) != "" {
 		warnings = append(warnings, "planner asked for clarification")
+		s.stationAIPersistTurn(userIDFromRequest(r), req, plan.Clarification)
 		_ = writeMsg(map[string]interface{}{
 			"type":          "result",
 			"answer":        strings.TrimSpace(plan.Clarification),
@@ -8405,9 +9378,11 @@ func (s *Server) handleAuthStationAIChatStream(w http.ResponseWriter, r *http.Re
 	}
 	if preflight.Status == "fail" {
 		warnings = append(warnings, "preflight failed: missing "+strings.Join(preflight.Missing, ", "))
+		failAnswer := stationAIPreflightFailAnswer(req.Locale, preflight.Missing)
+		s.stationAIPersistTurn(userIDFromRequest(r), req, failAnswer)
 		_ = writeMsg(map[string]interface{}{
 			"type":          "result",
-			"answer":        stationAIPreflightFailAnswer(req.Locale, preflight.Missing),
+			"answer":        failAnswer,
 			"provider":      req.Provider,
 			"model":         req.Model,
 			"assistant":     req.AssistantName,
@@ -8428,7 +9403,8 @@ func (s *Server) handleAuthStationAIChatStream(w http.ResponseWriter, r *http.Re
 	wikiSnippets := make([]aiKnowledgeSnippet, 0, 4)
 	webSnippets := make([]aiKnowledgeSnippet, 0, 4)
 	if useWiki {
-		ws, ww := s.stationAIWikiSnippets(r.Context(), req.Locale, req.UserMessage, req.WikiRepo, intent)
+		wikiPages := s.stationAIWikiPagesForRequest(userIDFromRequest(r), req)
+		ws, ww := s.stationAIWikiSnippets(r.Context(), req.Locale, req.UserMessage, req.WikiRepo, wikiPages, intent)
 		wikiSnippets = ws
 		warnings = append(warnings, ww...)
 	} else if enableWiki {
@@ -8439,16 +9415,20 @@ func (s *Server) handleAuthStationAIChatStream(w http.ResponseWriter, r *http.Re
 		}
 	}
 	if useWeb {
-		ws, ww := stationAIWebSnippets(r.Context(), req.Locale, req.UserMessage, intent)
+		ws, ww, cached := stationAIWebSnippets(r.Context(), req.Locale, req.UserMessage, intent)
 		webSnippets = ws
 		warnings = append(warnings, ww...)
+		pipeline["web_cache_hit"] = cached
 	} else if enableWeb {
 		log.Printf("[AI][CHAT] mode=stream web skipped for smalltalk intent")
 	}
+	toolBlock, toolWarnings := s.stationAIApplyTools(r.Context(), userIDFromRequest(r), req, intent, pipeline)
+	warnings = append(warnings, toolWarnings...)
 	knowledgeBlock := buildStationAIKnowledgeBlock(req.Locale, wikiSnippets, webSnippets)
 	agentBlock := buildStationAIAgentBlock(req.Locale, plan, contextForPrompt, wikiSnippets, webSnippets)
 
-	systemPrompt := stationAISystemPrompt(req.Locale, req.AssistantName, plan)
+	numberFormat := s.stationAINumberFormatForRequest(userIDFromRequest(r), req)
+	systemPrompt := stationAISystemPrompt(req.Locale, req.AssistantName, plan, numberFormat)
 	userPrompt := stationAIUserPrompt(req.Locale, req.UserMessage, contextJSON, plan)
 	if caveatBlock := stationAIPreflightCaveatBlock(req.Locale, preflight); caveatBlock != "" {
 		userPrompt += "\n\n" + caveatBlock
@@ -8459,6 +9439,9 @@ func (s *Server) handleAuthStationAIChatStream(w http.ResponseWriter, r *http.Re
 	if knowledgeBlock != "" {
 		userPrompt += "\n\n" + knowledgeBlock
 	}
+	if toolBlock != "" {
+		userPrompt += "\n\n" + toolBlock
+	}
 	messages := buildStationAIMessages(systemPrompt, req.History, userPrompt)
 	promptTokensEst := estimateTokensFromText(systemPrompt) + estimateTokensFromText(userPrompt)
 	for _, msg := range req.History {
@@ -8475,71 +9458,6 @@ func (s *Server) handleAuthStationAIChatStream(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	payload := map[string]interface{}{
-		"model":       req.Model,
-		"temperature": req.Temperature,
-		"max_tokens":  req.MaxTokens,
-		"stream":      true,
-		"stream_options": map[string]bool{
-			"include_usage": true,
-		},
-		"messages": messages,
-	}
-	body, err := json.Marshal(payload)
-	if err != nil {
-		writeErr("failed to encode ai request")
-		return
-	}
-
-	httpReq, err := http.NewRequestWithContext(
-		r.Context(),
-		http.MethodPost,
-		"https://openrouter.ai/api/v1/chat/completions",
-		bytes.NewReader(body),
-	)
-	if err != nil {
-		writeErr("failed to create ai request")
-		return
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+req.APIKey)
-	httpReq.Header.Set("HTTP-Referer", "http://localhost:1420")
-	httpReq.Header.Set("X-Title", "EVE Flipper Station AI")
-
-	client := &http.Client{Timeout: stationAIStreamHTTPTimeout}
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		writeErr("ai provider request failed: " + err.Error())
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		rawResp, _ := io.ReadAll(resp.Body)
-		errMsg := "ai provider error"
-		var errBody struct {
-			Error struct {
-				Message string `json:"message"`
-			} `json:"error"`
-		}
-		if json.Unmarshal(rawResp, &errBody) == nil && strings.TrimSpace(errBody.Error.Message) != "" {
-			errMsg = strings.TrimSpace(errBody.Error.Message)
-		}
-		writeErr(errMsg)
-		return
-	}
-
-	if !writeMsg(map[string]interface{}{
-		"type":         "progress",
-		"message":      streamMsg,
-		"progress_pct": 35,
-	}) {
-		return
-	}
-
-	scanner := bufio.NewScanner(resp.Body)
-	scanner.Buffer(make([]byte, 0, 128*1024), 8*1024*1024)
-	var eventData strings.Builder
 	var answerBuilder strings.Builder
 	answerRuneCount := 0
 	providerModel := req.Model
@@ -8547,140 +9465,227 @@ func (s *Server) handleAuthStationAIChatStream(w http.ResponseWriter, r *http.Re
 	usagePrompt := 0
 	usageCompletion := 0
 	usageTotal := 0
+	usageCost := 0.0
 
-	processEvent := func(raw string) (done bool, fatal bool) {
-		raw = strings.TrimSpace(raw)
-		if raw == "" {
-			return false, false
+	provider, _ := stationAIProviderFor(req)
+	streamer, canStream := provider.(*stationAIOpenAIProvider)
+	if !canStream {
+		// Providers without an OpenAI-style SSE stream answer in one piece.
+		reply, err := provider.Chat(r.Context(), req, messages)
+		if err != nil {
+			writeErr(err.Error())
+			return
 		}
-		if raw == "[DONE]" {
-			return true, false
-		}
-
-		var chunk struct {
-			ID      string `json:"id"`
-			Model   string `json:"model"`
-			Choices []struct {
-				Delta struct {
-					Content json.RawMessage `json:"content"`
-				} `json:"delta"`
-				Message struct {
-					Content json.RawMessage `json:"content"`
-				} `json:"message"`
-			} `json:"choices"`
-			Usage *struct {
-				PromptTokens     int `json:"prompt_tokens"`
-				CompletionTokens int `json:"completion_tokens"`
-				TotalTokens      int `json:"total_tokens"`
-			} `json:"usage"`
-		}
-		if err := json.Unmarshal([]byte(raw), &chunk); err != nil {
-			// Ignore malformed partial event and keep stream alive.
-			return false, false
+		answerBuilder.WriteString(reply.Answer)
+		providerModel = reply.Model
+		providerID = reply.ProviderID
+		usagePrompt, usageCompletion, usageTotal = stationAIUsageTokenInts(reply.Usage)
+		usageCost = stationAIUsageCost(reply.Usage)
+		stopHeartbeat()
+		if !writeMsg(map[string]interface{}{
+			"type":                  "delta",
+			"delta":                 reply.Answer,
+			"progress_pct":          90,
+			"completion_tokens_est": usageCompletion,
+			"total_tokens_est":      usageTotal,
+		}) {
+			return
+		}
+		if usageTotal > 0 && !writeMsg(map[string]interface{}{
+			"type":              "usage",
+			"prompt_tokens":     usagePrompt,
+			"completion_tokens": usageCompletion,
+			"total_tokens":      usageTotal,
+			"progress_pct":      96,
+		}) {
+			return
+		}
+	} else {
+		payload := map[string]interface{}{
+			"model":       req.Model,
+			"temperature": req.Temperature,
+			"max_tokens":  req.MaxTokens,
+			"stream":      true,
+			"stream_options": map[string]bool{
+				"include_usage": true,
+			},
+			"messages": messages,
+		}
+		httpReq, err := streamer.newRequest(r.Context(), req.APIKey, payload)
+		if err != nil {
+			writeErr(err.Error())
+			return
+		}
+
+		client := &http.Client{Timeout: stationAIStreamHTTPTimeout}
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			writeErr("ai provider request failed: " + err.Error())
+			return
 		}
+		defer resp.Body.Close()
 
-		if strings.TrimSpace(chunk.ID) != "" {
-			providerID = strings.TrimSpace(chunk.ID)
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			rawResp, _ := io.ReadAll(resp.Body)
+			writeErr(stationAIProviderErrorMessage(rawResp))
+			return
 		}
-		if strings.TrimSpace(chunk.Model) != "" {
-			providerModel = strings.TrimSpace(chunk.Model)
+
+		if !writeMsg(map[string]interface{}{
+			"type":         "progress",
+			"message":      streamMsg,
+			"progress_pct": 35,
+		}) {
+			return
 		}
 
-		for _, choice := range chunk.Choices {
-			delta := extractAIDelta(choice.Delta.Content)
-			if delta == "" {
-				delta = extractAIDelta(choice.Message.Content)
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 128*1024), 8*1024*1024)
+		var eventData strings.Builder
+
+		processEvent := func(raw string) (done bool, fatal bool) {
+			raw = strings.TrimSpace(raw)
+			if raw == "" {
+				return false, false
 			}
-			if delta == "" {
-				continue
+			if raw == "[DONE]" {
+				return true, false
 			}
-			answerBuilder.WriteString(delta)
-			answerRuneCount += utf8.RuneCountInString(delta)
 
-			completionTokensEst := estimateTokensFromRuneCount(answerRuneCount)
-			totalTokensEst := promptTokensEst + completionTokensEst
-			denom := req.MaxTokens
-			if denom < 500 {
-				denom = 500
+			var chunk struct {
+				ID      string `json:"id"`
+				Model   string `json:"model"`
+				Choices []struct {
+					Delta struct {
+						Content json.RawMessage `json:"content"`
+					} `json:"delta"`
+					Message struct {
+						Content json.RawMessage `json:"content"`
+					} `json:"message"`
+				} `json:"choices"`
+				Usage *struct {
+					PromptTokens     int     `json:"prompt_tokens"`
+					CompletionTokens int     `json:"completion_tokens"`
+					TotalTokens      int     `json:"total_tokens"`
+					Cost             float64 `json:"cost"`
+				} `json:"usage"`
 			}
-			if denom > 6000 {
-				denom = 6000
+			if err := json.Unmarshal([]byte(raw), &chunk); err != nil {
+				// Ignore malformed partial event and keep stream alive.
+				return false, false
 			}
-			progressPct := 45 + int(float64(completionTokensEst)*45.0/float64(denom))
-			if progressPct > 90 {
-				progressPct = 90
+
+			if strings.TrimSpace(chunk.ID) != "" {
+				providerID = strings.TrimSpace(chunk.ID)
 			}
+			if strings.TrimSpace(chunk.Model) != "" {
+				providerModel = strings.TrimSpace(chunk.Model)
+			}
+
+			for _, choice := range chunk.Choices {
+				delta := extractAIDelta(choice.Delta.Content)
+				if delta == "" {
+					delta = extractAIDelta(choice.Message.Content)
+				}
+				if delta == "" {
+					continue
+				}
+				stopHeartbeat()
+				answerBuilder.WriteString(delta)
+				answerRuneCount += utf8.RuneCountInString(delta)
+
+				completionTokensEst := estimateTokensFromRuneCount(answerRuneCount)
+				totalTokensEst := promptTokensEst + completionTokensEst
+				denom := req.MaxTokens
+				if denom < 500 {
+					denom = 500
+				}
+				if denom > 6000 {
+					denom = 6000
+				}
+				progressPct := 45 + int(float64(completionTokensEst)*45.0/float64(denom))
+				if progressPct > 90 {
+					progressPct = 90
+				}
 
-			if !writeMsg(map[string]interface{}{
-				"type":                  "delta",
-				"delta":                 delta,
-				"progress_pct":          progressPct,
-				"completion_tokens_est": completionTokensEst,
-				"total_tokens_est":      totalTokensEst,
-			}) {
-				return false, true
+				if !writeMsg(map[string]interface{}{
+					"type":                  "delta",
+					"delta":                 delta,
+					"progress_pct":          progressPct,
+					"completion_tokens_est": completionTokensEst,
+					"total_tokens_est":      totalTokensEst,
+				}) {
+					return false, true
+				}
 			}
-		}
 
-		if chunk.Usage != nil && chunk.Usage.TotalTokens > 0 {
-			usagePrompt = chunk.Usage.PromptTokens
-			usageCompletion = chunk.Usage.CompletionTokens
-			usageTotal = chunk.Usage.TotalTokens
-			if !writeMsg(map[string]interface{}{
-				"type":              "usage",
-				"prompt_tokens":     usagePrompt,
-				"completion_tokens": usageCompletion,
-				"total_tokens":      usageTotal,
-				"progress_pct":      96,
-			}) {
-				return false, true
+			if chunk.Usage != nil && chunk.Usage.TotalTokens > 0 {
+				usagePrompt = chunk.Usage.PromptTokens
+				usageCompletion = chunk.Usage.CompletionTokens
+				usageTotal = chunk.Usage.TotalTokens
+				usageCost = chunk.Usage.Cost
+				if !writeMsg(map[string]interface{}{
+					"type":              "usage",
+					"prompt_tokens":     usagePrompt,
+					"completion_tokens": usageCompletion,
+					"total_tokens":      usageTotal,
+					"progress_pct":      96,
+				}) {
+					return false, true
+				}
 			}
-		}
 
-		return false, false
-	}
+			return false, false
+		}
 
-	stop := false
-	for scanner.Scan() {
-		line := strings.TrimRight(scanner.Text(), "\r")
-		if line == "" {
-			done, fatal := processEvent(eventData.String())
-			eventData.Reset()
-			if fatal {
-				return
+		stop := false
+		for scanner.Scan() {
+			line := strings.TrimRight(scanner.Text(), "\r")
+			if line == "" {
+				done, fatal := processEvent(eventData.String())
+				eventData.Reset()
+				if fatal {
+					return
+				}
+				if done {
+					stop = true
+					break
+				}
+				continue
 			}
-			if done {
-				stop = true
-				break
+			if strings.HasPrefix(line, "data:") {
+				data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+				if eventData.Len() > 0 {
+					eventData.WriteByte('\n')
+				}
+				eventData.WriteString(data)
 			}
-			continue
 		}
-		if strings.HasPrefix(line, "data:") {
-			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
-			if eventData.Len() > 0 {
-				eventData.WriteByte('\n')
+		if !stop && eventData.Len() > 0 {
+			_, fatal := processEvent(eventData.String())
+			if fatal {
+				return
 			}
-			eventData.WriteString(data)
 		}
-	}
-	if !stop && eventData.Len() > 0 {
-		_, fatal := processEvent(eventData.String())
-		if fatal {
+		if err := scanner.Err(); err != nil {
+			log.Printf("[AI][CHAT] mode=stream read_error=%v", err)
+			writeErr("failed to read ai stream: " + err.Error())
 			return
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		log.Printf("[AI][CHAT] mode=stream read_error=%v", err)
-		writeErr("failed to read ai stream: " + err.Error())
-		return
-	}
+	s.recordStationAIUsage(userIDFromRequest(r), req, "chat", providerModel, map[string]interface{}{
+		"prompt_tokens":     usagePrompt,
+		"completion_tokens": usageCompletion,
+		"total_tokens":      usageTotal,
+		"cost":              usageCost,
+	})
 
 	answer := strings.TrimSpace(answerBuilder.String())
 	if answer == "" {
 		writeErr("empty ai answer")
 		return
 	}
-	if valid, issue := stationAIValidateAnswer(answer, intent); !valid {
+	if valid, issue := stationAIValidateAnswer(answer, intent, numberFormat); !valid {
 		warnings = append(warnings, "server validation requested retry: "+issue)
 		_ = writeMsg(map[string]interface{}{
 			"type":         "progress",
@@ -8693,10 +9698,16 @@ func (s *Server) handleAuthStationAIChatStream(w http.ResponseWriter, r *http.Re
 			map[string]string{"role": "assistant", "content": answer},
 			map[string]string{"role": "user", "content": stationAIRetryCorrectionPrompt(req.Locale, issue)},
 		)
-		retryReply, retryErr := s.stationAIOpenRouterChatOnce(r.Context(), req, retryMessages)
+		// The retry is a silent non-streaming call; keep the connection alive.
+		stopRetryHeartbeat := stream.startHeartbeat(stationAIStreamHeartbeatInterval)
+		retryReply, retryErr := s.stationAIChatOnce(r.Context(), req, retryMessages)
+		stopRetryHeartbeat()
+		if retryErr == nil {
+			s.recordStationAIUsage(userIDFromRequest(r), req, "chat", retryReply.Model, retryReply.Usage)
+		}
 		if retryErr != nil {
 			warnings = append(warnings, "retry failed: "+retryErr.Error())
-		} else if validRetry, retryIssue := stationAIValidateAnswer(retryReply.Answer, intent); validRetry {
+		} else if validRetry, retryIssue := stationAIValidateAnswer(retryReply.Answer, intent, numberFormat); validRetry {
 			answer = retryReply.Answer
 			if strings.TrimSpace(retryReply.Model) != "" {
 				providerModel = retryReply.Model
@@ -8735,6 +9746,7 @@ func (s *Server) handleAuthStationAIChatStream(w http.ResponseWriter, r *http.Re
 		}
 	}
 
+	s.stationAIPersistTurn(userIDFromRequest(r), req, answer)
 	_ = writeMsg(result)
 	log.Printf(
 		"[AI][CHAT] mode=stream done intent=%s wiki_snippets=%d web_snippets=%d warnings=%d provider_model=%s",
@@ -8829,8 +9841,11 @@ func stationAIRequestsFullScanSettings(userMessage string) bool {
 	return containsAnyLower(msg, fullTerms) && containsAnyLower(msg, settingsTerms)
 }
 
-func stationAISystemPrompt(locale, assistantName string, plan stationAIPlannerPlan) string {
+func stationAISystemPrompt(locale, assistantName string, plan stationAIPlannerPlan, format stationAINumberFormat) string {
 	policy := stationAIIntentPolicy(locale, plan)
+	if instruction := format.PromptInstruction(locale); instruction != "" {
+		policy += " " + instruction
+	}
 	agents := "none"
 	if len(plan.Agents) > 0 {
 		agents = strings.Join(plan.Agents, ", ")
@@ -9026,6 +10041,9 @@ func (s *Server) handleAuthPortfolio(w http.ResponseWriter, r *http.Request) {
 	}
 
 	fetchTxns := func(sess *auth.Session) ([]esi.WalletTransaction, error) {
+		if err := requireScope(sess, auth.ScopeReadCharacterWallet); err != nil {
+			return nil, err
+		}
 		if cached, ok := s.getWalletTxnCache(sess.CharacterID); ok {
 			return cached, nil
 		}
@@ -9059,7 +10077,9 @@ func (s *Server) handleAuthPortfolio(w http.ResponseWriter, r *http.Request) {
 		if fetchErr != nil {
 			log.Printf("[AUTH] Portfolio txns error (%s): %v", sess.CharacterName, fetchErr)
 			if !allScope {
-				writeError(w, 500, "failed to fetch transactions: "+fetchErr.Error())
+				if !writeScopeError(w, fetchErr) {
+					writeError(w, 500, "failed to fetch transactions: "+fetchErr.Error())
+				}
 				return
 			}
 			continue
@@ -9112,6 +10132,9 @@ func (s *Server) handleAuthPortfolioOptimize(w http.ResponseWriter, r *http.Requ
 	}
 
 	fetchTxns := func(sess *auth.Session) ([]esi.WalletTransaction, error) {
+		if err := requireScope(sess, auth.ScopeReadCharacterWallet); err != nil {
+			return nil, err
+		}
 		if cached, ok := s.getWalletTxnCache(sess.CharacterID); ok {
 			return cached, nil
 		}
@@ -9145,7 +10168,9 @@ func (s *Server) handleAuthPortfolioOptimize(w http.ResponseWriter, r *http.Requ
 		if fetchErr != nil {
 			log.Printf("[AUTH] Optimizer txns error (%s): %v", sess.CharacterName, fetchErr)
 			if !allScope {
-				writeError(w, 500, "failed to fetch transactions: "+fetchErr.Error())
+				if !writeScopeError(w, fetchErr) {
+					writeError(w, 500, "failed to fetch transactions: "+fetchErr.Error())
+				}
 				return
 			}
 			continue
@@ -9215,6 +10240,7 @@ func (s *Server) handleIndustryAnalyze(w http.ResponseWriter, r *http.Request) {
 		MaterialEfficiency int32   `json:"me"`
 		TimeEfficiency     int32   `json:"te"`
 		SystemName         string  `json:"system_name"`
+		SystemID           int32   `json:"system_id"`  // Optional: takes precedence over system_name
 		StationID          int64   `json:"station_id"` // Optional: specific station/structure for price lookup
 		FacilityTax        float64 `json:"facility_tax"`
 		StructureBonus     float64 `json:"structure_bonus"`
@@ -9224,22 +10250,27 @@ func (s *Server) handleIndustryAnalyze(w http.ResponseWriter, r *http.Request) {
 		OwnBlueprint       *bool   `json:"own_blueprint"` // nil → true (default)
 		BlueprintCost      float64 `json:"blueprint_cost"`
 		BlueprintIsBPO     bool    `json:"blueprint_is_bpo"`
+		IncludeInvention   bool    `json:"include_invention"`
+		Decryptor          int32   `json:"decryptor"` // Decryptor type ID, 0 = none
+		// Refinery settings for reaction nodes; tax nil → facility_tax.
+		ReactionStructureBonus float64  `json:"reaction_structure_bonus"`
+		ReactionFacilityTax    *float64 `json:"reaction_facility_tax"`
 	}
 
 	r.Body = http.MaxBytesReader(w, r.Body, industryAnalyzeMaxBodyBytes)
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
 	if err := decoder.Decode(&req); err != nil {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 	if err := decoder.Decode(&struct{}{}); err != io.EOF {
-		writeError(w, 400, "invalid json")
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
 		return
 	}
 
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
 		return
 	}
 
@@ -9255,6 +10286,11 @@ func (s *Server) handleIndustryAnalyze(w http.ResponseWriter, r *http.Request) {
 	req.StructureBonus = clampFloat64(req.StructureBonus, -100, 100)
 	req.BrokerFee = clampFloat64(req.BrokerFee, 0, 100)
 	req.SalesTaxPercent = clampFloat64(req.SalesTaxPercent, 0, 100)
+	req.ReactionStructureBonus = clampFloat64(req.ReactionStructureBonus, -100, 100)
+	reactionFacilityTax := req.FacilityTax
+	if req.ReactionFacilityTax != nil {
+		reactionFacilityTax = clampFloat64(*req.ReactionFacilityTax, 0, 100)
+	}
 	if req.StationID < 0 {
 		req.StationID = 0
 	}
@@ -9262,10 +10298,25 @@ func (s *Server) handleIndustryAnalyze(w http.ResponseWriter, r *http.Request) {
 		req.BlueprintCost = 0
 	}
 	req.SystemName = strings.TrimSpace(req.SystemName)
+	if req.Decryptor != 0 {
+		if _, ok := engine.Decryptors[req.Decryptor]; !ok {
+			writeError(w, 400, "unknown decryptor")
+			return
+		}
+	}
 
 	// Resolve system ID
 	var systemID int32
-	if req.SystemName != "" {
+	if req.SystemID != 0 {
+		s.mu.RLock()
+		_, ok := s.sdeData.Systems[req.SystemID]
+		s.mu.RUnlock()
+		if !ok {
+			writeErrorCode(w, 400, errCodeSystemNotFound, "unknown system_id")
+			return
+		}
+		systemID = req.SystemID
+	} else if req.SystemName != "" {
 		s.mu.RLock()
 		systemID = s.sdeData.SystemByName[strings.ToLower(req.SystemName)]
 		s.mu.RUnlock()
@@ -9286,6 +10337,11 @@ func (s *Server) handleIndustryAnalyze(w http.ResponseWriter, r *http.Request) {
 		OwnBlueprint:       req.OwnBlueprint == nil || *req.OwnBlueprint,
 		BlueprintCost:      req.BlueprintCost,
 		BlueprintIsBPO:     req.BlueprintIsBPO,
+		IncludeInvention:   req.IncludeInvention,
+		Decryptor:          req.Decryptor,
+
+		ReactionStructureBonus: req.ReactionStructureBonus,
+		ReactionFacilityTax:    reactionFacilityTax,
 	}
 
 	// Use NDJSON streaming for progress
@@ -9301,8 +10357,8 @@ func (s *Server) handleIndustryAnalyze(w http.ResponseWriter, r *http.Request) {
 	analyzer := s.industryAnalyzer
 	s.mu.RUnlock()
 
-	log.Printf("[API] IndustryAnalyze: typeID=%d, runs=%d, ME=%d, TE=%d, system=%s",
-		req.TypeID, req.Runs, req.MaterialEfficiency, req.TimeEfficiency, req.SystemName)
+	log.Printf("[API] IndustryAnalyze: typeID=%d, runs=%d, ME=%d, TE=%d, system=%s (%d)",
+		req.TypeID, req.Runs, req.MaterialEfficiency, req.TimeEfficiency, req.SystemName, systemID)
 
 	startTime := time.Now()
 
@@ -9330,7 +10386,7 @@ func (s *Server) handleIndustryAnalyze(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleIndustrySearch(w http.ResponseWriter, r *http.Request) {
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
 		return
 	}
 
@@ -9364,22 +10420,26 @@ func (s *Server) handleIndustrySearch(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleIndustrySystems(w http.ResponseWriter, r *http.Request) {
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
 		return
 	}
 
-	// Return list of systems with cost indices
-	systems, err := s.esi.FetchIndustrySystems()
+	s.mu.RLock()
+	sdeData := s.sdeData
+	analyzer := s.industryAnalyzer
+	s.mu.RUnlock()
+
+	// Share the analyzer's cache so this list and analyze results agree.
+	cache := esi.NewIndustryCache()
+	if analyzer != nil && analyzer.IndustryCache != nil {
+		cache = analyzer.IndustryCache
+	}
+	indices, err := s.esi.FetchIndustrySystemCostIndices(cache)
 	if err != nil {
 		writeError(w, 500, "failed to fetch industry systems: "+err.Error())
 		return
 	}
 
-	// Enrich with system names
-	s.mu.RLock()
-	sdeData := s.sdeData
-	s.mu.RUnlock()
-
 	type SystemWithName struct {
 		SolarSystemID   int32   `json:"solar_system_id"`
 		SolarSystemName string  `json:"solar_system_name"`
@@ -9389,40 +10449,29 @@ func (s *Server) handleIndustrySystems(w http.ResponseWriter, r *http.Request) {
 		Invention       float64 `json:"invention"`
 	}
 
-	result := make([]SystemWithName, 0, len(systems))
-	for _, sys := range systems {
+	result := make([]SystemWithName, 0, len(indices))
+	for systemID, idx := range indices {
 		name := ""
-		if s, ok := sdeData.Systems[sys.SolarSystemID]; ok {
+		if s, ok := sdeData.Systems[systemID]; ok {
 			name = s.Name
 		}
-
-		swn := SystemWithName{
-			SolarSystemID:   sys.SolarSystemID,
+		result = append(result, SystemWithName{
+			SolarSystemID:   systemID,
 			SolarSystemName: name,
-		}
-
-		for _, ci := range sys.CostIndices {
-			switch ci.Activity {
-			case "manufacturing":
-				swn.Manufacturing = ci.CostIndex
-			case "reaction":
-				swn.Reaction = ci.CostIndex
-			case "copying":
-				swn.Copying = ci.CostIndex
-			case "invention":
-				swn.Invention = ci.CostIndex
-			}
-		}
-
-		result = append(result, swn)
+			Manufacturing:   idx.Manufacturing,
+			Reaction:        idx.Reaction,
+			Copying:         idx.Copying,
+			Invention:       idx.Invention,
+		})
 	}
+	sort.Slice(result, func(i, j int) bool { return result[i].SolarSystemID < result[j].SolarSystemID })
 
 	writeJSON(w, result)
 }
 
 func (s *Server) handleIndustryStatus(w http.ResponseWriter, r *http.Request) {
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
 		return
 	}
 
@@ -9450,7 +10499,7 @@ func (s *Server) handleIndustryStatus(w http.ResponseWriter, r *http.Request) {
 // handleDemandRegions returns cached demand data for all regions.
 func (s *Server) handleDemandRegions(w http.ResponseWriter, r *http.Request) {
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
 		return
 	}
 
@@ -9471,14 +10520,14 @@ func (s *Server) handleDemandRegions(w http.ResponseWriter, r *http.Request) {
 		"regions":           regions,
 		"count":             len(regions),
 		"cache_age_minutes": cacheAge,
-		"stale":             len(regions) == 0 || !s.db.IsDemandCacheFresh(60*time.Minute),
+		"stale":             len(regions) == 0 || !s.db.IsDemandCacheFresh(s.demandCacheTTL()),
 	})
 }
 
 // handleDemandHotZones returns regions with elevated kill activity.
 func (s *Server) handleDemandHotZones(w http.ResponseWriter, r *http.Request) {
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
 		return
 	}
 
@@ -9490,8 +10539,8 @@ func (s *Server) handleDemandHotZones(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Check if cache is fresh (less than 1 hour old)
-	if s.db.IsDemandCacheFresh(60 * time.Minute) {
+	// Check if the DB cache is within the configured demand TTL
+	if s.db.IsDemandCacheFresh(s.demandCacheTTL()) {
 		// Return from cache
 		zones, err := s.db.GetHotZones(limit)
 		if err != nil {
@@ -9547,7 +10596,7 @@ func (s *Server) handleDemandHotZones(w http.ResponseWriter, r *http.Request) {
 // handleDemandRegion returns detailed demand data for a single region.
 func (s *Server) handleDemandRegion(w http.ResponseWriter, r *http.Request) {
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
 		return
 	}
 
@@ -9565,7 +10614,7 @@ func (s *Server) handleDemandRegion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if cached != nil && time.Since(cached.UpdatedAt) < 60*time.Minute {
+	if cached != nil && time.Since(cached.UpdatedAt) < s.demandCacheTTL() {
 		writeJSON(w, map[string]interface{}{
 			"region":     cached,
 			"from_cache": true,
@@ -9590,7 +10639,7 @@ func (s *Server) handleDemandRegion(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if zone == nil {
-		writeError(w, 404, "region not found")
+		writeErrorCode(w, 404, errCodeRegionNotFound, "region not found")
 		return
 	}
 
@@ -9616,7 +10665,7 @@ func (s *Server) handleDemandRegion(w http.ResponseWriter, r *http.Request) {
 // handleDemandOpportunities returns trade opportunities for a specific region.
 func (s *Server) handleDemandOpportunities(w http.ResponseWriter, r *http.Request) {
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
 		return
 	}
 
@@ -9640,27 +10689,7 @@ func (s *Server) handleDemandOpportunities(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Try to load fitting profile from cache (TTL 2 hours)
-	var fittingProfile *zkillboard.RegionDemandProfile
-	if s.db.IsFittingProfileFresh(regionID, 2*time.Hour) {
-		items, err := s.db.GetFittingDemandProfile(regionID)
-		if err == nil && len(items) > 0 {
-			fittingProfile = &zkillboard.RegionDemandProfile{
-				RegionID: regionID,
-				Items:    make(map[int32]*zkillboard.ItemDemandProfile),
-			}
-			for _, item := range items {
-				fittingProfile.Items[item.TypeID] = &zkillboard.ItemDemandProfile{
-					TypeID:         item.TypeID,
-					TypeName:       item.TypeName,
-					Category:       item.Category,
-					TotalDestroyed: item.TotalDestroyed,
-					KillmailCount:  item.KillmailCount,
-					AvgPerKillmail: item.AvgPerKillmail,
-					EstDailyDemand: item.EstDailyDemand,
-				}
-			}
-		}
-	}
+	fittingProfile := s.cachedFittingProfile(regionID)
 
 	// Get opportunities (with fitting profile if available)
 	opportunities, err := analyzer.GetRegionOpportunities(regionID, esiClient, fittingProfile)
@@ -9670,7 +10699,7 @@ func (s *Server) handleDemandOpportunities(w http.ResponseWriter, r *http.Reques
 	}
 
 	if opportunities == nil {
-		writeError(w, 404, "region not found or no data")
+		writeErrorCode(w, 404, errCodeRegionNotFound, "region not found or no data")
 		return
 	}
 
@@ -9762,7 +10791,7 @@ func (s *Server) handleDemandOpportunities(w http.ResponseWriter, r *http.Reques
 // handleDemandFittings returns raw fitting demand data for a region.
 func (s *Server) handleDemandFittings(w http.ResponseWriter, r *http.Request) {
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
 		return
 	}
 
@@ -9790,28 +10819,182 @@ func (s *Server) handleDemandFittings(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleDemandRefresh forces a refresh of demand data for all regions.
-// Uses NDJSON streaming so the frontend can track progress in real time.
-func (s *Server) handleDemandRefresh(w http.ResponseWriter, r *http.Request) {
+// cachedFittingProfile loads a region's fitting profile from the DB cache
+// (TTL 2 hours). Returns nil when missing or stale.
+func (s *Server) cachedFittingProfile(regionID int32) *zkillboard.RegionDemandProfile {
+	if !s.db.IsFittingProfileFresh(regionID, 2*time.Hour) {
+		return nil
+	}
+	items, err := s.db.GetFittingDemandProfile(regionID)
+	if err != nil || len(items) == 0 {
+		return nil
+	}
+	profile := &zkillboard.RegionDemandProfile{
+		RegionID: regionID,
+		Items:    make(map[int32]*zkillboard.ItemDemandProfile),
+	}
+	for _, item := range items {
+		profile.SampledKills = item.SampledKills
+		profile.TotalKills24h = item.TotalKills24h
+		profile.Items[item.TypeID] = &zkillboard.ItemDemandProfile{
+			TypeID:         item.TypeID,
+			TypeName:       item.TypeName,
+			Category:       item.Category,
+			TotalDestroyed: item.TotalDestroyed,
+			KillmailCount:  item.KillmailCount,
+			AvgPerKillmail: item.AvgPerKillmail,
+			EstDailyDemand: item.EstDailyDemand,
+		}
+	}
+	return profile
+}
+
+// saveFittingProfile stores a freshly analyzed fitting profile in the DB cache.
+func (s *Server) saveFittingProfile(regionID int32, profile *zkillboard.RegionDemandProfile) {
+	var dbItems []db.FittingDemandItem
+	for _, item := range profile.Items {
+		dbItems = append(dbItems, db.FittingDemandItem{
+			RegionID:       regionID,
+			TypeID:         item.TypeID,
+			TypeName:       item.TypeName,
+			Category:       item.Category,
+			TotalDestroyed: item.TotalDestroyed,
+			KillmailCount:  item.KillmailCount,
+			AvgPerKillmail: item.AvgPerKillmail,
+			EstDailyDemand: item.EstDailyDemand,
+			SampledKills:   profile.SampledKills,
+			TotalKills24h:  profile.TotalKills24h,
+		})
+	}
+	if err := s.db.SaveFittingDemandProfile(regionID, dbItems); err != nil {
+		log.Printf("[Demand] Failed to save fitting profile for region %d: %v", regionID, err)
+	}
+}
+
+// handleDemandCustomFitting estimates regional demand for the items of a
+// user-supplied EFT fit. The body is the raw EFT text; ?region_id= selects
+// the region. Unknown module names come back as warnings.
+func (s *Server) handleDemandCustomFitting(w http.ResponseWriter, r *http.Request) {
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+
+	regionIDInt, err := strconv.Atoi(strings.TrimSpace(r.URL.Query().Get("region_id")))
+	if err != nil || regionIDInt <= 0 {
+		writeError(w, 400, "invalid region_id")
+		return
+	}
+	regionID := int32(regionIDInt)
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 64<<10))
+	if err != nil {
+		writeError(w, 400, "fit too large")
 		return
 	}
 
 	s.mu.RLock()
 	analyzer := s.demandAnalyzer
+	esiClient := s.esi
+	sdeData := s.sdeData
 	s.mu.RUnlock()
 
-	if analyzer == nil {
-		writeError(w, 503, "demand analyzer not ready")
+	if sdeData == nil {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+	if _, ok := sdeData.Regions[regionID]; !ok {
+		writeErrorCode(w, 400, errCodeRegionNotFound, "unknown region_id")
+		return
+	}
+
+	typeByName := make(map[string]int32, len(sdeData.Types))
+	for id, t := range sdeData.Types {
+		typeByName[strings.ToLower(t.Name)] = id
+	}
+	fit, warnings, err := zkillboard.ParseEFT(string(body), func(name string) (int32, bool) {
+		id, ok := typeByName[strings.ToLower(name)]
+		return id, ok
+	})
+	if err != nil {
+		writeError(w, 400, err.Error())
+		return
+	}
+
+	profile := s.cachedFittingProfile(regionID)
+	fromCache := profile != nil
+	if profile == nil {
+		if analyzer == nil || esiClient == nil {
+			writeError(w, 503, "demand analyzer not ready")
+			return
+		}
+		profile, err = analyzer.AnalyzeRegionFittings(regionID, esiClient, sdeData, 100)
+		if err != nil {
+			writeError(w, 502, fmt.Sprintf("fitting analysis failed: %v", err))
+			return
+		}
+		s.saveFittingProfile(regionID, profile)
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"region_id":       regionID,
+		"fit":             fit,
+		"items":           zkillboard.FitDemand(fit, profile),
+		"sampled_kills":   profile.SampledKills,
+		"total_kills_24h": profile.TotalKills24h,
+		"from_cache":      fromCache,
+		"warnings":        warnings,
+	})
+}
+
+// handleDemandRefresh forces a refresh of demand data, for all regions or
+// just ?region_id=. Refreshes of the same scope are rate limited to protect
+// zKillboard; the result reports the number of invalidated cache entries and
+// when the next refresh is allowed.
+// Uses NDJSON streaming so the frontend can track progress in real time.
+func (s *Server) handleDemandRefresh(w http.ResponseWriter, r *http.Request) {
+	if !s.isReady() {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
 		return
 	}
 
 	s.mu.RLock()
+	analyzer := s.demandAnalyzer
 	esiClient := s.esi
 	sdeData := s.sdeData
 	s.mu.RUnlock()
 
+	if analyzer == nil {
+		writeError(w, 503, "demand analyzer not ready")
+		return
+	}
+
+	var regionID int32
+	if v := strings.TrimSpace(r.URL.Query().Get("region_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || id <= 0 {
+			writeError(w, 400, "invalid region_id")
+			return
+		}
+		regionID = int32(id)
+		if sdeData != nil {
+			if _, ok := sdeData.Regions[regionID]; !ok {
+				writeErrorCode(w, 400, errCodeRegionNotFound, "unknown region_id")
+				return
+			}
+		}
+	}
+
+	nextRefresh, ok := analyzer.BeginRefresh(regionID, time.Now())
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(nextRefresh).Seconds())+1))
+		writeJSONStatus(w, 429, map[string]interface{}{
+			"error":           "demand data was refreshed recently",
+			"next_refresh_at": nextRefresh.UTC().Format(time.RFC3339),
+		})
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/x-ndjson")
 	w.Header().Set("Cache-Control", "no-cache")
 	flusher, ok := w.(http.Flusher)
@@ -9825,86 +11008,113 @@ func (s *Server) handleDemandRefresh(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "%s\n", line)
 		flusher.Flush()
 	}
-
-	sendProgress("Clearing cache...")
-	analyzer.ClearCache()
-	log.Printf("[Demand] Cache cleared, starting refresh...")
-
-	sendProgress("Fetching region kill data from zKillboard...")
-	zones, err := analyzer.GetHotZones(0)
-	if err != nil {
-		log.Printf("[Demand] Refresh failed: %v", err)
+	sendError := func(err error) {
 		line, _ := json.Marshal(map[string]string{"type": "error", "message": err.Error()})
 		fmt.Fprintf(w, "%s\n", line)
 		flusher.Flush()
-		return
 	}
-
-	sendProgress(fmt.Sprintf("Saving %d regions...", len(zones)))
-	for _, z := range zones {
-		if err := s.db.SaveDemandRegion(&db.DemandRegion{
-			RegionID:      z.RegionID,
-			RegionName:    z.RegionName,
-			HotScore:      z.HotScore,
-			Status:        z.Status,
-			KillsToday:    z.KillsToday,
-			KillsBaseline: z.KillsBaseline,
-			ISKDestroyed:  z.ISKDestroyed,
-			ActivePlayers: z.ActivePlayers,
-			TopShips:      z.TopShips,
-		}); err != nil {
-			log.Printf("[Demand] Failed to save region %d: %v", z.RegionID, err)
+	analyzeFittings := func(id int32) {
+		profile, err := analyzer.AnalyzeRegionFittings(id, esiClient, sdeData, 100)
+		if err != nil {
+			log.Printf("[Demand] Fitting analysis failed for region %d: %v", id, err)
+			return
 		}
+		s.saveFittingProfile(id, profile)
 	}
-	log.Printf("[Demand] Region refresh completed: %d regions", len(zones))
 
-	// Analyze fittings for hot regions (elevated+)
-	var hotRegions []zkillboard.RegionHotZone
-	for _, z := range zones {
-		if z.HotScore >= 1.15 {
-			hotRegions = append(hotRegions, z)
+	var invalidated, refreshed int
+	if regionID != 0 {
+		sendProgress("Clearing region cache...")
+		invalidated = analyzer.InvalidateRegion(regionID)
+		log.Printf("[Demand] Region %d cache cleared, starting refresh...", regionID)
+
+		sendProgress("Fetching region kill data from zKillboard...")
+		zone, err := analyzer.GetSingleRegionStats(regionID)
+		if err != nil {
+			log.Printf("[Demand] Refresh of region %d failed: %v", regionID, err)
+			sendError(err)
+			return
 		}
-	}
-	if len(hotRegions) > 0 && esiClient != nil && sdeData != nil {
-		sendProgress(fmt.Sprintf("Analyzing killmail fittings for %d hot regions...", len(hotRegions)))
-		for i, z := range hotRegions {
-			sendProgress(fmt.Sprintf("Analyzing fittings: %s (%d/%d)...", z.RegionName, i+1, len(hotRegions)))
-			profile, err := analyzer.AnalyzeRegionFittings(z.RegionID, esiClient, sdeData, 100)
-			if err != nil {
-				log.Printf("[Demand] Fitting analysis failed for region %d: %v", z.RegionID, err)
-				continue
-			}
-			var dbItems []db.FittingDemandItem
-			for _, item := range profile.Items {
-				dbItems = append(dbItems, db.FittingDemandItem{
-					RegionID:       z.RegionID,
-					TypeID:         item.TypeID,
-					TypeName:       item.TypeName,
-					Category:       item.Category,
-					TotalDestroyed: item.TotalDestroyed,
-					KillmailCount:  item.KillmailCount,
-					AvgPerKillmail: item.AvgPerKillmail,
-					EstDailyDemand: item.EstDailyDemand,
-					SampledKills:   profile.SampledKills,
-					TotalKills24h:  profile.TotalKills24h,
-				})
+		if zone == nil {
+			sendError(fmt.Errorf("no kill data for region %d", regionID))
+			return
+		}
+		s.saveDemandRegion(zone)
+		refreshed = 1
+
+		if esiClient != nil && sdeData != nil {
+			sendProgress(fmt.Sprintf("Analyzing killmail fittings: %s...", zone.RegionName))
+			analyzeFittings(regionID)
+		}
+	} else {
+		sendProgress("Clearing cache...")
+		invalidated = analyzer.ClearCache()
+		log.Printf("[Demand] Cache cleared (%d regions), starting refresh...", invalidated)
+
+		sendProgress("Fetching region kill data from zKillboard...")
+		zones, err := analyzer.GetHotZones(0)
+		if err != nil {
+			log.Printf("[Demand] Refresh failed: %v", err)
+			sendError(err)
+			return
+		}
+
+		sendProgress(fmt.Sprintf("Saving %d regions...", len(zones)))
+		for i := range zones {
+			s.saveDemandRegion(&zones[i])
+		}
+		refreshed = len(zones)
+		log.Printf("[Demand] Region refresh completed: %d regions", len(zones))
+
+		// Analyze fittings for hot regions (elevated+)
+		var hotRegions []zkillboard.RegionHotZone
+		for _, z := range zones {
+			if z.HotScore >= 1.15 {
+				hotRegions = append(hotRegions, z)
 			}
-			if err := s.db.SaveFittingDemandProfile(z.RegionID, dbItems); err != nil {
-				log.Printf("[Demand] Failed to save fitting profile for region %d: %v", z.RegionID, err)
+		}
+		if len(hotRegions) > 0 && esiClient != nil && sdeData != nil {
+			sendProgress(fmt.Sprintf("Analyzing killmail fittings for %d hot regions...", len(hotRegions)))
+			for i, z := range hotRegions {
+				sendProgress(fmt.Sprintf("Analyzing fittings: %s (%d/%d)...", z.RegionName, i+1, len(hotRegions)))
+				analyzeFittings(z.RegionID)
 			}
+			log.Printf("[Demand] Fitting analysis completed for %d regions", len(hotRegions))
 		}
-		log.Printf("[Demand] Fitting analysis completed for %d regions", len(hotRegions))
 	}
 
-	line, _ := json.Marshal(map[string]interface{}{
-		"type":    "result",
-		"status":  "completed",
-		"regions": len(zones),
-	})
+	result := map[string]interface{}{
+		"type":            "result",
+		"status":          "completed",
+		"regions":         refreshed,
+		"invalidated":     invalidated,
+		"next_refresh_at": nextRefresh.UTC().Format(time.RFC3339),
+	}
+	if regionID != 0 {
+		result["region_id"] = regionID
+	}
+	line, _ := json.Marshal(result)
 	fmt.Fprintf(w, "%s\n", line)
 	flusher.Flush()
 }
 
+// saveDemandRegion caches a region's hot-zone stats in the DB.
+func (s *Server) saveDemandRegion(z *zkillboard.RegionHotZone) {
+	if err := s.db.SaveDemandRegion(&db.DemandRegion{
+		RegionID:      z.RegionID,
+		RegionName:    z.RegionName,
+		HotScore:      z.HotScore,
+		Status:        z.Status,
+		KillsToday:    z.KillsToday,
+		KillsBaseline: z.KillsBaseline,
+		ISKDestroyed:  z.ISKDestroyed,
+		ActivePlayers: z.ActivePlayers,
+		TopShips:      z.TopShips,
+	}); err != nil {
+		log.Printf("[Demand] Failed to save region %d: %v", z.RegionID, err)
+	}
+}
+
 // --- PLEX+ ---
 
 func (s *Server) buildPLEXDashboard(salesTax, brokerFee float64, nes engine.NESPrices, omegaUSD float64) (engine.PLEXDashboard, error) {
@@ -9980,12 +11190,24 @@ func (s *Server) buildPLEXDashboard(salesTax, brokerFee float64, nes engine.NESP
 	}
 
 	dashboard := engine.ComputePLEXDashboard(plexOrders, relatedOrders, history, relatedHistory, salesTax, brokerFee, nes, omegaUSD, crossHubOrders)
+
+	// 6) PLEX in each hub region, for the hub matrix
+	hubPLEXOrders := make(map[int32][]esi.MarketOrder, len(engine.PLEXHubRegions))
+	for _, hub := range engine.PLEXHubRegions {
+		orders, err := s.esi.FetchRegionOrdersByType(hub.RegionID, engine.PLEXTypeID)
+		if err != nil {
+			log.Printf("[PLEX] Failed to fetch PLEX orders in %s: %v", hub.Name, err)
+			continue
+		}
+		hubPLEXOrders[hub.RegionID] = orders
+	}
+	dashboard.HubMatrix = engine.ComputePLEXHubMatrix(hubPLEXOrders, salesTax, brokerFee)
 	return dashboard, nil
 }
 
 func (s *Server) handlePLEXDashboard(w http.ResponseWriter, r *http.Request) {
 	if !s.isReady() {
-		writeError(w, 503, "SDE not loaded yet")
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
 		return
 	}
 
@@ -10051,6 +11273,11 @@ func (s *Server) handlePLEXDashboard(w http.ResponseWriter, r *http.Request) {
 		}
 
 		s.setPLEXCache(cacheKey, dashboard)
+		if s.db != nil {
+			if err := s.db.RecordPLEXHistory(dashboard, time.Now()); err != nil {
+				log.Printf("[PLEX] Failed to record history: %v", err)
+			}
+		}
 		return dashboard, nil
 	})
 	if err != nil {
@@ -10068,6 +11295,33 @@ func (s *Server) handlePLEXDashboard(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, dashboard)
 }
 
+// handlePLEXHistory returns the stored hourly PLEX dashboard points for the
+// last ?days= days (default 30, max 365), oldest first.
+func (s *Server) handlePLEXHistory(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	days := 30
+	if raw := strings.TrimSpace(r.URL.Query().Get("days")); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 || v > db.PLEXHistoryRetentionDays {
+			writeError(w, 400, fmt.Sprintf("days must be between 1 and %d", db.PLEXHistoryRetentionDays))
+			return
+		}
+		days = v
+	}
+	points, err := s.db.ListPLEXHistory(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		writeError(w, 500, "failed to load PLEX history")
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"days":   days,
+		"points": points,
+	})
+}
+
 // ============================================================
 // Corporation Handlers
 // ============================================================
@@ -10091,6 +11345,10 @@ func (s *Server) handleAuthRoles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	sess := selectedSessions[0]
+	if err := requireScope(sess, auth.ScopeReadCorporationRoles); err != nil {
+		writeScopeError(w, err)
+		return
+	}
 	token, err := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
 	if err != nil {
 		writeError(w, 401, err.Error())
@@ -10120,12 +11378,7 @@ func (s *Server) handleAuthRoles(w http.ResponseWriter, r *http.Request) {
 
 	if rolesErr == nil && roles != nil {
 		result.Roles = roles.Roles
-		for _, role := range roles.Roles {
-			if role == "Director" || role == "CEO" {
-				result.IsDirector = true
-				break
-			}
-		}
+		result.IsDirector = hasCorpDirectorRole(roles.Roles)
 	}
 	if corpErr != nil {
 		log.Printf("[CORP] Failed to fetch corp ID: %v", corpErr)
@@ -10134,44 +11387,134 @@ func (s *Server) handleAuthRoles(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, result)
 }
 
-// corpProvider returns the appropriate CorpDataProvider based on the ?mode= query param.
-func (s *Server) corpProvider(r *http.Request) (corp.CorpDataProvider, error) {
-	mode := r.URL.Query().Get("mode")
-	if mode == "live" {
-		userID := userIDFromRequest(r)
-		characterID, allScope, err := parseAuthScope(r)
-		if err != nil {
-			return nil, err
-		}
-		selectedSessions, err := s.authSessionsForScope(userID, characterID, allScope, false)
-		if err != nil {
-			return nil, fmt.Errorf("not logged in: %w", err)
-		}
-		sess := selectedSessions[0]
-		token, err := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
-		if err != nil {
-			return nil, fmt.Errorf("not logged in: %w", err)
+// hasCorpDirectorRole reports whether roles cover the corporation ESI
+// endpoints the dashboard reads (wallets, members, industry, mining, orders).
+func hasCorpDirectorRole(roles []string) bool {
+	for _, role := range roles {
+		if role == "Director" || role == "CEO" {
+			return true
 		}
-		corpID, err := s.esi.GetCharacterCorporationID(sess.CharacterID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to resolve corporation: %w", err)
+	}
+	return false
+}
+
+// corpProvider returns the CorpDataProvider for the ?mode= query param:
+//   - "demo": synthetic data
+//   - "live": ESI data for the selected character (error if not logged in)
+//   - "auto" or empty: ESI data when the character holds Director/CEO,
+//     demo data otherwise
+//
+// Responses flag demo data in the body ("is_demo" on the dashboard and wallet
+// summary, "demo" on lists) and in the X-Corp-Demo header.
+func (s *Server) corpProvider(r *http.Request) (corp.CorpDataProvider, error) {
+	switch r.URL.Query().Get("mode") {
+	case "live":
+		return s.liveCorpProvider(r, false)
+	case "demo":
+	default:
+		provider, err := s.liveCorpProvider(r, true)
+		if err == nil {
+			return provider, nil
 		}
-		s.mu.RLock()
-		sdeData := s.sdeData
-		s.mu.RUnlock()
-		return corp.NewESICorpProvider(s.esi, sdeData, token, corpID, sess.CharacterID), nil
+		// Expected for every non-director, so keep it out of the normal log.
+		logger.Debug("CORP", fmt.Sprintf("Auto mode serving demo data: %v", err))
 	}
-	// Default: demo mode
 	if s.demoCorpProvider == nil {
 		return nil, fmt.Errorf("demo data not ready (SDE still loading)")
 	}
 	return s.demoCorpProvider, nil
 }
 
+// liveCorpProvider builds an ESICorpProvider for the selected character.
+// With requireDirector it first checks the character's corp roles.
+func (s *Server) liveCorpProvider(r *http.Request, requireDirector bool) (corp.CorpDataProvider, error) {
+	if s.sessions == nil || s.esi == nil {
+		return nil, fmt.Errorf("not logged in")
+	}
+	userID := userIDFromRequest(r)
+	characterID, allScope, err := parseAuthScope(r)
+	if err != nil {
+		return nil, err
+	}
+	selectedSessions, err := s.authSessionsForScope(userID, characterID, allScope, false)
+	if err != nil {
+		return nil, fmt.Errorf("not logged in: %w", err)
+	}
+	sess := selectedSessions[0]
+	if err := requireScope(sess, auth.ScopeReadCorporationRoles); err != nil {
+		return nil, err
+	}
+	token, err := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
+	if err != nil {
+		return nil, fmt.Errorf("not logged in: %w", err)
+	}
+	if requireDirector {
+		director, err := s.characterHasCorpDirectorRole(sess.CharacterID, token)
+		if err != nil {
+			return nil, err
+		}
+		if !director {
+			return nil, fmt.Errorf("%s lacks the Director role", sess.CharacterName)
+		}
+	}
+	corpID, err := s.esi.GetCharacterCorporationID(sess.CharacterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve corporation: %w", err)
+	}
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	return corp.NewESICorpProvider(s.esi, sdeData, token, corpID, sess.CharacterID), nil
+}
+
+// characterHasCorpDirectorRole checks (and briefly caches) a character's roles.
+func (s *Server) characterHasCorpDirectorRole(characterID int64, token string) (bool, error) {
+	now := time.Now()
+	s.corpRolesMu.Lock()
+	if entry, ok := s.corpRolesCache[characterID]; ok && now.Before(entry.expiresAt) {
+		s.corpRolesMu.Unlock()
+		return entry.director, nil
+	}
+	s.corpRolesMu.Unlock()
+
+	roles, err := s.esi.GetCharacterRoles(characterID, token)
+	if err != nil {
+		return false, err
+	}
+	director := hasCorpDirectorRole(roles.Roles)
+	s.corpRolesMu.Lock()
+	if s.corpRolesCache == nil {
+		s.corpRolesCache = make(map[int64]corpRolesCacheEntry)
+	}
+	s.corpRolesCache[characterID] = corpRolesCacheEntry{director: director, expiresAt: now.Add(corpRolesCacheTTL)}
+	s.corpRolesMu.Unlock()
+	return director, nil
+}
+
+// corpListResponse wraps a corp list endpoint's items with the demo flag.
+type corpListResponse struct {
+	Demo  bool        `json:"demo"`
+	Items interface{} `json:"items"`
+}
+
+// writeCorpJSON writes a corp endpoint response, flagging demo data in the
+// X-Corp-Demo header. Object responses carry is_demo themselves.
+func writeCorpJSON(w http.ResponseWriter, provider corp.CorpDataProvider, v interface{}) {
+	w.Header().Set("X-Corp-Demo", strconv.FormatBool(provider.IsDemo()))
+	writeJSON(w, v)
+}
+
+// writeCorpList writes a corp list response wrapped in corpListResponse.
+func writeCorpList(w http.ResponseWriter, provider corp.CorpDataProvider, items interface{}) {
+	writeCorpJSON(w, provider, corpListResponse{Demo: provider.IsDemo(), Items: items})
+}
+
 func (s *Server) handleCorpDashboard(w http.ResponseWriter, r *http.Request) {
 	provider, err := s.corpProvider(r)
 	if err != nil {
-		writeError(w, 400, err.Error())
+		if !writeScopeError(w, err) {
+			writeError(w, 400, err.Error())
+		}
 		return
 	}
 
@@ -10202,13 +11545,15 @@ func (s *Server) handleCorpDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, dashboard)
+	writeCorpJSON(w, provider, dashboard)
 }
 
 func (s *Server) handleCorpMembers(w http.ResponseWriter, r *http.Request) {
 	provider, err := s.corpProvider(r)
 	if err != nil {
-		writeError(w, 400, err.Error())
+		if !writeScopeError(w, err) {
+			writeError(w, 400, err.Error())
+		}
 		return
 	}
 
@@ -10218,29 +11563,40 @@ func (s *Server) handleCorpMembers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, members)
+	writeCorpList(w, provider, members)
 }
 
 func (s *Server) handleCorpWallets(w http.ResponseWriter, r *http.Request) {
 	provider, err := s.corpProvider(r)
 	if err != nil {
-		writeError(w, 400, err.Error())
+		if !writeScopeError(w, err) {
+			writeError(w, 400, err.Error())
+		}
 		return
 	}
 
-	wallets, err := provider.GetWallets()
+	days := corp.DefaultWalletSummaryDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		if v, err := strconv.Atoi(d); err == nil && v > 0 && v <= 365 {
+			days = v
+		}
+	}
+
+	summary, err := corp.BuildWalletSummary(provider, days)
 	if err != nil {
 		writeError(w, 500, err.Error())
 		return
 	}
 
-	writeJSON(w, wallets)
+	writeCorpJSON(w, provider, summary)
 }
 
 func (s *Server) handleCorpJournal(w http.ResponseWriter, r *http.Request) {
 	provider, err := s.corpProvider(r)
 	if err != nil {
-		writeError(w, 400, err.Error())
+		if !writeScopeError(w, err) {
+			writeError(w, 400, err.Error())
+		}
 		return
 	}
 
@@ -10263,13 +11619,15 @@ func (s *Server) handleCorpJournal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, journal)
+	writeCorpList(w, provider, journal)
 }
 
 func (s *Server) handleCorpOrders(w http.ResponseWriter, r *http.Request) {
 	provider, err := s.corpProvider(r)
 	if err != nil {
-		writeError(w, 400, err.Error())
+		if !writeScopeError(w, err) {
+			writeError(w, 400, err.Error())
+		}
 		return
 	}
 
@@ -10279,13 +11637,15 @@ func (s *Server) handleCorpOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, orders)
+	writeCorpList(w, provider, orders)
 }
 
 func (s *Server) handleCorpIndustry(w http.ResponseWriter, r *http.Request) {
 	provider, err := s.corpProvider(r)
 	if err != nil {
-		writeError(w, 400, err.Error())
+		if !writeScopeError(w, err) {
+			writeError(w, 400, err.Error())
+		}
 		return
 	}
 
@@ -10295,13 +11655,15 @@ func (s *Server) handleCorpIndustry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, jobs)
+	writeCorpList(w, provider, jobs)
 }
 
 func (s *Server) handleCorpMining(w http.ResponseWriter, r *http.Request) {
 	provider, err := s.corpProvider(r)
 	if err != nil {
-		writeError(w, 400, err.Error())
+		if !writeScopeError(w, err) {
+			writeError(w, 400, err.Error())
+		}
 		return
 	}
 
@@ -10311,5 +11673,5 @@ func (s *Server) handleCorpMining(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, entries)
+	writeCorpList(w, provider, entries)
 }
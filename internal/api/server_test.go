@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"eve-flipper/internal/config"
+	"eve-flipper/internal/engine"
 	"eve-flipper/internal/esi"
+	"eve-flipper/internal/sde"
 )
 
 // GET /api/status is not tested here because it calls esi.Client.HealthCheck() which performs a real HTTP request.
@@ -69,6 +71,36 @@ func TestWalletTxnCache_ExpiresByTTL(t *testing.T) {
 	}
 }
 
+func TestWalletBalanceCache_PerCharacterTTLAndClear(t *testing.T) {
+	srv := &Server{}
+	srv.setWalletBalanceCache(1001, 1.5e9)
+	srv.setWalletBalanceCache(2002, 42)
+
+	if got, ok := srv.getWalletBalanceCache(1001); !ok || got != 1.5e9 {
+		t.Fatalf("expected cache hit for 1001, got ok=%v balance=%v", ok, got)
+	}
+	if got, ok := srv.getWalletBalanceCache(2002); !ok || got != 42 {
+		t.Fatalf("expected cache hit for 2002, got ok=%v balance=%v", ok, got)
+	}
+	if _, ok := srv.getWalletBalanceCache(3003); ok {
+		t.Fatalf("expected cache miss for unknown character")
+	}
+
+	srv.walletBalanceMu.Lock()
+	e := srv.walletBalanceCache[1001]
+	e.fetchedAt = time.Now().Add(-walletBalanceCacheTTL - time.Second)
+	srv.walletBalanceCache[1001] = e
+	srv.walletBalanceMu.Unlock()
+	if _, ok := srv.getWalletBalanceCache(1001); ok {
+		t.Fatalf("expected cache miss for stale entry")
+	}
+
+	srv.clearWalletBalanceCache()
+	if _, ok := srv.getWalletBalanceCache(2002); ok {
+		t.Fatalf("expected cache miss after clear")
+	}
+}
+
 func TestEnsureRequestUserID_SignedCookieRoundTrip(t *testing.T) {
 	srv := NewServer(config.Default(), &esi.Client{}, nil, nil, nil)
 
@@ -160,3 +192,165 @@ func TestAuthRevisionBumpAndStatusPayload(t *testing.T) {
 		t.Fatalf("payload auth_revision = %d, want 2", revision)
 	}
 }
+
+func TestHandleTypeAutocomplete_PrefixFirstAndSkipsDisabled(t *testing.T) {
+	srv := &Server{
+		ready: true,
+		sdeData: &sde.Data{Types: map[int32]*sde.ItemType{
+			2048:              {ID: 2048, Name: "Damage Control II"},
+			2046:              {ID: 2046, Name: "Damage Control I"},
+			12235:             {ID: 12235, Name: "Control Tower"},
+			11269:             {ID: 11269, Name: "Reactive Armor Hardener Control"},
+			engine.MPTCTypeID: {ID: engine.MPTCTypeID, Name: "Multiple Pilot Training Certificate"},
+		}},
+	}
+
+	get := func(q string) []typeSuggestion {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		srv.handleTypeAutocomplete(rec, httptest.NewRequest(http.MethodGet, "/api/types/autocomplete?q="+q, nil))
+		var resp struct {
+			Types []typeSuggestion `json:"types"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp.Types
+	}
+
+	got := get("control")
+	want := []int32{12235, 2046, 2048, 11269}
+	if len(got) != len(want) {
+		t.Fatalf("matches = %+v, want type IDs %v", got, want)
+	}
+	for i, id := range want {
+		if got[i].TypeID != id {
+			t.Errorf("result[%d] = %+v, want type %d (prefix matches first, then by name)", i, got[i], id)
+		}
+	}
+	if got := get("pilot"); len(got) != 0 {
+		t.Errorf("market-disabled type suggested: %+v", got)
+	}
+}
+
+func TestResolveExecutionPlanIDs(t *testing.T) {
+	data := &sde.Data{
+		Types:        map[int32]*sde.ItemType{34: {ID: 34, Name: "Tritanium"}},
+		RegionByName: map[string]int32{"the forge": 10000002},
+	}
+
+	typeID, regionID, err := resolveExecutionPlanIDs(data, 0, " tritanium ", 0, "The Forge")
+	if err != nil || typeID != 34 || regionID != 10000002 {
+		t.Errorf("by name = (%d, %d, %v), want (34, 10000002, nil)", typeID, regionID, err)
+	}
+	typeID, regionID, err = resolveExecutionPlanIDs(data, 35, "Tritanium", 10000043, "The Forge")
+	if err != nil || typeID != 35 || regionID != 10000043 {
+		t.Errorf("numeric IDs must win, got (%d, %d, %v)", typeID, regionID, err)
+	}
+	if _, _, err := resolveExecutionPlanIDs(data, 0, "Unobtainium", 0, ""); err == nil {
+		t.Error("unknown type_name accepted")
+	}
+	if _, _, err := resolveExecutionPlanIDs(data, 34, "", 0, "Nowhere"); err == nil {
+		t.Error("unknown region_name accepted")
+	}
+	if _, _, err := resolveExecutionPlanIDs(nil, 0, "Tritanium", 1, ""); err != errSDENotLoaded {
+		t.Errorf("nil SDE err = %v, want errSDENotLoaded", err)
+	}
+}
+
+func TestRegionalDayTargets(t *testing.T) {
+	srv := &Server{sdeData: &sde.Data{
+		Systems: map[int32]*sde.SolarSystem{
+			30000142: {ID: 30000142, Name: "Jita", RegionID: 10000002},
+			30002187: {ID: 30002187, Name: "Amarr", RegionID: 10000043},
+		},
+		SystemByName: map[string]int32{"jita": 30000142, "amarr": 30002187},
+	}}
+
+	// Single-system requests resolve to exactly that destination.
+	targets, err := srv.regionalDayTargets(scanRequest{}, engine.ScanParams{TargetMarketSystemID: 30000142})
+	if err != nil || len(targets) != 1 || targets[0].regionID != 10000002 {
+		t.Fatalf("single target = %+v, %v", targets, err)
+	}
+
+	targets, err = srv.regionalDayTargets(
+		scanRequest{TargetMarketSystems: []string{"Amarr", " jita ", ""}},
+		engine.ScanParams{TargetMarketSystemID: 30000142},
+	)
+	if err != nil {
+		t.Fatalf("regionalDayTargets: %v", err)
+	}
+	if len(targets) != 2 || targets[0].systemName != "Jita" || targets[1].systemName != "Amarr" || targets[1].regionID != 10000043 {
+		t.Errorf("targets = %+v, want Jita then Amarr without duplicates", targets)
+	}
+
+	if _, err := srv.regionalDayTargets(scanRequest{TargetMarketSystems: []string{"Dodixie"}}, engine.ScanParams{}); err == nil {
+		t.Error("unknown target system accepted")
+	}
+	if targets, _ := srv.regionalDayTargets(scanRequest{}, engine.ScanParams{}); len(targets) != 0 {
+		t.Errorf("no targets requested, got %+v", targets)
+	}
+}
+
+func TestResolveScanRegions(t *testing.T) {
+	data := &sde.Data{
+		Regions: map[int32]*sde.Region{
+			10000002: {ID: 10000002, Name: "The Forge"},
+			10000043: {ID: 10000043, Name: "Domain"},
+		},
+		RegionByName: map[string]int32{"the forge": 10000002, "domain": 10000043},
+	}
+
+	sources, target, err := resolveScanRegions(data, scanRequest{SourceRegions: []string{" The Forge ", "domain", "THE FORGE"}, TargetRegion: "Domain"})
+	if err != nil || len(sources) != 2 || sources[0] != 10000002 || sources[1] != 10000043 || target != 10000043 {
+		t.Fatalf("by name = %v, %d, %v", sources, target, err)
+	}
+
+	// IDs win over names, even names that would not resolve.
+	sources, target, err = resolveScanRegions(data, scanRequest{
+		SourceRegions:   []string{"Nowhere"},
+		SourceRegionIDs: []int32{10000043, 10000043},
+		TargetRegion:    "Nowhere",
+		TargetRegionID:  10000002,
+	})
+	if err != nil || len(sources) != 1 || sources[0] != 10000043 || target != 10000002 {
+		t.Fatalf("by id = %v, %d, %v", sources, target, err)
+	}
+
+	if _, _, err := resolveScanRegions(data, scanRequest{SourceRegionIDs: []int32{1}}); err == nil {
+		t.Error("unknown source region id accepted")
+	}
+	if _, _, err := resolveScanRegions(data, scanRequest{TargetRegionID: 1}); err == nil {
+		t.Error("unknown target region id accepted")
+	}
+	if sources, target, err := resolveScanRegions(data, scanRequest{}); err != nil || sources == nil || len(sources) != 0 || target != 0 {
+		t.Errorf("empty request = %v, %d, %v", sources, target, err)
+	}
+}
+
+func TestParseScanParams_ContractFilters(t *testing.T) {
+	srv := &Server{
+		ready:   true,
+		cfg:     config.Default(),
+		sdeData: &sde.Data{SystemByName: map[string]int32{"jita": 30000142}},
+	}
+
+	params, err := srv.parseScanParams("", scanRequest{
+		SystemName:       "Jita",
+		ContractTypes:    []string{"Auction", " item_exchange", "auction"},
+		ExcludeIssuerIDs: []int64{98000001, 2112000001},
+	})
+	if err != nil {
+		t.Fatalf("parseScanParams: %v", err)
+	}
+	if len(params.ContractTypes) != 2 || params.ContractTypes[0] != engine.ContractTypeAuction || params.ContractTypes[1] != engine.ContractTypeItemExchange {
+		t.Fatalf("ContractTypes = %v", params.ContractTypes)
+	}
+	if len(params.ExcludeIssuerIDs) != 2 {
+		t.Fatalf("ExcludeIssuerIDs = %v", params.ExcludeIssuerIDs)
+	}
+
+	if _, err := srv.parseScanParams("", scanRequest{SystemName: "Jita", ContractTypes: []string{"courier"}}); err == nil {
+		t.Fatal("unknown contract type accepted")
+	}
+}
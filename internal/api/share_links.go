@@ -0,0 +1,139 @@
+package api
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"eve-flipper/internal/config"
+)
+
+const sharedScanPathPrefix = "/api/shared/"
+
+// isSharedScanPath reports whether path is a public share link, which skips
+// the API key and user-scope middleware.
+func isSharedScanPath(path string) bool {
+	return strings.HasPrefix(path, sharedScanPathPrefix)
+}
+
+func generateShareToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// handleCreateShareLink mints a read-only link to one saved scan. The token
+// is returned once; only its hash is stored. ttl_hours overrides the
+// caller's share_link_ttl_hours setting.
+// POST /api/scan/history/{id}/share
+// Body (optional): {"ttl_hours": 24}
+func (s *Server) handleCreateShareLink(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, 400, "invalid id")
+		return
+	}
+	var req struct {
+		TTLHours int `json:"ttl_hours"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+			return
+		}
+	}
+	if req.TTLHours < 0 || req.TTLHours > config.MaxShareLinkTTLHours {
+		writeError(w, 400, fmt.Sprintf("ttl_hours must be between 1 and %d", config.MaxShareLinkTTLHours))
+		return
+	}
+	if s.db.GetHistoryByID(id) == nil {
+		writeError(w, 404, "not found")
+		return
+	}
+
+	userID := userIDFromRequest(r)
+	ttl := req.TTLHours
+	if ttl == 0 {
+		ttl = config.DefaultShareLinkTTLHours
+		if cfg := s.loadConfigForUser(userID); cfg != nil && cfg.ShareLinkTTLHours > 0 {
+			ttl = cfg.ShareLinkTTLHours
+		}
+	}
+
+	token, err := generateShareToken()
+	if err != nil {
+		writeError(w, 500, "failed to create share link")
+		return
+	}
+	link, err := s.db.CreateShareLink(userID, id, token, time.Now().Add(time.Duration(ttl)*time.Hour))
+	if err != nil {
+		writeError(w, 500, "failed to create share link: "+err.Error())
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"token":      token,
+		"url":        sharedScanPathPrefix + token,
+		"scan_id":    link.ScanID,
+		"expires_at": link.ExpiresAt,
+	})
+}
+
+// handleRevokeShareLinks revokes every link the caller created for a scan.
+// DELETE /api/scan/history/{id}/share
+func (s *Server) handleRevokeShareLinks(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, 400, "invalid id")
+		return
+	}
+	n, err := s.db.DeleteShareLinksForScan(userIDFromRequest(r), id)
+	if err != nil {
+		writeError(w, 500, "revoke failed: "+err.Error())
+		return
+	}
+	if n == 0 {
+		writeError(w, 404, "no share links for this scan")
+		return
+	}
+	writeJSON(w, map[string]interface{}{"status": "revoked", "revoked": n})
+}
+
+// handleGetSharedScan serves the scan behind a share link: the history
+// record and its rows as the link's creator sees them, and nothing else.
+// GET /api/shared/{token}
+func (s *Server) handleGetSharedScan(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSpace(r.PathValue("token"))
+	if token == "" {
+		writeError(w, 404, "not found")
+		return
+	}
+	link, err := s.db.GetShareLink(token, time.Now())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, 404, "share link not found or expired")
+			return
+		}
+		writeError(w, 500, "failed to load share link")
+		return
+	}
+	record := s.db.GetHistoryByID(link.ScanID)
+	if record == nil {
+		writeError(w, 404, "share link not found or expired")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, map[string]interface{}{
+		"scan":       record,
+		"results":    s.loadHistoryResults(link.UserID, record),
+		"expires_at": link.ExpiresAt,
+	})
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"eve-flipper/internal/engine"
+)
+
+func TestScanShareLinkLifecycle(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database, apiKey: "secret"}
+	handler := srv.Handler()
+
+	scanID := database.InsertHistoryFull("station", "Jita", 1, 200, 200, 900, map[string]float64{"min_margin": 5}, nil)
+	database.InsertStationResults(scanID, []engine.StationTrade{{TypeID: 34, TypeName: "Tritanium", DailyProfit: 200}})
+	path := "/api/scan/history/" + strconv.FormatInt(scanID, 10) + "/share"
+
+	req := requestWithUserID(http.MethodPost, path, strings.NewReader(`{"ttl_hours":2}`), "owner")
+	req.SetPathValue("id", strconv.FormatInt(scanID, 10))
+	rec := httptest.NewRecorder()
+	srv.handleCreateShareLink(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var link struct {
+		Token string `json:"token"`
+		URL   string `json:"url"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&link); err != nil || link.Token == "" {
+		t.Fatalf("decode link: %v (%+v)", err, link)
+	}
+
+	// The shared route needs neither the API key nor a user cookie.
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, link.URL, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("shared status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if cookies := rec.Result().Cookies(); len(cookies) != 0 {
+		t.Errorf("shared route set cookies %v", cookies)
+	}
+	var shared map[string]json.RawMessage
+	if err := json.NewDecoder(rec.Body).Decode(&shared); err != nil {
+		t.Fatalf("decode shared: %v", err)
+	}
+	if len(shared) != 3 || shared["scan"] == nil || shared["results"] == nil || shared["expires_at"] == nil {
+		t.Fatalf("shared keys = %v, want only scan, results and expires_at", shared)
+	}
+	if !strings.Contains(string(shared["results"]), "Tritanium") {
+		t.Errorf("shared results = %s", shared["results"])
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/shared/not-a-token", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown token status = %d, want 404", rec.Code)
+	}
+
+	// Someone else cannot revoke the owner's links.
+	req = requestWithUserID(http.MethodDelete, path, nil, "other")
+	req.SetPathValue("id", strconv.FormatInt(scanID, 10))
+	rec = httptest.NewRecorder()
+	srv.handleRevokeShareLinks(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("foreign revoke status = %d, want 404", rec.Code)
+	}
+
+	req = requestWithUserID(http.MethodDelete, path, nil, "owner")
+	req.SetPathValue("id", strconv.FormatInt(scanID, 10))
+	rec = httptest.NewRecorder()
+	srv.handleRevokeShareLinks(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("revoke status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, link.URL, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("revoked link status = %d, want 404", rec.Code)
+	}
+}
+
+func TestHandleCreateShareLink_Rejects(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+	scanID := database.InsertHistoryFull("station", "Jita", 0, 0, 0, 0, nil, nil)
+
+	cases := []struct {
+		id, body string
+		want     int
+	}{
+		{strconv.FormatInt(scanID, 10), `{"ttl_hours":100000}`, http.StatusBadRequest},
+		{strconv.FormatInt(scanID, 10), `{"ttl_hours":-1}`, http.StatusBadRequest},
+		{strconv.FormatInt(scanID+1000, 10), `{}`, http.StatusNotFound},
+		{"abc", `{}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		req := requestWithUserID(http.MethodPost, "/api/scan/history/x/share", strings.NewReader(tc.body), "owner")
+		req.SetPathValue("id", tc.id)
+		rec := httptest.NewRecorder()
+		srv.handleCreateShareLink(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("id=%s body=%s: status = %d, want %d", tc.id, tc.body, rec.Code, tc.want)
+		}
+	}
+}
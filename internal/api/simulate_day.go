@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"eve-flipper/internal/auth"
+	"eve-flipper/internal/engine"
+)
+
+// handleAuthSimulateDay runs a station scan for the requested region and
+// feeds the results through the day simulator, projecting how the character's
+// capital, cargo and order slots would play out hour by hour.
+// Capital and order slots default to the active character's wallet balance and
+// trade skills when omitted.
+func (s *Server) handleAuthSimulateDay(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromRequest(r)
+	if !s.isReady() {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+
+	var req struct {
+		RegionID             int32   `json:"region_id"`
+		StationID            int64   `json:"station_id"` // 0 = all stations in region
+		Capital              float64 `json:"capital"`
+		CargoM3              float64 `json:"cargo_m3"`
+		OrderSlots           int     `json:"order_slots"`
+		Hours                int     `json:"hours"`
+		MinMargin            float64 `json:"min_margin"`
+		SalesTaxPercent      float64 `json:"sales_tax_percent"`
+		BrokerFee            float64 `json:"broker_fee"`
+		CTSProfile           string  `json:"cts_profile"`
+		SplitTradeFees       bool    `json:"split_trade_fees"`
+		BuyBrokerFeePercent  float64 `json:"buy_broker_fee_percent"`
+		SellBrokerFeePercent float64 `json:"sell_broker_fee_percent"`
+		BuySalesTaxPercent   float64 `json:"buy_sales_tax_percent"`
+		SellSalesTaxPercent  float64 `json:"sell_sales_tax_percent"`
+		MinDailyVolume       int64   `json:"min_daily_volume"`
+		MinItemProfit        float64 `json:"min_item_profit"`
+		MaxSDS               int     `json:"max_sds"`
+	}
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+			return
+		}
+	}
+	if raw := strings.TrimSpace(r.URL.Query().Get("region_id")); raw != "" {
+		v, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || v <= 0 {
+			writeError(w, 400, "invalid region_id")
+			return
+		}
+		req.RegionID = int32(v)
+	}
+	if req.RegionID <= 0 {
+		writeError(w, 400, "region_id is required")
+		return
+	}
+	if req.Capital < 0 || req.CargoM3 < 0 || req.OrderSlots < 0 {
+		writeError(w, 400, "capital, cargo_m3 and order_slots must be non-negative")
+		return
+	}
+
+	sess := s.sessions.GetForUser(userID)
+	if sess == nil {
+		writeErrorCode(w, 401, errCodeNotLoggedIn, "not logged in")
+		return
+	}
+
+	s.mu.RLock()
+	scanner := s.scanner
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	if scanner == nil || sdeData == nil {
+		writeError(w, 503, "station scanner not ready")
+		return
+	}
+	if _, ok := sdeData.Regions[req.RegionID]; !ok {
+		writeErrorCode(w, 400, errCodeRegionNotFound, "unknown region")
+		return
+	}
+
+	capitalSource := "request"
+	slotsSource := "request"
+	if req.Capital <= 0 || req.OrderSlots <= 0 {
+		token, err := s.sessions.EnsureValidTokenForUser(s.sso, userID)
+		if err != nil {
+			writeError(w, 401, err.Error())
+			return
+		}
+		if req.Capital <= 0 {
+			if err := requireScope(sess, auth.ScopeReadCharacterWallet); err != nil {
+				writeScopeError(w, err)
+				return
+			}
+			balance, balErr := s.esi.GetWalletBalance(sess.CharacterID, token)
+			if balErr != nil {
+				writeError(w, 500, "failed to fetch wallet balance: "+balErr.Error())
+				return
+			}
+			req.Capital = balance
+			capitalSource = "wallet"
+		}
+		if req.OrderSlots <= 0 && !sess.HasScope(auth.ScopeReadSkills) {
+			// Basic logins do not grant skills; simulate the untrained limit.
+			req.OrderSlots = engine.OrderSlotsFromSkills(nil)
+			slotsSource = "default"
+		} else if req.OrderSlots <= 0 {
+			skills, skillErr := s.esi.GetSkills(sess.CharacterID, token)
+			if skillErr != nil {
+				log.Printf("[AUTH] SimulateDay skills error (%s): %v", sess.CharacterName, skillErr)
+				skills = nil
+			}
+			req.OrderSlots = engine.OrderSlotsFromSkills(skills)
+			slotsSource = "skills"
+		}
+	}
+
+	userCfg := s.loadConfigForUser(userID)
+	if !req.SplitTradeFees {
+		if req.SalesTaxPercent <= 0 && userCfg != nil && userCfg.SalesTaxPercent > 0 {
+			req.SalesTaxPercent = userCfg.SalesTaxPercent
+		}
+		if req.BrokerFee <= 0 && userCfg != nil && userCfg.BrokerFeePercent > 0 {
+			req.BrokerFee = userCfg.BrokerFeePercent
+		}
+	}
+
+	params := engine.StationTradeParams{
+		RegionID:             req.RegionID,
+		MinMargin:            req.MinMargin,
+		SalesTaxPercent:      req.SalesTaxPercent,
+		BrokerFee:            req.BrokerFee,
+		CTSProfile:           req.CTSProfile,
+		SplitTradeFees:       req.SplitTradeFees,
+		BuyBrokerFeePercent:  req.BuyBrokerFeePercent,
+		SellBrokerFeePercent: req.SellBrokerFeePercent,
+		BuySalesTaxPercent:   req.BuySalesTaxPercent,
+		SellSalesTaxPercent:  req.SellSalesTaxPercent,
+		MinDailyVolume:       req.MinDailyVolume,
+		MinItemProfit:        req.MinItemProfit,
+		MaxSDS:               req.MaxSDS,
+		Ctx:                  r.Context(),
+	}
+	scanScope := fmt.Sprintf("Region %d (all)", req.RegionID)
+	if req.StationID > 0 {
+		params.StationIDs = map[int64]bool{req.StationID: true}
+		scanScope = fmt.Sprintf("Station %d", req.StationID)
+	}
+
+	scanResults, scanErr := scanner.ScanStationTrades(params, func(string) {})
+	if scanErr != nil {
+		if errors.Is(scanErr, context.Canceled) || errors.Is(scanErr, context.DeadlineExceeded) {
+			writeError(w, 499, "request canceled")
+			return
+		}
+		writeError(w, 500, scanErr.Error())
+		return
+	}
+	scanResults = filterStationTradesExcludeStructures(scanResults)
+	scanResults = s.filterStationTradesMarketDisabled(userID, scanResults)
+	sort.Slice(scanResults, func(i, j int) bool {
+		return scanResults[i].DailyProfit > scanResults[j].DailyProfit
+	})
+
+	simulation := engine.SimulateTradingDay(scanResults, engine.DaySimulationParams{
+		Capital:    req.Capital,
+		CargoM3:    req.CargoM3,
+		OrderSlots: req.OrderSlots,
+		Hours:      req.Hours,
+	})
+
+	writeJSON(w, struct {
+		GeneratedAt   string                     `json:"generated_at"`
+		RegionID      int32                      `json:"region_id"`
+		ScanScope     string                     `json:"scan_scope"`
+		CapitalSource string                     `json:"capital_source"`
+		SlotsSource   string                     `json:"order_slots_source"`
+		CacheMeta     stationCacheMeta           `json:"cache_meta"`
+		Simulation    engine.DaySimulationResult `json:"simulation"`
+	}{
+		GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+		RegionID:      req.RegionID,
+		ScanScope:     scanScope,
+		CapitalSource: capitalSource,
+		SlotsSource:   slotsSource,
+		CacheMeta:     s.stationCacheMetaForRegions(map[int32]bool{req.RegionID: true}),
+		Simulation:    simulation,
+	})
+}
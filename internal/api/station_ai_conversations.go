@@ -0,0 +1,147 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"eve-flipper/internal/db"
+)
+
+func (s *Server) handleAuthListStationAIConversations(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	limit := 50
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			writeError(w, 400, "invalid limit")
+			return
+		}
+		if v > 500 {
+			v = 500
+		}
+		limit = v
+	}
+	conversations, err := s.db.ListAIConversationsForUser(userIDFromRequest(r), limit)
+	if err != nil {
+		writeError(w, 500, "failed to list conversations")
+		return
+	}
+	writeJSON(w, map[string]interface{}{"conversations": conversations})
+}
+
+func (s *Server) handleAuthCreateStationAIConversation(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	var body struct {
+		Title string `json:"title"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+			return
+		}
+	}
+	conversation, err := s.db.CreateAIConversationForUser(userIDFromRequest(r), body.Title)
+	if err != nil {
+		writeError(w, 500, "failed to create conversation")
+		return
+	}
+	writeJSON(w, conversation)
+}
+
+func (s *Server) handleAuthGetStationAIConversation(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	conversationID, err := strconv.ParseInt(strings.TrimSpace(r.PathValue("conversationID")), 10, 64)
+	if err != nil || conversationID <= 0 {
+		writeError(w, 400, "invalid conversation id")
+		return
+	}
+	conversation, messages, err := s.db.GetAIConversationForUser(userIDFromRequest(r), conversationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, 404, "conversation not found")
+			return
+		}
+		writeError(w, 500, "failed to load conversation")
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"conversation": conversation,
+		"messages":     messages,
+	})
+}
+
+// stationAILoadConversation merges the stored history of req.Conversation
+// into req.History. Returns an HTTP status and message when the conversation
+// cannot be used; status 0 means success or no conversation requested.
+func (s *Server) stationAILoadConversation(userID string, req *stationAIChatRequestPayload) (int, string) {
+	if req.Conversation == 0 {
+		return 0, ""
+	}
+	if req.Conversation < 0 {
+		return 400, "invalid conversation_id"
+	}
+	if s.db == nil {
+		return 503, "database unavailable"
+	}
+	_, stored, err := s.db.GetAIConversationForUser(userID, req.Conversation)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 404, "conversation not found"
+		}
+		return 500, "failed to load conversation"
+	}
+	req.History = mergeStationAIHistory(stored, req.History)
+	return 0, ""
+}
+
+// mergeStationAIHistory puts the stored turns first and keeps only client
+// turns the server has not seen, so stored history wins on conflicts.
+func mergeStationAIHistory(stored []db.AIMessage, client []stationAIHistoryMessage) []stationAIHistoryMessage {
+	if len(stored) == 0 {
+		return client
+	}
+	seen := make(map[string]bool, len(stored))
+	merged := make([]stationAIHistoryMessage, 0, len(stored)+len(client))
+	for _, m := range stored {
+		seen[m.Role+"\x00"+strings.TrimSpace(m.Content)] = true
+		merged = append(merged, stationAIHistoryMessage{Role: m.Role, Content: m.Content})
+	}
+	for _, m := range client {
+		if seen[m.Role+"\x00"+strings.TrimSpace(m.Content)] {
+			continue
+		}
+		merged = append(merged, m)
+	}
+	return normalizeStationAIHistory(merged)
+}
+
+// stationAIPersistTurn stores the user message and the assistant answer in
+// the request's conversation. Diagnostic answers are dropped, matching the
+// filtering applied to client-supplied history.
+func (s *Server) stationAIPersistTurn(userID string, req stationAIChatRequestPayload, answer string) {
+	if req.Conversation <= 0 || s.db == nil {
+		return
+	}
+	messages := []db.AIMessage{{Role: "user", Content: req.UserMessage}}
+	answer = strings.TrimSpace(answer)
+	if answer != "" && !stationAIIsDiagnosticAssistantMessage(answer) {
+		messages = append(messages, db.AIMessage{Role: "assistant", Content: answer})
+	}
+	if err := s.db.AppendAIMessagesForUser(userID, req.Conversation, messages); err != nil {
+		log.Printf("[AI][CHAT] persist conversation=%d: %v", req.Conversation, err)
+	}
+}
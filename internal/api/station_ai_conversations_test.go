@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestStationAIConversationPersistAndMerge(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+	const userID = "user-ai-conversation"
+
+	conv, err := database.CreateAIConversationForUser(userID, "")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	req := stationAIChatRequestPayload{Conversation: conv.ID, UserMessage: "best trade in Jita?"}
+	srv.stationAIPersistTurn(userID, req, "Buy Tritanium.")
+	req.UserMessage = "why?"
+	srv.stationAIPersistTurn(userID, req, `{"status":"need_full_context","rows_count":0}`)
+
+	stored, messages, err := database.GetAIConversationForUser(userID, conv.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("messages = %+v, want diagnostic answer dropped", messages)
+	}
+	if stored.Title != "best trade in Jita?" {
+		t.Fatalf("title = %q, want first user message", stored.Title)
+	}
+
+	next := stationAIChatRequestPayload{
+		Conversation: conv.ID,
+		History: []stationAIHistoryMessage{
+			{Role: "user", Content: "best trade in Jita?"},
+			{Role: "assistant", Content: "client-only note"},
+		},
+	}
+	if status, msg := srv.stationAILoadConversation(userID, &next); status != 0 {
+		t.Fatalf("load: %d %s", status, msg)
+	}
+	if len(next.History) != 4 || next.History[0].Content != "best trade in Jita?" || next.History[3].Content != "client-only note" {
+		t.Fatalf("merged history = %+v", next.History)
+	}
+
+	other := stationAIChatRequestPayload{Conversation: conv.ID}
+	if status, _ := srv.stationAILoadConversation("someone-else", &other); status != http.StatusNotFound {
+		t.Fatalf("foreign conversation status = %d, want 404", status)
+	}
+}
+
+func TestHandleAuthGetStationAIConversation(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+	const userID = "user-ai-conversation-get"
+	conv, err := database.CreateAIConversationForUser(userID, "Hauling")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	get := func(id, user string) *httptest.ResponseRecorder {
+		req := requestWithUserID(http.MethodGet, "/api/auth/station/ai/conversations/"+id, nil, user)
+		req.SetPathValue("conversationID", id)
+		rec := httptest.NewRecorder()
+		srv.handleAuthGetStationAIConversation(rec, req)
+		return rec
+	}
+	id := strconv.FormatInt(conv.ID, 10)
+	rec := get(id, userID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Conversation struct {
+			ID    int64  `json:"id"`
+			Title string `json:"title"`
+		} `json:"conversation"`
+		Messages []json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Conversation.ID != conv.ID || resp.Conversation.Title != "Hauling" || resp.Messages == nil {
+		t.Fatalf("response = %s", rec.Body.String())
+	}
+	if rec := get(id, "intruder"); rec.Code != http.StatusNotFound {
+		t.Fatalf("foreign status = %d, want 404", rec.Code)
+	}
+	if rec := get("abc", userID); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad id status = %d, want 400", rec.Code)
+	}
+}
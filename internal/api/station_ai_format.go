@@ -0,0 +1,144 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+
+	"eve-flipper/internal/config"
+)
+
+// stationAINumberFormat is the resolved number/ISK formatting preference for
+// one AI chat request. The zero value disables format enforcement.
+type stationAINumberFormat struct {
+	Locale    string // en-US | de-DE | ru-RU
+	Thousands string // primary grouping separator
+	Decimal   string
+	ISKFormat string // full | compact
+}
+
+// normalizeStationAINumberLocale maps user input to a supported number locale.
+func normalizeStationAINumberLocale(v string) string {
+	return config.NormalizeAINumberLocale(v)
+}
+
+// normalizeStationAIISKFormat returns "full", "compact" or "" for unknown input.
+func normalizeStationAIISKFormat(v string) string {
+	return config.NormalizeAIISKFormat(v)
+}
+
+// resolveStationAINumberFormat picks separators for the number locale, falling
+// back to the chat locale's conventions when no preference is set.
+func resolveStationAINumberFormat(chatLocale, numberLocale, iskFormat string) stationAINumberFormat {
+	locale := normalizeStationAINumberLocale(numberLocale)
+	if locale == "" {
+		locale = "en-US"
+		if chatLocale == "ru" {
+			locale = "ru-RU"
+		}
+	}
+	f := stationAINumberFormat{Locale: locale, ISKFormat: normalizeStationAIISKFormat(iskFormat)}
+	if f.ISKFormat == "" {
+		f.ISKFormat = "full"
+	}
+	switch locale {
+	case "de-DE":
+		f.Thousands, f.Decimal = ".", ","
+	case "ru-RU":
+		f.Thousands, f.Decimal = " ", ","
+	default:
+		f.Thousands, f.Decimal = ",", "."
+	}
+	return f
+}
+
+// Example renders a sample amount in this format for prompts and validation messages.
+func (f stationAINumberFormat) Example() string {
+	if f.ISKFormat == "compact" {
+		return "1" + f.Decimal + "23M ISK"
+	}
+	return "1" + f.Thousands + "234" + f.Thousands + "567" + f.Decimal + "89 ISK"
+}
+
+// PromptInstruction is appended to the system prompt so every answer uses the
+// same ISK notation.
+func (f stationAINumberFormat) PromptInstruction(locale string) string {
+	if f.Locale == "" {
+		return ""
+	}
+	thousands := fmt.Sprintf("%q", f.Thousands)
+	if f.Thousands == " " {
+		thousands = "space"
+		if locale == "ru" {
+			thousands = "пробел"
+		}
+	}
+	if locale == "ru" {
+		style := "пиши суммы полностью, без сокращений K/M/B"
+		if f.ISKFormat == "compact" {
+			style = "крупные суммы сокращай суффиксами K/M/B (например 4" + f.Decimal + "5B ISK)"
+		}
+		return fmt.Sprintf(
+			"Формат чисел (%s): разделитель тысяч %s, десятичный разделитель %q; %s. "+
+				"Всегда указывай валюту как ISK после числа, например %s. Не смешивай форматы в одном ответе.",
+			f.Locale, thousands, f.Decimal, style, f.Example(),
+		)
+	}
+	style := "write amounts in full, no K/M/B abbreviations"
+	if f.ISKFormat == "compact" {
+		style = "abbreviate large amounts with K/M/B suffixes (e.g. 4" + f.Decimal + "5B ISK)"
+	}
+	return fmt.Sprintf(
+		"Number format (%s): thousands separator %s, decimal separator %q; %s. "+
+			"Always write the currency as ISK after the number, e.g. %s. Do not mix formats within one answer.",
+		f.Locale, thousands, f.Decimal, style, f.Example(),
+	)
+}
+
+// validateISKAmounts checks every "<number> ISK" in the answer against the
+// preferred separators and abbreviation style. Ungrouped integers are always
+// accepted since they parse the same way in every locale.
+func (f stationAINumberFormat) validateISKAmounts(answer string) (bool, string) {
+	if f.Locale == "" {
+		return true, ""
+	}
+	thousands := regexp.QuoteMeta(f.Thousands)
+	numberChars := `[\d.,]`
+	if f.Thousands == " " {
+		thousands = `[ \x{00a0}\x{202f}]`
+		numberChars = `[\d., \x{00a0}\x{202f}]`
+	}
+	// Number (with any separators), optional magnitude suffix, then the ISK marker.
+	amountRe := regexp.MustCompile(`(?i)(\d(?:` + numberChars + `*\d)?)\s*(k|m|b|bn|mil|тыс\.?|млн|млрд)?\s*isk\b`)
+	decimal := regexp.QuoteMeta(f.Decimal)
+	grouped := regexp.MustCompile(`^\d{1,3}(?:` + thousands + `\d{3})+(?:` + decimal + `\d+)?$`)
+	plain := regexp.MustCompile(`^\d+(?:` + decimal + `\d+)?$`)
+
+	for _, m := range amountRe.FindAllStringSubmatch(answer, -1) {
+		number, suffix := m[1], m[2]
+		if suffix != "" && f.ISKFormat == "full" {
+			return false, fmt.Sprintf("ISK amounts abbreviated; user prefers full amounts (e.g. %s)", f.Example())
+		}
+		if !grouped.MatchString(number) && !plain.MatchString(number) {
+			return false, fmt.Sprintf("ISK amounts not formatted per user preference (expected e.g. %s)", f.Example())
+		}
+	}
+	return true, ""
+}
+
+// stationAINumberFormatForRequest resolves the format for a chat request:
+// explicit request fields win, then the user's saved AI preferences, then the
+// chat locale's conventions.
+func (s *Server) stationAINumberFormatForRequest(userID string, req stationAIChatRequestPayload) stationAINumberFormat {
+	numberLocale, iskFormat := req.NumberLocale, req.ISKFormat
+	if numberLocale == "" || iskFormat == "" {
+		if cfg := s.loadConfigForUser(userID); cfg != nil {
+			if numberLocale == "" {
+				numberLocale = cfg.AINumberLocale
+			}
+			if iskFormat == "" {
+				iskFormat = cfg.AIISKFormat
+			}
+		}
+	}
+	return resolveStationAINumberFormat(req.Locale, numberLocale, iskFormat)
+}
@@ -283,7 +283,7 @@ func TestStationAIPreflightPartialWhenRuntimeUnavailable(t *testing.T) {
 }
 
 func TestStationAIValidateAnswer(t *testing.T) {
-	valid, issue := stationAIValidateAnswer(`{"status":"CONSTRAINT_VIOLATION"}`, stationAIIntentTrading)
+	valid, issue := stationAIValidateAnswer(`{"status":"CONSTRAINT_VIOLATION"}`, stationAIIntentTrading, stationAINumberFormat{})
 	if valid {
 		t.Fatalf("expected diagnostic answer to be rejected")
 	}
@@ -291,7 +291,7 @@ func TestStationAIValidateAnswer(t *testing.T) {
 		t.Fatalf("expected rejection issue for diagnostic answer")
 	}
 
-	valid, _ = stationAIValidateAnswer("Keep filters stable and re-run later.", stationAIIntentTrading)
+	valid, _ = stationAIValidateAnswer("Keep filters stable and re-run later.", stationAIIntentTrading, stationAINumberFormat{})
 	if valid {
 		t.Fatalf("expected trading answer without numbers to be rejected")
 	}
@@ -299,12 +299,51 @@ func TestStationAIValidateAnswer(t *testing.T) {
 	valid, issue = stationAIValidateAnswer(
 		"Recommendation: raise min_daily_volume to 20 and min_item_profit to 1000000 ISK based on current rows.",
 		stationAIIntentTrading,
+		stationAINumberFormat{},
 	)
 	if !valid {
 		t.Fatalf("expected numeric trading answer to pass validation, issue=%q", issue)
 	}
 }
 
+func TestStationAIValidateAnswerISKFormat(t *testing.T) {
+	prefix := "Recommendation based on current rows: buy Tritanium and relist; expected margin after fees is "
+	cases := []struct {
+		name   string
+		format stationAINumberFormat
+		answer string
+		valid  bool
+	}{
+		{"en grouped", resolveStationAINumberFormat("en", "", ""), prefix + "1,234,567.89 ISK per day.", true},
+		{"en ungrouped", resolveStationAINumberFormat("en", "", ""), prefix + "1000000 ISK per day.", true},
+		{"en with de separators", resolveStationAINumberFormat("en", "", ""), prefix + "1.234.567,89 ISK per day.", false},
+		{"en abbreviated in full mode", resolveStationAINumberFormat("en", "", "full"), prefix + "1.5M ISK per day.", false},
+		{"en abbreviated in compact mode", resolveStationAINumberFormat("en", "", "compact"), prefix + "1.5M ISK per day.", true},
+		{"de grouped", resolveStationAINumberFormat("en", "de-DE", ""), prefix + "1.234.567,89 ISK per day.", true},
+		{"ru follows chat locale", resolveStationAINumberFormat("ru", "", ""), prefix + "1 234 567,89 ISK per day.", true},
+		{"ru nbsp grouping", resolveStationAINumberFormat("ru", "", ""), prefix + "1\u00a0234\u00a0567 ISK per day.", true},
+		{"ru with en separators", resolveStationAINumberFormat("ru", "", ""), prefix + "1,234,567.89 ISK per day.", false},
+		{"separate numbers stay separate", resolveStationAINumberFormat("en", "", ""), prefix + "20, then 1,234 ISK per day.", true},
+	}
+	for _, tc := range cases {
+		valid, issue := stationAIValidateAnswer(tc.answer, stationAIIntentTrading, tc.format)
+		if valid != tc.valid {
+			t.Fatalf("%s: valid=%t issue=%q, want %t", tc.name, valid, issue, tc.valid)
+		}
+	}
+}
+
+func TestStationAISystemPromptIncludesNumberFormat(t *testing.T) {
+	plan := stationAIPlannerPlan{Intent: stationAIIntentTrading}
+	prompt := stationAISystemPrompt("en", "Ivy AI", plan, resolveStationAINumberFormat("en", "de", "compact"))
+	if !strings.Contains(prompt, "de-DE") || !strings.Contains(prompt, "1,23M ISK") {
+		t.Fatalf("system prompt missing number format instruction: %s", prompt)
+	}
+	if prompt := stationAISystemPrompt("en", "Ivy AI", plan, stationAINumberFormat{}); strings.Contains(prompt, "Number format") {
+		t.Fatalf("zero format should not add an instruction: %s", prompt)
+	}
+}
+
 func TestNormalizeStationAIChatRequestStripsRuntimeContext(t *testing.T) {
 	req := stationAIChatRequestPayload{
 		Provider:    "openrouter",
@@ -0,0 +1,323 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	stationAIProviderOpenRouter = "openrouter"
+	stationAIProviderOpenAI     = "openai"
+	stationAIProviderAnthropic  = "anthropic"
+	stationAIProviderOllama     = "ollama"
+
+	defaultOllamaChatURL = "http://localhost:11434/v1/chat/completions"
+
+	stationAIProviderTimeout = 90 * time.Second
+	anthropicAPIVersion      = "2023-06-01"
+)
+
+// stationAIProvider sends one non-streaming chat completion. Messages use the
+// OpenAI role/content shape; implementations translate as needed and map
+// usage to prompt_tokens/completion_tokens/total_tokens so callers can stay
+// provider-agnostic.
+type stationAIProvider interface {
+	Chat(ctx context.Context, req stationAIChatRequestPayload, messages []map[string]string) (stationAIProviderReply, error)
+}
+
+// stationAIProviderFor returns the provider selected by req.Provider.
+func stationAIProviderFor(req stationAIChatRequestPayload) (stationAIProvider, bool) {
+	switch req.Provider {
+	case stationAIProviderOpenRouter:
+		return &stationAIOpenAIProvider{
+			endpoint: "https://openrouter.ai/api/v1/chat/completions",
+			headers: map[string]string{
+				"HTTP-Referer": "http://localhost:1420",
+				"X-Title":      "EVE Flipper Station AI",
+			},
+			// Ask OpenRouter to report usage.cost so spend can be tracked.
+			extraBody: map[string]interface{}{"usage": map[string]bool{"include": true}},
+		}, true
+	case stationAIProviderOpenAI:
+		return &stationAIOpenAIProvider{endpoint: "https://api.openai.com/v1/chat/completions"}, true
+	case stationAIProviderAnthropic:
+		return &stationAIAnthropicProvider{endpoint: "https://api.anthropic.com/v1/messages"}, true
+	case stationAIProviderOllama:
+		endpoint := req.BaseURL
+		if endpoint == "" {
+			endpoint = defaultOllamaChatURL
+		}
+		return &stationAIOpenAIProvider{endpoint: endpoint}, true
+	}
+	return nil, false
+}
+
+// stationAIProviderName is the display name of a provider ID, for status
+// messages shown to the user.
+func stationAIProviderName(provider string) string {
+	switch provider {
+	case stationAIProviderOpenRouter:
+		return "OpenRouter"
+	case stationAIProviderOpenAI:
+		return "OpenAI"
+	case stationAIProviderAnthropic:
+		return "Anthropic"
+	case stationAIProviderOllama:
+		return "Ollama"
+	}
+	return provider
+}
+
+// stationAIOpenAIProvider talks to any v1/chat/completions endpoint
+// (OpenAI itself, OpenRouter and Ollama). It also supports SSE streaming.
+type stationAIOpenAIProvider struct {
+	endpoint  string
+	headers   map[string]string
+	extraBody map[string]interface{}
+}
+
+func (p *stationAIOpenAIProvider) newRequest(ctx context.Context, apiKey string, payload map[string]interface{}) (*http.Request, error) {
+	for k, v := range p.extraBody {
+		payload[k] = v
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ai request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ai request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	for k, v := range p.headers {
+		httpReq.Header.Set(k, v)
+	}
+	return httpReq, nil
+}
+
+func (p *stationAIOpenAIProvider) Chat(
+	ctx context.Context,
+	req stationAIChatRequestPayload,
+	messages []map[string]string,
+) (stationAIProviderReply, error) {
+	httpReq, err := p.newRequest(ctx, req.APIKey, map[string]interface{}{
+		"model":       req.Model,
+		"temperature": req.Temperature,
+		"max_tokens":  req.MaxTokens,
+		"messages":    messages,
+	})
+	if err != nil {
+		return stationAIProviderReply{}, err
+	}
+	rawResp, err := stationAIDoProviderRequest(httpReq)
+	if err != nil {
+		return stationAIProviderReply{}, err
+	}
+
+	var orResp struct {
+		ID      string `json:"id"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content json.RawMessage `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage map[string]interface{} `json:"usage"`
+	}
+	if err := json.Unmarshal(rawResp, &orResp); err != nil {
+		return stationAIProviderReply{}, fmt.Errorf("invalid ai provider response: %w", err)
+	}
+	if len(orResp.Choices) == 0 {
+		return stationAIProviderReply{}, errors.New("empty ai response")
+	}
+
+	answer := strings.TrimSpace(extractAIContent(orResp.Choices[0].Message.Content))
+	if answer == "" {
+		return stationAIProviderReply{}, errors.New("empty ai answer")
+	}
+	model := strings.TrimSpace(orResp.Model)
+	if model == "" {
+		model = req.Model
+	}
+	return stationAIProviderReply{
+		Answer:     answer,
+		Model:      model,
+		ProviderID: strings.TrimSpace(orResp.ID),
+		Usage:      orResp.Usage,
+	}, nil
+}
+
+// stationAIAnthropicProvider talks to the Anthropic Messages API, which takes
+// the system prompt as a top-level field rather than a message.
+type stationAIAnthropicProvider struct {
+	endpoint string
+}
+
+func (p *stationAIAnthropicProvider) Chat(
+	ctx context.Context,
+	req stationAIChatRequestPayload,
+	messages []map[string]string,
+) (stationAIProviderReply, error) {
+	system, turns := anthropicMessages(messages)
+	payload := map[string]interface{}{
+		"model":      req.Model,
+		"max_tokens": req.MaxTokens,
+		"messages":   turns,
+		// Anthropic caps temperature at 1.0.
+		"temperature": min(req.Temperature, 1.0),
+	}
+	if system != "" {
+		payload["system"] = system
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return stationAIProviderReply{}, fmt.Errorf("failed to encode ai request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return stationAIProviderReply{}, fmt.Errorf("failed to create ai request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", req.APIKey)
+	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	rawResp, err := stationAIDoProviderRequest(httpReq)
+	if err != nil {
+		return stationAIProviderReply{}, err
+	}
+
+	var anResp struct {
+		ID      string `json:"id"`
+		Model   string `json:"model"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(rawResp, &anResp); err != nil {
+		return stationAIProviderReply{}, fmt.Errorf("invalid ai provider response: %w", err)
+	}
+	var sb strings.Builder
+	for _, block := range anResp.Content {
+		if block.Type == "text" {
+			sb.WriteString(block.Text)
+		}
+	}
+	answer := strings.TrimSpace(sb.String())
+	if answer == "" {
+		return stationAIProviderReply{}, errors.New("empty ai answer")
+	}
+	model := strings.TrimSpace(anResp.Model)
+	if model == "" {
+		model = req.Model
+	}
+	return stationAIProviderReply{
+		Answer:     answer,
+		Model:      model,
+		ProviderID: strings.TrimSpace(anResp.ID),
+		Usage: map[string]interface{}{
+			"prompt_tokens":     anResp.Usage.InputTokens,
+			"completion_tokens": anResp.Usage.OutputTokens,
+			"total_tokens":      anResp.Usage.InputTokens + anResp.Usage.OutputTokens,
+		},
+	}, nil
+}
+
+// anthropicMessages lifts system messages into a single system prompt and
+// merges consecutive turns from the same role, since the Messages API only
+// accepts alternating user/assistant turns.
+func anthropicMessages(messages []map[string]string) (string, []map[string]string) {
+	systemParts := make([]string, 0, 1)
+	turns := make([]map[string]string, 0, len(messages))
+	for _, msg := range messages {
+		role := msg["role"]
+		content := msg["content"]
+		switch role {
+		case "system":
+			if strings.TrimSpace(content) != "" {
+				systemParts = append(systemParts, content)
+			}
+			continue
+		case "user", "assistant":
+		default:
+			continue
+		}
+		if n := len(turns); n > 0 && turns[n-1]["role"] == role {
+			turns[n-1]["content"] += "\n\n" + content
+			continue
+		}
+		turns = append(turns, map[string]string{"role": role, "content": content})
+	}
+	return strings.Join(systemParts, "\n\n"), turns
+}
+
+// stationAIDoProviderRequest executes a provider call and returns the body of
+// a 2xx response. Both OpenAI-style and Anthropic errors carry error.message.
+func stationAIDoProviderRequest(httpReq *http.Request) ([]byte, error) {
+	client := &http.Client{Timeout: stationAIProviderTimeout}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ai provider request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rawResp, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read ai provider response: %w", readErr)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.New(stationAIProviderErrorMessage(rawResp))
+	}
+	return rawResp, nil
+}
+
+func stationAIProviderErrorMessage(rawResp []byte) string {
+	var errBody struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(rawResp, &errBody) == nil && strings.TrimSpace(errBody.Error.Message) != "" {
+		return strings.TrimSpace(errBody.Error.Message)
+	}
+	return "ai provider error"
+}
+
+// validateLocalProviderURL accepts only http(s) URLs on a loopback host, so a
+// request cannot make the server call arbitrary hosts on its network.
+func validateLocalProviderURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "invalid provider_base_url"
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "provider_base_url must use http or https"
+	}
+	if u.User != nil {
+		return "provider_base_url must not contain credentials"
+	}
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return ""
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return ""
+	}
+	return "provider_base_url must point to localhost"
+}
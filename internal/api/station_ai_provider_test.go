@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeStationAIChatRequestProviders(t *testing.T) {
+	for _, provider := range []string{"", "OpenRouter", "openai", "anthropic", "ollama"} {
+		req := stationAIChatRequestPayload{Provider: provider, APIKey: "k", Model: "m", UserMessage: "hi"}
+		if _, _, _, validationErr := normalizeStationAIChatRequest(&req); validationErr != "" {
+			t.Fatalf("provider %q: unexpected validation error %q", provider, validationErr)
+		}
+	}
+	req := stationAIChatRequestPayload{Provider: "mystery", APIKey: "k", Model: "m", UserMessage: "hi"}
+	if _, _, _, validationErr := normalizeStationAIChatRequest(&req); validationErr != "unsupported ai provider" {
+		t.Fatalf("validation error = %q, want unsupported ai provider", validationErr)
+	}
+}
+
+func TestAnthropicMessagesLiftsSystemPrompt(t *testing.T) {
+	system, turns := anthropicMessages([]map[string]string{
+		{"role": "system", "content": "be terse"},
+		{"role": "user", "content": "a"},
+		{"role": "user", "content": "b"},
+		{"role": "assistant", "content": "c"},
+	})
+	if system != "be terse" {
+		t.Fatalf("system = %q", system)
+	}
+	if len(turns) != 2 || turns[0]["role"] != "user" || turns[0]["content"] != "a\n\nb" || turns[1]["role"] != "assistant" {
+		t.Fatalf("turns = %+v", turns)
+	}
+}
+
+func TestStationAIAnthropicProviderChat(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "secret" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("missing anthropic auth headers: %v", r.Header)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"id":"msg_1","model":"claude-x","content":[{"type":"text","text":"Hello"}],"usage":{"input_tokens":12,"output_tokens":3}}`))
+	}))
+	defer srv.Close()
+
+	p := &stationAIAnthropicProvider{endpoint: srv.URL}
+	reply, err := p.Chat(context.Background(), stationAIChatRequestPayload{
+		APIKey: "secret", Model: "claude-x", MaxTokens: 100, Temperature: 1.5,
+	}, []map[string]string{
+		{"role": "system", "content": "sys"},
+		{"role": "user", "content": "hi"},
+	})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if got["system"] != "sys" || got["temperature"] != 1.0 {
+		t.Fatalf("request body = %+v", got)
+	}
+	if msgs, _ := got["messages"].([]interface{}); len(msgs) != 1 {
+		t.Fatalf("messages = %+v, want only the user turn", got["messages"])
+	}
+	if reply.Answer != "Hello" || reply.ProviderID != "msg_1" {
+		t.Fatalf("reply = %+v", reply)
+	}
+	if p, c, total := stationAIUsageTokenInts(reply.Usage); p != 12 || c != 3 || total != 15 {
+		t.Fatalf("usage = %d/%d/%d, want 12/3/15", p, c, total)
+	}
+}
+
+func TestStationAIOpenAIProviderChatError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer k" {
+			t.Errorf("authorization = %q", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"message":"bad key"}}`))
+	}))
+	defer srv.Close()
+
+	p := &stationAIOpenAIProvider{endpoint: srv.URL}
+	_, err := p.Chat(context.Background(), stationAIChatRequestPayload{APIKey: "k", Model: "m"}, nil)
+	if err == nil || err.Error() != "bad key" {
+		t.Fatalf("err = %v, want bad key", err)
+	}
+}
+
+func TestNormalizeStationAIChatRequestOllama(t *testing.T) {
+	req := stationAIChatRequestPayload{Provider: "ollama", Model: "llama3.1", UserMessage: "hi"}
+	if _, _, _, validationErr := normalizeStationAIChatRequest(&req); validationErr != "" {
+		t.Fatalf("ollama without api key: %q", validationErr)
+	}
+	if req.BaseURL != defaultOllamaChatURL {
+		t.Fatalf("base url = %q, want default", req.BaseURL)
+	}
+
+	for _, tc := range []struct {
+		url  string
+		want string
+	}{
+		{"http://127.0.0.1:8080/v1/chat/completions", ""},
+		{"http://[::1]:11434/v1/chat/completions", ""},
+		{"http://192.168.1.10:11434/v1/chat/completions", "provider_base_url must point to localhost"},
+		{"http://localhost.evil.example/v1", "provider_base_url must point to localhost"},
+		{"file:///etc/passwd", "invalid provider_base_url"},
+		{"http://user:pw@localhost:11434/", "provider_base_url must not contain credentials"},
+	} {
+		req := stationAIChatRequestPayload{Provider: "ollama", BaseURL: tc.url, Model: "m", UserMessage: "hi"}
+		if _, _, _, validationErr := normalizeStationAIChatRequest(&req); validationErr != tc.want {
+			t.Errorf("%s: validation error = %q, want %q", tc.url, validationErr, tc.want)
+		}
+	}
+
+	cloud := stationAIChatRequestPayload{Provider: "openai", BaseURL: "http://localhost:1/", APIKey: "k", Model: "m", UserMessage: "hi"}
+	if _, _, _, validationErr := normalizeStationAIChatRequest(&cloud); validationErr != "" || cloud.BaseURL != "" {
+		t.Fatalf("cloud providers should ignore provider_base_url: err=%q url=%q", validationErr, cloud.BaseURL)
+	}
+}
+
+func TestStationAIOllamaProviderSendsNoAuthorization(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("unexpected authorization header %q", auth)
+		}
+		_, _ = w.Write([]byte(`{"model":"llama3.1","choices":[{"message":{"content":"local"}}]}`))
+	}))
+	defer srv.Close()
+
+	provider, ok := stationAIProviderFor(stationAIChatRequestPayload{Provider: "ollama", BaseURL: srv.URL})
+	if !ok {
+		t.Fatal("ollama provider not registered")
+	}
+	reply, err := provider.Chat(context.Background(), stationAIChatRequestPayload{Model: "llama3.1"}, nil)
+	if err != nil || reply.Answer != "local" {
+		t.Fatalf("reply=%+v err=%v", reply, err)
+	}
+}
+
+func TestStationAIProviderName(t *testing.T) {
+	for provider, want := range map[string]string{
+		stationAIProviderOpenRouter: "OpenRouter",
+		stationAIProviderAnthropic:  "Anthropic",
+		stationAIProviderOllama:     "Ollama",
+	} {
+		if got := stationAIProviderName(provider); got != want {
+			t.Errorf("stationAIProviderName(%q) = %q, want %q", provider, got, want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// stationAIStreamHeartbeatInterval is how often an idle AI chat stream sends
+// a keepalive. Reverse proxies commonly drop responses that stay silent for
+// 30-60 seconds, which a long planner pass or model think easily exceeds.
+const stationAIStreamHeartbeatInterval = 15 * time.Second
+
+// stationAIStreamKeepalive is an SSE-style comment line. It is not JSON, so
+// NDJSON readers must skip lines starting with ':'.
+const stationAIStreamKeepalive = ": keepalive\n"
+
+// stationAIStreamWriter serializes NDJSON lines and keepalives onto one
+// response so the heartbeat goroutine never interleaves with a frame.
+type stationAIStreamWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (sw *stationAIStreamWriter) writeLine(line []byte) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if _, err := sw.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	sw.flusher.Flush()
+	return nil
+}
+
+// startHeartbeat writes a keepalive every interval until the returned stop
+// is called. stop is idempotent and returns only after the goroutine exits,
+// so nothing is written once the handler has returned.
+func (sw *stationAIStreamWriter) startHeartbeat(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				sw.mu.Lock()
+				_, err := io.WriteString(sw.w, stationAIStreamKeepalive)
+				if err == nil {
+					sw.flusher.Flush()
+				}
+				sw.mu.Unlock()
+				if err != nil {
+					// The client is gone; the handler notices on its next write.
+					return
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-exited
+		})
+	}
+}
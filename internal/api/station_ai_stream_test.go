@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStationAIStreamWriter_HeartbeatUntilStopped(t *testing.T) {
+	rr := httptest.NewRecorder()
+	sw := &stationAIStreamWriter{w: rr, flusher: rr}
+
+	stop := sw.startHeartbeat(5 * time.Millisecond)
+	time.Sleep(40 * time.Millisecond)
+	if err := sw.writeLine([]byte(`{"type":"delta","delta":"hi"}`)); err != nil {
+		t.Fatalf("writeLine: %v", err)
+	}
+	stop()
+	stop() // idempotent
+
+	sw.mu.Lock()
+	before := rr.Body.String()
+	sw.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	if after := rr.Body.String(); after != before {
+		t.Fatalf("wrote after stop: %q", strings.TrimPrefix(after, before))
+	}
+
+	keepalives, frames := 0, 0
+	for _, line := range strings.Split(strings.TrimSuffix(before, "\n"), "\n") {
+		switch {
+		case line+"\n" == stationAIStreamKeepalive:
+			keepalives++
+		case json.Valid([]byte(line)):
+			frames++
+		default:
+			t.Fatalf("interleaved or malformed line %q", line)
+		}
+	}
+	if keepalives == 0 || frames != 1 {
+		t.Fatalf("keepalives=%d frames=%d, want >0 and 1", keepalives, frames)
+	}
+}
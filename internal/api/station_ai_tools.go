@@ -0,0 +1,403 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"time"
+
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/engine"
+)
+
+// stationAIToolMaxCalls caps how many tools one chat turn may run.
+const stationAIToolMaxCalls = 2
+
+// stationAIToolScanTopRows is how many scan rows are handed back to the model.
+const stationAIToolScanTopRows = 8
+
+// stationAIToolMaxRadius bounds run_station_scan so a chat turn cannot start
+// a scan far heavier than the user would launch from the tab.
+const stationAIToolMaxRadius = 5
+
+// stationAIToolCall is one tool invocation requested by the model.
+type stationAIToolCall struct {
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// stationAIToolResult is reported back to the model and, via the pipeline
+// meta, to the client.
+type stationAIToolResult struct {
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	OK        bool            `json:"ok"`
+	Error     string          `json:"error,omitempty"`
+	Result    interface{}     `json:"result,omitempty"`
+}
+
+var stationAIToolDescriptions = map[string]string{
+	config.AIToolRunStationScan: `run_station_scan {"system_name": string, "radius": int 0-5, "station_id": int, "min_margin": number} - ` +
+		`runs a station trading scan with the user's saved fees and returns the most profitable rows. ` +
+		`station_id scans one station; otherwise radius > 0 scans around system_name and radius 0 its whole region. Omitted fields use the user's settings.`,
+	config.AIToolOpenMarket: `open_market {"type_id": int} or {"type_name": string} - opens the item's market window in the user's EVE client.`,
+}
+
+// stationAIAllowedTools returns the tools the model may call this turn: only
+// for trading questions, only with ai_tools_enabled, and only those on the
+// user's allowlist. Every tool uses ESI on the user's behalf, so none are
+// offered without a logged-in character.
+func (s *Server) stationAIAllowedTools(userID string, intent stationAIIntentKind) []string {
+	if intent != stationAIIntentTrading {
+		return nil
+	}
+	cfg := s.loadConfigForUser(userID)
+	if cfg == nil || !cfg.AIToolsEnabled {
+		return nil
+	}
+	if s.sessions == nil || s.sessions.GetForUser(userID) == nil {
+		return nil
+	}
+	tools := make([]string, 0, len(cfg.AIToolAllowlist))
+	for _, name := range cfg.AIToolAllowlist {
+		if _, known := stationAIToolDescriptions[name]; known {
+			tools = append(tools, name)
+		}
+	}
+	return tools
+}
+
+func stationAIToolSystemPrompt(tools []string) string {
+	var b strings.Builder
+	b.WriteString("You are the tool agent for EVE Flipper. Decide whether answering the user's trading question needs a fresh scan or an in-game action. ")
+	fmt.Fprintf(&b, "Return JSON ONLY, no markdown: {\"calls\": [{\"tool\": name, \"arguments\": {...}}]} with at most %d calls, or {\"calls\": []} when the visible context is enough. ", stationAIToolMaxCalls)
+	b.WriteString("Only call tools the user asked for or clearly needs. Available tools:\n")
+	for _, name := range tools {
+		b.WriteString("- " + stationAIToolDescriptions[name] + "\n")
+	}
+	return b.String()
+}
+
+// parseStationAIToolCalls extracts the calls from the tool agent's reply,
+// dropping tools that are not allowed and anything past the per-turn cap.
+func parseStationAIToolCalls(answer string, allowed []string) ([]stationAIToolCall, error) {
+	jsonBlock := aiExtractJSONObject(answer)
+	if jsonBlock == "" {
+		return nil, fmt.Errorf("tool agent did not return json")
+	}
+	var payload struct {
+		Calls []stationAIToolCall `json:"calls"`
+	}
+	if err := json.Unmarshal([]byte(jsonBlock), &payload); err != nil {
+		return nil, fmt.Errorf("tool agent json parse failed: %w", err)
+	}
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		allowedSet[name] = true
+	}
+	calls := make([]stationAIToolCall, 0, len(payload.Calls))
+	for _, call := range payload.Calls {
+		call.Tool = strings.ToLower(strings.TrimSpace(call.Tool))
+		if !allowedSet[call.Tool] || len(calls) >= stationAIToolMaxCalls {
+			continue
+		}
+		if len(call.Arguments) == 0 || string(call.Arguments) == "null" {
+			call.Arguments = json.RawMessage("{}")
+		}
+		calls = append(calls, call)
+	}
+	return calls, nil
+}
+
+// stationAIToolPass asks the model which allowed tools to run for this turn
+// and runs them. Failures never fail the chat: they become warnings or
+// unsuccessful results the final answer can mention.
+func (s *Server) stationAIToolPass(ctx context.Context, userID string, req stationAIChatRequestPayload, tools []string) ([]stationAIToolResult, []string) {
+	toolReq := req
+	toolReq.Model = stationAIResolvePlannerModel(req)
+	toolReq.Temperature = 0
+	toolReq.MaxTokens = 220
+	messages := []map[string]string{
+		{"role": "system", "content": stationAIToolSystemPrompt(tools)},
+		{"role": "user", "content": "User message:\n" + req.UserMessage + "\n\nTab context summary:\n" + stationAIPlannerContextSnippet(req.Context) + "\nReturn JSON only."},
+	}
+	passCtx, cancel := context.WithTimeout(ctx, 35*time.Second)
+	defer cancel()
+	reply, err := s.stationAIChatOnce(passCtx, toolReq, messages)
+	if err != nil {
+		return nil, []string{"tool agent unavailable, answering without tools"}
+	}
+	s.recordStationAIUsage(userID, toolReq, "tools", reply.Model, reply.Usage)
+	calls, err := parseStationAIToolCalls(reply.Answer, tools)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+
+	results := make([]stationAIToolResult, 0, len(calls))
+	for _, call := range calls {
+		result := stationAIToolResult{Tool: call.Tool, Arguments: call.Arguments}
+		var out interface{}
+		switch call.Tool {
+		case config.AIToolRunStationScan:
+			out, err = s.stationAIToolRunStationScan(ctx, userID, call.Arguments)
+		case config.AIToolOpenMarket:
+			out, err = s.stationAIToolOpenMarket(ctx, call.Arguments)
+		}
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.OK = true
+			result.Result = out
+		}
+		log.Printf("[AI][TOOLS] tool=%s ok=%t err=%q", call.Tool, result.OK, result.Error)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// stationAIToolResultsBlock renders tool results for the final answer prompt.
+func stationAIToolResultsBlock(locale string, results []stationAIToolResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(stationAIRuntimeLocaleText(locale,
+		"Tool results (executed by the server for this question; cite them as [TOOL N]):",
+		"Результаты инструментов (выполнены сервером для этого вопроса; ссылайся как [TOOL N]):"))
+	for i, r := range results {
+		payload, _ := json.Marshal(r)
+		fmt.Fprintf(&b, "\n[TOOL %d] %s", i+1, aiTrimForPrompt(string(payload), 4000))
+	}
+	return b.String()
+}
+
+// invokeInternal runs one of the server's own handlers for the current user,
+// so tools get exactly the validation, gating and side effects the UI does.
+func invokeInternal(ctx context.Context, handler http.HandlerFunc, method, path string, body interface{}) (*httptest.ResponseRecorder, error) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	r, err := http.NewRequestWithContext(ctx, method, path, bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler(rec, r)
+	return rec, nil
+}
+
+// internalErrorMessage pulls the message out of a writeError body.
+func internalErrorMessage(rec *httptest.ResponseRecorder) string {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(rec.Body.Bytes(), &body) == nil && body.Error != "" {
+		return body.Error
+	}
+	return fmt.Sprintf("http %d", rec.Code)
+}
+
+type stationAIToolScanRow struct {
+	TypeID        int32   `json:"type_id"`
+	TypeName      string  `json:"type_name"`
+	StationName   string  `json:"station_name"`
+	BuyPrice      float64 `json:"buy_price"`
+	SellPrice     float64 `json:"sell_price"`
+	MarginPercent float64 `json:"margin_percent"`
+	DailyProfit   float64 `json:"daily_profit"`
+	DailyVolume   int64   `json:"daily_volume"`
+}
+
+type stationAIToolScanResult struct {
+	ScanID int64                  `json:"scan_id"`
+	Count  int                    `json:"count"`
+	Top    []stationAIToolScanRow `json:"top"`
+}
+
+// stationAIToolRunStationScan builds a station scan request from the user's
+// saved settings plus the model's arguments and runs it through the regular
+// scan handler, so it is saved to history like any other scan.
+func (s *Server) stationAIToolRunStationScan(ctx context.Context, userID string, rawArgs json.RawMessage) (*stationAIToolScanResult, error) {
+	var args struct {
+		SystemName string   `json:"system_name"`
+		Radius     int      `json:"radius"`
+		StationID  int64    `json:"station_id"`
+		MinMargin  *float64 `json:"min_margin"`
+	}
+	if err := json.Unmarshal(rawArgs, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if !s.isReady() {
+		return nil, errSDENotLoaded
+	}
+	cfg := s.loadConfigForUser(userID)
+
+	systemName := strings.TrimSpace(args.SystemName)
+	if systemName == "" {
+		systemName = cfg.SystemName
+	}
+	radius := args.Radius
+	if radius < 0 {
+		radius = 0
+	}
+	if radius > stationAIToolMaxRadius {
+		radius = stationAIToolMaxRadius
+	}
+	minMargin := cfg.MinMargin
+	if args.MinMargin != nil && *args.MinMargin >= 0 {
+		minMargin = *args.MinMargin
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	var regionID int32
+	if args.StationID > 0 {
+		st, ok := sdeData.Stations[args.StationID]
+		if !ok {
+			return nil, fmt.Errorf("unknown station_id %d", args.StationID)
+		}
+		if sys, ok := sdeData.Systems[st.SystemID]; ok {
+			regionID = sys.RegionID
+		}
+		radius = 0
+	} else {
+		systemID, ok := sdeData.SystemByName[strings.ToLower(systemName)]
+		if !ok {
+			return nil, fmt.Errorf("unknown system %q", systemName)
+		}
+		if sys, ok := sdeData.Systems[systemID]; ok {
+			regionID = sys.RegionID
+		}
+	}
+
+	body := map[string]interface{}{
+		"station_id":              args.StationID,
+		"region_id":               regionID,
+		"system_name":             systemName,
+		"radius":                  radius,
+		"min_margin":              minMargin,
+		"sales_tax_percent":       cfg.SalesTaxPercent,
+		"broker_fee":              cfg.BrokerFeePercent,
+		"split_trade_fees":        cfg.SplitTradeFees,
+		"buy_broker_fee_percent":  cfg.BuyBrokerFeePercent,
+		"sell_broker_fee_percent": cfg.SellBrokerFeePercent,
+		"buy_sales_tax_percent":   cfg.BuySalesTaxPercent,
+		"sell_sales_tax_percent":  cfg.SellSalesTaxPercent,
+		"min_daily_volume":        cfg.MinDailyVolume,
+		"min_item_profit":         cfg.MinItemProfit,
+		"avg_price_period":        cfg.AvgPricePeriod,
+	}
+	rec, err := invokeInternal(ctx, s.gatedScan("station", s.handleScanStation), http.MethodPost, "/api/scan/station", body)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Code != http.StatusOK {
+		return nil, fmt.Errorf("scan rejected: %s", internalErrorMessage(rec))
+	}
+	return parseStationScanStream(rec.Body.Bytes())
+}
+
+// parseStationScanStream reads the NDJSON written by handleScanStation and
+// summarizes its result frame.
+func parseStationScanStream(stream []byte) (*stationAIToolScanResult, error) {
+	sc := bufio.NewScanner(bytes.NewReader(stream))
+	sc.Buffer(make([]byte, 0, 64*1024), len(stream)+1)
+	for sc.Scan() {
+		var frame struct {
+			Type    string                `json:"type"`
+			Message string                `json:"message"`
+			Data    []engine.StationTrade `json:"data"`
+			Count   int                   `json:"count"`
+			ScanID  int64                 `json:"scan_id"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &frame); err != nil {
+			continue
+		}
+		switch frame.Type {
+		case "error":
+			return nil, fmt.Errorf("scan failed: %s", frame.Message)
+		case "result":
+			rows := frame.Data
+			sort.SliceStable(rows, func(i, j int) bool {
+				return stationTradeKPIProfit(rows[i]) > stationTradeKPIProfit(rows[j])
+			})
+			if len(rows) > stationAIToolScanTopRows {
+				rows = rows[:stationAIToolScanTopRows]
+			}
+			out := &stationAIToolScanResult{ScanID: frame.ScanID, Count: frame.Count, Top: make([]stationAIToolScanRow, 0, len(rows))}
+			for _, row := range rows {
+				out.Top = append(out.Top, stationAIToolScanRow{
+					TypeID:        row.TypeID,
+					TypeName:      row.TypeName,
+					StationName:   row.StationName,
+					BuyPrice:      row.BuyPrice,
+					SellPrice:     row.SellPrice,
+					MarginPercent: row.MarginPercent,
+					DailyProfit:   stationTradeKPIProfit(row),
+					DailyVolume:   row.DailyVolume,
+				})
+			}
+			return out, nil
+		}
+	}
+	return nil, fmt.Errorf("scan ended without a result")
+}
+
+// stationAIToolOpenMarket opens a market window through the regular UI
+// handler, which checks the login and the open-window scope.
+func (s *Server) stationAIToolOpenMarket(ctx context.Context, rawArgs json.RawMessage) (map[string]interface{}, error) {
+	var args struct {
+		TypeID   int32  `json:"type_id"`
+		TypeName string `json:"type_name"`
+	}
+	if err := json.Unmarshal(rawArgs, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if !s.isReady() {
+		return nil, errSDENotLoaded
+	}
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	typeID, _, err := resolveExecutionPlanIDs(sdeData, args.TypeID, args.TypeName, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	if typeID <= 0 {
+		return nil, fmt.Errorf("type_id or type_name is required")
+	}
+	rec, err := invokeInternal(ctx, s.handleUIOpenMarket, http.MethodPost, "/api/ui/open-market", map[string]int32{"type_id": typeID})
+	if err != nil {
+		return nil, err
+	}
+	if rec.Code != http.StatusOK {
+		return nil, fmt.Errorf("open market failed: %s", internalErrorMessage(rec))
+	}
+	out := map[string]interface{}{"opened": true, "type_id": typeID}
+	if t, ok := sdeData.Types[typeID]; ok {
+		out["type_name"] = t.Name
+	}
+	return out, nil
+}
+
+// stationAIApplyTools runs the tool pass when any tool is allowed and records
+// it in the pipeline meta. It returns the prompt block for the final answer.
+func (s *Server) stationAIApplyTools(ctx context.Context, userID string, req stationAIChatRequestPayload, intent stationAIIntentKind, pipeline map[string]interface{}) (string, []string) {
+	tools := s.stationAIAllowedTools(userID, intent)
+	if len(tools) == 0 {
+		return "", nil
+	}
+	results, warnings := s.stationAIToolPass(ctx, userID, req, tools)
+	pipeline["tool_calls"] = results
+	return stationAIToolResultsBlock(req.Locale, results), warnings
+}
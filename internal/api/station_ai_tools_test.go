@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"eve-flipper/internal/auth"
+	"eve-flipper/internal/config"
+)
+
+func TestStationAIAllowedTools(t *testing.T) {
+	database := openAPITestDB(t)
+	userID := "user-ai-tools"
+	srv := &Server{db: database}
+
+	cfg := database.LoadConfigForUser(userID)
+	cfg.AIToolAllowlist = []string{config.AIToolOpenMarket}
+	if err := database.SaveConfigForUser(userID, cfg); err != nil {
+		t.Fatalf("SaveConfigForUser: %v", err)
+	}
+	if tools := srv.stationAIAllowedTools(userID, stationAIIntentTrading); tools != nil {
+		t.Fatalf("tools with ai_tools_enabled off = %v", tools)
+	}
+
+	cfg.AIToolsEnabled = true
+	if err := database.SaveConfigForUser(userID, cfg); err != nil {
+		t.Fatalf("SaveConfigForUser: %v", err)
+	}
+	srv.sessions = auth.NewSessionStore(database.SqlDB())
+	if tools := srv.stationAIAllowedTools(userID, stationAIIntentTrading); tools != nil {
+		t.Fatalf("tools while logged out = %v", tools)
+	}
+
+	if err := srv.sessions.SaveAndActivateForUser(userID, &auth.Session{
+		CharacterID:   90000001,
+		CharacterName: "Test Pilot",
+		AccessToken:   "test-access-token",
+		RefreshToken:  "test-refresh-token",
+		ExpiresAt:     time.Now().Add(2 * time.Hour),
+	}); err != nil {
+		t.Fatalf("SaveAndActivateForUser: %v", err)
+	}
+	if tools := srv.stationAIAllowedTools(userID, stationAIIntentTrading); !reflect.DeepEqual(tools, []string{config.AIToolOpenMarket}) {
+		t.Fatalf("tools = %v, want only the allowlisted tool", tools)
+	}
+	if tools := srv.stationAIAllowedTools(userID, stationAIIntentProduct); tools != nil {
+		t.Fatalf("tools for product help = %v", tools)
+	}
+}
+
+func TestParseStationAIToolCalls(t *testing.T) {
+	allowed := []string{config.AIToolRunStationScan, config.AIToolOpenMarket}
+	answer := "```json\n{\"calls\":[" +
+		"{\"tool\":\"Run_Station_Scan\",\"arguments\":{\"system_name\":\"Jita\",\"radius\":1}}," +
+		"{\"tool\":\"sell_everything\",\"arguments\":{}}," +
+		"{\"tool\":\"open_market\"}," +
+		"{\"tool\":\"open_market\",\"arguments\":{\"type_id\":34}}]}\n```"
+
+	calls, err := parseStationAIToolCalls(answer, allowed)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(calls) != stationAIToolMaxCalls {
+		t.Fatalf("calls = %d, want cap %d", len(calls), stationAIToolMaxCalls)
+	}
+	if calls[0].Tool != config.AIToolRunStationScan || calls[1].Tool != config.AIToolOpenMarket {
+		t.Fatalf("tools = %q, %q", calls[0].Tool, calls[1].Tool)
+	}
+	if string(calls[1].Arguments) != "{}" {
+		t.Fatalf("missing arguments = %s, want {}", calls[1].Arguments)
+	}
+
+	if calls, err := parseStationAIToolCalls(`{"calls":[{"tool":"open_market","arguments":{}}]}`, []string{config.AIToolRunStationScan}); err != nil || len(calls) != 0 {
+		t.Fatalf("non-allowlisted tool: calls=%v err=%v", calls, err)
+	}
+	if _, err := parseStationAIToolCalls("no tools needed", allowed); err == nil {
+		t.Fatal("expected error for non-json reply")
+	}
+}
+
+func TestParseStationScanStream(t *testing.T) {
+	rows := make([]map[string]interface{}, 0, 10)
+	for i := 1; i <= 10; i++ {
+		rows = append(rows, map[string]interface{}{"TypeID": i, "TypeName": "Item", "DailyProfit": float64(i * 1000)})
+	}
+	result, _ := json.Marshal(map[string]interface{}{"type": "result", "data": rows, "count": 10, "scan_id": 42})
+	stream := `{"type":"progress","message":"Fetching orders"}` + "\n" + string(result) + "\n"
+
+	got, err := parseStationScanStream([]byte(stream))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got.ScanID != 42 || got.Count != 10 || len(got.Top) != stationAIToolScanTopRows {
+		t.Fatalf("summary = scan %d count %d top %d", got.ScanID, got.Count, len(got.Top))
+	}
+	if got.Top[0].TypeID != 10 || got.Top[0].DailyProfit != 10000 {
+		t.Fatalf("top row = %+v, want most profitable first", got.Top[0])
+	}
+
+	if _, err := parseStationScanStream([]byte(`{"type":"error","message":"region_id is required"}` + "\n")); err == nil || !strings.Contains(err.Error(), "region_id") {
+		t.Fatalf("error frame: %v", err)
+	}
+}
+
+func TestStationAIToolResultsBlock(t *testing.T) {
+	if block := stationAIToolResultsBlock("en", nil); block != "" {
+		t.Fatalf("empty results block = %q", block)
+	}
+	block := stationAIToolResultsBlock("en", []stationAIToolResult{{Tool: config.AIToolOpenMarket, Error: "not logged in"}})
+	if !strings.Contains(block, "[TOOL 1]") || !strings.Contains(block, "not logged in") {
+		t.Fatalf("block = %q", block)
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"eve-flipper/internal/db"
+)
+
+const maxStationAIUsageDays = 365
+
+func (s *Server) handleAuthStationAIUsage(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	days := 30
+	if raw := strings.TrimSpace(r.URL.Query().Get("days")); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			writeError(w, 400, "invalid days")
+			return
+		}
+		if v > maxStationAIUsageDays {
+			v = maxStationAIUsageDays
+		}
+		days = v
+	}
+	summary, err := s.db.GetAIUsageSummaryForUser(userIDFromRequest(r), days)
+	if err != nil {
+		writeError(w, 500, "failed to load ai usage")
+		return
+	}
+	writeJSON(w, summary)
+}
+
+// recordStationAIUsage persists the token usage reported for one provider
+// call. Calls without reported usage are skipped rather than guessed.
+func (s *Server) recordStationAIUsage(userID string, req stationAIChatRequestPayload, kind, model string, usage map[string]interface{}) {
+	if s.db == nil {
+		return
+	}
+	prompt, completion, total := stationAIUsageTokenInts(usage)
+	if total <= 0 && prompt+completion <= 0 {
+		return
+	}
+	if strings.TrimSpace(model) == "" {
+		model = req.Model
+	}
+	err := s.db.RecordAIUsageForUser(userID, db.AIUsageRecord{
+		Provider:         req.Provider,
+		Model:            model,
+		Kind:             kind,
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      total,
+		CostUSD:          stationAIUsageCost(usage),
+	})
+	if err != nil {
+		log.Printf("[AI][CHAT] record usage: %v", err)
+	}
+}
+
+// stationAIUsageCost returns usage.cost (USD) when the provider reports it.
+func stationAIUsageCost(usage map[string]interface{}) float64 {
+	if v, ok := usage["cost"].(float64); ok && v > 0 {
+		return v
+	}
+	return 0
+}
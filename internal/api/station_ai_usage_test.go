@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleAuthStationAIUsage(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+	const userID = "user-ai-usage"
+	req := stationAIChatRequestPayload{Provider: "openrouter", Model: "openai/gpt-4o-mini"}
+
+	srv.recordStationAIUsage(userID, req, "planner", "", map[string]interface{}{
+		"prompt_tokens": 80.0, "completion_tokens": 20.0, "total_tokens": 100.0,
+	})
+	srv.recordStationAIUsage(userID, req, "chat", "openai/gpt-4o-mini-2024", map[string]interface{}{
+		"prompt_tokens": 1000, "completion_tokens": 200, "total_tokens": 1200, "cost": 0.0015,
+	})
+	// No reported usage: nothing is stored.
+	srv.recordStationAIUsage(userID, req, "chat", "", nil)
+
+	rec := httptest.NewRecorder()
+	srv.handleAuthStationAIUsage(rec, requestWithUserID(http.MethodGet, "/api/auth/station/ai/usage?days=7", nil, userID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Days   int `json:"days"`
+		Totals struct {
+			Requests    int     `json:"requests"`
+			TotalTokens int64   `json:"total_tokens"`
+			CostUSD     float64 `json:"cost_usd"`
+		} `json:"totals"`
+		ByModel []struct {
+			Model string `json:"model"`
+		} `json:"by_model"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Days != 7 || resp.Totals.Requests != 2 || resp.Totals.TotalTokens != 1300 || resp.Totals.CostUSD != 0.0015 {
+		t.Fatalf("response = %s", rec.Body.String())
+	}
+	if len(resp.ByModel) != 2 || resp.ByModel[0].Model != "openai/gpt-4o-mini-2024" {
+		t.Fatalf("by_model = %+v", resp.ByModel)
+	}
+
+	bad := httptest.NewRecorder()
+	srv.handleAuthStationAIUsage(bad, requestWithUserID(http.MethodGet, "/api/auth/station/ai/usage?days=-1", nil, userID))
+	if bad.Code != http.StatusBadRequest {
+		t.Fatalf("days=-1 status = %d, want 400", bad.Code)
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStationAIWebCacheKey(t *testing.T) {
+	a := stationAIWebCacheKey("en", "What is best margin in Jita?", stationAIIntentResearch)
+	b := stationAIWebCacheKey("en", "jita margin best", stationAIIntentResearch)
+	if a != b {
+		t.Fatalf("reordered terms produced different keys: %q vs %q", a, b)
+	}
+	if a == stationAIWebCacheKey("ru", "jita margin best", stationAIIntentResearch) {
+		t.Error("locale not part of the key")
+	}
+	if a == stationAIWebCacheKey("en", "jita margin best", stationAIIntentTrading) {
+		t.Error("intent not part of the key")
+	}
+}
+
+func TestStationAIWebSnippetsServedFromCache(t *testing.T) {
+	const msg = "plex price trend forecast"
+	key := stationAIWebCacheKey("en", msg, stationAIIntentResearch)
+	t.Cleanup(func() { aiWebSnippetCache.Delete(key) })
+
+	storeStationAIWebCache(key, []aiKnowledgeSnippet{{SourceLabel: "WEB", Title: "PLEX", Content: "cached"}})
+	got, warnings, cached := stationAIWebSnippets(context.Background(), "en", msg, stationAIIntentResearch)
+	if !cached || len(warnings) != 0 || len(got) != 1 || got[0].Content != "cached" {
+		t.Fatalf("got %+v warnings %v cached %v", got, warnings, cached)
+	}
+	got[0].Content = "mutated"
+	if again, _, _ := stationAIWebSnippets(context.Background(), "en", msg, stationAIIntentResearch); again[0].Content != "cached" {
+		t.Error("caller mutation leaked into the cache")
+	}
+
+	// Expired entries are dropped rather than served.
+	aiWebSnippetCache.Store(key, stationAIWebCacheEntry{
+		Snippets:  []aiKnowledgeSnippet{{Content: "stale"}},
+		FetchedAt: time.Now().Add(-stationAIWebCacheTTL - time.Minute),
+	})
+	storeStationAIWebCache("other", nil)
+	t.Cleanup(func() { aiWebSnippetCache.Delete("other") })
+	if _, ok := aiWebSnippetCache.Load(key); ok {
+		t.Error("expired entry survived a store")
+	}
+}
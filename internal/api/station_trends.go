@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"eve-flipper/internal/db"
+	"eve-flipper/internal/engine"
+)
+
+// recordStationTrends stores the day's snapshot of a finished station scan
+// for users with station_trends_enabled.
+func (s *Server) recordStationTrends(userID string, results []engine.StationTrade) {
+	if s.db == nil || len(results) == 0 {
+		return
+	}
+	points := make([]db.StationTrendPoint, 0, len(results))
+	for _, r := range results {
+		if r.TypeID <= 0 || r.StationID <= 0 {
+			continue
+		}
+		points = append(points, db.StationTrendPoint{
+			RegionID:    r.RegionID,
+			StationID:   r.StationID,
+			TypeID:      r.TypeID,
+			CTS:         r.CTS,
+			DailyProfit: stationTradeKPIProfit(r),
+			Margin:      r.MarginPercent,
+		})
+	}
+	if err := s.db.RecordStationTrendsForUser(userID, points, time.Now()); err != nil {
+		log.Printf("[API] recordStationTrends: %v", err)
+	}
+}
+
+// handleStationTrends returns the daily CTS/profit/margin series of one item
+// from the user's recorded station scans.
+func (s *Server) handleStationTrends(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		writeErrorCode(w, 503, errCodeDatabaseUnavailable, "database unavailable")
+		return
+	}
+	q := r.URL.Query()
+	typeID, err := strconv.ParseInt(strings.TrimSpace(q.Get("type_id")), 10, 32)
+	if err != nil || typeID <= 0 {
+		writeError(w, 400, "type_id is required")
+		return
+	}
+	var stationID int64
+	if raw := strings.TrimSpace(q.Get("station_id")); raw != "" {
+		stationID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || stationID < 0 {
+			writeError(w, 400, "invalid station_id")
+			return
+		}
+	}
+	days := 14
+	if raw := strings.TrimSpace(q.Get("days")); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 || v > db.StationTrendsRetentionDays {
+			writeError(w, 400, fmt.Sprintf("days must be between 1 and %d", db.StationTrendsRetentionDays))
+			return
+		}
+		days = v
+	}
+
+	userID := userIDFromRequest(r)
+	points, err := s.db.ListStationTrendsForUser(userID, int32(typeID), stationID, time.Now().AddDate(0, 0, -(days-1)))
+	if err != nil {
+		writeError(w, 500, "failed to load station trends")
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"type_id":    typeID,
+		"station_id": stationID,
+		"days":       days,
+		"enabled":    s.loadConfigForUser(userID).StationTrendsEnabled,
+		"points":     points,
+	})
+}
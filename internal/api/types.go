@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type marketGroupRef struct {
+	MarketGroupID int32  `json:"market_group_id"`
+	Name          string `json:"name"`
+}
+
+// typeInfo is the SDE metadata of one type as served by GET /api/types/{typeID}.
+type typeInfo struct {
+	TypeID          int32            `json:"type_id"`
+	Name            string           `json:"name"`
+	Volume          float64          `json:"volume"` // packaged m³
+	GroupID         int32            `json:"group_id"`
+	GroupName       string           `json:"group_name"`
+	CategoryID      int32            `json:"category_id"`
+	CategoryName    string           `json:"category_name"`
+	MarketGroupID   int32            `json:"market_group_id"`
+	MarketGroupPath []marketGroupRef `json:"market_group_path"` // root first
+	MarketDisabled  bool             `json:"market_disabled"`
+}
+
+// handleGetType returns a type's SDE metadata so clients don't need their own
+// copy of the type dump. market_disabled reflects the caller's overrides.
+// GET /api/types/{typeID}
+func (s *Server) handleGetType(w http.ResponseWriter, r *http.Request) {
+	typeID, err := strconv.ParseInt(strings.TrimSpace(r.PathValue("typeID")), 10, 32)
+	if err != nil || typeID <= 0 {
+		writeErrorCode(w, 400, errCodeInvalidTypeID, "invalid type id")
+		return
+	}
+	if !s.isReady() {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+
+	t, ok := sdeData.Types[int32(typeID)]
+	if !ok {
+		writeErrorCode(w, 404, errCodeTypeNotFound, "type not found")
+		return
+	}
+	info := typeInfo{
+		TypeID:          t.ID,
+		Name:            t.Name,
+		Volume:          t.Volume,
+		GroupID:         t.GroupID,
+		CategoryID:      t.CategoryID,
+		MarketGroupID:   t.MarketGroupID,
+		MarketGroupPath: []marketGroupRef{},
+		MarketDisabled:  s.isMarketDisabledForUser(userIDFromRequest(r), t.ID),
+	}
+	if g, ok := sdeData.Groups[t.GroupID]; ok {
+		info.GroupName = g.Name
+	}
+	if c, ok := sdeData.Categories[t.CategoryID]; ok {
+		info.CategoryName = c.Name
+	}
+	for _, g := range sdeData.MarketGroupPath(t.MarketGroupID) {
+		info.MarketGroupPath = append(info.MarketGroupPath, marketGroupRef{MarketGroupID: g.ID, Name: g.Name})
+	}
+	writeJSON(w, info)
+}
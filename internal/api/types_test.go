@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"eve-flipper/internal/engine"
+	"eve-flipper/internal/sde"
+)
+
+func TestHandleGetType(t *testing.T) {
+	srv := &Server{
+		ready: true,
+		sdeData: &sde.Data{
+			Types: map[int32]*sde.ItemType{
+				587:               {ID: 587, Name: "Rifter", Volume: 2500, GroupID: 25, CategoryID: 6, MarketGroupID: 64},
+				engine.MPTCTypeID: {ID: engine.MPTCTypeID, Name: "Multiple Pilot Training Certificate"},
+			},
+			Groups:     map[int32]*sde.ItemGroup{25: {ID: 25, Name: "Frigate", CategoryID: 6}},
+			Categories: map[int32]*sde.ItemCategory{6: {ID: 6, Name: "Ship"}},
+			MarketGroups: map[int32]*sde.MarketGroup{
+				4:  {ID: 4, Name: "Ships"},
+				61: {ID: 61, Name: "Frigates", ParentID: 4},
+				64: {ID: 64, Name: "Minmatar", ParentID: 61},
+			},
+		},
+	}
+	get := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/types/"+id, nil)
+		req.SetPathValue("typeID", id)
+		rec := httptest.NewRecorder()
+		srv.handleGetType(rec, req)
+		return rec
+	}
+
+	rec := get("587")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var info typeInfo
+	if err := json.NewDecoder(rec.Body).Decode(&info); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if info.Name != "Rifter" || info.Volume != 2500 || info.GroupName != "Frigate" || info.CategoryName != "Ship" || info.MarketDisabled {
+		t.Fatalf("info = %+v", info)
+	}
+	if len(info.MarketGroupPath) != 3 || info.MarketGroupPath[0].Name != "Ships" || info.MarketGroupPath[2].MarketGroupID != 64 {
+		t.Fatalf("market_group_path = %+v, want Ships > Frigates > Minmatar", info.MarketGroupPath)
+	}
+
+	rec = get(strconv.Itoa(int(engine.MPTCTypeID)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("MPTC status = %d", rec.Code)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&info); err != nil || !info.MarketDisabled {
+		t.Fatalf("MPTC market_disabled = %v (err %v), want true", info.MarketDisabled, err)
+	}
+
+	if rec := get("999999"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown type status = %d, want 404", rec.Code)
+	}
+	if rec := get("abc"); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad id status = %d, want 400", rec.Code)
+	}
+	srv.ready = false
+	if rec := get("587"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("SDE not ready status = %d, want 503", rec.Code)
+	}
+}
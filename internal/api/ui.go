@@ -2,36 +2,55 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
+	"time"
+
+	"eve-flipper/internal/auth"
 )
 
-// handleUIOpenMarket opens a market window in the EVE client for the given type_id.
-// POST /api/ui/open-market
-// Body: {"type_id": 34}
-func (s *Server) handleUIOpenMarket(w http.ResponseWriter, r *http.Request) {
+// uiSession returns the active character and a fresh access token for the
+// in-game UI endpoints, writing a not_logged_in error when there is none and
+// a 403 when the character did not grant the UI scope the endpoint needs.
+func (s *Server) uiSession(w http.ResponseWriter, r *http.Request, scope string) (*auth.Session, string, bool) {
 	if s.sessions == nil {
-		http.Error(w, `{"error":"not_logged_in"}`, http.StatusUnauthorized)
-		return
+		writeErrorCode(w, http.StatusUnauthorized, errCodeNotLoggedIn, "not_logged_in")
+		return nil, "", false
 	}
 	userID := userIDFromRequest(r)
 	sess := s.sessions.GetForUser(userID)
 	if sess == nil || sess.AccessToken == "" {
-		http.Error(w, `{"error":"not_logged_in"}`, http.StatusUnauthorized)
-		return
+		writeErrorCode(w, http.StatusUnauthorized, errCodeNotLoggedIn, "not_logged_in")
+		return nil, "", false
+	}
+	if err := requireScope(sess, scope); err != nil {
+		writeScopeError(w, err)
+		return nil, "", false
 	}
 	token := strings.TrimSpace(sess.AccessToken)
 	if s.sso != nil {
 		refreshed, err := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
 		if err != nil {
-			http.Error(w, `{"error":"not_logged_in"}`, http.StatusUnauthorized)
-			return
+			writeErrorCode(w, http.StatusUnauthorized, errCodeNotLoggedIn, "not_logged_in")
+			return nil, "", false
 		}
 		token = strings.TrimSpace(refreshed)
 	}
 	if token == "" {
-		http.Error(w, `{"error":"not_logged_in"}`, http.StatusUnauthorized)
+		writeErrorCode(w, http.StatusUnauthorized, errCodeNotLoggedIn, "not_logged_in")
+		return nil, "", false
+	}
+	return sess, token, true
+}
+
+// handleUIOpenMarket opens a market window in the EVE client for the given type_id.
+// POST /api/ui/open-market
+// Body: {"type_id": 34}
+func (s *Server) handleUIOpenMarket(w http.ResponseWriter, r *http.Request) {
+	sess, token, ok := s.uiSession(w, r, auth.ScopeOpenWindow)
+	if !ok {
 		return
 	}
 
@@ -39,19 +58,19 @@ func (s *Server) handleUIOpenMarket(w http.ResponseWriter, r *http.Request) {
 		TypeID int64 `json:"type_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		writeErrorCode(w, http.StatusBadRequest, errCodeInvalidJSON, "invalid_request")
 		return
 	}
 
 	if req.TypeID <= 0 {
-		http.Error(w, `{"error":"invalid_type_id"}`, http.StatusBadRequest)
+		writeErrorCode(w, http.StatusBadRequest, errCodeInvalidTypeID, "invalid_type_id")
 		return
 	}
 
 	log.Printf("[API] OpenMarketWindow: type_id=%d, character_id=%d", req.TypeID, sess.CharacterID)
 	if err := s.esi.OpenMarketWindow(req.TypeID, token); err != nil {
 		log.Printf("[API] OpenMarketWindow error: type_id=%d, err=%v", req.TypeID, err)
-		http.Error(w, `{"error":"esi_error"}`, http.StatusInternalServerError)
+		writeErrorCode(w, http.StatusInternalServerError, errCodeESI, "esi_error")
 		return
 	}
 	log.Printf("[API] OpenMarketWindow success: type_id=%d", req.TypeID)
@@ -60,31 +79,89 @@ func (s *Server) handleUIOpenMarket(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"success":true}`))
 }
 
-// handleUISetWaypoint sets a waypoint in the EVE client.
-// POST /api/ui/set-waypoint
-// Body: {"solar_system_id": 30000142, "clear_other_waypoints": true, "add_to_beginning": false}
-func (s *Server) handleUISetWaypoint(w http.ResponseWriter, r *http.Request) {
-	if s.sessions == nil {
-		http.Error(w, `{"error":"not_logged_in"}`, http.StatusUnauthorized)
+// maxOpenMarketBatch caps POST /api/ui/open-market/batch.
+const maxOpenMarketBatch = 10
+
+// openMarketBatchDelay spaces consecutive market windows so the EVE client
+// does not drop requests that arrive back to back.
+const openMarketBatchDelay = 400 * time.Millisecond
+
+// openMarketResult reports whether one market window of a batch was opened.
+type openMarketResult struct {
+	TypeID int64  `json:"type_id"`
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+}
+
+// handleUIOpenMarketBatch opens market windows for several types in a row.
+// POST /api/ui/open-market/batch
+// Body: {"type_ids": [34, 35, 36]}
+func (s *Server) handleUIOpenMarketBatch(w http.ResponseWriter, r *http.Request) {
+	sess, token, ok := s.uiSession(w, r, auth.ScopeOpenWindow)
+	if !ok {
 		return
 	}
-	userID := userIDFromRequest(r)
-	sess := s.sessions.GetForUser(userID)
-	if sess == nil || sess.AccessToken == "" {
-		http.Error(w, `{"error":"not_logged_in"}`, http.StatusUnauthorized)
+
+	var req struct {
+		TypeIDs []int64 `json:"type_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorCode(w, http.StatusBadRequest, errCodeInvalidJSON, "invalid_request")
 		return
 	}
-	token := strings.TrimSpace(sess.AccessToken)
-	if s.sso != nil {
-		refreshed, err := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
-		if err != nil {
-			http.Error(w, `{"error":"not_logged_in"}`, http.StatusUnauthorized)
+	typeIDs := make([]int64, 0, len(req.TypeIDs))
+	seen := make(map[int64]bool, len(req.TypeIDs))
+	for _, id := range req.TypeIDs {
+		if id <= 0 {
+			writeErrorCode(w, http.StatusBadRequest, errCodeInvalidTypeID, "invalid_type_id")
 			return
 		}
-		token = strings.TrimSpace(refreshed)
+		if !seen[id] {
+			seen[id] = true
+			typeIDs = append(typeIDs, id)
+		}
 	}
-	if token == "" {
-		http.Error(w, `{"error":"not_logged_in"}`, http.StatusUnauthorized)
+	if len(typeIDs) == 0 {
+		writeErrorCode(w, http.StatusBadRequest, errCodeInvalidTypeID, "invalid_type_id")
+		return
+	}
+	if len(typeIDs) > maxOpenMarketBatch {
+		writeErrorCode(w, http.StatusBadRequest, errCodeTooManyTypeIDs, fmt.Sprintf("at most %d type_ids per request", maxOpenMarketBatch))
+		return
+	}
+
+	log.Printf("[API] OpenMarketWindow batch: types=%d, character_id=%d", len(typeIDs), sess.CharacterID)
+	results := make([]openMarketResult, 0, len(typeIDs))
+	success := true
+	for i, id := range typeIDs {
+		if i > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(openMarketBatchDelay):
+			}
+		}
+		res := openMarketResult{TypeID: id, OK: true}
+		if err := s.esi.OpenMarketWindow(id, token); err != nil {
+			log.Printf("[API] OpenMarketWindow error: type_id=%d, err=%v", id, err)
+			res.OK = false
+			res.Error = err.Error()
+			success = false
+		}
+		results = append(results, res)
+	}
+	writeJSON(w, map[string]interface{}{
+		"success": success,
+		"results": results,
+	})
+}
+
+// handleUISetWaypoint sets a waypoint in the EVE client.
+// POST /api/ui/set-waypoint
+// Body: {"solar_system_id": 30000142, "clear_other_waypoints": true, "add_to_beginning": false}
+func (s *Server) handleUISetWaypoint(w http.ResponseWriter, r *http.Request) {
+	sess, token, ok := s.uiSession(w, r, auth.ScopeWriteWaypoint)
+	if !ok {
 		return
 	}
 
@@ -94,12 +171,12 @@ func (s *Server) handleUISetWaypoint(w http.ResponseWriter, r *http.Request) {
 		AddToBeginning      bool  `json:"add_to_beginning"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		writeErrorCode(w, http.StatusBadRequest, errCodeInvalidJSON, "invalid_request")
 		return
 	}
 
 	if req.SolarSystemID <= 0 {
-		http.Error(w, `{"error":"invalid_solar_system_id"}`, http.StatusBadRequest)
+		writeErrorCode(w, http.StatusBadRequest, errCodeInvalidSystemID, "invalid_solar_system_id")
 		return
 	}
 
@@ -107,7 +184,7 @@ func (s *Server) handleUISetWaypoint(w http.ResponseWriter, r *http.Request) {
 		req.SolarSystemID, req.ClearOtherWaypoints, req.AddToBeginning, sess.CharacterID)
 	if err := s.esi.SetWaypoint(req.SolarSystemID, req.ClearOtherWaypoints, req.AddToBeginning, token); err != nil {
 		log.Printf("[API] SetWaypoint error: solar_system_id=%d, err=%v", req.SolarSystemID, err)
-		http.Error(w, `{"error":"esi_error"}`, http.StatusInternalServerError)
+		writeErrorCode(w, http.StatusInternalServerError, errCodeESI, "esi_error")
 		return
 	}
 	log.Printf("[API] SetWaypoint success: solar_system_id=%d", req.SolarSystemID)
@@ -116,31 +193,83 @@ func (s *Server) handleUISetWaypoint(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"success":true}`))
 }
 
-// handleUIOpenContract opens a contract window in the EVE client.
-// POST /api/ui/open-contract
-// Body: {"contract_id": 123456789}
-func (s *Server) handleUIOpenContract(w http.ResponseWriter, r *http.Request) {
-	if s.sessions == nil {
-		http.Error(w, `{"error":"not_logged_in"}`, http.StatusUnauthorized)
+// maxRouteWaypoints caps POST /api/ui/set-route; each hop is one ESI call.
+const maxRouteWaypoints = 50
+
+// routeWaypointResult reports whether one hop of a set-route call was set.
+type routeWaypointResult struct {
+	LocationID int64  `json:"location_id"`
+	OK         bool   `json:"ok"`
+	Error      string `json:"error,omitempty"`
+}
+
+// handleUISetRoute plots a whole route in the EVE client, one waypoint per hop.
+// POST /api/ui/set-route
+// Body: {"location_ids": [30000142, 60003760, 30002187], "clear_existing": true}
+func (s *Server) handleUISetRoute(w http.ResponseWriter, r *http.Request) {
+	sess, token, ok := s.uiSession(w, r, auth.ScopeWriteWaypoint)
+	if !ok {
 		return
 	}
-	userID := userIDFromRequest(r)
-	sess := s.sessions.GetForUser(userID)
-	if sess == nil || sess.AccessToken == "" {
-		http.Error(w, `{"error":"not_logged_in"}`, http.StatusUnauthorized)
+
+	var req struct {
+		LocationIDs   []int64 `json:"location_ids"`
+		ClearExisting bool    `json:"clear_existing"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorCode(w, http.StatusBadRequest, errCodeInvalidJSON, "invalid_request")
 		return
 	}
-	token := strings.TrimSpace(sess.AccessToken)
-	if s.sso != nil {
-		refreshed, err := s.sessions.EnsureValidTokenForUserCharacter(s.sso, userID, sess.CharacterID)
-		if err != nil {
-			http.Error(w, `{"error":"not_logged_in"}`, http.StatusUnauthorized)
+	if len(req.LocationIDs) == 0 || len(req.LocationIDs) > maxRouteWaypoints {
+		writeErrorCode(w, http.StatusBadRequest, errCodeInvalidLocationIDs, fmt.Sprintf("location_ids must hold 1-%d positive IDs", maxRouteWaypoints))
+		return
+	}
+	for _, id := range req.LocationIDs {
+		if id <= 0 {
+			writeErrorCode(w, http.StatusBadRequest, errCodeInvalidLocationIDs, fmt.Sprintf("location_ids must hold 1-%d positive IDs", maxRouteWaypoints))
 			return
 		}
-		token = strings.TrimSpace(refreshed)
 	}
-	if token == "" {
-		http.Error(w, `{"error":"not_logged_in"}`, http.StatusUnauthorized)
+
+	log.Printf("[API] SetRoute: hops=%d, clear=%t, character_id=%d", len(req.LocationIDs), req.ClearExisting, sess.CharacterID)
+	hops := setRouteWaypoints(req.LocationIDs, req.ClearExisting, func(locationID int64, clear bool) error {
+		return s.esi.SetWaypoint(locationID, clear, false, token)
+	})
+	success := true
+	for _, h := range hops {
+		if !h.OK {
+			success = false
+			log.Printf("[API] SetRoute hop failed: location_id=%d, err=%s", h.LocationID, h.Error)
+		}
+	}
+	writeJSON(w, map[string]interface{}{
+		"success": success,
+		"hops":    hops,
+	})
+}
+
+// setRouteWaypoints appends each location to the autopilot route in order.
+// Only the first hop may clear the existing route; a failed hop does not stop
+// the rest from being set.
+func setRouteWaypoints(locationIDs []int64, clearExisting bool, setWaypoint func(locationID int64, clear bool) error) []routeWaypointResult {
+	out := make([]routeWaypointResult, 0, len(locationIDs))
+	for i, id := range locationIDs {
+		res := routeWaypointResult{LocationID: id, OK: true}
+		if err := setWaypoint(id, clearExisting && i == 0); err != nil {
+			res.OK = false
+			res.Error = err.Error()
+		}
+		out = append(out, res)
+	}
+	return out
+}
+
+// handleUIOpenContract opens a contract window in the EVE client.
+// POST /api/ui/open-contract
+// Body: {"contract_id": 123456789}
+func (s *Server) handleUIOpenContract(w http.ResponseWriter, r *http.Request) {
+	sess, token, ok := s.uiSession(w, r, auth.ScopeOpenWindow)
+	if !ok {
 		return
 	}
 
@@ -148,19 +277,19 @@ func (s *Server) handleUIOpenContract(w http.ResponseWriter, r *http.Request) {
 		ContractID int64 `json:"contract_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		writeErrorCode(w, http.StatusBadRequest, errCodeInvalidJSON, "invalid_request")
 		return
 	}
 
 	if req.ContractID <= 0 {
-		http.Error(w, `{"error":"invalid_contract_id"}`, http.StatusBadRequest)
+		writeErrorCode(w, http.StatusBadRequest, errCodeInvalidContractID, "invalid_contract_id")
 		return
 	}
 
 	log.Printf("[API] OpenContractWindow: contract_id=%d, character_id=%d", req.ContractID, sess.CharacterID)
 	if err := s.esi.OpenContractWindow(req.ContractID, token); err != nil {
 		log.Printf("[API] OpenContractWindow error: contract_id=%d, err=%v", req.ContractID, err)
-		http.Error(w, `{"error":"esi_error"}`, http.StatusInternalServerError)
+		writeErrorCode(w, http.StatusInternalServerError, errCodeESI, "esi_error")
 		return
 	}
 	log.Printf("[API] OpenContractWindow success: contract_id=%d", req.ContractID)
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"eve-flipper/internal/auth"
+)
+
+func TestUIErrorsKeepLegacyErrorValues(t *testing.T) {
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder) map[string]string {
+		t.Helper()
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode %q: %v", rec.Body.String(), err)
+		}
+		return body
+	}
+
+	rec := httptest.NewRecorder()
+	(&Server{}).handleUIOpenMarket(rec, httptest.NewRequest(http.MethodPost, "/api/ui/open-market", strings.NewReader(`{}`)))
+	if body := decode(t, rec); rec.Code != http.StatusUnauthorized || body["error"] != "not_logged_in" || body["code"] != errCodeNotLoggedIn {
+		t.Fatalf("logged out: %d %v", rec.Code, body)
+	}
+
+	database := openAPITestDB(t)
+	const userID = "user-ui-errors"
+	srv := newAuthedIndustryTestServer(t, database, userID)
+	if err := srv.sessions.SaveAndActivateForUser(userID, &auth.Session{
+		CharacterID:   90000003,
+		CharacterName: "UI Pilot",
+		AccessToken:   "test-access-token",
+		RefreshToken:  "test-refresh-token",
+		ExpiresAt:     time.Now().Add(2 * time.Hour),
+		Scopes:        auth.ScopeOpenWindow,
+	}); err != nil {
+		t.Fatalf("SaveAndActivateForUser: %v", err)
+	}
+	for _, tc := range []struct {
+		h           http.HandlerFunc
+		body        string
+		error, code string
+	}{
+		{srv.handleUIOpenMarket, `{`, "invalid_request", errCodeInvalidJSON},
+		{srv.handleUIOpenMarket, `{"type_id":0}`, "invalid_type_id", errCodeInvalidTypeID},
+		{srv.handleUIOpenMarketBatch, `{"type_ids":[34,-1]}`, "invalid_type_id", errCodeInvalidTypeID},
+	} {
+		rec := httptest.NewRecorder()
+		tc.h(rec, requestWithUserID(http.MethodPost, "/api/ui/open-market", strings.NewReader(tc.body), userID))
+		if body := decode(t, rec); rec.Code != http.StatusBadRequest || body["error"] != tc.error || body["code"] != tc.code {
+			t.Fatalf("%s: %d %v, want error %q code %q", tc.body, rec.Code, body, tc.error, tc.code)
+		}
+	}
+}
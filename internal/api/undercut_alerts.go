@@ -0,0 +1,106 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/engine"
+	"eve-flipper/internal/esi"
+)
+
+// undercutAlertInterval is how often the active character's orders are
+// checked against the order book. ESI caches regional orders for 5 minutes,
+// so polling faster would only see the same book again.
+const undercutAlertInterval = 5 * time.Minute
+
+// startUndercutAlertLoop runs checkUndercutAlerts on a ticker. It is started
+// once, together with the other background alert loops.
+func (s *Server) startUndercutAlertLoop() {
+	s.undercutOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(undercutAlertInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				s.checkUndercutAlerts()
+			}
+		}()
+	})
+}
+
+// checkUndercutAlerts runs the undercut analysis of handleAuthUndercuts for
+// the active character of every user that enabled AlertUndercut. Returns the
+// number of alerts sent.
+func (s *Server) checkUndercutAlerts() int {
+	sent := 0
+	enabled := func(cfg *config.Config) bool { return cfg.AlertUndercut }
+	s.forEachAlertCharacterOrders("Undercut", true, enabled, func(userID string, cfg *config.Config, orders []esi.CharacterOrder) {
+		var statuses []engine.UndercutStatus
+		if len(orders) > 0 {
+			statuses = engine.AnalyzeUndercuts(orders, s.fetchUndercutBook(orders))
+		}
+		sent += s.sendUndercutAlerts(userID, cfg, orders, statuses)
+	})
+	return sent
+}
+
+// sendUndercutAlerts pings once for every order that a competitor beat on
+// price since the last check. An order that regains the top spot is
+// re-armed; orders that are no longer active are forgotten.
+func (s *Server) sendUndercutAlerts(userID string, cfg *config.Config, orders []esi.CharacterOrder, statuses []engine.UndercutStatus) int {
+	byID := make(map[int64]esi.CharacterOrder, len(orders))
+	for _, o := range orders {
+		byID[o.OrderID] = o
+	}
+
+	s.undercutAlertsMu.Lock()
+	if s.undercutAlerted == nil {
+		s.undercutAlerted = make(map[string]map[int64]bool)
+	}
+	prev := s.undercutAlerted[userID]
+	next := make(map[int64]bool)
+	var fresh []engine.UndercutStatus
+	for _, st := range statuses {
+		if _, ok := byID[st.OrderID]; !ok {
+			continue
+		}
+		// An empty book means the fetch failed (the player's own order would
+		// otherwise be in it); keep the previous state instead of re-arming.
+		if len(st.BookLevels) == 0 {
+			next[st.OrderID] = prev[st.OrderID]
+			continue
+		}
+		if st.UndercutAmount <= 0 {
+			continue
+		}
+		next[st.OrderID] = true
+		if !prev[st.OrderID] {
+			fresh = append(fresh, st)
+		}
+	}
+	s.undercutAlerted[userID] = next
+	s.undercutAlertsMu.Unlock()
+
+	for _, st := range fresh {
+		message := s.undercutMessage(byID[st.OrderID], st)
+		result := s.sendConfiguredExternalAlerts(cfg, message)
+		if len(result.Failed) > 0 {
+			log.Printf("[ALERT] Undercut alert for order %d failed on %v", st.OrderID, result.Failed)
+		}
+		log.Printf("[ALERT] Sent undercut alert: %s (channels: %v)", message, result.Sent)
+	}
+	return len(fresh)
+}
+
+// undercutMessage describes an undercut order, e.g.
+// "Sell order undercut: Tritanium at Jita IV - Moon 4, yours 5.10 ISK vs best 5.00 ISK (2.0%), position 3/7".
+func (s *Server) undercutMessage(o esi.CharacterOrder, st engine.UndercutStatus) string {
+	typeName, location := s.characterOrderNames(o)
+	side := "Sell"
+	if o.IsBuyOrder {
+		side = "Buy"
+	}
+	return fmt.Sprintf("%s order undercut: %s at %s, yours %.2f ISK vs best %.2f ISK (%.1f%%), position %d/%d",
+		side, typeName, location, o.Price, st.BestPrice, st.UndercutPct, st.Position, st.TotalOrders)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"testing"
+
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/engine"
+	"eve-flipper/internal/esi"
+)
+
+func TestSendUndercutAlerts_PingsOncePerUndercut(t *testing.T) {
+	srv := &Server{}
+	cfg := config.Default()
+	cfg.AlertUndercut = true
+	cfg.AlertDiscord = true // no webhook configured: nothing leaves the process
+
+	const station = 60003760
+	orders := []esi.CharacterOrder{
+		{OrderID: 1, TypeID: 34, TypeName: "Tritanium", LocationID: station, LocationName: "Jita", Price: 5.10},
+		{OrderID: 2, TypeID: 35, TypeName: "Pyerite", LocationID: station, LocationName: "Jita", Price: 9.00},
+	}
+	book := func(tritBest float64) []esi.MarketOrder {
+		return []esi.MarketOrder{
+			{OrderID: 1, TypeID: 34, LocationID: station, Price: 5.10},
+			{OrderID: 100, TypeID: 34, LocationID: station, Price: tritBest},
+			{OrderID: 2, TypeID: 35, LocationID: station, Price: 9.00},
+			{OrderID: 200, TypeID: 35, LocationID: station, Price: 9.50},
+		}
+	}
+	check := func(statuses []engine.UndercutStatus) int {
+		return srv.sendUndercutAlerts("user-a", cfg, orders, statuses)
+	}
+
+	if got := check(engine.AnalyzeUndercuts(orders, book(5.00))); got != 1 {
+		t.Fatalf("first undercut sent %d alerts, want 1", got)
+	}
+	if got := check(engine.AnalyzeUndercuts(orders, book(4.90))); got != 0 {
+		t.Fatalf("still undercut sent %d alerts, want 0", got)
+	}
+	// A failed book fetch must not re-arm the order.
+	if got := check(engine.AnalyzeUndercuts(orders, nil)); got != 0 {
+		t.Fatalf("empty book sent %d alerts, want 0", got)
+	}
+	if got := check(engine.AnalyzeUndercuts(orders, book(4.90))); got != 0 {
+		t.Fatalf("after empty book sent %d alerts, want 0", got)
+	}
+	// Back on top, then undercut again: ping again.
+	if got := check(engine.AnalyzeUndercuts(orders, book(5.20))); got != 0 {
+		t.Fatalf("back on top sent %d alerts, want 0", got)
+	}
+	if got := check(engine.AnalyzeUndercuts(orders, book(5.05))); got != 1 {
+		t.Fatalf("undercut again sent %d alerts, want 1", got)
+	}
+	// Other users are tracked separately.
+	if got := srv.sendUndercutAlerts("user-b", cfg, orders, engine.AnalyzeUndercuts(orders, book(5.05))); got != 1 {
+		t.Fatalf("other user sent %d alerts, want 1", got)
+	}
+}
+
+func TestUndercutMessage(t *testing.T) {
+	srv := &Server{}
+	o := esi.CharacterOrder{TypeName: "Tritanium", LocationName: "Jita IV - Moon 4", Price: 5.10}
+	st := engine.UndercutStatus{Position: 3, TotalOrders: 7, BestPrice: 5.00, UndercutPct: 1.96}
+	want := "Sell order undercut: Tritanium at Jita IV - Moon 4, yours 5.10 ISK vs best 5.00 ISK (2.0%), position 3/7"
+	if got := srv.undercutMessage(o, st); got != want {
+		t.Fatalf("message = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,292 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/esi"
+)
+
+// maxWatchlistImportBytes bounds the import payload (a few thousand items fit easily).
+const maxWatchlistImportBytes = 4 << 20
+
+// maxWatchlistBulkAdd caps how many type IDs one bulk-add request may carry.
+const maxWatchlistBulkAdd = 1000
+
+const (
+	defaultWatchlistHistoryRegionID int32 = 10000002 // The Forge
+	defaultWatchlistHistoryDays           = 30
+	maxWatchlistHistoryDays               = 90 // market history cache keeps 90 days
+)
+
+// watchlistHistoryPoint is one day of the sparkline series.
+type watchlistHistoryPoint struct {
+	Date    string  `json:"date"`
+	Average float64 `json:"average"`
+	Volume  int64   `json:"volume"`
+}
+
+func isValidWatchlistAlertMetric(metric string) bool {
+	switch metric {
+	case "", "margin_percent", "total_profit", "profit_per_unit", "daily_volume":
+		return true
+	}
+	return false
+}
+
+// handleExportWatchlist returns the user's watchlist as a downloadable JSON file.
+func (s *Server) handleExportWatchlist(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromRequest(r)
+	items := s.db.GetWatchlistForUser(userID)
+	if items == nil {
+		items = []config.WatchlistItem{}
+	}
+	filename := fmt.Sprintf("eve-flipper-watchlist-%s.json", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	writeJSON(w, items)
+}
+
+// handleImportWatchlist upserts an exported watchlist array. Unknown and
+// market-disabled types are skipped; items already on the list get their
+// alert settings overwritten by the imported values.
+func (s *Server) handleImportWatchlist(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromRequest(r)
+
+	var items []config.WatchlistItem
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWatchlistImportBytes)).Decode(&items); err != nil {
+		writeError(w, 400, "invalid json: expected an array of watchlist items")
+		return
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	if sdeData == nil {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+
+	var resp struct {
+		Added           int                    `json:"added"`
+		Updated         int                    `json:"updated"`
+		SkippedUnknown  int                    `json:"skipped_unknown"`
+		SkippedDisabled int                    `json:"skipped_disabled"`
+		Items           []config.WatchlistItem `json:"items"`
+	}
+	now := time.Now().Format(time.RFC3339)
+	seen := make(map[int32]bool, len(items))
+	for _, item := range items {
+		t, ok := sdeData.Types[item.TypeID]
+		if !ok {
+			resp.SkippedUnknown++
+			continue
+		}
+		if s.isMarketDisabledForUser(userID, item.TypeID) {
+			resp.SkippedDisabled++
+			continue
+		}
+		if seen[item.TypeID] {
+			continue
+		}
+		seen[item.TypeID] = true
+
+		item.TypeName = t.Name
+		if !isValidWatchlistAlertMetric(item.AlertMetric) {
+			item.AlertMetric = "margin_percent"
+		}
+		if item.AlertThreshold < 0 {
+			item.AlertThreshold = 0
+		}
+		if _, ok := sdeData.Regions[item.RegionID]; !ok {
+			item.RegionID, item.DemandAlertThreshold = 0, 0
+		}
+		if _, err := time.Parse(time.RFC3339, item.AddedAt); err != nil {
+			item.AddedAt = now
+		}
+
+		if s.db.AddWatchlistItemForUser(userID, item) {
+			resp.Added++
+			continue
+		}
+		s.db.UpdateWatchlistItemForUser(userID, item.TypeID, item.AlertMinMargin, item.AlertEnabled, item.AlertMetric, item.AlertThreshold)
+		s.db.UpdateWatchlistDemandAlertForUser(userID, item.TypeID, item.RegionID, item.DemandAlertThreshold)
+		resp.Updated++
+	}
+
+	current := s.db.GetWatchlistForUser(userID)
+	resp.Items = make([]config.WatchlistItem, 0, len(current))
+	for _, it := range current {
+		if s.isMarketDisabledForUser(userID, it.TypeID) {
+			continue
+		}
+		resp.Items = append(resp.Items, it)
+	}
+	writeJSON(w, resp)
+}
+
+// handleBulkAddWatchlist adds many types (e.g. selected scan rows) in one
+// transaction with a shared alert setting. Types already on the list are left
+// as they are; inserted_count reports how many were new.
+func (s *Server) handleBulkAddWatchlist(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromRequest(r)
+
+	var req struct {
+		TypeIDs        []int32 `json:"type_ids"`
+		AlertMetric    string  `json:"alert_metric"`
+		AlertThreshold float64 `json:"alert_threshold"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorCode(w, 400, errCodeInvalidJSON, "invalid json")
+		return
+	}
+	if len(req.TypeIDs) == 0 {
+		writeError(w, 400, "type_ids is required")
+		return
+	}
+	if len(req.TypeIDs) > maxWatchlistBulkAdd {
+		writeError(w, 400, fmt.Sprintf("too many type_ids (max %d)", maxWatchlistBulkAdd))
+		return
+	}
+	if !isValidWatchlistAlertMetric(req.AlertMetric) {
+		writeError(w, 400, "invalid alert_metric")
+		return
+	}
+	if req.AlertThreshold < 0 {
+		req.AlertThreshold = 0
+	}
+
+	s.mu.RLock()
+	sdeData := s.sdeData
+	s.mu.RUnlock()
+	if sdeData == nil {
+		writeErrorCode(w, 503, errCodeSDENotReady, "SDE not loaded yet")
+		return
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	items := make([]config.WatchlistItem, 0, len(req.TypeIDs))
+	seen := make(map[int32]bool, len(req.TypeIDs))
+	for _, typeID := range req.TypeIDs {
+		t, ok := sdeData.Types[typeID]
+		if !ok {
+			writeErrorCode(w, 400, errCodeTypeNotFound, fmt.Sprintf("unknown type_id %d", typeID))
+			return
+		}
+		if s.isMarketDisabledForUser(userID, typeID) {
+			writeError(w, 400, fmt.Sprintf("type_id %d is market-disabled", typeID))
+			return
+		}
+		if seen[typeID] {
+			continue
+		}
+		seen[typeID] = true
+		items = append(items, config.WatchlistItem{
+			TypeID:         typeID,
+			TypeName:       t.Name,
+			AddedAt:        now,
+			AlertMetric:    req.AlertMetric,
+			AlertThreshold: req.AlertThreshold,
+		})
+	}
+
+	inserted, err := s.db.AddWatchlistItemsForUser(userID, items)
+	if err != nil {
+		log.Printf("[API] watchlist bulk add: %v", err)
+		writeError(w, 500, "failed to add watchlist items")
+		return
+	}
+
+	current := s.db.GetWatchlistForUser(userID)
+	filtered := make([]config.WatchlistItem, 0, len(current))
+	for _, it := range current {
+		if s.isMarketDisabledForUser(userID, it.TypeID) {
+			continue
+		}
+		filtered = append(filtered, it)
+	}
+	writeJSON(w, struct {
+		Items         []config.WatchlistItem `json:"items"`
+		InsertedCount int                    `json:"inserted_count"`
+	}{
+		Items:         filtered,
+		InsertedCount: inserted,
+	})
+}
+
+// handleWatchlistHistory returns a compact daily price/volume series for one
+// type, used for the watchlist sparklines. History comes from the local cache
+// and is refreshed from ESI when missing or stale.
+func (s *Server) handleWatchlistHistory(w http.ResponseWriter, r *http.Request) {
+	typeID, err := strconv.ParseInt(r.PathValue("typeID"), 10, 32)
+	if err != nil || typeID <= 0 {
+		writeError(w, 400, "invalid type_id")
+		return
+	}
+	q := r.URL.Query()
+	regionID := defaultWatchlistHistoryRegionID
+	if raw := strings.TrimSpace(q.Get("region_id")); raw != "" {
+		v, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || v <= 0 {
+			writeError(w, 400, "invalid region_id")
+			return
+		}
+		regionID = int32(v)
+	}
+	days := defaultWatchlistHistoryDays
+	if raw := strings.TrimSpace(q.Get("days")); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			writeError(w, 400, "invalid days")
+			return
+		}
+		days = v
+	}
+	if days > maxWatchlistHistoryDays {
+		days = maxWatchlistHistoryDays
+	}
+
+	var entries []esi.HistoryEntry
+	cached := false
+	if s.db != nil {
+		entries, cached = s.db.GetMarketHistory(regionID, int32(typeID))
+	}
+	if !cached {
+		fetched, err := s.esi.FetchMarketHistory(regionID, int32(typeID))
+		if err != nil {
+			log.Printf("[API] watchlist history FetchMarketHistory(%d, %d): %v", regionID, typeID, err)
+			writeError(w, 502, "failed to fetch market history")
+			return
+		}
+		if s.db != nil && len(fetched) > 0 {
+			s.db.SetMarketHistory(regionID, int32(typeID), fetched)
+		}
+		entries = fetched
+	}
+
+	cutoff := time.Now().UTC().AddDate(0, 0, -days).Format("2006-01-02")
+	points := make([]watchlistHistoryPoint, 0, days)
+	for _, e := range entries {
+		if e.Date < cutoff {
+			continue
+		}
+		points = append(points, watchlistHistoryPoint{Date: e.Date, Average: e.Average, Volume: e.Volume})
+	}
+
+	writeJSON(w, struct {
+		TypeID   int32                   `json:"type_id"`
+		RegionID int32                   `json:"region_id"`
+		Days     int                     `json:"days"`
+		Points   []watchlistHistoryPoint `json:"points"`
+	}{
+		TypeID:   int32(typeID),
+		RegionID: regionID,
+		Days:     days,
+		Points:   points,
+	})
+}
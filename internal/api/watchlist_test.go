@@ -0,0 +1,171 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"eve-flipper/internal/config"
+	"eve-flipper/internal/engine"
+	"eve-flipper/internal/esi"
+	"eve-flipper/internal/sde"
+)
+
+func TestHandleImportWatchlist_CountsAndUpsert(t *testing.T) {
+	database := openAPITestDB(t)
+	const userID = "watchlist-import-user"
+	srv := &Server{
+		db: database,
+		sdeData: &sde.Data{Types: map[int32]*sde.ItemType{
+			34:                {ID: 34, Name: "Tritanium"},
+			35:                {ID: 35, Name: "Pyerite"},
+			engine.MPTCTypeID: {ID: engine.MPTCTypeID, Name: "Multiple Pilot Training Certificate"},
+		}},
+		ready: true,
+	}
+	database.AddWatchlistItemForUser(userID, config.WatchlistItem{TypeID: 35, TypeName: "Pyerite", AlertMetric: "margin_percent"})
+
+	payload, _ := json.Marshal([]config.WatchlistItem{
+		{TypeID: 34, TypeName: "stale name", AlertMetric: "total_profit", AlertThreshold: 1_000_000, AlertEnabled: true},
+		{TypeID: 35, AlertMetric: "daily_volume", AlertThreshold: 500, AlertEnabled: true},
+		{TypeID: 999999},
+		{TypeID: engine.MPTCTypeID},
+	})
+	rec := httptest.NewRecorder()
+	srv.handleImportWatchlist(rec, requestWithUserID(http.MethodPost, "/api/watchlist/import", bytes.NewReader(payload), userID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Added           int                    `json:"added"`
+		Updated         int                    `json:"updated"`
+		SkippedUnknown  int                    `json:"skipped_unknown"`
+		SkippedDisabled int                    `json:"skipped_disabled"`
+		Items           []config.WatchlistItem `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Added != 1 || resp.Updated != 1 || resp.SkippedUnknown != 1 || resp.SkippedDisabled != 1 {
+		t.Fatalf("counts = %+v", resp)
+	}
+	byType := make(map[int32]config.WatchlistItem)
+	for _, it := range resp.Items {
+		byType[it.TypeID] = it
+	}
+	if byType[34].TypeName != "Tritanium" {
+		t.Fatalf("imported type name = %q, want SDE name", byType[34].TypeName)
+	}
+	if byType[35].AlertMetric != "daily_volume" || byType[35].AlertThreshold != 500 {
+		t.Fatalf("existing item not updated: %+v", byType[35])
+	}
+}
+
+func TestHandleExportWatchlist_Attachment(t *testing.T) {
+	database := openAPITestDB(t)
+	const userID = "watchlist-export-user"
+	database.AddWatchlistItemForUser(userID, config.WatchlistItem{TypeID: 34, TypeName: "Tritanium"})
+	srv := &Server{db: database}
+
+	rec := httptest.NewRecorder()
+	srv.handleExportWatchlist(rec, requestWithUserID(http.MethodGet, "/api/watchlist/export", nil, userID))
+	if !strings.HasPrefix(rec.Header().Get("Content-Disposition"), "attachment;") {
+		t.Fatalf("Content-Disposition = %q", rec.Header().Get("Content-Disposition"))
+	}
+	var items []config.WatchlistItem
+	if err := json.Unmarshal(rec.Body.Bytes(), &items); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(items) != 1 || items[0].TypeID != 34 {
+		t.Fatalf("export = %+v", items)
+	}
+}
+
+func TestHandleBulkAddWatchlist_InsertedCount(t *testing.T) {
+	database := openAPITestDB(t)
+	const userID = "watchlist-bulk-user"
+	srv := &Server{
+		db: database,
+		sdeData: &sde.Data{Types: map[int32]*sde.ItemType{
+			34: {ID: 34, Name: "Tritanium"},
+			35: {ID: 35, Name: "Pyerite"},
+		}},
+		ready: true,
+	}
+	database.AddWatchlistItemForUser(userID, config.WatchlistItem{TypeID: 35, TypeName: "Pyerite"})
+
+	body := `{"type_ids":[34,35,34],"alert_metric":"total_profit","alert_threshold":5000000}`
+	rec := httptest.NewRecorder()
+	srv.handleBulkAddWatchlist(rec, requestWithUserID(http.MethodPost, "/api/watchlist/bulk", strings.NewReader(body), userID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Items         []config.WatchlistItem `json:"items"`
+		InsertedCount int                    `json:"inserted_count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.InsertedCount != 1 || len(resp.Items) != 2 {
+		t.Fatalf("inserted_count = %d items = %d, want 1/2", resp.InsertedCount, len(resp.Items))
+	}
+	for _, it := range resp.Items {
+		if it.TypeID == 34 && (it.TypeName != "Tritanium" || it.AlertMetric != "total_profit") {
+			t.Fatalf("bulk item = %+v", it)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleBulkAddWatchlist(rec, requestWithUserID(http.MethodPost, "/api/watchlist/bulk", strings.NewReader(`{"type_ids":[999999]}`), userID))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown type status = %d, want 400", rec.Code)
+	}
+}
+
+func TestHandleWatchlistHistory_UsesCacheAndTrimsDays(t *testing.T) {
+	database := openAPITestDB(t)
+	srv := &Server{db: database}
+
+	now := time.Now().UTC()
+	entries := make([]esi.HistoryEntry, 0, 40)
+	for i := 39; i >= 0; i-- {
+		entries = append(entries, esi.HistoryEntry{
+			Date:    now.AddDate(0, 0, -i).Format("2006-01-02"),
+			Average: float64(100 + i),
+			Volume:  int64(1000 + i),
+		})
+	}
+	database.SetMarketHistory(10000002, 34, entries)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/watchlist/34/history?days=7", nil)
+	req.SetPathValue("typeID", "34")
+	rec := httptest.NewRecorder()
+	srv.handleWatchlistHistory(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		RegionID int32                   `json:"region_id"`
+		Days     int                     `json:"days"`
+		Points   []watchlistHistoryPoint `json:"points"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.RegionID != 10000002 || resp.Days != 7 {
+		t.Fatalf("region/days = %d/%d, want The Forge/7", resp.RegionID, resp.Days)
+	}
+	if len(resp.Points) != 8 {
+		t.Fatalf("points = %d, want 8 (cutoff day inclusive)", len(resp.Points))
+	}
+	last := resp.Points[len(resp.Points)-1]
+	if last.Average != 100 || last.Volume != 1000 {
+		t.Fatalf("last point = %+v", last)
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"eve-flipper/internal/db"
+)
+
+func TestWebsocketAcceptRFCExample(t *testing.T) {
+	if got := websocketAccept("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("accept = %q", got)
+	}
+}
+
+func TestUpgradeWebSocketRejectsForeignOrigin(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:13370/api/ws/scan", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Origin", "https://evil.example")
+	rec := httptest.NewRecorder()
+	if _, err := upgradeWebSocket(rec, req); err == nil || rec.Code != http.StatusForbidden {
+		t.Fatalf("err = %v, status = %d; want 403", err, rec.Code)
+	}
+}
+
+func TestWSScanRejectsUnknownScanType(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc((&Server{}).handleWSScan))
+	defer ts.Close()
+
+	conn, br := dialTestWebSocket(t, ts.URL)
+	defer conn.Close()
+
+	writeTestWSFrame(t, conn, wsOpPing, []byte("hi"))
+	if op, payload := readTestWSFrame(t, br); op != wsOpPong || string(payload) != "hi" {
+		t.Fatalf("ping reply = %d %q, want pong", op, payload)
+	}
+
+	writeTestWSFrame(t, conn, wsOpText, []byte(`{"scan":"nope","system_name":"Jita"}`))
+	op, payload := readTestWSFrame(t, br)
+	if op != wsOpText || !strings.Contains(string(payload), `"unknown scan type: nope"`) {
+		t.Fatalf("frame = %d %s, want error frame", op, payload)
+	}
+	if op, payload := readTestWSFrame(t, br); op != wsOpClose || binary.BigEndian.Uint16(payload) != wsCloseNormal {
+		t.Fatalf("frame = %d %v, want normal close", op, payload)
+	}
+}
+
+func TestWSScanSharesScanGate(t *testing.T) {
+	srv := &Server{}
+	release, _, ok := srv.scanGate.tryAcquire(db.DefaultUserID, "multi_region")
+	if !ok {
+		t.Fatal("could not claim gate")
+	}
+	defer release()
+	ts := httptest.NewServer(http.HandlerFunc(srv.handleWSScan))
+	defer ts.Close()
+
+	conn, br := dialTestWebSocket(t, ts.URL)
+	defer conn.Close()
+
+	writeTestWSFrame(t, conn, wsOpText, []byte(`{"scan":"multi_region","system_name":"Jita"}`))
+	op, payload := readTestWSFrame(t, br)
+	if op != wsOpText || !strings.Contains(string(payload), `"code":"rate_limited"`) {
+		t.Fatalf("frame = %d %s, want rate_limited error frame", op, payload)
+	}
+	if op, payload := readTestWSFrame(t, br); op != wsOpClose || binary.BigEndian.Uint16(payload) != wsCloseTryAgainLater {
+		t.Fatalf("frame = %d %v, want try-again-later close", op, payload)
+	}
+}
+
+func dialTestWebSocket(t *testing.T, serverURL string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	addr := strings.TrimPrefix(serverURL, "http://")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(conn, "GET /api/ws/scan HTTP/1.1\r\nHost: "+addr+"\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake status = %d, accept = %q", resp.StatusCode, resp.Header.Get("Sec-WebSocket-Accept"))
+	}
+	return conn, br
+}
+
+func writeTestWSFrame(t *testing.T, conn net.Conn, op byte, payload []byte) {
+	t.Helper()
+	mask := [4]byte{1, 2, 3, 4}
+	frame := []byte{0x80 | op, 0x80 | byte(len(payload))}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatalf("write frame: %v", err)
+	}
+}
+
+func readTestWSFrame(t *testing.T, br *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var hdr [2]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	length := int(hdr[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(br, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		t.Fatalf("read payload: %v", err)
+	}
+	return hdr[0] & 0x0F, payload
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"eve-flipper/internal/metrics"
+)
+
+// handleWSScan runs one scan over a WebSocket. The first client message is
+// the same JSON body the NDJSON scan endpoints accept, plus
+// "scan": "radius" (default), "multi_region" or "contracts". The server then
+// sends the same progress/result/error frames as text messages and closes
+// the connection. Closing the socket early cancels the scan.
+// The scan shares the per-user gate of the matching HTTP route; while one is
+// running, a rate_limited error frame is sent and the socket closed.
+func (s *Server) handleWSScan(w http.ResponseWriter, r *http.Request) {
+	userID := userIDFromRequest(r)
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		log.Printf("[API] WS scan upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close(wsCloseNormal, "")
+
+	send := func(frame interface{}) error {
+		return conn.WriteText(marshalScanFrame(frame))
+	}
+
+	msg, err := conn.ReadMessage()
+	if err != nil {
+		return
+	}
+	var kind struct {
+		Scan string `json:"scan"`
+	}
+	var req scanRequest
+	if json.Unmarshal(msg, &kind) != nil || json.Unmarshal(msg, &req) != nil {
+		send(map[string]string{"type": "error", "message": "invalid json"})
+		conn.Close(wsCloseInvalidPayload, "invalid json")
+		return
+	}
+	scanType := kind.Scan
+	if scanType == "" {
+		scanType = "radius"
+	}
+	switch scanType {
+	case "radius", "multi_region", "contracts":
+	default:
+		send(map[string]string{"type": "error", "message": "unknown scan type: " + scanType})
+		return
+	}
+	release, startedAt, ok := s.scanGate.tryAcquire(userID, scanType)
+	if !ok {
+		send(map[string]string{"type": "error", "code": errCodeRateLimited, "message": scanGateBusyMessage(scanType, startedAt)})
+		conn.Close(wsCloseTryAgainLater, "scan already running")
+		return
+	}
+	defer release()
+	params, err := s.parseScanParams(userID, req)
+	if err != nil {
+		send(map[string]string{"type": "error", "message": err.Error()})
+		return
+	}
+
+	// The hijacked connection is no longer tied to r.Context(); a reader
+	// goroutine notices the client going away instead.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	metrics.ScansTotal.Inc(scanType)
+	defer metrics.ScanDuration.ObserveSince(time.Now(), scanType)
+	switch scanType {
+	case "radius":
+		s.runFlipScan(ctx, userID, req, params, false, send)
+	case "multi_region":
+		s.runFlipScan(ctx, userID, req, params, true, send)
+	case "contracts":
+		s.runContractScan(ctx, userID, req, params, send)
+	}
+}
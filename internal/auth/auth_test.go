@@ -3,6 +3,8 @@ package auth
 import (
 	"database/sql"
 	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
@@ -75,6 +77,7 @@ func TestSessionStore_SaveGetDelete(t *testing.T) {
 			refresh_token   TEXT NOT NULL,
 			expires_at      INTEGER NOT NULL,
 			is_active       INTEGER NOT NULL DEFAULT 0,
+			scopes          TEXT NOT NULL DEFAULT '',
 			PRIMARY KEY (user_id, character_id)
 		)`)
 	if err != nil {
@@ -167,6 +170,7 @@ func TestSessionStore_UserIsolation(t *testing.T) {
 			refresh_token   TEXT NOT NULL,
 			expires_at      INTEGER NOT NULL,
 			is_active       INTEGER NOT NULL DEFAULT 0,
+			scopes          TEXT NOT NULL DEFAULT '',
 			PRIMARY KEY (user_id, character_id)
 		)`)
 	if err != nil {
@@ -222,6 +226,7 @@ func newSessionStoreForTokenTest(t *testing.T) *SessionStore {
 			refresh_token   TEXT NOT NULL,
 			expires_at      INTEGER NOT NULL,
 			is_active       INTEGER NOT NULL DEFAULT 0,
+			scopes          TEXT NOT NULL DEFAULT '',
 			PRIMARY KEY (user_id, character_id)
 		)`)
 	if err != nil {
@@ -273,3 +278,118 @@ func TestSessionStore_EnsureValidTokenForUser_ExpiredTokenRequiresSSO(t *testing
 		t.Fatalf("error = %v, want contains %q", err, "sso not configured")
 	}
 }
+
+func TestSessionStore_EnsureValidToken_PrunesSessionOnInvalidGrant(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid refresh token. Token missing/expired."}`))
+	}))
+	defer srv.Close()
+
+	store := newSessionStoreForTokenTest(t)
+	var revokedUser string
+	var revokedChar int64
+	store.OnRevoked(func(userID string, characterID int64) {
+		revokedUser, revokedChar = userID, characterID
+	})
+	if err := store.SaveAndActivateForUser("u1", &Session{
+		CharacterID:   101,
+		CharacterName: "Pilot One",
+		AccessToken:   "access-token",
+		RefreshToken:  "revoked-refresh-token",
+		ExpiresAt:     time.Now().Add(-2 * time.Minute),
+	}); err != nil {
+		t.Fatalf("SaveAndActivateForUser: %v", err)
+	}
+
+	sso := &SSOConfig{ClientID: "cid", ClientSecret: "secret", tokenURL: srv.URL}
+	_, err := store.EnsureValidTokenForUserCharacter(sso, "u1", 101)
+	if err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Fatalf("error = %v, want a not logged in error", err)
+	}
+	if got := store.GetByCharacterIDForUser("u1", 101); got != nil {
+		t.Fatalf("session still stored after invalid_grant: %+v", got)
+	}
+	if revokedUser != "u1" || revokedChar != 101 {
+		t.Fatalf("OnRevoked called with %q/%d, want u1/101", revokedUser, revokedChar)
+	}
+}
+
+func TestSessionStore_EnsureValidToken_KeepsSessionOnTransientFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("upstream unavailable"))
+	}))
+	defer srv.Close()
+
+	store := newSessionStoreForTokenTest(t)
+	store.OnRevoked(func(string, int64) { t.Error("OnRevoked called for a transient failure") })
+	if err := store.SaveAndActivateForUser("u1", &Session{
+		CharacterID:   101,
+		CharacterName: "Pilot One",
+		AccessToken:   "access-token",
+		RefreshToken:  "refresh-token",
+		ExpiresAt:     time.Now().Add(-2 * time.Minute),
+	}); err != nil {
+		t.Fatalf("SaveAndActivateForUser: %v", err)
+	}
+
+	sso := &SSOConfig{ClientID: "cid", ClientSecret: "secret", tokenURL: srv.URL}
+	_, err := store.EnsureValidTokenForUserCharacter(sso, "u1", 101)
+	if err == nil || IsTokenRevoked(err) {
+		t.Fatalf("error = %v, want a transient refresh error", err)
+	}
+	if got := store.GetByCharacterIDForUser("u1", 101); got == nil {
+		t.Fatal("session removed after a transient failure")
+	}
+}
+
+func TestSessionHasScope(t *testing.T) {
+	legacy := &Session{}
+	if !legacy.HasScope(ScopeOpenWindow) {
+		t.Error("session without recorded scopes should be treated as full access")
+	}
+	basic := &Session{Scopes: BasicScopes}
+	if !basic.HasScope(ScopeReadCharacterOrders) || !basic.HasScope(ScopeReadCharacterWallet) || !basic.HasScope(ScopeReadLocation) {
+		t.Errorf("basic session missing a basic scope: %q", basic.Scopes)
+	}
+	if basic.HasScope(ScopeReadAssets) {
+		t.Error("basic session should not have the assets scope")
+	}
+	var nilSess *Session
+	if nilSess.HasScope(ScopeReadLocation) {
+		t.Error("nil session should have no scopes")
+	}
+}
+
+func TestSessionStore_PersistsScopes(t *testing.T) {
+	store := newSessionStoreForTokenTest(t)
+	if err := store.SaveAndActivateForUser("u1", &Session{
+		CharacterID:   101,
+		CharacterName: "Pilot One",
+		AccessToken:   "at",
+		RefreshToken:  "rt",
+		ExpiresAt:     time.Now().Add(time.Hour),
+		Scopes:        BasicScopes,
+	}); err != nil {
+		t.Fatalf("SaveAndActivateForUser: %v", err)
+	}
+	if got := store.GetForUser("u1"); got == nil || got.Scopes != BasicScopes {
+		t.Fatalf("GetForUser scopes = %+v, want %q", got, BasicScopes)
+	}
+	if list := store.ListForUser("u1"); len(list) != 1 || list[0].Scopes != BasicScopes {
+		t.Fatalf("ListForUser = %+v", list)
+	}
+}
+
+func TestBuildAuthURLWithScopes(t *testing.T) {
+	c := &SSOConfig{ClientID: "test-client", Scopes: "esi-assets.read_assets.v1"}
+	parsed, err := url.Parse(c.BuildAuthURLWithScopes("st", BasicScopes))
+	if err != nil {
+		t.Fatalf("parse URL: %v", err)
+	}
+	if got := parsed.Query().Get("scope"); got != BasicScopes {
+		t.Errorf("scope = %q, want %q", got, BasicScopes)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,12 +19,56 @@ const (
 	verifyURL    = "https://login.eveonline.com/oauth/verify"
 )
 
+// ESI scopes checked by scope-gated endpoints.
+const (
+	ScopeReadLocation         = "esi-location.read_location.v1"
+	ScopeReadSkills           = "esi-skills.read_skills.v1"
+	ScopeReadCharacterWallet  = "esi-wallet.read_character_wallet.v1"
+	ScopeReadAssets           = "esi-assets.read_assets.v1"
+	ScopeReadBlueprints       = "esi-characters.read_blueprints.v1"
+	ScopeStructureMarkets     = "esi-markets.structure_markets.v1"
+	ScopeReadStructures       = "esi-universe.read_structures.v1"
+	ScopeReadCharacterOrders  = "esi-markets.read_character_orders.v1"
+	ScopeReadCorporationRoles = "esi-characters.read_corporation_roles.v1"
+	ScopeOpenWindow           = "esi-ui.open_window.v1"
+	ScopeWriteWaypoint        = "esi-ui.write_waypoint.v1"
+)
+
+// BasicScopes is the reduced scope set requested by ?mode=basic logins:
+// enough for station trading (location, own orders, wallet) and nothing else.
+var BasicScopes = strings.Join([]string{
+	ScopeReadLocation,
+	ScopeReadCharacterOrders,
+	ScopeReadCharacterWallet,
+}, " ")
+
 // SSOConfig holds EVE SSO OAuth2 configuration.
 type SSOConfig struct {
 	ClientID     string
 	ClientSecret string
 	CallbackURL  string
 	Scopes       string
+
+	tokenURL string // overrides the SSO token endpoint in tests
+}
+
+// TokenError is a non-200 response from the SSO token endpoint.
+type TokenError struct {
+	StatusCode int
+	Code       string // OAuth error code, e.g. "invalid_grant"
+	Body       string
+}
+
+func (e *TokenError) Error() string {
+	return fmt.Sprintf("token request failed (%d): %s", e.StatusCode, e.Body)
+}
+
+// IsTokenRevoked reports whether err means the refresh token is permanently
+// unusable (revoked in-game, expired or already rotated), as opposed to a
+// transient SSO failure worth retrying.
+func IsTokenRevoked(err error) bool {
+	var tokenErr *TokenError
+	return errors.As(err, &tokenErr) && tokenErr.Code == "invalid_grant"
 }
 
 // TokenResponse is the response from the EVE SSO token endpoint.
@@ -38,6 +83,7 @@ type TokenResponse struct {
 type CharacterInfo struct {
 	CharacterID   int64  `json:"CharacterID"`
 	CharacterName string `json:"CharacterName"`
+	Scopes        string `json:"Scopes"` // space-separated scopes granted to the token
 }
 
 // GenerateState creates a random state string for CSRF protection.
@@ -49,11 +95,17 @@ func GenerateState() string {
 
 // BuildAuthURL constructs the EVE SSO authorization URL.
 func (c *SSOConfig) BuildAuthURL(state string) string {
+	return c.BuildAuthURLWithScopes(state, c.Scopes)
+}
+
+// BuildAuthURLWithScopes constructs the EVE SSO authorization URL for a
+// specific scope set instead of the configured one.
+func (c *SSOConfig) BuildAuthURLWithScopes(state, scopes string) string {
 	params := url.Values{
 		"response_type": {"code"},
 		"redirect_uri":  {c.CallbackURL},
 		"client_id":     {c.ClientID},
-		"scope":         {c.Scopes},
+		"scope":         {scopes},
 		"state":         {state},
 	}
 	return authorizeURL + "?" + params.Encode()
@@ -78,7 +130,11 @@ func (c *SSOConfig) RefreshToken(refreshToken string) (*TokenResponse, error) {
 }
 
 func (c *SSOConfig) tokenRequest(data url.Values) (*TokenResponse, error) {
-	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(data.Encode()))
+	endpoint := tokenURL
+	if c.tokenURL != "" {
+		endpoint = c.tokenURL
+	}
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -94,7 +150,11 @@ func (c *SSOConfig) tokenRequest(data url.Values) (*TokenResponse, error) {
 
 	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("token request failed (%d): %s", resp.StatusCode, string(body))
+		var oauthErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(body, &oauthErr)
+		return nil, &TokenError{StatusCode: resp.StatusCode, Code: oauthErr.Error, Body: string(body)}
 	}
 
 	var tok TokenResponse
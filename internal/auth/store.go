@@ -16,11 +16,33 @@ type Session struct {
 	RefreshToken  string
 	ExpiresAt     time.Time
 	Active        bool
+	// Scopes is the space-separated scope list granted at login. Empty for
+	// sessions stored before scopes were recorded.
+	Scopes string
+}
+
+// HasScope reports whether the session was granted scope. Sessions without
+// recorded scopes predate basic logins and were granted the full set.
+func (s *Session) HasScope(scope string) bool {
+	if s == nil {
+		return false
+	}
+	if strings.TrimSpace(s.Scopes) == "" {
+		return true
+	}
+	for _, granted := range strings.Fields(s.Scopes) {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
 }
 
 // SessionStore handles session persistence in SQLite.
 type SessionStore struct {
 	db *sql.DB
+
+	onRevoked func(userID string, characterID int64)
 }
 
 const defaultUserID = "default"
@@ -30,6 +52,12 @@ func NewSessionStore(db *sql.DB) *SessionStore {
 	return &SessionStore{db: db}
 }
 
+// OnRevoked registers fn to be called after a session is deleted because its
+// refresh token was revoked. Set it once, before the store is in use.
+func (s *SessionStore) OnRevoked(fn func(userID string, characterID int64)) {
+	s.onRevoked = fn
+}
+
 func normalizeUserID(userID string) string {
 	trimmed := strings.TrimSpace(userID)
 	if trimmed == "" {
@@ -58,14 +86,15 @@ func (s *SessionStore) SaveForUser(userID string, sess *Session) error {
 	defer tx.Rollback()
 
 	_, err = tx.Exec(`
-		INSERT INTO auth_session (user_id, character_id, character_name, access_token, refresh_token, expires_at, is_active)
-		VALUES (?, ?, ?, ?, ?, ?, 0)
+		INSERT INTO auth_session (user_id, character_id, character_name, access_token, refresh_token, expires_at, is_active, scopes)
+		VALUES (?, ?, ?, ?, ?, ?, 0, ?)
 		ON CONFLICT(user_id, character_id) DO UPDATE SET
 			character_name = excluded.character_name,
 			access_token = excluded.access_token,
 			refresh_token = excluded.refresh_token,
-			expires_at = excluded.expires_at`,
-		userID, sess.CharacterID, sess.CharacterName, sess.AccessToken, sess.RefreshToken, sess.ExpiresAt.Unix(),
+			expires_at = excluded.expires_at,
+			scopes = excluded.scopes`,
+		userID, sess.CharacterID, sess.CharacterName, sess.AccessToken, sess.RefreshToken, sess.ExpiresAt.Unix(), sess.Scopes,
 	)
 	if err != nil {
 		return err
@@ -107,15 +136,16 @@ func (s *SessionStore) SaveAndActivateForUser(userID string, sess *Session) erro
 	}
 
 	_, err = tx.Exec(`
-		INSERT INTO auth_session (user_id, character_id, character_name, access_token, refresh_token, expires_at, is_active)
-		VALUES (?, ?, ?, ?, ?, ?, 1)
+		INSERT INTO auth_session (user_id, character_id, character_name, access_token, refresh_token, expires_at, is_active, scopes)
+		VALUES (?, ?, ?, ?, ?, ?, 1, ?)
 		ON CONFLICT(user_id, character_id) DO UPDATE SET
 			character_name = excluded.character_name,
 			access_token = excluded.access_token,
 			refresh_token = excluded.refresh_token,
 			expires_at = excluded.expires_at,
-			is_active = 1`,
-		userID, sess.CharacterID, sess.CharacterName, sess.AccessToken, sess.RefreshToken, sess.ExpiresAt.Unix(),
+			is_active = 1,
+			scopes = excluded.scopes`,
+		userID, sess.CharacterID, sess.CharacterName, sess.AccessToken, sess.RefreshToken, sess.ExpiresAt.Unix(), sess.Scopes,
 	)
 	if err != nil {
 		return err
@@ -139,7 +169,7 @@ func (s *SessionStore) GetForUser(userID string) *Session {
 	}
 
 	if sess := s.querySession(`
-		SELECT character_id, character_name, access_token, refresh_token, expires_at, is_active
+		SELECT character_id, character_name, access_token, refresh_token, expires_at, is_active, scopes
 		FROM auth_session
 		WHERE user_id = ? AND is_active = 1
 		LIMIT 1`, userID); sess != nil {
@@ -147,7 +177,7 @@ func (s *SessionStore) GetForUser(userID string) *Session {
 	}
 	// Fallback for legacy/edge states: return first session even if no active flag.
 	return s.querySession(`
-		SELECT character_id, character_name, access_token, refresh_token, expires_at, is_active
+		SELECT character_id, character_name, access_token, refresh_token, expires_at, is_active, scopes
 		FROM auth_session
 		WHERE user_id = ?
 		ORDER BY character_name ASC, character_id ASC
@@ -164,12 +194,31 @@ func (s *SessionStore) GetByCharacterIDForUser(userID string, characterID int64)
 	userID = normalizeUserID(userID)
 
 	return s.querySession(`
-		SELECT character_id, character_name, access_token, refresh_token, expires_at, is_active
+		SELECT character_id, character_name, access_token, refresh_token, expires_at, is_active, scopes
 		FROM auth_session
 		WHERE user_id = ? AND character_id = ?
 		LIMIT 1`, userID, characterID)
 }
 
+// ListUserIDs returns every user that has at least one stored session.
+func (s *SessionStore) ListUserIDs() []string {
+	rows, err := s.db.Query(`SELECT DISTINCT user_id FROM auth_session ORDER BY user_id`)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			continue
+		}
+		out = append(out, userID)
+	}
+	return out
+}
+
 // List returns all stored character sessions (active first).
 func (s *SessionStore) List() []*Session {
 	return s.ListForUser(defaultUserID)
@@ -180,7 +229,7 @@ func (s *SessionStore) ListForUser(userID string) []*Session {
 	userID = normalizeUserID(userID)
 
 	rows, err := s.db.Query(`
-		SELECT character_id, character_name, access_token, refresh_token, expires_at, is_active
+		SELECT character_id, character_name, access_token, refresh_token, expires_at, is_active, scopes
 		FROM auth_session
 		WHERE user_id = ?
 		ORDER BY is_active DESC, character_name ASC, character_id ASC`, userID)
@@ -194,7 +243,7 @@ func (s *SessionStore) ListForUser(userID string) []*Session {
 		var sess Session
 		var expiresUnix int64
 		var activeInt int
-		if err := rows.Scan(&sess.CharacterID, &sess.CharacterName, &sess.AccessToken, &sess.RefreshToken, &expiresUnix, &activeInt); err != nil {
+		if err := rows.Scan(&sess.CharacterID, &sess.CharacterName, &sess.AccessToken, &sess.RefreshToken, &expiresUnix, &activeInt, &sess.Scopes); err != nil {
 			continue
 		}
 		sess.ExpiresAt = time.Unix(expiresUnix, 0)
@@ -209,7 +258,7 @@ func (s *SessionStore) querySession(query string, args ...interface{}) *Session
 	var expiresUnix int64
 	var activeInt int
 	err := s.db.QueryRow(query, args...).
-		Scan(&sess.CharacterID, &sess.CharacterName, &sess.AccessToken, &sess.RefreshToken, &expiresUnix, &activeInt)
+		Scan(&sess.CharacterID, &sess.CharacterName, &sess.AccessToken, &sess.RefreshToken, &expiresUnix, &activeInt, &sess.Scopes)
 	if err != nil {
 		return nil
 	}
@@ -357,8 +406,17 @@ func (s *SessionStore) ensureValidTokenForSession(userID string, sess *Session,
 	log.Printf("[AUTH] Refreshing token for %s", sess.CharacterName)
 	tok, err := sso.RefreshToken(sess.RefreshToken)
 	if err != nil {
-		_ = s.DeleteByCharacterIDForUser(userID, sess.CharacterID)
-		return "", fmt.Errorf("refresh failed: %w", err)
+		if !IsTokenRevoked(err) {
+			// Transient SSO failure: keep the session so a later call can retry.
+			return "", fmt.Errorf("refresh failed: %w", err)
+		}
+		log.Printf("[AUTH] Refresh token revoked for %s, removing session", sess.CharacterName)
+		if delErr := s.DeleteByCharacterIDForUser(userID, sess.CharacterID); delErr != nil {
+			log.Printf("[AUTH] Failed to remove revoked session for %s: %v", sess.CharacterName, delErr)
+		} else if s.onRevoked != nil {
+			s.onRevoked(userID, sess.CharacterID)
+		}
+		return "", fmt.Errorf("not logged in: %s must log in again (refresh token revoked)", sess.CharacterName)
 	}
 
 	sess.AccessToken = tok.AccessToken
@@ -0,0 +1,275 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxRadius             = 50
+	maxAvgPricePeriod     = 365
+	maxSourceRegions      = 32
+	maxAIWikiPages        = 32
+	maxCategoryIDs        = 64
+	maxExcludedIDs        = 500
+	maxESIRetries         = 10 // matches esi.MaxRetriesLimit
+	minDemandCacheMins    = 1
+	maxDemandCacheMins    = 24 * 60
+	maxInventoryCacheMins = 60
+	maxRetentionDays      = 3650
+	minMarketHistoryDays  = 30 // the widest metric windows need a month of history
+	defaultAvgPriceDays   = 14
+)
+
+// Clamp returns a copy of cfg with every value moved into its valid range,
+// plus a human-readable note for each value that changed. cfg itself is
+// not modified.
+func Clamp(cfg *Config) (*Config, []string) {
+	if cfg == nil {
+		cfg = Default()
+	}
+	c := *cfg
+	a := &adjuster{}
+
+	a.floatMin("cargo_capacity", &c.CargoCapacity, 0)
+	a.intRange("buy_radius", &c.BuyRadius, 0, maxRadius)
+	a.intRange("sell_radius", &c.SellRadius, 0, maxRadius)
+	a.floatRange("min_margin", &c.MinMargin, 0, 100)
+	a.floatRange("sales_tax_percent", &c.SalesTaxPercent, 0, 100)
+	a.floatRange("broker_fee_percent", &c.BrokerFeePercent, 0, 100)
+	a.floatRange("buy_broker_fee_percent", &c.BuyBrokerFeePercent, 0, 100)
+	a.floatRange("sell_broker_fee_percent", &c.SellBrokerFeePercent, 0, 100)
+	a.floatRange("buy_sales_tax_percent", &c.BuySalesTaxPercent, 0, 100)
+	a.floatRange("sell_sales_tax_percent", &c.SellSalesTaxPercent, 0, 100)
+	if c.MinDailyVolume < 0 {
+		a.notef("min_daily_volume raised from %d to 0", c.MinDailyVolume)
+		c.MinDailyVolume = 0
+	}
+	a.floatMin("max_investment", &c.MaxInvestment, 0)
+	a.floatMin("min_item_profit", &c.MinItemProfit, 0)
+	a.floatMin("min_s2b_per_day", &c.MinS2BPerDay, 0)
+	a.floatMin("min_bfs_per_day", &c.MinBfSPerDay, 0)
+	a.floatMin("min_s2b_bfs_ratio", &c.MinS2BBfSRatio, 0)
+	a.floatMin("max_s2b_bfs_ratio", &c.MaxS2BBfSRatio, 0)
+	a.floatRange("min_route_security", &c.MinRouteSecurity, 0, 1)
+	if c.AvgPricePeriod <= 0 {
+		a.notef("avg_price_period %d is not positive, reset to %d", c.AvgPricePeriod, defaultAvgPriceDays)
+		c.AvgPricePeriod = defaultAvgPriceDays
+	} else {
+		a.intRange("avg_price_period", &c.AvgPricePeriod, 1, maxAvgPricePeriod)
+	}
+	a.floatMin("min_period_roi", &c.MinPeriodROI, 0)
+	a.floatMin("max_dos", &c.MaxDOS, 0)
+	a.floatMin("min_demand_per_day", &c.MinDemandPerDay, 0)
+	a.floatMin("purchase_demand_days", &c.PurchaseDemandDays, 0)
+	a.floatMin("shipping_cost_per_m3_jump", &c.ShippingCostPerM3Jump, 0)
+	if c.TargetMarketLocationID < 0 {
+		a.notef("target_market_location_id %d is negative, cleared", c.TargetMarketLocationID)
+		c.TargetMarketLocationID = 0
+	}
+	c.TargetRegion = strings.TrimSpace(c.TargetRegion)
+	c.TargetMarketSystem = strings.TrimSpace(c.TargetMarketSystem)
+
+	if c.SourceRegions != nil {
+		clean := make([]string, 0, len(c.SourceRegions))
+		seen := make(map[string]bool, len(c.SourceRegions))
+		dropped := 0
+		for _, name := range c.SourceRegions {
+			trimmed := strings.TrimSpace(name)
+			key := strings.ToLower(trimmed)
+			if trimmed == "" || seen[key] || len(clean) >= maxSourceRegions {
+				dropped++
+				continue
+			}
+			seen[key] = true
+			clean = append(clean, trimmed)
+		}
+		if dropped > 0 {
+			a.notef("source_regions: dropped %d blank, duplicate or excess entries (max %d)", dropped, maxSourceRegions)
+		}
+		c.SourceRegions = clean
+	}
+	a.idList("category_ids", &c.CategoryIDs, maxCategoryIDs)
+	a.idList("exclude_type_ids", &c.ExcludeTypeIDs, maxExcludedIDs)
+	a.idList("exclude_market_group_ids", &c.ExcludeMarketGroupIDs, maxExcludedIDs)
+
+	if locale := NormalizeAINumberLocale(c.AINumberLocale); locale != c.AINumberLocale {
+		if locale == "" && strings.TrimSpace(c.AINumberLocale) != "" {
+			a.notef("ai_number_locale %q not recognized, cleared", c.AINumberLocale)
+		}
+		c.AINumberLocale = locale
+	}
+	if format := NormalizeAIISKFormat(c.AIISKFormat); format != c.AIISKFormat {
+		if format == "" {
+			if strings.TrimSpace(c.AIISKFormat) != "" {
+				a.notef("ai_isk_format %q not recognized, reset to \"full\"", c.AIISKFormat)
+			}
+			format = "full"
+		}
+		c.AIISKFormat = format
+	}
+	if c.AIWikiPages != nil {
+		clean, dropped := SanitizeAIWikiPages(c.AIWikiPages)
+		if dropped > 0 {
+			a.notef("ai_wiki_pages: dropped %d invalid, duplicate or excess entries (max %d)", dropped, maxAIWikiPages)
+		}
+		if len(clean) == 0 {
+			a.notef("ai_wiki_pages is empty, reset to the default pages")
+			clean = DefaultAIWikiPages()
+		}
+		c.AIWikiPages = clean
+	}
+	if c.AIToolAllowlist != nil {
+		clean := make([]string, 0, len(c.AIToolAllowlist))
+		seen := make(map[string]bool, len(c.AIToolAllowlist))
+		dropped := 0
+		for _, name := range c.AIToolAllowlist {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if (name != AIToolRunStationScan && name != AIToolOpenMarket) || seen[name] {
+				dropped++
+				continue
+			}
+			seen[name] = true
+			clean = append(clean, name)
+		}
+		if dropped > 0 {
+			a.notef("ai_tool_allowlist: dropped %d unknown or duplicate entries", dropped)
+		}
+		c.AIToolAllowlist = clean
+	}
+	a.intRange("opacity", &c.Opacity, 0, 100)
+	a.intRange("esi_max_retries", &c.ESIMaxRetries, 0, maxESIRetries)
+	a.intRange("demand_cache_minutes", &c.DemandCacheMinutes, minDemandCacheMins, maxDemandCacheMins)
+	a.intRange("inventory_cache_minutes", &c.InventoryCacheMinutes, 0, maxInventoryCacheMins)
+	a.intRange("history_retention_days", &c.HistoryRetentionDays, 1, maxRetentionDays)
+	a.intRange("market_history_retention_days", &c.MarketHistoryRetentionDays, minMarketHistoryDays, maxRetentionDays)
+	a.intRange("share_link_ttl_hours", &c.ShareLinkTTLHours, 1, MaxShareLinkTTLHours)
+	if !c.AlertTelegram && !c.AlertDiscord && !c.AlertDesktop {
+		a.notef("alert_desktop enabled because at least one alert channel is required")
+		c.AlertDesktop = true
+	}
+	return &c, a.notes
+}
+
+// NormalizeAINumberLocale maps user input to a supported number locale
+// (en-US, de-DE, ru-RU), or "" for unknown input.
+func NormalizeAINumberLocale(v string) string {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(v), "_", "-")) {
+	case "en", "en-us", "en-gb":
+		return "en-US"
+	case "de", "de-de", "eu":
+		return "de-DE"
+	case "ru", "ru-ru", "fr", "fr-fr":
+		return "ru-RU"
+	}
+	return ""
+}
+
+var aiWikiPageRe = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// SanitizeAIWikiPage turns user input into a GitHub wiki page name: spaces
+// become dashes and a trailing ".md" is dropped. Names with characters
+// outside [A-Za-z0-9._-], or made only of dots, yield "".
+func SanitizeAIWikiPage(page string) string {
+	page = strings.TrimSpace(page)
+	page = strings.TrimSuffix(page, ".md")
+	page = strings.Join(strings.Fields(page), "-")
+	if len(page) > 100 || !aiWikiPageRe.MatchString(page) || strings.Trim(page, ".") == "" {
+		return ""
+	}
+	return page
+}
+
+// SanitizeAIWikiPages sanitizes each page, dropping invalid entries,
+// case-insensitive duplicates and anything past the first 32. It returns
+// the cleaned list and how many entries were dropped.
+func SanitizeAIWikiPages(pages []string) ([]string, int) {
+	clean := make([]string, 0, len(pages))
+	seen := make(map[string]bool, len(pages))
+	dropped := 0
+	for _, p := range pages {
+		name := SanitizeAIWikiPage(p)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] || len(clean) >= maxAIWikiPages {
+			dropped++
+			continue
+		}
+		seen[key] = true
+		clean = append(clean, name)
+	}
+	return clean, dropped
+}
+
+// NormalizeAIISKFormat returns "full", "compact" or "" for unknown input.
+func NormalizeAIISKFormat(v string) string {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "full":
+		return "full"
+	case "compact", "short":
+		return "compact"
+	}
+	return ""
+}
+
+// adjuster collects the notes produced by Clamp.
+type adjuster struct {
+	notes []string
+}
+
+func (a *adjuster) notef(format string, args ...interface{}) {
+	a.notes = append(a.notes, fmt.Sprintf(format, args...))
+}
+
+func (a *adjuster) intRange(name string, v *int, lo, hi int) {
+	switch {
+	case *v < lo:
+		a.notef("%s raised from %d to %d", name, *v, lo)
+		*v = lo
+	case *v > hi:
+		a.notef("%s reduced from %d to %d", name, *v, hi)
+		*v = hi
+	}
+}
+
+func (a *adjuster) floatMin(name string, v *float64, lo float64) {
+	if *v < lo {
+		a.notef("%s raised from %s to %s", name, formatNumber(*v), formatNumber(lo))
+		*v = lo
+	}
+}
+
+func (a *adjuster) floatRange(name string, v *float64, lo, hi float64) {
+	a.floatMin(name, v, lo)
+	if *v > hi {
+		a.notef("%s reduced from %s to %s", name, formatNumber(*v), formatNumber(hi))
+		*v = hi
+	}
+}
+
+// idList drops non-positive, duplicate and excess IDs. A nil list stays nil.
+func (a *adjuster) idList(name string, v *[]int32, max int) {
+	if *v == nil {
+		return
+	}
+	clean := make([]int32, 0, len(*v))
+	seen := make(map[int32]bool, len(*v))
+	dropped := 0
+	for _, id := range *v {
+		if id <= 0 || seen[id] || len(clean) >= max {
+			dropped++
+			continue
+		}
+		seen[id] = true
+		clean = append(clean, id)
+	}
+	if dropped > 0 {
+		a.notef("%s: dropped %d invalid, duplicate or excess entries (max %d)", name, dropped, max)
+	}
+	*v = clean
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
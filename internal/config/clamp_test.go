@@ -0,0 +1,104 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClamp_ReportsAdjustments(t *testing.T) {
+	in := Default()
+	in.Opacity = 80
+	in.BuyRadius = 80
+	in.MinMargin = -3
+	in.SourceRegions = []string{"The Forge", " the forge ", ""}
+	in.AIISKFormat = "weird"
+	in.AlertDesktop = false
+
+	out, notes := Clamp(in)
+	if out.BuyRadius != 50 || out.MinMargin != 0 || out.AIISKFormat != "full" || !out.AlertDesktop {
+		t.Fatalf("clamped = %+v", out)
+	}
+	if !reflect.DeepEqual(out.SourceRegions, []string{"The Forge"}) {
+		t.Fatalf("SourceRegions = %v", out.SourceRegions)
+	}
+	want := []string{
+		"buy_radius reduced from 80 to 50",
+		"min_margin raised from -3 to 0",
+		"source_regions: dropped 2 blank, duplicate or excess entries (max 32)",
+		`ai_isk_format "weird" not recognized, reset to "full"`,
+		"alert_desktop enabled because at least one alert channel is required",
+	}
+	if !reflect.DeepEqual(notes, want) {
+		t.Fatalf("notes = %q\nwant %q", notes, want)
+	}
+	if in.BuyRadius != 80 || len(in.SourceRegions) != 3 {
+		t.Fatal("Clamp modified its input")
+	}
+}
+
+func TestClamp_ValidConfigUnchanged(t *testing.T) {
+	in := Default()
+	in.Opacity = 90
+	out, notes := Clamp(in)
+	if len(notes) != 0 {
+		t.Fatalf("notes = %q, want none", notes)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("clamped = %+v, want %+v", out, in)
+	}
+}
+
+func TestClamp_ExclusionIDs(t *testing.T) {
+	in := Default()
+	in.Opacity = 90
+	in.ExcludeTypeIDs = []int32{40520, 0, 40520, -1, 44992}
+	in.ExcludeMarketGroupIDs = []int32{1954}
+
+	out, notes := Clamp(in)
+	if !reflect.DeepEqual(out.ExcludeTypeIDs, []int32{40520, 44992}) {
+		t.Fatalf("ExcludeTypeIDs = %v", out.ExcludeTypeIDs)
+	}
+	if !reflect.DeepEqual(out.ExcludeMarketGroupIDs, []int32{1954}) {
+		t.Fatalf("ExcludeMarketGroupIDs = %v", out.ExcludeMarketGroupIDs)
+	}
+	want := []string{"exclude_type_ids: dropped 3 invalid, duplicate or excess entries (max 500)"}
+	if !reflect.DeepEqual(notes, want) {
+		t.Fatalf("notes = %q\nwant %q", notes, want)
+	}
+}
+
+func TestClamp_AIWikiPages(t *testing.T) {
+	in := Default()
+	in.Opacity = 90
+	in.AIWikiPages = []string{" Trading Guide ", "trading-guide", "Home.md", "../secrets", "..", ""}
+
+	out, notes := Clamp(in)
+	if !reflect.DeepEqual(out.AIWikiPages, []string{"Trading-Guide", "Home"}) {
+		t.Fatalf("AIWikiPages = %v", out.AIWikiPages)
+	}
+	want := []string{"ai_wiki_pages: dropped 4 invalid, duplicate or excess entries (max 32)"}
+	if !reflect.DeepEqual(notes, want) {
+		t.Fatalf("notes = %q\nwant %q", notes, want)
+	}
+
+	in.AIWikiPages = []string{"a/b"}
+	out, _ = Clamp(in)
+	if !reflect.DeepEqual(out.AIWikiPages, DefaultAIWikiPages()) {
+		t.Fatalf("all-invalid AIWikiPages = %v, want defaults", out.AIWikiPages)
+	}
+}
+
+func TestClamp_AIToolAllowlist(t *testing.T) {
+	in := Default()
+	in.Opacity = 90
+	in.AIToolAllowlist = []string{" Open_Market ", "open_market", "delete_assets"}
+
+	out, notes := Clamp(in)
+	if !reflect.DeepEqual(out.AIToolAllowlist, []string{AIToolOpenMarket}) {
+		t.Fatalf("AIToolAllowlist = %v", out.AIToolAllowlist)
+	}
+	want := []string{"ai_tool_allowlist: dropped 2 unknown or duplicate entries"}
+	if !reflect.DeepEqual(notes, want) {
+		t.Fatalf("notes = %q\nwant %q", notes, want)
+	}
+}
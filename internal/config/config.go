@@ -9,6 +9,10 @@ type WatchlistItem struct {
 	AlertEnabled   bool    `json:"alert_enabled"`
 	AlertMetric    string  `json:"alert_metric"`    // margin_percent | total_profit | profit_per_unit | daily_volume
 	AlertThreshold float64 `json:"alert_threshold"` // threshold for selected metric
+	// Demand-spike alert: fires when the item's estimated daily losses in
+	// RegionID reach DemandAlertThreshold units/day. 0 = off.
+	RegionID             int32   `json:"region_id,omitempty"`
+	DemandAlertThreshold float64 `json:"demand_alert_threshold,omitempty"`
 }
 
 // Config holds application settings (in-memory representation).
@@ -51,17 +55,106 @@ type Config struct {
 	CategoryIDs            []int32  `json:"category_ids"`
 	SellOrderMode          bool     `json:"sell_order_mode"`
 
+	// Types and market groups (with descendants) dropped from every scan.
+	ExcludeTypeIDs        []int32 `json:"exclude_type_ids"`
+	ExcludeMarketGroupIDs []int32 `json:"exclude_market_group_ids"`
+
 	AlertTelegram       bool   `json:"alert_telegram"`
 	AlertDiscord        bool   `json:"alert_discord"`
 	AlertDesktop        bool   `json:"alert_desktop"`
 	AlertTelegramToken  string `json:"alert_telegram_token"`
 	AlertTelegramChatID string `json:"alert_telegram_chat_id"`
 	AlertDiscordWebhook string `json:"alert_discord_webhook"`
+	AlertOrderExpiry    bool   `json:"alert_order_expiry"` // remind before open market orders expire
+	AlertUndercut       bool   `json:"alert_undercut"`     // ping when the active character's orders get undercut
 	Opacity             int    `json:"opacity"`
 	WindowX             int    `json:"window_x"`
 	WindowY             int    `json:"window_y"`
 	WindowW             int    `json:"window_w"`
 	WindowH             int    `json:"window_h"`
+
+	// AI answer formatting preferences.
+	AINumberLocale string `json:"ai_number_locale"` // "" (follow chat locale) | en-US | de-DE | ru-RU
+	AIISKFormat    string `json:"ai_isk_format"`    // full | compact
+
+	// AIWikiPages are the wiki pages the assistant's keyword fallback reads
+	// when semantic wiki retrieval has no hits. Forks with their own wiki
+	// layout replace them.
+	AIWikiPages []string `json:"ai_wiki_pages"`
+
+	// AIToolsEnabled lets the assistant run server-side tools (scans, in-game
+	// windows) for trading questions; AIToolAllowlist names the tools it may
+	// use. Both are required: an empty allowlist disables every tool.
+	AIToolsEnabled  bool     `json:"ai_tools_enabled"`
+	AIToolAllowlist []string `json:"ai_tool_allowlist"`
+
+	// PriceFallbackEnabled lets station scans use third-party aggregate
+	// prices for hub regions when ESI returns no orders.
+	PriceFallbackEnabled bool `json:"price_fallback_enabled"`
+	// StationTrendsEnabled records a daily CTS/profit/margin snapshot per item
+	// after each station scan, for GET /api/station/trends.
+	StationTrendsEnabled bool `json:"station_trends_enabled"`
+
+	// ESIMaxRetries is how often transient ESI errors (5xx, 420) are retried.
+	// Server-level: only the default user's value applies.
+	ESIMaxRetries int `json:"esi_max_retries"`
+
+	// DemandCacheMinutes is how long zKillboard region stats are reused
+	// before the demand analyzer queries zKillboard again. Server-level:
+	// only the default user's value applies.
+	DemandCacheMinutes int `json:"demand_cache_minutes"`
+
+	// InventoryCacheMinutes is how long a regional day-trader scan reuses the
+	// assets and sell orders fetched by a previous scan. 0 always refetches.
+	InventoryCacheMinutes int `json:"inventory_cache_minutes"`
+
+	// HistoryRetentionDays is how long scan history is kept, and
+	// MarketHistoryRetentionDays how many days of cached ESI market history
+	// are kept per region and type. Both are enforced at startup and by a
+	// periodic prune. Server-level: only the default user's values apply.
+	HistoryRetentionDays       int `json:"history_retention_days"`
+	MarketHistoryRetentionDays int `json:"market_history_retention_days"`
+
+	// ShareLinkTTLHours is how long a read-only scan share link stays valid
+	// unless the request creating it asks for less or more.
+	ShareLinkTTLHours int `json:"share_link_ttl_hours"`
+}
+
+// Default retention windows for scan history and cached market history.
+const (
+	DefaultHistoryRetentionDays       = 30
+	DefaultMarketHistoryRetentionDays = 90
+)
+
+// Bounds for share link lifetimes.
+const (
+	DefaultShareLinkTTLHours = 72
+	MaxShareLinkTTLHours     = 30 * 24
+)
+
+// Tools the assistant can be allowed to call.
+const (
+	AIToolRunStationScan = "run_station_scan"
+	AIToolOpenMarket     = "open_market"
+)
+
+// DefaultAIWikiPages returns the pages of the project wiki searched by the
+// assistant's fallback retrieval.
+func DefaultAIWikiPages() []string {
+	return []string{
+		"Home",
+		"Station-Trading",
+		"Execution-Plan",
+		"Radius-Scan",
+		"Region-Arbitrage",
+		"Route-Trading",
+		"Contract-Scanner",
+		"Industry-Chain-Optimizer",
+		"Getting-Started",
+		"API-Reference",
+		"PLEX-Dashboard",
+		"War-Tracker",
+	}
 }
 
 // Default returns a Config with sensible defaults.
@@ -88,10 +181,20 @@ func Default() *Config {
 			"Metropolis",
 			"Heimatar",
 		},
-		TargetMarketSystem: "Jita",
-		AlertDesktop:       true,
-		Opacity:            230,
-		WindowW:            800,
-		WindowH:            600,
+		TargetMarketSystem:    "Jita",
+		AlertDesktop:          true,
+		Opacity:               230,
+		WindowW:               800,
+		WindowH:               600,
+		AIISKFormat:           "full",
+		AIWikiPages:           DefaultAIWikiPages(),
+		AIToolAllowlist:       []string{AIToolRunStationScan, AIToolOpenMarket},
+		ESIMaxRetries:         3,
+		DemandCacheMinutes:    30,
+		InventoryCacheMinutes: 5,
+
+		HistoryRetentionDays:       DefaultHistoryRetentionDays,
+		MarketHistoryRetentionDays: DefaultMarketHistoryRetentionDays,
+		ShareLinkTTLHours:          DefaultShareLinkTTLHours,
 	}
 }
@@ -213,6 +213,7 @@ var refTypeCategory = map[string]string{
 var categoryLabels = map[string]string{
 	"bounties": "Bounties & Ratting",
 	"market":   "Market Operations",
+	"market_escrow": "Market Escrow",
 	"mining":   "Moon Mining",
 	"pi":       "Planetary Interaction",
 	"industry": "Industry",
@@ -224,6 +224,7 @@ func (d *DemoCorpProvider) GetJournal(division int, days int) ([]CorpJournalEntr
 				FirstPartyName:  member.Name,
 				SecondPartyID:   98000042, // corp ID
 				SecondPartyName: "Void Horizons",
+				Division:        division,
 			})
 		}
 	}
@@ -134,6 +134,7 @@ func (e *ESICorpProvider) GetJournal(division int, days int) ([]CorpJournalEntry
 			Description:   entry.Description,
 			FirstPartyID:  entry.FirstPartyID,
 			SecondPartyID: entry.SecondPartyID,
+			Division:      division,
 		})
 	}
 
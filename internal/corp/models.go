@@ -27,6 +27,7 @@ type CorpJournalEntry struct {
 	SecondPartyID  int64   `json:"second_party_id,omitempty"`
 	FirstPartyName string  `json:"first_party_name,omitempty"`  // enriched
 	SecondPartyName string `json:"second_party_name,omitempty"` // enriched
+	Division       int     `json:"division,omitempty"`          // wallet division the entry was fetched from
 }
 
 // CorpTransaction mirrors ESI GET /corporations/{id}/wallets/{division}/transactions/.
@@ -229,3 +230,47 @@ type CharacterRoles struct {
 	IsDirector   bool     `json:"is_director"`
 	CorporationID int32   `json:"corporation_id"`
 }
+
+// ============================================================
+// Wallet summary
+// ============================================================
+
+// WalletSummary is the response for GET /api/corp/wallets: per-division
+// net flow over the last Days days, broken down by category.
+type WalletSummary struct {
+	IsDemo        bool                 `json:"is_demo"`
+	Days          int                  `json:"days"`
+	Since         string               `json:"since"` // YYYY-MM-DD, inclusive
+	TotalBalance  float64              `json:"total_balance"`
+	TotalIncome   float64              `json:"total_income"`
+	TotalExpenses float64              `json:"total_expenses"` // negative
+	NetFlow       float64              `json:"net_flow"`
+	Divisions     []DivisionFlow       `json:"divisions"`
+	Categories    []WalletCategoryFlow `json:"categories"` // corp-wide
+	// Warnings name divisions whose journal failed to load; their flows
+	// are missing from the totals.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// DivisionFlow summarizes one wallet division over the window.
+type DivisionFlow struct {
+	Division     int                  `json:"division"`
+	Name         string               `json:"name"`
+	Balance      float64              `json:"balance"`       // current balance
+	StartBalance float64              `json:"start_balance"` // balance before the first entry in the window
+	BalanceDelta float64              `json:"balance_delta"` // sum of entry amounts in the window
+	Income       float64              `json:"income"`
+	Expenses     float64              `json:"expenses"` // negative
+	Entries      int                  `json:"entries"`
+	Categories   []WalletCategoryFlow `json:"categories"`
+}
+
+// WalletCategoryFlow is income/expense for one ref_type category.
+type WalletCategoryFlow struct {
+	Category string  `json:"category"` // taxes, market_escrow, market, industry, bounties, ...
+	Label    string  `json:"label"`
+	Income   float64 `json:"income"`
+	Expenses float64 `json:"expenses"` // negative
+	Net      float64 `json:"net"`
+	Entries  int     `json:"entries"`
+}
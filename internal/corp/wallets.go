@@ -0,0 +1,190 @@
+package corp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultWalletSummaryDays is the window used when none is requested.
+const DefaultWalletSummaryDays = 30
+
+// walletCategory maps a journal ref_type to a wallet summary category.
+// Escrow is split out of "market" because it is ISK parked in buy orders
+// rather than spent.
+func walletCategory(refType string) string {
+	if refType == "market_escrow" {
+		return "market_escrow"
+	}
+	if cat := refTypeCategory[refType]; cat != "" {
+		return cat
+	}
+	return "other"
+}
+
+// BuildWalletSummary fetches wallets and all 7 division journals from the
+// provider and summarizes the last days days. A division whose journal fails
+// is left out and reported in Warnings; only a wallets error is fatal.
+func BuildWalletSummary(provider CorpDataProvider, days int) (*WalletSummary, error) {
+	if days <= 0 {
+		days = DefaultWalletSummaryDays
+	}
+	var (
+		wallets    []CorpWalletDivision
+		walletsErr error
+		journal    []CorpJournalEntry
+		journalErr [8]error // by division
+		journalMu  sync.Mutex
+		wg         sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wallets, walletsErr = provider.GetWallets()
+	}()
+	for div := 1; div <= 7; div++ {
+		wg.Add(1)
+		go func(d int) {
+			defer wg.Done()
+			entries, err := provider.GetJournal(d, days)
+			journalMu.Lock()
+			defer journalMu.Unlock()
+			if err != nil {
+				journalErr[d] = err
+				return
+			}
+			journal = append(journal, entries...)
+		}(div)
+	}
+	wg.Wait()
+	if walletsErr != nil {
+		return nil, walletsErr
+	}
+
+	summary := SummarizeWallets(journal, wallets, days, time.Now().UTC())
+	summary.IsDemo = provider.IsDemo()
+	for div, err := range journalErr {
+		if err != nil {
+			summary.Warnings = append(summary.Warnings, fmt.Sprintf("division %d journal: %v", div, err))
+		}
+	}
+	return summary, nil
+}
+
+// SummarizeWallets computes per-division balance deltas and category
+// breakdowns for journal entries dated within the last days days.
+// Entries are attributed by their Division field; divisions supplies names
+// (from the read_divisions scope) and current balances.
+func SummarizeWallets(journal []CorpJournalEntry, divisions []CorpWalletDivision, days int, now time.Time) *WalletSummary {
+	if days <= 0 {
+		days = DefaultWalletSummaryDays
+	}
+	since := now.AddDate(0, 0, -days).Format("2006-01-02")
+	summary := &WalletSummary{Days: days, Since: since}
+
+	flows := make(map[int]*DivisionFlow, len(divisions))
+	for _, d := range divisions {
+		flows[d.Division] = &DivisionFlow{Division: d.Division, Name: d.Name, Balance: d.Balance}
+		summary.TotalBalance += d.Balance
+	}
+
+	inWindow := make([]CorpJournalEntry, 0, len(journal))
+	for _, e := range journal {
+		if len(e.Date) >= 10 && e.Date[:10] >= since {
+			inWindow = append(inWindow, e)
+		}
+	}
+	sort.SliceStable(inWindow, func(i, j int) bool {
+		if inWindow[i].Date != inWindow[j].Date {
+			return inWindow[i].Date < inWindow[j].Date
+		}
+		return inWindow[i].ID < inWindow[j].ID
+	})
+
+	corpCats := make(map[string]*WalletCategoryFlow)
+	divCats := make(map[int]map[string]*WalletCategoryFlow)
+	for _, e := range inWindow {
+		cat := walletCategory(e.RefType)
+		addCategoryFlow(corpCats, cat, e.Amount)
+		if e.Amount > 0 {
+			summary.TotalIncome += e.Amount
+		} else {
+			summary.TotalExpenses += e.Amount
+		}
+		if e.Division <= 0 {
+			continue
+		}
+
+		flow := flows[e.Division]
+		if flow == nil {
+			flow = &DivisionFlow{Division: e.Division, Name: fmt.Sprintf("Division %d", e.Division)}
+			flows[e.Division] = flow
+		}
+		if flow.Entries == 0 {
+			flow.StartBalance = e.Balance - e.Amount
+		}
+		flow.Entries++
+		flow.BalanceDelta += e.Amount
+		if e.Amount > 0 {
+			flow.Income += e.Amount
+		} else {
+			flow.Expenses += e.Amount
+		}
+		if divCats[e.Division] == nil {
+			divCats[e.Division] = make(map[string]*WalletCategoryFlow)
+		}
+		addCategoryFlow(divCats[e.Division], cat, e.Amount)
+	}
+	summary.NetFlow = summary.TotalIncome + summary.TotalExpenses
+	summary.Categories = sortedCategoryFlows(corpCats)
+
+	summary.Divisions = make([]DivisionFlow, 0, len(flows))
+	for div, flow := range flows {
+		if flow.Entries == 0 {
+			flow.StartBalance = flow.Balance
+		}
+		flow.Categories = sortedCategoryFlows(divCats[div])
+		summary.Divisions = append(summary.Divisions, *flow)
+	}
+	sort.Slice(summary.Divisions, func(i, j int) bool {
+		return summary.Divisions[i].Division < summary.Divisions[j].Division
+	})
+	return summary
+}
+
+func addCategoryFlow(m map[string]*WalletCategoryFlow, cat string, amount float64) {
+	f := m[cat]
+	if f == nil {
+		label := categoryLabels[cat]
+		if label == "" {
+			label = cat
+		}
+		f = &WalletCategoryFlow{Category: cat, Label: label}
+		m[cat] = f
+	}
+	f.Entries++
+	f.Net += amount
+	if amount > 0 {
+		f.Income += amount
+	} else {
+		f.Expenses += amount
+	}
+}
+
+// sortedCategoryFlows orders categories by absolute net flow, largest first.
+func sortedCategoryFlows(m map[string]*WalletCategoryFlow) []WalletCategoryFlow {
+	out := make([]WalletCategoryFlow, 0, len(m))
+	for _, f := range m {
+		out = append(out, *f)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ai, aj := math.Abs(out[i].Net), math.Abs(out[j].Net)
+		if ai != aj {
+			return ai > aj
+		}
+		return out[i].Category < out[j].Category
+	})
+	return out
+}
@@ -0,0 +1,75 @@
+package corp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSummarizeWallets(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	divisions := []CorpWalletDivision{
+		{Division: 1, Name: "Master Wallet", Balance: 1_150},
+		{Division: 2, Name: "Industry", Balance: 500},
+	}
+	journal := []CorpJournalEntry{
+		{ID: 1, Division: 1, Date: "2026-01-01T00:00:00Z", RefType: "bounty_prizes", Amount: 9_999, Balance: 9_999}, // outside window
+		{ID: 3, Division: 1, Date: "2026-03-20T10:00:00Z", RefType: "market_escrow", Amount: -300, Balance: 1_150},
+		{ID: 2, Division: 1, Date: "2026-03-10T10:00:00Z", RefType: "bounty_prizes", Amount: 450, Balance: 1_450},
+		{ID: 4, Division: 3, Date: "2026-03-25T10:00:00Z", RefType: "transaction_tax", Amount: -20, Balance: 80},
+	}
+
+	s := SummarizeWallets(journal, divisions, 30, now)
+	if s.Since != "2026-03-01" || s.TotalBalance != 1_650 {
+		t.Fatalf("since=%s total_balance=%v", s.Since, s.TotalBalance)
+	}
+	if s.TotalIncome != 450 || s.TotalExpenses != -320 || s.NetFlow != 130 {
+		t.Fatalf("income=%v expenses=%v net=%v", s.TotalIncome, s.TotalExpenses, s.NetFlow)
+	}
+	if len(s.Divisions) != 3 {
+		t.Fatalf("divisions = %+v", s.Divisions)
+	}
+
+	master := s.Divisions[0]
+	if master.Name != "Master Wallet" || master.StartBalance != 1_000 || master.BalanceDelta != 150 || master.Entries != 2 {
+		t.Fatalf("master = %+v", master)
+	}
+	if len(master.Categories) != 2 || master.Categories[0].Category != "bounties" || master.Categories[1].Category != "market_escrow" {
+		t.Fatalf("master categories = %+v", master.Categories)
+	}
+
+	idle := s.Divisions[1]
+	if idle.Entries != 0 || idle.StartBalance != 500 || len(idle.Categories) != 0 {
+		t.Fatalf("idle division = %+v", idle)
+	}
+	if unnamed := s.Divisions[2]; unnamed.Name != "Division 3" || unnamed.Categories[0].Category != "taxes" {
+		t.Fatalf("unnamed division = %+v", unnamed)
+	}
+}
+
+type journalErrorProvider struct {
+	*DemoCorpProvider
+	failDivision int
+}
+
+func (p journalErrorProvider) GetJournal(division int, days int) ([]CorpJournalEntry, error) {
+	if division == p.failDivision {
+		return nil, errors.New("esi 500")
+	}
+	return p.DemoCorpProvider.GetJournal(division, days)
+}
+
+func TestBuildWalletSummaryReportsJournalErrors(t *testing.T) {
+	s, err := BuildWalletSummary(journalErrorProvider{NewDemoCorpProvider(), 3}, 30)
+	if err != nil {
+		t.Fatalf("BuildWalletSummary: %v", err)
+	}
+	if len(s.Warnings) != 1 || s.Warnings[0] != "division 3 journal: esi 500" {
+		t.Fatalf("warnings = %q", s.Warnings)
+	}
+
+	s, err = BuildWalletSummary(NewDemoCorpProvider(), 30)
+	if err != nil || len(s.Warnings) != 0 {
+		t.Fatalf("clean summary: err=%v warnings=%q", err, s.Warnings)
+	}
+}
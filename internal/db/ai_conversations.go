@@ -0,0 +1,173 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// maxAIConversationTitleLength caps auto-generated conversation titles.
+const maxAIConversationTitleLength = 80
+
+// AIConversation is a stored Station AI chat thread.
+type AIConversation struct {
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	MessageCount int    `json:"message_count"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+}
+
+// AIMessage is one user or assistant turn in a conversation.
+type AIMessage struct {
+	ID        int64  `json:"id"`
+	Role      string `json:"role"` // user | assistant
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+func aiConversationTitle(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > maxAIConversationTitleLength {
+		s = strings.TrimSpace(string(runes[:maxAIConversationTitleLength])) + "..."
+	}
+	return s
+}
+
+// CreateAIConversationForUser starts a new conversation. An empty title is
+// filled in from the first user message appended to it.
+func (d *DB) CreateAIConversationForUser(userID, title string) (AIConversation, error) {
+	userID = normalizeUserID(userID)
+	title = aiConversationTitle(title)
+	now := time.Now().UTC().Format(time.RFC3339)
+	res, err := d.sql.Exec(
+		"INSERT INTO ai_conversations (user_id, title, created_at, updated_at) VALUES (?, ?, ?, ?)",
+		userID, title, now, now,
+	)
+	if err != nil {
+		return AIConversation{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return AIConversation{}, err
+	}
+	return AIConversation{ID: id, Title: title, CreatedAt: now, UpdatedAt: now}, nil
+}
+
+// ListAIConversationsForUser returns the user's conversations, most recently
+// active first.
+func (d *DB) ListAIConversationsForUser(userID string, limit int) ([]AIConversation, error) {
+	userID = normalizeUserID(userID)
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := d.sql.Query(`
+		SELECT c.id, c.title, c.created_at, c.updated_at,
+		       (SELECT COUNT(*) FROM ai_messages m WHERE m.conversation_id = c.id)
+		  FROM ai_conversations c
+		 WHERE c.user_id = ?
+		 ORDER BY c.updated_at DESC, c.id DESC
+		 LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]AIConversation, 0)
+	for rows.Next() {
+		var c AIConversation
+		if err := rows.Scan(&c.ID, &c.Title, &c.CreatedAt, &c.UpdatedAt, &c.MessageCount); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// GetAIConversationForUser returns a conversation with its messages in order.
+// Returns sql.ErrNoRows when the conversation does not belong to the user.
+func (d *DB) GetAIConversationForUser(userID string, conversationID int64) (AIConversation, []AIMessage, error) {
+	userID = normalizeUserID(userID)
+	var c AIConversation
+	err := d.sql.QueryRow(
+		"SELECT id, title, created_at, updated_at FROM ai_conversations WHERE user_id = ? AND id = ?",
+		userID, conversationID,
+	).Scan(&c.ID, &c.Title, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return AIConversation{}, nil, err
+	}
+
+	rows, err := d.sql.Query(
+		"SELECT id, role, content, created_at FROM ai_messages WHERE conversation_id = ? ORDER BY id",
+		conversationID,
+	)
+	if err != nil {
+		return AIConversation{}, nil, err
+	}
+	defer rows.Close()
+
+	messages := make([]AIMessage, 0)
+	for rows.Next() {
+		var m AIMessage
+		if err := rows.Scan(&m.ID, &m.Role, &m.Content, &m.CreatedAt); err != nil {
+			return AIConversation{}, nil, err
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return AIConversation{}, nil, err
+	}
+	c.MessageCount = len(messages)
+	return c, messages, nil
+}
+
+// AppendAIMessagesForUser adds turns to one of the user's conversations and
+// bumps its updated_at. Returns sql.ErrNoRows for unknown conversations.
+func (d *DB) AppendAIMessagesForUser(userID string, conversationID int64, messages []AIMessage) error {
+	userID = normalizeUserID(userID)
+	if len(messages) == 0 {
+		return nil
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var title string
+	if err := tx.QueryRow(
+		"SELECT title FROM ai_conversations WHERE user_id = ? AND id = ?",
+		userID, conversationID,
+	).Scan(&title); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO ai_messages (conversation_id, role, content, created_at) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, m := range messages {
+		if title == "" && m.Role == "user" {
+			title = aiConversationTitle(m.Content)
+		}
+		if _, err := stmt.Exec(conversationID, m.Role, m.Content, now); err != nil {
+			return err
+		}
+	}
+
+	res, err := tx.Exec(
+		"UPDATE ai_conversations SET title = ?, updated_at = ? WHERE user_id = ? AND id = ?",
+		title, now, userID, conversationID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
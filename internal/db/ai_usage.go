@@ -0,0 +1,111 @@
+package db
+
+import (
+	"time"
+)
+
+// AIUsageRecord is the token usage of one LLM call.
+type AIUsageRecord struct {
+	Provider         string
+	Model            string
+	Kind             string // chat | planner
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	CostUSD          float64 // only when the provider reports it (OpenRouter)
+}
+
+// AIUsageBucket aggregates usage for one day or one model.
+type AIUsageBucket struct {
+	Day              string  `json:"day,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	Requests         int     `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// AIUsageSummary is the usage of a user over a trailing window.
+type AIUsageSummary struct {
+	Days    int             `json:"days"`
+	Since   string          `json:"since"`
+	Totals  AIUsageBucket   `json:"totals"`
+	ByDay   []AIUsageBucket `json:"by_day"`
+	ByModel []AIUsageBucket `json:"by_model"`
+}
+
+// RecordAIUsageForUser stores one LLM call's token usage.
+func (d *DB) RecordAIUsageForUser(userID string, rec AIUsageRecord) error {
+	userID = normalizeUserID(userID)
+	if rec.Kind == "" {
+		rec.Kind = "chat"
+	}
+	if rec.TotalTokens <= 0 {
+		rec.TotalTokens = rec.PromptTokens + rec.CompletionTokens
+	}
+	_, err := d.sql.Exec(`
+		INSERT INTO ai_usage (
+			user_id, provider, model, kind, prompt_tokens, completion_tokens, total_tokens, cost_usd, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, userID, rec.Provider, rec.Model, rec.Kind, rec.PromptTokens, rec.CompletionTokens, rec.TotalTokens,
+		rec.CostUSD, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// GetAIUsageSummaryForUser aggregates the user's usage over the last days
+// days, per UTC day (oldest first) and per model (most tokens first).
+func (d *DB) GetAIUsageSummaryForUser(userID string, days int) (AIUsageSummary, error) {
+	userID = normalizeUserID(userID)
+	if days <= 0 {
+		days = 30
+	}
+	since := time.Now().UTC().AddDate(0, 0, -days).Format(time.RFC3339)
+	summary := AIUsageSummary{
+		Days:    days,
+		Since:   since,
+		ByDay:   []AIUsageBucket{},
+		ByModel: []AIUsageBucket{},
+	}
+
+	query := func(groupExpr, order string, scanKey func(*AIUsageBucket) interface{}) ([]AIUsageBucket, error) {
+		rows, err := d.sql.Query(`
+			SELECT `+groupExpr+`, COUNT(*), SUM(prompt_tokens), SUM(completion_tokens), SUM(total_tokens), SUM(cost_usd)
+			  FROM ai_usage
+			 WHERE user_id = ? AND created_at >= ?
+			 GROUP BY 1
+			 ORDER BY `+order, userID, since)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		out := make([]AIUsageBucket, 0)
+		for rows.Next() {
+			var b AIUsageBucket
+			if err := rows.Scan(scanKey(&b), &b.Requests, &b.PromptTokens, &b.CompletionTokens, &b.TotalTokens, &b.CostUSD); err != nil {
+				return nil, err
+			}
+			out = append(out, b)
+		}
+		return out, rows.Err()
+	}
+
+	byDay, err := query("substr(created_at, 1, 10)", "1 ASC", func(b *AIUsageBucket) interface{} { return &b.Day })
+	if err != nil {
+		return summary, err
+	}
+	summary.ByDay = byDay
+	for _, b := range byDay {
+		summary.Totals.Requests += b.Requests
+		summary.Totals.PromptTokens += b.PromptTokens
+		summary.Totals.CompletionTokens += b.CompletionTokens
+		summary.Totals.TotalTokens += b.TotalTokens
+		summary.Totals.CostUSD += b.CostUSD
+	}
+	byModel, err := query("model", "5 DESC, 1 ASC", func(b *AIUsageBucket) interface{} { return &b.Model })
+	if err != nil {
+		return summary, err
+	}
+	summary.ByModel = byModel
+	return summary, nil
+}
@@ -19,6 +19,41 @@ type AlertHistoryEntry struct {
 	ChannelsFailed  map[string]string `json:"channels_failed,omitempty"`
 	SentAt          string            `json:"sent_at"`
 	ScanID          *int64            `json:"scan_id,omitempty"`
+	Source          string            `json:"source"` // "price" (scan results) or "demand" (killmail demand)
+}
+
+// AlertHistoryFilter narrows an alert history query. Zero fields don't filter.
+type AlertHistoryFilter struct {
+	TypeID int32
+	Since  time.Time // sent at or after
+	Until  time.Time // sent before
+	// Channel keeps alerts delivered to this channel ("telegram", "discord"
+	// or "desktop"); alerts that only failed there are left out.
+	Channel string
+}
+
+// Alert history sources.
+const (
+	AlertSourcePrice  = "price"
+	AlertSourceDemand = "demand"
+)
+
+// MarkOrderExpiryAlertedForUser records that the user was warned about
+// orderID on day (YYYY-MM-DD). It returns false if that warning was already
+// recorded, so each order is announced at most once per day.
+func (d *DB) MarkOrderExpiryAlertedForUser(userID string, orderID int64, day string) (bool, error) {
+	userID = normalizeUserID(userID)
+	res, err := d.sql.Exec(`
+		INSERT OR IGNORE INTO order_expiry_alerts (user_id, order_id, alert_date)
+		VALUES (?, ?, ?)`, userID, orderID, day)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
 }
 
 // SaveAlertHistory records a sent alert to the history table.
@@ -39,12 +74,15 @@ func (d *DB) SaveAlertHistoryForUser(userID string, entry AlertHistoryEntry) err
 	if entry.SentAt == "" {
 		entry.SentAt = time.Now().UTC().Format(time.RFC3339)
 	}
+	if entry.Source == "" {
+		entry.Source = AlertSourcePrice
+	}
 
 	_, err := d.sql.Exec(`
 		INSERT INTO alert_history (
 			user_id, watchlist_type_id, type_name, alert_metric, alert_threshold,
-			current_value, message, channels_sent, channels_failed, sent_at, scan_id
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			current_value, message, channels_sent, channels_failed, sent_at, scan_id, source
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		userID,
 		entry.WatchlistTypeID,
 		entry.TypeName,
@@ -56,6 +94,7 @@ func (d *DB) SaveAlertHistoryForUser(userID string, entry AlertHistoryEntry) err
 		string(channelsFailedJSON),
 		entry.SentAt,
 		entry.ScanID,
+		entry.Source,
 	)
 	return err
 }
@@ -69,18 +108,19 @@ func (d *DB) GetAlertHistory(typeID int32, limit int) ([]AlertHistoryEntry, erro
 // GetAlertHistoryForUser returns alert history with optional filters for a specific user.
 // If typeID is 0, returns all alerts. Limit controls max results (0 = unlimited).
 func (d *DB) GetAlertHistoryForUser(userID string, typeID int32, limit int) ([]AlertHistoryEntry, error) {
-	return d.GetAlertHistoryPageForUser(userID, typeID, limit, 0)
+	return d.GetAlertHistoryPageForUser(userID, AlertHistoryFilter{TypeID: typeID}, limit, 0)
 }
 
 // GetAlertHistoryPage returns alert history with optional limit/offset pagination.
 // If typeID is 0, returns all alerts. Limit 0 means unlimited.
 func (d *DB) GetAlertHistoryPage(typeID int32, limit int, offset int) ([]AlertHistoryEntry, error) {
-	return d.GetAlertHistoryPageForUser(DefaultUserID, typeID, limit, offset)
+	return d.GetAlertHistoryPageForUser(DefaultUserID, AlertHistoryFilter{TypeID: typeID}, limit, offset)
 }
 
-// GetAlertHistoryPageForUser returns alert history for a specific user with optional limit/offset pagination.
-// If typeID is 0, returns all alerts. Limit 0 means unlimited.
-func (d *DB) GetAlertHistoryPageForUser(userID string, typeID int32, limit int, offset int) ([]AlertHistoryEntry, error) {
+// GetAlertHistoryPageForUser returns alert history for a specific user matching
+// every set field of filter, newest first, with optional limit/offset
+// pagination. Limit 0 means unlimited.
+func (d *DB) GetAlertHistoryPageForUser(userID string, filter AlertHistoryFilter, limit int, offset int) ([]AlertHistoryEntry, error) {
 	userID = normalizeUserID(userID)
 
 	if limit < 0 {
@@ -92,14 +132,27 @@ func (d *DB) GetAlertHistoryPageForUser(userID string, typeID int32, limit int,
 
 	query := `
 		SELECT id, watchlist_type_id, type_name, alert_metric, alert_threshold,
-		       current_value, message, channels_sent, channels_failed, sent_at, scan_id
+		       current_value, message, channels_sent, channels_failed, sent_at, scan_id, source
 		  FROM alert_history
 		 WHERE user_id = ?
 	`
 	args := []interface{}{userID}
-	if typeID > 0 {
+	if filter.TypeID > 0 {
 		query += " AND watchlist_type_id = ?"
-		args = append(args, typeID)
+		args = append(args, filter.TypeID)
+	}
+	// sent_at is stored as UTC RFC3339, so string comparison orders by time.
+	if !filter.Since.IsZero() {
+		query += " AND sent_at >= ?"
+		args = append(args, filter.Since.UTC().Format(time.RFC3339))
+	}
+	if !filter.Until.IsZero() {
+		query += " AND sent_at < ?"
+		args = append(args, filter.Until.UTC().Format(time.RFC3339))
+	}
+	if filter.Channel != "" {
+		query += " AND EXISTS (SELECT 1 FROM json_each(channels_sent) WHERE value = ?)"
+		args = append(args, filter.Channel)
 	}
 	query += " ORDER BY sent_at DESC"
 	if limit > 0 {
@@ -138,6 +191,7 @@ func (d *DB) GetAlertHistoryPageForUser(userID string, typeID int32, limit int,
 			&channelsFailedStr,
 			&e.SentAt,
 			&scanID,
+			&e.Source,
 		); err != nil {
 			return nil, err
 		}
@@ -65,6 +65,9 @@ func TestAlertHistory_SaveAndRetrieve(t *testing.T) {
 	if len(h.ChannelsFailed) != 1 {
 		t.Errorf("expected 1 channel failed, got %d", len(h.ChannelsFailed))
 	}
+	if h.Source != AlertSourcePrice {
+		t.Errorf("expected default source=price, got %q", h.Source)
+	}
 }
 
 func TestAlertHistory_GetLastAlertTime(t *testing.T) {
@@ -229,3 +232,62 @@ func setupTestDB(t *testing.T) *DB {
 	}
 	return db
 }
+
+func TestAlertHistory_FilterByDateAndChannel(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	db.AddWatchlistItem(config.WatchlistItem{
+		TypeID:       34,
+		TypeName:     "Tritanium",
+		AddedAt:      time.Now().UTC().Format(time.RFC3339),
+		AlertEnabled: true,
+	})
+
+	base := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	for i, channels := range [][]string{{"telegram"}, {"discord", "desktop"}, {"telegram", "desktop"}} {
+		err := db.SaveAlertHistory(AlertHistoryEntry{
+			WatchlistTypeID: 34,
+			TypeName:        "Tritanium",
+			AlertMetric:     "margin_percent",
+			Message:         "alert",
+			ChannelsSent:    channels,
+			SentAt:          base.Add(time.Duration(i) * 24 * time.Hour).Format(time.RFC3339),
+		})
+		if err != nil {
+			t.Fatalf("SaveAlertHistory: %v", err)
+		}
+	}
+
+	// A non-UTC bound is compared by instant, not by its local wall clock.
+	plusTwo := time.FixedZone("UTC+2", 2*3600)
+	tests := []struct {
+		name   string
+		filter AlertHistoryFilter
+		want   []string // sent_at, newest first
+	}{
+		{"no filter", AlertHistoryFilter{}, []string{"2026-03-12T12:00:00Z", "2026-03-11T12:00:00Z", "2026-03-10T12:00:00Z"}},
+		{"since inclusive", AlertHistoryFilter{Since: base.Add(24 * time.Hour)}, []string{"2026-03-12T12:00:00Z", "2026-03-11T12:00:00Z"}},
+		{"until exclusive", AlertHistoryFilter{Until: base.Add(24 * time.Hour)}, []string{"2026-03-10T12:00:00Z"}},
+		{"range in other zone", AlertHistoryFilter{Since: time.Date(2026, 3, 11, 14, 0, 0, 0, plusTwo), Until: time.Date(2026, 3, 11, 15, 0, 0, 0, plusTwo)}, []string{"2026-03-11T12:00:00Z"}},
+		{"channel", AlertHistoryFilter{Channel: "desktop"}, []string{"2026-03-12T12:00:00Z", "2026-03-11T12:00:00Z"}},
+		{"channel and range", AlertHistoryFilter{Channel: "telegram", Until: base.Add(48 * time.Hour)}, []string{"2026-03-10T12:00:00Z"}},
+		{"other type", AlertHistoryFilter{TypeID: 35}, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := db.GetAlertHistoryPageForUser(DefaultUserID, tc.filter, 0, 0)
+			if err != nil {
+				t.Fatalf("GetAlertHistoryPageForUser: %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %d entries, want %d", len(got), len(tc.want))
+			}
+			for i := range got {
+				if got[i].SentAt != tc.want[i] {
+					t.Errorf("entry %d sent_at = %s, want %s", i, got[i].SentAt, tc.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"fmt"
+	"os"
+)
+
+// BackupTo writes a consistent copy of the database to path using
+// VACUUM INTO. The copy is taken inside a read transaction, so in WAL mode
+// concurrent writers keep going while it runs. path must not exist yet.
+func (d *DB) BackupTo(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup target %s already exists", path)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("backup target: %w", err)
+	}
+	if _, err := d.sql.Exec("VACUUM INTO ?", path); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("vacuum into: %w", err)
+	}
+	return nil
+}
+
+// Size returns the size of the main database file in bytes (page_count *
+// page_size), excluding the WAL.
+func (d *DB) Size() (int64, error) {
+	var pages, pageSize int64
+	if err := d.sql.QueryRow("PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, err
+	}
+	if err := d.sql.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return pages * pageSize, nil
+}
+
+// Vacuum rebuilds the database file to release free pages and truncates the
+// WAL afterwards. It returns the number of bytes reclaimed. VACUUM holds a
+// write lock for its whole run, so writers wait on busy_timeout meanwhile.
+func (d *DB) Vacuum() (int64, error) {
+	before, err := d.Size()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := d.sql.Exec("VACUUM"); err != nil {
+		return 0, fmt.Errorf("vacuum: %w", err)
+	}
+	d.sql.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	after, err := d.Size()
+	if err != nil {
+		return 0, err
+	}
+	return max(before-after, 0), nil
+}
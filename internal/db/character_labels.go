@@ -0,0 +1,61 @@
+package db
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxCharacterLabelLength is the longest label (in characters) kept for a character.
+const MaxCharacterLabelLength = 64
+
+// NormalizeCharacterLabel trims whitespace and caps the label length.
+func NormalizeCharacterLabel(label string) string {
+	label = strings.TrimSpace(label)
+	if utf8.RuneCountInString(label) > MaxCharacterLabelLength {
+		label = strings.TrimSpace(string([]rune(label)[:MaxCharacterLabelLength]))
+	}
+	return label
+}
+
+// SetCharacterLabelForUser stores a display label for one of the user's
+// characters. An empty label removes it.
+func (d *DB) SetCharacterLabelForUser(userID string, characterID int64, label string) error {
+	userID = normalizeUserID(userID)
+	label = NormalizeCharacterLabel(label)
+	if label == "" {
+		_, err := d.sql.Exec(
+			"DELETE FROM character_labels WHERE user_id = ? AND character_id = ?",
+			userID, characterID,
+		)
+		return err
+	}
+	_, err := d.sql.Exec(`
+		INSERT INTO character_labels (user_id, character_id, label, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id, character_id) DO UPDATE SET
+			label = excluded.label,
+			updated_at = excluded.updated_at
+	`, userID, characterID, label, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// GetCharacterLabelsForUser returns characterID -> label for the user.
+func (d *DB) GetCharacterLabelsForUser(userID string) map[int64]string {
+	userID = normalizeUserID(userID)
+	labels := make(map[int64]string)
+	rows, err := d.sql.Query("SELECT character_id, label FROM character_labels WHERE user_id = ?", userID)
+	if err != nil {
+		return labels
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var label string
+		if err := rows.Scan(&id, &label); err != nil {
+			continue
+		}
+		labels[id] = label
+	}
+	return labels
+}
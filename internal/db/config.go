@@ -1,6 +1,7 @@
 package db
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -19,10 +20,15 @@ func (d *DB) LoadConfig() *config.Config {
 // LoadConfigForUser reads config from SQLite for a specific user.
 // If empty, returns defaults.
 func (d *DB) LoadConfigForUser(userID string) *config.Config {
-	userID = normalizeUserID(userID)
+	return loadConfigForUser(d.sql.Query, normalizeUserID(userID))
+}
+
+// loadConfigForUser reads a user's config through query, which is d.sql.Query
+// or tx.Query so PatchConfigForUser reads inside its transaction.
+func loadConfigForUser(query func(string, ...interface{}) (*sql.Rows, error), userID string) *config.Config {
 	cfg := config.Default()
 
-	rows, err := d.sql.Query("SELECT key, value FROM config WHERE user_id = ?", userID)
+	rows, err := query("SELECT key, value FROM config WHERE user_id = ?", userID)
 	if err != nil {
 		return cfg
 	}
@@ -141,6 +147,18 @@ func (d *DB) LoadConfigForUser(userID string) *config.Config {
 	if v, ok := m["sell_order_mode"]; ok {
 		cfg.SellOrderMode, _ = strconv.ParseBool(v)
 	}
+	if v, ok := m["exclude_type_ids"]; ok {
+		var ids []int32
+		if err := json.Unmarshal([]byte(v), &ids); err == nil {
+			cfg.ExcludeTypeIDs = ids
+		}
+	}
+	if v, ok := m["exclude_market_group_ids"]; ok {
+		var ids []int32
+		if err := json.Unmarshal([]byte(v), &ids); err == nil {
+			cfg.ExcludeMarketGroupIDs = ids
+		}
+	}
 	if v, ok := m["alert_telegram"]; ok {
 		cfg.AlertTelegram, _ = strconv.ParseBool(v)
 	}
@@ -159,6 +177,57 @@ func (d *DB) LoadConfigForUser(userID string) *config.Config {
 	if v, ok := m["alert_discord_webhook"]; ok {
 		cfg.AlertDiscordWebhook = v
 	}
+	if v, ok := m["alert_order_expiry"]; ok {
+		cfg.AlertOrderExpiry, _ = strconv.ParseBool(v)
+	}
+	if v, ok := m["alert_undercut"]; ok {
+		cfg.AlertUndercut, _ = strconv.ParseBool(v)
+	}
+	if v, ok := m["ai_number_locale"]; ok {
+		cfg.AINumberLocale = v
+	}
+	if v, ok := m["ai_isk_format"]; ok {
+		cfg.AIISKFormat = v
+	}
+	if v, ok := m["ai_wiki_pages"]; ok {
+		var pages []string
+		if err := json.Unmarshal([]byte(v), &pages); err == nil && len(pages) > 0 {
+			cfg.AIWikiPages = pages
+		}
+	}
+	if v, ok := m["ai_tools_enabled"]; ok {
+		cfg.AIToolsEnabled, _ = strconv.ParseBool(v)
+	}
+	if v, ok := m["ai_tool_allowlist"]; ok {
+		var tools []string
+		if err := json.Unmarshal([]byte(v), &tools); err == nil {
+			cfg.AIToolAllowlist = tools
+		}
+	}
+	if v, ok := m["price_fallback_enabled"]; ok {
+		cfg.PriceFallbackEnabled, _ = strconv.ParseBool(v)
+	}
+	if v, ok := m["station_trends_enabled"]; ok {
+		cfg.StationTrendsEnabled, _ = strconv.ParseBool(v)
+	}
+	if v, ok := m["esi_max_retries"]; ok {
+		cfg.ESIMaxRetries, _ = strconv.Atoi(v)
+	}
+	if v, ok := m["demand_cache_minutes"]; ok {
+		cfg.DemandCacheMinutes, _ = strconv.Atoi(v)
+	}
+	if v, ok := m["inventory_cache_minutes"]; ok {
+		cfg.InventoryCacheMinutes, _ = strconv.Atoi(v)
+	}
+	if v, ok := m["history_retention_days"]; ok {
+		cfg.HistoryRetentionDays, _ = strconv.Atoi(v)
+	}
+	if v, ok := m["market_history_retention_days"]; ok {
+		cfg.MarketHistoryRetentionDays, _ = strconv.Atoi(v)
+	}
+	if v, ok := m["share_link_ttl_hours"]; ok {
+		cfg.ShareLinkTTLHours, _ = strconv.Atoi(v)
+	}
 	if v, ok := m["opacity"]; ok {
 		cfg.Opacity, _ = strconv.Atoi(v)
 	}
@@ -183,83 +252,155 @@ func (d *DB) SaveConfig(cfg *config.Config) error {
 	return d.SaveConfigForUser(DefaultUserID, cfg)
 }
 
-// SaveConfigForUser writes config to SQLite (upsert all fields) for a specific user.
+// SaveConfigForUser writes config to SQLite (upsert all fields) for a specific user
+// and records a config_history snapshot when anything besides window state changed.
 func (d *DB) SaveConfigForUser(userID string, cfg *config.Config) error {
 	userID = normalizeUserID(userID)
+	d.configMu.Lock()
+	defer d.configMu.Unlock()
+
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	prev := loadConfigForUser(tx.Query, userID)
+	if err := saveConfigTx(tx, userID, prev, cfg); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// PatchConfigForUser applies a partial update to a user's config as one
+// read-modify-write: apply receives the stored config and edits it in place,
+// and the result is saved in the same transaction. Concurrent patches to
+// different fields therefore both persist. Returns the saved config.
+func (d *DB) PatchConfigForUser(userID string, apply func(cfg *config.Config)) (*config.Config, error) {
+	userID = normalizeUserID(userID)
+	d.configMu.Lock()
+	defer d.configMu.Unlock()
 
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return nil, err
+	}
+	prev := loadConfigForUser(tx.Query, userID)
+	// A second copy rather than *prev: apply may reuse prev's slices.
+	cfg := loadConfigForUser(tx.Query, userID)
+	apply(cfg)
+	if err := saveConfigTx(tx, userID, prev, cfg); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// saveConfigTx upserts every field of cfg and records the change from prev
+// in config_history. The caller owns tx.
+func saveConfigTx(tx *sql.Tx, userID string, prev, cfg *config.Config) error {
 	sourceRegionsJSON := "[]"
 	if b, err := json.Marshal(cfg.SourceRegions); err == nil {
 		sourceRegionsJSON = string(b)
 	}
+	aiWikiPagesJSON := "[]"
+	if b, err := json.Marshal(cfg.AIWikiPages); err == nil {
+		aiWikiPagesJSON = string(b)
+	}
+	aiToolAllowlistJSON := "[]"
+	if b, err := json.Marshal(cfg.AIToolAllowlist); err == nil && cfg.AIToolAllowlist != nil {
+		aiToolAllowlistJSON = string(b)
+	}
 	categoryIDsJSON := "[]"
 	if b, err := json.Marshal(cfg.CategoryIDs); err == nil {
 		categoryIDsJSON = string(b)
 	}
+	excludeTypeIDsJSON := "[]"
+	if b, err := json.Marshal(cfg.ExcludeTypeIDs); err == nil {
+		excludeTypeIDsJSON = string(b)
+	}
+	excludeMarketGroupIDsJSON := "[]"
+	if b, err := json.Marshal(cfg.ExcludeMarketGroupIDs); err == nil {
+		excludeMarketGroupIDsJSON = string(b)
+	}
 
 	pairs := map[string]string{
-		"system_name":               cfg.SystemName,
-		"cargo_capacity":            fmt.Sprintf("%g", cfg.CargoCapacity),
-		"buy_radius":                strconv.Itoa(cfg.BuyRadius),
-		"sell_radius":               strconv.Itoa(cfg.SellRadius),
-		"min_margin":                fmt.Sprintf("%g", cfg.MinMargin),
-		"sales_tax_percent":         fmt.Sprintf("%g", cfg.SalesTaxPercent),
-		"broker_fee_percent":        fmt.Sprintf("%g", cfg.BrokerFeePercent),
-		"split_trade_fees":          strconv.FormatBool(cfg.SplitTradeFees),
-		"buy_broker_fee_percent":    fmt.Sprintf("%g", cfg.BuyBrokerFeePercent),
-		"sell_broker_fee_percent":   fmt.Sprintf("%g", cfg.SellBrokerFeePercent),
-		"buy_sales_tax_percent":     fmt.Sprintf("%g", cfg.BuySalesTaxPercent),
-		"sell_sales_tax_percent":    fmt.Sprintf("%g", cfg.SellSalesTaxPercent),
-		"min_daily_volume":          strconv.FormatInt(cfg.MinDailyVolume, 10),
-		"max_investment":            fmt.Sprintf("%g", cfg.MaxInvestment),
-		"min_item_profit":           fmt.Sprintf("%g", cfg.MinItemProfit),
-		"min_s2b_per_day":           fmt.Sprintf("%g", cfg.MinS2BPerDay),
-		"min_bfs_per_day":           fmt.Sprintf("%g", cfg.MinBfSPerDay),
-		"min_s2b_bfs_ratio":         fmt.Sprintf("%g", cfg.MinS2BBfSRatio),
-		"max_s2b_bfs_ratio":         fmt.Sprintf("%g", cfg.MaxS2BBfSRatio),
-		"min_route_security":        fmt.Sprintf("%g", cfg.MinRouteSecurity),
-		"avg_price_period":          strconv.Itoa(cfg.AvgPricePeriod),
-		"min_period_roi":            fmt.Sprintf("%g", cfg.MinPeriodROI),
-		"max_dos":                   fmt.Sprintf("%g", cfg.MaxDOS),
-		"min_demand_per_day":        fmt.Sprintf("%g", cfg.MinDemandPerDay),
-		"purchase_demand_days":      fmt.Sprintf("%g", cfg.PurchaseDemandDays),
-		"shipping_cost_per_m3_jump": fmt.Sprintf("%g", cfg.ShippingCostPerM3Jump),
-		"source_regions":            sourceRegionsJSON,
-		"target_region":             cfg.TargetRegion,
-		"target_market_system":      cfg.TargetMarketSystem,
-		"target_market_location_id": strconv.FormatInt(cfg.TargetMarketLocationID, 10),
-		"category_ids":              categoryIDsJSON,
-		"sell_order_mode":           strconv.FormatBool(cfg.SellOrderMode),
-		"alert_telegram":            strconv.FormatBool(cfg.AlertTelegram),
-		"alert_discord":             strconv.FormatBool(cfg.AlertDiscord),
-		"alert_desktop":             strconv.FormatBool(cfg.AlertDesktop),
-		"alert_telegram_token":      cfg.AlertTelegramToken,
-		"alert_telegram_chat_id":    cfg.AlertTelegramChatID,
-		"alert_discord_webhook":     cfg.AlertDiscordWebhook,
-		"opacity":                   strconv.Itoa(cfg.Opacity),
-		"window_x":                  strconv.Itoa(cfg.WindowX),
-		"window_y":                  strconv.Itoa(cfg.WindowY),
-		"window_w":                  strconv.Itoa(cfg.WindowW),
-		"window_h":                  strconv.Itoa(cfg.WindowH),
+		"system_name":                   cfg.SystemName,
+		"cargo_capacity":                fmt.Sprintf("%g", cfg.CargoCapacity),
+		"buy_radius":                    strconv.Itoa(cfg.BuyRadius),
+		"sell_radius":                   strconv.Itoa(cfg.SellRadius),
+		"min_margin":                    fmt.Sprintf("%g", cfg.MinMargin),
+		"sales_tax_percent":             fmt.Sprintf("%g", cfg.SalesTaxPercent),
+		"broker_fee_percent":            fmt.Sprintf("%g", cfg.BrokerFeePercent),
+		"split_trade_fees":              strconv.FormatBool(cfg.SplitTradeFees),
+		"buy_broker_fee_percent":        fmt.Sprintf("%g", cfg.BuyBrokerFeePercent),
+		"sell_broker_fee_percent":       fmt.Sprintf("%g", cfg.SellBrokerFeePercent),
+		"buy_sales_tax_percent":         fmt.Sprintf("%g", cfg.BuySalesTaxPercent),
+		"sell_sales_tax_percent":        fmt.Sprintf("%g", cfg.SellSalesTaxPercent),
+		"min_daily_volume":              strconv.FormatInt(cfg.MinDailyVolume, 10),
+		"max_investment":                fmt.Sprintf("%g", cfg.MaxInvestment),
+		"min_item_profit":               fmt.Sprintf("%g", cfg.MinItemProfit),
+		"min_s2b_per_day":               fmt.Sprintf("%g", cfg.MinS2BPerDay),
+		"min_bfs_per_day":               fmt.Sprintf("%g", cfg.MinBfSPerDay),
+		"min_s2b_bfs_ratio":             fmt.Sprintf("%g", cfg.MinS2BBfSRatio),
+		"max_s2b_bfs_ratio":             fmt.Sprintf("%g", cfg.MaxS2BBfSRatio),
+		"min_route_security":            fmt.Sprintf("%g", cfg.MinRouteSecurity),
+		"avg_price_period":              strconv.Itoa(cfg.AvgPricePeriod),
+		"min_period_roi":                fmt.Sprintf("%g", cfg.MinPeriodROI),
+		"max_dos":                       fmt.Sprintf("%g", cfg.MaxDOS),
+		"min_demand_per_day":            fmt.Sprintf("%g", cfg.MinDemandPerDay),
+		"purchase_demand_days":          fmt.Sprintf("%g", cfg.PurchaseDemandDays),
+		"shipping_cost_per_m3_jump":     fmt.Sprintf("%g", cfg.ShippingCostPerM3Jump),
+		"source_regions":                sourceRegionsJSON,
+		"target_region":                 cfg.TargetRegion,
+		"target_market_system":          cfg.TargetMarketSystem,
+		"target_market_location_id":     strconv.FormatInt(cfg.TargetMarketLocationID, 10),
+		"category_ids":                  categoryIDsJSON,
+		"sell_order_mode":               strconv.FormatBool(cfg.SellOrderMode),
+		"exclude_type_ids":              excludeTypeIDsJSON,
+		"exclude_market_group_ids":      excludeMarketGroupIDsJSON,
+		"alert_telegram":                strconv.FormatBool(cfg.AlertTelegram),
+		"alert_discord":                 strconv.FormatBool(cfg.AlertDiscord),
+		"alert_desktop":                 strconv.FormatBool(cfg.AlertDesktop),
+		"alert_telegram_token":          cfg.AlertTelegramToken,
+		"alert_telegram_chat_id":        cfg.AlertTelegramChatID,
+		"alert_discord_webhook":         cfg.AlertDiscordWebhook,
+		"alert_order_expiry":            strconv.FormatBool(cfg.AlertOrderExpiry),
+		"alert_undercut":                strconv.FormatBool(cfg.AlertUndercut),
+		"ai_number_locale":              cfg.AINumberLocale,
+		"ai_isk_format":                 cfg.AIISKFormat,
+		"ai_wiki_pages":                 aiWikiPagesJSON,
+		"ai_tools_enabled":              strconv.FormatBool(cfg.AIToolsEnabled),
+		"ai_tool_allowlist":             aiToolAllowlistJSON,
+		"price_fallback_enabled":        strconv.FormatBool(cfg.PriceFallbackEnabled),
+		"station_trends_enabled":        strconv.FormatBool(cfg.StationTrendsEnabled),
+		"esi_max_retries":               strconv.Itoa(cfg.ESIMaxRetries),
+		"demand_cache_minutes":          strconv.Itoa(cfg.DemandCacheMinutes),
+		"inventory_cache_minutes":       strconv.Itoa(cfg.InventoryCacheMinutes),
+		"history_retention_days":        strconv.Itoa(cfg.HistoryRetentionDays),
+		"market_history_retention_days": strconv.Itoa(cfg.MarketHistoryRetentionDays),
+		"share_link_ttl_hours":          strconv.Itoa(cfg.ShareLinkTTLHours),
+		"opacity":                       strconv.Itoa(cfg.Opacity),
+		"window_x":                      strconv.Itoa(cfg.WindowX),
+		"window_y":                      strconv.Itoa(cfg.WindowY),
+		"window_w":                      strconv.Itoa(cfg.WindowW),
+		"window_h":                      strconv.Itoa(cfg.WindowH),
 	}
 
-	tx, err := d.sql.Begin()
-	if err != nil {
-		return err
-	}
 	stmt, err := tx.Prepare("INSERT OR REPLACE INTO config (user_id, key, value) VALUES (?, ?, ?)")
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 	defer stmt.Close()
 
 	for k, v := range pairs {
 		if _, err := stmt.Exec(userID, k, v); err != nil {
-			tx.Rollback()
 			return err
 		}
 	}
-	return tx.Commit()
+	return insertConfigHistory(tx, userID, prev, cfg)
 }
 
 // MigrateFromJSON checks for config.json and imports it into SQLite.
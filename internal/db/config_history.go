@@ -0,0 +1,178 @@
+package db
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"eve-flipper/internal/config"
+)
+
+// ConfigHistoryKeep is how many config snapshots per user survive
+// CleanupOldHistory.
+const ConfigHistoryKeep = 50
+
+// configHistorySummaryMax caps the number of changed keys spelled out in a
+// snapshot summary; the rest are counted.
+const configHistorySummaryMax = 6
+
+// configUIStateKeys change on every window move and are not worth a
+// history entry on their own.
+var configUIStateKeys = map[string]bool{
+	"opacity":  true,
+	"window_x": true,
+	"window_y": true,
+	"window_w": true,
+	"window_h": true,
+}
+
+// configSecretKeys are summarized as "changed" instead of showing values.
+var configSecretKeys = map[string]bool{
+	"alert_telegram_token":   true,
+	"alert_telegram_chat_id": true,
+	"alert_discord_webhook":  true,
+}
+
+// ConfigHistoryEntry is the config as it was after one save.
+type ConfigHistoryEntry struct {
+	ID        int64          `json:"id"`
+	Config    *config.Config `json:"config"`
+	Summary   string         `json:"summary"`
+	CreatedAt string         `json:"created_at"`
+}
+
+// ListConfigHistoryForUser returns the newest snapshots first.
+func (d *DB) ListConfigHistoryForUser(userID string, limit int) ([]ConfigHistoryEntry, error) {
+	userID = normalizeUserID(userID)
+	if limit <= 0 || limit > ConfigHistoryKeep {
+		limit = ConfigHistoryKeep
+	}
+	rows, err := d.sql.Query(
+		"SELECT id, config, summary, created_at FROM config_history WHERE user_id = ? ORDER BY id DESC LIMIT ?",
+		userID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]ConfigHistoryEntry, 0)
+	for rows.Next() {
+		e, err := scanConfigHistoryEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// GetConfigHistoryEntryForUser returns one snapshot, or sql.ErrNoRows.
+func (d *DB) GetConfigHistoryEntryForUser(userID string, id int64) (ConfigHistoryEntry, error) {
+	userID = normalizeUserID(userID)
+	row := d.sql.QueryRow(
+		"SELECT id, config, summary, created_at FROM config_history WHERE user_id = ? AND id = ?",
+		userID, id,
+	)
+	return scanConfigHistoryEntry(row)
+}
+
+// PruneConfigHistory keeps the newest keep snapshots per user.
+func (d *DB) PruneConfigHistory(keep int) (int64, error) {
+	res, err := d.sql.Exec(`
+		DELETE FROM config_history
+		WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY id DESC) AS rn
+				FROM config_history
+			) WHERE rn > ?
+		)
+	`, keep)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// insertConfigHistory records next as a snapshot when it differs from prev
+// in anything other than window state.
+func insertConfigHistory(tx *sql.Tx, userID string, prev, next *config.Config) error {
+	summary, changed := configDiffSummary(prev, next)
+	if !changed {
+		return nil
+	}
+	raw, err := json.Marshal(next)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO config_history (user_id, config, summary, created_at) VALUES (?, ?, ?, ?)",
+		userID, string(raw), summary, time.Now().UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+// configDiffSummary describes what changed from prev to next, e.g.
+// "buy_radius: 5 → 10, min_margin: 5 → 8". changed is false when only UI
+// state differs.
+func configDiffSummary(prev, next *config.Config) (summary string, changed bool) {
+	before, err1 := configFields(prev)
+	after, err2 := configFields(next)
+	if err1 != nil || err2 != nil {
+		return "", true
+	}
+	keys := make([]string, 0, len(after))
+	for k := range after {
+		if configUIStateKeys[k] || bytes.Equal(before[k], after[k]) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return "", false
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, configHistorySummaryMax+1)
+	for i, k := range keys {
+		if i == configHistorySummaryMax {
+			parts = append(parts, fmt.Sprintf("+%d more", len(keys)-i))
+			break
+		}
+		if configSecretKeys[k] {
+			parts = append(parts, k+" changed")
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s → %s", k, before[k], after[k]))
+	}
+	return strings.Join(parts, ", "), true
+}
+
+func configFields(cfg *config.Config) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	err = json.Unmarshal(raw, &m)
+	return m, err
+}
+
+// scanConfigHistoryEntry decodes a snapshot over config.Default(), like
+// scanConfigProfile.
+func scanConfigHistoryEntry(row interface{ Scan(...interface{}) error }) (ConfigHistoryEntry, error) {
+	var e ConfigHistoryEntry
+	var raw string
+	if err := row.Scan(&e.ID, &raw, &e.Summary, &e.CreatedAt); err != nil {
+		return ConfigHistoryEntry{}, err
+	}
+	e.Config = config.Default()
+	if err := json.Unmarshal([]byte(raw), e.Config); err != nil {
+		return ConfigHistoryEntry{}, err
+	}
+	return e, nil
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+
+	"eve-flipper/internal/config"
+)
+
+// ConfigProfile is a named snapshot of a user's config.
+type ConfigProfile struct {
+	Name      string         `json:"name"`
+	Config    *config.Config `json:"config"`
+	CreatedAt string         `json:"created_at"`
+	UpdatedAt string         `json:"updated_at"`
+}
+
+// SaveConfigProfileForUser creates or replaces the named profile.
+func (d *DB) SaveConfigProfileForUser(userID, name string, cfg *config.Config) (ConfigProfile, error) {
+	userID = normalizeUserID(userID)
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return ConfigProfile{}, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err = d.sql.Exec(`
+		INSERT INTO config_profiles (user_id, name, config, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, name) DO UPDATE SET config = excluded.config, updated_at = excluded.updated_at
+	`, userID, name, string(raw), now, now)
+	if err != nil {
+		return ConfigProfile{}, err
+	}
+	return d.GetConfigProfileForUser(userID, name)
+}
+
+// ListConfigProfilesForUser returns the user's profiles ordered by name.
+func (d *DB) ListConfigProfilesForUser(userID string) ([]ConfigProfile, error) {
+	userID = normalizeUserID(userID)
+	rows, err := d.sql.Query(
+		"SELECT name, config, created_at, updated_at FROM config_profiles WHERE user_id = ? ORDER BY name COLLATE NOCASE",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]ConfigProfile, 0)
+	for rows.Next() {
+		p, err := scanConfigProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// GetConfigProfileForUser returns one profile, or sql.ErrNoRows.
+func (d *DB) GetConfigProfileForUser(userID, name string) (ConfigProfile, error) {
+	userID = normalizeUserID(userID)
+	row := d.sql.QueryRow(
+		"SELECT name, config, created_at, updated_at FROM config_profiles WHERE user_id = ? AND name = ?",
+		userID, name,
+	)
+	return scanConfigProfile(row)
+}
+
+// DeleteConfigProfileForUser removes a profile. Returns false if it did not exist.
+func (d *DB) DeleteConfigProfileForUser(userID, name string) (bool, error) {
+	userID = normalizeUserID(userID)
+	res, err := d.sql.Exec("DELETE FROM config_profiles WHERE user_id = ? AND name = ?", userID, name)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// scanConfigProfile decodes a profile row. Stored configs are layered over
+// config.Default() so fields added after the profile was saved get defaults.
+func scanConfigProfile(row interface{ Scan(...interface{}) error }) (ConfigProfile, error) {
+	var p ConfigProfile
+	var raw string
+	if err := row.Scan(&p.Name, &raw, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return ConfigProfile{}, err
+	}
+	p.Config = config.Default()
+	if err := json.Unmarshal([]byte(raw), p.Config); err != nil {
+		return ConfigProfile{}, err
+	}
+	return p, nil
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+
+	"eve-flipper/internal/engine"
+)
+
+// CTSProfile is a user-defined set of Composite Trading Score weights,
+// selectable in station scans by name next to the built-in presets.
+type CTSProfile struct {
+	Name      string            `json:"name"`
+	Weights   engine.CTSWeights `json:"weights"`
+	CreatedAt string            `json:"created_at"`
+	UpdatedAt string            `json:"updated_at"`
+}
+
+// SaveCTSProfileForUser creates or replaces the named profile.
+func (d *DB) SaveCTSProfileForUser(userID, name string, weights engine.CTSWeights) (CTSProfile, error) {
+	userID = normalizeUserID(userID)
+	raw, err := json.Marshal(weights)
+	if err != nil {
+		return CTSProfile{}, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err = d.sql.Exec(`
+		INSERT INTO cts_profiles (user_id, name, weights, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, name) DO UPDATE SET weights = excluded.weights, updated_at = excluded.updated_at
+	`, userID, name, string(raw), now, now)
+	if err != nil {
+		return CTSProfile{}, err
+	}
+	return d.GetCTSProfileForUser(userID, name)
+}
+
+// ListCTSProfilesForUser returns the user's profiles ordered by name.
+func (d *DB) ListCTSProfilesForUser(userID string) ([]CTSProfile, error) {
+	userID = normalizeUserID(userID)
+	rows, err := d.sql.Query(
+		"SELECT name, weights, created_at, updated_at FROM cts_profiles WHERE user_id = ? ORDER BY name COLLATE NOCASE",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]CTSProfile, 0)
+	for rows.Next() {
+		p, err := scanCTSProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// GetCTSProfileForUser returns one profile, or sql.ErrNoRows.
+func (d *DB) GetCTSProfileForUser(userID, name string) (CTSProfile, error) {
+	userID = normalizeUserID(userID)
+	row := d.sql.QueryRow(
+		"SELECT name, weights, created_at, updated_at FROM cts_profiles WHERE user_id = ? AND name = ?",
+		userID, name,
+	)
+	return scanCTSProfile(row)
+}
+
+// DeleteCTSProfileForUser removes a profile. Returns false if it did not exist.
+func (d *DB) DeleteCTSProfileForUser(userID, name string) (bool, error) {
+	userID = normalizeUserID(userID)
+	res, err := d.sql.Exec("DELETE FROM cts_profiles WHERE user_id = ? AND name = ?", userID, name)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func scanCTSProfile(row interface{ Scan(...interface{}) error }) (CTSProfile, error) {
+	var p CTSProfile
+	var raw string
+	if err := row.Scan(&p.Name, &raw, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return CTSProfile{}, err
+	}
+	if err := json.Unmarshal([]byte(raw), &p.Weights); err != nil {
+		return CTSProfile{}, err
+	}
+	return p, nil
+}
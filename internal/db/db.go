@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"eve-flipper/internal/logger"
 
@@ -15,6 +17,15 @@ import (
 // DB wraps a SQLite database connection.
 type DB struct {
 	sql *sql.DB
+
+	// Retention windows in days (see SetHistoryRetention); zero means the
+	// config defaults.
+	scanHistoryDays   atomic.Int32
+	marketHistoryDays atomic.Int32
+
+	// configMu serializes config read-modify-writes so concurrent saves and
+	// patches for the same user cannot drop each other's fields.
+	configMu sync.Mutex
 }
 
 func dbPath() string {
@@ -1212,6 +1223,332 @@ func (d *DB) migrate() error {
 		logger.Info("DB", "Applied migration v27 (regional day-trader history rows)")
 	}
 
+	if version < 28 {
+		_, err := d.sql.Exec(`
+			CREATE TABLE IF NOT EXISTS character_labels (
+				user_id      TEXT NOT NULL,
+				character_id INTEGER NOT NULL,
+				label        TEXT NOT NULL,
+				updated_at   TEXT NOT NULL,
+				PRIMARY KEY (user_id, character_id)
+			);
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (28);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v28: %w", err)
+		}
+		logger.Info("DB", "Applied migration v28 (character labels)")
+	}
+
+	if version < 29 {
+		_, err := d.sql.Exec(`
+			CREATE TABLE IF NOT EXISTS ai_conversations (
+				id         INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id    TEXT NOT NULL,
+				title      TEXT NOT NULL DEFAULT '',
+				created_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_ai_conversations_user ON ai_conversations(user_id, updated_at DESC);
+
+			CREATE TABLE IF NOT EXISTS ai_messages (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				conversation_id INTEGER NOT NULL REFERENCES ai_conversations(id) ON DELETE CASCADE,
+				role            TEXT NOT NULL,
+				content         TEXT NOT NULL,
+				created_at      TEXT NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_ai_messages_conversation ON ai_messages(conversation_id, id);
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (29);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v29: %w", err)
+		}
+		logger.Info("DB", "Applied migration v29 (station AI conversations)")
+	}
+
+	if version < 30 {
+		_, err := d.sql.Exec(`
+			CREATE TABLE IF NOT EXISTS ai_usage (
+				id                INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id           TEXT NOT NULL,
+				provider          TEXT NOT NULL DEFAULT '',
+				model             TEXT NOT NULL,
+				kind              TEXT NOT NULL DEFAULT 'chat',
+				prompt_tokens     INTEGER NOT NULL DEFAULT 0,
+				completion_tokens INTEGER NOT NULL DEFAULT 0,
+				total_tokens      INTEGER NOT NULL DEFAULT 0,
+				cost_usd          REAL NOT NULL DEFAULT 0,
+				created_at        TEXT NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_ai_usage_user_created ON ai_usage(user_id, created_at);
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (30);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v30: %w", err)
+		}
+		logger.Info("DB", "Applied migration v30 (station AI token usage)")
+	}
+
+	if version < 31 {
+		_, err := d.sql.Exec(`
+			CREATE TABLE IF NOT EXISTS config_profiles (
+				user_id    TEXT NOT NULL,
+				name       TEXT NOT NULL,
+				config     TEXT NOT NULL,
+				created_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL,
+				PRIMARY KEY (user_id, name)
+			);
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (31);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v31: %w", err)
+		}
+		logger.Info("DB", "Applied migration v31 (config profiles)")
+	}
+
+	if version < 32 {
+		_, err := d.sql.Exec(`
+			CREATE TABLE IF NOT EXISTS config_history (
+				id         INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id    TEXT NOT NULL,
+				config     TEXT NOT NULL,
+				summary    TEXT NOT NULL DEFAULT '',
+				created_at TEXT NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_config_history_user ON config_history(user_id, id);
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (32);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v32: %w", err)
+		}
+		logger.Info("DB", "Applied migration v32 (config history)")
+	}
+
+	if version < 33 {
+		_, err := d.sql.Exec(`
+			CREATE TABLE IF NOT EXISTS plex_history (
+				hour        TEXT PRIMARY KEY,
+				recorded_at TEXT NOT NULL,
+				plex_buy    REAL NOT NULL DEFAULT 0,
+				plex_sell   REAL NOT NULL DEFAULT 0,
+				spread_pct  REAL NOT NULL DEFAULT 0,
+				volume_24h  INTEGER NOT NULL DEFAULT 0,
+				nes_ratios  TEXT NOT NULL DEFAULT '[]'
+			);
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (33);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v33: %w", err)
+		}
+		logger.Info("DB", "Applied migration v33 (plex history)")
+	}
+
+	if version < 34 {
+		_, err := d.sql.Exec(`
+			ALTER TABLE watchlist ADD COLUMN region_id INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE watchlist ADD COLUMN demand_alert_threshold REAL NOT NULL DEFAULT 0;
+			ALTER TABLE alert_history ADD COLUMN source TEXT NOT NULL DEFAULT 'price';
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (34);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v34: %w", err)
+		}
+		logger.Info("DB", "Applied migration v34 (watchlist demand alerts)")
+	}
+
+	if version < 35 {
+		_, err := d.sql.Exec(`
+			CREATE TABLE IF NOT EXISTS order_expiry_alerts (
+				user_id    TEXT    NOT NULL,
+				order_id   INTEGER NOT NULL,
+				alert_date TEXT    NOT NULL,
+				PRIMARY KEY (user_id, order_id, alert_date)
+			);
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (35);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v35: %w", err)
+		}
+		logger.Info("DB", "Applied migration v35 (order expiry alerts)")
+	}
+
+	if version < 36 {
+		_, err := d.sql.Exec(`
+			CREATE TABLE IF NOT EXISTS cts_profiles (
+				user_id    TEXT NOT NULL,
+				name       TEXT NOT NULL,
+				weights    TEXT NOT NULL,
+				created_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL,
+				PRIMARY KEY (user_id, name)
+			);
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (36);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v36: %w", err)
+		}
+		logger.Info("DB", "Applied migration v36 (custom CTS profiles)")
+	}
+
+	if version < 37 {
+		_, err := d.sql.Exec(`
+			ALTER TABLE auth_session ADD COLUMN scopes TEXT NOT NULL DEFAULT '';
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (37);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v37: %w", err)
+		}
+		logger.Info("DB", "Applied migration v37 (auth session scopes)")
+	}
+
+	if version < 38 {
+		historyExists, err := d.tableExists("scan_history")
+		if err != nil {
+			return fmt.Errorf("migration v38 check scan_history exists: %w", err)
+		}
+		if historyExists {
+			if err := d.ensureTableColumn("scan_history", "partial", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+				return fmt.Errorf("migration v38 add scan_history.partial: %w", err)
+			}
+		}
+		if _, err := d.sql.Exec(`INSERT OR IGNORE INTO schema_version (version) VALUES (38);`); err != nil {
+			return fmt.Errorf("migration v38: %w", err)
+		}
+		logger.Info("DB", "Applied migration v38 (partial scan history)")
+	}
+
+	if version < 39 {
+		_, err := d.sql.Exec(`
+			CREATE TABLE IF NOT EXISTS scan_templates (
+				id         INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id    TEXT NOT NULL,
+				tab        TEXT NOT NULL,
+				name       TEXT NOT NULL,
+				params     TEXT NOT NULL,
+				created_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL,
+				UNIQUE (user_id, tab, name)
+			);
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (39);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v39: %w", err)
+		}
+		logger.Info("DB", "Applied migration v39 (scan templates)")
+	}
+
+	if version < 40 {
+		_, err := d.sql.Exec(`
+			CREATE TABLE IF NOT EXISTS market_disabled_overrides (
+				user_id    TEXT NOT NULL,
+				type_id    INTEGER NOT NULL,
+				disabled   INTEGER NOT NULL,
+				created_at TEXT NOT NULL,
+				PRIMARY KEY (user_id, type_id)
+			);
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (40);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v40: %w", err)
+		}
+		logger.Info("DB", "Applied migration v40 (market-disabled overrides)")
+	}
+
+	if version < 41 {
+		historyExists, err := d.tableExists("scan_history")
+		if err != nil {
+			return fmt.Errorf("migration v41 check scan_history exists: %w", err)
+		}
+		if historyExists {
+			if err := d.ensureTableColumn("scan_history", "imported", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+				return fmt.Errorf("migration v41 add scan_history.imported: %w", err)
+			}
+			if err := d.ensureTableColumn("scan_history", "imported_by", "TEXT NOT NULL DEFAULT ''"); err != nil {
+				return fmt.Errorf("migration v41 add scan_history.imported_by: %w", err)
+			}
+		}
+		if _, err := d.sql.Exec(`INSERT OR IGNORE INTO schema_version (version) VALUES (41);`); err != nil {
+			return fmt.Errorf("migration v41: %w", err)
+		}
+		logger.Info("DB", "Applied migration v41 (imported scan history)")
+	}
+
+	if version < 42 {
+		historyExists, err := d.tableExists("scan_history")
+		if err != nil {
+			return fmt.Errorf("migration v42 check scan_history exists: %w", err)
+		}
+		if historyExists {
+			if err := d.ensureTableColumn("scan_history", "engine_version", "TEXT NOT NULL DEFAULT ''"); err != nil {
+				return fmt.Errorf("migration v42 add scan_history.engine_version: %w", err)
+			}
+			if err := d.ensureTableColumn("scan_history", "resolved_params_json", "TEXT NOT NULL DEFAULT ''"); err != nil {
+				return fmt.Errorf("migration v42 add scan_history.resolved_params_json: %w", err)
+			}
+		}
+		if _, err := d.sql.Exec(`INSERT OR IGNORE INTO schema_version (version) VALUES (42);`); err != nil {
+			return fmt.Errorf("migration v42: %w", err)
+		}
+		logger.Info("DB", "Applied migration v42 (scan engine version)")
+	}
+
+	if version < 43 {
+		_, err := d.sql.Exec(`
+			CREATE TABLE IF NOT EXISTS share_links (
+				token_hash TEXT PRIMARY KEY,
+				scan_id    INTEGER NOT NULL,
+				user_id    TEXT NOT NULL,
+				created_at TEXT NOT NULL,
+				expires_at TEXT NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_share_links_scan ON share_links(scan_id, user_id);
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (43);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v43: %w", err)
+		}
+		logger.Info("DB", "Applied migration v43 (scan share links)")
+	}
+
+	if version < 44 {
+		_, err := d.sql.Exec(`
+			CREATE TABLE IF NOT EXISTS station_trade_trends (
+				user_id      TEXT    NOT NULL,
+				region_id    INTEGER NOT NULL,
+				station_id   INTEGER NOT NULL,
+				type_id      INTEGER NOT NULL,
+				date         TEXT    NOT NULL,
+				cts          REAL    NOT NULL DEFAULT 0,
+				daily_profit REAL    NOT NULL DEFAULT 0,
+				margin       REAL    NOT NULL DEFAULT 0,
+				PRIMARY KEY (user_id, region_id, station_id, type_id, date)
+			);
+			CREATE INDEX IF NOT EXISTS idx_station_trade_trends_type ON station_trade_trends(user_id, type_id, date);
+			CREATE INDEX IF NOT EXISTS idx_station_trade_trends_date ON station_trade_trends(date);
+
+			INSERT OR IGNORE INTO schema_version (version) VALUES (44);
+		`)
+		if err != nil {
+			return fmt.Errorf("migration v44: %w", err)
+		}
+		logger.Info("DB", "Applied migration v44 (station trade trends)")
+	}
+
 	return nil
 }
 
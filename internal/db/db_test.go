@@ -2,10 +2,14 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
+	"sync"
 	"testing"
+	"time"
 
 	"eve-flipper/internal/config"
 	"eve-flipper/internal/engine"
+	"eve-flipper/internal/esi"
 
 	_ "modernc.org/sqlite"
 )
@@ -416,6 +420,165 @@ func TestDB_GetHistoryByID(t *testing.T) {
 	}
 }
 
+func TestDB_InsertPartialHistory(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	full := d.InsertHistoryFull("contracts", "Jita", 3, 1_000_000, 2_500_000, 1200, map[string]int{"buy_radius": 5}, nil)
+	partial := d.InsertPartialHistory("contracts", "Jita", 1, 900_000, 900_000, 400, map[string]int{"buy_radius": 5}, nil)
+
+	if rec := d.GetHistoryByID(full); rec == nil || rec.Partial {
+		t.Errorf("full record = %+v, want partial=false", rec)
+	}
+	rec := d.GetHistoryByID(partial)
+	if rec == nil || !rec.Partial || rec.Count != 1 {
+		t.Fatalf("partial record = %+v, want partial=true count=1", rec)
+	}
+	records := d.GetHistory(10)
+	if len(records) != 2 || !records[0].Partial || records[1].Partial {
+		t.Errorf("GetHistory partial flags = %+v", records)
+	}
+}
+
+func TestDB_InsertHistoryFull_RecordsEngineVersion(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	resolved := engine.ScanParams{CurrentSystemID: 30000142, BuyRadius: 5, MinMargin: 7.5}
+	id := d.InsertHistoryFull("radius", "Jita", 1, 100, 100, 50, map[string]int{"buy_radius": 5}, resolved)
+	legacy := d.InsertHistory("radius", "Jita", 1, 100)
+
+	rec := d.GetHistoryByID(id)
+	if rec == nil || rec.EngineVersion != engine.EngineVersion {
+		t.Fatalf("record = %+v, want engine_version %q", rec, engine.EngineVersion)
+	}
+	var got engine.ScanParams
+	if err := json.Unmarshal(rec.ResolvedParams, &got); err != nil {
+		t.Fatalf("resolved params %s: %v", rec.ResolvedParams, err)
+	}
+	if got.CurrentSystemID != 30000142 || got.BuyRadius != 5 || got.MinMargin != 7.5 {
+		t.Errorf("resolved params = %+v", got)
+	}
+
+	old := d.GetHistoryByID(legacy)
+	if old == nil || old.EngineVersion != "" || old.ResolvedParams != nil {
+		t.Errorf("legacy record = %+v, want no engine version or resolved params", old)
+	}
+	if list := d.GetHistory(10); len(list) != 2 || list[1].EngineVersion != engine.EngineVersion || list[1].ResolvedParams != nil {
+		t.Errorf("GetHistory = %+v, want engine version without resolved params", list)
+	}
+}
+
+func TestDB_ShareLinks_ExpireAndRevoke(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	scanID := d.InsertHistory("station", "Jita", 1, 100)
+	now := time.Now()
+	if _, err := d.CreateShareLink("owner", scanID, "live-token", now.Add(time.Hour)); err != nil {
+		t.Fatalf("CreateShareLink: %v", err)
+	}
+	if _, err := d.CreateShareLink("owner", scanID, "stale-token", now.Add(-time.Minute)); err != nil {
+		t.Fatalf("CreateShareLink: %v", err)
+	}
+
+	link, err := d.GetShareLink("live-token", now)
+	if err != nil || link.ScanID != scanID || link.UserID != "owner" {
+		t.Fatalf("GetShareLink = %+v, %v", link, err)
+	}
+	if _, err := d.GetShareLink("stale-token", now); err != sql.ErrNoRows {
+		t.Errorf("expired link err = %v, want sql.ErrNoRows", err)
+	}
+	var stored int
+	d.sql.QueryRow("SELECT COUNT(*) FROM share_links WHERE token_hash = 'live-token'").Scan(&stored)
+	if stored != 0 {
+		t.Error("token stored in plain text")
+	}
+
+	if n, err := d.PruneExpiredShareLinks(now); err != nil || n != 1 {
+		t.Errorf("PruneExpiredShareLinks = %d, %v, want 1", n, err)
+	}
+	if n, _ := d.DeleteShareLinksForScan("someone-else", scanID); n != 0 {
+		t.Errorf("foreign revoke removed %d links", n)
+	}
+	if n, err := d.DeleteShareLinksForScan("owner", scanID); err != nil || n != 1 {
+		t.Errorf("DeleteShareLinksForScan = %d, %v, want 1", n, err)
+	}
+	if _, err := d.GetShareLink("live-token", now); err != sql.ErrNoRows {
+		t.Errorf("revoked link err = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestDB_ScanTemplates_UpsertListDelete(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	first, err := d.SaveScanTemplateForUser("user-a", "radius", "Jita", json.RawMessage(`{"buy_radius":5}`))
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	again, err := d.SaveScanTemplateForUser("user-a", "radius", "Jita", json.RawMessage(`{"buy_radius":7}`))
+	if err != nil {
+		t.Fatalf("resave: %v", err)
+	}
+	if again.ID != first.ID || string(again.Params) != `{"buy_radius":7}` {
+		t.Errorf("resave = %+v, want same id with new params", again)
+	}
+	if _, err := d.SaveScanTemplateForUser("user-a", "contracts", "Jita", json.RawMessage(`{}`)); err != nil {
+		t.Fatalf("save contracts: %v", err)
+	}
+
+	all, _ := d.ListScanTemplatesForUser("user-a", "")
+	radius, _ := d.ListScanTemplatesForUser("user-a", "radius")
+	other, _ := d.ListScanTemplatesForUser("user-b", "")
+	if len(all) != 2 || len(radius) != 1 || len(other) != 0 {
+		t.Fatalf("list sizes all=%d radius=%d other=%d, want 2/1/0", len(all), len(radius), len(other))
+	}
+
+	if ok, _ := d.DeleteScanTemplateForUser("user-b", first.ID); ok {
+		t.Error("deleted another user's template")
+	}
+	if ok, _ := d.DeleteScanTemplateForUser("user-a", first.ID); !ok {
+		t.Error("delete returned false")
+	}
+	if _, err := d.GetScanTemplateForUser("user-a", first.ID); err == nil {
+		t.Error("template still present after delete")
+	}
+}
+
+func TestDB_MarketDisabledOverrides(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	if err := d.SetMarketDisabledOverrideForUser("user-a", 34, true); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := d.SetMarketDisabledOverrideForUser("user-a", 34, false); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if err := d.SetMarketDisabledOverrideForUser("user-a", 35, true); err != nil {
+		t.Fatalf("set 35: %v", err)
+	}
+
+	list, err := d.ListMarketDisabledOverridesForUser("user-a")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list) != 2 || list[0].TypeID != 34 || list[0].Disabled || !list[1].Disabled {
+		t.Fatalf("list = %+v, want [34 enabled, 35 disabled]", list)
+	}
+	if other, _ := d.ListMarketDisabledOverridesForUser("user-b"); len(other) != 0 {
+		t.Errorf("user-b sees %d overrides", len(other))
+	}
+
+	if ok, _ := d.DeleteMarketDisabledOverrideForUser("user-a", 34); !ok {
+		t.Error("delete returned false")
+	}
+	if ok, _ := d.DeleteMarketDisabledOverrideForUser("user-a", 34); ok {
+		t.Error("second delete returned true")
+	}
+}
+
 func TestDB_InsertFlipResults_ZeroScanIDNoOp(t *testing.T) {
 	d := openTestDB(t)
 	defer d.Close()
@@ -458,6 +621,8 @@ func TestDB_ConfigRoundTrip(t *testing.T) {
 		TargetMarketLocationID: 60003760,
 		CategoryIDs:            []int32{6, 8},
 		SellOrderMode:          true,
+		ExcludeTypeIDs:         []int32{40520},
+		ExcludeMarketGroupIDs:  []int32{1954, 2},
 		AlertTelegram:          true,
 		AlertDiscord:           true,
 		AlertDesktop:           false,
@@ -467,7 +632,12 @@ func TestDB_ConfigRoundTrip(t *testing.T) {
 		Opacity:                200,
 		WindowW:                1024,
 		WindowH:                768,
+		DemandCacheMinutes:     45,
+		InventoryCacheMinutes:  12,
+		AIWikiPages:            []string{"Home", "Trading-Guide"},
 	}
+	cfg.HistoryRetentionDays = 60
+	cfg.MarketHistoryRetentionDays = 180
 	if err := d.SaveConfig(cfg); err != nil {
 		t.Fatalf("SaveConfig: %v", err)
 	}
@@ -487,6 +657,15 @@ func TestDB_ConfigRoundTrip(t *testing.T) {
 	if got.WindowW != 1024 || got.WindowH != 768 {
 		t.Errorf("LoadConfig window = %dx%d", got.WindowW, got.WindowH)
 	}
+	if got.DemandCacheMinutes != 45 {
+		t.Errorf("LoadConfig demand_cache_minutes = %d, want 45", got.DemandCacheMinutes)
+	}
+	if got.InventoryCacheMinutes != 12 {
+		t.Errorf("LoadConfig inventory_cache_minutes = %d, want 12", got.InventoryCacheMinutes)
+	}
+	if got.HistoryRetentionDays != 60 || got.MarketHistoryRetentionDays != 180 {
+		t.Errorf("LoadConfig retention = %d/%d days, want 60/180", got.HistoryRetentionDays, got.MarketHistoryRetentionDays)
+	}
 	if got.AvgPricePeriod != 21 || got.MaxDOS != 4.5 || got.MinDemandPerDay != 7 || got.PurchaseDemandDays != 0.5 {
 		t.Errorf("LoadConfig region thresholds mismatch: avg=%d max_dos=%v min_demand=%v purchase_days=%v", got.AvgPricePeriod, got.MaxDOS, got.MinDemandPerDay, got.PurchaseDemandDays)
 	}
@@ -496,6 +675,12 @@ func TestDB_ConfigRoundTrip(t *testing.T) {
 	if !got.SellOrderMode || len(got.CategoryIDs) != 2 || len(got.SourceRegions) != 2 {
 		t.Errorf("LoadConfig region arrays/flags mismatch: sell_mode=%v categories=%v sources=%v", got.SellOrderMode, got.CategoryIDs, got.SourceRegions)
 	}
+	if len(got.ExcludeTypeIDs) != 1 || got.ExcludeTypeIDs[0] != 40520 || len(got.ExcludeMarketGroupIDs) != 2 {
+		t.Errorf("LoadConfig exclusions mismatch: types=%v groups=%v", got.ExcludeTypeIDs, got.ExcludeMarketGroupIDs)
+	}
+	if len(got.AIWikiPages) != 2 || got.AIWikiPages[1] != "Trading-Guide" {
+		t.Errorf("LoadConfig ai_wiki_pages = %v", got.AIWikiPages)
+	}
 }
 
 func TestDB_RegionalDayResultsRoundTrip(t *testing.T) {
@@ -676,6 +861,74 @@ func TestDB_WatchlistAlertSettingsRoundTrip(t *testing.T) {
 	}
 }
 
+func TestDB_WatchlistDemandAlerts(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	d.AddWatchlistItemForUser("u1", config.WatchlistItem{TypeID: 34, TypeName: "Tritanium", AddedAt: "2026-02-13T00:00:00Z"})
+	d.AddWatchlistItemForUser("u1", config.WatchlistItem{
+		TypeID: 35, TypeName: "Pyerite", AddedAt: "2026-02-13T00:00:00Z",
+		RegionID: 10000002, DemandAlertThreshold: 5,
+	})
+	// A threshold without a region is meaningless and dropped.
+	d.AddWatchlistItemForUser("u2", config.WatchlistItem{TypeID: 36, TypeName: "Mexallon", AddedAt: "2026-02-13T00:00:00Z", DemandAlertThreshold: 5})
+
+	alerts, err := d.ListWatchlistDemandAlerts()
+	if err != nil {
+		t.Fatalf("ListWatchlistDemandAlerts: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].UserID != "u1" || alerts[0].Item.TypeID != 35 || alerts[0].Item.RegionID != 10000002 {
+		t.Fatalf("alerts = %+v, want Pyerite for u1", alerts)
+	}
+
+	d.UpdateWatchlistDemandAlertForUser("u1", 34, 10000043, 2.5)
+	d.UpdateWatchlistDemandAlertForUser("u1", 35, 0, 5)
+	items := d.GetWatchlistForUser("u1")
+	byType := map[int32]config.WatchlistItem{}
+	for _, it := range items {
+		byType[it.TypeID] = it
+	}
+	if byType[34].RegionID != 10000043 || byType[34].DemandAlertThreshold != 2.5 {
+		t.Fatalf("Tritanium = %+v, want demand alert in 10000043", byType[34])
+	}
+	if byType[35].RegionID != 0 || byType[35].DemandAlertThreshold != 0 {
+		t.Fatalf("Pyerite = %+v, want demand alert cleared", byType[35])
+	}
+}
+
+func TestDB_AddWatchlistItemsForUserIsIdempotent(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	d.AddWatchlistItemForUser("bulk-user", config.WatchlistItem{TypeID: 34, TypeName: "Tritanium", AddedAt: "2026-02-13T00:00:00Z"})
+	inserted, err := d.AddWatchlistItemsForUser("bulk-user", []config.WatchlistItem{
+		{TypeID: 34, TypeName: "Tritanium", AddedAt: "2026-02-14T00:00:00Z", AlertMetric: "daily_volume", AlertThreshold: 10},
+		{TypeID: 35, TypeName: "Pyerite", AddedAt: "2026-02-14T00:00:00Z", AlertMetric: "daily_volume", AlertThreshold: 10},
+	})
+	if err != nil {
+		t.Fatalf("AddWatchlistItemsForUser: %v", err)
+	}
+	if inserted != 1 {
+		t.Fatalf("inserted = %d, want 1", inserted)
+	}
+	items := d.GetWatchlistForUser("bulk-user")
+	if len(items) != 2 {
+		t.Fatalf("watchlist len = %d, want 2", len(items))
+	}
+	for _, it := range items {
+		switch it.TypeID {
+		case 34:
+			if it.AlertMetric != "margin_percent" {
+				t.Fatalf("existing row was modified: %+v", it)
+			}
+		case 35:
+			if it.AlertMetric != "daily_volume" || it.AlertThreshold != 10 || !it.AlertEnabled {
+				t.Fatalf("bulk row alert mismatch: %+v", it)
+			}
+		}
+	}
+}
+
 func TestDB_UserScopedDataIsolation(t *testing.T) {
 	d := openTestDB(t)
 	defer d.Close()
@@ -864,3 +1117,425 @@ func TestDB_MigrateV16_PreservesLegacyAlertHistory(t *testing.T) {
 		t.Fatalf("migrated alert_history message = %q, want %q", message, "legacy alert")
 	}
 }
+
+func TestDB_AIConversationsAreUserScoped(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	first, err := d.CreateAIConversationForUser("user-a", "")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := d.CreateAIConversationForUser("user-b", "other"); err != nil {
+		t.Fatalf("create other: %v", err)
+	}
+	if err := d.AppendAIMessagesForUser("user-a", first.ID, []AIMessage{
+		{Role: "user", Content: "  what   should I haul?  "},
+		{Role: "assistant", Content: "PLEX"},
+	}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := d.AppendAIMessagesForUser("user-b", first.ID, []AIMessage{{Role: "user", Content: "x"}}); err != sql.ErrNoRows {
+		t.Fatalf("append to foreign conversation err = %v, want sql.ErrNoRows", err)
+	}
+
+	list, err := d.ListAIConversationsForUser("user-a", 0)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list) != 1 || list[0].MessageCount != 2 || list[0].Title != "what should I haul?" {
+		t.Fatalf("list = %+v", list)
+	}
+}
+
+func TestDB_AIUsageSummaryAggregatesByDayAndModel(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	for _, rec := range []AIUsageRecord{
+		{Model: "gpt-4o-mini", Kind: "planner", PromptTokens: 100, CompletionTokens: 20},
+		{Model: "gpt-4o-mini", PromptTokens: 900, CompletionTokens: 300, TotalTokens: 1200, CostUSD: 0.002},
+		{Model: "claude-x", PromptTokens: 50, CompletionTokens: 50, TotalTokens: 100},
+	} {
+		if err := d.RecordAIUsageForUser("user-a", rec); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+	if err := d.RecordAIUsageForUser("user-b", AIUsageRecord{Model: "gpt-4o-mini", TotalTokens: 5000}); err != nil {
+		t.Fatalf("record other: %v", err)
+	}
+	// Outside the window.
+	if _, err := d.sql.Exec(
+		"INSERT INTO ai_usage (user_id, model, total_tokens, created_at) VALUES ('user-a', 'old', 999, '2000-01-01T00:00:00Z')",
+	); err != nil {
+		t.Fatalf("insert old: %v", err)
+	}
+
+	summary, err := d.GetAIUsageSummaryForUser("user-a", 30)
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	if summary.Totals.Requests != 3 || summary.Totals.TotalTokens != 1420 {
+		t.Fatalf("totals = %+v, want 3 requests / 1420 tokens", summary.Totals)
+	}
+	if len(summary.ByDay) != 1 || summary.ByDay[0].Day == "" {
+		t.Fatalf("by_day = %+v", summary.ByDay)
+	}
+	if len(summary.ByModel) != 2 || summary.ByModel[0].Model != "gpt-4o-mini" || summary.ByModel[0].TotalTokens != 1320 {
+		t.Fatalf("by_model = %+v", summary.ByModel)
+	}
+	if summary.ByModel[0].CostUSD != 0.002 {
+		t.Fatalf("cost = %v, want 0.002", summary.ByModel[0].CostUSD)
+	}
+}
+
+func TestConfigProfilesPerUser(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	cfg := config.Default()
+	cfg.MinMargin = 15
+	if _, err := d.SaveConfigProfileForUser("alice", "camping", cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	cfg.MinMargin = 20
+	if _, err := d.SaveConfigProfileForUser("alice", "camping", cfg); err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	if _, err := d.SaveConfigProfileForUser("bob", "arbitrage", config.Default()); err != nil {
+		t.Fatalf("save bob: %v", err)
+	}
+
+	profiles, err := d.ListConfigProfilesForUser("alice")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(profiles) != 1 || profiles[0].Config.MinMargin != 20 {
+		t.Fatalf("alice profiles = %+v", profiles)
+	}
+	if _, err := d.GetConfigProfileForUser("alice", "arbitrage"); err != sql.ErrNoRows {
+		t.Fatalf("foreign profile err = %v, want sql.ErrNoRows", err)
+	}
+	if ok, err := d.DeleteConfigProfileForUser("alice", "camping"); err != nil || !ok {
+		t.Fatalf("delete = %v, %v", ok, err)
+	}
+	if ok, _ := d.DeleteConfigProfileForUser("alice", "camping"); ok {
+		t.Fatal("second delete should report not found")
+	}
+}
+
+func TestCTSProfilesPerUser(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	w := engine.CTSWeights{SpreadROI: 3, OBDS: 1, Volume: 1}
+	if _, err := d.SaveCTSProfileForUser("alice", "spready", w); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	w.SDS = 2
+	saved, err := d.SaveCTSProfileForUser("alice", "spready", w)
+	if err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	if saved.Weights != w {
+		t.Fatalf("saved weights = %+v, want %+v", saved.Weights, w)
+	}
+	if _, err := d.SaveCTSProfileForUser("bob", "safe", engine.DefaultCTSWeights); err != nil {
+		t.Fatalf("save bob: %v", err)
+	}
+
+	profiles, err := d.ListCTSProfilesForUser("alice")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(profiles) != 1 || profiles[0].Weights.SDS != 2 {
+		t.Fatalf("alice profiles = %+v", profiles)
+	}
+	if _, err := d.GetCTSProfileForUser("alice", "safe"); err != sql.ErrNoRows {
+		t.Fatalf("foreign profile err = %v, want sql.ErrNoRows", err)
+	}
+	if ok, err := d.DeleteCTSProfileForUser("alice", "spready"); err != nil || !ok {
+		t.Fatalf("delete = %v, %v", ok, err)
+	}
+	if ok, _ := d.DeleteCTSProfileForUser("alice", "spready"); ok {
+		t.Fatal("second delete should report not found")
+	}
+}
+
+func TestConfigHistorySnapshotsAndPrune(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	cfg := config.Default()
+	cfg.BuyRadius = 8
+	if err := d.SaveConfigForUser("alice", cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	// Window-only changes are not recorded.
+	cfg.WindowX = 400
+	if err := d.SaveConfigForUser("alice", cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	cfg.MinMargin = 9
+	cfg.AlertDiscordWebhook = "https://discord.example/secret"
+	if err := d.SaveConfigForUser("alice", cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	history, err := d.ListConfigHistoryForUser("alice", 20)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history len = %d, want 2", len(history))
+	}
+	if got, want := history[0].Summary, "alert_discord_webhook changed, min_margin: 5 → 9"; got != want {
+		t.Fatalf("summary = %q, want %q", got, want)
+	}
+	if history[1].Config.BuyRadius != 8 || history[1].Config.MinMargin != 5 {
+		t.Fatalf("older snapshot = %+v", history[1].Config)
+	}
+	if other, _ := d.ListConfigHistoryForUser("bob", 20); len(other) != 0 {
+		t.Fatalf("bob history len = %d, want 0", len(other))
+	}
+
+	for i := 0; i < 5; i++ {
+		cfg.BuyRadius = 10 + i
+		if err := d.SaveConfigForUser("alice", cfg); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+	if n, err := d.PruneConfigHistory(3); err != nil || n != 4 {
+		t.Fatalf("prune = %d, %v; want 4 removed", n, err)
+	}
+	history, _ = d.ListConfigHistoryForUser("alice", 20)
+	if len(history) != 3 || history[0].Config.BuyRadius != 14 {
+		t.Fatalf("after prune len = %d, newest buy_radius = %d", len(history), history[0].Config.BuyRadius)
+	}
+}
+
+func TestBackupTo(t *testing.T) {
+	dir := t.TempDir()
+	sqlDB, err := sql.Open("sqlite", dir+"/live.db?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	d := &DB{sql: sqlDB}
+	defer d.Close()
+	if err := d.migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	cfg := config.Default()
+	cfg.BuyRadius = 12
+	if err := d.SaveConfigForUser("alice", cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	target := dir + "/backup.db"
+	if err := d.BackupTo(target); err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	if err := d.BackupTo(target); err == nil {
+		t.Fatal("backup over an existing file should fail")
+	}
+
+	copyDB, err := sql.Open("sqlite", target)
+	if err != nil {
+		t.Fatalf("open backup: %v", err)
+	}
+	defer copyDB.Close()
+	restored := &DB{sql: copyDB}
+	if got := restored.LoadConfigForUser("alice").BuyRadius; got != 12 {
+		t.Fatalf("backup buy_radius = %d, want 12", got)
+	}
+}
+
+func TestDeleteOrphanedResultsAndVacuum(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	scanID := d.InsertHistory("radius", "Jita", 1, 100)
+	d.InsertFlipResults(scanID, []engine.FlipResult{{TypeID: 34, TypeName: "Tritanium"}})
+	if _, err := d.sql.Exec("INSERT INTO route_results (scan_id, type_name) VALUES (?, ?)", scanID+100, "Orphan"); err != nil {
+		t.Fatalf("insert orphan: %v", err)
+	}
+
+	n, err := d.DeleteOrphanedResults()
+	if err != nil || n != 1 {
+		t.Fatalf("DeleteOrphanedResults = %d, %v; want 1", n, err)
+	}
+	if got := d.GetFlipResults(scanID); len(got) != 1 {
+		t.Fatalf("flip results for live scan = %d, want 1", len(got))
+	}
+	if _, err := d.Vacuum(); err != nil {
+		t.Fatalf("vacuum: %v", err)
+	}
+	if size, err := d.Size(); err != nil || size <= 0 {
+		t.Fatalf("Size = %d, %v", size, err)
+	}
+}
+
+func TestPLEXHistoryOnePointPerHour(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	dash := engine.PLEXDashboard{
+		PLEXPrice: engine.PLEXGlobalPrice{BuyPrice: 4_000_000, SellPrice: 4_100_000, Volume24h: 50_000},
+		Arbitrage: []engine.ArbitragePath{
+			{Name: "Extractor", PLEXCost: 293, RevenueISK: 1_465_000_000, ROI: 20},
+			{Name: "Spread", Type: "spread"}, // market path, not an NES ratio
+		},
+	}
+	base := time.Date(2026, 10, 1, 10, 5, 0, 0, time.UTC)
+	if err := d.RecordPLEXHistory(dash, base); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	dash.PLEXPrice.SellPrice = 4_200_000
+	if err := d.RecordPLEXHistory(dash, base.Add(40*time.Minute)); err != nil {
+		t.Fatalf("record same hour: %v", err)
+	}
+	if err := d.RecordPLEXHistory(dash, base.Add(2*time.Hour)); err != nil {
+		t.Fatalf("record next hours: %v", err)
+	}
+
+	points, err := d.ListPLEXHistory(base.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("points = %d, want 2 (one per hour)", len(points))
+	}
+	if points[0].PLEXSell != 4_200_000 {
+		t.Fatalf("first point sell = %v, want latest value in its hour", points[0].PLEXSell)
+	}
+	if len(points[0].NESRatios) != 1 || points[0].NESRatios[0].ISKPerPLEX != 5_000_000 {
+		t.Fatalf("nes ratios = %+v, want Extractor at 5M ISK/PLEX", points[0].NESRatios)
+	}
+}
+
+func TestStationTrendsOnePointPerDay(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	base := time.Now().UTC()
+	jita := stationTrendPoint(10000002, 60003760, 34, 70, 1_000_000, 12)
+	amarr := stationTrendPoint(10000043, 60008494, 34, 40, 300_000, 8)
+	if err := d.RecordStationTrendsForUser("u1", []StationTrendPoint{jita, amarr}, base.AddDate(0, 0, -1)); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	jita.CTS = 75
+	if err := d.RecordStationTrendsForUser("u1", []StationTrendPoint{jita}, base); err != nil {
+		t.Fatalf("record today: %v", err)
+	}
+	jita.CTS = 80
+	if err := d.RecordStationTrendsForUser("u1", []StationTrendPoint{jita}, base); err != nil {
+		t.Fatalf("record today again: %v", err)
+	}
+	if err := d.RecordStationTrendsForUser("u2", []StationTrendPoint{jita}, base); err != nil {
+		t.Fatalf("record other user: %v", err)
+	}
+
+	points, err := d.ListStationTrendsForUser("u1", 34, 60003760, base.AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(points) != 2 || points[0].CTS != 70 || points[1].CTS != 80 {
+		t.Fatalf("jita points = %+v, want yesterday 70 then today's latest 80", points)
+	}
+	if points[1].Date != base.Format("2006-01-02") {
+		t.Fatalf("date = %q", points[1].Date)
+	}
+	all, err := d.ListStationTrendsForUser("u1", 34, 0, base.AddDate(0, 0, -7))
+	if err != nil || len(all) != 3 {
+		t.Fatalf("all stations = %d (%v), want 3", len(all), err)
+	}
+
+	if err := d.RecordStationTrendsForUser("u1", []StationTrendPoint{jita}, base.AddDate(0, 0, -StationTrendsRetentionDays-1)); err != nil {
+		t.Fatalf("record old: %v", err)
+	}
+	if n, err := d.PruneStationTrends(); err != nil || n != 1 {
+		t.Fatalf("prune = %d (%v), want 1", n, err)
+	}
+}
+
+func stationTrendPoint(regionID int32, stationID int64, typeID int32, cts, profit, margin float64) StationTrendPoint {
+	return StationTrendPoint{RegionID: regionID, StationID: stationID, TypeID: typeID, CTS: cts, DailyProfit: profit, Margin: margin}
+}
+
+func TestCleanupOldHistory_HonorsRetention(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+	d.SetHistoryRetention(10, 40)
+
+	oldScan := d.InsertHistory("radius", "Jita", 1, 100)
+	newScan := d.InsertHistory("radius", "Jita", 1, 100)
+	if _, err := d.sql.Exec("UPDATE scan_history SET timestamp = ? WHERE id = ?",
+		time.Now().AddDate(0, 0, -11).Format(time.RFC3339), oldScan); err != nil {
+		t.Fatalf("backdate scan: %v", err)
+	}
+
+	day := func(daysAgo int) string { return time.Now().AddDate(0, 0, -daysAgo).Format("2006-01-02") }
+	d.SetMarketHistory(10000002, 34, []esi.HistoryEntry{
+		{Date: day(60), Volume: 1},
+		{Date: day(35), Volume: 2},
+		{Date: day(1), Volume: 3},
+	})
+	if _, err := d.sql.Exec("INSERT INTO market_history (region_id, type_id, date, average, highest, lowest, volume, order_count) VALUES (10000002, 34, ?, 0, 0, 0, 9, 0)", day(45)); err != nil {
+		t.Fatalf("insert old history row: %v", err)
+	}
+
+	d.CleanupOldHistory()
+
+	if rec := d.GetHistoryByID(oldScan); rec != nil {
+		t.Error("scan older than the retention window survived cleanup")
+	}
+	if rec := d.GetHistoryByID(newScan); rec == nil {
+		t.Error("recent scan removed by cleanup")
+	}
+	entries, ok := d.GetMarketHistory(10000002, 34)
+	if !ok || len(entries) != 2 {
+		t.Fatalf("market history after cleanup = %+v, want the 35- and 1-day-old rows", entries)
+	}
+}
+
+func TestPatchConfigForUser_ConcurrentPatchesBothPersist(t *testing.T) {
+	dir := t.TempDir()
+	sqlDB, err := sql.Open("sqlite", dir+"/live.db?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	d := &DB{sql: sqlDB}
+	defer d.Close()
+	if err := d.migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		var (
+			wg    sync.WaitGroup
+			start = make(chan struct{})
+			errs  = make(chan error, 2)
+		)
+		patch := func(apply func(cfg *config.Config)) {
+			defer wg.Done()
+			<-start
+			if _, err := d.PatchConfigForUser("alice", apply); err != nil {
+				errs <- err
+			}
+		}
+		wg.Add(2)
+		go patch(func(cfg *config.Config) { cfg.BuyRadius = 100 + i })
+		go patch(func(cfg *config.Config) { cfg.SellRadius = 200 + i })
+		close(start)
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Fatalf("patch: %v", err)
+		}
+
+		got := d.LoadConfigForUser("alice")
+		if got.BuyRadius != 100+i || got.SellRadius != 200+i {
+			t.Fatalf("round %d: buy_radius = %d, sell_radius = %d; a patch was lost", i, got.BuyRadius, got.SellRadius)
+		}
+	}
+}
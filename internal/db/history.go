@@ -2,7 +2,10 @@ package db
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
+
+	"eve-flipper/internal/engine"
 )
 
 // ScanRecord represents a scan history entry.
@@ -16,6 +19,13 @@ type ScanRecord struct {
 	TotalProfit float64         `json:"total_profit"`
 	DurationMs  int64           `json:"duration_ms"`
 	Params      json.RawMessage `json:"params"`
+	Partial     bool            `json:"partial"`  // scan was canceled before finishing
+	Imported    bool            `json:"imported"` // loaded from an exported bundle, not scanned here
+	// EngineVersion is engine.EngineVersion when the scan ran ("" for scans
+	// saved before it was recorded). ResolvedParams holds the engine
+	// parameters the request resolved to; only GetHistoryByID loads it.
+	EngineVersion  string          `json:"engine_version"`
+	ResolvedParams json.RawMessage `json:"resolved_params,omitempty"`
 }
 
 // InsertHistory inserts a scan history record and returns its ID.
@@ -31,12 +41,54 @@ func (d *DB) InsertHistory(tab, system string, count int, topProfit float64) int
 	return id
 }
 
-// InsertHistoryFull inserts a scan history record with all fields.
-func (d *DB) InsertHistoryFull(tab, system string, count int, topProfit, totalProfit float64, durationMs int64, params interface{}) int64 {
+// InsertHistoryFull inserts a scan history record with all fields. params is
+// the raw request; resolved is the engine parameter struct it resolved to.
+// The record is stamped with the current engine.EngineVersion.
+func (d *DB) InsertHistoryFull(tab, system string, count int, topProfit, totalProfit float64, durationMs int64, params, resolved interface{}) int64 {
+	return d.insertHistory(tab, system, count, topProfit, totalProfit, durationMs, params, resolved, false)
+}
+
+// InsertPartialHistory is InsertHistoryFull for a scan that was canceled
+// part-way; the record is flagged so its results are not mistaken for a
+// complete scan.
+func (d *DB) InsertPartialHistory(tab, system string, count int, topProfit, totalProfit float64, durationMs int64, params, resolved interface{}) int64 {
+	return d.insertHistory(tab, system, count, topProfit, totalProfit, durationMs, params, resolved, true)
+}
+
+func (d *DB) insertHistory(tab, system string, count int, topProfit, totalProfit float64, durationMs int64, params, resolved interface{}, partial bool) int64 {
 	paramsJSON, _ := json.Marshal(params)
+	resolvedJSON := ""
+	if resolved != nil {
+		if b, err := json.Marshal(resolved); err == nil {
+			resolvedJSON = string(b)
+		}
+	}
+	result, err := d.sql.Exec(
+		`INSERT INTO scan_history (timestamp, tab, system, count, top_profit, total_profit, duration_ms, params_json, partial, engine_version, resolved_params_json)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		time.Now().Format(time.RFC3339), tab, system, count, topProfit, totalProfit, durationMs, string(paramsJSON), partial, engine.EngineVersion, resolvedJSON,
+	)
+	if err != nil {
+		return 0
+	}
+	id, _ := result.LastInsertId()
+	return id
+}
+
+// InsertImportedHistory stores a scan exported elsewhere as a new history
+// record owned by userID. Only the descriptive fields of rec are used; the
+// timestamp is the import time so retention counts from the import, while
+// the engine version and resolved parameters are the exporter's.
+func (d *DB) InsertImportedHistory(userID string, rec ScanRecord) int64 {
+	params := string(rec.Params)
+	if params == "" {
+		params = "{}"
+	}
 	result, err := d.sql.Exec(
-		"INSERT INTO scan_history (timestamp, tab, system, count, top_profit, total_profit, duration_ms, params_json) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-		time.Now().Format(time.RFC3339), tab, system, count, topProfit, totalProfit, durationMs, string(paramsJSON),
+		`INSERT INTO scan_history (timestamp, tab, system, count, top_profit, total_profit, duration_ms, params_json, partial, imported, imported_by, engine_version, resolved_params_json)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?, ?)`,
+		time.Now().Format(time.RFC3339), rec.Tab, rec.System, rec.Count, rec.TopProfit, rec.TotalProfit, rec.DurationMs, params, rec.Partial, normalizeUserID(userID),
+		rec.EngineVersion, string(rec.ResolvedParams),
 	)
 	if err != nil {
 		return 0
@@ -52,7 +104,7 @@ func (d *DB) GetHistory(limit int) []ScanRecord {
 	}
 	rows, err := d.sql.Query(
 		`SELECT id, timestamp, tab, system, count, top_profit,
-		 COALESCE(total_profit, 0), COALESCE(duration_ms, 0), COALESCE(params_json, '{}')
+		 COALESCE(total_profit, 0), COALESCE(duration_ms, 0), COALESCE(params_json, '{}'), partial, imported, engine_version
 		 FROM scan_history ORDER BY id DESC LIMIT ?`,
 		limit,
 	)
@@ -65,7 +117,7 @@ func (d *DB) GetHistory(limit int) []ScanRecord {
 	for rows.Next() {
 		var r ScanRecord
 		var paramsStr string
-		rows.Scan(&r.ID, &r.Timestamp, &r.Tab, &r.System, &r.Count, &r.TopProfit, &r.TotalProfit, &r.DurationMs, &paramsStr)
+		rows.Scan(&r.ID, &r.Timestamp, &r.Tab, &r.System, &r.Count, &r.TopProfit, &r.TotalProfit, &r.DurationMs, &paramsStr, &r.Partial, &r.Imported, &r.EngineVersion)
 		r.Params = json.RawMessage(paramsStr)
 		records = append(records, r)
 	}
@@ -79,16 +131,21 @@ func (d *DB) GetHistory(limit int) []ScanRecord {
 func (d *DB) GetHistoryByID(id int64) *ScanRecord {
 	row := d.sql.QueryRow(
 		`SELECT id, timestamp, tab, system, count, top_profit,
-		 COALESCE(total_profit, 0), COALESCE(duration_ms, 0), COALESCE(params_json, '{}')
+		 COALESCE(total_profit, 0), COALESCE(duration_ms, 0), COALESCE(params_json, '{}'), partial, imported,
+		 engine_version, resolved_params_json
 		 FROM scan_history WHERE id = ?`,
 		id,
 	)
 	var r ScanRecord
-	var paramsStr string
-	if err := row.Scan(&r.ID, &r.Timestamp, &r.Tab, &r.System, &r.Count, &r.TopProfit, &r.TotalProfit, &r.DurationMs, &paramsStr); err != nil {
+	var paramsStr, resolvedStr string
+	if err := row.Scan(&r.ID, &r.Timestamp, &r.Tab, &r.System, &r.Count, &r.TopProfit, &r.TotalProfit, &r.DurationMs, &paramsStr, &r.Partial, &r.Imported,
+		&r.EngineVersion, &resolvedStr); err != nil {
 		return nil
 	}
 	r.Params = json.RawMessage(paramsStr)
+	if resolvedStr != "" {
+		r.ResolvedParams = json.RawMessage(resolvedStr)
+	}
 	return &r
 }
 
@@ -103,6 +160,7 @@ func (d *DB) DeleteHistory(id int64) error {
 	tx.Exec("DELETE FROM contract_results WHERE scan_id = ?", id)
 	tx.Exec("DELETE FROM station_results WHERE scan_id = ?", id)
 	tx.Exec("DELETE FROM route_results WHERE scan_id = ?", id)
+	tx.Exec("DELETE FROM share_links WHERE scan_id = ?", id)
 	tx.Exec("DELETE FROM scan_history WHERE id = ?", id)
 	return tx.Commit()
 }
@@ -138,6 +196,7 @@ func (d *DB) ClearHistory(olderThanDays int) (int64, error) {
 		tx.Exec("DELETE FROM contract_results WHERE scan_id = ?", id)
 		tx.Exec("DELETE FROM station_results WHERE scan_id = ?", id)
 		tx.Exec("DELETE FROM route_results WHERE scan_id = ?", id)
+		tx.Exec("DELETE FROM share_links WHERE scan_id = ?", id)
 	}
 	result, err := tx.Exec("DELETE FROM scan_history WHERE timestamp < ?", cutoff)
 	if err != nil {
@@ -148,3 +207,27 @@ func (d *DB) ClearHistory(olderThanDays int) (int64, error) {
 	count, _ := result.RowsAffected()
 	return count, nil
 }
+
+// scanResultTables hold per-scan rows keyed by scan_history.id.
+var scanResultTables = []string{
+	"flip_results",
+	"regional_day_results",
+	"contract_results",
+	"station_results",
+	"route_results",
+}
+
+// DeleteOrphanedResults removes result rows whose scan_history entry no
+// longer exists (e.g. left behind by an interrupted ClearHistory).
+func (d *DB) DeleteOrphanedResults() (int64, error) {
+	var total int64
+	for _, table := range scanResultTables {
+		res, err := d.sql.Exec("DELETE FROM " + table + " WHERE scan_id NOT IN (SELECT id FROM scan_history)")
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", table, err)
+		}
+		n, _ := res.RowsAffected()
+		total += n
+	}
+	return total, nil
+}
@@ -232,6 +232,7 @@ type IndustryPlanPreview struct {
 	Tasks     []IndustryTaskPreview  `json:"tasks"`
 	Jobs      []IndustryJobPlanInput `json:"jobs"`
 	Warnings  []string               `json:"warnings"`
+	Diff      IndustryPlanDiff       `json:"diff"`
 }
 
 type IndustryLedgerEntry struct {
@@ -2008,6 +2009,10 @@ func (d *DB) PreviewIndustryPlanForUser(userID string, projectID int64, patch In
 		}
 		return IndustryPlanPreview{}, err
 	}
+	current, err := loadIndustryPlanState(d.sql.Query, userID, projectID)
+	if err != nil {
+		return IndustryPlanPreview{}, err
+	}
 
 	nowTime := time.Now().UTC()
 	now := nowTime.Format(time.RFC3339)
@@ -2209,43 +2214,57 @@ func (d *DB) PreviewIndustryPlanForUser(userID string, projectID int64, patch In
 		Tasks:     taskPreview,
 		Jobs:      jobsForPreview,
 		Warnings:  warnings,
+		Diff:      diffIndustryPlan(current, patch, jobsForPreview),
 	}, nil
 }
 
 func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch IndustryPlanPatch) (IndustryPlanSummary, error) {
+	summary, _, err := d.ApplyIndustryPlanWithDiffForUser(userID, projectID, patch)
+	return summary, err
+}
+
+// ApplyIndustryPlanWithDiffForUser applies patch like ApplyIndustryPlanForUser
+// and also returns the diff it enacted, computed inside the same transaction.
+func (d *DB) ApplyIndustryPlanWithDiffForUser(userID string, projectID int64, patch IndustryPlanPatch) (IndustryPlanSummary, IndustryPlanDiff, error) {
+	var diff IndustryPlanDiff
 	userID = normalizeUserID(userID)
 	if projectID <= 0 {
-		return IndustryPlanSummary{}, fmt.Errorf("project_id must be positive")
+		return IndustryPlanSummary{}, diff, fmt.Errorf("project_id must be positive")
 	}
 
 	project, err := d.GetIndustryProjectForUser(userID, projectID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return IndustryPlanSummary{}, sql.ErrNoRows
+			return IndustryPlanSummary{}, diff, sql.ErrNoRows
 		}
-		return IndustryPlanSummary{}, err
+		return IndustryPlanSummary{}, diff, err
 	}
 
 	tx, err := d.sql.Begin()
 	if err != nil {
-		return IndustryPlanSummary{}, err
+		return IndustryPlanSummary{}, diff, err
 	}
 	defer tx.Rollback()
 
+	current, err := loadIndustryPlanState(tx.Query, userID, projectID)
+	if err != nil {
+		return IndustryPlanSummary{}, diff, err
+	}
+
 	if patch.Replace {
 		if _, err := tx.Exec(`DELETE FROM industry_jobs WHERE user_id = ? AND project_id = ?`, userID, projectID); err != nil {
-			return IndustryPlanSummary{}, err
+			return IndustryPlanSummary{}, diff, err
 		}
 		if _, err := tx.Exec(`DELETE FROM industry_tasks WHERE user_id = ? AND project_id = ?`, userID, projectID); err != nil {
-			return IndustryPlanSummary{}, err
+			return IndustryPlanSummary{}, diff, err
 		}
 		if _, err := tx.Exec(`DELETE FROM industry_material_plan WHERE user_id = ? AND project_id = ?`, userID, projectID); err != nil {
-			return IndustryPlanSummary{}, err
+			return IndustryPlanSummary{}, diff, err
 		}
 	}
 	if patch.Replace || patch.ReplaceBlueprintPool {
 		if _, err := tx.Exec(`DELETE FROM industry_blueprint_pool WHERE user_id = ? AND project_id = ?`, userID, projectID); err != nil {
-			return IndustryPlanSummary{}, err
+			return IndustryPlanSummary{}, diff, err
 		}
 	}
 
@@ -2258,7 +2277,7 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 	if !patch.Replace {
 		existingTaskIDs, err = loadIndustryTaskIDSetTx(tx, userID, projectID)
 		if err != nil {
-			return IndustryPlanSummary{}, err
+			return IndustryPlanSummary{}, diff, err
 		}
 	}
 	if len(patch.Tasks) > 0 {
@@ -2269,7 +2288,7 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`)
 		if err != nil {
-			return IndustryPlanSummary{}, err
+			return IndustryPlanSummary{}, diff, err
 		}
 		defer stmt.Close()
 
@@ -2296,11 +2315,11 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 				now,
 			)
 			if err != nil {
-				return IndustryPlanSummary{}, err
+				return IndustryPlanSummary{}, diff, err
 			}
 			insertedID, err := res.LastInsertId()
 			if err != nil {
-				return IndustryPlanSummary{}, err
+				return IndustryPlanSummary{}, diff, err
 			}
 			inputIndex := int64(idx + 1)
 			inputTaskIndexToID[inputIndex] = insertedID
@@ -2343,7 +2362,7 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 				   SET parent_task_id = ?, updated_at = ?
 				 WHERE user_id = ? AND project_id = ? AND id = ?
 			`, nullablePositiveInt64(parentID), now, userID, projectID, insertedTaskRecords[i].ID); err != nil {
-				return IndustryPlanSummary{}, err
+				return IndustryPlanSummary{}, diff, err
 			}
 		}
 	}
@@ -2370,7 +2389,7 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 			taskParents := map[int64]int64{}
 			taskPlannedEnd := map[int64]time.Time{}
 			if err := loadIndustryTaskSchedulingMapsTx(tx, userID, projectID, taskParents, taskPlannedEnd); err != nil {
-				return IndustryPlanSummary{}, err
+				return IndustryPlanSummary{}, diff, err
 			}
 			jobsForInsert = splitAndScheduleIndustryJobs(jobsForInsert, schedulerCfg, nowTime, taskParents, taskPlannedEnd)
 			schedulerApplied = true
@@ -2403,13 +2422,13 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 	if !patch.Replace {
 		blueprintPool, err = listIndustryBlueprintPoolForProjectTx(tx, userID, projectID)
 		if err != nil {
-			return IndustryPlanSummary{}, err
+			return IndustryPlanSummary{}, diff, err
 		}
 	}
 	blueprintPool = mergeIndustryBlueprintPool(blueprintPool, patch.Blueprints)
 	taskRecordsForCaps, err := listIndustryTaskRecordsForProjectTx(tx, userID, projectID)
 	if err != nil {
-		return IndustryPlanSummary{}, err
+		return IndustryPlanSummary{}, diff, err
 	}
 	if len(jobsForInsert) > 0 && len(taskRecordsForCaps) > 0 {
 		var capWarnings []string
@@ -2433,7 +2452,7 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`)
 		if err != nil {
-			return IndustryPlanSummary{}, err
+			return IndustryPlanSummary{}, diff, err
 		}
 		defer stmt.Close()
 
@@ -2456,7 +2475,7 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 				now,
 				now,
 			); err != nil {
-				return IndustryPlanSummary{}, err
+				return IndustryPlanSummary{}, diff, err
 			}
 			jobInsertCount++
 		}
@@ -2481,7 +2500,7 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 				updated_at = excluded.updated_at
 		`)
 		if err != nil {
-			return IndustryPlanSummary{}, err
+			return IndustryPlanSummary{}, diff, err
 		}
 		defer stmt.Close()
 
@@ -2503,7 +2522,7 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 				normalizeIndustryMaterialSource(m.Source),
 				now,
 			); err != nil {
-				return IndustryPlanSummary{}, err
+				return IndustryPlanSummary{}, diff, err
 			}
 			materialUpsertCount++
 		}
@@ -2526,7 +2545,7 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 				updated_at = excluded.updated_at
 		`)
 		if err != nil {
-			return IndustryPlanSummary{}, err
+			return IndustryPlanSummary{}, diff, err
 		}
 		defer stmt.Close()
 
@@ -2551,7 +2570,7 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 				normalizeIndustryBlueprintAvailableRuns(bp.IsBPO, bp.AvailableRuns),
 				now,
 			); err != nil {
-				return IndustryPlanSummary{}, err
+				return IndustryPlanSummary{}, diff, err
 			}
 			blueprintUpsertCount++
 		}
@@ -2566,11 +2585,12 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 		   SET status = ?, updated_at = ?
 		 WHERE user_id = ? AND id = ?
 	`, projectStatus, now, userID, projectID); err != nil {
-		return IndustryPlanSummary{}, err
+		return IndustryPlanSummary{}, diff, err
 	}
 
+	diff = diffIndustryPlan(current, patch, jobsForInsert)
 	if err := tx.Commit(); err != nil {
-		return IndustryPlanSummary{}, err
+		return IndustryPlanSummary{}, IndustryPlanDiff{}, err
 	}
 
 	return IndustryPlanSummary{
@@ -2586,7 +2606,7 @@ func (d *DB) ApplyIndustryPlanForUser(userID string, projectID int64, patch Indu
 		JobsPlannedTotal: len(jobsForInsert),
 		Warnings:         warnings,
 		UpdatedAt:        now,
-	}, nil
+	}, diff, nil
 }
 
 func (d *DB) getIndustryJobForUser(userID string, jobID int64) (*IndustryJob, error) {
@@ -547,6 +547,79 @@ func TestIndustryLedgerPreviewPlanNoPersist(t *testing.T) {
 	}
 }
 
+func TestIndustryLedgerPreviewPlanDiff(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	project, err := d.CreateIndustryProjectForUser("user-diff", IndustryProjectCreateInput{Name: "Diff Test"})
+	if err != nil {
+		t.Fatalf("CreateIndustryProjectForUser: %v", err)
+	}
+	if _, err := d.ApplyIndustryPlanForUser("user-diff", project.ID, IndustryPlanPatch{
+		Materials: []IndustryMaterialPlanInput{
+			{TypeID: 34, TypeName: "Tritanium", RequiredQty: 1000, BuyQty: 1000, UnitCostISK: 5},
+			{TypeID: 35, TypeName: "Pyerite", RequiredQty: 100, BuyQty: 100, UnitCostISK: 10},
+		},
+		Blueprints: []IndustryBlueprintPoolInput{
+			{BlueprintTypeID: 1000, BlueprintName: "Widget Blueprint", Quantity: 1, ME: 5, IsBPO: true},
+		},
+		Jobs: []IndustryJobPlanInput{{Activity: "manufacturing", Runs: 1, CostISK: 2000}},
+	}); err != nil {
+		t.Fatalf("ApplyIndustryPlanForUser: %v", err)
+	}
+
+	patch := IndustryPlanPatch{
+		Replace: true,
+		Materials: []IndustryMaterialPlanInput{
+			{TypeID: 34, TypeName: "Tritanium", RequiredQty: 2000, BuyQty: 2000, UnitCostISK: 5},
+			{TypeID: 36, TypeName: "Mexallon", RequiredQty: 10, BuyQty: 10, UnitCostISK: 50},
+		},
+		Blueprints: []IndustryBlueprintPoolInput{
+			{BlueprintTypeID: 1000, BlueprintName: "Widget Blueprint", Quantity: 1, ME: 10, IsBPO: true},
+		},
+		Jobs: []IndustryJobPlanInput{{Activity: "manufacturing", Runs: 2, CostISK: 3000}},
+	}
+	preview, err := d.PreviewIndustryPlanForUser("user-diff", project.ID, patch)
+	if err != nil {
+		t.Fatalf("PreviewIndustryPlanForUser: %v", err)
+	}
+	diff := preview.Diff
+	changes := map[int32]IndustryPlanMaterialChange{}
+	for _, c := range diff.Materials {
+		changes[c.TypeID] = c
+	}
+	if len(changes) != 3 ||
+		changes[34].Change != "changed" || changes[34].CostDeltaISK != 5000 ||
+		changes[35].Change != "removed" || changes[35].After != nil ||
+		changes[36].Change != "added" || changes[36].Before != nil {
+		t.Fatalf("material changes = %+v", diff.Materials)
+	}
+	if len(diff.Blueprints) != 1 || diff.Blueprints[0].Change != "changed" ||
+		diff.Blueprints[0].Before.ME != 5 || diff.Blueprints[0].After.ME != 10 {
+		t.Fatalf("blueprint changes = %+v", diff.Blueprints)
+	}
+	// Materials 6000 -> 10500, jobs 2000 -> 3000 (replace drops the old job).
+	if diff.MaterialCostBeforeISK != 6000 || diff.MaterialCostAfterISK != 10500 ||
+		diff.JobCostBeforeISK != 2000 || diff.JobCostAfterISK != 3000 || diff.CostDeltaISK != 5500 {
+		t.Fatalf("cost diff = %+v", diff)
+	}
+
+	_, applied, err := d.ApplyIndustryPlanWithDiffForUser("user-diff", project.ID, patch)
+	if err != nil {
+		t.Fatalf("ApplyIndustryPlanWithDiffForUser: %v", err)
+	}
+	if applied.CostDeltaISK != diff.CostDeltaISK || len(applied.Materials) != len(diff.Materials) {
+		t.Fatalf("applied diff = %+v, want preview diff %+v", applied, diff)
+	}
+	again, err := d.PreviewIndustryPlanForUser("user-diff", project.ID, patch)
+	if err != nil {
+		t.Fatalf("PreviewIndustryPlanForUser after apply: %v", err)
+	}
+	if len(again.Diff.Materials) != 0 || len(again.Diff.Blueprints) != 0 || again.Diff.CostDeltaISK != 0 {
+		t.Fatalf("re-preview of applied plan = %+v, want no changes", again.Diff)
+	}
+}
+
 func TestIndustryLedgerSchedulerStrategyDefaults(t *testing.T) {
 	d := openTestDB(t)
 	defer d.Close()
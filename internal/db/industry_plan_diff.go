@@ -0,0 +1,332 @@
+package db
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+)
+
+// IndustryPlanMaterialState is one material row before or after a plan patch.
+// CostISK is what the row still has to buy: BuyQty * UnitCostISK.
+type IndustryPlanMaterialState struct {
+	RequiredQty  int64   `json:"required_qty"`
+	AvailableQty int64   `json:"available_qty"`
+	BuyQty       int64   `json:"buy_qty"`
+	BuildQty     int64   `json:"build_qty"`
+	UnitCostISK  float64 `json:"unit_cost_isk"`
+	Source       string  `json:"source"`
+	CostISK      float64 `json:"cost_isk"`
+}
+
+// IndustryPlanMaterialChange is a material row the patch adds, removes or
+// changes, keyed like the table by task and type.
+type IndustryPlanMaterialChange struct {
+	Change       string                     `json:"change"` // added | removed | changed
+	TaskID       int64                      `json:"task_id"`
+	TypeID       int32                      `json:"type_id"`
+	TypeName     string                     `json:"type_name"`
+	Before       *IndustryPlanMaterialState `json:"before,omitempty"`
+	After        *IndustryPlanMaterialState `json:"after,omitempty"`
+	CostDeltaISK float64                    `json:"cost_delta_isk"`
+}
+
+type IndustryPlanBlueprintState struct {
+	Quantity      int64 `json:"quantity"`
+	ME            int32 `json:"me"`
+	TE            int32 `json:"te"`
+	AvailableRuns int64 `json:"available_runs"`
+}
+
+// IndustryPlanBlueprintChange is a blueprint pool row the patch adds,
+// removes or changes, keyed by blueprint type, location and BPO/BPC.
+type IndustryPlanBlueprintChange struct {
+	Change          string                      `json:"change"` // added | removed | changed
+	BlueprintTypeID int32                       `json:"blueprint_type_id"`
+	BlueprintName   string                      `json:"blueprint_name"`
+	LocationID      int64                       `json:"location_id"`
+	IsBPO           bool                        `json:"is_bpo"`
+	Before          *IndustryPlanBlueprintState `json:"before,omitempty"`
+	After           *IndustryPlanBlueprintState `json:"after,omitempty"`
+}
+
+// IndustryPlanDiff compares a project's materials, blueprint pool and
+// projected cost before and after a plan patch. Projected cost is the ISK
+// still to spend on material purchases plus the cost of the project's jobs.
+type IndustryPlanDiff struct {
+	Materials             []IndustryPlanMaterialChange  `json:"materials"`
+	Blueprints            []IndustryPlanBlueprintChange `json:"blueprints"`
+	MaterialCostBeforeISK float64                       `json:"material_cost_before_isk"`
+	MaterialCostAfterISK  float64                       `json:"material_cost_after_isk"`
+	JobCostBeforeISK      float64                       `json:"job_cost_before_isk"`
+	JobCostAfterISK       float64                       `json:"job_cost_after_isk"`
+	CostDeltaISK          float64                       `json:"cost_delta_isk"`
+}
+
+const (
+	industryPlanChangeAdded   = "added"
+	industryPlanChangeRemoved = "removed"
+	industryPlanChangeChanged = "changed"
+)
+
+type industryPlanState struct {
+	materials  []IndustryMaterialPlan
+	blueprints []IndustryBlueprintPool
+	jobCostISK float64
+}
+
+// loadIndustryPlanState reads what a plan diff compares against. query is
+// d.sql.Query or tx.Query so preview and apply see the same rows.
+func loadIndustryPlanState(
+	query func(string, ...interface{}) (*sql.Rows, error),
+	userID string,
+	projectID int64,
+) (industryPlanState, error) {
+	var state industryPlanState
+
+	rows, err := query(`
+		SELECT id, user_id, project_id, task_id, type_id, type_name, required_qty, available_qty,
+		       buy_qty, build_qty, unit_cost_isk, source, updated_at
+		  FROM industry_material_plan
+		 WHERE user_id = ? AND project_id = ?
+	`, userID, projectID)
+	if err != nil {
+		return state, err
+	}
+	for rows.Next() {
+		var row IndustryMaterialPlan
+		if err := rows.Scan(
+			&row.ID,
+			&row.UserID,
+			&row.ProjectID,
+			&row.TaskID,
+			&row.TypeID,
+			&row.TypeName,
+			&row.RequiredQty,
+			&row.AvailableQty,
+			&row.BuyQty,
+			&row.BuildQty,
+			&row.UnitCostISK,
+			&row.Source,
+			&row.UpdatedAt,
+		); err != nil {
+			rows.Close()
+			return state, err
+		}
+		state.materials = append(state.materials, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return state, err
+	}
+
+	rows, err = query(`
+		SELECT id, user_id, project_id, blueprint_type_id, blueprint_name, location_id,
+		       quantity, me, te, is_bpo, available_runs, updated_at
+		  FROM industry_blueprint_pool
+		 WHERE user_id = ? AND project_id = ?
+	`, userID, projectID)
+	if err != nil {
+		return state, err
+	}
+	state.blueprints, err = scanIndustryBlueprintPoolRows(rows)
+	rows.Close()
+	if err != nil {
+		return state, err
+	}
+
+	rows, err = query(`
+		SELECT COALESCE(SUM(cost_isk), 0)
+		  FROM industry_jobs
+		 WHERE user_id = ? AND project_id = ?
+	`, userID, projectID)
+	if err != nil {
+		return state, err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err := rows.Scan(&state.jobCostISK); err != nil {
+			return state, err
+		}
+	}
+	return state, rows.Err()
+}
+
+// diffIndustryPlan applies patch to before the way ApplyIndustryPlanForUser
+// writes it and reports the difference. plannedJobs are the jobs the patch
+// inserts after scheduling and blueprint caps.
+func diffIndustryPlan(before industryPlanState, patch IndustryPlanPatch, plannedJobs []IndustryJobPlanInput) IndustryPlanDiff {
+	type materialKey struct {
+		TaskID int64
+		TypeID int32
+	}
+	materialsBefore := make(map[materialKey]IndustryMaterialPlan, len(before.materials))
+	for _, row := range before.materials {
+		materialsBefore[materialKey{row.TaskID, row.TypeID}] = row
+	}
+	materialsAfter := make(map[materialKey]IndustryMaterialPlan, len(before.materials)+len(patch.Materials))
+	if !patch.Replace {
+		for k, row := range materialsBefore {
+			materialsAfter[k] = row
+		}
+	}
+	for _, m := range patch.Materials {
+		if m.TypeID <= 0 {
+			continue
+		}
+		materialsAfter[materialKey{m.TaskID, m.TypeID}] = IndustryMaterialPlan{
+			TaskID:       m.TaskID,
+			TypeID:       m.TypeID,
+			TypeName:     strings.TrimSpace(m.TypeName),
+			RequiredQty:  m.RequiredQty,
+			AvailableQty: m.AvailableQty,
+			BuyQty:       m.BuyQty,
+			BuildQty:     m.BuildQty,
+			UnitCostISK:  m.UnitCostISK,
+			Source:       normalizeIndustryMaterialSource(m.Source),
+		}
+	}
+
+	diff := IndustryPlanDiff{
+		Materials:        []IndustryPlanMaterialChange{},
+		Blueprints:       []IndustryPlanBlueprintChange{},
+		JobCostBeforeISK: before.jobCostISK,
+	}
+	materialState := func(row IndustryMaterialPlan) *IndustryPlanMaterialState {
+		return &IndustryPlanMaterialState{
+			RequiredQty:  row.RequiredQty,
+			AvailableQty: row.AvailableQty,
+			BuyQty:       row.BuyQty,
+			BuildQty:     row.BuildQty,
+			UnitCostISK:  row.UnitCostISK,
+			Source:       row.Source,
+			CostISK:      float64(row.BuyQty) * row.UnitCostISK,
+		}
+	}
+	for k, old := range materialsBefore {
+		diff.MaterialCostBeforeISK += float64(old.BuyQty) * old.UnitCostISK
+		if _, kept := materialsAfter[k]; !kept {
+			st := materialState(old)
+			diff.Materials = append(diff.Materials, IndustryPlanMaterialChange{
+				Change:       industryPlanChangeRemoved,
+				TaskID:       k.TaskID,
+				TypeID:       k.TypeID,
+				TypeName:     old.TypeName,
+				Before:       st,
+				CostDeltaISK: -st.CostISK,
+			})
+		}
+	}
+	for k, row := range materialsAfter {
+		after := materialState(row)
+		diff.MaterialCostAfterISK += after.CostISK
+		old, existed := materialsBefore[k]
+		change := IndustryPlanMaterialChange{
+			Change:       industryPlanChangeAdded,
+			TaskID:       k.TaskID,
+			TypeID:       k.TypeID,
+			TypeName:     row.TypeName,
+			After:        after,
+			CostDeltaISK: after.CostISK,
+		}
+		if existed {
+			prev := materialState(old)
+			if *prev == *after {
+				continue
+			}
+			change.Change = industryPlanChangeChanged
+			change.Before = prev
+			change.CostDeltaISK = after.CostISK - prev.CostISK
+			if change.TypeName == "" {
+				change.TypeName = old.TypeName
+			}
+		}
+		diff.Materials = append(diff.Materials, change)
+	}
+	sort.Slice(diff.Materials, func(i, j int) bool {
+		a, b := diff.Materials[i], diff.Materials[j]
+		if a.TypeID != b.TypeID {
+			return a.TypeID < b.TypeID
+		}
+		return a.TaskID < b.TaskID
+	})
+
+	type bpKey struct {
+		TypeID     int32
+		LocationID int64
+		IsBPO      bool
+	}
+	keyOf := func(row IndustryBlueprintPool) bpKey {
+		return bpKey{row.BlueprintTypeID, row.LocationID, row.IsBPO}
+	}
+	bpState := func(row IndustryBlueprintPool) *IndustryPlanBlueprintState {
+		return &IndustryPlanBlueprintState{
+			Quantity:      row.Quantity,
+			ME:            row.ME,
+			TE:            row.TE,
+			AvailableRuns: row.AvailableRuns,
+		}
+	}
+	bpBase := before.blueprints
+	if patch.Replace || patch.ReplaceBlueprintPool {
+		bpBase = nil
+	}
+	bpBefore := make(map[bpKey]IndustryBlueprintPool, len(before.blueprints))
+	for _, row := range before.blueprints {
+		bpBefore[keyOf(row)] = row
+	}
+	bpAfter := make(map[bpKey]IndustryBlueprintPool)
+	for _, row := range mergeIndustryBlueprintPool(bpBase, patch.Blueprints) {
+		bpAfter[keyOf(row)] = row
+	}
+	for k, old := range bpBefore {
+		if _, kept := bpAfter[k]; !kept {
+			diff.Blueprints = append(diff.Blueprints, IndustryPlanBlueprintChange{
+				Change:          industryPlanChangeRemoved,
+				BlueprintTypeID: k.TypeID,
+				BlueprintName:   old.BlueprintName,
+				LocationID:      k.LocationID,
+				IsBPO:           k.IsBPO,
+				Before:          bpState(old),
+			})
+		}
+	}
+	for k, row := range bpAfter {
+		change := IndustryPlanBlueprintChange{
+			Change:          industryPlanChangeAdded,
+			BlueprintTypeID: k.TypeID,
+			BlueprintName:   row.BlueprintName,
+			LocationID:      k.LocationID,
+			IsBPO:           k.IsBPO,
+			After:           bpState(row),
+		}
+		if old, existed := bpBefore[k]; existed {
+			prev := bpState(old)
+			if *prev == *change.After {
+				continue
+			}
+			change.Change = industryPlanChangeChanged
+			change.Before = prev
+		}
+		diff.Blueprints = append(diff.Blueprints, change)
+	}
+	sort.Slice(diff.Blueprints, func(i, j int) bool {
+		a, b := diff.Blueprints[i], diff.Blueprints[j]
+		if a.BlueprintTypeID != b.BlueprintTypeID {
+			return a.BlueprintTypeID < b.BlueprintTypeID
+		}
+		if a.LocationID != b.LocationID {
+			return a.LocationID < b.LocationID
+		}
+		return a.IsBPO && !b.IsBPO
+	})
+
+	if !patch.Replace {
+		diff.JobCostAfterISK = before.jobCostISK
+	}
+	for _, j := range plannedJobs {
+		diff.JobCostAfterISK += j.CostISK
+	}
+	diff.CostDeltaISK = (diff.MaterialCostAfterISK + diff.JobCostAfterISK) -
+		(diff.MaterialCostBeforeISK + diff.JobCostBeforeISK)
+	return diff
+}
@@ -0,0 +1,58 @@
+package db
+
+import "time"
+
+// MarketDisabledOverride adds a type to the built-in market-disabled list for
+// one user. Only Disabled=true rows have an effect; built-in types cannot be
+// re-enabled because the scanners drop them before results reach the API.
+type MarketDisabledOverride struct {
+	TypeID    int32  `json:"type_id"`
+	Disabled  bool   `json:"disabled"`
+	CreatedAt string `json:"created_at"`
+}
+
+// SetMarketDisabledOverrideForUser creates or replaces the override for typeID.
+func (d *DB) SetMarketDisabledOverrideForUser(userID string, typeID int32, disabled bool) error {
+	userID = normalizeUserID(userID)
+	_, err := d.sql.Exec(`
+		INSERT INTO market_disabled_overrides (user_id, type_id, disabled, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id, type_id) DO UPDATE SET disabled = excluded.disabled, created_at = excluded.created_at
+	`, userID, typeID, disabled, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// ListMarketDisabledOverridesForUser returns the user's overrides ordered by type ID.
+func (d *DB) ListMarketDisabledOverridesForUser(userID string) ([]MarketDisabledOverride, error) {
+	userID = normalizeUserID(userID)
+	rows, err := d.sql.Query(
+		"SELECT type_id, disabled, created_at FROM market_disabled_overrides WHERE user_id = ? ORDER BY type_id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]MarketDisabledOverride, 0)
+	for rows.Next() {
+		var o MarketDisabledOverride
+		if err := rows.Scan(&o.TypeID, &o.Disabled, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// DeleteMarketDisabledOverrideForUser removes an override. Returns false if
+// there was none.
+func (d *DB) DeleteMarketDisabledOverrideForUser(userID string, typeID int32) (bool, error) {
+	userID = normalizeUserID(userID)
+	res, err := d.sql.Exec("DELETE FROM market_disabled_overrides WHERE user_id = ? AND type_id = ?", userID, typeID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
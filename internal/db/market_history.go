@@ -4,6 +4,7 @@ import (
 	"log"
 	"time"
 
+	"eve-flipper/internal/config"
 	"eve-flipper/internal/esi"
 )
 
@@ -49,7 +50,8 @@ func (d *DB) GetMarketHistory(regionID int32, typeID int32) ([]esi.HistoryEntry,
 }
 
 // SetMarketHistory stores market history entries in the cache.
-// Only entries from the last 90 days are stored to bound database growth.
+// Only entries inside the market history retention window are stored to
+// bound database growth.
 func (d *DB) SetMarketHistory(regionID int32, typeID int32, entries []esi.HistoryEntry) {
 	tx, err := d.sql.Begin()
 	if err != nil {
@@ -66,8 +68,8 @@ func (d *DB) SetMarketHistory(regionID int32, typeID int32, entries []esi.Histor
 	}
 	defer stmt.Close()
 
-	// Only keep last 90 days of data
-	cutoff := time.Now().AddDate(0, 0, -90).Format("2006-01-02")
+	_, marketDays := d.HistoryRetention()
+	cutoff := time.Now().AddDate(0, 0, -marketDays).Format("2006-01-02")
 	for _, e := range entries {
 		if e.Date >= cutoff {
 			stmt.Exec(regionID, typeID, e.Date, e.Average, e.Highest, e.Lowest, e.Volume, e.OrderCount)
@@ -83,15 +85,44 @@ func (d *DB) SetMarketHistory(regionID int32, typeID int32, entries []esi.Histor
 	tx.Commit()
 }
 
-// CleanupOldHistory removes market history data older than 90 days and
-// meta entries that haven't been refreshed in over 30 days.
+// SetHistoryRetention sets how many days of scan history and of cached
+// market history are kept. Values below 1 restore the config defaults.
+func (d *DB) SetHistoryRetention(scanDays, marketDays int) {
+	d.scanHistoryDays.Store(int32(max(scanDays, 0)))
+	d.marketHistoryDays.Store(int32(max(marketDays, 0)))
+}
+
+// HistoryRetention returns the scan and market history retention in days.
+func (d *DB) HistoryRetention() (scanDays, marketDays int) {
+	scanDays = int(d.scanHistoryDays.Load())
+	if scanDays <= 0 {
+		scanDays = config.DefaultHistoryRetentionDays
+	}
+	marketDays = int(d.marketHistoryDays.Load())
+	if marketDays <= 0 {
+		marketDays = config.DefaultMarketHistoryRetentionDays
+	}
+	return scanDays, marketDays
+}
+
+// CleanupOldHistory removes scan history and market history older than the
+// retention windows (see SetHistoryRetention), market history meta entries
+// that haven't been refreshed in over 30 days, and trims config_history to
+// the newest ConfigHistoryKeep snapshots per user.
 // Should be called periodically (e.g. on startup or daily) to prevent
 // unbounded SQLite database growth.
 func (d *DB) CleanupOldHistory() {
-	cutoffDate := time.Now().AddDate(0, 0, -90).Format("2006-01-02")
+	scanDays, marketDays := d.HistoryRetention()
+	cutoffDate := time.Now().AddDate(0, 0, -marketDays).Format("2006-01-02")
 	cutoffMeta := time.Now().AddDate(0, 0, -30).Format(time.RFC3339)
 
-	// Delete history rows older than 90 days
+	if n, err := d.ClearHistory(scanDays); err != nil {
+		log.Printf("[DB] CleanupOldHistory: scan history delete error: %v", err)
+	} else if n > 0 {
+		log.Printf("[DB] CleanupOldHistory: removed %d scans older than %d days", n, scanDays)
+	}
+
+	// Delete history rows outside the retention window
 	res, err := d.sql.Exec("DELETE FROM market_history WHERE date < ?", cutoffDate)
 	if err != nil {
 		log.Printf("[DB] CleanupOldHistory: history delete error: %v", err)
@@ -119,4 +150,29 @@ func (d *DB) CleanupOldHistory() {
 	} else if n, _ := res.RowsAffected(); n > 0 {
 		log.Printf("[DB] CleanupOldHistory: removed %d orphaned history rows", n)
 	}
+
+	// Keep only the newest config snapshots per user
+	if n, err := d.PruneConfigHistory(ConfigHistoryKeep); err != nil {
+		log.Printf("[DB] CleanupOldHistory: config history prune error: %v", err)
+	} else if n > 0 {
+		log.Printf("[DB] CleanupOldHistory: removed %d old config snapshots", n)
+	}
+
+	if n, err := d.PrunePLEXHistory(); err != nil {
+		log.Printf("[DB] CleanupOldHistory: plex history prune error: %v", err)
+	} else if n > 0 {
+		log.Printf("[DB] CleanupOldHistory: removed %d old PLEX history points", n)
+	}
+
+	if n, err := d.PruneStationTrends(); err != nil {
+		log.Printf("[DB] CleanupOldHistory: station trends prune error: %v", err)
+	} else if n > 0 {
+		log.Printf("[DB] CleanupOldHistory: removed %d old station trend points", n)
+	}
+
+	if n, err := d.PruneExpiredShareLinks(time.Now()); err != nil {
+		log.Printf("[DB] CleanupOldHistory: share link prune error: %v", err)
+	} else if n > 0 {
+		log.Printf("[DB] CleanupOldHistory: removed %d expired share links", n)
+	}
 }
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+
+	"eve-flipper/internal/engine"
+)
+
+// PLEXHistoryRetentionDays bounds plex_history; at one point per hour this
+// is under 9k rows.
+const PLEXHistoryRetentionDays = 365
+
+// PLEXHistoryPoint is the stored summary of one PLEX dashboard build.
+type PLEXHistoryPoint struct {
+	RecordedAt string         `json:"recorded_at"`
+	PLEXBuy    float64        `json:"plex_buy"`
+	PLEXSell   float64        `json:"plex_sell"`
+	SpreadPct  float64        `json:"spread_pct"`
+	Volume24h  int64          `json:"volume_24h"`
+	NESRatios  []PLEXNESRatio `json:"nes_ratios"`
+}
+
+// PLEXNESRatio is what one NES conversion path returned per PLEX spent.
+type PLEXNESRatio struct {
+	Name       string  `json:"name"`
+	PLEXCost   int     `json:"plex_cost"`
+	ISKPerPLEX float64 `json:"isk_per_plex"` // Net revenue / PLEX spent
+	ROI        float64 `json:"roi"`
+}
+
+// RecordPLEXHistory stores the dashboard's key figures in the hour bucket of
+// at, replacing an earlier point from the same hour.
+func (d *DB) RecordPLEXHistory(dash engine.PLEXDashboard, at time.Time) error {
+	ratios := make([]PLEXNESRatio, 0, len(dash.Arbitrage))
+	for _, p := range dash.Arbitrage {
+		if p.PLEXCost <= 0 || p.NoData {
+			continue
+		}
+		ratios = append(ratios, PLEXNESRatio{
+			Name:       p.Name,
+			PLEXCost:   p.PLEXCost,
+			ISKPerPLEX: p.RevenueISK / float64(p.PLEXCost),
+			ROI:        p.ROI,
+		})
+	}
+	raw, err := json.Marshal(ratios)
+	if err != nil {
+		return err
+	}
+
+	at = at.UTC()
+	_, err = d.sql.Exec(`
+		INSERT INTO plex_history (hour, recorded_at, plex_buy, plex_sell, spread_pct, volume_24h, nes_ratios)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(hour) DO UPDATE SET
+			recorded_at = excluded.recorded_at,
+			plex_buy    = excluded.plex_buy,
+			plex_sell   = excluded.plex_sell,
+			spread_pct  = excluded.spread_pct,
+			volume_24h  = excluded.volume_24h,
+			nes_ratios  = excluded.nes_ratios
+	`,
+		at.Format("2006-01-02T15"), at.Format(time.RFC3339),
+		dash.PLEXPrice.BuyPrice, dash.PLEXPrice.SellPrice, dash.PLEXPrice.SpreadPct, dash.PLEXPrice.Volume24h,
+		string(raw),
+	)
+	return err
+}
+
+// ListPLEXHistory returns points recorded at or after since, oldest first.
+func (d *DB) ListPLEXHistory(since time.Time) ([]PLEXHistoryPoint, error) {
+	rows, err := d.sql.Query(`
+		SELECT recorded_at, plex_buy, plex_sell, spread_pct, volume_24h, nes_ratios
+		FROM plex_history WHERE hour >= ? ORDER BY hour
+	`, since.UTC().Format("2006-01-02T15"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]PLEXHistoryPoint, 0)
+	for rows.Next() {
+		var p PLEXHistoryPoint
+		var raw string
+		if err := rows.Scan(&p.RecordedAt, &p.PLEXBuy, &p.PLEXSell, &p.SpreadPct, &p.Volume24h, &raw); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(raw), &p.NESRatios); err != nil || p.NESRatios == nil {
+			p.NESRatios = []PLEXNESRatio{}
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// PrunePLEXHistory deletes points older than PLEXHistoryRetentionDays.
+func (d *DB) PrunePLEXHistory() (int64, error) {
+	cutoff := time.Now().UTC().AddDate(0, 0, -PLEXHistoryRetentionDays).Format("2006-01-02T15")
+	res, err := d.sql.Exec("DELETE FROM plex_history WHERE hour < ?", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ScanTemplate is a named, reusable scan request for one tab. Params is the
+// request body as the scan endpoint accepts it.
+type ScanTemplate struct {
+	ID        int64           `json:"id"`
+	Tab       string          `json:"tab"`
+	Name      string          `json:"name"`
+	Params    json.RawMessage `json:"params"`
+	CreatedAt string          `json:"created_at"`
+	UpdatedAt string          `json:"updated_at"`
+}
+
+// SaveScanTemplateForUser creates the template or replaces the params of the
+// one with the same tab and name.
+func (d *DB) SaveScanTemplateForUser(userID, tab, name string, params json.RawMessage) (ScanTemplate, error) {
+	userID = normalizeUserID(userID)
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err := d.sql.Exec(`
+		INSERT INTO scan_templates (user_id, tab, name, params, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, tab, name) DO UPDATE SET params = excluded.params, updated_at = excluded.updated_at
+	`, userID, tab, name, string(params), now, now)
+	if err != nil {
+		return ScanTemplate{}, err
+	}
+	row := d.sql.QueryRow(
+		"SELECT id, tab, name, params, created_at, updated_at FROM scan_templates WHERE user_id = ? AND tab = ? AND name = ?",
+		userID, tab, name,
+	)
+	return scanTemplateRow(row)
+}
+
+// ListScanTemplatesForUser returns the user's templates ordered by tab and
+// name. An empty tab lists every tab.
+func (d *DB) ListScanTemplatesForUser(userID, tab string) ([]ScanTemplate, error) {
+	userID = normalizeUserID(userID)
+	rows, err := d.sql.Query(`
+		SELECT id, tab, name, params, created_at, updated_at FROM scan_templates
+		WHERE user_id = ? AND (? = '' OR tab = ?)
+		ORDER BY tab, name COLLATE NOCASE`,
+		userID, tab, tab,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]ScanTemplate, 0)
+	for rows.Next() {
+		t, err := scanTemplateRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// GetScanTemplateForUser returns one template, or sql.ErrNoRows.
+func (d *DB) GetScanTemplateForUser(userID string, id int64) (ScanTemplate, error) {
+	userID = normalizeUserID(userID)
+	row := d.sql.QueryRow(
+		"SELECT id, tab, name, params, created_at, updated_at FROM scan_templates WHERE user_id = ? AND id = ?",
+		userID, id,
+	)
+	return scanTemplateRow(row)
+}
+
+// DeleteScanTemplateForUser removes a template. Returns false if it did not exist.
+func (d *DB) DeleteScanTemplateForUser(userID string, id int64) (bool, error) {
+	userID = normalizeUserID(userID)
+	res, err := d.sql.Exec("DELETE FROM scan_templates WHERE user_id = ? AND id = ?", userID, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func scanTemplateRow(row interface{ Scan(...interface{}) error }) (ScanTemplate, error) {
+	var t ScanTemplate
+	var raw string
+	if err := row.Scan(&t.ID, &t.Tab, &t.Name, &raw, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return ScanTemplate{}, err
+	}
+	t.Params = json.RawMessage(raw)
+	return t, nil
+}
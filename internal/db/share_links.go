@@ -0,0 +1,74 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+)
+
+// ShareLink is a read-only link to one saved scan. Only a hash of the token
+// is stored, so the token itself is shown once, when the link is created.
+type ShareLink struct {
+	ScanID    int64  `json:"scan_id"`
+	UserID    string `json:"-"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+func shareTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateShareLink stores a link for scanID created by userID.
+func (d *DB) CreateShareLink(userID string, scanID int64, token string, expiresAt time.Time) (ShareLink, error) {
+	link := ShareLink{
+		ScanID:    scanID,
+		UserID:    normalizeUserID(userID),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+	}
+	_, err := d.sql.Exec(
+		"INSERT INTO share_links (token_hash, scan_id, user_id, created_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+		shareTokenHash(token), link.ScanID, link.UserID, link.CreatedAt, link.ExpiresAt,
+	)
+	return link, err
+}
+
+// GetShareLink resolves a token. Unknown and expired tokens return
+// sql.ErrNoRows.
+func (d *DB) GetShareLink(token string, now time.Time) (ShareLink, error) {
+	var link ShareLink
+	err := d.sql.QueryRow(
+		"SELECT scan_id, user_id, created_at, expires_at FROM share_links WHERE token_hash = ?",
+		shareTokenHash(token),
+	).Scan(&link.ScanID, &link.UserID, &link.CreatedAt, &link.ExpiresAt)
+	if err != nil {
+		return ShareLink{}, err
+	}
+	expires, err := time.Parse(time.RFC3339, link.ExpiresAt)
+	if err != nil || !now.Before(expires) {
+		return ShareLink{}, sql.ErrNoRows
+	}
+	return link, nil
+}
+
+// DeleteShareLinksForScan revokes every link userID created for scanID and
+// returns how many there were.
+func (d *DB) DeleteShareLinksForScan(userID string, scanID int64) (int64, error) {
+	res, err := d.sql.Exec("DELETE FROM share_links WHERE scan_id = ? AND user_id = ?", scanID, normalizeUserID(userID))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PruneExpiredShareLinks deletes links that expired before now.
+func (d *DB) PruneExpiredShareLinks(now time.Time) (int64, error) {
+	res, err := d.sql.Exec("DELETE FROM share_links WHERE expires_at <= ?", now.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"time"
+)
+
+// StationTrendsRetentionDays bounds station_trade_trends. Rows are one per
+// user, station, item and day, so a daily Jita scan keeps a few hundred
+// thousand rows at most.
+const StationTrendsRetentionDays = 90
+
+// StationTrendPoint is the daily snapshot of one item's station-trade
+// attractiveness, taken from the user's last station scan that day.
+type StationTrendPoint struct {
+	RegionID    int32   `json:"region_id"`
+	StationID   int64   `json:"station_id"`
+	TypeID      int32   `json:"type_id"`
+	Date        string  `json:"date"` // YYYY-MM-DD, UTC
+	CTS         float64 `json:"cts"`
+	DailyProfit float64 `json:"daily_profit"`
+	Margin      float64 `json:"margin"`
+}
+
+// RecordStationTrendsForUser upserts points into the UTC day of at; a later
+// scan on the same day replaces the earlier values. Date on points is ignored.
+func (d *DB) RecordStationTrendsForUser(userID string, points []StationTrendPoint, at time.Time) error {
+	if len(points) == 0 {
+		return nil
+	}
+	userID = normalizeUserID(userID)
+	day := at.UTC().Format("2006-01-02")
+
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`
+		INSERT INTO station_trade_trends (user_id, region_id, station_id, type_id, date, cts, daily_profit, margin)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, region_id, station_id, type_id, date) DO UPDATE SET
+			cts          = excluded.cts,
+			daily_profit = excluded.daily_profit,
+			margin       = excluded.margin
+	`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, p := range points {
+		if _, err := stmt.Exec(userID, p.RegionID, p.StationID, p.TypeID, day, p.CTS, p.DailyProfit, p.Margin); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListStationTrendsForUser returns typeID's points from since onwards, oldest
+// first. stationID 0 returns every station the item was seen at.
+func (d *DB) ListStationTrendsForUser(userID string, typeID int32, stationID int64, since time.Time) ([]StationTrendPoint, error) {
+	rows, err := d.sql.Query(`
+		SELECT region_id, station_id, type_id, date, cts, daily_profit, margin
+		FROM station_trade_trends
+		WHERE user_id = ? AND type_id = ? AND date >= ? AND (? = 0 OR station_id = ?)
+		ORDER BY date, station_id
+	`, normalizeUserID(userID), typeID, since.UTC().Format("2006-01-02"), stationID, stationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]StationTrendPoint, 0)
+	for rows.Next() {
+		var p StationTrendPoint
+		if err := rows.Scan(&p.RegionID, &p.StationID, &p.TypeID, &p.Date, &p.CTS, &p.DailyProfit, &p.Margin); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// PruneStationTrends deletes points older than StationTrendsRetentionDays.
+func (d *DB) PruneStationTrends() (int64, error) {
+	cutoff := time.Now().UTC().AddDate(0, 0, -StationTrendsRetentionDays).Format("2006-01-02")
+	res, err := d.sql.Exec("DELETE FROM station_trade_trends WHERE date < ?", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
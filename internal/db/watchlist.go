@@ -14,7 +14,8 @@ func (d *DB) GetWatchlistForUser(userID string) []config.WatchlistItem {
 	userID = normalizeUserID(userID)
 
 	rows, err := d.sql.Query(`
-		SELECT type_id, type_name, added_at, alert_min_margin, alert_enabled, alert_metric, alert_threshold
+		SELECT type_id, type_name, added_at, alert_min_margin, alert_enabled, alert_metric, alert_threshold,
+		       region_id, demand_alert_threshold
 		  FROM watchlist
 		 WHERE user_id = ?
 		 ORDER BY added_at DESC
@@ -35,6 +36,8 @@ func (d *DB) GetWatchlistForUser(userID string) []config.WatchlistItem {
 			&item.AlertEnabled,
 			&item.AlertMetric,
 			&item.AlertThreshold,
+			&item.RegionID,
+			&item.DemandAlertThreshold,
 		)
 		if item.AlertMetric == "" {
 			item.AlertMetric = "margin_percent"
@@ -74,6 +77,80 @@ func (d *DB) AddWatchlistItem(item config.WatchlistItem) bool {
 func (d *DB) AddWatchlistItemForUser(userID string, item config.WatchlistItem) bool {
 	userID = normalizeUserID(userID)
 
+	item = normalizeWatchlistAlert(item)
+	res, err := d.sql.Exec(insertWatchlistItemSQL,
+		userID,
+		item.TypeID,
+		item.TypeName,
+		item.AddedAt,
+		item.AlertMinMargin,
+		item.AlertEnabled,
+		item.AlertMetric,
+		item.AlertThreshold,
+		item.RegionID,
+		item.DemandAlertThreshold,
+	)
+	if err != nil {
+		return false
+	}
+	n, _ := res.RowsAffected()
+	return n > 0
+}
+
+// AddWatchlistItemsForUser inserts several watchlist items in one transaction.
+// Items already on the list are left untouched. Returns the number of rows
+// actually inserted.
+func (d *DB) AddWatchlistItemsForUser(userID string, items []config.WatchlistItem) (int, error) {
+	userID = normalizeUserID(userID)
+
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(insertWatchlistItemSQL)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	inserted := 0
+	for _, item := range items {
+		item = normalizeWatchlistAlert(item)
+		res, err := stmt.Exec(
+			userID,
+			item.TypeID,
+			item.TypeName,
+			item.AddedAt,
+			item.AlertMinMargin,
+			item.AlertEnabled,
+			item.AlertMetric,
+			item.AlertThreshold,
+			item.RegionID,
+			item.DemandAlertThreshold,
+		)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			inserted++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+const insertWatchlistItemSQL = `INSERT OR IGNORE INTO watchlist
+	   (user_id, type_id, type_name, added_at, alert_min_margin, alert_enabled, alert_metric, alert_threshold,
+	    region_id, demand_alert_threshold)
+	 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// normalizeWatchlistAlert keeps the legacy alert_min_margin column in sync with
+// the metric/threshold pair before an insert.
+func normalizeWatchlistAlert(item config.WatchlistItem) config.WatchlistItem {
 	if item.AlertMetric == "" {
 		item.AlertMetric = "margin_percent"
 	}
@@ -88,24 +165,10 @@ func (d *DB) AddWatchlistItemForUser(userID string, item config.WatchlistItem) b
 	} else if item.AlertMinMargin < 0 {
 		item.AlertMinMargin = 0
 	}
-	res, err := d.sql.Exec(
-		`INSERT OR IGNORE INTO watchlist
-		   (user_id, type_id, type_name, added_at, alert_min_margin, alert_enabled, alert_metric, alert_threshold)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		userID,
-		item.TypeID,
-		item.TypeName,
-		item.AddedAt,
-		item.AlertMinMargin,
-		item.AlertEnabled,
-		item.AlertMetric,
-		item.AlertThreshold,
-	)
-	if err != nil {
-		return false
+	if item.DemandAlertThreshold < 0 || item.RegionID <= 0 {
+		item.DemandAlertThreshold = 0
 	}
-	n, _ := res.RowsAffected()
-	return n > 0
+	return item
 }
 
 // DeleteWatchlistItem removes a watchlist item by type ID.
@@ -151,3 +214,47 @@ func (d *DB) UpdateWatchlistItemForUser(userID string, typeID int32, alertMinMar
 		typeID,
 	)
 }
+
+// UpdateWatchlistDemandAlertForUser sets the demand-spike alert of a
+// watchlist item. regionID 0 or threshold 0 disables it.
+func (d *DB) UpdateWatchlistDemandAlertForUser(userID string, typeID int32, regionID int32, threshold float64) {
+	userID = normalizeUserID(userID)
+	if regionID <= 0 || threshold < 0 {
+		regionID, threshold = 0, 0
+	}
+	d.sql.Exec(
+		`UPDATE watchlist SET region_id = ?, demand_alert_threshold = ? WHERE user_id = ? AND type_id = ?`,
+		regionID, threshold, userID, typeID,
+	)
+}
+
+// WatchlistDemandAlert is a watchlist item with an active demand alert.
+type WatchlistDemandAlert struct {
+	UserID string
+	Item   config.WatchlistItem
+}
+
+// ListWatchlistDemandAlerts returns every user's watchlist items that have a
+// demand-spike alert configured.
+func (d *DB) ListWatchlistDemandAlerts() ([]WatchlistDemandAlert, error) {
+	rows, err := d.sql.Query(`
+		SELECT user_id, type_id, type_name, region_id, demand_alert_threshold
+		  FROM watchlist
+		 WHERE region_id > 0 AND demand_alert_threshold > 0
+		 ORDER BY user_id, region_id, type_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WatchlistDemandAlert
+	for rows.Next() {
+		var a WatchlistDemandAlert
+		if err := rows.Scan(&a.UserID, &a.Item.TypeID, &a.Item.TypeName, &a.Item.RegionID, &a.Item.DemandAlertThreshold); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"math"
+	"sort"
+)
+
+// Capital allocation activities.
+const (
+	ActivityStationTrading = "station_trading"
+	ActivityIndustry       = "industry"
+)
+
+// minIndustryCycleDays is the shortest capital lock-up assumed for an industry
+// project: even quick jobs need time to haul and sell the output.
+const minIndustryCycleDays = 1.0
+
+// CapitalOpportunity is one place ISK can be parked, with the capital it can
+// absorb and the profit it yields per day at that size.
+type CapitalOpportunity struct {
+	Activity        string  `json:"activity"` // station_trading | industry
+	Label           string  `json:"label"`
+	TypeID          int32   `json:"type_id,omitempty"`
+	ReferenceID     int64   `json:"reference_id,omitempty"` // station ID or industry project ID
+	Capacity        float64 `json:"capacity"`               // max ISK deployable
+	DailyProfit     float64 `json:"daily_profit"`           // profit per day when fully funded
+	DailyROIPercent float64 `json:"daily_roi_percent"`
+	Allocated       float64 `json:"allocated"`
+}
+
+// CapitalAllocationLeg summarises the ISK assigned to one activity.
+type CapitalAllocationLeg struct {
+	Activity            string               `json:"activity"`
+	Candidates          int                  `json:"candidates"`
+	BestDailyROIPercent float64              `json:"best_daily_roi_percent"`
+	Allocated           float64              `json:"allocated"`
+	SharePercent        float64              `json:"share_percent"`
+	ExpectedDailyProfit float64              `json:"expected_daily_profit"`
+	DailyROIPercent     float64              `json:"daily_roi_percent"`
+	Opportunities       []CapitalOpportunity `json:"opportunities"` // funded opportunities, best first
+}
+
+// CapitalAllocation is the suggested split of capital between activities.
+type CapitalAllocation struct {
+	Capital                 float64              `json:"capital"`
+	Allocated               float64              `json:"allocated"`
+	Idle                    float64              `json:"idle"`
+	ExpectedDailyProfit     float64              `json:"expected_daily_profit"`
+	ExpectedDailyROIPercent float64              `json:"expected_daily_roi_percent"`
+	StationTrading          CapitalAllocationLeg `json:"station_trading"`
+	Industry                CapitalAllocationLeg `json:"industry"`
+	Recommendation          string               `json:"recommendation"` // station_trading | industry | split | none
+}
+
+// StationTradeOpportunities converts scan rows into capital opportunities.
+// A station trade absorbs one day of executable volume at the buy price.
+func StationTradeOpportunities(trades []StationTrade) []CapitalOpportunity {
+	out := make([]CapitalOpportunity, 0, len(trades))
+	for _, t := range trades {
+		units := stationTradeUnitsPerDay(t)
+		if units <= 0 || t.BuyPrice <= 0 {
+			continue
+		}
+		capacity := units * t.BuyPrice
+		out = append(out, CapitalOpportunity{
+			Activity:        ActivityStationTrading,
+			Label:           t.TypeName,
+			TypeID:          t.TypeID,
+			ReferenceID:     t.StationID,
+			Capacity:        sanitizeFloat(capacity),
+			DailyProfit:     sanitizeFloat(t.DailyProfit),
+			DailyROIPercent: sanitizeFloat(t.DailyProfit / capacity * 100),
+		})
+	}
+	return out
+}
+
+// IndustryProjectOpportunity builds a capital opportunity from a project's
+// outstanding cost, expected sale revenue and build time. Returns false when
+// the project is not expected to make a profit.
+func IndustryProjectOpportunity(label string, projectID int64, cost, revenue, durationSeconds float64) (CapitalOpportunity, bool) {
+	cost = sanitizeFloat(cost)
+	profit := sanitizeFloat(revenue) - cost
+	if cost <= 0 || profit <= 0 {
+		return CapitalOpportunity{}, false
+	}
+	days := math.Max(sanitizeFloat(durationSeconds)/86400, minIndustryCycleDays)
+	daily := profit / days
+	return CapitalOpportunity{
+		Activity:        ActivityIndustry,
+		Label:           label,
+		ReferenceID:     projectID,
+		Capacity:        cost,
+		DailyProfit:     sanitizeFloat(daily),
+		DailyROIPercent: sanitizeFloat(daily / cost * 100),
+	}, true
+}
+
+// AllocateCapital funds opportunities greedily by daily ROI until capital runs
+// out. Partially funded opportunities earn profit pro rata, so the split
+// follows the marginal return of each activity rather than its headline ROI.
+func AllocateCapital(capital float64, opportunities []CapitalOpportunity) CapitalAllocation {
+	capital = sanitizeFloat(capital)
+	if capital < 0 {
+		capital = 0
+	}
+	result := CapitalAllocation{
+		Capital:        capital,
+		StationTrading: CapitalAllocationLeg{Activity: ActivityStationTrading, Opportunities: []CapitalOpportunity{}},
+		Industry:       CapitalAllocationLeg{Activity: ActivityIndustry, Opportunities: []CapitalOpportunity{}},
+		Recommendation: "none",
+	}
+
+	ranked := make([]CapitalOpportunity, 0, len(opportunities))
+	for _, o := range opportunities {
+		if o.Capacity <= 0 || o.DailyProfit <= 0 {
+			continue
+		}
+		leg := result.leg(o.Activity)
+		if leg == nil {
+			continue
+		}
+		leg.Candidates++
+		if o.DailyROIPercent > leg.BestDailyROIPercent {
+			leg.BestDailyROIPercent = o.DailyROIPercent
+		}
+		ranked = append(ranked, o)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].DailyROIPercent > ranked[j].DailyROIPercent
+	})
+
+	remaining := capital
+	for _, o := range ranked {
+		if remaining <= 0 {
+			break
+		}
+		o.Allocated = math.Min(o.Capacity, remaining)
+		remaining -= o.Allocated
+		profit := o.DailyProfit * o.Allocated / o.Capacity
+
+		leg := result.leg(o.Activity)
+		leg.Allocated += o.Allocated
+		leg.ExpectedDailyProfit += profit
+		leg.Opportunities = append(leg.Opportunities, o)
+	}
+
+	for _, leg := range []*CapitalAllocationLeg{&result.StationTrading, &result.Industry} {
+		leg.Allocated = sanitizeFloat(leg.Allocated)
+		leg.ExpectedDailyProfit = sanitizeFloat(leg.ExpectedDailyProfit)
+		if leg.Allocated > 0 {
+			leg.DailyROIPercent = sanitizeFloat(leg.ExpectedDailyProfit / leg.Allocated * 100)
+		}
+		if capital > 0 {
+			leg.SharePercent = sanitizeFloat(leg.Allocated / capital * 100)
+		}
+		result.Allocated += leg.Allocated
+		result.ExpectedDailyProfit += leg.ExpectedDailyProfit
+	}
+	result.Idle = sanitizeFloat(math.Max(capital-result.Allocated, 0))
+	if capital > 0 {
+		result.ExpectedDailyROIPercent = sanitizeFloat(result.ExpectedDailyProfit / capital * 100)
+	}
+
+	switch {
+	case result.StationTrading.Allocated > 0 && result.Industry.Allocated > 0:
+		result.Recommendation = "split"
+	case result.StationTrading.Allocated > 0:
+		result.Recommendation = ActivityStationTrading
+	case result.Industry.Allocated > 0:
+		result.Recommendation = ActivityIndustry
+	}
+	return result
+}
+
+func (a *CapitalAllocation) leg(activity string) *CapitalAllocationLeg {
+	switch activity {
+	case ActivityStationTrading:
+		return &a.StationTrading
+	case ActivityIndustry:
+		return &a.Industry
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAllocateCapital_SplitsByMarginalROI(t *testing.T) {
+	// Station trade: 100 units/day at 100 ISK, 10 ISK profit each -> 10k ISK capacity, 10%/day.
+	station := StationTradeOpportunities([]StationTrade{
+		{TypeID: 34, TypeName: "Tritanium", BuyPrice: 100, ProfitPerUnit: 10, DailyProfit: 1000},
+	})
+	if len(station) != 1 || station[0].Capacity != 10_000 || math.Abs(station[0].DailyROIPercent-10) > 1e-9 {
+		t.Fatalf("station opportunity = %+v", station)
+	}
+	// Industry: 100k cost, 120k revenue over 4 days -> 5k/day, 5%/day.
+	industry, ok := IndustryProjectOpportunity("Rifters", 7, 100_000, 120_000, 4*86400)
+	if !ok || math.Abs(industry.DailyROIPercent-5) > 1e-9 {
+		t.Fatalf("industry opportunity = %+v ok=%v", industry, ok)
+	}
+
+	got := AllocateCapital(60_000, append(station, industry))
+	if got.StationTrading.Allocated != 10_000 || got.Industry.Allocated != 50_000 {
+		t.Fatalf("allocated station/industry = %v/%v, want 10000/50000", got.StationTrading.Allocated, got.Industry.Allocated)
+	}
+	if math.Abs(got.ExpectedDailyProfit-3500) > 1e-6 {
+		t.Fatalf("expected daily profit = %v, want 3500", got.ExpectedDailyProfit)
+	}
+	if got.Recommendation != "split" || got.Idle != 0 {
+		t.Fatalf("recommendation=%q idle=%v", got.Recommendation, got.Idle)
+	}
+	if math.Abs(got.StationTrading.SharePercent+got.Industry.SharePercent-100) > 1e-9 {
+		t.Fatalf("shares do not add up: %v + %v", got.StationTrading.SharePercent, got.Industry.SharePercent)
+	}
+}
+
+func TestAllocateCapital_IdleAndSingleActivity(t *testing.T) {
+	station := StationTradeOpportunities([]StationTrade{
+		{TypeID: 34, BuyPrice: 100, ProfitPerUnit: 10, DailyProfit: 1000},
+	})
+	got := AllocateCapital(25_000, station)
+	if got.Recommendation != ActivityStationTrading {
+		t.Fatalf("recommendation = %q", got.Recommendation)
+	}
+	if got.Idle != 15_000 {
+		t.Fatalf("idle = %v, want 15000", got.Idle)
+	}
+	if got.Industry.Candidates != 0 || len(got.Industry.Opportunities) != 0 {
+		t.Fatalf("unexpected industry leg: %+v", got.Industry)
+	}
+}
+
+func TestIndustryProjectOpportunity_RejectsLossesAndFloorsDuration(t *testing.T) {
+	if _, ok := IndustryProjectOpportunity("loss", 1, 100, 90, 3600); ok {
+		t.Fatal("loss-making project should be rejected")
+	}
+	o, ok := IndustryProjectOpportunity("quick", 2, 100, 110, 3600)
+	if !ok {
+		t.Fatal("profitable project rejected")
+	}
+	// One-hour job still locks capital for a full day.
+	if o.DailyProfit != 10 {
+		t.Fatalf("daily profit = %v, want 10", o.DailyProfit)
+	}
+}
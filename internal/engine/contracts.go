@@ -2,6 +2,7 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math"
@@ -241,6 +242,10 @@ func contractCarryDays(holdDays int, estLiqDays float64) float64 {
 	return carryDays
 }
 
+// ErrPartialScan marks a scan that was canceled part-way; the results
+// returned with it are valid but incomplete.
+var ErrPartialScan = errors.New("scan canceled with partial results")
+
 func checkContextCanceled(ctx context.Context) error {
 	if ctx == nil {
 		return nil
@@ -279,12 +284,14 @@ func shouldExcludeRigWithShip(item esi.ContractItem, rigName string, shipSizeCla
 	return rigSize > 0 && rigSize == shipSizeClass
 }
 
-func blockedContractTypeID(items []esi.ContractItem) int32 {
+// blockedContractTypeID returns the first offered type that is market-disabled
+// or user-excluded, or 0 if the contract may be evaluated.
+func blockedContractTypeID(items []esi.ContractItem, excluded map[int32]bool) int32 {
 	for _, item := range items {
 		if item.Quantity <= 0 {
 			continue
 		}
-		if isMarketDisabledType(item.TypeID) {
+		if isMarketDisabledType(item.TypeID) || excluded[item.TypeID] {
 			return item.TypeID
 		}
 	}
@@ -426,6 +433,81 @@ func selectInstantLiquidationSystem(
 	return best, true
 }
 
+// Public contract types the contract scanner can evaluate.
+const (
+	ContractTypeItemExchange = "item_exchange"
+	// Auctions are priced at their buyout; those without one are skipped,
+	// since the winning bid is unknown until they close.
+	ContractTypeAuction = "auction"
+)
+
+// NormalizeContractTypes lowercases and deduplicates contract type filters,
+// defaulting to item exchanges when none are given. Unknown types are an
+// error.
+func NormalizeContractTypes(types []string) ([]string, error) {
+	out := make([]string, 0, len(types))
+	seen := make(map[string]bool, len(types))
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		switch t {
+		case ContractTypeItemExchange, ContractTypeAuction:
+		default:
+			return nil, fmt.Errorf("unknown contract type %q (want %s or %s)", t, ContractTypeItemExchange, ContractTypeAuction)
+		}
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	if len(out) == 0 {
+		out = append(out, ContractTypeItemExchange)
+	}
+	return out, nil
+}
+
+// contractPrefilter drops contracts by type and issuer before any item or
+// price lookups.
+type contractPrefilter struct {
+	types   map[string]bool
+	issuers map[int64]bool
+}
+
+func newContractPrefilter(params ScanParams) (contractPrefilter, error) {
+	types, err := NormalizeContractTypes(params.ContractTypes)
+	if err != nil {
+		return contractPrefilter{}, err
+	}
+	f := contractPrefilter{
+		types:   make(map[string]bool, len(types)),
+		issuers: make(map[int64]bool, len(params.ExcludeIssuerIDs)),
+	}
+	for _, t := range types {
+		f.types[t] = true
+	}
+	for _, id := range params.ExcludeIssuerIDs {
+		f.issuers[id] = true
+	}
+	return f, nil
+}
+
+// accept reports whether c should be evaluated. Accepted auctions get their
+// buyout as Price, which is what taking them costs.
+func (f contractPrefilter) accept(c *esi.PublicContract) bool {
+	if !f.types[c.Type] {
+		return false
+	}
+	if f.issuers[int64(c.IssuerID)] || f.issuers[int64(c.IssuerCorporationID)] {
+		return false
+	}
+	if c.Type == ContractTypeAuction {
+		if c.Buyout <= 0 {
+			return false
+		}
+		c.Price = c.Buyout
+	}
+	return true
+}
+
 // itemPriceData holds market data for an item type.
 type itemPriceData struct {
 	MinSellPrice float64 // Cheapest sell order price
@@ -442,6 +524,9 @@ func (s *Scanner) ScanContracts(params ScanParams, progress func(string)) ([]Con
 }
 
 // ScanContractsWithContext is cancellation-aware variant of ScanContracts.
+// Cancellation before any contract is evaluated returns ctx.Err(). Once
+// evaluation has started it returns the results found so far together with
+// an error wrapping both ErrPartialScan and ctx.Err().
 func (s *Scanner) ScanContractsWithContext(ctx context.Context, params ScanParams, progress func(string)) ([]ContractResult, error) {
 	if ctx == nil {
 		ctx = context.Background()
@@ -619,13 +704,18 @@ func (s *Scanner) ScanContractsWithContext(ctx context.Context, params ScanParam
 		}
 	}
 
-	// Filter contracts: only item_exchange, not expired, price > threshold, reachable location
+	// Filter contracts: wanted type and issuer, not expired, price > threshold,
+	// reachable location. Done before item fetches, which dominate ESI calls.
+	prefilter, err := newContractPrefilter(params)
+	if err != nil {
+		return nil, err
+	}
 	var candidates []esi.PublicContract
 	for _, c := range allContracts {
 		if err := checkContextCanceled(ctx); err != nil {
 			return nil, err
 		}
-		if c.Type != "item_exchange" {
+		if !prefilter.accept(&c) {
 			continue
 		}
 		if c.IsExpired() {
@@ -646,7 +736,7 @@ func (s *Scanner) ScanContractsWithContext(ctx context.Context, params ScanParam
 		candidates = append(candidates, c)
 	}
 
-	log.Printf("[DEBUG] ScanContracts: %d item_exchange candidates after filtering (location + price)", len(candidates))
+	log.Printf("[DEBUG] ScanContracts: %d candidates after filtering (type, issuer, location, price)", len(candidates))
 	emitProgress(fmt.Sprintf("Evaluating %d contracts...", len(candidates)))
 
 	if len(candidates) == 0 {
@@ -659,27 +749,21 @@ func (s *Scanner) ScanContractsWithContext(ctx context.Context, params ScanParam
 		contractIDs[i] = c.ContractID
 	}
 
-	contractItemsCh := make(chan map[int32][]esi.ContractItem, 1)
-	go func() {
-		contractItemsCh <- s.ESI.FetchContractItemsBatch(contractIDs, s.ContractItemsCache, func(done, total int) {
-			emitProgress(fmt.Sprintf("Fetching contract items %d/%d...", done, total))
-		})
-	}()
-
-	var contractItems map[int32][]esi.ContractItem
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case contractItems = <-contractItemsCh:
-	}
+	// On cancel the batch stops handing out contracts and returns once the
+	// in-flight fetches drain. The contracts fetched by then are still
+	// evaluated and returned as a partial scan.
+	contractItems := s.ESI.FetchContractItemsBatchContext(ctx, contractIDs, s.ContractItemsCache, func(done, total int) {
+		emitProgress(fmt.Sprintf("Fetching contract items %d/%d...", done, total))
+	})
+	canceled := checkContextCanceled(ctx)
 
 	log.Printf("[DEBUG] ScanContracts: fetched items for %d contracts", len(contractItems))
-	if missing := len(candidates) - len(contractItems); missing > 0 {
+	if missing := len(candidates) - len(contractItems); missing > 0 && canceled == nil {
 		emitProgress(fmt.Sprintf("Warning: missing contract items for %d contracts; results may be incomplete", missing))
 	}
 
 	// Collect unique type IDs that need history lookup (estimate mode only).
-	if !contractInstant {
+	if !contractInstant && canceled == nil {
 		typeIDsNeedHistory := make(map[int32]bool)
 		for _, items := range contractItems {
 			for _, item := range items {
@@ -696,16 +780,8 @@ func (s *Scanner) ScanContractsWithContext(ctx context.Context, params ScanParam
 
 		if s.History != nil && len(typeIDsNeedHistory) > 0 {
 			emitProgress(fmt.Sprintf("Fetching market history for %d item types...", len(typeIDsNeedHistory)))
-			historyDone := make(chan struct{})
-			go func() {
-				s.fetchContractItemsHistory(typeIDsNeedHistory, priceData, primaryRegion)
-				close(historyDone)
-			}()
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-historyDone:
-			}
+			s.fetchContractItemsHistory(ctx, typeIDsNeedHistory, priceData, primaryRegion)
+			canceled = checkContextCanceled(ctx)
 		}
 
 		log.Printf("[DEBUG] ScanContracts: enriched %d types with history", len(typeIDsNeedHistory))
@@ -716,20 +792,27 @@ func (s *Scanner) ScanContractsWithContext(ctx context.Context, params ScanParam
 	holdDays := contractHoldDays(params)
 	targetConfidence := contractTargetConfidence(params)
 	resolvedTypeNames := make(map[int32]string)
+	excluded := s.excludedTypes(params.ExcludeTypeIDs, params.ExcludeMarketGroupIDs)
 
+	// Results accumulate as contracts are evaluated so that a cancellation
+	// still hands back everything found so far. A cancel during the fetches
+	// above evaluates what was fetched; one during this loop stops it.
 	var results []ContractResult
 
 	for _, contract := range candidates {
-		if err := checkContextCanceled(ctx); err != nil {
-			return nil, err
+		if canceled == nil {
+			if err := checkContextCanceled(ctx); err != nil {
+				canceled = err
+				break
+			}
 		}
 		items, ok := contractItems[contract.ContractID]
 		if !ok || len(items) == 0 {
 			continue
 		}
-		blockedTypeID := blockedContractTypeID(items)
+		blockedTypeID := blockedContractTypeID(items, excluded)
 		if blockedTypeID != 0 {
-			log.Printf("[DEBUG] Contract %d: skipping - contains market-disabled type %d", contract.ContractID, blockedTypeID)
+			log.Printf("[DEBUG] Contract %d: skipping - contains market-disabled or excluded type %d", contract.ContractID, blockedTypeID)
 			continue
 		}
 
@@ -1080,6 +1163,7 @@ func (s *Scanner) ScanContractsWithContext(ctx context.Context, params ScanParam
 
 		results = append(results, ContractResult{
 			ContractID:            contract.ContractID,
+			ContractType:          contract.Type,
 			Title:                 title,
 			Price:                 contract.Price,
 			MarketValue:           marketValue,
@@ -1106,7 +1190,22 @@ func (s *Scanner) ScanContractsWithContext(ctx context.Context, params ScanParam
 
 	log.Printf("[DEBUG] ScanContracts: %d profitable results", len(results))
 
-	// Sort by profit descending, keep top 100
+	results = rankContractResults(results)
+
+	if canceled == nil {
+		canceled = checkContextCanceled(ctx)
+	}
+	if canceled != nil {
+		log.Printf("[DEBUG] ScanContracts: canceled, returning %d partial results", len(results))
+		return results, fmt.Errorf("%w: %w", ErrPartialScan, canceled)
+	}
+	emitProgress(fmt.Sprintf("Found %d profitable contracts", len(results)))
+	return results, nil
+}
+
+// rankContractResults sorts by expected profit (plain profit when there is
+// no estimate), best first, and caps the list at MaxUnlimitedResults.
+func rankContractResults(results []ContractResult) []ContractResult {
 	sort.Slice(results, func(i, j int) bool {
 		left := results[i].ExpectedProfit
 		if left == 0 {
@@ -1122,12 +1221,7 @@ func (s *Scanner) ScanContractsWithContext(ctx context.Context, params ScanParam
 	if len(results) > MaxUnlimitedResults {
 		results = results[:MaxUnlimitedResults]
 	}
-
-	if err := checkContextCanceled(ctx); err != nil {
-		return nil, err
-	}
-	emitProgress(fmt.Sprintf("Found %d profitable contracts", len(results)))
-	return results, nil
+	return results
 }
 
 // locationToSystem maps a station/structure ID to its solar system ID.
@@ -1144,7 +1238,9 @@ func (s *Scanner) locationToSystem(locationID int64, marketLocationSystems map[i
 }
 
 // fetchContractItemsHistory fetches market history for contract items and calculates VWAP.
-func (s *Scanner) fetchContractItemsHistory(typeIDs map[int32]bool, priceData map[int32]*itemPriceData, regionID int32) {
+// On cancel it starts no new fetches and returns once the running ones finish,
+// leaving the types fetched so far enriched.
+func (s *Scanner) fetchContractItemsHistory(ctx context.Context, typeIDs map[int32]bool, priceData map[int32]*itemPriceData, regionID int32) {
 	if s.History == nil || len(typeIDs) == 0 {
 		return
 	}
@@ -1159,6 +1255,9 @@ func (s *Scanner) fetchContractItemsHistory(typeIDs map[int32]bool, priceData ma
 			continue
 		}
 
+		if checkContextCanceled(ctx) != nil {
+			break
+		}
 		wg.Add(1)
 		sem <- struct{}{}
 		go func(tid int32, pdata *itemPriceData) {
@@ -3,10 +3,16 @@ package engine
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"math"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"eve-flipper/internal/esi"
+	"eve-flipper/internal/graph"
 	"eve-flipper/internal/sde"
 )
 
@@ -335,12 +341,57 @@ func TestBlockedContractTypeID(t *testing.T) {
 		{TypeID: MPTCTypeID, Quantity: 0},  // ignored (non-positive qty)
 		{TypeID: MPTCTypeID, Quantity: -5}, // ignored (non-positive qty)
 	}
-	if got := blockedContractTypeID(items); got != MPTCTypeID {
+	if got := blockedContractTypeID(items, nil); got != MPTCTypeID {
 		t.Fatalf("blockedContractTypeID = %d, want %d", got, MPTCTypeID)
 	}
-	if got := blockedContractTypeID([]esi.ContractItem{{TypeID: 34, Quantity: 10}}); got != 0 {
+	if got := blockedContractTypeID([]esi.ContractItem{{TypeID: 34, Quantity: 10}}, nil); got != 0 {
 		t.Fatalf("blockedContractTypeID(non-blocked) = %d, want 0", got)
 	}
+	excluded := map[int32]bool{PLEXTypeID: true}
+	if got := blockedContractTypeID([]esi.ContractItem{{TypeID: 34, Quantity: 10}, {TypeID: PLEXTypeID, Quantity: 1}}, excluded); got != PLEXTypeID {
+		t.Fatalf("blockedContractTypeID(user-excluded) = %d, want %d", got, PLEXTypeID)
+	}
+}
+
+func TestContractPrefilter(t *testing.T) {
+	f, err := newContractPrefilter(ScanParams{})
+	if err != nil {
+		t.Fatalf("default prefilter: %v", err)
+	}
+	exchange := esi.PublicContract{Type: ContractTypeItemExchange, Price: 100}
+	auction := esi.PublicContract{Type: ContractTypeAuction, Price: 10, Buyout: 500}
+	if !f.accept(&exchange) || f.accept(&auction) {
+		t.Fatal("default should take item exchanges only")
+	}
+
+	f, err = newContractPrefilter(ScanParams{
+		ContractTypes:    []string{ContractTypeAuction},
+		ExcludeIssuerIDs: []int64{7, 98000001},
+	})
+	if err != nil {
+		t.Fatalf("auction prefilter: %v", err)
+	}
+	if f.accept(&exchange) {
+		t.Fatal("item exchange accepted by auction-only filter")
+	}
+	if !f.accept(&auction) || auction.Price != 500 {
+		t.Fatalf("auction accepted=%v price=%v, want buyout 500", f.accept(&auction), auction.Price)
+	}
+	if noBuyout := (esi.PublicContract{Type: ContractTypeAuction, Price: 10}); f.accept(&noBuyout) {
+		t.Fatal("auction without buyout accepted")
+	}
+	for _, c := range []esi.PublicContract{
+		{Type: ContractTypeAuction, Buyout: 500, IssuerID: 7},
+		{Type: ContractTypeAuction, Buyout: 500, IssuerID: 8, IssuerCorporationID: 98000001},
+	} {
+		if f.accept(&c) {
+			t.Fatalf("excluded issuer accepted: %+v", c)
+		}
+	}
+
+	if _, err := NormalizeContractTypes([]string{"courier"}); err == nil {
+		t.Fatal("courier accepted")
+	}
 }
 
 func TestScanContractsWithContext_CanceledBeforeStart(t *testing.T) {
@@ -352,6 +403,29 @@ func TestScanContractsWithContext_CanceledBeforeStart(t *testing.T) {
 	if !errors.Is(err, context.Canceled) {
 		t.Fatalf("err = %v, want context.Canceled", err)
 	}
+	if errors.Is(err, ErrPartialScan) {
+		t.Fatal("nothing was evaluated, so the error must not be partial")
+	}
+}
+
+func TestRankContractResults_SortsByExpectedProfitAndCaps(t *testing.T) {
+	results := []ContractResult{
+		{ContractID: 1, Profit: 5e6},
+		{ContractID: 2, Profit: 9e6, ExpectedProfit: 2e6},
+		{ContractID: 3, Profit: 1e6, ExpectedProfit: 8e6},
+	}
+	got := rankContractResults(results)
+	want := []int32{3, 1, 2}
+	for i, id := range want {
+		if got[i].ContractID != id {
+			t.Fatalf("order = %v, want %v", []int32{got[0].ContractID, got[1].ContractID, got[2].ContractID}, want)
+		}
+	}
+
+	many := make([]ContractResult, MaxUnlimitedResults+5)
+	if n := len(rankContractResults(many)); n != MaxUnlimitedResults {
+		t.Errorf("len = %d, want cap %d", n, MaxUnlimitedResults)
+	}
 }
 
 func TestContractItemLabel_UsesSDENameFirst(t *testing.T) {
@@ -380,3 +454,76 @@ func TestContractItemLabel_FallbackToTypeIDAndCachesPerScan(t *testing.T) {
 		t.Fatalf("expected scan cache to store fallback label, got %q", cache[34133])
 	}
 }
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func jsonResponse(r *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}, "X-Pages": {"1"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    r,
+	}
+}
+
+func TestScanContractsWithContext_CanceledDuringItemsFetch(t *testing.T) {
+	const (
+		regionID  = int32(10000002)
+		systemID  = int32(30000142)
+		stationID = int64(60003760)
+	)
+	u := graph.NewUniverse()
+	u.SetRegion(systemID, regionID)
+	u.SetSecurity(systemID, 0.9)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	expires := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	client := esi.NewClient(nil)
+	client.SetHTTPClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, fmt.Sprintf("/markets/%d/orders/", regionID)):
+			if r.URL.Query().Get("order_type") == "buy" {
+				return jsonResponse(r, 200, fmt.Sprintf(`[{"order_id":1,"type_id":34,"location_id":%d,"system_id":%d,"price":150,"volume_remain":1000000,"is_buy_order":true,"duration":90}]`, stationID, systemID)), nil
+			}
+			return jsonResponse(r, 200, `[]`), nil
+		case strings.HasSuffix(path, fmt.Sprintf("/contracts/public/%d/", regionID)):
+			return jsonResponse(r, 200, fmt.Sprintf(`[
+				{"contract_id":1,"type":"item_exchange","price":12000000,"start_location_id":%[1]d,"date_expired":%[2]q},
+				{"contract_id":2,"type":"item_exchange","price":12000000,"start_location_id":%[1]d,"date_expired":%[2]q}
+			]`, stationID, expires)), nil
+		case strings.HasSuffix(path, "/contracts/public/items/1/"):
+			return jsonResponse(r, 200, `[{"type_id":34,"quantity":100000,"is_included":true}]`), nil
+		case strings.HasSuffix(path, "/contracts/public/items/2/"):
+			// The scan is canceled while this contract's items are in flight.
+			cancel()
+			return jsonResponse(r, 404, `{"error":"canceled"}`), nil
+		}
+		return jsonResponse(r, 200, `{"name":"Jita IV - Moon 4 - Caldari Navy Assembly Plant"}`), nil
+	})})
+
+	s := NewScanner(&sde.Data{
+		Universe: u,
+		Regions:  map[int32]*sde.Region{regionID: {ID: regionID, Name: "The Forge"}},
+		Systems:  map[int32]*sde.SolarSystem{systemID: {ID: systemID, Name: "Jita", RegionID: regionID, Security: 0.9}},
+		Stations: map[int64]*sde.Station{stationID: {ID: stationID, SystemID: systemID}},
+		Types:    map[int32]*sde.ItemType{34: {ID: 34, Name: "Tritanium", Volume: 0.01}},
+	}, client)
+
+	results, err := s.ScanContractsWithContext(ctx, ScanParams{
+		CurrentSystemID:            systemID,
+		ContractInstantLiquidation: true,
+	}, func(string) {})
+	if !errors.Is(err, ErrPartialScan) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want ErrPartialScan wrapping context.Canceled", err)
+	}
+	if len(results) != 1 || results[0].ContractID != 1 {
+		t.Fatalf("results = %+v, want the one contract fetched before the cancel", results)
+	}
+	if results[0].Profit <= 0 {
+		t.Fatalf("Profit = %v, want > 0", results[0].Profit)
+	}
+}
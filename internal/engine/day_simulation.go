@@ -0,0 +1,297 @@
+package engine
+
+import (
+	"math"
+	"sort"
+
+	"eve-flipper/internal/esi"
+)
+
+const (
+	defaultDaySimulationHours = 24
+	maxDaySimulationHours     = 72
+	// Base market order slots every character has before trade skills.
+	baseMarketOrderSlots = 5
+)
+
+// Trade skill type IDs that raise the market order limit.
+const (
+	skillTrade     int32 = 3443
+	skillRetail    int32 = 3444
+	skillWholesale int32 = 16596
+	skillTycoon    int32 = 18580
+)
+
+// DaySimulationParams describes the trader's resources for a forward projection.
+type DaySimulationParams struct {
+	Capital    float64 // starting liquid ISK
+	CargoM3    float64 // max inventory volume held at once (0 = unlimited)
+	OrderSlots int     // market order slots available (each position uses a buy + a sell order)
+	Hours      int     // projection horizon (default 24, max 72)
+}
+
+// DaySimulationPosition is one trade selected by the capital allocator.
+type DaySimulationPosition struct {
+	TypeID          int32   `json:"type_id"`
+	TypeName        string  `json:"type_name"`
+	StationID       int64   `json:"station_id"`
+	StationName     string  `json:"station_name"`
+	UnitCost        float64 `json:"unit_cost"`
+	ProfitPerUnit   float64 `json:"profit_per_unit"`
+	UnitsPerHour    float64 `json:"units_per_hour"`
+	TargetUnits     int64   `json:"target_units"` // liquidity cap over the horizon
+	UnitsBought     int64   `json:"units_bought"`
+	UnitsSold       int64   `json:"units_sold"`
+	CapitalPeak     float64 `json:"capital_peak"` // max ISK tied up in this position
+	ProjectedProfit float64 `json:"projected_profit"`
+	LimitedBy       string  `json:"limited_by"` // liquidity | capital | cargo
+}
+
+// DaySimulationPoint is one hour on the projected profit/capital curve.
+type DaySimulationPoint struct {
+	Hour             int     `json:"hour"`
+	UnitsBought      int64   `json:"units_bought"`
+	UnitsSold        int64   `json:"units_sold"`
+	HourProfit       float64 `json:"hour_profit"`
+	CumulativeProfit float64 `json:"cumulative_profit"`
+	Cash             float64 `json:"cash"`
+	InventoryValue   float64 `json:"inventory_value"` // unsold stock at cost
+	Capital          float64 `json:"capital"`         // cash + inventory at cost
+	InventoryM3      float64 `json:"inventory_m3"`
+}
+
+// DaySimulationResult is the full projection returned to the client.
+type DaySimulationResult struct {
+	Hours            int                     `json:"hours"`
+	StartingCapital  float64                 `json:"starting_capital"`
+	EndingCapital    float64                 `json:"ending_capital"`
+	ProjectedProfit  float64                 `json:"projected_profit"`
+	ROIPercent       float64                 `json:"roi_percent"`
+	PeakCapitalInUse float64                 `json:"peak_capital_in_use"`
+	OrderSlots       int                     `json:"order_slots"`
+	OrderSlotsUsed   int                     `json:"order_slots_used"`
+	CargoM3          float64                 `json:"cargo_m3"`
+	Candidates       int                     `json:"candidates"`
+	Positions        []DaySimulationPosition `json:"positions"`
+	Curve            []DaySimulationPoint    `json:"curve"`
+}
+
+// OrderSlotsFromSkills returns the market order limit implied by a skill sheet.
+// Falls back to the untrained base when skills are unavailable.
+func OrderSlotsFromSkills(sheet *esi.SkillSheet) int {
+	slots := baseMarketOrderSlots
+	if sheet == nil {
+		return slots
+	}
+	for _, sk := range sheet.Skills {
+		level := sk.ActiveLevel
+		switch sk.SkillID {
+		case skillTrade:
+			slots += 4 * level
+		case skillRetail:
+			slots += 8 * level
+		case skillWholesale:
+			slots += 16 * level
+		case skillTycoon:
+			slots += 32 * level
+		}
+	}
+	return slots
+}
+
+// daySimPosition is the mutable per-trade state while stepping through hours.
+type daySimPosition struct {
+	out       DaySimulationPosition
+	volume    float64
+	rate      float64
+	target    float64
+	bought    float64
+	sold      float64
+	inventory float64
+}
+
+// stationTradeUnitsPerDay recovers the executable daily share the scanner
+// used when computing DailyProfit.
+func stationTradeUnitsPerDay(t StationTrade) float64 {
+	if t.ProfitPerUnit <= 0 || t.DailyProfit <= 0 {
+		return 0
+	}
+	return t.DailyProfit / t.ProfitPerUnit
+}
+
+// SimulateTradingDay projects an hour-by-hour trading session over the given
+// station trades. Trades are ranked by executable daily profit and greedily
+// assigned order slots; each hour buy orders fill up to the trade's liquidity
+// share while cash and cargo allow, and stock bought in earlier hours sells at
+// the same rate. Sale proceeds are recycled into positions still below their
+// liquidity cap, so the capital curve compounds within the horizon.
+func SimulateTradingDay(trades []StationTrade, params DaySimulationParams) DaySimulationResult {
+	hours := params.Hours
+	if hours <= 0 {
+		hours = defaultDaySimulationHours
+	}
+	if hours > maxDaySimulationHours {
+		hours = maxDaySimulationHours
+	}
+	capital := sanitizeFloat(params.Capital)
+	if capital < 0 {
+		capital = 0
+	}
+	slots := params.OrderSlots
+	if slots <= 0 {
+		slots = baseMarketOrderSlots
+	}
+	cargo := params.CargoM3
+	if cargo < 0 || math.IsNaN(cargo) || math.IsInf(cargo, 0) {
+		cargo = 0
+	}
+
+	result := DaySimulationResult{
+		Hours:           hours,
+		StartingCapital: capital,
+		EndingCapital:   capital,
+		OrderSlots:      slots,
+		CargoM3:         cargo,
+		Positions:       []DaySimulationPosition{},
+		Curve:           make([]DaySimulationPoint, 0, hours),
+	}
+
+	candidates := make([]StationTrade, 0, len(trades))
+	for _, t := range trades {
+		if t.BuyPrice <= 0 || stationTradeUnitsPerDay(t) <= 0 {
+			continue
+		}
+		candidates = append(candidates, t)
+	}
+	result.Candidates = len(candidates)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].DailyProfit != candidates[j].DailyProfit {
+			return candidates[i].DailyProfit > candidates[j].DailyProfit
+		}
+		return candidates[i].CTS > candidates[j].CTS
+	})
+
+	maxPositions := slots / 2
+	if maxPositions < 1 {
+		maxPositions = 1
+	}
+	if len(candidates) > maxPositions {
+		candidates = candidates[:maxPositions]
+	}
+
+	positions := make([]*daySimPosition, 0, len(candidates))
+	for _, t := range candidates {
+		unitsPerDay := stationTradeUnitsPerDay(t)
+		p := &daySimPosition{
+			volume: t.Volume,
+			rate:   unitsPerDay / 24,
+			target: math.Floor(unitsPerDay * float64(hours) / 24),
+		}
+		if p.target < 1 {
+			continue
+		}
+		p.out = DaySimulationPosition{
+			TypeID:        t.TypeID,
+			TypeName:      t.TypeName,
+			StationID:     t.StationID,
+			StationName:   t.StationName,
+			UnitCost:      t.BuyPrice,
+			ProfitPerUnit: t.ProfitPerUnit,
+			UnitsPerHour:  sanitizeFloat(p.rate),
+			TargetUnits:   int64(p.target),
+			LimitedBy:     "liquidity",
+		}
+		positions = append(positions, p)
+	}
+	result.OrderSlotsUsed = 2 * len(positions)
+
+	cash := capital
+	cumulative := 0.0
+	for h := 1; h <= hours; h++ {
+		point := DaySimulationPoint{Hour: h}
+
+		// Sell stock carried over from previous hours first; proceeds are
+		// available for this hour's buys.
+		for _, p := range positions {
+			sell := math.Min(p.rate, p.inventory)
+			if sell <= 0 {
+				continue
+			}
+			p.inventory -= sell
+			p.sold += sell
+			cash += sell * (p.out.UnitCost + p.out.ProfitPerUnit)
+			profit := sell * p.out.ProfitPerUnit
+			point.HourProfit += profit
+			point.UnitsSold += int64(math.Round(sell))
+		}
+
+		inventoryM3 := 0.0
+		for _, p := range positions {
+			inventoryM3 += p.inventory * p.volume
+		}
+
+		for _, p := range positions {
+			want := math.Min(p.rate, p.target-p.bought)
+			if want <= 0 {
+				continue
+			}
+			buy := want
+			if affordable := cash / p.out.UnitCost; affordable < buy {
+				buy = affordable
+				p.out.LimitedBy = "capital"
+			}
+			if cargo > 0 && p.volume > 0 {
+				if fits := (cargo - inventoryM3) / p.volume; fits < buy {
+					buy = math.Max(fits, 0)
+					p.out.LimitedBy = "cargo"
+				}
+			}
+			if buy <= 0 {
+				continue
+			}
+			p.bought += buy
+			p.inventory += buy
+			cash -= buy * p.out.UnitCost
+			inventoryM3 += buy * p.volume
+			point.UnitsBought += int64(math.Round(buy))
+			if held := p.inventory * p.out.UnitCost; held > p.out.CapitalPeak {
+				p.out.CapitalPeak = held
+			}
+		}
+
+		inventoryValue := 0.0
+		for _, p := range positions {
+			inventoryValue += p.inventory * p.out.UnitCost
+		}
+		if inventoryValue > result.PeakCapitalInUse {
+			result.PeakCapitalInUse = inventoryValue
+		}
+
+		cumulative += point.HourProfit
+		point.HourProfit = sanitizeFloat(point.HourProfit)
+		point.CumulativeProfit = sanitizeFloat(cumulative)
+		point.Cash = sanitizeFloat(cash)
+		point.InventoryValue = sanitizeFloat(inventoryValue)
+		point.Capital = sanitizeFloat(cash + inventoryValue)
+		point.InventoryM3 = sanitizeFloat(inventoryM3)
+		result.Curve = append(result.Curve, point)
+	}
+
+	for _, p := range positions {
+		p.out.UnitsBought = int64(math.Round(p.bought))
+		p.out.UnitsSold = int64(math.Round(p.sold))
+		p.out.CapitalPeak = sanitizeFloat(p.out.CapitalPeak)
+		p.out.ProjectedProfit = sanitizeFloat(p.sold * p.out.ProfitPerUnit)
+		result.Positions = append(result.Positions, p.out)
+	}
+
+	result.ProjectedProfit = sanitizeFloat(cumulative)
+	result.PeakCapitalInUse = sanitizeFloat(result.PeakCapitalInUse)
+	if n := len(result.Curve); n > 0 {
+		result.EndingCapital = result.Curve[n-1].Capital
+	}
+	if capital > 0 {
+		result.ROIPercent = sanitizeFloat(result.ProjectedProfit / capital * 100)
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"eve-flipper/internal/esi"
+)
+
+func TestSimulateTradingDay_LiquidityBound(t *testing.T) {
+	trades := []StationTrade{
+		// 240 units/day -> 10 units/hour.
+		{TypeID: 34, BuyPrice: 100, ProfitPerUnit: 10, DailyProfit: 2400, Volume: 0.01},
+	}
+	got := SimulateTradingDay(trades, DaySimulationParams{Capital: 1_000_000, OrderSlots: 10, Hours: 24})
+
+	if len(got.Positions) != 1 {
+		t.Fatalf("positions = %d, want 1", len(got.Positions))
+	}
+	pos := got.Positions[0]
+	if pos.TargetUnits != 240 || pos.UnitsBought != 240 {
+		t.Fatalf("target/bought = %d/%d, want 240/240", pos.TargetUnits, pos.UnitsBought)
+	}
+	// First hour only buys; 23 hours of sales at 10 units/hour.
+	if pos.UnitsSold != 230 {
+		t.Fatalf("units sold = %d, want 230", pos.UnitsSold)
+	}
+	if math.Abs(got.ProjectedProfit-2300) > 1e-6 {
+		t.Fatalf("projected profit = %v, want 2300", got.ProjectedProfit)
+	}
+	if pos.LimitedBy != "liquidity" {
+		t.Fatalf("limited by = %q, want liquidity", pos.LimitedBy)
+	}
+	if len(got.Curve) != 24 || got.Curve[0].HourProfit != 0 {
+		t.Fatalf("unexpected curve start: %+v", got.Curve[0])
+	}
+	last := got.Curve[len(got.Curve)-1]
+	if math.Abs(last.Capital-(1_000_000+2300)) > 1e-6 {
+		t.Fatalf("ending capital = %v, want %v", last.Capital, 1_000_000+2300)
+	}
+}
+
+func TestSimulateTradingDay_CapitalRecycled(t *testing.T) {
+	trades := []StationTrade{
+		{TypeID: 34, BuyPrice: 100, ProfitPerUnit: 10, DailyProfit: 2400},
+	}
+	// Only 5 units affordable at once: hour 1 buys 5, later hours buy what
+	// the previous hour's sales freed.
+	got := SimulateTradingDay(trades, DaySimulationParams{Capital: 500, OrderSlots: 2, Hours: 4})
+	pos := got.Positions[0]
+	if pos.UnitsBought <= 5 {
+		t.Fatalf("units bought = %d, want proceeds reinvested beyond 5", pos.UnitsBought)
+	}
+	if pos.LimitedBy != "capital" {
+		t.Fatalf("limited by = %q, want capital", pos.LimitedBy)
+	}
+	if pos.CapitalPeak > got.EndingCapital+1e-6 {
+		t.Fatalf("capital peak = %v exceeds available capital %v", pos.CapitalPeak, got.EndingCapital)
+	}
+}
+
+func TestSimulateTradingDay_SlotsAndCargo(t *testing.T) {
+	trades := []StationTrade{
+		{TypeID: 1, BuyPrice: 10, ProfitPerUnit: 1, DailyProfit: 500, Volume: 1},
+		{TypeID: 2, BuyPrice: 10, ProfitPerUnit: 1, DailyProfit: 900, Volume: 1},
+		{TypeID: 3, BuyPrice: 10, ProfitPerUnit: 1, DailyProfit: 100, Volume: 1},
+		{TypeID: 4, BuyPrice: 0, ProfitPerUnit: 1, DailyProfit: 1000},
+	}
+	got := SimulateTradingDay(trades, DaySimulationParams{Capital: 1e9, OrderSlots: 5, CargoM3: 20})
+	if got.Candidates != 3 {
+		t.Fatalf("candidates = %d, want 3", got.Candidates)
+	}
+	if got.OrderSlotsUsed != 4 || len(got.Positions) != 2 {
+		t.Fatalf("slots used = %d positions = %d, want 4/2", got.OrderSlotsUsed, len(got.Positions))
+	}
+	if got.Positions[0].TypeID != 2 || got.Positions[1].TypeID != 1 {
+		t.Fatalf("positions not ranked by daily profit: %+v", got.Positions)
+	}
+	for _, p := range got.Curve {
+		if p.InventoryM3 > 20+1e-6 {
+			t.Fatalf("hour %d inventory %v m3 exceeds cargo", p.Hour, p.InventoryM3)
+		}
+	}
+	if got.Positions[1].LimitedBy != "cargo" {
+		t.Fatalf("second position limited by = %q, want cargo", got.Positions[1].LimitedBy)
+	}
+}
+
+func TestOrderSlotsFromSkills(t *testing.T) {
+	if got := OrderSlotsFromSkills(nil); got != 5 {
+		t.Fatalf("nil sheet slots = %d, want 5", got)
+	}
+	sheet := &esi.SkillSheet{Skills: []esi.SkillEntry{
+		{SkillID: 3443, ActiveLevel: 5},
+		{SkillID: 3444, ActiveLevel: 5},
+		{SkillID: 16596, ActiveLevel: 5},
+		{SkillID: 18580, ActiveLevel: 5},
+	}}
+	if got := OrderSlotsFromSkills(sheet); got != 305 {
+		t.Fatalf("max skills slots = %d, want 305", got)
+	}
+}
@@ -27,8 +27,8 @@ type DepthLevel struct {
 // ExecutionPlanResult is the output of the slippage simulator.
 type ExecutionPlanResult struct {
 	BestPrice       float64      `json:"best_price"`        // top of book
-	ExpectedPrice   float64      `json:"expected_price"`    // volume-weighted avg fill price
-	SlippagePercent float64      `json:"slippage_percent"`  // (expected - best) / best * 100
+	ExpectedPrice   float64      `json:"expected_price"`    // VWAP of the filled part of the walk
+	SlippagePercent float64      `json:"slippage_percent"`  // VWAP vs top of book in %, >= 0 on both sides
 	TotalCost       float64      `json:"total_cost"`        // expected price * filled quantity (buy cost / sell revenue for fillable part)
 	DepthLevels     []DepthLevel `json:"depth_levels"`      // fill curve (first N levels until Q filled)
 	TotalDepth      int32        `json:"total_depth"`       // total volume in book (for this type/location)
@@ -53,6 +53,11 @@ func TestComputeExecutionPlan_Sell_Exact(t *testing.T) {
 	if math.Abs(got.ExpectedPrice-wantExpected) > 1e-6 {
 		t.Errorf("ExpectedPrice = %v, want %v", got.ExpectedPrice, wantExpected)
 	}
+	// Selling below the best bid is a cost, so slippage stays positive.
+	wantSlippage := (90 - wantExpected) / 90 * 100
+	if math.Abs(got.SlippagePercent-wantSlippage) > 1e-3 {
+		t.Errorf("SlippagePercent (sell) = %v, want %v", got.SlippagePercent, wantSlippage)
+	}
 	if !got.CanFill {
 		t.Error("CanFill want true")
 	}
@@ -1,5 +1,11 @@
 package engine
 
+import (
+	"math"
+
+	"eve-flipper/internal/esi"
+)
+
 // tradeFeeInputs carries legacy + split fee fields for profitability calculations.
 // Legacy mode (SplitTradeFees=false):
 // - Buy side: broker only
@@ -60,3 +66,205 @@ func tradeFeeMultipliers(in tradeFeeInputs) (buyCostMult, sellRevenueMult float6
 	}
 	return
 }
+
+// FeeBreakdown itemises the fees applied to one unit of a trade. Percent
+// fields are the normalized rates actually used; ISK fields are those rates
+// applied to the row's buy/sell prices, so NetProfitPerUnit can be checked
+// against the displayed ProfitPerUnit.
+type FeeBreakdown struct {
+	BuyBrokerPercent  float64 `json:"buy_broker_percent"`
+	BuyTaxPercent     float64 `json:"buy_tax_percent"`
+	SellBrokerPercent float64 `json:"sell_broker_percent"`
+	SellTaxPercent    float64 `json:"sell_tax_percent"`
+	BuyBroker         float64 `json:"buy_broker"`
+	BuyTax            float64 `json:"buy_tax"`
+	SellBroker        float64 `json:"sell_broker"`
+	SellTax           float64 `json:"sell_tax"`
+	TotalFees         float64 `json:"total_fees"`
+	NetProfitPerUnit  float64 `json:"net_profit_per_unit"`
+}
+
+// buildFeeBreakdown applies the normalized fee rates to a buy/sell price pair.
+// The net value matches tradeFeeMultipliers, including the clamp that stops
+// sell-side fees from exceeding revenue.
+func buildFeeBreakdown(in tradeFeeInputs, buyPrice, sellPrice float64) *FeeBreakdown {
+	buyBroker, buyTax, sellBroker, sellTax := tradeFeePercents(in)
+	fb := &FeeBreakdown{
+		BuyBrokerPercent:  buyBroker,
+		BuyTaxPercent:     buyTax,
+		SellBrokerPercent: sellBroker,
+		SellTaxPercent:    sellTax,
+		BuyBroker:         sanitizeFloat(buyPrice * buyBroker / 100),
+		BuyTax:            sanitizeFloat(buyPrice * buyTax / 100),
+		SellBroker:        sanitizeFloat(sellPrice * sellBroker / 100),
+		SellTax:           sanitizeFloat(sellPrice * sellTax / 100),
+	}
+	if sellSide := fb.SellBroker + fb.SellTax; sellSide > sellPrice {
+		// Mirror the sellRevenueMult floor: fees can eat revenue, not exceed it.
+		scale := sellPrice / sellSide
+		fb.SellBroker = sanitizeFloat(fb.SellBroker * scale)
+		fb.SellTax = sanitizeFloat(fb.SellTax * scale)
+	}
+	fb.TotalFees = sanitizeFloat(fb.BuyBroker + fb.BuyTax + fb.SellBroker + fb.SellTax)
+	fb.NetProfitPerUnit = sanitizeFloat(sellPrice - buyPrice - fb.TotalFees)
+	return fb
+}
+
+// instantSellProfitPerUnit is the per-unit result of buying at buyPrice and
+// selling straight into the best buy order at bidPrice. Filling someone
+// else's order pays sales tax but no broker fee, so only the sell tax is
+// taken off the bid.
+func instantSellProfitPerUnit(in tradeFeeInputs, buyPrice, bidPrice float64) float64 {
+	if buyPrice <= 0 || bidPrice <= 0 {
+		return 0
+	}
+	buyCostMult, _ := tradeFeeMultipliers(in)
+	_, _, _, sellTax := tradeFeePercents(in)
+	return sanitizeFloat(bidPrice*(1-sellTax/100) - buyPrice*buyCostMult)
+}
+
+// FeeSimulationInput describes a hypothetical trade: buy Quantity units at
+// BuyPrice, haul them Jumps jumps and sell at SellPrice. Fee fields follow
+// the same legacy/split rules as scan parameters.
+type FeeSimulationInput struct {
+	BuyPrice              float64
+	SellPrice             float64
+	Quantity              int64
+	VolumeM3              float64 // per unit
+	Jumps                 int
+	ShippingCostPerM3Jump float64
+
+	SplitTradeFees       bool
+	BrokerFeePercent     float64
+	SalesTaxPercent      float64
+	BuyBrokerFeePercent  float64
+	SellBrokerFeePercent float64
+	BuySalesTaxPercent   float64
+	SellSalesTaxPercent  float64
+}
+
+// FeeSimulation is the outcome of a FeeSimulationInput. ISK fields cover the
+// whole quantity; PerUnit is the breakdown scans attach to their rows.
+type FeeSimulation struct {
+	Quantity     int64   `json:"quantity"`
+	BuyCost      float64 `json:"buy_cost"`
+	SellRevenue  float64 `json:"sell_revenue"`
+	GrossProfit  float64 `json:"gross_profit"`
+	BuyBroker    float64 `json:"buy_broker"`
+	BuyTax       float64 `json:"buy_tax"`
+	SellBroker   float64 `json:"sell_broker"`
+	SellTax      float64 `json:"sell_tax"`
+	TotalFees    float64 `json:"total_fees"`
+	ShippingCost float64 `json:"shipping_cost"`
+	NetProfit    float64 `json:"net_profit"`
+	// MarginPercent is net profit over everything paid out (buy cost, buy-side
+	// fees, shipping). Without shipping it equals a scan row's MarginPercent.
+	MarginPercent float64       `json:"margin_percent"`
+	PerUnit       *FeeBreakdown `json:"per_unit"`
+}
+
+// SimulateTradeFees prices a hypothetical trade with the fee math the
+// scanners use.
+func SimulateTradeFees(in FeeSimulationInput) FeeSimulation {
+	fees := tradeFeeInputs{
+		SplitTradeFees:       in.SplitTradeFees,
+		BrokerFeePercent:     in.BrokerFeePercent,
+		SalesTaxPercent:      in.SalesTaxPercent,
+		BuyBrokerFeePercent:  in.BuyBrokerFeePercent,
+		SellBrokerFeePercent: in.SellBrokerFeePercent,
+		BuySalesTaxPercent:   in.BuySalesTaxPercent,
+		SellSalesTaxPercent:  in.SellSalesTaxPercent,
+	}
+	fb := buildFeeBreakdown(fees, in.BuyPrice, in.SellPrice)
+	qty := float64(max(in.Quantity, 0))
+
+	out := FeeSimulation{
+		Quantity:    max(in.Quantity, 0),
+		BuyCost:     sanitizeFloat(in.BuyPrice * qty),
+		SellRevenue: sanitizeFloat(in.SellPrice * qty),
+		BuyBroker:   sanitizeFloat(fb.BuyBroker * qty),
+		BuyTax:      sanitizeFloat(fb.BuyTax * qty),
+		SellBroker:  sanitizeFloat(fb.SellBroker * qty),
+		SellTax:     sanitizeFloat(fb.SellTax * qty),
+		TotalFees:   sanitizeFloat(fb.TotalFees * qty),
+		PerUnit:     fb,
+	}
+	out.GrossProfit = sanitizeFloat(out.SellRevenue - out.BuyCost)
+	if in.ShippingCostPerM3Jump > 0 && in.VolumeM3 > 0 && in.Jumps > 0 {
+		out.ShippingCost = sanitizeFloat(in.ShippingCostPerM3Jump * in.VolumeM3 * qty * float64(in.Jumps))
+	}
+	out.NetProfit = sanitizeFloat(fb.NetProfitPerUnit*qty - out.ShippingCost)
+	if outlay := out.BuyCost + out.BuyBroker + out.BuyTax + out.ShippingCost; outlay > 0 {
+		out.MarginPercent = sanitizeFloat(out.NetProfit / outlay * 100)
+	}
+	return out
+}
+
+// Base NPC-station trade fees for an untrained character with neutral
+// standings, in percent.
+const (
+	BaseSalesTaxPercent  = 8.0
+	BaseBrokerFeePercent = 3.0
+)
+
+// Skills that lower trade fees.
+const (
+	skillAccounting      int32 = 16622
+	skillBrokerRelations int32 = 3446
+)
+
+// TradeStandings are the character's effective standings towards the
+// station owner's faction and corporation (-10..10); they lower broker fees.
+type TradeStandings struct {
+	Faction     float64 `json:"faction"`
+	Corporation float64 `json:"corporation"`
+}
+
+// TradeFees are the sales tax and NPC broker fee a character pays, and the
+// skill levels they were derived from.
+type TradeFees struct {
+	SalesTaxPercent      float64 `json:"sales_tax_percent"`
+	BrokerFeePercent     float64 `json:"broker_fee_percent"`
+	AccountingLevel      int     `json:"accounting_level"`
+	BrokerRelationsLevel int     `json:"broker_relations_level"`
+	// NeutralStandings is set when no standings were given: the broker fee
+	// then ignores faction and corporation standings, which can move the
+	// real fee in either direction.
+	NeutralStandings bool `json:"neutral_standings"`
+}
+
+// EffectiveTradeFees computes the fees implied by a skill sheet:
+// Accounting cuts the base sales tax by 11% per level, Broker Relations
+// takes 0.3 points off the broker fee per level, and faction and corporation
+// standings take 0.03 and 0.02 points per standing (negative standings add
+// them). Only the first standings value is used; without it standings count
+// as neutral.
+// A nil sheet yields the base rates.
+func EffectiveTradeFees(skills *esi.SkillSheet, standings ...TradeStandings) TradeFees {
+	var out TradeFees
+	if skills != nil {
+		for _, sk := range skills.Skills {
+			switch sk.SkillID {
+			case skillAccounting:
+				out.AccountingLevel = min(max(sk.ActiveLevel, 0), 5)
+			case skillBrokerRelations:
+				out.BrokerRelationsLevel = min(max(sk.ActiveLevel, 0), 5)
+			}
+		}
+	}
+	out.SalesTaxPercent = roundFeePercent(BaseSalesTaxPercent * (1 - 0.11*float64(out.AccountingLevel)))
+
+	broker := BaseBrokerFeePercent - 0.3*float64(out.BrokerRelationsLevel)
+	if len(standings) > 0 {
+		broker -= 0.03*standings[0].Faction + 0.02*standings[0].Corporation
+	} else {
+		out.NeutralStandings = true
+	}
+	out.BrokerFeePercent = roundFeePercent(math.Max(broker, 0))
+	return out
+}
+
+// roundFeePercent trims float noise (8*(1-0.55) = 3.5999999...).
+func roundFeePercent(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
@@ -3,6 +3,8 @@ package engine
 import (
 	"math"
 	"testing"
+
+	"eve-flipper/internal/esi"
 )
 
 func TestTradeFeeMultipliers_LegacyFallback(t *testing.T) {
@@ -54,3 +56,131 @@ func TestTradeFeeMultipliers_Clamp(t *testing.T) {
 		t.Fatalf("sellMult = %v, want 0", sellMult)
 	}
 }
+
+func TestBuildFeeBreakdown_MatchesMultipliers(t *testing.T) {
+	cases := []tradeFeeInputs{
+		{SplitTradeFees: false, BrokerFeePercent: 3, SalesTaxPercent: 8},
+		{
+			SplitTradeFees:       true,
+			BuyBrokerFeePercent:  0.5,
+			SellBrokerFeePercent: 0.2,
+			BuySalesTaxPercent:   0.1,
+			SellSalesTaxPercent:  3.6,
+		},
+	}
+	const buyPrice, sellPrice = 1000.0, 1250.0
+	for _, in := range cases {
+		fb := buildFeeBreakdown(in, buyPrice, sellPrice)
+		buyMult, sellMult := tradeFeeMultipliers(in)
+		want := sellPrice*sellMult - buyPrice*buyMult
+		if math.Abs(fb.NetProfitPerUnit-want) > 1e-9 {
+			t.Fatalf("split=%v net = %v, want %v", in.SplitTradeFees, fb.NetProfitPerUnit, want)
+		}
+		sum := fb.BuyBroker + fb.BuyTax + fb.SellBroker + fb.SellTax
+		if math.Abs(fb.TotalFees-sum) > 1e-9 {
+			t.Fatalf("total fees = %v, want component sum %v", fb.TotalFees, sum)
+		}
+	}
+
+	legacy := buildFeeBreakdown(cases[0], buyPrice, sellPrice)
+	if legacy.BuyTax != 0 || legacy.BuyTaxPercent != 0 {
+		t.Fatalf("legacy mode should not apply buy tax: %+v", legacy)
+	}
+	if math.Abs(legacy.SellBroker-37.5) > 1e-9 || math.Abs(legacy.SellTax-100) > 1e-9 {
+		t.Fatalf("legacy sell components = %v/%v, want 37.5/100", legacy.SellBroker, legacy.SellTax)
+	}
+}
+
+func TestBuildFeeBreakdown_SellFeesClampedToRevenue(t *testing.T) {
+	in := tradeFeeInputs{SplitTradeFees: true, SellBrokerFeePercent: 80, SellSalesTaxPercent: 60}
+	fb := buildFeeBreakdown(in, 10, 100)
+	if math.Abs(fb.SellBroker+fb.SellTax-100) > 1e-9 {
+		t.Fatalf("sell-side fees = %v, want clamped to revenue 100", fb.SellBroker+fb.SellTax)
+	}
+	if math.Abs(fb.NetProfitPerUnit-(-10)) > 1e-9 {
+		t.Fatalf("net = %v, want -10", fb.NetProfitPerUnit)
+	}
+}
+
+func TestInstantSellProfitPerUnit_SkipsSellBroker(t *testing.T) {
+	in := tradeFeeInputs{SplitTradeFees: false, BrokerFeePercent: 3, SalesTaxPercent: 8}
+	// 1250 * 0.92 - 1000 * 1.03: sales tax on the bid, broker on the buy only.
+	if got := instantSellProfitPerUnit(in, 1000, 1250); math.Abs(got-120) > 1e-9 {
+		t.Fatalf("instant = %v, want 120", got)
+	}
+	if listed := buildFeeBreakdown(in, 1000, 1250).NetProfitPerUnit; listed >= 120 {
+		t.Fatalf("listing net %v should pay the extra sell broker fee", listed)
+	}
+	if got := instantSellProfitPerUnit(in, 1000, 0); got != 0 {
+		t.Fatalf("no bid = %v, want 0", got)
+	}
+}
+
+func TestEffectiveTradeFees(t *testing.T) {
+	if got := EffectiveTradeFees(nil); got.SalesTaxPercent != BaseSalesTaxPercent || got.BrokerFeePercent != BaseBrokerFeePercent {
+		t.Fatalf("nil skills = %+v, want base rates", got)
+	}
+
+	sheet := &esi.SkillSheet{Skills: []esi.SkillEntry{
+		{SkillID: skillAccounting, ActiveLevel: 5},
+		{SkillID: skillBrokerRelations, ActiveLevel: 4},
+		{SkillID: skillTrade, ActiveLevel: 5},
+	}}
+	got := EffectiveTradeFees(sheet)
+	if got.AccountingLevel != 5 || got.BrokerRelationsLevel != 4 {
+		t.Fatalf("levels = %d/%d, want 5/4", got.AccountingLevel, got.BrokerRelationsLevel)
+	}
+	if got.SalesTaxPercent != 3.6 || got.BrokerFeePercent != 1.8 {
+		t.Fatalf("fees = %v/%v, want 3.6/1.8", got.SalesTaxPercent, got.BrokerFeePercent)
+	}
+	if !got.NeutralStandings {
+		t.Fatal("fees without standings should be flagged as assuming neutral standings")
+	}
+
+	// Broker Relations V with 10 faction and corporation standing: 3 - 1.5 - 0.3 - 0.2.
+	sheet.Skills[1].ActiveLevel = 5
+	got = EffectiveTradeFees(sheet, TradeStandings{Faction: 10, Corporation: 10})
+	if got.BrokerFeePercent != 1.0 || got.NeutralStandings {
+		t.Fatalf("max-standing fees = %+v, want broker 1.0 from real standings", got)
+	}
+	if got = EffectiveTradeFees(sheet, TradeStandings{Faction: -5}); got.BrokerFeePercent != 1.65 {
+		t.Fatalf("negative-standing broker fee = %v, want 1.65", got.BrokerFeePercent)
+	}
+}
+
+func TestSimulateTradeFees(t *testing.T) {
+	in := FeeSimulationInput{
+		BuyPrice:         1000,
+		SellPrice:        1250,
+		Quantity:         10,
+		BrokerFeePercent: 3,
+		SalesTaxPercent:  8,
+	}
+	sim := SimulateTradeFees(in)
+
+	// Per unit: 1250*(1-0.11) - 1000*1.03 = 82.5.
+	if math.Abs(sim.NetProfit-825) > 1e-9 {
+		t.Fatalf("net = %v, want 825", sim.NetProfit)
+	}
+	if sim.GrossProfit != 2500 || sim.BuyBroker != 300 || sim.SellBroker != 375 || sim.SellTax != 1000 {
+		t.Fatalf("components = %+v", sim)
+	}
+	// Without shipping the margin matches the scanners' profit / effective buy.
+	if want := 82.5 / 1030 * 100; math.Abs(sim.MarginPercent-want) > 1e-9 {
+		t.Fatalf("margin = %v, want %v", sim.MarginPercent, want)
+	}
+
+	in.VolumeM3 = 0.5
+	in.Jumps = 4
+	in.ShippingCostPerM3Jump = 100
+	sim = SimulateTradeFees(in)
+	if sim.ShippingCost != 2000 {
+		t.Fatalf("shipping = %v, want 2000", sim.ShippingCost)
+	}
+	if math.Abs(sim.NetProfit-(-1175)) > 1e-9 {
+		t.Fatalf("net with shipping = %v, want -1175", sim.NetProfit)
+	}
+	if want := -1175.0 / (10300 + 2000) * 100; math.Abs(sim.MarginPercent-want) > 1e-9 {
+		t.Fatalf("margin with shipping = %v, want %v", sim.MarginPercent, want)
+	}
+}
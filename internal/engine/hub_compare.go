@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"sort"
+
+	"eve-flipper/internal/esi"
+)
+
+// HubBasketItem is one line of a basket to be sold at a hub.
+type HubBasketItem struct {
+	TypeID   int32 `json:"type_id"`
+	Quantity int32 `json:"quantity"`
+}
+
+// HubCandidate is a trade hub system to compare.
+type HubCandidate struct {
+	SystemID   int32
+	SystemName string
+	RegionID   int32
+}
+
+// HubItemProceeds is what one basket line fetches when dumped into a hub's
+// buy orders.
+type HubItemProceeds struct {
+	TypeID        int32   `json:"type_id"`
+	TypeName      string  `json:"type_name"`
+	Quantity      int32   `json:"quantity"`
+	FilledQty     int32   `json:"filled_qty"`
+	BestPrice     float64 `json:"best_price"`     // highest bid in the hub
+	ExpectedPrice float64 `json:"expected_price"` // volume-weighted fill price
+	GrossValue    float64 `json:"gross_value"`
+	CanFill       bool    `json:"can_fill"`
+}
+
+// HubComparison is the net result of selling a basket at one hub.
+type HubComparison struct {
+	SystemID    int32             `json:"system_id"`
+	SystemName  string            `json:"system_name"`
+	RegionID    int32             `json:"region_id"`
+	GrossValue  float64           `json:"gross_value"`
+	SalesTax    float64           `json:"sales_tax"`
+	NetProceeds float64           `json:"net_proceeds"`
+	FilledItems int               `json:"filled_items"` // lines the hub can absorb completely
+	UnsoldUnits int64             `json:"unsold_units"` // units left over after the book runs dry
+	CoveragePct float64           `json:"coverage_pct"` // sold units / basket units * 100
+	Items       []HubItemProceeds `json:"items"`
+}
+
+// CompareHubs sells the basket into the buy orders located in each hub
+// system (an instant sale, so only sales tax applies) and ranks the hubs by
+// net proceeds, best first. orders must hold the regional books of the
+// basket types for every hub region.
+func CompareHubs(basket []HubBasketItem, hubs []HubCandidate, orders []esi.MarketOrder, salesTaxPercent float64, typeName func(int32) string) []HubComparison {
+	type systemType struct {
+		systemID int32
+		typeID   int32
+	}
+	bids := make(map[systemType][]esi.MarketOrder)
+	for _, o := range orders {
+		if !o.IsBuyOrder || o.VolumeRemain <= 0 {
+			continue
+		}
+		k := systemType{o.SystemID, o.TypeID}
+		bids[k] = append(bids[k], o)
+	}
+	taxRate := clampPercent(salesTaxPercent) / 100
+
+	var basketUnits int64
+	for _, item := range basket {
+		basketUnits += int64(item.Quantity)
+	}
+
+	out := make([]HubComparison, 0, len(hubs))
+	for _, hub := range hubs {
+		cmp := HubComparison{
+			SystemID:   hub.SystemID,
+			SystemName: hub.SystemName,
+			RegionID:   hub.RegionID,
+			Items:      make([]HubItemProceeds, 0, len(basket)),
+		}
+		var soldUnits int64
+		for _, item := range basket {
+			row := HubItemProceeds{TypeID: item.TypeID, Quantity: item.Quantity}
+			if typeName != nil {
+				row.TypeName = typeName(item.TypeID)
+			}
+			plan := ComputeExecutionPlan(bids[systemType{hub.SystemID, item.TypeID}], item.Quantity, false)
+			for _, lv := range plan.DepthLevels {
+				row.FilledQty += lv.VolumeFilled
+			}
+			row.BestPrice = plan.BestPrice
+			row.ExpectedPrice = plan.ExpectedPrice
+			row.GrossValue = plan.TotalCost
+			row.CanFill = plan.CanFill
+
+			cmp.GrossValue += row.GrossValue
+			if row.CanFill {
+				cmp.FilledItems++
+			}
+			soldUnits += int64(row.FilledQty)
+			cmp.Items = append(cmp.Items, row)
+		}
+		cmp.SalesTax = cmp.GrossValue * taxRate
+		cmp.NetProceeds = cmp.GrossValue - cmp.SalesTax
+		cmp.UnsoldUnits = basketUnits - soldUnits
+		if basketUnits > 0 {
+			cmp.CoveragePct = float64(soldUnits) / float64(basketUnits) * 100
+		}
+		out = append(out, cmp)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].NetProceeds > out[j].NetProceeds
+	})
+	return out
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"eve-flipper/internal/esi"
+)
+
+func TestCompareHubs_RanksByNetProceeds(t *testing.T) {
+	const (
+		jita  = int32(30000142)
+		amarr = int32(30002187)
+		trit  = int32(34)
+		pye   = int32(35)
+	)
+	orders := []esi.MarketOrder{
+		// Jita: deep Tritanium bids, no Pyerite buyers.
+		{TypeID: trit, SystemID: jita, Price: 5, VolumeRemain: 1000, IsBuyOrder: true},
+		{TypeID: trit, SystemID: jita, Price: 4, VolumeRemain: 1000, IsBuyOrder: true},
+		{TypeID: trit, SystemID: jita, Price: 9, VolumeRemain: 1000, IsBuyOrder: false}, // asks are ignored
+		// Amarr: better top bid but thin; Pyerite buyers.
+		{TypeID: trit, SystemID: amarr, Price: 6, VolumeRemain: 100, IsBuyOrder: true},
+		{TypeID: pye, SystemID: amarr, Price: 10, VolumeRemain: 50, IsBuyOrder: true},
+	}
+	basket := []HubBasketItem{{TypeID: trit, Quantity: 1500}, {TypeID: pye, Quantity: 50}}
+	hubs := []HubCandidate{
+		{SystemID: amarr, SystemName: "Amarr", RegionID: 10000043},
+		{SystemID: jita, SystemName: "Jita", RegionID: 10000002},
+	}
+
+	got := CompareHubs(basket, hubs, orders, 10, nil)
+	if len(got) != 2 {
+		t.Fatalf("len = %d, want 2", len(got))
+	}
+	// Jita: 1000*5 + 500*4 = 7000 gross, 6300 net.
+	// Amarr: 100*6 + 50*10 = 1100 gross, 990 net.
+	if got[0].SystemName != "Jita" || got[1].SystemName != "Amarr" {
+		t.Fatalf("order = %s, %s; want Jita first", got[0].SystemName, got[1].SystemName)
+	}
+	jitaRow := got[0]
+	if math.Abs(jitaRow.GrossValue-7000) > 1e-6 || math.Abs(jitaRow.NetProceeds-6300) > 1e-6 {
+		t.Fatalf("Jita gross/net = %v/%v, want 7000/6300", jitaRow.GrossValue, jitaRow.NetProceeds)
+	}
+	if jitaRow.FilledItems != 1 || jitaRow.UnsoldUnits != 50 {
+		t.Fatalf("Jita filled=%d unsold=%d, want 1/50", jitaRow.FilledItems, jitaRow.UnsoldUnits)
+	}
+	if tr := jitaRow.Items[0]; tr.FilledQty != 1500 || !tr.CanFill || tr.BestPrice != 5 {
+		t.Fatalf("Jita tritanium = %+v", tr)
+	}
+	amarrRow := got[1]
+	if math.Abs(amarrRow.NetProceeds-990) > 1e-6 || amarrRow.UnsoldUnits != 1400 {
+		t.Fatalf("Amarr net=%v unsold=%d, want 990/1400", amarrRow.NetProceeds, amarrRow.UnsoldUnits)
+	}
+}
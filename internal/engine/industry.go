@@ -28,21 +28,30 @@ type IndustryParams struct {
 	OwnBlueprint        bool    // true = user owns BP (default), false = must buy
 	BlueprintCost       float64 // ISK cost of blueprint (BPO or BPC)
 	BlueprintIsBPO      bool    // true = BPO (amortize over runs), false = BPC (one-time)
+	IncludeInvention    bool    // Expand invention for T2 blueprints (uses invented BPC ME/TE)
+	Decryptor           int32   // Optional decryptor type ID for invention (0 = none)
+
+	// Reactions run in a refinery with its own rigs and tax; ME/TE and
+	// StructureBonus do not apply to reaction formulas.
+	ReactionStructureBonus float64 // Refinery reaction material bonus %
+	ReactionFacilityTax    float64 // Refinery facility tax %
 }
 
 // MaterialNode represents a node in the production tree.
 type MaterialNode struct {
 	TypeID      int32           `json:"type_id"`
 	TypeName    string          `json:"type_name"`
-	Quantity    int32           `json:"quantity"`     // Required quantity
-	IsBase      bool            `json:"is_base"`      // True if cannot be further produced
-	BuyPrice    float64         `json:"buy_price"`    // Market buy price (sell orders)
-	BuildCost   float64         `json:"build_cost"`   // Total cost to build (materials + job cost)
-	ShouldBuild bool            `json:"should_build"` // True if building is cheaper than buying
-	JobCost     float64         `json:"job_cost"`     // Manufacturing job installation cost
-	Children    []*MaterialNode `json:"children"`     // Required sub-materials
-	Blueprint   *BlueprintInfo  `json:"blueprint"`    // Blueprint info if buildable
-	Depth       int             `json:"depth"`        // Depth in tree
+	Quantity    int32           `json:"quantity"`           // Required quantity
+	IsBase      bool            `json:"is_base"`            // True if cannot be further produced
+	BuyPrice    float64         `json:"buy_price"`          // Market buy price (sell orders)
+	BuildCost   float64         `json:"build_cost"`         // Total cost to build (materials + job cost)
+	ShouldBuild bool            `json:"should_build"`       // True if building is cheaper than buying
+	JobCost     float64         `json:"job_cost"`           // Job installation cost (manufacturing or reaction)
+	JobType     string          `json:"job_type,omitempty"` // "manufacturing" or "reaction"; empty for base materials
+	Children    []*MaterialNode `json:"children"`           // Required sub-materials
+	Blueprint   *BlueprintInfo  `json:"blueprint"`          // Blueprint info if buildable
+	Invention   *InventionStep  `json:"invention,omitempty"`
+	Depth       int             `json:"depth"` // Depth in tree
 }
 
 // BlueprintInfo contains blueprint information for display.
@@ -72,8 +81,11 @@ type IndustryAnalysis struct {
 	ManufacturingTime     int32           `json:"manufacturing_time"` // Total time in seconds
 	TotalJobCost          float64         `json:"total_job_cost"`     // Sum of all job installation costs
 	MaterialTree          *MaterialNode   `json:"material_tree"`
-	FlatMaterials         []*FlatMaterial `json:"flat_materials"` // Flattened list of base materials
-	SystemCostIndex       float64         `json:"system_cost_index"`
+	FlatMaterials         []*FlatMaterial `json:"flat_materials"`      // Flattened list of base materials
+	SystemID              int32           `json:"system_id"`           // System whose cost indices were applied (0 = none)
+	SystemCostIndex       float64         `json:"system_cost_index"`   // Manufacturing index
+	ReactionCostIndex     float64         `json:"reaction_cost_index"` // Applied to reaction nodes
+	InventionCostIndex    float64         `json:"invention_cost_index"`
 	RegionID              int32           `json:"region_id"`               // Market region for execution plan
 	RegionName            string          `json:"region_name"`             // Optional display name
 	BlueprintCostIncluded float64         `json:"blueprint_cost_included"` // BP cost added to build cost
@@ -185,15 +197,16 @@ func (a *IndustryAnalyzer) Analyze(params IndustryParams, progress func(string))
 	}
 	a.marketPrices = marketPrices
 
-	// Get system cost index
-	var costIndex float64
+	// Get system cost indices. They are passed down rather than stored on the
+	// analyzer, which is shared by concurrent requests.
+	var costIndices esi.SystemCostIndices
 	if params.SystemID != 0 {
 		progress("Fetching system cost index...")
 		idx, err := a.loadSystemCostIndex(params.SystemID)
 		if err != nil {
 			log.Printf("Warning: failed to fetch cost index: %v", err)
 		} else {
-			costIndex = idx.Manufacturing
+			costIndices = *idx
 		}
 	}
 
@@ -211,7 +224,7 @@ func (a *IndustryAnalyzer) Analyze(params IndustryParams, progress func(string))
 
 	// Calculate costs
 	progress("Calculating optimal costs...")
-	a.calculateCosts(tree, costIndex, params)
+	a.calculateCosts(tree, costIndices, params)
 
 	// Flatten materials for shopping list
 	flatMaterials := a.flattenMaterials(tree)
@@ -283,7 +296,10 @@ func (a *IndustryAnalyzer) Analyze(params IndustryParams, progress func(string))
 		TotalJobCost:          totalJobCost,
 		MaterialTree:          tree,
 		FlatMaterials:         flatMaterials,
-		SystemCostIndex:       costIndex,
+		SystemID:              params.SystemID,
+		SystemCostIndex:       costIndices.Manufacturing,
+		ReactionCostIndex:     costIndices.Reaction,
+		InventionCostIndex:    costIndices.Invention,
 		RegionID:              regionID,
 		RegionName:            regionName,
 		BlueprintCostIncluded: bpCostIncluded,
@@ -318,18 +334,34 @@ func (a *IndustryAnalyzer) buildMaterialTree(typeID int32, quantity int32, param
 		runsNeeded++
 	}
 
+	node.JobType = bp.JobType()
+
+	// Invented T2 copies come with their own ME/TE instead of the user's BP.
+	// Reaction formulas have neither and use the refinery's bonus.
+	me, te := params.MaterialEfficiency, params.TimeEfficiency
+	structureBonus := params.StructureBonus
+	if bp.IsReaction {
+		me, te = 0, 0
+		structureBonus = params.ReactionStructureBonus
+	} else if params.IncludeInvention {
+		if step := a.planInvention(bp, runsNeeded, params); step != nil {
+			node.Invention = step
+			me, te = step.ResultME, step.ResultTE
+		}
+	}
+
 	node.Blueprint = &BlueprintInfo{
 		BlueprintTypeID: bp.BlueprintTypeID,
 		ProductQuantity: bp.ProductQuantity,
-		ME:              params.MaterialEfficiency,
-		TE:              params.TimeEfficiency,
-		Time:            bp.CalculateTimeWithTE(runsNeeded, params.TimeEfficiency),
+		ME:              me,
+		TE:              te,
+		Time:            bp.CalculateTimeWithTE(runsNeeded, te),
 	}
 
 	// FIX #5: Apply ME and structure bonus in a single step before ceiling
 	// to avoid rounding errors from intermediate truncation.
 	// EVE formula: max(runs, ceil(base × runs × (1-ME/100) × (1-structureBonus/100)))
-	materials := bp.CalculateMaterialsWithMEAndStructure(runsNeeded, params.MaterialEfficiency, params.StructureBonus)
+	materials := bp.CalculateMaterialsWithMEAndStructure(runsNeeded, me, structureBonus)
 
 	// Build children recursively
 	for _, mat := range materials {
@@ -341,10 +373,10 @@ func (a *IndustryAnalyzer) buildMaterialTree(typeID int32, quantity int32, param
 }
 
 // calculateCosts calculates build costs bottom-up and decides buy vs build.
-func (a *IndustryAnalyzer) calculateCosts(node *MaterialNode, costIndex float64, params IndustryParams) {
+func (a *IndustryAnalyzer) calculateCosts(node *MaterialNode, costIndices esi.SystemCostIndices, params IndustryParams) {
 	// First, calculate costs for all children
 	for _, child := range node.Children {
-		a.calculateCosts(child, costIndex, params)
+		a.calculateCosts(child, costIndices, params)
 	}
 
 	if node.IsBase {
@@ -367,9 +399,16 @@ func (a *IndustryAnalyzer) calculateCosts(node *MaterialNode, costIndex float64,
 	// Calculate job installation cost
 	// Formula: EIV * cost_index * (1 + facility_tax)
 	eiv := a.calculateEIV(node)
-	node.JobCost = eiv * costIndex * (1 + params.FacilityTax/100)
+	if node.JobType == "reaction" {
+		node.JobCost = eiv * costIndices.Reaction * (1 + params.ReactionFacilityTax/100)
+	} else {
+		node.JobCost = eiv * costIndices.Manufacturing * (1 + params.FacilityTax/100)
+	}
 
 	node.BuildCost = materialCost + node.JobCost
+	if node.Invention != nil {
+		node.BuildCost += a.costInvention(node, costIndices.Invention, params)
+	}
 
 	// Decide: buy or build
 	if node.BuyPrice > 0 && node.BuyPrice < node.BuildCost {
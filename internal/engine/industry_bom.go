@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"sort"
+
+	"eve-flipper/internal/sde"
+)
+
+// bomMaxDepth bounds component expansion, matching the analyzer default.
+const bomMaxDepth = 10
+
+// BOMTask is one production task of a project: runs of the blueprint that
+// makes ProductTypeID.
+type BOMTask struct {
+	ProductTypeID int32
+	Runs          int32
+	Activity      string // "manufacturing" or "reaction"; other activities are ignored
+}
+
+// BOMLine is one leaf material of a bill of materials.
+type BOMLine struct {
+	TypeID       int32   `json:"type_id"`
+	TypeName     string  `json:"type_name"`
+	Quantity     int64   `json:"quantity"`
+	VolumeM3     float64 `json:"volume_m3"`
+	UnitCostISK  float64 `json:"unit_cost_isk"`
+	TotalCostISK float64 `json:"total_cost_isk"`
+}
+
+// ProjectBOM is the fully expanded raw material list for a set of tasks.
+type ProjectBOM struct {
+	Lines         []BOMLine `json:"lines"`
+	InHouse       []int32   `json:"in_house_type_ids"` // Products covered by their own task, not expanded
+	Skipped       []int32   `json:"skipped_type_ids"`  // Task products without a known blueprint
+	TotalQuantity int64     `json:"total_quantity"`
+	TotalVolumeM3 float64   `json:"total_volume_m3"`
+	TotalCostISK  float64   `json:"total_cost_isk"`
+}
+
+// BuildProjectBOM expands each task's inputs down to leaf materials. Inputs
+// that another task produces are left to that task; other buildable
+// components are expanded recursively. blueprintME gives the ME per
+// blueprint type ID (missing = 0); unitCosts are optional ISK per unit.
+func BuildProjectBOM(data *sde.Data, tasks []BOMTask, blueprintME map[int32]int32, unitCosts map[int32]float64) *ProjectBOM {
+	out := &ProjectBOM{Lines: []BOMLine{}, InHouse: []int32{}, Skipped: []int32{}}
+	if data == nil || data.Industry == nil {
+		return out
+	}
+
+	inHouse := make(map[int32]bool)
+	for _, t := range tasks {
+		if bomTaskCounts(t) {
+			inHouse[t.ProductTypeID] = true
+		}
+	}
+
+	totals := make(map[int32]int64)
+	var expand func(typeID int32, qty int64, depth int)
+	expand = func(typeID int32, qty int64, depth int) {
+		bp, ok := data.Industry.GetBlueprintForProduct(typeID)
+		if !ok || depth >= bomMaxDepth || len(bp.Materials) == 0 {
+			totals[typeID] += qty
+			return
+		}
+		runs := (qty + int64(bp.ProductQuantity) - 1) / int64(bp.ProductQuantity)
+		for _, mat := range bomMaterials(bp, runs, blueprintME) {
+			if inHouse[mat.TypeID] {
+				continue
+			}
+			expand(mat.TypeID, int64(mat.Quantity), depth+1)
+		}
+	}
+
+	for _, t := range tasks {
+		if !bomTaskCounts(t) {
+			continue
+		}
+		bp, ok := data.Industry.GetBlueprintForProduct(t.ProductTypeID)
+		if !ok {
+			out.Skipped = append(out.Skipped, t.ProductTypeID)
+			continue
+		}
+		for _, mat := range bomMaterials(bp, int64(t.Runs), blueprintME) {
+			if inHouse[mat.TypeID] {
+				continue
+			}
+			expand(mat.TypeID, int64(mat.Quantity), 1)
+		}
+	}
+
+	for typeID := range inHouse {
+		out.InHouse = append(out.InHouse, typeID)
+	}
+	sort.Slice(out.InHouse, func(i, j int) bool { return out.InHouse[i] < out.InHouse[j] })
+
+	for typeID, qty := range totals {
+		line := BOMLine{TypeID: typeID, Quantity: qty, UnitCostISK: unitCosts[typeID]}
+		if t, ok := data.Types[typeID]; ok {
+			line.TypeName = t.Name
+			line.VolumeM3 = t.Volume * float64(qty)
+		}
+		line.TotalCostISK = line.UnitCostISK * float64(qty)
+		out.Lines = append(out.Lines, line)
+		out.TotalQuantity += qty
+		out.TotalVolumeM3 += line.VolumeM3
+		out.TotalCostISK += line.TotalCostISK
+	}
+	sort.Slice(out.Lines, func(i, j int) bool {
+		if out.Lines[i].TotalCostISK != out.Lines[j].TotalCostISK {
+			return out.Lines[i].TotalCostISK > out.Lines[j].TotalCostISK
+		}
+		return out.Lines[i].TypeID < out.Lines[j].TypeID
+	})
+	return out
+}
+
+func bomTaskCounts(t BOMTask) bool {
+	if t.ProductTypeID <= 0 || t.Runs <= 0 {
+		return false
+	}
+	return t.Activity == "" || t.Activity == "manufacturing" || t.Activity == "reaction"
+}
+
+// bomMaterials applies the blueprint's synced ME; reaction formulas have none.
+func bomMaterials(bp *sde.Blueprint, runs int64, blueprintME map[int32]int32) []sde.BlueprintMaterial {
+	me := blueprintME[bp.BlueprintTypeID]
+	if bp.IsReaction {
+		me = 0
+	}
+	return bp.CalculateMaterialsWithME(int32(runs), me)
+}
@@ -0,0 +1,57 @@
+package engine
+
+import "testing"
+
+func TestBuildProjectBOM_ExpandsComponentsNotBuiltInHouse(t *testing.T) {
+	data := newTestIndustrySDE()
+
+	bom := BuildProjectBOM(data, []BOMTask{
+		{ProductTypeID: 1000, Runs: 2, Activity: "manufacturing"},
+	}, nil, map[int32]float64{34: 5, 1002: 100})
+
+	got := bomQuantities(bom)
+	// 20 Build Components are expanded to 60 Tritanium; Base Component is a leaf.
+	if len(got) != 2 || got[34] != 60 || got[1002] != 10 {
+		t.Fatalf("lines = %v, want {34:60, 1002:10}", got)
+	}
+	if bom.TotalQuantity != 70 || !industryAlmostEqual(bom.TotalCostISK, 60*5+10*100) {
+		t.Fatalf("totals = %d / %v, want 70 / 1300", bom.TotalQuantity, bom.TotalCostISK)
+	}
+	if !industryAlmostEqual(bom.TotalVolumeM3, 60*0.01+10*0.5) {
+		t.Fatalf("TotalVolumeM3 = %v, want 5.6", bom.TotalVolumeM3)
+	}
+	if bom.Lines[0].TypeID != 1002 {
+		t.Fatalf("first line = %d, want most expensive (1002)", bom.Lines[0].TypeID)
+	}
+}
+
+func TestBuildProjectBOM_InHouseTaskUsesPoolME(t *testing.T) {
+	data := newTestIndustrySDE()
+
+	bom := BuildProjectBOM(data, []BOMTask{
+		{ProductTypeID: 1000, Runs: 2, Activity: "manufacturing"},
+		{ProductTypeID: 1001, Runs: 20, Activity: ""},
+		{ProductTypeID: 1001, Runs: 5, Activity: "invention"}, // ignored
+		{ProductTypeID: 9999, Runs: 1, Activity: "manufacturing"},
+	}, map[int32]int32{2001: 10}, nil)
+
+	got := bomQuantities(bom)
+	// Build Component comes from its own task: ceil(3 × 20 × 0.9) = 54 Tritanium.
+	if len(got) != 2 || got[34] != 54 || got[1002] != 10 {
+		t.Fatalf("lines = %v, want {34:54, 1002:10}", got)
+	}
+	if len(bom.InHouse) != 3 || bom.InHouse[0] != 1000 || bom.InHouse[1] != 1001 {
+		t.Fatalf("InHouse = %v, want [1000 1001 9999]", bom.InHouse)
+	}
+	if len(bom.Skipped) != 1 || bom.Skipped[0] != 9999 {
+		t.Fatalf("Skipped = %v, want [9999]", bom.Skipped)
+	}
+}
+
+func bomQuantities(bom *ProjectBOM) map[int32]int64 {
+	out := make(map[int32]int64, len(bom.Lines))
+	for _, l := range bom.Lines {
+		out[l.TypeID] = l.Quantity
+	}
+	return out
+}
@@ -168,7 +168,7 @@ func TestAnalyze_EndToEndInjectedPricing(t *testing.T) {
 			if systemID != 30000142 {
 				t.Fatalf("systemID = %d, want 30000142", systemID)
 			}
-			return &esi.SystemCostIndices{Manufacturing: 0.1}, nil
+			return &esi.SystemCostIndices{Manufacturing: 0.1, Reaction: 0.02}, nil
 		},
 		fetchMarketPricesFn: func(_ IndustryParams) (map[int32]float64, error) {
 			return map[int32]float64{
@@ -208,6 +208,9 @@ func TestAnalyze_EndToEndInjectedPricing(t *testing.T) {
 	if !industryAlmostEqual(result.SystemCostIndex, 0.1) {
 		t.Fatalf("SystemCostIndex = %v, want 0.1", result.SystemCostIndex)
 	}
+	if result.SystemID != 30000142 || !industryAlmostEqual(result.ReactionCostIndex, 0.02) {
+		t.Fatalf("SystemID = %d, ReactionCostIndex = %v; want 30000142, 0.02", result.SystemID, result.ReactionCostIndex)
+	}
 	if !industryAlmostEqual(result.MarketBuyPrice, 600.0) {
 		t.Fatalf("MarketBuyPrice = %v, want 600", result.MarketBuyPrice)
 	}
@@ -322,7 +325,7 @@ func TestCalculateCosts_PrefersBuyingWhenCheaper(t *testing.T) {
 	}
 
 	tree := a.buildMaterialTree(1001, 1, IndustryParams{MaxDepth: 10}, 0)
-	a.calculateCosts(tree, 0.1, IndustryParams{})
+	a.calculateCosts(tree, esi.SystemCostIndices{Manufacturing: 0.1}, IndustryParams{})
 
 	if tree.ShouldBuild {
 		t.Fatalf("tree.ShouldBuild = true, want false")
@@ -338,6 +341,70 @@ func TestCalculateCosts_PrefersBuyingWhenCheaper(t *testing.T) {
 	}
 }
 
+func TestBuildMaterialTree_ReactionNode(t *testing.T) {
+	data := newTestIndustrySDE()
+	ind := data.Industry
+	ind.Blueprints[6000] = &sde.Blueprint{
+		BlueprintTypeID: 6000,
+		ProductTypeID:   5000,
+		ProductQuantity: 1,
+		Time:            3600,
+		Materials:       []sde.BlueprintMaterial{{TypeID: 5001, Quantity: 300}},
+	}
+	ind.ProductToBlueprint[5000] = 6000
+	ind.Blueprints[6001] = &sde.Blueprint{
+		BlueprintTypeID: 6001,
+		ProductTypeID:   5001,
+		ProductQuantity: 200,
+		Time:            10800,
+		IsReaction:      true,
+		Materials:       []sde.BlueprintMaterial{{TypeID: 16634, Quantity: 100}},
+	}
+	ind.ProductToBlueprint[5001] = 6001
+	data.Types[5000] = &sde.ItemType{ID: 5000, Name: "Composite Hull"}
+	data.Types[5001] = &sde.ItemType{ID: 5001, Name: "Composite"}
+	data.Types[16634] = &sde.ItemType{ID: 16634, Name: "Moon Goo"}
+
+	a := &IndustryAnalyzer{
+		SDE:            data,
+		marketPrices:   map[int32]float64{16634: 10, 5001: 1000},
+		adjustedPrices: map[int32]float64{16634: 10},
+	}
+	params := IndustryParams{
+		MaxDepth:               10,
+		MaterialEfficiency:     10,
+		StructureBonus:         1,
+		ReactionStructureBonus: 2.4,
+		ReactionFacilityTax:    10,
+	}
+
+	tree := a.buildMaterialTree(5000, 1, params, 0)
+	if tree.JobType != "manufacturing" {
+		t.Fatalf("root JobType = %q, want manufacturing", tree.JobType)
+	}
+	rxn := tree.Children[0]
+	if rxn.JobType != "reaction" {
+		t.Fatalf("child JobType = %q, want reaction", rxn.JobType)
+	}
+	if rxn.Blueprint.ME != 0 || rxn.Blueprint.TE != 0 {
+		t.Fatalf("reaction ME/TE = %d/%d, want 0/0", rxn.Blueprint.ME, rxn.Blueprint.TE)
+	}
+	// 300 units / 200 per run = 2 runs; refinery bonus only: ceil(100 × 2 × 0.976) = 196.
+	goo := rxn.Children[0]
+	if goo.Quantity != 196 || goo.JobType != "" {
+		t.Fatalf("goo = %+v, want quantity 196 and no job type", goo)
+	}
+
+	a.calculateCosts(tree, esi.SystemCostIndices{Reaction: 0.05}, params)
+	// EIV 10 × 100 × 2 runs = 2000; × reaction index 0.05 × 1.10 tax.
+	if !industryAlmostEqual(rxn.JobCost, 110) {
+		t.Fatalf("reaction JobCost = %v, want 110", rxn.JobCost)
+	}
+	if !industryAlmostEqual(rxn.BuildCost, 1960+110) {
+		t.Fatalf("reaction BuildCost = %v, want 2070", rxn.BuildCost)
+	}
+}
+
 func TestAnalyze_TypeNotFound(t *testing.T) {
 	a := &IndustryAnalyzer{
 		SDE: &sde.Data{
@@ -0,0 +1,183 @@
+package engine
+
+import (
+	"math"
+
+	"eve-flipper/internal/sde"
+)
+
+// Invented T2 BPCs start at ME 2 / TE 4 before decryptor modifiers.
+const (
+	inventionBaseME = 2
+	inventionBaseTE = 4
+	// inventionJobCostRate is the share of the product's EIV used as the
+	// invention job base cost.
+	inventionJobCostRate = 0.02
+)
+
+// Decryptor describes the modifiers one decryptor applies to an invention job.
+type Decryptor struct {
+	TypeID                int32   `json:"type_id"`
+	Name                  string  `json:"name"`
+	ProbabilityMultiplier float64 `json:"probability_multiplier"`
+	RunModifier           int32   `json:"run_modifier"`
+	MEModifier            int32   `json:"me_modifier"`
+	TEModifier            int32   `json:"te_modifier"`
+}
+
+// Decryptors lists the generic decryptors by type ID.
+var Decryptors = map[int32]Decryptor{
+	34201: {TypeID: 34201, Name: "Accelerant Decryptor", ProbabilityMultiplier: 1.2, RunModifier: 1, MEModifier: 2, TEModifier: 10},
+	34202: {TypeID: 34202, Name: "Attainment Decryptor", ProbabilityMultiplier: 1.8, RunModifier: 4, MEModifier: -1, TEModifier: 4},
+	34203: {TypeID: 34203, Name: "Augmentation Decryptor", ProbabilityMultiplier: 0.6, RunModifier: 9, MEModifier: -2, TEModifier: 2},
+	34204: {TypeID: 34204, Name: "Optimized Attainment Decryptor", ProbabilityMultiplier: 1.9, RunModifier: 2, MEModifier: 1, TEModifier: -2},
+	34205: {TypeID: 34205, Name: "Optimized Augmentation Decryptor", ProbabilityMultiplier: 0.9, RunModifier: 7, MEModifier: 2, TEModifier: 0},
+	34206: {TypeID: 34206, Name: "Parity Decryptor", ProbabilityMultiplier: 1.5, RunModifier: 3, MEModifier: 1, TEModifier: -2},
+	34207: {TypeID: 34207, Name: "Process Decryptor", ProbabilityMultiplier: 1.1, RunModifier: 0, MEModifier: 3, TEModifier: 6},
+	34208: {TypeID: 34208, Name: "Symmetry Decryptor", ProbabilityMultiplier: 1.0, RunModifier: 2, MEModifier: 1, TEModifier: 8},
+}
+
+// InventionMaterial is one per-attempt invention input (datacore, decryptor).
+type InventionMaterial struct {
+	TypeID    int32   `json:"type_id"`
+	TypeName  string  `json:"type_name"`
+	Quantity  int32   `json:"quantity"` // Per attempt
+	UnitPrice float64 `json:"unit_price"`
+}
+
+// InventionStep is the invention job that supplies a T2 node's blueprint copies.
+type InventionStep struct {
+	T1BlueprintTypeID int32               `json:"t1_blueprint_type_id"`
+	T1BlueprintName   string              `json:"t1_blueprint_name"`
+	Datacores         []InventionMaterial `json:"datacores"`
+	Decryptor         *InventionMaterial  `json:"decryptor,omitempty"`
+	BaseProbability   float64             `json:"base_probability"` // From the SDE, before skills
+	Probability       float64             `json:"probability"`      // After decryptor, capped at 1
+	RunsPerBPC        int32               `json:"runs_per_bpc"`
+	ResultME          int32               `json:"result_me"`
+	ResultTE          int32               `json:"result_te"`
+	Time              int32               `json:"time"`              // Seconds per attempt
+	BPCsNeeded        int32               `json:"bpcs_needed"`       // Successful copies to cover the node's runs
+	ExpectedAttempts  float64             `json:"expected_attempts"` // BPCsNeeded / Probability
+
+	DatacoreCostPerAttempt  float64 `json:"datacore_cost_per_attempt"`
+	DecryptorCostPerAttempt float64 `json:"decryptor_cost_per_attempt"`
+	JobCostPerAttempt       float64 `json:"job_cost_per_attempt"`
+	DatacoreCostPerRun      float64 `json:"datacore_cost_per_run"` // Datacores per successful manufacturing run
+	CostPerRun              float64 `json:"cost_per_run"`          // All invention cost per successful run, excluding the T1 BPC
+	CostPerUnit             float64 `json:"cost_per_unit"`         // CostPerRun / product quantity
+	TotalCost               float64 `json:"total_cost"`            // CostPerRun × the node's runs, folded into its build cost
+}
+
+// planInvention builds the quantity side of the invention step for a T2
+// blueprint, or nil when bp is not invented or has no usable probability.
+// Costs are filled in later by costInvention.
+func (a *IndustryAnalyzer) planInvention(bp *sde.Blueprint, runsNeeded int32, params IndustryParams) *InventionStep {
+	src, product, ok := a.SDE.Industry.GetInventionSource(bp.BlueprintTypeID)
+	if !ok || product.Probability <= 0 {
+		return nil
+	}
+	inv := src.Activities["invention"]
+
+	step := &InventionStep{
+		T1BlueprintTypeID: src.BlueprintTypeID,
+		T1BlueprintName:   a.typeName(src.BlueprintTypeID),
+		BaseProbability:   product.Probability,
+		Probability:       product.Probability,
+		RunsPerBPC:        product.Quantity,
+		ResultME:          inventionBaseME,
+		ResultTE:          inventionBaseTE,
+		Time:              inv.Time,
+	}
+	for _, m := range inv.Materials {
+		step.Datacores = append(step.Datacores, InventionMaterial{
+			TypeID:   m.TypeID,
+			TypeName: a.typeName(m.TypeID),
+			Quantity: m.Quantity,
+		})
+	}
+	if d, ok := Decryptors[params.Decryptor]; ok {
+		step.Decryptor = &InventionMaterial{TypeID: d.TypeID, TypeName: d.Name, Quantity: 1}
+		step.Probability *= d.ProbabilityMultiplier
+		step.RunsPerBPC += d.RunModifier
+		step.ResultME += d.MEModifier
+		step.ResultTE += d.TEModifier
+	}
+	step.Probability = math.Min(step.Probability, 1)
+	if step.RunsPerBPC < 1 {
+		step.RunsPerBPC = 1
+	}
+	step.ResultME = clampInt32(step.ResultME, 0, 10)
+	step.ResultTE = clampInt32(step.ResultTE, 0, 20)
+
+	step.BPCsNeeded = (runsNeeded + step.RunsPerBPC - 1) / step.RunsPerBPC
+	step.ExpectedAttempts = float64(step.BPCsNeeded) / step.Probability
+	return step
+}
+
+// costInvention prices a planned invention step for node and returns the
+// cost to fold into the node's build cost. Runs left over on the last BPC are
+// not charged to this build. The T1 BPCs consumed are not charged either:
+// they are assumed to be copied from a BPO the builder owns, so neither the
+// copy job fee nor a market BPC price is included.
+func (a *IndustryAnalyzer) costInvention(node *MaterialNode, inventionCostIndex float64, params IndustryParams) float64 {
+	step := node.Invention
+	bp, ok := a.SDE.Industry.GetBlueprintForProduct(node.TypeID)
+	if step == nil || !ok {
+		return 0
+	}
+
+	step.DatacoreCostPerAttempt = 0
+	for i := range step.Datacores {
+		dc := &step.Datacores[i]
+		dc.UnitPrice = a.marketPrices[dc.TypeID]
+		step.DatacoreCostPerAttempt += dc.UnitPrice * float64(dc.Quantity)
+	}
+	step.DecryptorCostPerAttempt = 0
+	if step.Decryptor != nil {
+		step.Decryptor.UnitPrice = a.marketPrices[step.Decryptor.TypeID]
+		step.DecryptorCostPerAttempt = step.Decryptor.UnitPrice
+	}
+
+	// Invention job cost is based on one run of the product blueprint's EIV.
+	var productEIV float64
+	for _, mat := range bp.Materials {
+		productEIV += a.adjustedPrices[mat.TypeID] * float64(mat.Quantity)
+	}
+	step.JobCostPerAttempt = productEIV * inventionJobCostRate * inventionCostIndex * (1 + params.FacilityTax/100)
+
+	attemptCost := step.DatacoreCostPerAttempt + step.DecryptorCostPerAttempt + step.JobCostPerAttempt
+	perRun := 1 / (step.Probability * float64(step.RunsPerBPC))
+	step.DatacoreCostPerRun = step.DatacoreCostPerAttempt * perRun
+	step.CostPerRun = attemptCost * perRun
+	if bp.ProductQuantity > 0 {
+		step.CostPerUnit = step.CostPerRun / float64(bp.ProductQuantity)
+	}
+	step.TotalCost = step.CostPerRun * float64(a.nodeRuns(node, bp))
+	return step.TotalCost
+}
+
+func (a *IndustryAnalyzer) nodeRuns(node *MaterialNode, bp *sde.Blueprint) int32 {
+	runs := node.Quantity / bp.ProductQuantity
+	if node.Quantity%bp.ProductQuantity != 0 {
+		runs++
+	}
+	return runs
+}
+
+func (a *IndustryAnalyzer) typeName(typeID int32) string {
+	if t, ok := a.SDE.Types[typeID]; ok {
+		return t.Name
+	}
+	return ""
+}
+
+func clampInt32(v, lo, hi int32) int32 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
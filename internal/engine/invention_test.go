@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"testing"
+
+	"eve-flipper/internal/esi"
+	"eve-flipper/internal/sde"
+)
+
+func TestBuildMaterialTree_InventionWithDecryptor(t *testing.T) {
+	a := &IndustryAnalyzer{
+		SDE: newTestInventionSDE(),
+		marketPrices: map[int32]float64{
+			1000:  300,
+			34:    1,
+			20410: 100,
+			20411: 50,
+			34206: 1000,
+		},
+		adjustedPrices: map[int32]float64{
+			1000: 100,
+			34:   1,
+		},
+	}
+	params := IndustryParams{
+		MaxDepth:           1,
+		MaterialEfficiency: 10,
+		IncludeInvention:   true,
+		Decryptor:          34206, // Parity: ×1.5 chance, +3 runs, +1 ME, -2 TE
+	}
+
+	tree := a.buildMaterialTree(3000, 20, params, 0)
+	step := tree.Invention
+	if step == nil {
+		t.Fatalf("Invention = nil, want step for T2 blueprint")
+	}
+	if step.T1BlueprintTypeID != 2000 {
+		t.Fatalf("T1BlueprintTypeID = %d, want 2000", step.T1BlueprintTypeID)
+	}
+	if !industryAlmostEqual(step.Probability, 0.375) {
+		t.Fatalf("Probability = %v, want 0.375", step.Probability)
+	}
+	if step.RunsPerBPC != 13 || step.ResultME != 3 || step.ResultTE != 2 {
+		t.Fatalf("runs/ME/TE = %d/%d/%d, want 13/3/2", step.RunsPerBPC, step.ResultME, step.ResultTE)
+	}
+	if step.BPCsNeeded != 2 || !industryAlmostEqual(step.ExpectedAttempts, 2/0.375) {
+		t.Fatalf("BPCsNeeded = %d, ExpectedAttempts = %v; want 2, %v", step.BPCsNeeded, step.ExpectedAttempts, 2/0.375)
+	}
+	// Invented ME 3 replaces the requested ME 10: ceil(10 × 20 × 0.97) = 194.
+	if tree.Blueprint.ME != 3 {
+		t.Fatalf("Blueprint.ME = %d, want 3", tree.Blueprint.ME)
+	}
+	byType := map[int32]*MaterialNode{}
+	for _, child := range tree.Children {
+		byType[child.TypeID] = child
+	}
+	if byType[34] == nil || byType[34].Quantity != 194 {
+		t.Fatalf("tritanium child = %+v, want quantity 194", byType[34])
+	}
+
+	a.calculateCosts(tree, esi.SystemCostIndices{Invention: 0.1}, params)
+
+	// Per attempt: datacores 2×100 + 2×50, decryptor 1000, job 110 × 0.02 × 0.1.
+	attempt := 300 + 1000 + 0.22
+	perRun := attempt / (0.375 * 13)
+	if !industryAlmostEqual(step.JobCostPerAttempt, 0.22) {
+		t.Fatalf("JobCostPerAttempt = %v, want 0.22", step.JobCostPerAttempt)
+	}
+	if !industryAlmostEqual(step.DatacoreCostPerRun, 300/(0.375*13)) {
+		t.Fatalf("DatacoreCostPerRun = %v, want %v", step.DatacoreCostPerRun, 300/(0.375*13))
+	}
+	if !industryAlmostEqual(step.CostPerRun, perRun) || !industryAlmostEqual(step.CostPerUnit, perRun) {
+		t.Fatalf("CostPerRun = %v, CostPerUnit = %v; want %v", step.CostPerRun, step.CostPerUnit, perRun)
+	}
+	wantBuild := 20*300.0 + 194 + perRun*20
+	if !industryAlmostEqual(tree.BuildCost, wantBuild) {
+		t.Fatalf("BuildCost = %v, want %v", tree.BuildCost, wantBuild)
+	}
+}
+
+func TestBuildMaterialTree_InventionDisabled(t *testing.T) {
+	a := &IndustryAnalyzer{SDE: newTestInventionSDE()}
+
+	tree := a.buildMaterialTree(3000, 1, IndustryParams{MaxDepth: 1, MaterialEfficiency: 10}, 0)
+	if tree.Invention != nil {
+		t.Fatalf("Invention = %+v, want nil without IncludeInvention", tree.Invention)
+	}
+	if tree.Blueprint.ME != 10 {
+		t.Fatalf("Blueprint.ME = %d, want requested 10", tree.Blueprint.ME)
+	}
+
+	// T1 items have no invention source even when invention is requested.
+	t1 := a.buildMaterialTree(1000, 1, IndustryParams{MaxDepth: 1, IncludeInvention: true}, 0)
+	if t1.Invention != nil {
+		t.Fatalf("T1 Invention = %+v, want nil", t1.Invention)
+	}
+}
+
+func newTestInventionSDE() *sde.Data {
+	data := newTestIndustrySDE()
+	ind := data.Industry
+
+	ind.Blueprints[2000].Activities = map[string]*sde.ActivityData{
+		"invention": {
+			Time: 3000,
+			Materials: []sde.BlueprintMaterial{
+				{TypeID: 20410, Quantity: 2},
+				{TypeID: 20411, Quantity: 2},
+			},
+			Products: []sde.BlueprintProduct{
+				{TypeID: 4000, Quantity: 10, Probability: 0.25},
+			},
+		},
+	}
+	ind.InventedFrom[4000] = 2000
+
+	ind.Blueprints[4000] = &sde.Blueprint{
+		BlueprintTypeID: 4000,
+		ProductTypeID:   3000,
+		ProductQuantity: 1,
+		Time:            7200,
+		Materials: []sde.BlueprintMaterial{
+			{TypeID: 1000, Quantity: 1},
+			{TypeID: 34, Quantity: 10},
+		},
+	}
+	ind.ProductToBlueprint[3000] = 4000
+
+	data.Types[3000] = &sde.ItemType{ID: 3000, Name: "T2 Item", Volume: 5}
+	data.Types[20410] = &sde.ItemType{ID: 20410, Name: "Datacore A"}
+	data.Types[20411] = &sde.ItemType{ID: 20411, Name: "Datacore B"}
+	return data
+}
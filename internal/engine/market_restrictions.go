@@ -1,5 +1,11 @@
 package engine
 
+import (
+	"sort"
+
+	"eve-flipper/internal/esi"
+)
+
 // marketDisabledTypeIDs lists item types that may appear in ESI market data
 // but are not practically tradable via normal sell-side execution.
 // Keep this list conservative: only hard-verified market-disabled types.
@@ -9,11 +15,27 @@ var marketDisabledTypeIDs = map[int32]struct{}{
 
 const playerStructureLocationIDMin int64 = 1_000_000_000_000
 
+// maxPlayerOrderDurationDays is the longest duration a player can pick for a
+// market order. NPC-seeded sell orders (skillbooks, faction BPCs etc.) are
+// published with 365-day durations, which is the only stable marker ESI gives.
+const maxPlayerOrderDurationDays int32 = 90
+
 func isMarketDisabledType(typeID int32) bool {
 	_, blocked := marketDisabledTypeIDs[typeID]
 	return blocked
 }
 
+// MarketDisabledTypeIDs returns the built-in market-disabled list ordered by
+// type ID.
+func MarketDisabledTypeIDs() []int32 {
+	out := make([]int32, 0, len(marketDisabledTypeIDs))
+	for id := range marketDisabledTypeIDs {
+		out = append(out, id)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
 // isPlayerStructureLocationID reports whether a market location id belongs to an Upwell structure.
 func isPlayerStructureLocationID(locationID int64) bool {
 	return locationID > playerStructureLocationIDMin
@@ -29,3 +51,30 @@ func IsMarketDisabledTypeID(typeID int32) bool {
 func IsPlayerStructureLocationID(locationID int64) bool {
 	return isPlayerStructureLocationID(locationID)
 }
+
+// IsNPCSeededOrder reports whether an order looks NPC-seeded: a sell order in
+// an NPC station with a duration no player can set. Such orders have
+// effectively unlimited depth and should not be treated as player supply.
+func IsNPCSeededOrder(o esi.MarketOrder) bool {
+	if o.IsBuyOrder || isPlayerStructureLocationID(o.LocationID) {
+		return false
+	}
+	return o.Duration > maxPlayerOrderDurationDays
+}
+
+// excludedTypes resolves user exclusions (explicit types plus every type under
+// the given market groups) to a lookup set. Returns nil when nothing is
+// excluded; reading a nil map is safe, so callers index it unconditionally.
+func (s *Scanner) excludedTypes(typeIDs, marketGroupIDs []int32) map[int32]bool {
+	if len(typeIDs) == 0 && len(marketGroupIDs) == 0 {
+		return nil
+	}
+	out := make(map[int32]bool, len(typeIDs))
+	if len(marketGroupIDs) > 0 && s.SDE != nil {
+		out = s.SDE.TypesInMarketGroups(marketGroupIDs)
+	}
+	for _, id := range typeIDs {
+		out[id] = true
+	}
+	return out
+}
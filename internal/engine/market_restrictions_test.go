@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"testing"
+
+	"eve-flipper/internal/esi"
+	"eve-flipper/internal/sde"
+)
+
+func TestIsNPCSeededOrder(t *testing.T) {
+	cases := []struct {
+		name  string
+		order esi.MarketOrder
+		want  bool
+	}{
+		{"npc sell in station", esi.MarketOrder{LocationID: 60003760, Duration: 365}, true},
+		{"player sell max duration", esi.MarketOrder{LocationID: 60003760, Duration: 90}, false},
+		{"buy order long duration", esi.MarketOrder{LocationID: 60003760, Duration: 365, IsBuyOrder: true}, false},
+		{"structure long duration", esi.MarketOrder{LocationID: 1_035_466_617_946, Duration: 365}, false},
+		{"unknown duration", esi.MarketOrder{LocationID: 60003760}, false},
+	}
+	for _, tc := range cases {
+		if got := IsNPCSeededOrder(tc.order); got != tc.want {
+			t.Errorf("%s: IsNPCSeededOrder = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestExcludedTypes(t *testing.T) {
+	s := &Scanner{SDE: &sde.Data{
+		MarketGroups: map[int32]*sde.MarketGroup{
+			1:  {ID: 1, Name: "Special Edition Assets"},
+			2:  {ID: 2, Name: "Skill Trading", ParentID: 1},
+			10: {ID: 10, Name: "Minerals"},
+		},
+		Types: map[int32]*sde.ItemType{
+			34:    {ID: 34, Name: "Tritanium", MarketGroupID: 10},
+			40519: {ID: 40519, Name: "Skill Extractor", MarketGroupID: 2},
+			40520: {ID: 40520, Name: "Large Skill Injector", MarketGroupID: 2},
+		},
+	}}
+
+	if got := s.excludedTypes(nil, nil); got != nil {
+		t.Fatalf("no exclusions = %v, want nil", got)
+	}
+	got := s.excludedTypes([]int32{44992}, []int32{1})
+	for _, id := range []int32{44992, 40519, 40520} {
+		if !got[id] {
+			t.Errorf("type %d should be excluded", id)
+		}
+	}
+	if got[34] {
+		t.Error("Tritanium is outside the excluded market group")
+	}
+}
+
+func TestMarketDisabledTypeIDs(t *testing.T) {
+	list := MarketDisabledTypeIDs()
+	found := false
+	for i, id := range list {
+		if i > 0 && list[i-1] >= id {
+			t.Fatalf("list not ordered by type ID: %v", list)
+		}
+		if id == MPTCTypeID {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("MPTC missing from built-in list")
+	}
+}
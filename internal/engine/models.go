@@ -1,5 +1,16 @@
 package engine
 
+import "context"
+
+// Currency is the unit of every monetary field in engine results. Amounts are
+// emitted as unrounded numbers; clients localize them for display.
+const Currency = "ISK"
+
+// EngineVersion identifies the scoring logic that produced a stored scan.
+// Bump it whenever profit, fee, CTS or ranking math changes, so results saved
+// before and after the change can be told apart.
+const EngineVersion = "1"
+
 // FlipResult represents a single profitable flip opportunity (buy low at one station, sell high at another).
 type FlipResult struct {
 	TypeID          int32
@@ -42,6 +53,19 @@ type FlipResult struct {
 	BuyCompetitors  int     `json:"BuyCompetitors"`
 	SellCompetitors int     `json:"SellCompetitors"`
 	DailyProfit     float64 `json:"DailyProfit"` // ProfitPerUnit * min(UnitsToBuy, DailyVolume)
+	// Per-unit profit of dumping into the destination's best buy order
+	// (BestBidPrice) net of sales tax only, next to ProfitPerUnit which
+	// also charges the sell-side broker fee of a listing.
+	InstantSellProfit float64 `json:"InstantSellProfit"`
+	// Hauling efficiency of the depth-aware profit: per m3 of cargo moved and
+	// per jump of the route (see setHaulingEfficiency).
+	ISKPerM3   float64 `json:"ISKPerM3"`
+	ISKPerJump float64 `json:"ISKPerJump"`
+	// Units of the type that fit in ScanParams.CargoCapacity. UnitsToBuy and
+	// the execution-plan profits never exceed it; CargoLimited is set when
+	// the hold, not book depth or budget, is what bound the trade.
+	CargoLimitedQty int32 `json:"CargoLimitedQty"`
+	CargoLimited    bool  `json:"CargoLimited"`
 	// Sell-book supply at the destination market scope for this type.
 	// Populated from live destination sell orders (station/system fallback).
 	TargetSellSupply int64 `json:"TargetSellSupply,omitempty"`
@@ -61,6 +85,8 @@ type FlipResult struct {
 	CanFill           bool    `json:"CanFill"`              // true when requested quantity is executable profitably
 	SlippageBuyPct    float64 `json:"SlippageBuyPct,omitempty"`
 	SlippageSellPct   float64 `json:"SlippageSellPct,omitempty"`
+	// Per-unit fee components behind ProfitPerUnit (top-of-book prices).
+	FeeBreakdown *FeeBreakdown `json:"fee_breakdown,omitempty"`
 
 	// Regional day-trader enrichments (EVE Guru-style grouped region view).
 	DaySecurity           float64   `json:"DaySecurity,omitempty"`
@@ -91,6 +117,7 @@ type FlipResult struct {
 // ContractResult represents a profitable public contract compared to market value.
 type ContractResult struct {
 	ContractID            int32
+	ContractType          string `json:"ContractType,omitempty"` // item_exchange or auction
 	Title                 string
 	Price                 float64 // contract asking price
 	MarketValue           float64 // sum of market prices for all items
@@ -144,6 +171,18 @@ type RouteResult struct {
 	HopCount         int
 	TargetSystemName string `json:"TargetSystemName,omitempty"` // optional trip destination constraint
 	TargetJumps      int    `json:"TargetJumps,omitempty"`      // deadhead jumps from final trade to target
+	// Round-trip mode: the outbound leg is Hops; ReturnHops haul cargo back
+	// toward the origin. TotalProfit/TotalJumps cover both legs.
+	OutboundProfit float64    `json:"OutboundProfit,omitempty"`
+	OutboundJumps  int        `json:"OutboundJumps,omitempty"`
+	ReturnHops     []RouteHop `json:"ReturnHops,omitempty"`
+	ReturnProfit   float64    `json:"ReturnProfit,omitempty"`
+	ReturnJumps    int        `json:"ReturnJumps,omitempty"` // return trade jumps + deadhead to origin
+	// LowsecJumps counts jumps into systems below 0.5 security over the whole
+	// trip (only when a lowsec penalty is set). RiskAdjustedProfit is
+	// TotalProfit minus the penalty for each of them and orders the results.
+	LowsecJumps        int `json:"LowsecJumps,omitempty"`
+	RiskAdjustedProfit float64
 }
 
 // RouteParams holds the input parameters for multi-hop route search.
@@ -167,6 +206,14 @@ type RouteParams struct {
 	MinRouteSecurity     float64 // 0 = all space; 0.45 = highsec only; 0.7 = min 0.7
 	AllowEmptyHops       bool    // allow empty travel legs between trade hops
 	IncludeStructures    bool    // true = allow Upwell structure orders; false = NPC stations only
+	RoundTrip            bool    // also search a profitable return leg back toward the origin
+	// SecurityPenaltyPerLowsecJump (ISK) is charged against route profit for
+	// every jump into a system below 0.5 security, trading risk against
+	// profit instead of excluding the route. MinRouteSecurity stays a floor.
+	SecurityPenaltyPerLowsecJump float64
+	// User exclusions, applied on top of the market-disabled list.
+	ExcludeTypeIDs        []int32
+	ExcludeMarketGroupIDs []int32 // includes every descendant market group
 }
 
 // ScanParams holds the input parameters for radius and region scans.
@@ -230,4 +277,27 @@ type ScanParams struct {
 	ContractHoldDays           int     // Non-instant mode: hold horizon in days (0 = default)
 	ContractTargetConfidence   float64 // Non-instant mode: minimum full-liquidation probability in % (0 = default)
 	ExcludeRigsWithShip        bool    // If true, exclude rig pricing when contract contains a ship
+	// ContractTypes limits which public contracts are evaluated (see
+	// NormalizeContractTypes); empty = item exchanges only.
+	ContractTypes []string
+	// ExcludeIssuerIDs skips contracts whose issuing character or
+	// corporation is listed.
+	ExcludeIssuerIDs []int64
+
+	// ExcludeNPCOrders drops NPC-seeded orders (see IsNPCSeededOrder) before profit math.
+	ExcludeNPCOrders bool
+
+	// User exclusions, applied on top of the market-disabled list. Contracts
+	// containing an excluded type are skipped.
+	ExcludeTypeIDs        []int32
+	ExcludeMarketGroupIDs []int32 // includes every descendant market group
+
+	// Ctx allows cooperative cancellation of flip scans; nil never cancels.
+	Ctx context.Context `json:"-"`
+
+	// OnBatch, when set, receives provisional flip rows in batches of
+	// ResultBatchSize once prices and station names are known, before history
+	// enrichment and the volume post-filters. Later stages may still fill in
+	// or drop rows, so the slice Scan returns stays authoritative.
+	OnBatch func([]FlipResult) `json:"-"`
 }
@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"sort"
+
+	"eve-flipper/internal/esi"
+	"eve-flipper/internal/sde"
+)
+
+// NetWorthCharacterInput is everything fetched for one character. Components
+// that could not be fetched are left empty and simply contribute nothing.
+type NetWorthCharacterInput struct {
+	CharacterID   int64
+	CharacterName string
+	WalletISK     float64
+	Orders        []esi.CharacterOrder
+	Assets        []esi.CharacterAsset
+}
+
+// NetWorthCategory is the appraised value of assets in one SDE category.
+type NetWorthCategory struct {
+	CategoryID   int32   `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	ValueISK     float64 `json:"value_isk"`
+	VolumeM3     float64 `json:"volume_m3"` // packaged
+	TypeCount    int     `json:"type_count"`
+}
+
+// NetWorthCharacter is one character's share of the total.
+type NetWorthCharacter struct {
+	CharacterID   int64   `json:"character_id"`
+	CharacterName string  `json:"character_name"`
+	WalletISK     float64 `json:"wallet_isk"`
+	BuyEscrowISK  float64 `json:"buy_escrow_isk"`
+	SellOrdersISK float64 `json:"sell_orders_isk"`
+	AssetsISK     float64 `json:"assets_isk"`
+	TotalISK      float64 `json:"total_isk"`
+}
+
+// NetWorth sums liquid ISK, ISK and goods tied up in market orders, and the
+// appraised value of assets across characters.
+type NetWorth struct {
+	TotalISK      float64             `json:"total_isk"`
+	WalletISK     float64             `json:"wallet_isk"`
+	BuyEscrowISK  float64             `json:"buy_escrow_isk"`
+	SellOrdersISK float64             `json:"sell_orders_isk"`
+	AssetsISK     float64             `json:"assets_isk"`
+	Categories    []NetWorthCategory  `json:"categories"` // asset value by category, largest first
+	Characters    []NetWorthCharacter `json:"characters"`
+	UnpricedTypes int                 `json:"unpriced_types"`
+}
+
+// NetWorthAssetTypeIDs returns the distinct type IDs that ComputeNetWorth
+// would appraise, so callers can fetch prices for exactly those.
+func NetWorthAssetTypeIDs(chars []NetWorthCharacterInput) []int32 {
+	seen := make(map[int32]bool)
+	var ids []int32
+	for _, c := range chars {
+		for _, a := range c.Assets {
+			if a.TypeID <= 0 || a.IsBlueprintCopy || seen[a.TypeID] {
+				continue
+			}
+			seen[a.TypeID] = true
+			ids = append(ids, a.TypeID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// ComputeNetWorth values each character's wallet, buy order escrow, remaining
+// sell order volume at its listed price, and assets at prices[typeID].
+// Blueprint copies have no market price and are skipped; asset types missing
+// from prices are counted in UnpricedTypes and valued at zero.
+func ComputeNetWorth(sdeData *sde.Data, chars []NetWorthCharacterInput, prices map[int32]float64) NetWorth {
+	out := NetWorth{
+		Categories: []NetWorthCategory{},
+		Characters: make([]NetWorthCharacter, 0, len(chars)),
+	}
+	categories := make(map[int32]*NetWorthCategory)
+	categoryTypes := make(map[int32]map[int32]bool)
+	unpriced := make(map[int32]bool)
+
+	for _, c := range chars {
+		row := NetWorthCharacter{
+			CharacterID:   c.CharacterID,
+			CharacterName: c.CharacterName,
+			WalletISK:     c.WalletISK,
+		}
+		for _, o := range c.Orders {
+			if o.IsBuyOrder {
+				row.BuyEscrowISK += o.Escrow
+			} else {
+				row.SellOrdersISK += o.Price * float64(o.VolumeRemain)
+			}
+		}
+		for _, a := range c.Assets {
+			if a.TypeID <= 0 || a.IsBlueprintCopy {
+				continue
+			}
+			quantity := a.Quantity
+			if quantity <= 0 {
+				quantity = 1
+			}
+			var categoryID int32
+			var volume float64
+			if sdeData != nil {
+				if t, ok := sdeData.Types[a.TypeID]; ok {
+					categoryID = t.CategoryID
+					volume = t.Volume
+				}
+			}
+			cat := categories[categoryID]
+			if cat == nil {
+				cat = &NetWorthCategory{CategoryID: categoryID, CategoryName: "Unknown"}
+				if sdeData != nil {
+					if sc, ok := sdeData.Categories[categoryID]; ok {
+						cat.CategoryName = sc.Name
+					}
+				}
+				categories[categoryID] = cat
+				categoryTypes[categoryID] = make(map[int32]bool)
+			}
+			categoryTypes[categoryID][a.TypeID] = true
+			cat.VolumeM3 += volume * float64(quantity)
+
+			price, ok := prices[a.TypeID]
+			if !ok || price <= 0 {
+				unpriced[a.TypeID] = true
+				continue
+			}
+			value := price * float64(quantity)
+			cat.ValueISK += value
+			row.AssetsISK += value
+		}
+		row.TotalISK = row.WalletISK + row.BuyEscrowISK + row.SellOrdersISK + row.AssetsISK
+
+		out.WalletISK += row.WalletISK
+		out.BuyEscrowISK += row.BuyEscrowISK
+		out.SellOrdersISK += row.SellOrdersISK
+		out.AssetsISK += row.AssetsISK
+		out.TotalISK += row.TotalISK
+		out.Characters = append(out.Characters, row)
+	}
+
+	for id, cat := range categories {
+		cat.TypeCount = len(categoryTypes[id])
+		out.Categories = append(out.Categories, *cat)
+	}
+	sort.Slice(out.Categories, func(i, j int) bool {
+		if out.Categories[i].ValueISK != out.Categories[j].ValueISK {
+			return out.Categories[i].ValueISK > out.Categories[j].ValueISK
+		}
+		return out.Categories[i].CategoryID < out.Categories[j].CategoryID
+	})
+	sort.Slice(out.Characters, func(i, j int) bool {
+		return out.Characters[i].TotalISK > out.Characters[j].TotalISK
+	})
+	out.UnpricedTypes = len(unpriced)
+	return out
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"eve-flipper/internal/esi"
+	"eve-flipper/internal/sde"
+)
+
+func TestComputeNetWorth(t *testing.T) {
+	data := &sde.Data{
+		Types: map[int32]*sde.ItemType{
+			34:  {ID: 34, Name: "Tritanium", Volume: 0.01, CategoryID: 4},
+			587: {ID: 587, Name: "Rifter", Volume: 2500, CategoryID: 6},
+			691: {ID: 691, Name: "Rifter Blueprint", Volume: 0.01, CategoryID: 9},
+		},
+		Categories: map[int32]*sde.ItemCategory{
+			4: {ID: 4, Name: "Material"},
+			6: {ID: 6, Name: "Ship"},
+		},
+	}
+	chars := []NetWorthCharacterInput{
+		{
+			CharacterID: 1, CharacterName: "Trader", WalletISK: 1_000_000,
+			Orders: []esi.CharacterOrder{
+				{TypeID: 34, IsBuyOrder: true, Price: 4, VolumeRemain: 1000, Escrow: 2_500},
+				{TypeID: 587, Price: 600_000, VolumeRemain: 2},
+			},
+			Assets: []esi.CharacterAsset{
+				{ItemID: 1, TypeID: 34, Quantity: 10_000},
+				{ItemID: 2, TypeID: 587, Quantity: 1, IsSingleton: true},
+				{ItemID: 3, TypeID: 691, Quantity: -2, IsBlueprintCopy: true},
+			},
+		},
+		{
+			CharacterID: 2, CharacterName: "Alt", WalletISK: 50,
+			Assets: []esi.CharacterAsset{
+				{ItemID: 4, TypeID: 34, Quantity: 5_000},
+				{ItemID: 5, TypeID: 99999, Quantity: 1},
+			},
+		},
+	}
+	prices := map[int32]float64{34: 5, 587: 550_000}
+
+	if ids := NetWorthAssetTypeIDs(chars); len(ids) != 3 || ids[0] != 34 || ids[1] != 587 || ids[2] != 99999 {
+		t.Fatalf("asset type ids = %v, want [34 587 99999] (BPC skipped)", ids)
+	}
+
+	nw := ComputeNetWorth(data, chars, prices)
+	if nw.WalletISK != 1_000_050 || nw.BuyEscrowISK != 2_500 || nw.SellOrdersISK != 1_200_000 {
+		t.Fatalf("wallet/escrow/sell = %v/%v/%v", nw.WalletISK, nw.BuyEscrowISK, nw.SellOrdersISK)
+	}
+	if nw.AssetsISK != 625_000 {
+		t.Fatalf("assets = %v, want 625000", nw.AssetsISK)
+	}
+	if want := 1_000_050.0 + 2_500 + 1_200_000 + 625_000; nw.TotalISK != want {
+		t.Fatalf("total = %v, want %v", nw.TotalISK, want)
+	}
+	if nw.UnpricedTypes != 1 {
+		t.Fatalf("unpriced = %d, want 1", nw.UnpricedTypes)
+	}
+	if len(nw.Categories) != 3 {
+		t.Fatalf("categories = %+v, want ship, material, unknown", nw.Categories)
+	}
+	ship, mat, unknown := nw.Categories[0], nw.Categories[1], nw.Categories[2]
+	if ship.CategoryName != "Ship" || ship.ValueISK != 550_000 || ship.VolumeM3 != 2500 {
+		t.Fatalf("ship category = %+v", ship)
+	}
+	if mat.CategoryName != "Material" || mat.ValueISK != 75_000 || mat.TypeCount != 1 || math.Abs(mat.VolumeM3-150) > 1e-9 {
+		t.Fatalf("material category = %+v", mat)
+	}
+	if unknown.CategoryID != 0 || unknown.CategoryName != "Unknown" || unknown.ValueISK != 0 {
+		t.Fatalf("unknown category = %+v", unknown)
+	}
+	if len(nw.Characters) != 2 || nw.Characters[0].CharacterID != 1 || nw.Characters[1].TotalISK != 25_050 {
+		t.Fatalf("characters = %+v", nw.Characters)
+	}
+}
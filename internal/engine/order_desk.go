@@ -16,20 +16,32 @@ func NewOrderDeskHistoryKey(regionID, typeID int32) OrderDeskHistoryKey {
 	return OrderDeskHistoryKey{regionID, typeID}
 }
 
+// DefaultWarnExpiryDays is how close to expiry an order must be before the
+// order desk (and the expiry reminder) flags it.
+const DefaultWarnExpiryDays = 2
+
 // OrderDeskOptions controls recommendation and economics assumptions.
 type OrderDeskOptions struct {
 	SalesTaxPercent  float64
 	BrokerFeePercent float64
 	TargetETADays    float64
 	WarnExpiryDays   int
+	// Where each fee came from ("request", "skills", "config" or "default")
+	// and, when skills were used, what they implied. Echoed only.
+	SalesTaxSource  string
+	BrokerFeeSource string
+	SkillFees       *TradeFees
 }
 
 // OrderDeskSettings are echoed in the response.
 type OrderDeskSettings struct {
-	SalesTaxPercent  float64 `json:"sales_tax_percent"`
-	BrokerFeePercent float64 `json:"broker_fee_percent"`
-	TargetETADays    float64 `json:"target_eta_days"`
-	WarnExpiryDays   int     `json:"warn_expiry_days"`
+	SalesTaxPercent  float64    `json:"sales_tax_percent"`
+	BrokerFeePercent float64    `json:"broker_fee_percent"`
+	TargetETADays    float64    `json:"target_eta_days"`
+	WarnExpiryDays   int        `json:"warn_expiry_days"`
+	SalesTaxSource   string     `json:"sales_tax_source,omitempty"`
+	BrokerFeeSource  string     `json:"broker_fee_source,omitempty"`
+	SkillFees        *TradeFees `json:"skill_fees,omitempty"`
 }
 
 // OrderDeskSummary aggregates order health for quick triage.
@@ -85,6 +97,7 @@ type OrderDeskResponse struct {
 	Summary  OrderDeskSummary  `json:"summary"`
 	Orders   []OrderDeskOrder  `json:"orders"`
 	Settings OrderDeskSettings `json:"settings"`
+	Currency string            `json:"currency"`
 }
 
 func normalizeOrderDeskOptions(opt OrderDeskOptions) OrderDeskOptions {
@@ -104,7 +117,7 @@ func normalizeOrderDeskOptions(opt OrderDeskOptions) OrderDeskOptions {
 		opt.TargetETADays = 3
 	}
 	if opt.WarnExpiryDays <= 0 {
-		opt.WarnExpiryDays = 2
+		opt.WarnExpiryDays = DefaultWarnExpiryDays
 	}
 	return opt
 }
@@ -121,12 +134,16 @@ func ComputeOrderDesk(
 	opt = normalizeOrderDeskOptions(opt)
 
 	out := OrderDeskResponse{
-		Orders: []OrderDeskOrder{},
+		Orders:   []OrderDeskOrder{},
+		Currency: Currency,
 		Settings: OrderDeskSettings{
 			SalesTaxPercent:  opt.SalesTaxPercent,
 			BrokerFeePercent: opt.BrokerFeePercent,
 			TargetETADays:    opt.TargetETADays,
 			WarnExpiryDays:   opt.WarnExpiryDays,
+			SalesTaxSource:   opt.SalesTaxSource,
+			BrokerFeeSource:  opt.BrokerFeeSource,
+			SkillFees:        opt.SkillFees,
 		},
 	}
 	if len(playerOrders) == 0 {
@@ -179,13 +196,9 @@ func ComputeOrderDesk(
 		}
 		row.NetNotional = row.NetUnitISK * float64(po.VolumeRemain)
 
-		if issuedAt, err := time.Parse(time.RFC3339, po.Issued); err == nil {
-			expAt := issuedAt.AddDate(0, 0, po.Duration)
+		if expAt, days, ok := OrderExpiry(po, now); ok {
 			row.ExpiresAt = expAt.Format(time.RFC3339)
-			row.DaysToExpire = int(math.Ceil(expAt.Sub(now).Hours() / 24.0))
-			if row.DaysToExpire < 0 {
-				row.DaysToExpire = 0
-			}
+			row.DaysToExpire = days
 		}
 
 		hk := NewOrderDeskHistoryKey(po.RegionID, po.TypeID)
@@ -286,8 +299,8 @@ func ComputeOrderDesk(
 
 		row.AvgDailyVolume = orderDeskAvgDailyVolume(historyByKey[hk], 7)
 		row.EstimatedFillPerDay = row.AvgDailyVolume
-		if row.EstimatedFillPerDay > 0 && row.VolumeRemain > 0 {
-			row.ETADays = (float64(row.QueueAheadQty) + float64(row.VolumeRemain)) / row.EstimatedFillPerDay
+		if eta := orderETADays(row.QueueAheadQty, int64(row.VolumeRemain), row.EstimatedFillPerDay); eta >= 0 {
+			row.ETADays = eta
 			etaKnown = append(etaKnown, row.ETADays)
 		}
 
@@ -428,6 +441,17 @@ func orderDeskActionPriority(action string) int {
 	}
 }
 
+// orderETADays estimates how many days an order of volumeRemain units takes
+// to fill when queueAhead units sit in front of it and the market trades
+// fillPerDay units a day. It returns -1 when there is nothing to fill or no
+// flow to fill it.
+func orderETADays(queueAhead, volumeRemain int64, fillPerDay float64) float64 {
+	if fillPerDay <= 0 || volumeRemain <= 0 {
+		return -1
+	}
+	return (float64(max(queueAhead, 0)) + float64(volumeRemain)) / fillPerDay
+}
+
 func orderDeskMedian(values []float64) float64 {
 	if len(values) == 0 {
 		return 0
@@ -441,3 +465,19 @@ func orderDeskMedian(values []float64) float64 {
 	}
 	return (s[n/2-1] + s[n/2]) / 2
 }
+
+// OrderExpiry returns when a character order expires and the whole days left
+// until then (rounded up, never negative). ok is false when the issue date
+// cannot be parsed.
+func OrderExpiry(o esi.CharacterOrder, now time.Time) (expiresAt time.Time, daysLeft int, ok bool) {
+	issuedAt, err := time.Parse(time.RFC3339, o.Issued)
+	if err != nil {
+		return time.Time{}, -1, false
+	}
+	expiresAt = issuedAt.AddDate(0, 0, o.Duration)
+	daysLeft = int(math.Ceil(expiresAt.Sub(now).Hours() / 24.0))
+	if daysLeft < 0 {
+		daysLeft = 0
+	}
+	return expiresAt, daysLeft, true
+}
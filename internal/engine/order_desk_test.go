@@ -72,6 +72,9 @@ func TestComputeOrderDesk_QueueEtaAndReprice(t *testing.T) {
 	if got.Summary.NeedsReprice != 1 {
 		t.Fatalf("summary needs_reprice = %d, want 1", got.Summary.NeedsReprice)
 	}
+	if got.Currency != "ISK" {
+		t.Fatalf("currency = %q, want ISK", got.Currency)
+	}
 }
 
 func TestComputeOrderDesk_UnknownLiquidityCancelNearExpiry(t *testing.T) {
@@ -197,3 +200,60 @@ func TestComputeOrderDesk_UnavailableBookDoesNotAssumeTop(t *testing.T) {
 		t.Fatalf("recommendation = %q, want hold", row.Recommendation)
 	}
 }
+
+func TestComputeOrderDesk_EchoesFeeSources(t *testing.T) {
+	fees := EffectiveTradeFees(&esi.SkillSheet{Skills: []esi.SkillEntry{{SkillID: skillAccounting, ActiveLevel: 5}}})
+	out := ComputeOrderDesk(nil, nil, nil, nil, OrderDeskOptions{
+		SalesTaxPercent:  fees.SalesTaxPercent,
+		BrokerFeePercent: 2,
+		SalesTaxSource:   "skills",
+		BrokerFeeSource:  "request",
+		SkillFees:        &fees,
+	})
+	st := out.Settings
+	if st.SalesTaxPercent != 3.6 || st.SalesTaxSource != "skills" || st.BrokerFeePercent != 2 || st.BrokerFeeSource != "request" {
+		t.Fatalf("settings = %+v", st)
+	}
+	if st.SkillFees == nil || st.SkillFees.AccountingLevel != 5 {
+		t.Fatalf("skill_fees = %+v, want Accounting 5", st.SkillFees)
+	}
+}
+
+func TestOrderETADays(t *testing.T) {
+	if got := orderETADays(50, 100, 30); math.Abs(got-5) > 1e-9 {
+		t.Fatalf("eta = %v, want 5", got)
+	}
+	if got := orderETADays(-10, 100, 50); math.Abs(got-2) > 1e-9 {
+		t.Fatalf("negative queue: eta = %v, want 2", got)
+	}
+	if got := orderETADays(0, 100, 0); got != -1 {
+		t.Fatalf("no flow: eta = %v, want -1", got)
+	}
+	if got := orderETADays(10, 0, 50); got != -1 {
+		t.Fatalf("nothing to fill: eta = %v, want -1", got)
+	}
+}
+
+func TestOrderExpiry(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	o := esi.CharacterOrder{Duration: 90, Issued: now.AddDate(0, 0, -88).Add(-time.Hour).Format(time.RFC3339)}
+
+	expAt, days, ok := OrderExpiry(o, now)
+	if !ok {
+		t.Fatal("expected ok")
+	}
+	if want := now.AddDate(0, 0, 2).Add(-time.Hour); !expAt.Equal(want) {
+		t.Fatalf("expiresAt = %v, want %v", expAt, want)
+	}
+	if days != 2 {
+		t.Fatalf("daysLeft = %d, want 2 (partial days round up)", days)
+	}
+
+	o.Issued = now.AddDate(0, 0, -100).Format(time.RFC3339)
+	if _, days, _ := OrderExpiry(o, now); days != 0 {
+		t.Fatalf("expired order daysLeft = %d, want 0", days)
+	}
+	if _, _, ok := OrderExpiry(esi.CharacterOrder{Issued: "not a date"}, now); ok {
+		t.Fatal("expected !ok for unparseable issue date")
+	}
+}
@@ -82,6 +82,8 @@ const MaxHistoryPoints = 90
 
 // PLEXDashboard is the top-level response for GET /api/plex/dashboard.
 type PLEXDashboard struct {
+	Currency string `json:"currency"`
+
 	// Global PLEX market price (since July 2025, PLEX is a global market)
 	PLEXPrice PLEXGlobalPrice `json:"plex_price"`
 
@@ -117,6 +119,9 @@ type PLEXDashboard struct {
 
 	// Cross-hub arbitrage opportunities
 	CrossHub []CrossHubArbitrage `json:"cross_hub,omitempty"`
+
+	// PLEX quotes per trade hub region and hub-to-hub spreads
+	HubMatrix *PLEXHubMatrix `json:"hub_matrix,omitempty"`
 }
 
 // OmegaComparison compares PLEX-based Omega cost vs real-money cost.
@@ -709,6 +714,7 @@ func ComputePLEXDashboard(
 	}
 
 	return PLEXDashboard{
+		Currency:        Currency,
 		PLEXPrice:       globalPrice,
 		Arbitrage:       arbitrage,
 		SPFarm:          spFarm,
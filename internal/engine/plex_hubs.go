@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"sort"
+
+	"eve-flipper/internal/esi"
+)
+
+// PLEXHubRegions are the trade hub regions compared in the PLEX hub matrix.
+var PLEXHubRegions = []struct {
+	RegionID int32
+	Name     string
+}{
+	{10000002, "Jita"},    // The Forge
+	{10000043, "Amarr"},   // Domain
+	{10000032, "Dodixie"}, // Sinq Laison
+	{10000030, "Rens"},    // Heimatar
+	{10000042, "Hek"},     // Metropolis
+}
+
+// PLEXHubPrice is the best PLEX quote in one hub region.
+type PLEXHubPrice struct {
+	Hub        string  `json:"hub"`
+	RegionID   int32   `json:"region_id"`
+	BuyPrice   float64 `json:"buy_price"`  // highest buy order
+	SellPrice  float64 `json:"sell_price"` // lowest sell order
+	BuyOrders  int     `json:"buy_orders"`
+	SellOrders int     `json:"sell_orders"`
+}
+
+// PLEXHubSpread is buying PLEX in one hub and selling it in another.
+type PLEXHubSpread struct {
+	BuyHub    string  `json:"buy_hub"`
+	SellHub   string  `json:"sell_hub"`
+	BuyPrice  float64 `json:"buy_price"`  // best sell order at BuyHub
+	SellPrice float64 `json:"sell_price"` // best buy order at SellHub
+	// Instant: fill the SellHub buy order, sales tax only.
+	InstantProfitISK float64 `json:"instant_profit_isk"`
+	// Listed: relist at the SellHub best ask, sales tax + broker fee.
+	ListedProfitISK float64 `json:"listed_profit_isk"`
+	ROI             float64 `json:"roi"` // best of the two profits / BuyPrice * 100
+	Viable          bool    `json:"viable"`
+}
+
+// PLEXHubMatrix compares PLEX across hubs. With PLEX on the global market,
+// hubs usually have no orders at all; Note says so rather than leaving an
+// empty table unexplained.
+type PLEXHubMatrix struct {
+	Hubs      []PLEXHubPrice  `json:"hubs"`
+	Arbitrage []PLEXHubSpread `json:"arbitrage"`
+	Note      string          `json:"note,omitempty"`
+}
+
+// ComputePLEXHubMatrix builds the per-hub PLEX quotes from region orders
+// (regionID → orders) and ranks hub-to-hub spreads by ROI.
+func ComputePLEXHubMatrix(ordersByRegion map[int32][]esi.MarketOrder, salesTaxPct, brokerFeePct float64) *PLEXHubMatrix {
+	m := &PLEXHubMatrix{Hubs: []PLEXHubPrice{}, Arbitrage: []PLEXHubSpread{}}
+	for _, hub := range PLEXHubRegions {
+		orders := ordersByRegion[hub.RegionID]
+		p := PLEXHubPrice{
+			Hub:       hub.Name,
+			RegionID:  hub.RegionID,
+			BuyPrice:  bestBuyPrice(orders),
+			SellPrice: bestSellPrice(orders),
+		}
+		for _, o := range orders {
+			if o.IsBuyOrder {
+				p.BuyOrders++
+			} else {
+				p.SellOrders++
+			}
+		}
+		m.Hubs = append(m.Hubs, p)
+	}
+
+	taxOnly := 1.0 - salesTaxPct/100
+	taxAndBroker := 1.0 - salesTaxPct/100 - brokerFeePct/100
+	for _, from := range m.Hubs {
+		if from.SellPrice <= 0 {
+			continue
+		}
+		for _, to := range m.Hubs {
+			if to.Hub == from.Hub || (to.BuyPrice <= 0 && to.SellPrice <= 0) {
+				continue
+			}
+			s := PLEXHubSpread{
+				BuyHub:    from.Hub,
+				SellHub:   to.Hub,
+				BuyPrice:  from.SellPrice,
+				SellPrice: to.BuyPrice,
+			}
+			if to.BuyPrice > 0 {
+				s.InstantProfitISK = to.BuyPrice*taxOnly - from.SellPrice
+			}
+			if to.SellPrice > 0 {
+				s.ListedProfitISK = to.SellPrice*taxAndBroker - from.SellPrice
+			}
+			best := s.InstantProfitISK
+			if to.BuyPrice <= 0 || (to.SellPrice > 0 && s.ListedProfitISK > best) {
+				best = s.ListedProfitISK
+			}
+			s.ROI = safeDiv(best, from.SellPrice) * 100
+			s.Viable = best > 0
+			m.Arbitrage = append(m.Arbitrage, s)
+		}
+	}
+	sort.SliceStable(m.Arbitrage, func(i, j int) bool { return m.Arbitrage[i].ROI > m.Arbitrage[j].ROI })
+
+	withOrders := 0
+	for _, h := range m.Hubs {
+		if h.BuyOrders+h.SellOrders > 0 {
+			withOrders++
+		}
+	}
+	if withOrders == 0 {
+		m.Note = "No regional PLEX orders: PLEX trades on the global PLEX market, see plex_price."
+	}
+	return m
+}
@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"testing"
+
+	"eve-flipper/internal/esi"
+)
+
+func TestComputePLEXHubMatrix_RanksSpreads(t *testing.T) {
+	orders := map[int32][]esi.MarketOrder{
+		10000002: { // Jita: cheap ask
+			{Price: 4_000_000, IsBuyOrder: false},
+			{Price: 3_900_000, IsBuyOrder: true},
+		},
+		10000043: { // Amarr: rich bid
+			{Price: 4_300_000, IsBuyOrder: true},
+			{Price: 4_500_000, IsBuyOrder: false},
+		},
+	}
+
+	m := ComputePLEXHubMatrix(orders, 4, 1)
+	if len(m.Hubs) != len(PLEXHubRegions) {
+		t.Fatalf("hubs = %d, want %d", len(m.Hubs), len(PLEXHubRegions))
+	}
+	if m.Note != "" {
+		t.Fatalf("Note = %q, want empty when hubs have orders", m.Note)
+	}
+	if len(m.Arbitrage) != 2 {
+		t.Fatalf("arbitrage = %+v, want Jita↔Amarr both ways", m.Arbitrage)
+	}
+	top := m.Arbitrage[0]
+	if top.BuyHub != "Jita" || top.SellHub != "Amarr" {
+		t.Fatalf("top spread = %s → %s, want Jita → Amarr", top.BuyHub, top.SellHub)
+	}
+	// Instant: 4.3M × 0.96 − 4.0M = 128k; listed: 4.5M × 0.95 − 4.0M = 275k.
+	if !industryAlmostEqual(top.InstantProfitISK, 128_000) || !industryAlmostEqual(top.ListedProfitISK, 275_000) {
+		t.Fatalf("profits = %v / %v, want 128000 / 275000", top.InstantProfitISK, top.ListedProfitISK)
+	}
+	if !top.Viable || !industryAlmostEqual(top.ROI, 6.875) {
+		t.Fatalf("ROI = %v, viable = %v; want 6.875, true", top.ROI, top.Viable)
+	}
+	if m.Arbitrage[1].Viable {
+		t.Fatalf("Amarr → Jita should not be viable: %+v", m.Arbitrage[1])
+	}
+}
+
+func TestComputePLEXHubMatrix_NoRegionalOrders(t *testing.T) {
+	m := ComputePLEXHubMatrix(nil, 4, 1)
+	if len(m.Arbitrage) != 0 || m.Note == "" {
+		t.Fatalf("matrix = %+v, want no spreads and a global-market note", m)
+	}
+}
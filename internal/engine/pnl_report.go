@@ -0,0 +1,120 @@
+package engine
+
+import "sort"
+
+// RealizedPnLReport is a flat realized-profit report built from the FIFO
+// ledger of a PortfolioPnL: every traded type (not just the top ones), the
+// daily series and period totals.
+type RealizedPnLReport struct {
+	Items    []RealizedItemPnL `json:"items"`
+	Daily    []DailyPnLEntry   `json:"daily"`
+	Totals   RealizedPnLTotals `json:"totals"`
+	Coverage MatchingCoverage  `json:"coverage"`
+	Settings PortfolioSettings `json:"settings"`
+}
+
+// RealizedItemPnL aggregates the realized trades of one type.
+type RealizedItemPnL struct {
+	TypeID        int32   `json:"type_id"`
+	TypeName      string  `json:"type_name"`
+	Quantity      int64   `json:"quantity"`
+	Trades        int     `json:"trades"`
+	BuyVolume     float64 `json:"buy_volume"`  // gross ISK paid for the matched units
+	SellVolume    float64 `json:"sell_volume"` // gross ISK received
+	Fees          float64 `json:"fees"`        // broker fees on both legs
+	Taxes         float64 `json:"taxes"`
+	RealizedPnL   float64 `json:"realized_pnl"`
+	MarginPercent float64 `json:"margin_percent"` // realized P&L / total cost
+}
+
+// RealizedPnLTotals sums RealizedItemPnL across all types.
+type RealizedPnLTotals struct {
+	Types       int     `json:"types"`
+	Quantity    int64   `json:"quantity"`
+	Trades      int     `json:"trades"`
+	BuyVolume   float64 `json:"buy_volume"`
+	SellVolume  float64 `json:"sell_volume"`
+	Fees        float64 `json:"fees"`
+	Taxes       float64 `json:"taxes"`
+	NetPnL      float64 `json:"net_pnl"`
+	ROIPercent  float64 `json:"roi_percent"` // net / total cost (buy volume + buy fees)
+	ProfitDays  int     `json:"profit_days"`
+	LossDays    int     `json:"loss_days"`
+	TradingDays int     `json:"trading_days"`
+}
+
+// BuildRealizedPnLReport aggregates pnl.Ledger per type. The ledger must be
+// complete, so compute pnl with a negative LedgerLimit (unlimited). Items are
+// ordered by realized P&L, best first.
+func BuildRealizedPnLReport(pnl *PortfolioPnL) RealizedPnLReport {
+	out := RealizedPnLReport{
+		Items: []RealizedItemPnL{},
+		Daily: []DailyPnLEntry{},
+	}
+	if pnl == nil {
+		return out
+	}
+	out.Coverage = pnl.Coverage
+	out.Settings = pnl.Settings
+	if pnl.DailyPnL != nil {
+		out.Daily = pnl.DailyPnL
+	}
+
+	byType := make(map[int32]*RealizedItemPnL)
+	costByType := make(map[int32]float64)
+	totalCost := 0.0
+	for _, tr := range pnl.Ledger {
+		item := byType[tr.TypeID]
+		if item == nil {
+			item = &RealizedItemPnL{TypeID: tr.TypeID, TypeName: tr.TypeName}
+			byType[tr.TypeID] = item
+		}
+		if item.TypeName == "" {
+			item.TypeName = tr.TypeName
+		}
+		item.Quantity += int64(tr.Quantity)
+		item.Trades++
+		item.BuyVolume += tr.BuyGross
+		item.SellVolume += tr.SellGross
+		item.Fees += tr.BuyFee + tr.SellBrokerFee
+		item.Taxes += tr.SellTax
+		item.RealizedPnL += tr.RealizedPnL
+		costByType[tr.TypeID] += tr.BuyTotal
+		totalCost += tr.BuyTotal
+	}
+
+	for _, item := range byType {
+		if cost := costByType[item.TypeID]; cost > 0 {
+			item.MarginPercent = item.RealizedPnL / cost * 100
+		}
+		out.Items = append(out.Items, *item)
+
+		out.Totals.Types++
+		out.Totals.Quantity += item.Quantity
+		out.Totals.Trades += item.Trades
+		out.Totals.BuyVolume += item.BuyVolume
+		out.Totals.SellVolume += item.SellVolume
+		out.Totals.Fees += item.Fees
+		out.Totals.Taxes += item.Taxes
+		out.Totals.NetPnL += item.RealizedPnL
+	}
+	if totalCost > 0 {
+		out.Totals.ROIPercent = out.Totals.NetPnL / totalCost * 100
+	}
+	sort.Slice(out.Items, func(i, j int) bool {
+		if out.Items[i].RealizedPnL != out.Items[j].RealizedPnL {
+			return out.Items[i].RealizedPnL > out.Items[j].RealizedPnL
+		}
+		return out.Items[i].TypeID < out.Items[j].TypeID
+	})
+
+	for _, d := range out.Daily {
+		out.Totals.TradingDays++
+		if d.NetPnL > 0 {
+			out.Totals.ProfitDays++
+		} else if d.NetPnL < 0 {
+			out.Totals.LossDays++
+		}
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"eve-flipper/internal/esi"
+)
+
+func TestBuildRealizedPnLReport(t *testing.T) {
+	txns := []esi.WalletTransaction{
+		txn(-3, 34, "Tritanium", 60003760, "Jita", true, 100, 10),
+		txn(-2, 34, "Tritanium", 60003760, "Jita", false, 150, 6),
+		txn(-1, 34, "Tritanium", 60003760, "Jita", false, 90, 4),
+		txn(-3, 35, "Pyerite", 60003760, "Jita", true, 10, 100),
+		txn(-1, 35, "Pyerite", 60003760, "Jita", false, 12, 50),
+	}
+	pnl := ComputePortfolioPnLWithOptions(txns, PortfolioPnLOptions{
+		LookbackDays:     30,
+		SalesTaxPercent:  5,
+		BrokerFeePercent: 1,
+		LedgerLimit:      -1,
+	})
+	rep := BuildRealizedPnLReport(pnl)
+
+	if len(rep.Items) != 2 {
+		t.Fatalf("items = %+v", rep.Items)
+	}
+	trit := rep.Items[0]
+	if trit.TypeID != 34 || trit.Quantity != 10 || trit.Trades != 2 {
+		t.Fatalf("tritanium = %+v", trit)
+	}
+	// Buy 1000 gross (+10 fee); sell 900+360 = 1260 gross (-12.6 broker, -63 tax).
+	approx := func(name string, got, want float64) {
+		t.Helper()
+		if math.Abs(got-want) > 1e-6 {
+			t.Fatalf("%s = %v, want %v", name, got, want)
+		}
+	}
+	approx("trit buy volume", trit.BuyVolume, 1000)
+	approx("trit sell volume", trit.SellVolume, 1260)
+	approx("trit fees", trit.Fees, 10+12.6)
+	approx("trit taxes", trit.Taxes, 63)
+	approx("trit pnl", trit.RealizedPnL, 1260-12.6-63-1010)
+	approx("trit margin", trit.MarginPercent, (1260-12.6-63-1010)/1010*100)
+
+	pye := rep.Items[1]
+	if pye.TypeID != 35 || pye.Quantity != 50 {
+		t.Fatalf("pyerite = %+v (unsold units must not count)", pye)
+	}
+
+	tot := rep.Totals
+	if tot.Types != 2 || tot.Quantity != 60 || tot.Trades != 3 {
+		t.Fatalf("totals = %+v", tot)
+	}
+	approx("total buy volume", tot.BuyVolume, 1500)
+	approx("total sell volume", tot.SellVolume, 1860)
+	approx("total net", tot.NetPnL, trit.RealizedPnL+pye.RealizedPnL)
+
+	var dailyNet float64
+	for _, d := range rep.Daily {
+		dailyNet += d.NetPnL
+	}
+	approx("daily net", dailyNet, tot.NetPnL)
+	if tot.TradingDays != len(rep.Daily) || tot.TradingDays != 2 {
+		t.Fatalf("trading days = %d, daily = %d", tot.TradingDays, len(rep.Daily))
+	}
+}
+
+func TestBuildRealizedPnLReport_Nil(t *testing.T) {
+	rep := BuildRealizedPnLReport(nil)
+	if rep.Items == nil || rep.Daily == nil || rep.Totals.Types != 0 {
+		t.Fatalf("report = %+v", rep)
+	}
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// DefaultRegionWorkers bounds how many regions a multi-region scan fetches
+// and evaluates at once. ESI rate limits make more than this counterproductive.
+const DefaultRegionWorkers = 6
+
+// ScanRegions runs scan for every region with at most workers calls in
+// flight and concatenates the results in ascending region ID order, so the
+// merged output does not depend on which region finished first.
+//
+// The first error cancels the context passed to the remaining calls and is
+// returned once every started call has finished. If ctx is canceled, its
+// error is returned instead.
+func ScanRegions[T any](ctx context.Context, regionIDs []int32, workers int, scan func(ctx context.Context, regionID int32) ([]T, error)) ([]T, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if workers <= 0 {
+		workers = DefaultRegionWorkers
+	}
+	ordered := append([]int32(nil), regionIDs...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i] < ordered[j] })
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	perRegion := make([][]T, len(ordered))
+	sem := make(chan struct{}, workers)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for i, regionID := range ordered {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, regionID int32) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			rows, err := scan(ctx, regionID)
+			if err != nil {
+				fail(err)
+				return
+			}
+			perRegion[i] = rows
+		}(i, regionID)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	total := 0
+	for _, rows := range perRegion {
+		total += len(rows)
+	}
+	out := make([]T, 0, total)
+	for _, rows := range perRegion {
+		out = append(out, rows...)
+	}
+	return out, nil
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScanRegions_MergesInRegionOrder(t *testing.T) {
+	// Later regions finish first; the merge must still follow region IDs.
+	regions := []int32{10000043, 10000002, 10000032}
+	got, err := ScanRegions(context.Background(), regions, 3, func(_ context.Context, regionID int32) ([]int32, error) {
+		time.Sleep(time.Duration(10000050-regionID) * time.Millisecond / 10)
+		return []int32{regionID, regionID}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int32{10000002, 10000002, 10000032, 10000032, 10000043, 10000043}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestScanRegions_BoundsConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	regions := []int32{1, 2, 3, 4, 5, 6, 7, 8}
+	_, err := ScanRegions(context.Background(), regions, 2, func(_ context.Context, _ int32) ([]int, error) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		inFlight.Add(-1)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak.Load() > 2 {
+		t.Fatalf("peak concurrency = %d, want <= 2", peak.Load())
+	}
+}
+
+func TestScanRegions_ErrorCancelsRemaining(t *testing.T) {
+	boom := errors.New("esi down")
+	var started atomic.Int32
+	_, err := ScanRegions(context.Background(), []int32{1, 2, 3, 4, 5, 6}, 1, func(ctx context.Context, regionID int32) ([]int, error) {
+		started.Add(1)
+		if regionID == 2 {
+			return nil, boom
+		}
+		return []int{1}, ctx.Err()
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want %v", err, boom)
+	}
+	if started.Load() != 2 {
+		t.Fatalf("started %d scans after the failure, want the pool to stop at 2", started.Load())
+	}
+}
+
+func TestScanRegions_ParentCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ScanRegions(ctx, []int32{1, 2}, 2, func(ctx context.Context, _ int32) ([]int, error) {
+		return nil, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+// benchmarkFiveRegionScan simulates a 5-region station scan where each region
+// spends ~5ms waiting on ESI.
+func benchmarkFiveRegionScan(b *testing.B, workers int) {
+	regions := []int32{10000002, 10000043, 10000032, 10000042, 10000030}
+	scan := func(_ context.Context, regionID int32) ([]StationTrade, error) {
+		time.Sleep(5 * time.Millisecond)
+		return []StationTrade{{TypeID: 34, StationID: int64(regionID)}}, nil
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := ScanRegions(context.Background(), regions, workers, scan); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScanRegions_Sequential(b *testing.B) { benchmarkFiveRegionScan(b, 1) }
+
+func BenchmarkScanRegions_Pooled(b *testing.B) { benchmarkFiveRegionScan(b, DefaultRegionWorkers) }
@@ -688,6 +688,7 @@ func FlattenRegionalDayHubs(hubs []RegionalDayTradeHub) []FlipResult {
 				DayPriceHistory:       item.TargetPriceHistory,
 				DayTargetLowestSell:   sanitizeFloat(item.TargetLowestSell),
 			}
+			setHaulingEfficiency(&row)
 			rows = append(rows, row)
 		}
 	}
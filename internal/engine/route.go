@@ -111,18 +111,19 @@ func selectClosestRouteRegions(systemRegion map[int32]int32, systems map[int32]i
 // buildOrderIndex builds per-system order maps from raw orders.
 // This legacy helper keeps historical behavior (structures included).
 func buildOrderIndex(sellOrders, buyOrders []esi.MarketOrder) *orderIndex {
-	return buildOrderIndexWithFilters(sellOrders, buyOrders, true)
+	return buildOrderIndexWithFilters(sellOrders, buyOrders, true, nil)
 }
 
 // buildOrderIndexWithFilters builds per-system order maps and applies route-level order filters.
-func buildOrderIndexWithFilters(sellOrders, buyOrders []esi.MarketOrder, includeStructures bool) *orderIndex {
+// Types in excluded are dropped along with market-disabled ones.
+func buildOrderIndexWithFilters(sellOrders, buyOrders []esi.MarketOrder, includeStructures bool, excluded map[int32]bool) *orderIndex {
 	idx := &orderIndex{
 		cheapestSell: make(map[int32]map[int32]orderEntry),
 		highestBuy:   make(map[int32]map[int32][]orderEntry),
 	}
 
 	for _, o := range sellOrders {
-		if isMarketDisabledType(o.TypeID) {
+		if isMarketDisabledType(o.TypeID) || excluded[o.TypeID] {
 			continue
 		}
 		if !includeStructures && isPlayerStructureLocationID(o.LocationID) {
@@ -144,7 +145,7 @@ func buildOrderIndexWithFilters(sellOrders, buyOrders []esi.MarketOrder, include
 	}
 
 	for _, o := range buyOrders {
-		if isMarketDisabledType(o.TypeID) {
+		if isMarketDisabledType(o.TypeID) || excluded[o.TypeID] {
 			continue
 		}
 		if !includeStructures && isPlayerStructureLocationID(o.LocationID) {
@@ -506,7 +507,8 @@ func (s *Scanner) FindRoutes(params RouteParams, progress func(string)) ([]Route
 	log.Printf("[Route] Fetched %d sell, %d buy orders across %d regions (%d systems in envelope)",
 		len(sellOrders), len(buyOrders), len(regions), len(searchSystems))
 	progress("Building order index...")
-	idx := buildOrderIndexWithFilters(sellOrders, buyOrders, params.IncludeStructures)
+	idx := buildOrderIndexWithFilters(sellOrders, buyOrders, params.IncludeStructures,
+		s.excludedTypes(params.ExcludeTypeIDs, params.ExcludeMarketGroupIDs))
 	log.Printf(
 		"[Route] Search params: start=%s target=%s hops=%d-%d minMargin=%.2f minISK/jump=%.2f allowEmpty=%t",
 		startName,
@@ -759,12 +761,69 @@ func (s *Scanner) FindRoutes(params RouteParams, progress func(string)) ([]Route
 		completedRoutes = completedRoutes[:MaxUnlimitedResults]
 	}
 
+	if params.RoundTrip && len(completedRoutes) > 0 {
+		progress("Searching return legs...")
+		originDistance := make(map[int32]int)
+		distanceToOrigin := func(fromSystemID int32) int {
+			if dist, ok := originDistance[fromSystemID]; ok {
+				return dist
+			}
+			dist := s.jumpsBetweenWithSecurity(fromSystemID, systemID, params.MinRouteSecurity)
+			originDistance[fromSystemID] = dist
+			return dist
+		}
+		returnLegs := make(map[int32]routeReturnLeg)
+		findReturnLeg := func(fromSystemID int32) routeReturnLeg {
+			if leg, ok := returnLegs[fromSystemID]; ok {
+				return leg
+			}
+			leg := s.buildReturnLeg(fromSystemID, systemID, params.MaxHops, distanceToOrigin, func(from int32) []RouteHop {
+				return selectBestHopCandidates(from, branchFactor)
+			})
+			returnLegs[fromSystemID] = leg
+			return leg
+		}
+		kept := completedRoutes[:0]
+		for _, route := range completedRoutes {
+			// Return leg starts where the outbound trip ends (target system if set).
+			endSystemID := route.Hops[len(route.Hops)-1].DestSystemID
+			if targetSystemID != 0 {
+				endSystemID = targetSystemID
+			}
+			leg := findReturnLeg(endSystemID)
+			if !leg.ok {
+				continue
+			}
+			applyReturnLeg(&route, leg)
+			kept = append(kept, route)
+		}
+		completedRoutes = kept
+		sort.SliceStable(completedRoutes, func(i, j int) bool {
+			return completedRoutes[i].TotalProfit > completedRoutes[j].TotalProfit
+		})
+	}
+
+	for i := range completedRoutes {
+		completedRoutes[i].RiskAdjustedProfit = completedRoutes[i].TotalProfit
+	}
+	if params.SecurityPenaltyPerLowsecJump > 0 && len(completedRoutes) > 0 {
+		lowsecJumps := s.lowsecJumpCounter(params.MinRouteSecurity)
+		for i := range completedRoutes {
+			route := &completedRoutes[i]
+			route.LowsecJumps = routeLowsecJumps(*route, systemID, targetSystemID, params.RoundTrip, lowsecJumps)
+			route.RiskAdjustedProfit = sanitizeFloat(route.TotalProfit - float64(route.LowsecJumps)*params.SecurityPenaltyPerLowsecJump)
+		}
+		sort.SliceStable(completedRoutes, func(i, j int) bool {
+			return completedRoutes[i].RiskAdjustedProfit > completedRoutes[j].RiskAdjustedProfit
+		})
+	}
+
 	// Prefetch station names for all hops (buy and sell stations)
 	if len(completedRoutes) > 0 {
 		progress("Fetching station names...")
 		stations := make(map[int64]bool)
 		for _, route := range completedRoutes {
-			for _, hop := range route.Hops {
+			for _, hop := range append(route.Hops, route.ReturnHops...) {
 				stations[hop.LocationID] = true
 				if hop.DestLocationID != 0 {
 					stations[hop.DestLocationID] = true
@@ -772,14 +831,18 @@ func (s *Scanner) FindRoutes(params RouteParams, progress func(string)) ([]Route
 			}
 		}
 		s.ESI.PrefetchStationNames(stations)
-		for i := range completedRoutes {
-			for j := range completedRoutes[i].Hops {
-				completedRoutes[i].Hops[j].StationName = s.ESI.StationName(completedRoutes[i].Hops[j].LocationID)
-				if completedRoutes[i].Hops[j].DestLocationID != 0 {
-					completedRoutes[i].Hops[j].DestStationName = s.ESI.StationName(completedRoutes[i].Hops[j].DestLocationID)
+		fillNames := func(hops []RouteHop) {
+			for j := range hops {
+				hops[j].StationName = s.ESI.StationName(hops[j].LocationID)
+				if hops[j].DestLocationID != 0 {
+					hops[j].DestStationName = s.ESI.StationName(hops[j].DestLocationID)
 				}
 			}
 		}
+		for i := range completedRoutes {
+			fillNames(completedRoutes[i].Hops)
+			fillNames(completedRoutes[i].ReturnHops)
+		}
 	}
 
 	progress(fmt.Sprintf("Found %d routes", len(completedRoutes)))
@@ -787,6 +850,132 @@ func (s *Scanner) FindRoutes(params RouteParams, progress func(string)) ([]Route
 	return completedRoutes, nil
 }
 
+// routeReturnLeg is a chain of trades hauling cargo back toward the origin.
+type routeReturnLeg struct {
+	hops          []RouteHop
+	profit        float64
+	tradeJumps    int // jumps spent on return trades (incl. empty hops)
+	deadheadJumps int // remaining empty jumps from the last return trade to origin
+	ok            bool
+}
+
+// buildReturnLeg greedily chains the most profitable hops whose destination is
+// strictly closer to the origin, stopping at the origin or after maxHops.
+// Candidates come from the same hop selector as the outbound search, so the
+// security and structure filters apply unchanged. The leg is reported even
+// when no trade qualifies (pure deadhead), as long as the origin is reachable.
+func (s *Scanner) buildReturnLeg(
+	fromSystemID int32,
+	originSystemID int32,
+	maxHops int,
+	distanceToOrigin func(int32) int,
+	candidates func(int32) []RouteHop,
+) routeReturnLeg {
+	leg := routeReturnLeg{}
+	current := fromSystemID
+	currentDist := distanceToOrigin(current)
+	if currentDist == UnreachableJumps {
+		return leg
+	}
+	if maxHops < 1 {
+		maxHops = 1
+	}
+	for len(leg.hops) < maxHops && current != originSystemID {
+		var best *RouteHop
+		bestDist := currentDist
+		for _, hop := range candidates(current) {
+			destDist := distanceToOrigin(hop.DestSystemID)
+			if destDist == UnreachableJumps || destDist >= currentDist {
+				continue
+			}
+			if routeVisitsSystem(leg.hops, hop.DestSystemID) {
+				continue
+			}
+			if best == nil || hop.Profit > best.Profit {
+				h := hop
+				best = &h
+				bestDist = destDist
+			}
+		}
+		if best == nil {
+			break
+		}
+		leg.hops = append(leg.hops, *best)
+		leg.profit += best.Profit
+		leg.tradeJumps += best.Jumps + best.EmptyJumps
+		current = best.DestSystemID
+		currentDist = bestDist
+	}
+	leg.deadheadJumps = currentDist
+	leg.ok = true
+	return leg
+}
+
+// applyReturnLeg attaches a return leg to an outbound route and folds its
+// profit and jumps into the combined totals.
+func applyReturnLeg(route *RouteResult, leg routeReturnLeg) {
+	route.OutboundProfit = route.TotalProfit
+	route.OutboundJumps = route.TotalJumps
+	route.ReturnHops = copyHops(leg.hops)
+	route.ReturnProfit = leg.profit
+	route.ReturnJumps = leg.tradeJumps + leg.deadheadJumps
+	route.TotalProfit = route.OutboundProfit + route.ReturnProfit
+	route.TotalJumps = route.OutboundJumps + route.ReturnJumps
+	if route.TotalJumps > 0 {
+		route.ProfitPerJump = sanitizeFloat(route.TotalProfit / float64(route.TotalJumps))
+	}
+}
+
+// lowsecJumpCounter returns a memoized count of the jumps into systems below
+// 0.5 security on the shortest path between two systems, honoring the same
+// security floor as the route search.
+func (s *Scanner) lowsecJumpCounter(minSecurity float64) func(from, to int32) int {
+	cache := make(map[[2]int32]int)
+	return func(from, to int32) int {
+		if from == to || from == 0 || to == 0 {
+			return 0
+		}
+		key := [2]int32{from, to}
+		if n, ok := cache[key]; ok {
+			return n
+		}
+		n := 0
+		path := s.SDE.Universe.PathMinSecurity(from, to, minSecurity)
+		for i := 1; i < len(path); i++ {
+			if !isHighsecSecurity(s.SDE.Universe.SystemSecurity[path[i]]) {
+				n++
+			}
+		}
+		cache[key] = n
+		return n
+	}
+}
+
+// routeLowsecJumps walks a route leg by leg from the origin: empty travel to
+// each hop, the hop itself, the deadhead to the target and, for round trips,
+// the return leg and the way back to the origin.
+func routeLowsecJumps(route RouteResult, originID, targetID int32, roundTrip bool, count func(from, to int32) int) int {
+	n := 0
+	at := originID
+	walk := func(hops []RouteHop) {
+		for _, hop := range hops {
+			n += count(at, hop.SystemID)
+			n += count(hop.SystemID, hop.DestSystemID)
+			at = hop.DestSystemID
+		}
+	}
+	walk(route.Hops)
+	if targetID != 0 {
+		n += count(at, targetID)
+		at = targetID
+	}
+	if roundTrip {
+		walk(route.ReturnHops)
+		n += count(at, originID)
+	}
+	return n
+}
+
 func copyHops(hops []RouteHop) []RouteHop {
 	c := make([]RouteHop, len(hops))
 	copy(c, hops)
@@ -112,7 +112,7 @@ func TestBuildOrderIndexWithFilters_ExcludeStructures(t *testing.T) {
 		{SystemID: 2, TypeID: 100, Price: 25, VolumeRemain: 50, LocationID: 60008494},          // NPC station
 	}
 
-	idx := buildOrderIndexWithFilters(sellOrders, buyOrders, false)
+	idx := buildOrderIndexWithFilters(sellOrders, buyOrders, false, nil)
 	if got := idx.cheapestSell[1][100].LocationID; got != 60003760 {
 		t.Fatalf("cheapestSell location = %d, want NPC station 60003760", got)
 	}
@@ -121,6 +121,28 @@ func TestBuildOrderIndexWithFilters_ExcludeStructures(t *testing.T) {
 	}
 }
 
+func TestBuildOrderIndexWithFilters_ExcludedTypes(t *testing.T) {
+	sellOrders := []esi.MarketOrder{
+		{SystemID: 1, TypeID: 100, Price: 10, VolumeRemain: 50, LocationID: 60003760},
+		{SystemID: 1, TypeID: 200, Price: 10, VolumeRemain: 50, LocationID: 60003760},
+	}
+	buyOrders := []esi.MarketOrder{
+		{SystemID: 2, TypeID: 100, Price: 30, VolumeRemain: 50, LocationID: 60008494},
+		{SystemID: 2, TypeID: 200, Price: 30, VolumeRemain: 50, LocationID: 60008494},
+	}
+
+	idx := buildOrderIndexWithFilters(sellOrders, buyOrders, true, map[int32]bool{200: true})
+	if _, ok := idx.cheapestSell[1][200]; ok {
+		t.Fatal("excluded type 200 should not be indexed on the sell side")
+	}
+	if _, ok := idx.highestBuy[2][200]; ok {
+		t.Fatal("excluded type 200 should not be indexed on the buy side")
+	}
+	if _, ok := idx.cheapestSell[1][100]; !ok {
+		t.Fatal("type 100 should still be indexed")
+	}
+}
+
 func TestBuildOrderIndexWithFilters_IncludeStructures(t *testing.T) {
 	sellOrders := []esi.MarketOrder{
 		{SystemID: 1, TypeID: 100, Price: 10, VolumeRemain: 50, LocationID: 1_000_000_000_123}, // structure, best price
@@ -131,7 +153,7 @@ func TestBuildOrderIndexWithFilters_IncludeStructures(t *testing.T) {
 		{SystemID: 2, TypeID: 100, Price: 25, VolumeRemain: 50, LocationID: 60008494},          // NPC station
 	}
 
-	idx := buildOrderIndexWithFilters(sellOrders, buyOrders, true)
+	idx := buildOrderIndexWithFilters(sellOrders, buyOrders, true, nil)
 	if got := idx.cheapestSell[1][100].LocationID; got != 1_000_000_000_123 {
 		t.Fatalf("cheapestSell location = %d, want structure 1000000000123", got)
 	}
@@ -496,3 +518,111 @@ func TestRouteFilterJumpCountForTarget(t *testing.T) {
 		t.Fatalf("with target and zero trade jumps: got %d, want floor 1", got)
 	}
 }
+
+func TestBuildReturnLeg_ChainsTowardOrigin(t *testing.T) {
+	// Linear chain 1-2-3-4 with origin at 1; start from 4.
+	dist := map[int32]int{1: 0, 2: 1, 3: 2, 4: 3, 9: UnreachableJumps}
+	candidates := map[int32][]RouteHop{
+		4: {
+			{SystemID: 4, DestSystemID: 9, Profit: 9_000, Jumps: 1}, // unreachable from origin
+			{SystemID: 4, DestSystemID: 3, Profit: 1_000, Jumps: 1},
+			{SystemID: 4, DestSystemID: 2, Profit: 2_500, Jumps: 2},
+		},
+		2: {
+			{SystemID: 2, DestSystemID: 3, Profit: 5_000, Jumps: 1}, // moves away from origin
+			{SystemID: 2, DestSystemID: 1, Profit: 700, Jumps: 1},
+		},
+	}
+	s := &Scanner{}
+	leg := s.buildReturnLeg(4, 1, 5,
+		func(id int32) int { return dist[id] },
+		func(id int32) []RouteHop { return candidates[id] },
+	)
+	if !leg.ok {
+		t.Fatal("expected return leg")
+	}
+	if len(leg.hops) != 2 || leg.hops[0].DestSystemID != 2 || leg.hops[1].DestSystemID != 1 {
+		t.Fatalf("unexpected return hops: %+v", leg.hops)
+	}
+	if leg.profit != 3_200 || leg.tradeJumps != 3 || leg.deadheadJumps != 0 {
+		t.Fatalf("profit/jumps/deadhead = %v/%d/%d, want 3200/3/0", leg.profit, leg.tradeJumps, leg.deadheadJumps)
+	}
+}
+
+func TestBuildReturnLeg_DeadheadWhenNoTrades(t *testing.T) {
+	s := &Scanner{}
+	leg := s.buildReturnLeg(4, 1, 5,
+		func(id int32) int { return 3 },
+		func(int32) []RouteHop { return nil },
+	)
+	if !leg.ok || len(leg.hops) != 0 || leg.deadheadJumps != 3 {
+		t.Fatalf("expected pure deadhead of 3 jumps, got %+v", leg)
+	}
+
+	unreachable := s.buildReturnLeg(4, 1, 5,
+		func(int32) int { return UnreachableJumps },
+		func(int32) []RouteHop { return nil },
+	)
+	if unreachable.ok {
+		t.Fatal("expected no return leg when origin unreachable")
+	}
+}
+
+func TestApplyReturnLeg_SumsBothLegs(t *testing.T) {
+	route := RouteResult{TotalProfit: 10_000, TotalJumps: 6, HopCount: 2}
+	applyReturnLeg(&route, routeReturnLeg{
+		hops:          []RouteHop{{Profit: 4_000, Jumps: 3}},
+		profit:        4_000,
+		tradeJumps:    3,
+		deadheadJumps: 1,
+		ok:            true,
+	})
+	if route.OutboundProfit != 10_000 || route.ReturnProfit != 4_000 || route.TotalProfit != 14_000 {
+		t.Fatalf("profits outbound/return/total = %v/%v/%v", route.OutboundProfit, route.ReturnProfit, route.TotalProfit)
+	}
+	if route.OutboundJumps != 6 || route.ReturnJumps != 4 || route.TotalJumps != 10 {
+		t.Fatalf("jumps outbound/return/total = %d/%d/%d", route.OutboundJumps, route.ReturnJumps, route.TotalJumps)
+	}
+	if route.ProfitPerJump != 1_400 {
+		t.Fatalf("profit per jump = %v, want 1400", route.ProfitPerJump)
+	}
+}
+
+func TestRouteLowsecJumps(t *testing.T) {
+	// 1(hs) - 2(ls) - 3(hs) - 4(ls)
+	u := graph.NewUniverse()
+	for _, gate := range [][2]int32{{1, 2}, {2, 3}, {3, 4}} {
+		u.AddGate(gate[0], gate[1])
+		u.AddGate(gate[1], gate[0])
+	}
+	u.SetSecurity(1, 0.9)
+	u.SetSecurity(2, 0.4)
+	u.SetSecurity(3, 0.5)
+	u.SetSecurity(4, 0.1)
+	s := &Scanner{SDE: &sde.Data{Universe: u}}
+	count := s.lowsecJumpCounter(0)
+
+	if got := count(1, 3); got != 1 {
+		t.Errorf("count(1,3) = %d, want 1 (only system 2)", got)
+	}
+	if got := count(3, 3); got != 0 {
+		t.Errorf("count(3,3) = %d, want 0", got)
+	}
+
+	route := RouteResult{Hops: []RouteHop{
+		{SystemID: 1, DestSystemID: 3}, // via 2: 1 lowsec jump
+		{SystemID: 3, DestSystemID: 4}, // 1 lowsec jump
+	}}
+	if got := routeLowsecJumps(route, 1, 0, false, count); got != 2 {
+		t.Errorf("outbound lowsec jumps = %d, want 2", got)
+	}
+	// Round trip back from 4 to 1 passes 2 and lands in 1: one more.
+	if got := routeLowsecJumps(route, 1, 0, true, count); got != 3 {
+		t.Errorf("round-trip lowsec jumps = %d, want 3", got)
+	}
+	// Starting empty from 2 adds nothing for the start itself; the first hop
+	// is reached through 1 (highsec), then continues as before.
+	if got := routeLowsecJumps(route, 2, 0, false, count); got != 2 {
+		t.Errorf("lowsec jumps from 2 = %d, want 2", got)
+	}
+}
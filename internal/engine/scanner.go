@@ -17,6 +17,8 @@ const (
 	// MaxUnlimitedResults caps the working set to prevent server overload
 	// (sorting, history enrichment, and JSON serialization of very large result sets).
 	MaxUnlimitedResults = 5000
+	// ResultBatchSize is the number of rows per ScanParams.OnBatch call.
+	ResultBatchSize = 250
 	// UnreachableJumps is the fallback jump count when no path exists.
 	UnreachableJumps = 999
 )
@@ -77,18 +79,82 @@ func (s *Scanner) Scan(params ScanParams, progress func(string)) ([]FlipResult,
 	log.Printf("[DEBUG] Scan: buySystems=%d, sellSystems=%d, buyRegions=%d, sellRegions=%d",
 		len(buySystems), len(sellSystems), len(buyRegions), len(sellRegions))
 
+	if err := checkContextCanceled(params.Ctx); err != nil {
+		return nil, err
+	}
 	progress(fmt.Sprintf("Fetching orders from %d+%d regions...", len(buyRegions), len(sellRegions)))
-	idx := s.fetchAndIndex(buyRegions, buySystems, sellRegions, sellSystems)
+	idx := s.fetchAndIndex(buyRegions, buySystems, sellRegions, sellSystems, params.ExcludeNPCOrders)
+	if err := checkContextCanceled(params.Ctx); err != nil {
+		return nil, err
+	}
 	return s.calculateResults(params, idx, buySystems, progress)
 }
 
 // ScanMultiRegion finds profitable flip opportunities across whole regions.
 func (s *Scanner) ScanMultiRegion(params ScanParams, progress func(string)) ([]FlipResult, error) {
-	minSec := params.MinRouteSecurity
+	buyRegions, buySystems, buySystemsRadius := s.multiRegionBuyScope(params, progress)
+	sellRegions, sellSystems := s.multiRegionSellScope(params, progress)
+
+	if err := checkContextCanceled(params.Ctx); err != nil {
+		return nil, err
+	}
+	progress(fmt.Sprintf("Fetching orders: buy from %d region(s), sell from %d region(s)...", len(buyRegions), len(sellRegions)))
+	idx := s.fetchAndIndex(buyRegions, buySystems, sellRegions, sellSystems, params.ExcludeNPCOrders)
+	if err := checkContextCanceled(params.Ctx); err != nil {
+		return nil, err
+	}
+	return s.calculateResults(params, idx, buySystemsRadius, progress)
+}
 
-	var buyRegions map[int32]bool
-	var buySystems map[int32]int
-	var buySystemsRadius map[int32]int
+// SourceBooks holds the buy-side (source) sell orders of a multi-region scan
+// so several destinations can be scanned against one fetch.
+type SourceBooks struct {
+	idx          *scanIndex // only the source fields are filled
+	systemRadius map[int32]int
+}
+
+// FetchSourceBooks fetches the source side of a ScanMultiRegion scan. Only
+// the buy scope of params is used: origin, buy radius, route security,
+// source regions and the NPC order filter.
+func (s *Scanner) FetchSourceBooks(params ScanParams, progress func(string)) (*SourceBooks, error) {
+	buyRegions, buySystems, buySystemsRadius := s.multiRegionBuyScope(params, progress)
+	if err := checkContextCanceled(params.Ctx); err != nil {
+		return nil, err
+	}
+	progress(fmt.Sprintf("Fetching orders: buy from %d region(s)...", len(buyRegions)))
+	idx := newScanIndex()
+	s.indexSourceOrders(idx, buyRegions, buySystems, params.ExcludeNPCOrders)
+	return &SourceBooks{idx: idx, systemRadius: buySystemsRadius}, nil
+}
+
+// ScanMultiRegionFrom is ScanMultiRegion with the source side taken from src.
+// params must have the buy scope src was fetched with; only the destination
+// books are fetched.
+func (s *Scanner) ScanMultiRegionFrom(src *SourceBooks, params ScanParams, progress func(string)) ([]FlipResult, error) {
+	sellRegions, sellSystems := s.multiRegionSellScope(params, progress)
+
+	if err := checkContextCanceled(params.Ctx); err != nil {
+		return nil, err
+	}
+	progress(fmt.Sprintf("Fetching orders: sell from %d region(s)...", len(sellRegions)))
+	idx := newScanIndex()
+	// The source maps are only read by calculateResults, so every
+	// destination can share them.
+	idx.sellByType = src.idx.sellByType
+	idx.sellCounts = src.idx.sellCounts
+	idx.sellOrders = src.idx.sellOrders
+	s.indexDestinationOrders(idx, sellRegions, sellSystems, params.ExcludeNPCOrders)
+	if err := checkContextCanceled(params.Ctx); err != nil {
+		return nil, err
+	}
+	return s.calculateResults(params, idx, src.systemRadius, progress)
+}
+
+// multiRegionBuyScope resolves the source regions and systems of a
+// multi-region scan, plus jump distances from the origin when the scope
+// comes from the buy radius.
+func (s *Scanner) multiRegionBuyScope(params ScanParams, progress func(string)) (buyRegions map[int32]bool, buySystems, buySystemsRadius map[int32]int) {
+	minSec := params.MinRouteSecurity
 
 	// Optional EveGuru-style source scope: explicit source regions.
 	if len(params.SourceRegionIDs) > 0 {
@@ -103,40 +169,41 @@ func (s *Scanner) ScanMultiRegion(params ScanParams, progress func(string)) ([]F
 		// calculateResults will fall back to shortest-path queries per source system.
 		buySystemsRadius = make(map[int32]int)
 		progress(fmt.Sprintf("Using source region scope: %d region(s)...", len(buyRegions)))
-	} else {
-		progress("Finding buy regions by radius...")
-		if minSec > 0 {
-			buySystemsRadius = s.SDE.Universe.SystemsWithinRadiusMinSecurity(params.CurrentSystemID, params.BuyRadius, minSec)
-		} else {
-			buySystemsRadius = s.SDE.Universe.SystemsWithinRadius(params.CurrentSystemID, params.BuyRadius)
-		}
-		buyRegions = s.SDE.Universe.RegionsInSet(buySystemsRadius)
-		buySystems = s.SDE.Universe.SystemsInRegions(buyRegions)
+		return buyRegions, buySystems, buySystemsRadius
 	}
 
-	var sellRegions map[int32]bool
-	var sellSystems map[int32]int
+	progress("Finding buy regions by radius...")
+	if minSec > 0 {
+		buySystemsRadius = s.SDE.Universe.SystemsWithinRadiusMinSecurity(params.CurrentSystemID, params.BuyRadius, minSec)
+	} else {
+		buySystemsRadius = s.SDE.Universe.SystemsWithinRadius(params.CurrentSystemID, params.BuyRadius)
+	}
+	buyRegions = s.SDE.Universe.RegionsInSet(buySystemsRadius)
+	buySystems = s.SDE.Universe.SystemsInRegions(buyRegions)
+	return buyRegions, buySystems, buySystemsRadius
+}
 
+// multiRegionSellScope resolves the destination regions and systems of a
+// multi-region scan.
+func (s *Scanner) multiRegionSellScope(params ScanParams, progress func(string)) (sellRegions map[int32]bool, sellSystems map[int32]int) {
 	// Destination side is either fixed target region or classic sell-radius scope.
 	if params.TargetRegionID > 0 {
 		sellRegions = map[int32]bool{params.TargetRegionID: true}
 		sellSystems = s.SDE.Universe.SystemsInRegions(sellRegions)
 		progress(fmt.Sprintf("Using target region %d for sell side...", params.TargetRegionID))
-	} else {
-		progress("Finding sell regions by radius...")
-		var sellSystemsRadius map[int32]int
-		if minSec > 0 {
-			sellSystemsRadius = s.SDE.Universe.SystemsWithinRadiusMinSecurity(params.CurrentSystemID, params.SellRadius, minSec)
-		} else {
-			sellSystemsRadius = s.SDE.Universe.SystemsWithinRadius(params.CurrentSystemID, params.SellRadius)
-		}
-		sellRegions = s.SDE.Universe.RegionsInSet(sellSystemsRadius)
-		sellSystems = s.SDE.Universe.SystemsInRegions(sellRegions)
+		return sellRegions, sellSystems
 	}
 
-	progress(fmt.Sprintf("Fetching orders: buy from %d region(s), sell from %d region(s)...", len(buyRegions), len(sellRegions)))
-	idx := s.fetchAndIndex(buyRegions, buySystems, sellRegions, sellSystems)
-	return s.calculateResults(params, idx, buySystemsRadius, progress)
+	progress("Finding sell regions by radius...")
+	var sellSystemsRadius map[int32]int
+	if params.MinRouteSecurity > 0 {
+		sellSystemsRadius = s.SDE.Universe.SystemsWithinRadiusMinSecurity(params.CurrentSystemID, params.SellRadius, params.MinRouteSecurity)
+	} else {
+		sellSystemsRadius = s.SDE.Universe.SystemsWithinRadius(params.CurrentSystemID, params.SellRadius)
+	}
+	sellRegions = s.SDE.Universe.RegionsInSet(sellSystemsRadius)
+	sellSystems = s.SDE.Universe.SystemsInRegions(sellRegions)
+	return sellRegions, sellSystems
 }
 
 // --- Streaming order index types ---
@@ -208,6 +275,7 @@ func (s *Scanner) fetchOrdersStream(
 	regions map[int32]bool,
 	orderType string,
 	validSystems map[int32]int,
+	excludeNPC bool,
 ) <-chan []esi.MarketOrder {
 	ch := make(chan []esi.MarketOrder, len(regions))
 
@@ -243,6 +311,9 @@ func (s *Scanner) fetchOrdersStream(
 			// Filter to valid systems
 			filtered := make([]esi.MarketOrder, 0, len(orders)/2)
 			for _, o := range orders {
+				if excludeNPC && IsNPCSeededOrder(o) {
+					continue
+				}
 				if _, ok := validSystems[o.SystemID]; ok {
 					filtered = append(filtered, o)
 				}
@@ -266,13 +337,29 @@ func (s *Scanner) fetchOrdersStream(
 func (s *Scanner) fetchAndIndex(
 	buyRegions map[int32]bool, buySystems map[int32]int,
 	sellRegions map[int32]bool, sellSystems map[int32]int,
+	excludeNPC bool,
 ) *scanIndex {
-	sellCh := s.fetchOrdersStream(buyRegions, "sell", buySystems)
-	buyCh := s.fetchOrdersStream(sellRegions, "buy", sellSystems)
-	// Additional sell-side sell-book stream for mathematically consistent S2B/BfS split.
-	sellSideSellCh := s.fetchOrdersStream(sellRegions, "sell", sellSystems)
+	idx := newScanIndex()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.indexSourceOrders(idx, buyRegions, buySystems, excludeNPC)
+	}()
+	go func() {
+		defer wg.Done()
+		s.indexDestinationOrders(idx, sellRegions, sellSystems, excludeNPC)
+	}()
+	wg.Wait()
 
-	idx := &scanIndex{
+	log.Printf("[DEBUG] fetchAndIndex: %d sell orders, %d buy orders", len(idx.sellOrders), len(idx.buyOrders))
+	log.Printf("[DEBUG] sellByType: %d types, buyByType: %d types", len(idx.sellByType), len(idx.buyByType))
+	return idx
+}
+
+func newScanIndex() *scanIndex {
+	return &scanIndex{
 		sellByType:                       make(map[int32][]sellInfo),
 		sellCounts:                       make(map[locKey]int),
 		buyByType:                        make(map[int32][]buyInfo),
@@ -284,30 +371,39 @@ func (s *Scanner) fetchAndIndex(
 		sellSideSellMinPriceByLoc:        make(map[locKey]float64),
 		sellSideSellMinPriceByTypeSystem: make(map[sysTypeKey]float64),
 	}
+}
 
-	var wg sync.WaitGroup
-	wg.Add(3)
-
-	// Consumer 1: collect all sell orders grouped by type
-	go func() {
-		defer wg.Done()
-		for batch := range sellCh {
-			idx.sellOrders = append(idx.sellOrders, batch...)
-			for _, o := range batch {
-				idx.sellCounts[locKey{o.TypeID, o.LocationID}]++
-				idx.sellByType[o.TypeID] = append(idx.sellByType[o.TypeID], sellInfo{
-					Price: o.Price, VolumeRemain: o.VolumeRemain,
-					LocationID: o.LocationID, SystemID: o.SystemID,
-				})
-			}
+// indexSourceOrders streams the sell orders of the buy regions into the
+// source fields of idx (sellByType, sellCounts, sellOrders).
+func (s *Scanner) indexSourceOrders(idx *scanIndex, buyRegions map[int32]bool, buySystems map[int32]int, excludeNPC bool) {
+	sellCh := s.fetchOrdersStream(buyRegions, "sell", buySystems, excludeNPC)
+	for batch := range sellCh {
+		idx.sellOrders = append(idx.sellOrders, batch...)
+		for _, o := range batch {
+			idx.sellCounts[locKey{o.TypeID, o.LocationID}]++
+			idx.sellByType[o.TypeID] = append(idx.sellByType[o.TypeID], sellInfo{
+				Price: o.Price, VolumeRemain: o.VolumeRemain,
+				LocationID: o.LocationID, SystemID: o.SystemID,
+			})
 		}
-		// Fill order counts per location
-		for tid, sells := range idx.sellByType {
-			for i := range sells {
-				sells[i].OrderCount = idx.sellCounts[locKey{tid, sells[i].LocationID}]
-			}
+	}
+	// Fill order counts per location
+	for tid, sells := range idx.sellByType {
+		for i := range sells {
+			sells[i].OrderCount = idx.sellCounts[locKey{tid, sells[i].LocationID}]
 		}
-	}()
+	}
+}
+
+// indexDestinationOrders streams the buy and sell orders of the sell regions
+// into the destination fields of idx.
+func (s *Scanner) indexDestinationOrders(idx *scanIndex, sellRegions map[int32]bool, sellSystems map[int32]int, excludeNPC bool) {
+	buyCh := s.fetchOrdersStream(sellRegions, "buy", sellSystems, excludeNPC)
+	// Additional sell-side sell-book stream for mathematically consistent S2B/BfS split.
+	sellSideSellCh := s.fetchOrdersStream(sellRegions, "sell", sellSystems, excludeNPC)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
 
 	// Consumer 2: collect all buy orders grouped by type
 	go func() {
@@ -352,10 +448,6 @@ func (s *Scanner) fetchAndIndex(
 	}()
 
 	wg.Wait()
-
-	log.Printf("[DEBUG] fetchAndIndex: %d sell orders, %d buy orders", len(idx.sellOrders), len(idx.buyOrders))
-	log.Printf("[DEBUG] sellByType: %d types, buyByType: %d types", len(idx.sellByType), len(idx.buyByType))
-	return idx
 }
 
 // calculateResults is the shared profit calculation logic.
@@ -370,7 +462,7 @@ func (s *Scanner) calculateResults(
 	buyOrders := idx.buyOrders
 
 	progress("Calculating profits...")
-	buyCostMult, sellRevenueMult := tradeFeeMultipliers(tradeFeeInputs{
+	feeInputs := tradeFeeInputs{
 		SplitTradeFees:       params.SplitTradeFees,
 		BrokerFeePercent:     params.BrokerFeePercent,
 		SalesTaxPercent:      params.SalesTaxPercent,
@@ -378,7 +470,8 @@ func (s *Scanner) calculateResults(
 		SellBrokerFeePercent: params.SellBrokerFeePercent,
 		BuySalesTaxPercent:   params.BuySalesTaxPercent,
 		SellSalesTaxPercent:  params.SellSalesTaxPercent,
-	})
+	}
+	buyCostMult, sellRevenueMult := tradeFeeMultipliers(feeInputs)
 
 	// For each (typeID, sellLocationID, buyLocationID) keep only the best-profit pair.
 	// This deduplicates multiple orders at the same location while preserving
@@ -406,8 +499,9 @@ func (s *Scanner) calculateResults(
 		BestPriceVolume int32
 	}
 
+	excluded := s.excludedTypes(params.ExcludeTypeIDs, params.ExcludeMarketGroupIDs)
 	for typeID, sells := range idx.sellByType {
-		if isMarketDisabledType(typeID) {
+		if isMarketDisabledType(typeID) || excluded[typeID] {
 			continue
 		}
 		buys := idx.buyByType[typeID]
@@ -525,12 +619,17 @@ func (s *Scanner) calculateResults(
 					continue
 				}
 
+				// Cargo is the binding limit only when depth and budget
+				// would both allow more units than fit in the hold.
 				units := maxUnits
-				if sell.VolumeRemain < units {
+				cargoLimited := true
+				if sell.VolumeRemain <= units {
 					units = sell.VolumeRemain
+					cargoLimited = false
 				}
-				if buy.VolumeRemain < units {
+				if buy.VolumeRemain <= units {
 					units = buy.VolumeRemain
+					cargoLimited = false
 				}
 
 				// MaxInvestment filter
@@ -539,8 +638,9 @@ func (s *Scanner) calculateResults(
 					if maxAfford <= 0 {
 						continue
 					}
-					if units > maxAfford {
+					if units >= maxAfford {
 						units = maxAfford
+						cargoLimited = false
 					}
 				}
 
@@ -622,6 +722,8 @@ func (s *Scanner) calculateResults(
 					ProfitPerUnit:    profitPerUnit,
 					MarginPercent:    margin,
 					UnitsToBuy:       units,
+					CargoLimitedQty:  maxUnits,
+					CargoLimited:     cargoLimited,
 					BuyOrderRemain:   buy.VolumeRemain,
 					SellOrderRemain:  sell.VolumeRemain,
 					TotalProfit:      totalProfit,
@@ -642,6 +744,8 @@ func (s *Scanner) calculateResults(
 	// Flatten deduped results
 	results := make([]FlipResult, 0, len(bestPairs))
 	for _, r := range bestPairs {
+		r.FeeBreakdown = buildFeeBreakdown(feeInputs, r.BuyPrice, r.SellPrice)
+		r.InstantSellProfit = instantSellProfitPerUnit(feeInputs, r.BuyPrice, r.BestBidPrice)
 		results = append(results, *r)
 	}
 	log.Printf("[DEBUG] found %d results before sort/trim", len(results))
@@ -717,6 +821,8 @@ func (s *Scanner) calculateResults(
 			r.RealMarginPercent = realMarginPct
 
 			if safeQty != requestedQty {
+				// Book depth or profitability bound the trade below the hold.
+				r.CargoLimited = false
 				r.UnitsToBuy = safeQty
 				r.TotalProfit = r.ProfitPerUnit * float64(safeQty)
 				if r.TotalJumps > 0 {
@@ -775,6 +881,8 @@ func (s *Scanner) calculateResults(
 		}
 	}
 
+	emitResultBatches(params.OnBatch, results)
+
 	// Enrich with market history (volume, velocity, trend)
 	s.enrichWithHistory(results, progress)
 
@@ -809,6 +917,10 @@ func (s *Scanner) calculateResults(
 		results[i].DailyProfit = profitPerUnit * float64(sellablePerDay)
 	}
 
+	for i := range results {
+		setHaulingEfficiency(&results[i])
+	}
+
 	// Post-filter: min daily volume
 	needsHistory := params.MinDailyVolume > 0 ||
 		params.MinS2BPerDay > 0 ||
@@ -874,9 +986,21 @@ func (s *Scanner) calculateResults(
 	return results, nil
 }
 
+// emitResultBatches hands results to onBatch ResultBatchSize rows at a time.
+// Each batch is a copy: the caller's rows keep being enriched in place.
+func emitResultBatches(onBatch func([]FlipResult), results []FlipResult) {
+	if onBatch == nil {
+		return
+	}
+	for start := 0; start < len(results); start += ResultBatchSize {
+		end := min(start+ResultBatchSize, len(results))
+		onBatch(append([]FlipResult(nil), results[start:end]...))
+	}
+}
+
 // fetchOrders is the legacy blocking version, kept for non-scan callers.
 func (s *Scanner) fetchOrders(regions map[int32]bool, orderType string, validSystems map[int32]int) []esi.MarketOrder {
-	ch := s.fetchOrdersStream(regions, orderType, validSystems)
+	ch := s.fetchOrdersStream(regions, orderType, validSystems, false)
 	var all []esi.MarketOrder
 	for batch := range ch {
 		all = append(all, batch...)
@@ -889,6 +1013,26 @@ func (s *Scanner) jumpsBetween(from, to int32) int {
 	return s.jumpsBetweenWithSecurity(from, to, 0)
 }
 
+// setHaulingEfficiency fills ISKPerM3 and ISKPerJump from the depth-aware
+// profit (RealProfit, falling back to TotalProfit) and the quantity actually
+// hauled. Zero-volume types get no ISKPerM3; same-system trades count as one
+// jump so they are not ranked as worthless.
+func setHaulingEfficiency(r *FlipResult) {
+	profit := r.RealProfit
+	if profit <= 0 {
+		profit = r.TotalProfit
+	}
+	units := r.FilledQty
+	if units <= 0 {
+		units = r.UnitsToBuy
+	}
+	r.ISKPerM3 = 0
+	if m3 := r.Volume * float64(units); m3 > 0 {
+		r.ISKPerM3 = sanitizeFloat(profit / m3)
+	}
+	r.ISKPerJump = sanitizeFloat(profit / float64(max(r.TotalJumps, 1)))
+}
+
 // jumpsBetweenWithSecurity returns jump count using only systems with security >= minSecurity (0 = no filter).
 func (s *Scanner) jumpsBetweenWithSecurity(from, to int32, minSecurity float64) int {
 	var d int
@@ -239,6 +239,78 @@ func TestFindSafeExecutionQuantity_NoProfitableQty(t *testing.T) {
 	}
 }
 
+// twoStationFlipFixture is a Tritanium (0.01 m3) book with asks in system 1
+// and bids one jump away in system 2.
+func twoStationFlipFixture() (*Scanner, *scanIndex) {
+	u := graph.NewUniverse()
+	u.SetRegion(1, 10000002)
+	u.SetRegion(2, 10000002)
+	u.SetSecurity(1, 0.9)
+	u.SetSecurity(2, 0.9)
+	u.AddGate(1, 2)
+	u.AddGate(2, 1)
+
+	scanner := &Scanner{
+		SDE: &sde.Data{
+			Universe: u,
+			Systems: map[int32]*sde.SolarSystem{
+				1: {ID: 1, Name: "Alpha", RegionID: 10000002},
+				2: {ID: 2, Name: "Beta", RegionID: 10000002},
+			},
+			Types: map[int32]*sde.ItemType{
+				34: {ID: 34, Name: "Tritanium", Volume: 0.01},
+			},
+		},
+		ESI: esi.NewClient(nil),
+	}
+
+	const (
+		typeID       = int32(34)
+		buyLocID     = int64(100000000001)
+		sellLocID    = int64(100000000002)
+		buySystemID  = int32(1)
+		sellSystemID = int32(2)
+	)
+
+	asks := []esi.MarketOrder{
+		{TypeID: typeID, LocationID: buyLocID, SystemID: buySystemID, Price: 10, VolumeRemain: 5},
+		{TypeID: typeID, LocationID: buyLocID, SystemID: buySystemID, Price: 10, VolumeRemain: 7},
+		{TypeID: typeID, LocationID: buyLocID, SystemID: buySystemID, Price: 11, VolumeRemain: 20},
+	}
+	bids := []esi.MarketOrder{
+		{TypeID: typeID, LocationID: sellLocID, SystemID: sellSystemID, Price: 15, VolumeRemain: 4, IsBuyOrder: true},
+		{TypeID: typeID, LocationID: sellLocID, SystemID: sellSystemID, Price: 15, VolumeRemain: 6, IsBuyOrder: true},
+		{TypeID: typeID, LocationID: sellLocID, SystemID: sellSystemID, Price: 14, VolumeRemain: 50, IsBuyOrder: true},
+	}
+
+	idx := &scanIndex{
+		sellByType: map[int32][]sellInfo{
+			typeID: {
+				{Price: 10, VolumeRemain: 5, LocationID: buyLocID, SystemID: buySystemID},
+				{Price: 10, VolumeRemain: 7, LocationID: buyLocID, SystemID: buySystemID},
+				{Price: 11, VolumeRemain: 20, LocationID: buyLocID, SystemID: buySystemID},
+			},
+		},
+		buyByType: map[int32][]buyInfo{
+			typeID: {
+				{Price: 15, VolumeRemain: 4, LocationID: sellLocID, SystemID: sellSystemID},
+				{Price: 15, VolumeRemain: 6, LocationID: sellLocID, SystemID: sellSystemID},
+				{Price: 14, VolumeRemain: 50, LocationID: sellLocID, SystemID: sellSystemID},
+			},
+		},
+		sellOrders: asks,
+		buyOrders:  bids,
+		sellSideBuyDepthByType: map[int32]int64{
+			typeID: 60,
+		},
+		sellSideSellDepthByType: map[int32]int64{
+			typeID: 32,
+		},
+	}
+
+	return scanner, idx
+}
+
 func TestCalculateResults_TracksBestLevelPriceAndQty(t *testing.T) {
 	u := graph.NewUniverse()
 	u.SetRegion(1, 10000002)
@@ -342,6 +414,141 @@ func TestCalculateResults_TracksBestLevelPriceAndQty(t *testing.T) {
 	}
 }
 
+func TestCalculateResults_InstantSellProfit(t *testing.T) {
+	scanner, idx := twoStationFlipFixture()
+	params := ScanParams{
+		CurrentSystemID: 1,
+		CargoCapacity:   1_000_000,
+		MinMargin:       0.1,
+	}
+
+	results, err := scanner.calculateResults(params, idx, map[int32]int{1: 0}, func(string) {})
+	if err != nil {
+		t.Fatalf("calculateResults error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("len(results) = %d, want 1", len(results))
+	}
+	if got := results[0].InstantSellProfit; got != 5 {
+		t.Fatalf("InstantSellProfit = %v, want 5 (bid 15 - ask 10, no fees)", got)
+	}
+}
+
+func TestCalculateResults_ISKPerM3AndJump(t *testing.T) {
+	scanner, idx := twoStationFlipFixture()
+	params := ScanParams{
+		CurrentSystemID: 1,
+		CargoCapacity:   1_000_000,
+		MinMargin:       0.1,
+	}
+
+	results, err := scanner.calculateResults(params, idx, map[int32]int{1: 0}, func(string) {})
+	if err != nil {
+		t.Fatalf("calculateResults error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("len(results) = %d, want 1", len(results))
+	}
+
+	r := results[0]
+	if r.FilledQty <= 0 || r.RealProfit <= 0 {
+		t.Fatalf("expected depth-aware execution fields to be populated, got FilledQty=%d RealProfit=%f", r.FilledQty, r.RealProfit)
+	}
+	if want := r.RealProfit / (0.01 * float64(r.FilledQty)); math.Abs(r.ISKPerM3-want) > 1e-6 {
+		t.Fatalf("ISKPerM3 = %f, want %f", r.ISKPerM3, want)
+	}
+	if want := r.RealProfit / float64(max(r.TotalJumps, 1)); math.Abs(r.ISKPerJump-want) > 1e-6 {
+		t.Fatalf("ISKPerJump = %f, want %f", r.ISKPerJump, want)
+	}
+}
+
+func TestCalculateResults_CapsQuantityToCargo(t *testing.T) {
+	scanner, idx := twoStationFlipFixture()
+	bfs := map[int32]int{1: 0}
+
+	// 0.05 m3 holds 5 units of 0.01 m3 Tritanium; the books offer far more.
+	results, err := scanner.calculateResults(ScanParams{CurrentSystemID: 1, CargoCapacity: 0.05, MinMargin: 0.1}, idx, bfs, func(string) {})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("calculateResults = %d results, err %v; want 1", len(results), err)
+	}
+	r := results[0]
+	if r.CargoLimitedQty != 5 || r.UnitsToBuy != 5 || !r.CargoLimited {
+		t.Fatalf("CargoLimitedQty/UnitsToBuy/CargoLimited = %d/%d/%v, want 5/5/true", r.CargoLimitedQty, r.UnitsToBuy, r.CargoLimited)
+	}
+	// 5 units bought at 10 and sold at 15, no fees.
+	if r.FilledQty != 5 || math.Abs(r.RealProfit-25) > 1e-9 || math.Abs(r.ExpectedProfit-25) > 1e-9 {
+		t.Fatalf("FilledQty/RealProfit/ExpectedProfit = %d/%v/%v, want 5/25/25", r.FilledQty, r.RealProfit, r.ExpectedProfit)
+	}
+
+	// A big hold is bound by book depth instead.
+	results, err = scanner.calculateResults(ScanParams{CurrentSystemID: 1, CargoCapacity: 1_000_000, MinMargin: 0.1}, idx, bfs, func(string) {})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("calculateResults = %d results, err %v; want 1", len(results), err)
+	}
+	if results[0].CargoLimited {
+		t.Errorf("CargoLimited = true with a %d-unit hold and a shallower book", results[0].CargoLimitedQty)
+	}
+}
+
+func TestCalculateResults_EmitsProvisionalBatches(t *testing.T) {
+	scanner, idx := twoStationFlipFixture()
+	var batches [][]FlipResult
+	params := ScanParams{
+		CurrentSystemID: 1,
+		CargoCapacity:   1_000_000,
+		MinMargin:       0.1,
+		OnBatch:         func(batch []FlipResult) { batches = append(batches, batch) },
+	}
+	results, err := scanner.calculateResults(params, idx, map[int32]int{1: 0}, func(string) {})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("calculateResults = %d results, err %v; want 1", len(results), err)
+	}
+	if len(batches) != 1 || len(batches[0]) != 1 {
+		t.Fatalf("batches = %v, want one batch with the single row", batches)
+	}
+	if batches[0][0].TypeID != results[0].TypeID || batches[0][0].BuyStation == "" {
+		t.Fatalf("batch row = %+v, want the priced row with station names", batches[0][0])
+	}
+}
+
+func TestEmitResultBatches(t *testing.T) {
+	emitResultBatches(nil, make([]FlipResult, 10)) // nil callback is a no-op
+
+	results := make([]FlipResult, 2*ResultBatchSize+100)
+	var sizes []int
+	var first []FlipResult
+	emitResultBatches(func(batch []FlipResult) {
+		if first == nil {
+			first = batch
+		}
+		sizes = append(sizes, len(batch))
+	}, results)
+	if len(sizes) != 3 || sizes[0] != ResultBatchSize || sizes[1] != ResultBatchSize || sizes[2] != 100 {
+		t.Fatalf("batch sizes = %v", sizes)
+	}
+	results[0].TypeID = 34
+	if first[0].TypeID != 0 {
+		t.Fatal("batches must not alias the rows still being enriched")
+	}
+}
+
+func TestSetHaulingEfficiency_EdgeCases(t *testing.T) {
+	r := FlipResult{Volume: 5, UnitsToBuy: 100, FilledQty: 40, TotalProfit: 9000, RealProfit: 4000, TotalJumps: 8}
+	setHaulingEfficiency(&r)
+	if r.ISKPerM3 != 20 || r.ISKPerJump != 500 {
+		t.Fatalf("ISKPerM3/ISKPerJump = %v/%v, want 20/500 from RealProfit over filled qty", r.ISKPerM3, r.ISKPerJump)
+	}
+
+	r = FlipResult{Volume: 0, UnitsToBuy: 10, TotalProfit: 1000, TotalJumps: 0}
+	setHaulingEfficiency(&r)
+	if r.ISKPerM3 != 0 {
+		t.Errorf("zero-volume ISKPerM3 = %v, want 0", r.ISKPerM3)
+	}
+	if r.ISKPerJump != 1000 {
+		t.Errorf("same-system ISKPerJump = %v, want the full profit", r.ISKPerJump)
+	}
+}
+
 func TestHarmonicDailyShare_MonotoneAndBounded(t *testing.T) {
 	const daily = int64(10_000)
 	if got := harmonicDailyShare(0, 5); got != 0 {
@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"fmt"
 	"math"
 	"sort"
 	"strings"
@@ -345,12 +346,12 @@ func countTightSpreadOrders(orders []esi.MarketOrder) int {
 //   - CI         (10%): Competition matters but is partially captured by spread
 //     compression already; kept at lower weight to avoid double-counting.
 type CTSWeights struct {
-	SpreadROI float64
-	OBDS      float64
-	DRVI      float64
-	CI        float64
-	SDS       float64
-	Volume    float64
+	SpreadROI float64 `json:"spread_roi"`
+	OBDS      float64 `json:"obds"`
+	DRVI      float64 `json:"drvi"`
+	CI        float64 `json:"ci"`
+	SDS       float64 `json:"sds"`
+	Volume    float64 `json:"volume"`
 }
 
 var DefaultCTSWeights = CTSWeights{
@@ -368,6 +369,44 @@ const (
 	CTSProfileDefensive  = "defensive"
 )
 
+// IsBuiltinCTSProfile reports whether profile names one of the preset
+// weightings (case-insensitive). Custom profiles may not reuse these names.
+func IsBuiltinCTSProfile(profile string) bool {
+	switch strings.ToLower(strings.TrimSpace(profile)) {
+	case CTSProfileBalanced, CTSProfileAggressive, CTSProfileDefensive:
+		return true
+	}
+	return false
+}
+
+// ValidateCTSWeights rejects weights that CalcCTSWithWeights would silently
+// repair: negative, non-finite or all-zero components. Weights need not sum
+// to 1; they are normalized when scoring.
+func ValidateCTSWeights(w CTSWeights) error {
+	components := []struct {
+		name  string
+		value float64
+	}{
+		{"spread_roi", w.SpreadROI},
+		{"obds", w.OBDS},
+		{"drvi", w.DRVI},
+		{"ci", w.CI},
+		{"sds", w.SDS},
+		{"volume", w.Volume},
+	}
+	total := 0.0
+	for _, c := range components {
+		if math.IsNaN(c.value) || math.IsInf(c.value, 0) || c.value < 0 {
+			return fmt.Errorf("%s weight must be a non-negative number", c.name)
+		}
+		total += c.value
+	}
+	if total <= 0 {
+		return fmt.Errorf("at least one weight must be positive")
+	}
+	return nil
+}
+
 func normalizeCTSProfile(profile string) string {
 	switch strings.ToLower(strings.TrimSpace(profile)) {
 	case CTSProfileAggressive:
@@ -397,3 +397,22 @@ func TestIsExtremePrice_Threshold(t *testing.T) {
 		t.Fatalf("avgPrice<=0 should never be extreme")
 	}
 }
+
+func TestValidateCTSWeights(t *testing.T) {
+	if err := ValidateCTSWeights(CTSWeights{SpreadROI: 2, Volume: 1}); err != nil {
+		t.Fatalf("valid weights rejected: %v", err)
+	}
+	for _, w := range []CTSWeights{
+		{},
+		{SpreadROI: 1, SDS: -0.1},
+		{SpreadROI: math.Inf(1)},
+		{OBDS: math.NaN()},
+	} {
+		if err := ValidateCTSWeights(w); err == nil {
+			t.Fatalf("ValidateCTSWeights(%+v) = nil, want error", w)
+		}
+	}
+	if !IsBuiltinCTSProfile(" Balanced ") || IsBuiltinCTSProfile("custom") {
+		t.Fatal("IsBuiltinCTSProfile mismatch")
+	}
+}
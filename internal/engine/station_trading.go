@@ -33,6 +33,12 @@ var (
 		}
 		return c.FetchMarketHistory(regionID, typeID)
 	}
+	stationFetchStructureOrders = func(c *esi.Client, characterID, structureID int64, accessToken string) ([]esi.MarketOrder, error) {
+		if c == nil {
+			return nil, fmt.Errorf("nil ESI client")
+		}
+		return c.FetchStructureOrders(characterID, structureID, accessToken)
+	}
 	stationPrefetchNPCNames = func(c *esi.Client, ids map[int64]bool) {
 		if c == nil {
 			return
@@ -70,6 +76,15 @@ type StationTrade struct {
 	SellVolume     int64   `json:"SellVolume"` // total volume of sell orders
 	TotalProfit    float64 `json:"TotalProfit"`
 	DailyProfit    float64 `json:"DailyProfit"` // estimated executable daily profit
+	// InstantSellProfit is the per-unit result of buying at the bid and
+	// dumping straight back into the best buy order (sales tax, no sell
+	// broker fee) instead of listing at the ask. Usually negative: it is
+	// the price of liquidating now rather than waiting for ProfitPerUnit.
+	InstantSellProfit float64 `json:"InstantSellProfit"`
+	// EstimatedISKPerHour spreads one buy-then-sell cycle's profit over the
+	// time both orders take to fill (see stationISKPerHour). 0 without
+	// market history or when either side has no flow.
+	EstimatedISKPerHour float64 `json:"EstimatedISKPerHour"`
 	// TheoreticalDailyProfit is spread-only maker estimate (before execution realism).
 	TheoreticalDailyProfit float64 `json:"TheoreticalDailyProfit,omitempty"`
 	// RealizableDailyProfit is conservative realizable estimate used for KPI.
@@ -89,6 +104,8 @@ type StationTrade struct {
 	StationID        int64   `json:"StationID"`
 	SystemID         int32   `json:"SystemID,omitempty"`
 	RegionID         int32   `json:"RegionID,omitempty"`
+	// IsStructure marks rows priced from a player-owned structure market.
+	IsStructure bool `json:"IsStructure"`
 
 	// --- EVE Guru style metrics ---
 	CapitalRequired float64 `json:"CapitalRequired"` // Cycle capital: effectiveBuy * tradableUnits
@@ -120,6 +137,9 @@ type StationTrade struct {
 	// Risk flags
 	IsExtremePriceFlag bool `json:"IsExtremePriceFlag"` // Anomalous price detected
 	IsHighRiskFlag     bool `json:"IsHighRiskFlag"`     // SDS >= 50
+	// RestockSignal marks items the station is close to selling out of: DOS
+	// (listed sell volume / daily traded volume) is below params.RestockDays.
+	RestockSignal bool `json:"RestockSignal,omitempty"`
 
 	// Execution-plan derived (expected fill prices from order book depth)
 	ExpectedBuyPrice  float64 `json:"ExpectedBuyPrice,omitempty"`
@@ -130,6 +150,11 @@ type StationTrade struct {
 	CanFill           bool    `json:"CanFill"`                  // whether target quantity is fully fillable
 	SlippageBuyPct    float64 `json:"SlippageBuyPct,omitempty"`
 	SlippageSellPct   float64 `json:"SlippageSellPct,omitempty"`
+	// Per-unit fee components behind ProfitPerUnit (bid/ask prices).
+	FeeBreakdown *FeeBreakdown `json:"fee_breakdown,omitempty"`
+	// PriceSource is "esi" for live order books, or the fallback provider
+	// name when prices came from aggregates and are only approximate.
+	PriceSource string `json:"PriceSource,omitempty"`
 }
 
 // stationSortProxy returns a pre-history ranking score for a StationTrade.
@@ -334,6 +359,21 @@ func stationExecutionDesiredQtyFromDailyShare(dailyShare int64, buyVolume, sellV
 	return stationExecutionDesiredQty(dailyShare, buyVolume, sellVolume)
 }
 
+// stationISKPerHour estimates the hourly profit of trading qty units: the buy
+// order fills first, then the relisted sell order, each at our share of that
+// side's daily flow. Both ETAs use the order-desk model with an empty queue,
+// since a fresh order is placed at the top of the book. Returns 0 when either
+// ETA is unknown.
+func stationISKPerHour(profitPerUnit float64, qty int64, buyFillPerDay, sellFillPerDay float64) float64 {
+	buyETA := orderETADays(0, qty, buyFillPerDay)
+	sellETA := orderETADays(0, qty, sellFillPerDay)
+	if buyETA < 0 || sellETA < 0 {
+		return 0
+	}
+	hours := (buyETA + sellETA) * 24
+	return sanitizeFloat(profitPerUnit * float64(qty) / hours)
+}
+
 func stationFlowPerDay(entries []esi.HistoryEntry) float64 {
 	return avgDailyVolume(entries, stationFlowWindowDays)
 }
@@ -361,8 +401,9 @@ type StationTradeParams struct {
 	RegionID        int32
 	MinMargin       float64
 	SalesTaxPercent float64
-	BrokerFee       float64 // percent
-	CTSProfile      string  // balanced|aggressive|defensive
+	BrokerFee       float64     // percent
+	CTSProfile      string      // balanced|aggressive|defensive
+	CTSWeights      *CTSWeights // explicit weights (custom profile); overrides CTSProfile
 	// SplitTradeFees enables side-specific fee model.
 	// When false, legacy fields above are used.
 	SplitTradeFees       bool
@@ -370,7 +411,8 @@ type StationTradeParams struct {
 	SellBrokerFeePercent float64
 	BuySalesTaxPercent   float64
 	SellSalesTaxPercent  float64
-	MinDailyVolume       int64 // 0 = no filter
+	MinDailyVolume       int64   // 0 = no filter
+	MinISKPerHour        float64 // min EstimatedISKPerHour; 0 = no filter
 
 	// --- EVE Guru Profit Filters ---
 	MinItemProfit   float64 // Min profit per unit ISK (e.g. 1,000,000)
@@ -385,19 +427,30 @@ type StationTradeParams struct {
 	BvSRatioMax    float64 // Max B v S Ratio (e.g. 2.0)
 	MaxPVI         float64 // Max volatility % (e.g. 25%)
 	MaxSDS         int     // Max scam score (e.g. 40)
+	RestockDays    float64 // Set RestockSignal when DOS is below this; 0 = off
 
 	// --- Price Limits ---
 	LimitBuyToPriceLow bool // Don't buy above P.Low + 10%
 	FlagExtremePrices  bool // Flag anomalous prices
 
 	// --- Authentication ---
-	AccessToken string // For resolving player structure names (optional)
+	AccessToken string `json:"-"` // For structure names and structure market orders (optional)
+	CharacterID int64  `json:"-"` // Owner of AccessToken; scopes cached structure orders
 
 	// IncludeStructures controls whether player-owned structures are considered.
 	IncludeStructures bool
+	// ExcludeNPCOrders drops NPC-seeded orders so they do not set the bid/ask.
+	ExcludeNPCOrders bool
+	// User exclusions, applied on top of the market-disabled list.
+	ExcludeTypeIDs        []int32
+	ExcludeMarketGroupIDs []int32 // includes every descendant market group
+
+	// PriceFallback, when set, supplies aggregate hub prices if ESI returns
+	// an error or no orders for the region.
+	PriceFallback esi.PriceProvider `json:"-"`
 
 	// Ctx allows cooperative cancellation for long-running station scans.
-	Ctx context.Context
+	Ctx context.Context `json:"-"`
 }
 
 // ScanStationTrades finds profitable same-station trading opportunities.
@@ -407,6 +460,71 @@ func isPlayerStructureID(id int64) bool {
 	return id > 1_000_000_000_000
 }
 
+// mergeStructureOrders adds the authenticated market orders of the player
+// structures listed in params.StationIDs. The public region endpoint omits
+// structures whose market is not public, so each structure in the scanned
+// region is fetched directly; orders already present are not duplicated.
+// Structures in other regions, or whose location is unknown, are skipped, as
+// are structures the token has no market access to.
+func (s *Scanner) mergeStructureOrders(params StationTradeParams, orders []esi.MarketOrder, progress func(string)) []esi.MarketOrder {
+	if !params.IncludeStructures || params.AccessToken == "" {
+		return orders
+	}
+	structureIDs := make(map[int64]bool)
+	for id := range params.StationIDs {
+		if isPlayerStructureID(id) {
+			structureIDs[id] = true
+		}
+	}
+	if len(structureIDs) == 0 {
+		return orders
+	}
+
+	// Resolving names also records each structure's solar system.
+	stationPrefetchStructureNames(s.ESI, structureIDs, params.AccessToken)
+
+	seen := make(map[int64]bool, len(orders))
+	for _, o := range orders {
+		seen[o.OrderID] = true
+	}
+	ids := make([]int64, 0, len(structureIDs))
+	for id := range structureIDs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	added := 0
+	for _, id := range ids {
+		structOrders, err := stationFetchStructureOrders(s.ESI, params.CharacterID, id, params.AccessToken)
+		if err != nil {
+			log.Printf("[StationTrades] structure %d market unavailable: %v", id, err)
+			continue
+		}
+		for _, o := range structOrders {
+			if seen[o.OrderID] || !s.systemInRegion(o.SystemID, params.RegionID) {
+				continue
+			}
+			o.RegionID = params.RegionID
+			seen[o.OrderID] = true
+			orders = append(orders, o)
+			added++
+		}
+	}
+	if added > 0 {
+		progress(fmt.Sprintf("Added %d structure market orders", added))
+	}
+	return orders
+}
+
+// systemInRegion reports whether the SDE places systemID in regionID.
+func (s *Scanner) systemInRegion(systemID, regionID int32) bool {
+	if s.SDE == nil || systemID == 0 {
+		return false
+	}
+	sys, ok := s.SDE.Systems[systemID]
+	return ok && sys.RegionID == regionID
+}
+
 func (s *Scanner) ScanStationTrades(params StationTradeParams, progress func(string)) ([]StationTrade, error) {
 	checkCanceled := func() error {
 		if params.Ctx == nil {
@@ -425,12 +543,25 @@ func (s *Scanner) ScanStationTrades(params StationTradeParams, progress func(str
 
 	// Fetch all orders for the region
 	allOrders, err := stationFetchRegionOrders(s.ESI, params.RegionID, "all")
+	priceSource := esi.PriceSourceESI
+	if (err != nil || len(allOrders) == 0) && params.PriceFallback != nil {
+		if fallback := s.stationFallbackOrders(params, progress); len(fallback) > 0 {
+			if err != nil {
+				log.Printf("[StationTrades] ESI orders failed for region %d, using %s: %v", params.RegionID, params.PriceFallback.Name(), err)
+			}
+			allOrders, err = fallback, nil
+			priceSource = params.PriceFallback.Name()
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("fetch orders: %w", err)
 	}
 	if err := checkCanceled(); err != nil {
 		return nil, err
 	}
+	if priceSource == esi.PriceSourceESI {
+		allOrders = s.mergeStructureOrders(params, allOrders, progress)
+	}
 
 	progress(fmt.Sprintf("Processing %d orders...", len(allOrders)))
 
@@ -442,6 +573,7 @@ func (s *Scanner) ScanStationTrades(params StationTradeParams, progress func(str
 	fullRegionDepthByType := make(map[int32]int64)
 
 	filterStations := len(params.StationIDs) > 0
+	excluded := s.excludedTypes(params.ExcludeTypeIDs, params.ExcludeMarketGroupIDs)
 
 	for idx, o := range allOrders {
 		if idx%4096 == 0 {
@@ -449,7 +581,10 @@ func (s *Scanner) ScanStationTrades(params StationTradeParams, progress func(str
 				return nil, err
 			}
 		}
-		if isMarketDisabledType(o.TypeID) {
+		if isMarketDisabledType(o.TypeID) || excluded[o.TypeID] {
+			continue
+		}
+		if params.ExcludeNPCOrders && IsNPCSeededOrder(o) {
 			continue
 		}
 		fullRegionDepthByType[o.TypeID] += int64(o.VolumeRemain)
@@ -495,7 +630,7 @@ func (s *Scanner) ScanStationTrades(params StationTradeParams, progress func(str
 
 	progress(fmt.Sprintf("Analyzing %d items...", len(groups)))
 
-	buyCostMult, sellRevenueMult := tradeFeeMultipliers(tradeFeeInputs{
+	feeInputs := tradeFeeInputs{
 		SplitTradeFees:       params.SplitTradeFees,
 		BrokerFeePercent:     params.BrokerFee,
 		SalesTaxPercent:      params.SalesTaxPercent,
@@ -503,7 +638,8 @@ func (s *Scanner) ScanStationTrades(params StationTradeParams, progress func(str
 		SellBrokerFeePercent: params.SellBrokerFeePercent,
 		BuySalesTaxPercent:   params.BuySalesTaxPercent,
 		SellSalesTaxPercent:  params.SellSalesTaxPercent,
-	})
+	}
+	buyCostMult, sellRevenueMult := tradeFeeMultipliers(feeInputs)
 
 	var results []StationTrade
 	// Store order groups for advanced metrics calculation
@@ -623,10 +759,14 @@ func (s *Scanner) ScanStationTrades(params StationTradeParams, progress func(str
 			StationID:       key.locationID,
 			SystemID:        systemID,
 			RegionID:        params.RegionID,
+			IsStructure:     isPlayerStructureID(key.locationID),
 			CapitalRequired: sanitizeFloat(capitalRequired),
 			NowROI:          sanitizeFloat(margin), // initial fallback; refined from execution plans below
 			CI:              ci,
 			OBDS:            sanitizeFloat(obds),
+			FeeBreakdown:    buildFeeBreakdown(feeInputs, costToBuy, revenueFromSell),
+			// Dumping into the bid instead of listing at the ask.
+			InstantSellProfit: instantSellProfitPerUnit(feeInputs, costToBuy, highestBuy.Price),
 			// History-dependent fields will be calculated in enrichStationWithHistory
 		})
 
@@ -749,10 +889,76 @@ func (s *Scanner) ScanStationTrades(params StationTradeParams, progress func(str
 		log.Printf("[WARN] sanitizeFloat replaced %d NaN/Inf values during station scan", replaced)
 	}
 
+	for i := range results {
+		results[i].PriceSource = priceSource
+	}
+
 	progress(fmt.Sprintf("Found %d station trading opportunities", len(results)))
 	return results, nil
 }
 
+// stationFallbackOrders builds a synthetic order book at the region's trade
+// hub from aggregate prices: one sell order at the best ask and one buy order
+// at the best bid per type, each carrying the full side depth. Returns nil for
+// non-hub regions or when the provider has nothing.
+func (s *Scanner) stationFallbackOrders(params StationTradeParams, progress func(string)) []esi.MarketOrder {
+	stationID, ok := esi.TradeHubStations[params.RegionID]
+	if !ok || s.SDE == nil {
+		return nil
+	}
+	var systemID int32
+	if st := s.SDE.Stations[stationID]; st != nil {
+		systemID = st.SystemID
+	}
+	typeIDs := make([]int32, 0, len(s.SDE.Types))
+	for id := range s.SDE.Types {
+		if !isMarketDisabledType(id) {
+			typeIDs = append(typeIDs, id)
+		}
+	}
+	sort.Slice(typeIDs, func(i, j int) bool { return typeIDs[i] < typeIDs[j] })
+
+	ctx := params.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	progress(fmt.Sprintf("ESI orders unavailable, loading %s prices...", params.PriceFallback.Name()))
+	aggs, err := params.PriceFallback.Aggregates(ctx, params.RegionID, stationID, typeIDs)
+	if err != nil {
+		log.Printf("[StationTrades] %s fallback for region %d: %v", params.PriceFallback.Name(), params.RegionID, err)
+	}
+
+	orders := make([]esi.MarketOrder, 0, 2*len(aggs))
+	var nextID int64
+	add := func(typeID int32, price float64, volume int64, isBuy bool) {
+		if price <= 0 || volume <= 0 {
+			return
+		}
+		nextID--
+		orders = append(orders, esi.MarketOrder{
+			OrderID:      nextID, // negative IDs never collide with real orders
+			TypeID:       typeID,
+			LocationID:   stationID,
+			SystemID:     systemID,
+			RegionID:     params.RegionID,
+			Price:        price,
+			VolumeRemain: int32(minInt64(volume, math.MaxInt32)),
+			MinVolume:    1,
+			IsBuyOrder:   isBuy,
+			Duration:     maxPlayerOrderDurationDays,
+		})
+	}
+	for _, id := range typeIDs {
+		agg, ok := aggs[id]
+		if !ok {
+			continue
+		}
+		add(id, agg.SellMin, agg.SellVolume, false)
+		add(id, agg.BuyMax, agg.BuyVolume, true)
+	}
+	return orders
+}
+
 // applyStationTradeFilters applies post-history filters based on params.
 func applyStationTradeFilters(results []StationTrade, params StationTradeParams) []StationTrade {
 	filtered := make([]StationTrade, 0, len(results))
@@ -768,10 +974,11 @@ func applyStationTradeFilters(results []StationTrade, params StationTradeParams)
 		params.BvSRatioMax > 0 ||
 		params.MaxPVI > 0 ||
 		params.MaxSDS > 0 ||
+		params.MinISKPerHour > 0 ||
 		params.LimitBuyToPriceLow
 
 	// Debug counters
-	var dropExecution, dropHistory, dropMargin, dropItemProfit, dropVol, dropS2B, dropBfS, dropROI, dropBvS, dropPVI, dropSDS, dropPrice int
+	var dropExecution, dropHistory, dropMargin, dropItemProfit, dropVol, dropS2B, dropBfS, dropROI, dropBvS, dropPVI, dropSDS, dropPrice, dropISKHour int
 
 	for _, r := range results {
 		// Station trading is a maker strategy (buy at bid, sell at ask). If
@@ -845,6 +1052,11 @@ func applyStationTradeFilters(results []StationTrade, params StationTradeParams)
 			dropSDS++
 			continue
 		}
+		// Min ISK/hour over the estimated turnover time
+		if params.MinISKPerHour > 0 && r.EstimatedISKPerHour < params.MinISKPerHour {
+			dropISKHour++
+			continue
+		}
 		// Price limit filter: don't place buy order above historical low + 10%
 		if params.LimitBuyToPriceLow && r.PriceLow > 0 {
 			maxBuyPrice := r.PriceLow * 1.1
@@ -857,8 +1069,8 @@ func applyStationTradeFilters(results []StationTrade, params StationTradeParams)
 	}
 
 	if len(results) != len(filtered) {
-		log.Printf("[DEBUG] StationFilter drops: execution=%d history=%d margin=%d item_profit=%d vol=%d s2b=%d bfs=%d roi=%d bvs=%d pvi=%d sds=%d price=%d",
-			dropExecution, dropHistory, dropMargin, dropItemProfit, dropVol, dropS2B, dropBfS, dropROI, dropBvS, dropPVI, dropSDS, dropPrice)
+		log.Printf("[DEBUG] StationFilter drops: execution=%d history=%d margin=%d item_profit=%d vol=%d s2b=%d bfs=%d roi=%d bvs=%d pvi=%d sds=%d price=%d isk_hour=%d",
+			dropExecution, dropHistory, dropMargin, dropItemProfit, dropVol, dropS2B, dropBfS, dropROI, dropBvS, dropPVI, dropSDS, dropPrice, dropISKHour)
 	}
 
 	return filtered
@@ -886,6 +1098,9 @@ func (s *Scanner) enrichStationWithHistory(results []StationTrade, regionID int3
 		avgPeriod = 90
 	}
 	ctsWeights := CTSWeightsForProfile(params.CTSProfile)
+	if params.CTSWeights != nil {
+		ctsWeights = *params.CTSWeights
+	}
 	buyCostMult, sellRevenueMult := tradeFeeMultipliers(tradeFeeInputs{
 		SplitTradeFees:       params.SplitTradeFees,
 		BrokerFeePercent:     params.BrokerFee,
@@ -961,6 +1176,7 @@ func (s *Scanner) enrichStationWithHistory(results []StationTrade, regionID int3
 			results[idx].TheoreticalDailyProfit = 0
 			results[idx].RealizableDailyProfit = 0
 			results[idx].DailyProfit = 0
+			results[idx].EstimatedISKPerHour = 0
 			results[idx].TotalProfit = 0
 			results[idx].ConfidenceScore = 0
 			results[idx].ConfidenceLabel = stationConfidenceLabel(0)
@@ -1083,6 +1299,7 @@ func (s *Scanner) enrichStationWithHistory(results []StationTrade, regionID int3
 		// Days of Supply
 		if results[idx].BuyUnitsPerDay > 0 {
 			results[idx].DOS = sanitizeFloat(float64(results[idx].SellVolume) / results[idx].BuyUnitsPerDay)
+			results[idx].RestockSignal = params.RestockDays > 0 && results[idx].DOS < params.RestockDays
 		}
 
 		// Calculate SDS (Scam Detection Score)
@@ -1114,6 +1331,12 @@ func (s *Scanner) enrichStationWithHistory(results []StationTrade, regionID int3
 			results[idx].RealizableDailyProfit = 0
 		}
 		results[idx].DailyProfit = sanitizeFloat(results[idx].RealizableDailyProfit)
+		cycleProfitPerUnit := results[idx].ProfitPerUnit
+		if results[idx].HasExecutionEvidence {
+			cycleProfitPerUnit = results[idx].ExpectedProfit
+		}
+		results[idx].EstimatedISKPerHour = stationISKPerHour(
+			cycleProfitPerUnit, dailyShare, float64(buySideShare), float64(sellSideShare))
 		// TotalProfit: full book spread profit (not daily). Gives the user a
 		// sense of total addressable opportunity on this item/station.
 		tradableUnits := float64(minInt64(results[idx].BuyVolume, results[idx].SellVolume))
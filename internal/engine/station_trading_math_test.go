@@ -220,3 +220,33 @@ func TestStationConfidenceLabelBuckets(t *testing.T) {
 		t.Fatalf("high bucket = %q, want high", got)
 	}
 }
+
+func TestStationISKPerHour(t *testing.T) {
+	// 100 units at 1,000 ISK: buy fills in 100/200 = 0.5 days, the relisted
+	// sell in 100/50 = 2 days, so 100,000 ISK over 60 hours.
+	got := stationISKPerHour(1000, 100, 200, 50)
+	if want := 100000.0 / 60; got < want-1e-9 || got > want+1e-9 {
+		t.Fatalf("isk/hour = %v, want %v", got, want)
+	}
+	if got := stationISKPerHour(1000, 100, 200, 0); got != 0 {
+		t.Fatalf("no sell flow: isk/hour = %v, want 0", got)
+	}
+	if got := stationISKPerHour(1000, 0, 200, 50); got != 0 {
+		t.Fatalf("no quantity: isk/hour = %v, want 0", got)
+	}
+}
+
+func TestApplyStationTradeFilters_MinISKPerHour(t *testing.T) {
+	rows := []StationTrade{
+		{TypeID: 1, HistoryAvailable: true, EstimatedISKPerHour: 500_000},
+		{TypeID: 2, HistoryAvailable: true, EstimatedISKPerHour: 2_000_000},
+		{TypeID: 3, HistoryAvailable: false, EstimatedISKPerHour: 0},
+	}
+	out := applyStationTradeFilters(rows, StationTradeParams{MinISKPerHour: 1_000_000})
+	if len(out) != 1 || out[0].TypeID != 2 {
+		t.Fatalf("kept %+v, want only type 2", out)
+	}
+	if out := applyStationTradeFilters(rows, StationTradeParams{}); len(out) != 3 {
+		t.Fatalf("without threshold kept %d rows, want 3", len(out))
+	}
+}
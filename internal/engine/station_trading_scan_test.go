@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -102,3 +103,190 @@ func TestScanStationTrades_UsesFullRegionDepthWhenStationFiltered(t *testing.T)
 	}
 }
 
+type stubPriceProvider struct {
+	aggs      map[int32]esi.PriceAggregate
+	stationID int64
+}
+
+func (p *stubPriceProvider) Name() string { return esi.PriceSourceFuzzwork }
+
+func (p *stubPriceProvider) Aggregates(_ context.Context, _ int32, stationID int64, _ []int32) (map[int32]esi.PriceAggregate, error) {
+	p.stationID = stationID
+	return p.aggs, nil
+}
+
+func TestScanStationTrades_FallsBackToAggregatePricesWhenESIEmpty(t *testing.T) {
+	const (
+		regionID = int32(10000002)
+		typeID   = int32(34)
+		jita     = int64(60003760)
+	)
+
+	origFetchOrders := stationFetchRegionOrders
+	origPrefetchNPC := stationPrefetchNPCNames
+	origResolveName := stationResolveName
+	origFetchHistory := stationFetchMarketHistory
+	defer func() {
+		stationFetchRegionOrders = origFetchOrders
+		stationPrefetchNPCNames = origPrefetchNPC
+		stationResolveName = origResolveName
+		stationFetchMarketHistory = origFetchHistory
+	}()
+	stationFetchRegionOrders = func(_ *esi.Client, _ int32, _ string) ([]esi.MarketOrder, error) {
+		return nil, fmt.Errorf("ESI 502")
+	}
+	stationPrefetchNPCNames = func(_ *esi.Client, _ map[int64]bool) {}
+	stationResolveName = func(_ *esi.Client, _ int64) string { return "Jita IV - Moon 4" }
+	stationFetchMarketHistory = func(_ *esi.Client, _ int32, _ int32) ([]esi.HistoryEntry, error) {
+		return testHistoryFixedDailyVolume(100), nil
+	}
+
+	scanner := &Scanner{
+		SDE: &sde.Data{
+			Types: map[int32]*sde.ItemType{
+				typeID: {ID: typeID, Name: "Tritanium", Volume: 0.01},
+			},
+			Stations: map[int64]*sde.Station{jita: {ID: jita, SystemID: 30000142}},
+		},
+		History: &testHistoryProvider{
+			store: map[string][]esi.HistoryEntry{
+				fmt.Sprintf("%d:%d", regionID, typeID): testHistoryFixedDailyVolume(100),
+			},
+		},
+	}
+	params := StationTradeParams{RegionID: regionID, MinMargin: 0.1}
+
+	if _, err := scanner.ScanStationTrades(params, func(string) {}); err == nil {
+		t.Fatal("expected ESI error without a fallback provider")
+	}
+
+	fallback := &stubPriceProvider{aggs: map[int32]esi.PriceAggregate{
+		typeID: {TypeID: typeID, BuyMax: 90, BuyVolume: 500, SellMin: 100, SellVolume: 500},
+	}}
+	params.PriceFallback = fallback
+	results, err := scanner.ScanStationTrades(params, func(string) {})
+	if err != nil {
+		t.Fatalf("ScanStationTrades returned error: %v", err)
+	}
+	if fallback.stationID != jita {
+		t.Fatalf("fallback queried station %d, want hub %d", fallback.stationID, jita)
+	}
+	if len(results) != 1 {
+		t.Fatalf("len(results) = %d, want 1", len(results))
+	}
+	row := results[0]
+	if row.PriceSource != esi.PriceSourceFuzzwork || row.StationID != jita || row.SystemID != 30000142 {
+		t.Fatalf("row = source %q station %d system %d", row.PriceSource, row.StationID, row.SystemID)
+	}
+	if row.BuyPrice != 90 || row.SellPrice != 100 {
+		t.Fatalf("bid/ask = %v/%v, want 90/100", row.BuyPrice, row.SellPrice)
+	}
+}
+
+func TestScanStationTrades_MergesStructureMarketOrders(t *testing.T) {
+	const (
+		regionID    = int32(10000002)
+		typeID      = int32(34)
+		structureID = int64(1_035_466_617_946)
+		otherStruct = int64(1_041_000_000_001) // different region
+		systemID    = int32(30000144)
+	)
+
+	origFetchOrders := stationFetchRegionOrders
+	origFetchStructure := stationFetchStructureOrders
+	origPrefetchNPC := stationPrefetchNPCNames
+	origPrefetchStr := stationPrefetchStructureNames
+	origResolveName := stationResolveName
+	origFetchHistory := stationFetchMarketHistory
+	defer func() {
+		stationFetchRegionOrders = origFetchOrders
+		stationFetchStructureOrders = origFetchStructure
+		stationPrefetchNPCNames = origPrefetchNPC
+		stationPrefetchStructureNames = origPrefetchStr
+		stationResolveName = origResolveName
+		stationFetchMarketHistory = origFetchHistory
+	}()
+
+	// The region endpoint only knows one of the structure's orders.
+	stationFetchRegionOrders = func(_ *esi.Client, _ int32, _ string) ([]esi.MarketOrder, error) {
+		return []esi.MarketOrder{
+			{OrderID: 1, TypeID: typeID, LocationID: structureID, SystemID: systemID, Price: 90, VolumeRemain: 50, IsBuyOrder: true},
+		}, nil
+	}
+	var fetched []int64
+	stationFetchStructureOrders = func(_ *esi.Client, _, id int64, token string) ([]esi.MarketOrder, error) {
+		if token != "token" {
+			t.Fatalf("structure fetch token = %q", token)
+		}
+		fetched = append(fetched, id)
+		if id == otherStruct {
+			return []esi.MarketOrder{
+				{OrderID: 9, TypeID: typeID, LocationID: otherStruct, SystemID: 30045349, Price: 10, VolumeRemain: 5, IsBuyOrder: true},
+				{OrderID: 10, TypeID: typeID, LocationID: otherStruct, SystemID: 30045349, Price: 20, VolumeRemain: 5},
+			}, nil
+		}
+		return []esi.MarketOrder{
+			{OrderID: 1, TypeID: typeID, LocationID: structureID, SystemID: systemID, Price: 90, VolumeRemain: 50, IsBuyOrder: true},
+			{OrderID: 2, TypeID: typeID, LocationID: structureID, SystemID: systemID, Price: 100, VolumeRemain: 50},
+		}, nil
+	}
+	stationPrefetchNPCNames = func(_ *esi.Client, _ map[int64]bool) {}
+	stationPrefetchStructureNames = func(_ *esi.Client, _ map[int64]bool, _ string) {}
+	stationResolveName = func(_ *esi.Client, id int64) string {
+		return fmt.Sprintf("Keepstar %d", id)
+	}
+	stationFetchMarketHistory = func(_ *esi.Client, _ int32, _ int32) ([]esi.HistoryEntry, error) {
+		return testHistoryFixedDailyVolume(100), nil
+	}
+
+	scanner := &Scanner{
+		SDE: &sde.Data{
+			Types: map[int32]*sde.ItemType{
+				typeID: {ID: typeID, Name: "Tritanium", Volume: 0.01},
+			},
+			Systems: map[int32]*sde.SolarSystem{
+				systemID: {ID: systemID, RegionID: regionID},
+				30045349: {ID: 30045349, RegionID: 10000069},
+			},
+		},
+		History: &testHistoryProvider{
+			store: map[string][]esi.HistoryEntry{
+				fmt.Sprintf("%d:%d", regionID, typeID): testHistoryFixedDailyVolume(100),
+			},
+		},
+	}
+	params := StationTradeParams{
+		StationIDs:        map[int64]bool{structureID: true, otherStruct: true},
+		RegionID:          regionID,
+		MinMargin:         0.1,
+		IncludeStructures: true,
+	}
+
+	// Without a token the structure market cannot be read: no ask, no row.
+	results, err := scanner.ScanStationTrades(params, func(string) {})
+	if err != nil {
+		t.Fatalf("ScanStationTrades returned error: %v", err)
+	}
+	if len(results) != 0 || len(fetched) != 0 {
+		t.Fatalf("without token: results=%d fetched=%v, want none", len(results), fetched)
+	}
+
+	params.AccessToken = "token"
+	results, err = scanner.ScanStationTrades(params, func(string) {})
+	if err != nil {
+		t.Fatalf("ScanStationTrades returned error: %v", err)
+	}
+	if len(fetched) != 2 {
+		t.Fatalf("fetched structures = %v, want both", fetched)
+	}
+	if len(results) != 1 {
+		t.Fatalf("len(results) = %d, want 1", len(results))
+	}
+	row := results[0]
+	if row.StationID != structureID || !row.IsStructure {
+		t.Fatalf("row station=%d IsStructure=%v, want structure row", row.StationID, row.IsStructure)
+	}
+	if row.BuyPrice != 90 || row.SellPrice != 100 || row.BuyOrderCount != 1 {
+		t.Fatalf("bid/ask = %v/%v (buy orders %d), want 90/100 without duplicates", row.BuyPrice, row.SellPrice, row.BuyOrderCount)
+	}
+}
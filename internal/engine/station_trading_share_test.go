@@ -130,3 +130,37 @@ func TestEnrichStationWithHistory_UsesFullRegionDepthDenominator_AllStations(t *
 	}
 }
 
+
+func TestEnrichStationWithHistory_RestockSignal(t *testing.T) {
+	const (
+		regionID = int32(10000002)
+		typeID   = int32(34)
+	)
+
+	run := func(restockDays float64) StationTrade {
+		s := scannerWithHistory(regionID, typeID, testHistoryFixedDailyVolume(100))
+		results := []StationTrade{
+			{
+				TypeID:        typeID,
+				StationID:     1,
+				BuyVolume:     60,
+				SellVolume:    40,
+				ProfitPerUnit: 10,
+			},
+		}
+		fullDepthByType := map[int32]int64{typeID: 1000}
+		s.enrichStationWithHistory(results, regionID, map[stationTypeKey]*orderGroup{}, StationTradeParams{RestockDays: restockDays}, fullDepthByType, func(string) {})
+		return results[0]
+	}
+
+	// 40 units listed against 10 units/day traded => 4 days of stock.
+	if got := run(5); got.DOS != 4 || !got.RestockSignal {
+		t.Fatalf("threshold 5: DOS=%v RestockSignal=%v, want 4/true", got.DOS, got.RestockSignal)
+	}
+	if got := run(3); got.RestockSignal {
+		t.Fatal("threshold 3: RestockSignal set with 4 days of stock")
+	}
+	if got := run(0); got.RestockSignal {
+		t.Fatal("RestockSignal set with the threshold disabled")
+	}
+}
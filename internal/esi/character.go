@@ -20,6 +20,7 @@ type CharacterOrder struct {
 	IsBuyOrder   bool    `json:"is_buy_order"`
 	Duration     int     `json:"duration"`
 	Issued       string  `json:"issued"`
+	Escrow       float64 `json:"escrow,omitempty"` // ISK still held by a buy order
 	// Enriched fields (filled by server)
 	TypeName     string `json:"type_name,omitempty"`
 	LocationName string `json:"location_name,omitempty"`
@@ -119,7 +120,7 @@ func (c *Client) AuthGetJSON(url, accessToken string, dst interface{}) error {
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", "eve-flipper/1.0 (github.com)")
 
-	resp, err := c.http.Do(req)
+	resp, err := c.do(req, c.sem)
 	if err != nil {
 		<-c.sem
 		return err
@@ -186,7 +187,7 @@ func (c *Client) GetOrderHistory(characterID int64, accessToken string) ([]Histo
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", "eve-flipper/1.0 (github.com)")
 
-	resp, err := c.http.Do(req)
+	resp, err := c.do(req, c.sem)
 	if err != nil {
 		<-c.sem
 		return nil, fmt.Errorf("order history page 1: %w", err)
@@ -276,7 +277,7 @@ func (c *Client) GetCharacterAssets(characterID int64, accessToken string) ([]Ch
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", "eve-flipper/1.0 (github.com)")
 
-	resp, err := c.http.Do(req)
+	resp, err := c.do(req, c.sem)
 	if err != nil {
 		<-c.sem
 		return nil, fmt.Errorf("assets page 1: %w", err)
@@ -354,7 +355,7 @@ func (c *Client) GetCharacterBlueprints(characterID int64, accessToken string) (
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", "eve-flipper/1.0 (github.com)")
 
-	resp, err := c.http.Do(req)
+	resp, err := c.do(req, c.sem)
 	if err != nil {
 		<-c.sem
 		return nil, fmt.Errorf("blueprints page 1: %w", err)
@@ -11,14 +11,10 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-const (
-	maxRetries    = 3
-	retryBaseWait = 500 * time.Millisecond
-)
-
 const baseURL = "https://esi.evetech.net/latest"
 
 // StationStore is a persistent L2 cache for station names.
@@ -40,17 +36,24 @@ type Client struct {
 	stationStore  StationStore // L2 persistent cache (SQLite)
 	typeNameCache sync.Map     // int32 -> string (L1 in-memory)
 	orderCache    *OrderCache  // region order cache with ETag/Expires
+	etagCache     *ETagCache   // raw bodies by URL for If-None-Match (history)
+	maxRetries    atomic.Int32 // retries of transient errors (see SetMaxRetries)
+	errorLimit    errorLimitState
 
 	// EVERef structure name fallback (loaded at startup)
 	everefNames sync.Map // int64 -> string
 	// Known structure -> solar_system_id mappings from ESI/EVERef.
 	structureSystems sync.Map // int64 -> int32
+	// Authenticated structure market orders (see FetchStructureOrders).
+	structureOrders sync.Map // structureOrdersKey -> structureOrdersEntry
 
 	// Health check cache
 	healthMu      sync.RWMutex
 	healthOK      bool
 	healthChecked time.Time
 	healthLastOK  time.Time
+
+	serverStatus serverStatusCache // TQ status (see FetchServerStatus)
 }
 
 // NewClient creates an ESI client with rate limiting and the given station cache store.
@@ -71,13 +74,22 @@ func NewClient(store StationStore) *Client {
 		MaxConnsPerHost:     0,   // unlimited
 		IdleConnTimeout:     120 * time.Second,
 	}
-	return &Client{
+	c := &Client{
 		http:         &http.Client{Timeout: 30 * time.Second, Transport: transport},
 		sem:          make(chan struct{}, 50), // for GetJSON (history, stations, auth)
 		scanSem:      make(chan struct{}, 50), // for GetPaginatedDirect (market order pages)
 		stationStore: store,
 		orderCache:   NewOrderCache(),
+		etagCache:    NewETagCache(),
 	}
+	c.maxRetries.Store(DefaultMaxRetries)
+	return c
+}
+
+// SetHTTPClient replaces the HTTP client used for ESI requests, e.g. to
+// route them through a proxy or a test server.
+func (c *Client) SetHTTPClient(h *http.Client) {
+	c.http = h
 }
 
 const everefStructuresURL = "https://data.everef.net/structures/structures-latest.v2.json"
@@ -420,7 +432,7 @@ func (c *Client) PrefetchStructureNames(locationIDs map[int64]bool, accessToken
 
 // isRetryable returns true if the HTTP status code indicates a transient error worth retrying.
 func isRetryable(statusCode int) bool {
-	return statusCode == 420 || statusCode == 429 || statusCode == 502 || statusCode == 503 || statusCode == 504 || statusCode == 520
+	return statusCode == 420 || statusCode == 500 || statusCode == 429 || statusCode == 502 || statusCode == 503 || statusCode == 504 || statusCode == 520
 }
 
 // PostJSON sends a POST request with a JSON body and decodes the response into dst.
@@ -432,10 +444,10 @@ func (c *Client) PostJSON(url string, body interface{}, dst interface{}) error {
 	}
 
 	var lastErr error
+	maxRetries := c.retries()
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			wait := retryBaseWait * time.Duration(1<<(attempt-1))
-			time.Sleep(wait)
+			c.waitRetry(attempt)
 		}
 
 		c.sem <- struct{}{}
@@ -451,7 +463,7 @@ func (c *Client) PostJSON(url string, body interface{}, dst interface{}) error {
 		req.Header.Set("Accept", "application/json")
 		req.Header.Set("Content-Type", "application/json")
 
-		resp, err := c.http.Do(req)
+		resp, err := c.do(req, c.sem)
 		if err != nil {
 			<-c.sem
 			lastErr = err
@@ -496,14 +508,15 @@ func (r *bytesReader) Read(p []byte) (int, error) {
 }
 
 // GetJSON fetches a URL and decodes JSON into dst.
-// Retries up to maxRetries times on transient ESI errors (502/503/504) with exponential backoff.
+// Retries transient ESI errors (5xx, 420, 429) up to the configured max retries with
+// jittered exponential backoff.
 // Semaphore is released before sleeping so other requests can proceed.
 func (c *Client) GetJSON(url string, dst interface{}) error {
 	var lastErr error
+	maxRetries := c.retries()
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			wait := retryBaseWait * time.Duration(1<<(attempt-1)) // 500ms, 1s, 2s
-			time.Sleep(wait)
+			c.waitRetry(attempt)
 		}
 
 		c.sem <- struct{}{} // acquire only for the actual request
@@ -516,7 +529,7 @@ func (c *Client) GetJSON(url string, dst interface{}) error {
 		req.Header.Set("User-Agent", "eve-flipper/1.0 (github.com)")
 		req.Header.Set("Accept", "application/json")
 
-		resp, err := c.http.Do(req)
+		resp, err := c.do(req, c.sem)
 		if err != nil {
 			<-c.sem
 			lastErr = err
@@ -576,7 +589,7 @@ func (c *Client) getPaginatedInternal(url, accessToken string) ([]json.RawMessag
 		req.Header.Set("Authorization", "Bearer "+accessToken)
 	}
 
-	resp, err := c.http.Do(req)
+	resp, err := c.do(req, c.sem)
 	if err != nil {
 		<-c.sem
 		return nil, err
@@ -640,24 +653,39 @@ func (c *Client) getPaginatedInternal(url, accessToken string) ([]json.RawMessag
 
 // GetPaginatedDirect fetches all pages and decodes directly into MarketOrder slice.
 func (c *Client) GetPaginatedDirect(url string, regionID int32) ([]MarketOrder, error) {
-	orders, _, _, err := c.getPaginatedDirectWithHeaders(url, regionID)
+	orders, _, _, _, err := c.getPaginatedDirectWithHeaders(url, regionID)
 	return orders, err
 }
 
-// getPaginatedDirectWithHeaders fetches all pages, returning ETag and Expires from page 1.
+// countingReader counts the bytes read through it into n.
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (cr countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n.Add(int64(n))
+	return n, err
+}
+
+// getPaginatedDirectWithHeaders fetches all pages, returning ETag and Expires from page 1
+// and the total body bytes downloaded.
 // Uses scanSem so bulk page fetches never starve regular API calls.
 // Retries transient ESI errors with exponential backoff; semaphore released during sleep.
-func (c *Client) getPaginatedDirectWithHeaders(url string, regionID int32) ([]MarketOrder, string, time.Time, error) {
+func (c *Client) getPaginatedDirectWithHeaders(url string, regionID int32) ([]MarketOrder, string, time.Time, int64, error) {
 	// Fetch page 1 with retry
 	var page1 []MarketOrder
 	var totalPages int
 	var respEtag string
 	var respExpires time.Time
 	var lastErr error
+	var bodyBytes atomic.Int64
 
+	maxRetries := c.retries()
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			time.Sleep(retryBaseWait * time.Duration(1<<(attempt-1)))
+			c.waitRetry(attempt)
 		}
 
 		c.scanSem <- struct{}{}
@@ -665,10 +693,10 @@ func (c *Client) getPaginatedDirectWithHeaders(url string, regionID int32) ([]Ma
 		req, err := newESIRequest(url + "&page=1")
 		if err != nil {
 			<-c.scanSem
-			return nil, "", time.Time{}, err
+			return nil, "", time.Time{}, 0, err
 		}
 
-		resp, err := c.http.Do(req)
+		resp, err := c.do(req, c.scanSem)
 		if err != nil {
 			<-c.scanSem
 			lastErr = err
@@ -681,7 +709,7 @@ func (c *Client) getPaginatedDirectWithHeaders(url string, regionID int32) ([]Ma
 			<-c.scanSem
 			lastErr = fmt.Errorf("ESI %d on page 1", resp.StatusCode)
 			if !isRetryable(resp.StatusCode) {
-				return nil, "", time.Time{}, lastErr
+				return nil, "", time.Time{}, 0, lastErr
 			}
 			log.Printf("[ESI] Page 1 retryable %d (attempt %d/%d)", resp.StatusCode, attempt+1, maxRetries+1)
 			continue
@@ -694,7 +722,7 @@ func (c *Client) getPaginatedDirectWithHeaders(url string, regionID int32) ([]Ma
 		respEtag = resp.Header.Get("Etag")
 		respExpires = parseExpires(resp)
 
-		json.NewDecoder(resp.Body).Decode(&page1)
+		json.NewDecoder(countingReader{resp.Body, &bodyBytes}).Decode(&page1)
 		resp.Body.Close()
 		<-c.scanSem
 		lastErr = nil
@@ -702,7 +730,7 @@ func (c *Client) getPaginatedDirectWithHeaders(url string, regionID int32) ([]Ma
 	}
 
 	if lastErr != nil {
-		return nil, "", time.Time{}, lastErr
+		return nil, "", time.Time{}, 0, lastErr
 	}
 
 	for i := range page1 {
@@ -710,7 +738,7 @@ func (c *Client) getPaginatedDirectWithHeaders(url string, regionID int32) ([]Ma
 	}
 
 	if totalPages <= 1 {
-		return page1, respEtag, respExpires, nil
+		return page1, respEtag, respExpires, bodyBytes.Load(), nil
 	}
 
 	type pageResult struct {
@@ -726,7 +754,7 @@ func (c *Client) getPaginatedDirectWithHeaders(url string, regionID int32) ([]Ma
 
 			for attempt := 0; attempt <= maxRetries; attempt++ {
 				if attempt > 0 {
-					time.Sleep(retryBaseWait * time.Duration(1<<(attempt-1)))
+					c.waitRetry(attempt)
 				}
 
 				c.scanSem <- struct{}{}
@@ -738,7 +766,7 @@ func (c *Client) getPaginatedDirectWithHeaders(url string, regionID int32) ([]Ma
 					return
 				}
 
-				pageResp, err := c.http.Do(pageReq)
+				pageResp, err := c.do(pageReq, c.scanSem)
 				if err != nil {
 					<-c.scanSem
 					if attempt == maxRetries {
@@ -760,7 +788,7 @@ func (c *Client) getPaginatedDirectWithHeaders(url string, regionID int32) ([]Ma
 					continue
 				}
 
-				json.NewDecoder(pageResp.Body).Decode(&data)
+				json.NewDecoder(countingReader{pageResp.Body, &bodyBytes}).Decode(&data)
 				pageResp.Body.Close()
 				<-c.scanSem
 				for i := range data {
@@ -784,7 +812,7 @@ func (c *Client) getPaginatedDirectWithHeaders(url string, regionID int32) ([]Ma
 		}
 		all = append(all, r.data...)
 	}
-	return all, respEtag, respExpires, nil
+	return all, respEtag, respExpires, bodyBytes.Load(), nil
 }
 
 // PrefetchStationNames fetches station names concurrently for a set of location IDs.
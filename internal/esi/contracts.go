@@ -1,6 +1,7 @@
 package esi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -75,7 +76,7 @@ func (c *Client) FetchRegionContracts(regionID int32) ([]PublicContract, error)
 	req.Header.Set("User-Agent", "eve-flipper/1.0 (github.com)")
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.http.Do(req)
+	resp, err := c.do(req, c.sem)
 	if err != nil {
 		<-c.sem
 		return nil, err
@@ -176,6 +177,14 @@ func (cc *ContractItemsCache) evictOldest() {
 // Uses cache to skip already-fetched contracts. 50 parallel workers for throughput.
 // Returns a map of contractID -> []ContractItem. Failed fetches are silently skipped.
 func (c *Client) FetchContractItemsBatch(contractIDs []int32, cache *ContractItemsCache, progress func(done, total int)) map[int32][]ContractItem {
+	return c.FetchContractItemsBatchContext(context.Background(), contractIDs, cache, progress)
+}
+
+// FetchContractItemsBatchContext is FetchContractItemsBatch that stops
+// dispatching new fetches once ctx is done. Requests already in flight still
+// land in the cache, so a re-run only fetches the contracts that were never
+// reached. The returned map holds whatever was fetched before cancellation.
+func (c *Client) FetchContractItemsBatchContext(ctx context.Context, contractIDs []int32, cache *ContractItemsCache, progress func(done, total int)) map[int32][]ContractItem {
 	total := len(contractIDs)
 	if total == 0 {
 		return nil
@@ -245,10 +254,17 @@ func (c *Client) FetchContractItemsBatch(contractIDs []int32, cache *ContractIte
 
 	// Feed jobs
 	go func() {
+		defer close(jobs)
 		for _, id := range uncached {
-			jobs <- id
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- id:
+			}
 		}
-		close(jobs)
 	}()
 
 	// Close results when all workers done
@@ -283,6 +299,9 @@ func (c *Client) FetchContractItemsBatch(contractIDs []int32, cache *ContractIte
 			progress(done, total)
 		}
 	}
+	if ctx.Err() != nil {
+		log.Printf("[DEBUG] ContractItemsBatch: canceled after %d/%d contracts", done, total)
+	}
 	return out
 }
 
@@ -1,7 +1,15 @@
 package esi
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -42,3 +50,134 @@ func TestNewClient_NonNil(t *testing.T) {
 		t.Fatal("NewClient(nil) returned nil")
 	}
 }
+
+func TestFuzzworkPriceProvider_ParsesStringAndNumericAggregates(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{
+			"34": {"buy": {"max": "4.5", "volume": "1000.0"}, "sell": {"min": "5.1", "volume": "2500"}},
+			"35": {"buy": {"max": 9, "volume": 10}, "sell": {"min": 0, "volume": 0}},
+			"36": {"buy": {"max": "0", "volume": "0"}, "sell": {"min": "0", "volume": "0"}}
+		}`))
+	}))
+	defer srv.Close()
+
+	p := &FuzzworkPriceProvider{HTTP: srv.Client(), BaseURL: srv.URL + "/aggregates/"}
+	aggs, err := p.Aggregates(context.Background(), 10000002, 60003760, []int32{34, 35, 36})
+	if err != nil {
+		t.Fatalf("Aggregates: %v", err)
+	}
+	if gotQuery != "station=60003760&types=34,35,36" {
+		t.Fatalf("query = %q", gotQuery)
+	}
+	if a := aggs[34]; a.BuyMax != 4.5 || a.BuyVolume != 1000 || a.SellMin != 5.1 || a.SellVolume != 2500 {
+		t.Fatalf("type 34 = %+v", a)
+	}
+	if a := aggs[35]; a.BuyMax != 9 || a.SellMin != 0 {
+		t.Fatalf("type 35 = %+v", a)
+	}
+	if _, ok := aggs[36]; ok {
+		t.Fatal("empty aggregate should be dropped")
+	}
+}
+
+func TestFuzzworkPriceProvider_MergesConcurrentBatches(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		body := make(map[string]fuzzworkAggregate)
+		for _, id := range strings.Split(r.URL.Query().Get("types"), ",") {
+			body[id] = fuzzworkAggregate{Sell: fuzzworkSide{Min: 10, Volume: 1}}
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	defer srv.Close()
+
+	typeIDs := make([]int32, 2*fuzzworkBatchSize+50)
+	for i := range typeIDs {
+		typeIDs[i] = int32(i + 1)
+	}
+	p := &FuzzworkPriceProvider{HTTP: srv.Client(), BaseURL: srv.URL + "/aggregates/"}
+	aggs, err := p.Aggregates(context.Background(), 10000002, 0, typeIDs)
+	if err != nil {
+		t.Fatalf("Aggregates: %v", err)
+	}
+	if len(aggs) != len(typeIDs) || requests.Load() != 3 {
+		t.Fatalf("got %d aggregates in %d requests, want %d in 3", len(aggs), requests.Load(), len(typeIDs))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Aggregates(ctx, 10000002, 0, typeIDs); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled err = %v, want context.Canceled", err)
+	}
+}
+
+func TestAggregateOrders_BestBidAskPerStation(t *testing.T) {
+	orders := []MarketOrder{
+		{TypeID: 34, LocationID: 1, Price: 5, VolumeRemain: 10, IsBuyOrder: true},
+		{TypeID: 34, LocationID: 1, Price: 6, VolumeRemain: 5, IsBuyOrder: true},
+		{TypeID: 34, LocationID: 1, Price: 8, VolumeRemain: 7},
+		{TypeID: 34, LocationID: 1, Price: 7, VolumeRemain: 3},
+		{TypeID: 34, LocationID: 2, Price: 1, VolumeRemain: 100},
+	}
+	agg := AggregateOrders(orders, 1, nil)[34]
+	if agg.BuyMax != 6 || agg.BuyVolume != 15 || agg.SellMin != 7 || agg.SellVolume != 10 {
+		t.Fatalf("aggregate = %+v", agg)
+	}
+}
+
+func TestFetchContractItemsBatchContext_CanceledServesCacheOnly(t *testing.T) {
+	cache := NewContractItemsCache()
+	cache.items[1] = []ContractItem{{TypeID: 34, Quantity: 100, IsIncluded: true}}
+	cache.items[2] = nil // fetched earlier, no items
+	cache.order = append(cache.order, 1, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := NewClient(nil)
+	got := c.FetchContractItemsBatchContext(ctx, []int32{1, 2, 3}, cache, func(done, total int) {})
+	if len(got) != 1 || len(got[1]) != 1 {
+		t.Fatalf("got %v, want only the cached items of contract 1", got)
+	}
+	if _, ok := cache.items[3]; ok {
+		t.Error("contract 3 was fetched after cancellation")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestFetchStructureOrders_CachedPerCharacter(t *testing.T) {
+	var mu sync.Mutex
+	tokens := []string{}
+	c := NewClient(nil)
+	c.SetHTTPClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		mu.Unlock()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"X-Pages": {"1"}},
+			Body:       io.NopCloser(strings.NewReader(`[{"order_id":1,"type_id":34,"price":5,"volume_remain":10}]`)),
+			Request:    r,
+		}, nil
+	})})
+
+	const structureID = int64(1_035_466_617_946)
+	for _, call := range []struct {
+		characterID int64
+		token       string
+	}{{1, "a"}, {2, "b"}, {1, "a"}} {
+		orders, err := c.FetchStructureOrders(call.characterID, structureID, call.token)
+		if err != nil || len(orders) != 1 || orders[0].LocationID != structureID {
+			t.Fatalf("character %d: orders=%+v err=%v", call.characterID, orders, err)
+		}
+	}
+	if want := []string{"Bearer a", "Bearer b"}; strings.Join(tokens, ",") != strings.Join(want, ",") {
+		t.Fatalf("upstream calls = %v, want %v (second character refetches, repeat is cached)", tokens, want)
+	}
+}
@@ -0,0 +1,221 @@
+package esi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"eve-flipper/internal/logger"
+	"eve-flipper/internal/metrics"
+)
+
+// etagCacheMaxBytes bounds the raw bodies kept for conditional requests.
+// Market history bodies are ~20–60 KB each, so this holds a few thousand.
+const etagCacheMaxBytes = 64 << 20
+
+// etagCacheEntry is a raw response body with its HTTP caching metadata.
+type etagCacheEntry struct {
+	body    []byte
+	etag    string
+	expires time.Time
+	updated time.Time
+}
+
+// ETagCache is a thread-safe cache of raw ESI response bodies keyed by
+// request URL. Expired entries are kept (up to the byte budget) so their
+// ETag can be revalidated with If-None-Match instead of re-downloaded.
+type ETagCache struct {
+	mu         sync.Mutex
+	entries    map[string]*etagCacheEntry
+	size       int64
+	maxBytes   int64
+	bytesSaved atomic.Int64
+}
+
+// NewETagCache creates an empty ETag cache with the default byte budget.
+func NewETagCache() *ETagCache {
+	return &ETagCache{
+		entries:  make(map[string]*etagCacheEntry),
+		maxBytes: etagCacheMaxBytes,
+	}
+}
+
+// Get returns the cached entry for url. fresh is false when the entry has
+// expired and needs revalidation.
+func (ec *ETagCache) Get(url string) (body []byte, etag string, fresh, ok bool) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	e, found := ec.entries[url]
+	if !found {
+		return nil, "", false, false
+	}
+	return e.body, e.etag, time.Now().Before(e.expires), true
+}
+
+// Put stores a response body. Bodies without an ETag are not cached since
+// they can never be revalidated.
+func (ec *ETagCache) Put(url string, body []byte, etag string, expires time.Time) {
+	if etag == "" || int64(len(body)) > ec.maxBytes {
+		return
+	}
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	if old, ok := ec.entries[url]; ok {
+		ec.size -= int64(len(old.body))
+	}
+	ec.entries[url] = &etagCacheEntry{
+		body:    body,
+		etag:    etag,
+		expires: expires,
+		updated: time.Now().UTC(),
+	}
+	ec.size += int64(len(body))
+	ec.evictLocked()
+}
+
+// Touch refreshes the expiry of an entry after a 304 Not Modified and
+// returns the cached body.
+func (ec *ETagCache) Touch(url string, expires time.Time) ([]byte, bool) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	e, ok := ec.entries[url]
+	if !ok {
+		return nil, false
+	}
+	e.expires = expires
+	e.updated = time.Now().UTC()
+	ec.bytesSaved.Add(int64(len(e.body)))
+	return e.body, true
+}
+
+// BytesSaved returns the total body bytes served from cache on 304s.
+func (ec *ETagCache) BytesSaved() int64 {
+	return ec.bytesSaved.Load()
+}
+
+// Len returns the number of cached entries.
+func (ec *ETagCache) Len() int {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	return len(ec.entries)
+}
+
+// Clear removes all entries. Returns number of entries removed.
+func (ec *ETagCache) Clear() int {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	n := len(ec.entries)
+	ec.entries = make(map[string]*etagCacheEntry)
+	ec.size = 0
+	return n
+}
+
+// evictLocked drops the entries that expire soonest until the cache fits
+// its byte budget.
+func (ec *ETagCache) evictLocked() {
+	if ec.size <= ec.maxBytes {
+		return
+	}
+	keys := make([]string, 0, len(ec.entries))
+	for k := range ec.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return ec.entries[keys[i]].expires.Before(ec.entries[keys[j]].expires)
+	})
+	for _, k := range keys {
+		if ec.size <= ec.maxBytes {
+			return
+		}
+		ec.size -= int64(len(ec.entries[k].body))
+		delete(ec.entries, k)
+	}
+}
+
+// getJSONConditional is GetJSON backed by the ETag cache: fresh entries are
+// served without a request, expired ones are revalidated with If-None-Match
+// and a 304 serves the cached body. Falls back to GetJSON without a cache.
+func (c *Client) getJSONConditional(url string, dst interface{}) error {
+	if c.etagCache == nil {
+		return c.GetJSON(url, dst)
+	}
+	body, etag, fresh, ok := c.etagCache.Get(url)
+	if ok && fresh {
+		metrics.CacheRequestsTotal.Inc("esi_etag", "hit")
+		return json.Unmarshal(body, dst)
+	}
+
+	var lastErr error
+	maxRetries := c.retries()
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			c.waitRetry(attempt)
+		}
+
+		c.sem <- struct{}{}
+
+		req, err := newESIRequest(url)
+		if err != nil {
+			<-c.sem
+			return err
+		}
+		if ok && etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		resp, err := c.do(req, c.sem)
+		if err != nil {
+			<-c.sem
+			lastErr = err
+			log.Printf("[ESI] Request failed (attempt %d/%d): %v", attempt+1, maxRetries+1, err)
+			continue
+		}
+
+		switch {
+		case resp.StatusCode == 304:
+			resp.Body.Close()
+			<-c.sem
+			cached, found := c.etagCache.Touch(url, parseExpires(resp))
+			if !found {
+				// Evicted between Get and Touch: refetch without the ETag.
+				ok = false
+				lastErr = fmt.Errorf("ESI 304 without cached body")
+				continue
+			}
+			metrics.CacheRequestsTotal.Inc("esi_etag", "revalidated")
+			logger.Debug("ESI", fmt.Sprintf("304 %s: saved %d bytes (total %d)",
+				url, len(cached), c.etagCache.BytesSaved()))
+			return json.Unmarshal(cached, dst)
+		case resp.StatusCode == 200:
+			data, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			<-c.sem
+			if readErr != nil {
+				return readErr
+			}
+			if err := json.Unmarshal(data, dst); err != nil {
+				return err
+			}
+			metrics.CacheRequestsTotal.Inc("esi_etag", "miss")
+			c.etagCache.Put(url, data, resp.Header.Get("Etag"), parseExpires(resp))
+			return nil
+		}
+
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		<-c.sem
+		lastErr = fmt.Errorf("ESI %d: %s", resp.StatusCode, string(respBody))
+
+		if !isRetryable(resp.StatusCode) {
+			return lastErr
+		}
+		log.Printf("[ESI] Retryable error %d (attempt %d/%d): %s", resp.StatusCode, attempt+1, maxRetries+1, url)
+	}
+
+	return lastErr
+}
@@ -0,0 +1,84 @@
+package esi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetJSONConditionalRevalidatesWithETag(t *testing.T) {
+	var requests, conditional int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// Already expired so every call revalidates.
+		w.Header().Set("Expires", time.Now().Add(-time.Minute).UTC().Format(time.RFC1123))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Etag", `"v1"`)
+		_, _ = w.Write([]byte(`[{"date":"2026-01-01","average":5,"volume":10}]`))
+	}))
+	defer srv.Close()
+
+	c := &Client{http: srv.Client(), sem: make(chan struct{}, 1), etagCache: NewETagCache()}
+	for i := 0; i < 2; i++ {
+		var entries []HistoryEntry
+		if err := c.getJSONConditional(srv.URL+"/history", &entries); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if len(entries) != 1 || entries[0].Volume != 10 {
+			t.Fatalf("call %d entries = %+v", i, entries)
+		}
+	}
+	if requests != 2 || conditional != 1 {
+		t.Fatalf("requests=%d conditional=%d, want 2/1", requests, conditional)
+	}
+	if saved := c.etagCache.BytesSaved(); saved <= 0 {
+		t.Fatalf("BytesSaved=%d, want > 0", saved)
+	}
+}
+
+func TestGetJSONConditionalServesFreshEntryWithoutRequest(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Etag", `"v1"`)
+		w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(time.RFC1123))
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	c := &Client{http: srv.Client(), sem: make(chan struct{}, 1), etagCache: NewETagCache()}
+	for i := 0; i < 3; i++ {
+		var entries []HistoryEntry
+		if err := c.getJSONConditional(srv.URL, &entries); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("requests=%d, want 1", requests)
+	}
+}
+
+func TestETagCacheEvictsSoonestExpiringOverBudget(t *testing.T) {
+	ec := NewETagCache()
+	ec.maxBytes = 10
+	now := time.Now()
+	ec.Put("a", []byte("12345"), "a", now.Add(time.Minute))
+	ec.Put("b", []byte("12345"), "b", now.Add(time.Hour))
+	ec.Put("c", []byte("12345"), "c", now.Add(2*time.Hour))
+
+	if _, _, _, ok := ec.Get("a"); ok {
+		t.Fatal("soonest-expiring entry should be evicted")
+	}
+	if ec.Len() != 2 {
+		t.Fatalf("Len=%d, want 2", ec.Len())
+	}
+	ec.Put("d", []byte("x"), "", now.Add(time.Hour))
+	if _, _, _, ok := ec.Get("d"); ok {
+		t.Fatal("entry without ETag should not be cached")
+	}
+}
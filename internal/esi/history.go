@@ -31,12 +31,13 @@ type HistoryCache interface {
 }
 
 // FetchMarketHistory fetches market history for a type in a region from ESI.
+// Repeat fetches are revalidated with the previous ETag.
 func (c *Client) FetchMarketHistory(regionID, typeID int32) ([]HistoryEntry, error) {
 	url := fmt.Sprintf("%s/markets/%d/history/?datasource=tranquility&type_id=%d",
 		baseURL, regionID, typeID)
 
 	var entries []HistoryEntry
-	if err := c.GetJSON(url, &entries); err != nil {
+	if err := c.getJSONConditional(url, &entries); err != nil {
 		return nil, err
 	}
 	return entries, nil
@@ -89,36 +89,54 @@ func (c *Client) FetchMarketPrices() ([]IndustryPrice, error) {
 	return result, nil
 }
 
-// GetSystemCostIndex returns cached cost index for a system, fetching if needed.
-func (c *Client) GetSystemCostIndex(cache *IndustryCache, systemID int32) (*SystemCostIndices, error) {
+// industryCostIndicesTTL matches ESI's hourly refresh of /industry/systems/.
+const industryCostIndicesTTL = time.Hour
+
+// FetchIndustrySystemCostIndices returns cost indices for every system that
+// has industry activity, keyed by system ID. The result is served from cache
+// for an hour; callers must not modify it.
+func (c *Client) FetchIndustrySystemCostIndices(cache *IndustryCache) (map[int32]*SystemCostIndices, error) {
 	cache.mu.RLock()
-	if time.Since(cache.costIndicesTime) < time.Hour {
-		if idx, ok := cache.costIndices[systemID]; ok {
-			cache.mu.RUnlock()
-			return idx, nil
-		}
+	if time.Since(cache.costIndicesTime) < industryCostIndicesTTL && len(cache.costIndices) > 0 {
+		indices := cache.costIndices
+		cache.mu.RUnlock()
+		return indices, nil
 	}
 	cache.mu.RUnlock()
 
-	// Fetch fresh data
 	cache.mu.Lock()
 	defer cache.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if time.Since(cache.costIndicesTime) < time.Hour {
-		if idx, ok := cache.costIndices[systemID]; ok {
-			return idx, nil
-		}
+	if time.Since(cache.costIndicesTime) < industryCostIndicesTTL && len(cache.costIndices) > 0 {
+		return cache.costIndices, nil
 	}
 
-	// Fetch all systems
 	systems, err := c.FetchIndustrySystems()
 	if err != nil {
 		return nil, err
 	}
+	cache.costIndices = costIndicesBySystem(systems)
+	cache.costIndicesTime = time.Now()
+	return cache.costIndices, nil
+}
 
-	// Update cache
-	cache.costIndices = make(map[int32]*SystemCostIndices)
+// GetSystemCostIndex returns cached cost index for a system, fetching if needed.
+func (c *Client) GetSystemCostIndex(cache *IndustryCache, systemID int32) (*SystemCostIndices, error) {
+	indices, err := c.FetchIndustrySystemCostIndices(cache)
+	if err != nil {
+		return nil, err
+	}
+	if idx, ok := indices[systemID]; ok {
+		return idx, nil
+	}
+	// System not found in industry data, return zeros
+	return &SystemCostIndices{}, nil
+}
+
+// costIndicesBySystem converts the ESI /industry/systems/ payload.
+func costIndicesBySystem(systems []IndustryCostIndex) map[int32]*SystemCostIndices {
+	out := make(map[int32]*SystemCostIndices, len(systems))
 	for _, sys := range systems {
 		idx := &SystemCostIndices{}
 		for _, ci := range sys.CostIndices {
@@ -137,15 +155,9 @@ func (c *Client) GetSystemCostIndex(cache *IndustryCache, systemID int32) (*Syst
 				idx.TEResearch = ci.CostIndex
 			}
 		}
-		cache.costIndices[sys.SolarSystemID] = idx
+		out[sys.SolarSystemID] = idx
 	}
-	cache.costIndicesTime = time.Now()
-
-	if idx, ok := cache.costIndices[systemID]; ok {
-		return idx, nil
-	}
-	// System not found in industry data, return zeros
-	return &SystemCostIndices{}, nil
+	return out
 }
 
 // GetAdjustedPrice returns the adjusted price for a type, fetching if needed.
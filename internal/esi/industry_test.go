@@ -0,0 +1,38 @@
+package esi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCostIndicesBySystem(t *testing.T) {
+	raw := `[{"solar_system_id":30000142,"cost_indices":[{"activity":"manufacturing","cost_index":0.0412},{"activity":"reaction","cost_index":0.0123}]}]`
+	var systems []IndustryCostIndex
+	if err := json.Unmarshal([]byte(raw), &systems); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	got := costIndicesBySystem(systems)
+	idx := got[30000142]
+	if idx == nil || idx.Manufacturing != 0.0412 || idx.Reaction != 0.0123 || idx.Invention != 0 {
+		t.Fatalf("indices = %+v, want manufacturing 0.0412, reaction 0.0123", idx)
+	}
+}
+
+func TestGetSystemCostIndex_ServesFreshCache(t *testing.T) {
+	cache := NewIndustryCache()
+	cache.costIndices[30000142] = &SystemCostIndices{Manufacturing: 0.05}
+	cache.costIndicesTime = time.Now()
+
+	// A nil client proves no ESI request is made while the cache is fresh.
+	var c *Client
+	idx, err := c.GetSystemCostIndex(cache, 30000142)
+	if err != nil || idx.Manufacturing != 0.05 {
+		t.Fatalf("GetSystemCostIndex = %+v, %v; want manufacturing 0.05", idx, err)
+	}
+	idx, err = c.GetSystemCostIndex(cache, 30002187)
+	if err != nil || *idx != (SystemCostIndices{}) {
+		t.Fatalf("unknown system = %+v, %v; want zero indices", idx, err)
+	}
+}
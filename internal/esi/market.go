@@ -1,7 +1,9 @@
 package esi
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // MarketOrder mirrors the ESI market order response.
@@ -14,7 +16,8 @@ type MarketOrder struct {
 	VolumeRemain int32   `json:"volume_remain"`
 	MinVolume    int32   `json:"min_volume"`
 	IsBuyOrder   bool    `json:"is_buy_order"`
-	RegionID     int32   `json:"-"` // set by us
+	Duration     int32   `json:"duration"` // days; NPC-seeded orders exceed the player maximum
+	RegionID     int32   `json:"-"`        // set by us
 }
 
 // FetchRegionOrders fetches all market orders for a region.
@@ -31,3 +34,65 @@ func (c *Client) FetchRegionOrdersByType(regionID int32, typeID int32) ([]Market
 
 	return c.GetPaginatedDirect(url, regionID)
 }
+
+// structureOrdersTTL is how long structure market orders are reused. ESI
+// refreshes the structure market endpoint every 5 minutes.
+const structureOrdersTTL = 5 * time.Minute
+
+// structureOrdersKey scopes cached structure orders to the character whose
+// token fetched them: docking access differs between characters.
+type structureOrdersKey struct {
+	characterID int64
+	structureID int64
+}
+
+type structureOrdersEntry struct {
+	orders  []MarketOrder
+	fetched time.Time
+}
+
+// FetchStructureOrders fetches all market orders in a player-owned structure
+// via the authenticated /markets/structures/{structure_id}/ endpoint. The
+// token's character (characterID) needs docking access to the structure's
+// market. Results are cached per character and structure for
+// structureOrdersTTL and concurrent fetches of the same pair are coalesced.
+//
+// The endpoint does not report system_id; it is filled in from the known
+// structure location when available. RegionID is left for the caller to set.
+func (c *Client) FetchStructureOrders(characterID, structureID int64, accessToken string) ([]MarketOrder, error) {
+	key := structureOrdersKey{characterID: characterID, structureID: structureID}
+	if v, ok := c.structureOrders.Load(key); ok {
+		if e := v.(structureOrdersEntry); time.Since(e.fetched) < structureOrdersTTL {
+			return e.orders, nil
+		}
+	}
+
+	result, err, _ := c.orderCache.group.Do(fmt.Sprintf("structure:%d:%d", characterID, structureID), func() (interface{}, error) {
+		url := fmt.Sprintf("%s/markets/structures/%d/?datasource=tranquility", baseURL, structureID)
+		pages, err := c.AuthGetPaginated(url, accessToken)
+		if err != nil {
+			return nil, err
+		}
+		systemID, _ := c.StructureSystemID(structureID)
+		orders := make([]MarketOrder, 0, len(pages))
+		for _, raw := range pages {
+			var o MarketOrder
+			if err := json.Unmarshal(raw, &o); err != nil {
+				continue
+			}
+			if o.LocationID == 0 {
+				o.LocationID = structureID
+			}
+			if o.SystemID == 0 {
+				o.SystemID = systemID
+			}
+			orders = append(orders, o)
+		}
+		c.structureOrders.Store(key, structureOrdersEntry{orders: orders, fetched: time.Now()})
+		return orders, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]MarketOrder), nil
+}
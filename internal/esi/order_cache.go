@@ -4,10 +4,15 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"eve-flipper/internal/logger"
+	"eve-flipper/internal/metrics"
+
 	"golang.org/x/sync/singleflight"
 )
 
@@ -23,21 +28,24 @@ type orderCacheEntry struct {
 	etag    string    // ETag from ESI response (page 1)
 	expires time.Time // parsed Expires header
 	updated time.Time // when entry was last refreshed (MISS or 304)
+	bytes   int64     // body bytes of the last full fetch (all pages)
 }
 
 // OrderCache is a thread-safe in-memory cache for region market orders.
 // It uses ETag/Expires headers from ESI to avoid re-downloading unchanged data.
 // A singleflight.Group prevents duplicate in-flight fetches for the same key.
 type OrderCache struct {
-	mu      sync.RWMutex
-	entries map[orderCacheKey]*orderCacheEntry
-	group   singleflight.Group
+	mu         sync.RWMutex
+	entries    map[orderCacheKey]*orderCacheEntry
+	group      singleflight.Group
+	bytesSaved atomic.Int64
 }
 
 // OrderCacheWindow describes freshness bounds for a set of region cache entries.
 type OrderCacheWindow struct {
 	CurrentRevision int64
 	LastRefreshAt   time.Time
+	OldestRefreshAt time.Time // least recently refreshed entry
 	NextExpiryAt    time.Time
 	MinTTLSeconds   int64
 	MaxTTLSeconds   int64
@@ -106,6 +114,12 @@ func (oc *OrderCache) Get(regionID int32, orderType string) ([]MarketOrder, stri
 // Put stores orders in the cache with the given etag and expiry.
 // Periodically evicts long-expired entries to bound memory usage.
 func (oc *OrderCache) Put(regionID int32, orderType string, orders []MarketOrder, etag string, expires time.Time) {
+	oc.putSized(regionID, orderType, orders, etag, expires, 0)
+}
+
+// putSized is Put that also records the downloaded body size, which a later
+// 304 reports as bytes saved.
+func (oc *OrderCache) putSized(regionID int32, orderType string, orders []MarketOrder, etag string, expires time.Time, size int64) {
 	oc.mu.Lock()
 	defer oc.mu.Unlock()
 
@@ -125,11 +139,13 @@ func (oc *OrderCache) Put(regionID int32, orderType string, orders []MarketOrder
 		etag:    etag,
 		expires: expires,
 		updated: time.Now().UTC(),
+		bytes:   size,
 	}
 }
 
 // Touch updates the expiry of an existing cache entry (used on 304 Not Modified).
-func (oc *OrderCache) Touch(regionID int32, orderType string, expires time.Time) {
+// Returns the body bytes the 304 avoided re-downloading.
+func (oc *OrderCache) Touch(regionID int32, orderType string, expires time.Time) int64 {
 	oc.mu.Lock()
 	defer oc.mu.Unlock()
 
@@ -137,7 +153,15 @@ func (oc *OrderCache) Touch(regionID int32, orderType string, expires time.Time)
 	if e, ok := oc.entries[key]; ok {
 		e.expires = expires
 		e.updated = time.Now().UTC()
+		oc.bytesSaved.Add(e.bytes)
+		return e.bytes
 	}
+	return 0
+}
+
+// BytesSaved returns the total body bytes avoided by 304 revalidations.
+func (oc *OrderCache) BytesSaved() int64 {
+	return oc.bytesSaved.Load()
 }
 
 // WindowForRegions returns cache freshness bounds for the provided region IDs.
@@ -176,6 +200,7 @@ func (oc *OrderCache) WindowForRegions(regionIDs []int32, orderType string) Orde
 				found = true
 				window.NextExpiryAt = entry.expires
 				window.LastRefreshAt = entry.updated
+				window.OldestRefreshAt = entry.updated
 				maxExpiry = entry.expires
 				continue
 			}
@@ -188,6 +213,9 @@ func (oc *OrderCache) WindowForRegions(regionIDs []int32, orderType string) Orde
 			if entry.updated.After(window.LastRefreshAt) {
 				window.LastRefreshAt = entry.updated
 			}
+			if entry.updated.Before(window.OldestRefreshAt) {
+				window.OldestRefreshAt = entry.updated
+			}
 		}
 	}
 
@@ -214,6 +242,75 @@ func (oc *OrderCache) WindowForRegions(regionIDs []int32, orderType string) Orde
 	return window
 }
 
+// RegionIDs returns the regions with at least one cached order set, in
+// ascending order.
+func (oc *OrderCache) RegionIDs() []int32 {
+	oc.mu.RLock()
+	seen := make(map[int32]bool, len(oc.entries))
+	for key := range oc.entries {
+		seen[key.RegionID] = true
+	}
+	oc.mu.RUnlock()
+
+	ids := make([]int32, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// OrderCacheEntryStats describes one cached region order set.
+type OrderCacheEntryStats struct {
+	RegionID  int32     `json:"region_id"`
+	OrderType string    `json:"order_type"`
+	Orders    int       `json:"orders"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Expired   bool      `json:"expired"`
+}
+
+// Stats returns every cached entry, ordered by region then order type.
+func (oc *OrderCache) Stats() []OrderCacheEntryStats {
+	now := time.Now()
+	oc.mu.RLock()
+	out := make([]OrderCacheEntryStats, 0, len(oc.entries))
+	for key, e := range oc.entries {
+		out = append(out, OrderCacheEntryStats{
+			RegionID:  key.RegionID,
+			OrderType: key.OrderType,
+			Orders:    len(e.orders),
+			UpdatedAt: e.updated,
+			ExpiresAt: e.expires,
+			Expired:   now.After(e.expires),
+		})
+	}
+	oc.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].RegionID != out[j].RegionID {
+			return out[i].RegionID < out[j].RegionID
+		}
+		return out[i].OrderType < out[j].OrderType
+	})
+	return out
+}
+
+// OrderCacheStats returns per-entry order cache stats for debugging.
+func (c *Client) OrderCacheStats() []OrderCacheEntryStats {
+	if c == nil || c.orderCache == nil {
+		return nil
+	}
+	return c.orderCache.Stats()
+}
+
+// OrderCacheRegionIDs returns the regions currently held in the order cache.
+func (c *Client) OrderCacheRegionIDs() []int32 {
+	if c == nil || c.orderCache == nil {
+		return nil
+	}
+	return c.orderCache.RegionIDs()
+}
+
 // OrderCacheWindow returns cache freshness bounds for regions/order type.
 func (c *Client) OrderCacheWindow(regionIDs []int32, orderType string) OrderCacheWindow {
 	if c == nil || c.orderCache == nil {
@@ -257,6 +354,7 @@ func (c *Client) fetchRegionOrdersWithCache(regionID int32, orderType string) ([
 	orders, etag, hit := c.orderCache.Get(regionID, orderType)
 	if hit {
 		log.Printf("[ESI] OrderCache HIT region=%d type=%s (%d orders)", regionID, orderType, len(orders))
+		metrics.CacheRequestsTotal.Inc("orders", "hit")
 		return orders, nil
 	}
 
@@ -268,10 +366,13 @@ func (c *Client) fetchRegionOrdersWithCache(regionID int32, orderType string) ([
 		notModified, newExpires, err := c.conditionalCheck(url+"&page=1", etag)
 		if err == nil && notModified {
 			// 304 — data unchanged, refresh expiry
-			c.orderCache.Touch(regionID, orderType, newExpires)
+			saved := c.orderCache.Touch(regionID, orderType, newExpires)
 			cached, _, _ := c.orderCache.Get(regionID, orderType)
 			if cached != nil {
 				log.Printf("[ESI] OrderCache 304 region=%d type=%s (ETag match)", regionID, orderType)
+				metrics.CacheRequestsTotal.Inc("orders", "revalidated")
+				logger.Debug("ESI", fmt.Sprintf("OrderCache 304 region=%d type=%s: saved %d bytes (total %d)",
+					regionID, orderType, saved, c.orderCache.BytesSaved()))
 				return cached, nil
 			}
 		}
@@ -279,13 +380,14 @@ func (c *Client) fetchRegionOrdersWithCache(regionID int32, orderType string) ([
 	}
 
 	// 3. Full fetch
-	allOrders, respEtag, respExpires, err := c.getPaginatedDirectWithHeaders(url, regionID)
+	allOrders, respEtag, respExpires, size, err := c.getPaginatedDirectWithHeaders(url, regionID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Store in cache
-	c.orderCache.Put(regionID, orderType, allOrders, respEtag, respExpires)
+	c.orderCache.putSized(regionID, orderType, allOrders, respEtag, respExpires, size)
+	metrics.CacheRequestsTotal.Inc("orders", "miss")
 	log.Printf("[ESI] OrderCache MISS region=%d type=%s (%d orders, expires=%s)",
 		regionID, orderType, len(allOrders), respExpires.Format("15:04:05"))
 
@@ -304,7 +406,7 @@ func (c *Client) conditionalCheck(pageURL, etag string) (bool, time.Time, error)
 	}
 	req.Header.Set("If-None-Match", etag)
 
-	resp, err := c.http.Do(req)
+	resp, err := c.do(req, c.scanSem)
 	if err != nil {
 		return false, time.Time{}, err
 	}
@@ -57,6 +57,29 @@ func TestOrderCacheWindowForRegionsSellOnly(t *testing.T) {
 	}
 }
 
+func TestOrderCacheWindowTracksOldestRefresh(t *testing.T) {
+	oc := NewOrderCache()
+	now := time.Now().UTC()
+
+	oc.Put(10000043, "sell", nil, "s2", now.Add(9*time.Minute))
+	oc.Put(10000002, "sell", nil, "s1", now.Add(5*time.Minute))
+	oldest := now.Add(-20 * time.Minute)
+	oc.entries[orderCacheKey{10000043, "sell"}].updated = oldest
+
+	window := oc.WindowForRegions([]int32{10000002, 10000043}, "all")
+	if !window.OldestRefreshAt.Equal(oldest) {
+		t.Fatalf("OldestRefreshAt=%v, want %v", window.OldestRefreshAt, oldest)
+	}
+	if !window.LastRefreshAt.After(window.OldestRefreshAt) {
+		t.Fatalf("LastRefreshAt=%v should be after OldestRefreshAt", window.LastRefreshAt)
+	}
+
+	ids := oc.RegionIDs()
+	if len(ids) != 2 || ids[0] != 10000002 || ids[1] != 10000043 {
+		t.Fatalf("RegionIDs=%v, want [10000002 10000043]", ids)
+	}
+}
+
 func TestOrderCacheClear(t *testing.T) {
 	oc := NewOrderCache()
 	now := time.Now().UTC()
@@ -73,3 +96,39 @@ func TestOrderCacheClear(t *testing.T) {
 		t.Fatalf("Entries=%d, want 0", window.Entries)
 	}
 }
+
+func TestOrderCacheTouchReportsBytesSaved(t *testing.T) {
+	oc := NewOrderCache()
+	now := time.Now().UTC()
+	oc.putSized(10000002, "sell", nil, "s1", now.Add(-time.Minute), 4096)
+
+	if saved := oc.Touch(10000002, "sell", now.Add(5*time.Minute)); saved != 4096 {
+		t.Fatalf("Touch saved=%d, want 4096", saved)
+	}
+	if got := oc.BytesSaved(); got != 4096 {
+		t.Fatalf("BytesSaved=%d, want 4096", got)
+	}
+	window := oc.WindowForRegions([]int32{10000002}, "sell")
+	if window.Stale || window.MinTTLSeconds <= 0 {
+		t.Fatalf("window after 304 = %+v, want fresh TTL", window)
+	}
+}
+
+func TestOrderCacheStats(t *testing.T) {
+	oc := NewOrderCache()
+	now := time.Now().UTC()
+	oc.Put(10000043, "sell", make([]MarketOrder, 3), "s2", now.Add(5*time.Minute))
+	oc.Put(10000002, "sell", make([]MarketOrder, 2), "s1", now.Add(-time.Minute))
+	oc.Put(10000002, "buy", make([]MarketOrder, 1), "b1", now.Add(time.Minute))
+
+	stats := oc.Stats()
+	if len(stats) != 3 {
+		t.Fatalf("stats = %d entries, want 3", len(stats))
+	}
+	if stats[0].RegionID != 10000002 || stats[0].OrderType != "buy" || stats[2].RegionID != 10000043 {
+		t.Fatalf("order = %+v", stats)
+	}
+	if stats[1].Orders != 2 || !stats[1].Expired || stats[0].Expired {
+		t.Fatalf("forge sell = %+v, forge buy = %+v", stats[1], stats[0])
+	}
+}
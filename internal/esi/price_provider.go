@@ -0,0 +1,242 @@
+package esi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Price sources reported on scan rows.
+const (
+	PriceSourceESI      = "esi"
+	PriceSourceFuzzwork = "fuzzwork"
+)
+
+// TradeHubStations maps hub regions to their main trade station.
+var TradeHubStations = map[int32]int64{
+	10000002: 60003760, // Jita IV - Moon 4 - Caldari Navy Assembly Plant
+	10000043: 60008494, // Amarr VIII (Oris) - Emperor Family Academy
+	10000032: 60011866, // Dodixie IX - Moon 20 - Federation Navy Assembly Plant
+	10000030: 60004588, // Rens VI - Moon 8 - Brutor Tribe Treasury
+	10000042: 60005686, // Hek VIII - Moon 12 - Boundless Creation Factory
+}
+
+// PriceAggregate is the top of book for one type at one location.
+type PriceAggregate struct {
+	TypeID     int32
+	BuyMax     float64
+	BuyVolume  int64
+	SellMin    float64
+	SellVolume int64
+}
+
+// PriceProvider returns aggregate buy/sell prices for types at a station
+// (or the whole region when stationID is 0). A canceled ctx stops the
+// lookup and returns its error.
+type PriceProvider interface {
+	Name() string
+	Aggregates(ctx context.Context, regionID int32, stationID int64, typeIDs []int32) (map[int32]PriceAggregate, error)
+}
+
+// ESIPriceProvider aggregates live ESI region orders.
+type ESIPriceProvider struct {
+	Client *Client
+}
+
+func (p *ESIPriceProvider) Name() string { return PriceSourceESI }
+
+func (p *ESIPriceProvider) Aggregates(ctx context.Context, regionID int32, stationID int64, typeIDs []int32) (map[int32]PriceAggregate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	orders, err := p.Client.FetchRegionOrders(regionID, "all")
+	if err != nil {
+		return nil, err
+	}
+	return AggregateOrders(orders, stationID, typeIDs), nil
+}
+
+// AggregateOrders reduces orders to best bid/ask and total depth per type.
+// stationID 0 keeps every location; an empty typeIDs keeps every type.
+func AggregateOrders(orders []MarketOrder, stationID int64, typeIDs []int32) map[int32]PriceAggregate {
+	var want map[int32]bool
+	if len(typeIDs) > 0 {
+		want = make(map[int32]bool, len(typeIDs))
+		for _, id := range typeIDs {
+			want[id] = true
+		}
+	}
+	out := make(map[int32]PriceAggregate)
+	for _, o := range orders {
+		if stationID != 0 && o.LocationID != stationID {
+			continue
+		}
+		if want != nil && !want[o.TypeID] {
+			continue
+		}
+		agg := out[o.TypeID]
+		agg.TypeID = o.TypeID
+		if o.IsBuyOrder {
+			agg.BuyMax = math.Max(agg.BuyMax, o.Price)
+			agg.BuyVolume += int64(o.VolumeRemain)
+		} else {
+			if agg.SellMin == 0 || o.Price < agg.SellMin {
+				agg.SellMin = o.Price
+			}
+			agg.SellVolume += int64(o.VolumeRemain)
+		}
+		out[o.TypeID] = agg
+	}
+	return out
+}
+
+const (
+	fuzzworkAggregatesURL = "https://market.fuzzwork.co.uk/aggregates/"
+	fuzzworkBatchSize     = 200
+	// fuzzworkConcurrency bounds the batches in flight; a full SDE catalog
+	// is about 90 batches.
+	fuzzworkConcurrency = 6
+)
+
+// FuzzworkPriceProvider reads market.fuzzwork.co.uk aggregates, which are
+// built from periodic ESI snapshots and so are only approximately current.
+type FuzzworkPriceProvider struct {
+	HTTP    *http.Client
+	BaseURL string // defaults to the public aggregates endpoint
+}
+
+// NewFuzzworkPriceProvider returns a provider using its own HTTP client.
+func NewFuzzworkPriceProvider() *FuzzworkPriceProvider {
+	return &FuzzworkPriceProvider{HTTP: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (p *FuzzworkPriceProvider) Name() string { return PriceSourceFuzzwork }
+
+type fuzzworkSide struct {
+	Max    fuzzworkNumber `json:"max"`
+	Min    fuzzworkNumber `json:"min"`
+	Volume fuzzworkNumber `json:"volume"`
+}
+
+type fuzzworkAggregate struct {
+	Buy  fuzzworkSide `json:"buy"`
+	Sell fuzzworkSide `json:"sell"`
+}
+
+func (p *FuzzworkPriceProvider) Aggregates(ctx context.Context, regionID int32, stationID int64, typeIDs []int32) (map[int32]PriceAggregate, error) {
+	base := p.BaseURL
+	if base == "" {
+		base = fuzzworkAggregatesURL
+	}
+	scope := fmt.Sprintf("region=%d", regionID)
+	if stationID != 0 {
+		scope = fmt.Sprintf("station=%d", stationID)
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	out := make(map[int32]PriceAggregate, len(typeIDs))
+	sem := make(chan struct{}, fuzzworkConcurrency)
+	for start := 0; start < len(typeIDs); start += fuzzworkBatchSize {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			mu.Lock()
+			firstErr = err
+			mu.Unlock()
+			break
+		}
+		end := min(start+fuzzworkBatchSize, len(typeIDs))
+		ids := make([]string, 0, end-start)
+		for _, id := range typeIDs[start:end] {
+			ids = append(ids, strconv.Itoa(int(id)))
+		}
+		url := fmt.Sprintf("%s?%s&types=%s", base, scope, strings.Join(ids, ","))
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			batch, err := p.fetch(ctx, url)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			for key, sides := range batch {
+				id, err := strconv.ParseInt(key, 10, 32)
+				if err != nil {
+					continue
+				}
+				agg := PriceAggregate{
+					TypeID:     int32(id),
+					BuyMax:     float64(sides.Buy.Max),
+					BuyVolume:  int64(sides.Buy.Volume),
+					SellMin:    float64(sides.Sell.Min),
+					SellVolume: int64(sides.Sell.Volume),
+				}
+				if agg.BuyMax <= 0 && agg.SellMin <= 0 {
+					continue
+				}
+				out[agg.TypeID] = agg
+			}
+		}()
+	}
+	wg.Wait()
+	return out, firstErr
+}
+
+func (p *FuzzworkPriceProvider) fetch(ctx context.Context, url string) (map[string]fuzzworkAggregate, error) {
+	client := p.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := newESIRequest(url)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("fuzzwork: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fuzzwork: HTTP %d", resp.StatusCode)
+	}
+	var body map[string]fuzzworkAggregate
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("fuzzwork: decode: %w", err)
+	}
+	return body, nil
+}
+
+// fuzzworkNumber accepts both the string-encoded numbers Fuzzwork returns
+// and plain JSON numbers. Unparseable values decode as 0.
+type fuzzworkNumber float64
+
+func (n *fuzzworkNumber) UnmarshalJSON(b []byte) error {
+	raw := strings.Trim(strings.TrimSpace(string(b)), `"`)
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		v = 0
+	}
+	*n = fuzzworkNumber(v)
+	return nil
+}
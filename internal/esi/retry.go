@@ -0,0 +1,146 @@
+package esi
+
+import (
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"eve-flipper/internal/metrics"
+)
+
+const (
+	// DefaultMaxRetries is how many times a transient ESI error is retried.
+	DefaultMaxRetries = 3
+	// MaxRetriesLimit caps user-configured retries.
+	MaxRetriesLimit = 10
+
+	retryBaseWait = 500 * time.Millisecond
+	retryMaxWait  = 30 * time.Second
+
+	// errorLimitPauseBelow pauses all ESI requests until the error window
+	// resets once fewer errors than this remain (ESI bans at 0).
+	errorLimitPauseBelow = 10
+	errorLimitMaxPause   = 60 * time.Second
+)
+
+// errorLimitState tracks the X-ESI-Error-Limit-* headers of the last response.
+type errorLimitState struct {
+	mu      sync.RWMutex
+	known   bool
+	remain  int
+	resetAt time.Time
+}
+
+// SetMaxRetries sets how many times transient errors (5xx, 420, 429) are
+// retried before a request fails. Clamped to [0, MaxRetriesLimit].
+func (c *Client) SetMaxRetries(n int) {
+	c.maxRetries.Store(int32(ClampMaxRetries(n)))
+}
+
+// ClampMaxRetries bounds a configured retry count to [0, MaxRetriesLimit].
+func ClampMaxRetries(n int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > MaxRetriesLimit {
+		return MaxRetriesLimit
+	}
+	return n
+}
+
+func (c *Client) retries() int {
+	return int(c.maxRetries.Load())
+}
+
+// retryBackoff returns the wait before retry attempt (1-based): exponential
+// from retryBaseWait with up to 50% jitter, capped at retryMaxWait.
+func retryBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		return 0
+	}
+	wait := retryBaseWait << min(attempt-1, 16)
+	if wait > retryMaxWait || wait <= 0 {
+		wait = retryMaxWait
+	}
+	return wait + rand.N(wait/2+1)
+}
+
+// waitRetry sleeps before retry attempt. When ESI has error-limited us the
+// wait runs until the error window resets instead of the plain backoff.
+func (c *Client) waitRetry(attempt int) {
+	wait := retryBackoff(attempt)
+	if pause := c.errorLimitPause(0); pause > wait {
+		wait = pause
+	}
+	time.Sleep(wait)
+}
+
+// do sends req through the shared transport, pausing first while the ESI
+// error budget is nearly exhausted and recording the error-limit headers
+// of the response. The caller holds a slot of held; it is given back for
+// the pause so waiting requests do not starve the rest of the client.
+func (c *Client) do(req *http.Request, held chan struct{}) (*http.Response, error) {
+	if pause := c.errorLimitPause(errorLimitPauseBelow); pause > 0 {
+		log.Printf("[ESI] Error limit low, pausing %s", pause.Round(time.Second))
+		<-held
+		time.Sleep(pause)
+		held <- struct{}{}
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		metrics.ESIRequestsTotal.Inc("error")
+		metrics.ESIErrorsTotal.Inc("error")
+		return resp, err
+	}
+	code := metrics.StatusClass(resp.StatusCode)
+	metrics.ESIRequestsTotal.Inc(code)
+	if resp.StatusCode >= 400 {
+		metrics.ESIErrorsTotal.Inc(code)
+	}
+	c.observeErrorLimit(resp)
+	return resp, nil
+}
+
+// observeErrorLimit records X-ESI-Error-Limit-Remain/Reset from resp.
+func (c *Client) observeErrorLimit(resp *http.Response) {
+	remainRaw := resp.Header.Get("X-ESI-Error-Limit-Remain")
+	if remainRaw == "" {
+		return
+	}
+	remain, err := strconv.Atoi(remainRaw)
+	if err != nil {
+		return
+	}
+	reset, _ := strconv.Atoi(resp.Header.Get("X-ESI-Error-Limit-Reset"))
+	c.errorLimit.mu.Lock()
+	c.errorLimit.known = true
+	c.errorLimit.remain = remain
+	c.errorLimit.resetAt = time.Now().Add(time.Duration(reset) * time.Second)
+	c.errorLimit.mu.Unlock()
+}
+
+// errorLimitPause returns how long to wait for the error window to reset
+// when at most below errors remain (0 means only when fully exhausted).
+func (c *Client) errorLimitPause(below int) time.Duration {
+	c.errorLimit.mu.RLock()
+	defer c.errorLimit.mu.RUnlock()
+	if !c.errorLimit.known || c.errorLimit.remain > below {
+		return 0
+	}
+	pause := time.Until(c.errorLimit.resetAt)
+	if pause <= 0 {
+		return 0
+	}
+	return min(pause, errorLimitMaxPause)
+}
+
+// ErrorLimit returns the ESI error budget reported by the last response.
+// ok is false until a response carried the header.
+func (c *Client) ErrorLimit() (remain int, resetAt time.Time, ok bool) {
+	c.errorLimit.mu.RLock()
+	defer c.errorLimit.mu.RUnlock()
+	return c.errorLimit.remain, c.errorLimit.resetAt, c.errorLimit.known
+}
@@ -0,0 +1,123 @@
+package esi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetJSONRetriesTransientErrorsUpToConfiguredLimit(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-ESI-Error-Limit-Remain", "87")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "42")
+		if calls <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"ok"}`))
+	}))
+	defer srv.Close()
+
+	c := &Client{http: srv.Client(), sem: make(chan struct{}, 1)}
+	var out struct{ Name string }
+	if err := c.GetJSON(srv.URL, &out); err == nil {
+		t.Fatal("expected error with retries disabled")
+	}
+
+	c.SetMaxRetries(1)
+	if err := c.GetJSON(srv.URL, &out); err != nil {
+		t.Fatalf("GetJSON: %v", err)
+	}
+	if out.Name != "ok" || calls != 3 {
+		t.Fatalf("name=%q calls=%d", out.Name, calls)
+	}
+
+	remain, resetAt, ok := c.ErrorLimit()
+	if !ok || remain != 87 {
+		t.Fatalf("ErrorLimit = %d, %v, want 87", remain, ok)
+	}
+	if until := time.Until(resetAt); until < 40*time.Second || until > 42*time.Second {
+		t.Fatalf("reset in %s, want ~42s", until)
+	}
+}
+
+func TestErrorLimitPauseOnlyWhenBudgetLow(t *testing.T) {
+	c := &Client{}
+	if pause := c.errorLimitPause(errorLimitPauseBelow); pause != 0 {
+		t.Fatalf("pause without headers = %s", pause)
+	}
+	set := func(remain, reset string) {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("X-ESI-Error-Limit-Remain", remain)
+		resp.Header.Set("X-ESI-Error-Limit-Reset", reset)
+		c.observeErrorLimit(resp)
+	}
+	set("50", "30")
+	if pause := c.errorLimitPause(errorLimitPauseBelow); pause != 0 {
+		t.Fatalf("pause with healthy budget = %s", pause)
+	}
+	set("5", "30")
+	if pause := c.errorLimitPause(errorLimitPauseBelow); pause <= 25*time.Second {
+		t.Fatalf("pause with low budget = %s, want ~30s", pause)
+	}
+	set("0", "600")
+	if pause := c.errorLimitPause(0); pause != errorLimitMaxPause {
+		t.Fatalf("pause = %s, want capped at %s", pause, errorLimitMaxPause)
+	}
+}
+
+func TestDoReleasesSemaphoreWhilePausing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := &Client{http: srv.Client(), sem: make(chan struct{}, 1)}
+	c.errorLimit.known = true
+	c.errorLimit.remain = 1
+	c.errorLimit.resetAt = time.Now().Add(300 * time.Millisecond)
+
+	c.sem <- struct{}{}
+	done := make(chan error, 1)
+	go func() {
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		resp, err := c.do(req, c.sem)
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+
+	// The only slot is held by the pausing request; another caller must
+	// still get it during the pause.
+	select {
+	case c.sem <- struct{}{}:
+		<-c.sem
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("semaphore held during error-limit pause")
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	if len(c.sem) != 1 {
+		t.Fatalf("semaphore slots held after do = %d, want the caller's 1", len(c.sem))
+	}
+}
+
+func TestRetryBackoffGrowsWithJitterAndCap(t *testing.T) {
+	for attempt := 1; attempt <= 3; attempt++ {
+		base := retryBaseWait << (attempt - 1)
+		for i := 0; i < 20; i++ {
+			if d := retryBackoff(attempt); d < base || d > base+base/2 {
+				t.Fatalf("attempt %d backoff %s outside [%s, %s]", attempt, d, base, base+base/2)
+			}
+		}
+	}
+	if d := retryBackoff(40); d < retryMaxWait || d > retryMaxWait+retryMaxWait/2 {
+		t.Fatalf("capped backoff = %s", d)
+	}
+	if got := ClampMaxRetries(99); got != MaxRetriesLimit {
+		t.Fatalf("ClampMaxRetries(99) = %d", got)
+	}
+}
@@ -0,0 +1,110 @@
+package esi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// serverStatusTTL is how long a /status/ answer is reused. ESI itself caches
+// the endpoint for 30 seconds, so polling faster only burns error budget.
+const serverStatusTTL = 30 * time.Second
+
+// ServerStatus is the state of Tranquility as reported by ESI /status/.
+type ServerStatus struct {
+	// Online is always true when FetchServerStatus succeeds: ESI only
+	// answers /status/ with 200 while TQ is up. Downtime shows up as an error.
+	Online        bool      `json:"online"`
+	Players       int       `json:"players"`
+	ServerVersion string    `json:"server_version,omitempty"`
+	StartTime     time.Time `json:"start_time,omitempty"`
+	// VIP is set while TQ only admits developers, e.g. right after downtime.
+	VIP       bool      `json:"vip"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type serverStatusCache struct {
+	group  singleflight.Group // one upstream request at a time
+	mu     sync.Mutex         // guards the fields below, never held over HTTP
+	status ServerStatus
+	err    error
+	at     time.Time
+}
+
+// FetchServerStatus returns Tranquility's status, cached for 30 seconds.
+// An error means ESI was unreachable or answered with a non-200 status
+// (downtime, error limiting, 5xx), so TQ's state is unknown.
+func (c *Client) FetchServerStatus() (ServerStatus, error) {
+	return c.serverStatusFrom(baseURL + "/status/?datasource=tranquility")
+}
+
+func (c *Client) serverStatusFrom(url string) (ServerStatus, error) {
+	c.serverStatus.mu.Lock()
+	if !c.serverStatus.at.IsZero() && time.Since(c.serverStatus.at) < serverStatusTTL {
+		status, err := c.serverStatus.status, c.serverStatus.err
+		c.serverStatus.mu.Unlock()
+		return status, err
+	}
+	c.serverStatus.mu.Unlock()
+
+	v, _, _ := c.serverStatus.group.Do(url, func() (interface{}, error) {
+		status, err := c.fetchServerStatus(url)
+		// Failures are cached too, so a down ESI is not hit on every status poll.
+		c.serverStatus.mu.Lock()
+		c.serverStatus.status, c.serverStatus.err, c.serverStatus.at = status, err, time.Now()
+		c.serverStatus.mu.Unlock()
+		return serverStatusResult{status, err}, nil
+	})
+	res := v.(serverStatusResult)
+	return res.status, res.err
+}
+
+type serverStatusResult struct {
+	status ServerStatus
+	err    error
+}
+
+// fetchServerStatus makes a single request without retries: during downtime
+// ESI answers 503 immediately, and retrying would only delay the status page.
+func (c *Client) fetchServerStatus(url string) (ServerStatus, error) {
+	status := ServerStatus{CheckedAt: time.Now()}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return status, err
+	}
+	req.Header.Set("User-Agent", "eve-flipper/1.0 (github.com)")
+	req.Header.Set("Accept", "application/json")
+
+	c.sem <- struct{}{}
+	resp, err := c.do(req, c.sem)
+	<-c.sem
+	if err != nil {
+		return status, fmt.Errorf("ESI status: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// A 503 may mean downtime, but 420 and other 5xx say nothing about
+		// TQ, so report the state as unknown rather than offline.
+		return status, fmt.Errorf("ESI status: HTTP %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Players       int       `json:"players"`
+		ServerVersion string    `json:"server_version"`
+		StartTime     time.Time `json:"start_time"`
+		VIP           bool      `json:"vip"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return status, fmt.Errorf("ESI status: %w", err)
+	}
+	status.Online = true
+	status.Players = body.Players
+	status.ServerVersion = body.ServerVersion
+	status.StartTime = body.StartTime
+	status.VIP = body.VIP
+	return status, nil
+}
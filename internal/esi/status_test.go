@@ -0,0 +1,93 @@
+package esi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServerStatus_OnlineAndCached(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"players":23456,"server_version":"2865011","start_time":"2026-10-17T11:02:00Z","vip":true}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil)
+	c.http = srv.Client()
+	st, err := c.serverStatusFrom(srv.URL + "/status/")
+	if err != nil {
+		t.Fatalf("serverStatusFrom: %v", err)
+	}
+	if !st.Online || st.Players != 23456 || !st.VIP || st.ServerVersion != "2865011" || st.StartTime.IsZero() {
+		t.Fatalf("status = %+v", st)
+	}
+	if _, err := c.serverStatusFrom(srv.URL + "/status/"); err != nil || calls != 1 {
+		t.Fatalf("second call: err=%v upstream calls=%d, want cached", err, calls)
+	}
+}
+
+func TestServerStatus_Non200IsUnknown(t *testing.T) {
+	for _, code := range []int{http.StatusServiceUnavailable, 420, http.StatusBadGateway} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(code)
+			_, _ = w.Write([]byte(`{"error":"The datasource tranquility is temporarily unavailable"}`))
+		}))
+		c := NewClient(nil)
+		c.http = srv.Client()
+		st, err := c.fetchServerStatus(srv.URL + "/status/")
+		srv.Close()
+		if err == nil {
+			t.Fatalf("HTTP %d: status = %+v, want error", code, st)
+		}
+		if st.Online {
+			t.Fatalf("HTTP %d: status = %+v", code, st)
+		}
+	}
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	c := NewClient(nil)
+	if _, err := c.fetchServerStatus(srv.URL + "/status/"); err == nil {
+		t.Fatal("expected error when ESI is unreachable")
+	}
+}
+
+func TestServerStatus_ConcurrentCallersShareFetch(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`{"players":1}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(nil)
+	c.http = srv.Client()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.serverStatusFrom(srv.URL + "/status/"); err != nil {
+				t.Errorf("serverStatusFrom: %v", err)
+			}
+		}()
+	}
+	// The cache mutex must stay free while the request is in flight.
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.serverStatus.mu.Lock()
+	c.serverStatus.mu.Unlock()
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("upstream calls = %d, want 1", n)
+	}
+}
@@ -211,6 +211,55 @@ func (u *Universe) bfs(origin, dest int32, minSecurity float64) int {
 	return -1
 }
 
+// PathMinSecurity returns the systems along a shortest route from origin to
+// dest, both included, using only systems with security >= minSecurity (use
+// minSecurity <= 0 for no filter). Paths are not cached. Returns nil if no
+// path exists.
+func (u *Universe) PathMinSecurity(origin, dest int32, minSecurity float64) []int32 {
+	if origin == dest {
+		return []int32{origin}
+	}
+	if minSecurity > 0 {
+		if sec, ok := u.SystemSecurity[origin]; ok && sec < minSecurity {
+			return nil
+		}
+		if sec, ok := u.SystemSecurity[dest]; ok && sec < minSecurity {
+			return nil
+		}
+	}
+
+	parent := make(map[int32]int32, 256)
+	parent[origin] = origin
+	queue := []int32{origin}
+	for head := 0; head < len(queue); head++ {
+		current := queue[head]
+		for _, neighbor := range u.Adj[current] {
+			if _, visited := parent[neighbor]; visited {
+				continue
+			}
+			if minSecurity > 0 {
+				if sec, ok := u.SystemSecurity[neighbor]; !ok || sec < minSecurity {
+					continue
+				}
+			}
+			parent[neighbor] = current
+			if neighbor == dest {
+				var path []int32
+				for at := dest; at != origin; at = parent[at] {
+					path = append(path, at)
+				}
+				path = append(path, origin)
+				for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return path
+			}
+			queue = append(queue, neighbor)
+		}
+	}
+	return nil
+}
+
 // InitPathCache initializes the shortest-path LRU cache.
 // Must be called after the universe graph is fully loaded.
 // Safe to call multiple times (idempotent).
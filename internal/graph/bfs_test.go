@@ -86,3 +86,22 @@ func TestRegionsInSet(t *testing.T) {
 		t.Errorf("RegionsInSet: got %v, want {10:true}", regions)
 	}
 }
+
+func TestPathMinSecurity(t *testing.T) {
+	u := makeTestUniverse()
+	u.SystemSecurity = map[int32]float64{1: 0.9, 2: 0.9, 3: 0.3, 4: 0.9}
+
+	if got := u.PathMinSecurity(1, 4, 0); len(got) != 3 || got[0] != 1 || got[1] != 3 || got[2] != 4 {
+		t.Errorf("PathMinSecurity(1,4,0) = %v, want [1 3 4]", got)
+	}
+	if got := u.PathMinSecurity(1, 2, 0.5); len(got) != 2 || got[1] != 2 {
+		t.Errorf("PathMinSecurity(1,2,0.5) = %v, want [1 2]", got)
+	}
+	// 4 is only reachable through lowsec system 3.
+	if got := u.PathMinSecurity(1, 4, 0.5); got != nil {
+		t.Errorf("PathMinSecurity(1,4,0.5) = %v, want nil", got)
+	}
+	if got := u.PathMinSecurity(2, 2, 0); len(got) != 1 {
+		t.Errorf("PathMinSecurity(2,2,0) = %v, want [2]", got)
+	}
+}
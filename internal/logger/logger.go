@@ -5,6 +5,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unicode/utf8"
 )
@@ -225,6 +226,26 @@ func Banner(version string) {
 	fmt.Println()
 }
 
+var debugEnabled atomic.Bool
+
+// SetDebug enables or disables Debug output.
+func SetDebug(enabled bool) {
+	debugEnabled.Store(enabled)
+}
+
+// DebugEnabled reports whether Debug output is enabled.
+func DebugEnabled() bool {
+	return debugEnabled.Load()
+}
+
+// Debug prints a debug message when debug output is enabled
+func Debug(tag, msg string) {
+	if !debugEnabled.Load() {
+		return
+	}
+	printLog("DEBUG", tag, msg, dim, "·", ".")
+}
+
 // Info prints an info message
 func Info(tag, msg string) {
 	printLog("INFO", tag, msg, blue, "●", "*")
@@ -261,8 +282,17 @@ func Done(details string) {
 
 // Server prints the server listening message
 func Server(addr string) {
+	listening("http://" + addr)
+}
+
+// ServerSocket prints the listening message for a Unix domain socket.
+func ServerSocket(path string) {
+	listening("unix:" + path)
+}
+
+func listening(target string) {
 	fmt.Println()
-	Success("SERVER", "Listening on "+colorize(cyan+bold, "http://"+addr))
+	Success("SERVER", "Listening on "+colorize(cyan+bold, target))
 	fmt.Printf("%s %s %s\n", strings.Repeat(" ", 12), messageSeparator(), colorize(dim, "Press Ctrl+C to stop"))
 	fmt.Println()
 }
@@ -0,0 +1,35 @@
+package metrics
+
+import "strconv"
+
+// Application metrics exported on /metrics.
+var (
+	ScansTotal = NewCounterVec("eveflipper_scans_total",
+		"Scans started, by scan type.", "type")
+	ScanDuration = NewHistogramVec("eveflipper_scan_duration_seconds",
+		"Scan request duration in seconds, by scan type.", DefaultDurationBuckets, "type")
+
+	ESIRequestsTotal = NewCounterVec("eveflipper_esi_requests_total",
+		"ESI HTTP requests, by status code class (2xx, 304, 4xx, 5xx, error).", "code")
+	ESIErrorsTotal = NewCounterVec("eveflipper_esi_errors_total",
+		"ESI requests that failed with a transport error or a 4xx/5xx status.", "code")
+
+	CacheRequestsTotal = NewCounterVec("eveflipper_cache_requests_total",
+		"Cache lookups, by cache and result (hit, miss, revalidated).", "cache", "result")
+
+	AIChatCallsTotal = NewCounterVec("eveflipper_ai_chat_calls_total",
+		"Station AI chat requests, by provider and mode (chat, stream).", "provider", "mode")
+)
+
+// StatusClass buckets an HTTP status into the label used by the ESI
+// counters. 304 is kept separate so ETag revalidations are visible.
+func StatusClass(status int) string {
+	switch {
+	case status == 304:
+		return "304"
+	case status >= 200 && status < 600:
+		return strconv.Itoa(status/100) + "xx"
+	default:
+		return "other"
+	}
+}
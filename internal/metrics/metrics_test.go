@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCounterVecWritesLabelledSeries(t *testing.T) {
+	c := &CounterVec{metricName: "test_total", help: "Test.", labels: []string{"kind"}, values: map[string]float64{}}
+	c.Inc("b")
+	c.Add(2, "a")
+	c.Inc(`q"uote`)
+	c.Add(-1, "a") // ignored
+
+	var buf bytes.Buffer
+	c.write(&buf)
+	want := "# HELP test_total Test.\n# TYPE test_total counter\n" +
+		"test_total{kind=\"a\"} 2\n" +
+		"test_total{kind=\"b\"} 1\n" +
+		"test_total{kind=\"q\\\"uote\"} 1\n"
+	if buf.String() != want {
+		t.Fatalf("output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestHistogramVecCumulativeBuckets(t *testing.T) {
+	h := &HistogramVec{metricName: "dur_seconds", help: "Dur.", labels: []string{"type"},
+		buckets: []float64{1, 5}, series: map[string]*histogramSeries{}}
+	h.Observe(0.5, "x")
+	h.Observe(3, "x")
+	h.Observe(10, "x")
+
+	var buf bytes.Buffer
+	h.write(&buf)
+	out := buf.String()
+	for _, line := range []string{
+		`dur_seconds_bucket{type="x",le="1"} 1`,
+		`dur_seconds_bucket{type="x",le="5"} 2`,
+		`dur_seconds_bucket{type="x",le="+Inf"} 3`,
+		`dur_seconds_sum{type="x"} 13.5`,
+		`dur_seconds_count{type="x"} 3`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Fatalf("missing %q in:\n%s", line, out)
+		}
+	}
+	if h.Count("x") != 3 {
+		t.Fatalf("Count = %d", h.Count("x"))
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	for status, want := range map[int]string{200: "2xx", 304: "304", 420: "4xx", 503: "5xx", 0: "other"} {
+		if got := StatusClass(status); got != want {
+			t.Fatalf("StatusClass(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package sde
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+const (
+	// cacheFileName is the parsed-SDE snapshot stored next to the extracted SDE.
+	cacheFileName = "sde_cache.gob"
+	// cacheFormat is bumped whenever Data (or anything it embeds) changes shape,
+	// so snapshots written by older builds are rebuilt instead of half-decoded.
+	cacheFormat = 3
+)
+
+// cacheEnvelope wraps the gob-encoded Data with what is needed to decide
+// whether it may be used: the format, the SDE version it was built from and a
+// checksum of the payload.
+type cacheEnvelope struct {
+	Format   int
+	Version  string
+	Checksum [sha256.Size]byte
+	Payload  []byte
+}
+
+// sdeVersion fingerprints the extracted SDE directory from the path, size and
+// modification time of every file in it. A fresh download or extraction
+// changes the fingerprint and invalidates the cache.
+func sdeVersion(extractDir string) (string, error) {
+	var entries []string
+	err := filepath.WalkDir(extractDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(extractDir, path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, filepath.ToSlash(rel)+"|"+strconv.FormatInt(info.Size(), 10)+"|"+strconv.FormatInt(info.ModTime().UnixNano(), 10))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("no SDE files in %s", extractDir)
+	}
+	sort.Strings(entries)
+	h := sha256.New()
+	fmt.Fprintf(h, "format=%d\n", cacheFormat)
+	for _, e := range entries {
+		h.Write([]byte(e))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// saveCache writes data to path. The file is written to a temporary name and
+// renamed so a crash mid-write never leaves a truncated cache behind.
+func saveCache(path, version string, data *Data) error {
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(data); err != nil {
+		return fmt.Errorf("encode SDE cache: %w", err)
+	}
+	env := cacheEnvelope{
+		Format:   cacheFormat,
+		Version:  version,
+		Checksum: sha256.Sum256(payload.Bytes()),
+		Payload:  payload.Bytes(),
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(&env); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("write SDE cache: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadCache reads a cache written by saveCache. It fails if the file is
+// missing, was built from another SDE version or format, does not match its
+// checksum, or decodes to obviously incomplete data.
+func loadCache(path, version string) (*Data, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var env cacheEnvelope
+	if err := gob.NewDecoder(f).Decode(&env); err != nil {
+		return nil, fmt.Errorf("decode SDE cache: %w", err)
+	}
+	if env.Format != cacheFormat {
+		return nil, fmt.Errorf("SDE cache format %d, want %d", env.Format, cacheFormat)
+	}
+	if env.Version != version {
+		return nil, fmt.Errorf("SDE cache is for a different SDE version")
+	}
+	if sha256.Sum256(env.Payload) != env.Checksum {
+		return nil, fmt.Errorf("SDE cache checksum mismatch")
+	}
+
+	var data Data
+	if err := gob.NewDecoder(bytes.NewReader(env.Payload)).Decode(&data); err != nil {
+		return nil, fmt.Errorf("decode SDE cache payload: %w", err)
+	}
+	if len(data.Systems) == 0 || len(data.Types) == 0 || data.Universe == nil || data.Industry == nil {
+		return nil, fmt.Errorf("SDE cache is incomplete")
+	}
+	data.ensureMaps()
+	return &data, nil
+}
+
+// ensureMaps replaces maps that gob decoded as nil (it omits empty maps) with
+// empty ones, so callers can write to them exactly as after a full parse.
+func (d *Data) ensureMaps() {
+	if d.Systems == nil {
+		d.Systems = make(map[int32]*SolarSystem)
+	}
+	if d.SystemByName == nil {
+		d.SystemByName = make(map[string]int32)
+	}
+	if d.Regions == nil {
+		d.Regions = make(map[int32]*Region)
+	}
+	if d.RegionByName == nil {
+		d.RegionByName = make(map[string]int32)
+	}
+	if d.Types == nil {
+		d.Types = make(map[int32]*ItemType)
+	}
+	if d.Groups == nil {
+		d.Groups = make(map[int32]*ItemGroup)
+	}
+	if d.Categories == nil {
+		d.Categories = make(map[int32]*ItemCategory)
+	}
+	if d.MarketGroups == nil {
+		d.MarketGroups = make(map[int32]*MarketGroup)
+	}
+	if d.Stations == nil {
+		d.Stations = make(map[int64]*Station)
+	}
+	u := d.Universe
+	if u.Adj == nil {
+		u.Adj = make(map[int32][]int32)
+	}
+	if u.SystemRegion == nil {
+		u.SystemRegion = make(map[int32]int32)
+	}
+	if u.SystemSecurity == nil {
+		u.SystemSecurity = make(map[int32]float64)
+	}
+	ind := d.Industry
+	if ind.Blueprints == nil {
+		ind.Blueprints = make(map[int32]*Blueprint)
+	}
+	if ind.ProductToBlueprint == nil {
+		ind.ProductToBlueprint = make(map[int32]int32)
+	}
+	if ind.InventedFrom == nil {
+		ind.InventedFrom = make(map[int32]int32)
+	}
+	if ind.Reprocessing == nil {
+		ind.Reprocessing = make(map[int32]*ReprocessingMaterial)
+	}
+	if ind.BaseCategories == nil {
+		ind.BaseCategories = make(map[int32]bool)
+	}
+}
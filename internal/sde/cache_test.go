@@ -0,0 +1,102 @@
+package sde
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"eve-flipper/internal/graph"
+)
+
+func testCacheData() *Data {
+	u := graph.NewUniverse()
+	u.AddGate(30000142, 30000144)
+	u.AddGate(30000144, 30000142)
+	u.SetRegion(30000142, 10000002)
+	u.SetSecurity(30000142, 0.95)
+	ind := NewIndustryData()
+	ind.Blueprints[691] = &Blueprint{BlueprintTypeID: 691, ProductTypeID: 587, ProductQuantity: 1,
+		Materials: []BlueprintMaterial{{TypeID: 34, Quantity: 32000}}}
+	return &Data{
+		Systems:      map[int32]*SolarSystem{30000142: {ID: 30000142, Name: "Jita", RegionID: 10000002, Security: 0.95}},
+		SystemByName: map[string]int32{"jita": 30000142},
+		SystemNames:  []string{"Jita"},
+		Regions:      map[int32]*Region{10000002: {ID: 10000002, Name: "The Forge"}},
+		RegionByName: map[string]int32{"the forge": 10000002},
+		Types:        map[int32]*ItemType{34: {ID: 34, Name: "Tritanium", Volume: 0.01}},
+		Stations:     map[int64]*Station{60003760: {ID: 60003760, Name: "Station in Jita", SystemID: 30000142}},
+		Universe:     u,
+		Industry:     ind,
+	}
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), cacheFileName)
+	if err := saveCache(path, "v1", testCacheData()); err != nil {
+		t.Fatalf("saveCache: %v", err)
+	}
+
+	got, err := loadCache(path, "v1")
+	if err != nil {
+		t.Fatalf("loadCache: %v", err)
+	}
+	if got.Systems[30000142].Name != "Jita" || got.Types[34].Name != "Tritanium" {
+		t.Fatalf("decoded data = %+v", got)
+	}
+	if got.Universe.ShortestPath(30000142, 30000144) != 1 {
+		t.Fatal("universe adjacency not restored")
+	}
+	if got.Industry.Blueprints[691].Materials[0].Quantity != 32000 {
+		t.Fatal("industry data not restored")
+	}
+	// Groups was empty when saved; it must still be writable after decode.
+	got.Groups[1] = &ItemGroup{ID: 1}
+
+	if _, err := loadCache(path, "v2"); err == nil {
+		t.Fatal("loadCache with another SDE version: want error")
+	}
+}
+
+func TestLoadCacheRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), cacheFileName)
+	if err := saveCache(path, "v1", testCacheData()); err != nil {
+		t.Fatalf("saveCache: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw[len(raw)-10] ^= 0xff
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCache(path, "v1"); err == nil {
+		t.Fatal("corrupted cache: want error")
+	}
+}
+
+func TestSDEVersionChangesWithFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "types.jsonl")
+	if err := os.WriteFile(file, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	v1, err := sdeVersion(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := sdeVersion(dir); again != v1 {
+		t.Fatal("version is not stable")
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if v2, _ := sdeVersion(dir); v2 == v1 {
+		t.Fatal("version did not change after the SDE file changed")
+	}
+	if _, err := sdeVersion(t.TempDir()); err == nil {
+		t.Fatal("empty SDE dir: want error")
+	}
+}
@@ -16,6 +16,7 @@ type Blueprint struct {
 	Materials       []BlueprintMaterial       // Required materials for manufacturing
 	Time            int32                     // Manufacturing time in seconds (base)
 	Activities      map[string]*ActivityData  // All activities (manufacturing, copying, invention, etc.)
+	IsReaction      bool                      // Reaction formula: Materials/Time come from the reaction activity
 }
 
 // BlueprintMaterial represents a single material requirement.
@@ -61,6 +62,7 @@ type MaterialYield struct {
 type IndustryData struct {
 	Blueprints          map[int32]*Blueprint          // blueprintTypeID -> Blueprint
 	ProductToBlueprint  map[int32]int32               // productTypeID -> blueprintTypeID
+	InventedFrom        map[int32]int32               // T2 blueprintTypeID -> T1 blueprintTypeID
 	Reprocessing        map[int32]*ReprocessingMaterial // oreTypeID -> yields
 	BaseCategories      map[int32]bool                // categoryIDs that are "base" materials (minerals, PI, etc.)
 }
@@ -70,6 +72,7 @@ func NewIndustryData() *IndustryData {
 	return &IndustryData{
 		Blueprints:         make(map[int32]*Blueprint),
 		ProductToBlueprint: make(map[int32]int32),
+		InventedFrom:       make(map[int32]int32),
 		Reprocessing:       make(map[int32]*ReprocessingMaterial),
 		BaseCategories:     make(map[int32]bool),
 	}
@@ -241,17 +244,47 @@ func (ind *IndustryData) parseBlueprintLine(raw json.RawMessage) error {
 		}
 		blueprint.Activities["reaction"] = actData
 
-		// If no manufacturing product, use reaction product
+		// Reaction formulas have no manufacturing activity: the reaction is
+		// the production step, so its inputs and time become the blueprint's.
 		if blueprint.ProductTypeID == 0 && len(rxn.Products) > 0 {
+			blueprint.IsReaction = true
 			blueprint.ProductTypeID = rxn.Products[0].TypeID
 			blueprint.ProductQuantity = rxn.Products[0].Quantity
+			if blueprint.ProductQuantity == 0 {
+				blueprint.ProductQuantity = 1
+			}
+			blueprint.Materials = actData.Materials
+			blueprint.Time = rxn.Time
+		}
+	}
+
+	// Process invention activity (T1 BPC -> T2 BPC)
+	if inv := bp.Activities.Invention; inv != nil {
+		actData := &ActivityData{
+			Time: inv.Time,
+		}
+		for _, m := range inv.Materials {
+			actData.Materials = append(actData.Materials, BlueprintMaterial{
+				TypeID: m.TypeID, Quantity: m.Quantity,
+			})
+		}
+		for _, p := range inv.Products {
+			actData.Products = append(actData.Products, BlueprintProduct{
+				TypeID: p.TypeID, Quantity: p.Quantity, Probability: p.Probability,
+			})
 		}
+		blueprint.Activities["invention"] = actData
 	}
 
 	// Only store blueprints that produce something
 	if blueprint.ProductTypeID != 0 {
 		ind.Blueprints[bp.Key] = blueprint
 		ind.ProductToBlueprint[blueprint.ProductTypeID] = bp.Key
+		if inv := blueprint.Activities["invention"]; inv != nil {
+			for _, p := range inv.Products {
+				ind.InventedFrom[p.TypeID] = bp.Key
+			}
+		}
 	}
 
 	return nil
@@ -306,6 +339,37 @@ func (ind *IndustryData) GetBlueprintForProduct(typeID int32) (*Blueprint, bool)
 	return bp, ok
 }
 
+// GetInventionSource returns the T1 blueprint whose invention activity
+// produces the given T2 blueprint, along with that invention product entry.
+func (ind *IndustryData) GetInventionSource(blueprintTypeID int32) (*Blueprint, BlueprintProduct, bool) {
+	srcID, ok := ind.InventedFrom[blueprintTypeID]
+	if !ok {
+		return nil, BlueprintProduct{}, false
+	}
+	src, ok := ind.Blueprints[srcID]
+	if !ok {
+		return nil, BlueprintProduct{}, false
+	}
+	inv := src.Activities["invention"]
+	if inv == nil {
+		return nil, BlueprintProduct{}, false
+	}
+	for _, p := range inv.Products {
+		if p.TypeID == blueprintTypeID {
+			return src, p, true
+		}
+	}
+	return nil, BlueprintProduct{}, false
+}
+
+// JobType returns "reaction" for reaction formulas and "manufacturing" otherwise.
+func (bp *Blueprint) JobType() string {
+	if bp.IsReaction {
+		return "reaction"
+	}
+	return "manufacturing"
+}
+
 // CalculateMaterialsWithME calculates required materials with Material Efficiency applied.
 // ME ranges from 0-10 (each level reduces materials by 1%).
 func (bp *Blueprint) CalculateMaterialsWithME(runs int32, me int32) []BlueprintMaterial {
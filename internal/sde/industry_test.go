@@ -106,3 +106,53 @@ func TestGetBlueprintForProduct(t *testing.T) {
 		t.Error("GetBlueprintForProduct(999) should be false")
 	}
 }
+
+func TestParseBlueprintLine_IndexesInvention(t *testing.T) {
+	ind := NewIndustryData()
+	line := `{"_key": 1000, "activities": {
+		"manufacturing": {"time": 600, "materials": [{"typeID": 34, "quantity": 10}], "products": [{"typeID": 100, "quantity": 1}]},
+		"invention": {"time": 3000, "materials": [{"typeID": 20410, "quantity": 2}], "products": [{"typeID": 2000, "quantity": 10, "probability": 0.34}]}
+	}}`
+	if err := ind.parseBlueprintLine([]byte(line)); err != nil {
+		t.Fatalf("parseBlueprintLine: %v", err)
+	}
+	t2 := `{"_key": 2000, "activities": {"manufacturing": {"time": 1200, "materials": [{"typeID": 100, "quantity": 1}], "products": [{"typeID": 200, "quantity": 1}]}}}`
+	if err := ind.parseBlueprintLine([]byte(t2)); err != nil {
+		t.Fatalf("parseBlueprintLine: %v", err)
+	}
+
+	src, product, ok := ind.GetInventionSource(2000)
+	if !ok || src.BlueprintTypeID != 1000 {
+		t.Fatalf("GetInventionSource(2000) = %v, %v", src, ok)
+	}
+	if product.Quantity != 10 || product.Probability != 0.34 {
+		t.Errorf("invention product = %+v, want quantity 10, probability 0.34", product)
+	}
+	if mats := src.Activities["invention"].Materials; len(mats) != 1 || mats[0].TypeID != 20410 {
+		t.Errorf("invention materials = %+v", mats)
+	}
+	if _, _, ok := ind.GetInventionSource(1000); ok {
+		t.Error("GetInventionSource(1000) should be false for a T1 blueprint")
+	}
+}
+
+func TestParseBlueprintLine_ReactionFormula(t *testing.T) {
+	ind := NewIndustryData()
+	line := `{"_key": 46166, "activities": {"reaction": {"time": 10800,
+		"materials": [{"typeID": 16634, "quantity": 100}, {"typeID": 16643, "quantity": 100}, {"typeID": 4312, "quantity": 5}],
+		"products": [{"typeID": 16663, "quantity": 200}]}}}`
+	if err := ind.parseBlueprintLine([]byte(line)); err != nil {
+		t.Fatalf("parseBlueprintLine: %v", err)
+	}
+
+	bp, ok := ind.GetBlueprintForProduct(16663)
+	if !ok {
+		t.Fatal("reaction product not indexed")
+	}
+	if !bp.IsReaction || bp.JobType() != "reaction" {
+		t.Errorf("IsReaction = %v, JobType = %q; want reaction", bp.IsReaction, bp.JobType())
+	}
+	if bp.ProductQuantity != 200 || bp.Time != 10800 || len(bp.Materials) != 3 {
+		t.Errorf("reaction blueprint = %+v, want quantity 200, time 10800, 3 materials", bp)
+	}
+}
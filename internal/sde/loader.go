@@ -4,11 +4,14 @@ import (
 	"archive/zip"
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"eve-flipper/internal/graph"
@@ -19,14 +22,16 @@ const sdeURL = "https://developers.eveonline.com/static-data/eve-online-static-d
 
 // Data holds all parsed SDE data.
 type Data struct {
-	Systems      map[int32]*SolarSystem // systemID -> system
-	SystemByName map[string]int32       // lowercase name -> systemID
-	SystemNames  []string               // all system names for autocomplete
-	Regions      map[int32]*Region      // regionID -> region
-	RegionByName map[string]int32       // lowercase name -> regionID
-	Types        map[int32]*ItemType    // typeID -> type
-	Groups       map[int32]*ItemGroup   // groupID -> group metadata
-	Stations     map[int64]*Station     // stationID -> station
+	Systems      map[int32]*SolarSystem  // systemID -> system
+	SystemByName map[string]int32        // lowercase name -> systemID
+	SystemNames  []string                // all system names for autocomplete
+	Regions      map[int32]*Region       // regionID -> region
+	RegionByName map[string]int32        // lowercase name -> regionID
+	Types        map[int32]*ItemType     // typeID -> type
+	Groups       map[int32]*ItemGroup    // groupID -> group metadata
+	Categories   map[int32]*ItemCategory // categoryID -> category
+	MarketGroups map[int32]*MarketGroup  // marketGroupID -> market group (tree via ParentID)
+	Stations     map[int64]*Station      // stationID -> station
 	Universe     *graph.Universe
 	Industry     *IndustryData // blueprints, reprocessing, etc.
 }
@@ -53,6 +58,8 @@ type ItemType struct {
 	GroupID    int32   // item group (for categorization: rigs, ships, modules, etc.)
 	CategoryID int32   // item category (6=Ships, 7=Modules, 20=Implants, etc.)
 	IsRig      bool    // derived from group metadata
+	// MarketGroupID is the leaf market group the type is listed under.
+	MarketGroupID int32
 }
 
 // ItemGroup represents group-level SDE metadata used for type classification.
@@ -63,6 +70,19 @@ type ItemGroup struct {
 	IsRig      bool
 }
 
+// ItemCategory is the top level of the type classification (Ships, Modules...).
+type ItemCategory struct {
+	ID   int32
+	Name string
+}
+
+// MarketGroup is a node of the in-game market browser tree.
+type MarketGroup struct {
+	ID       int32
+	Name     string
+	ParentID int32 // 0 = top-level group
+}
+
 // Station represents an NPC station from the SDE.
 type Station struct {
 	ID       int64
@@ -70,7 +90,8 @@ type Station struct {
 	SystemID int32
 }
 
-// Load downloads (if needed) and parses the SDE.
+// Load downloads (if needed) and parses the SDE. A valid on-disk cache of a
+// previous parse is used instead when the extracted SDE has not changed.
 func Load(dataDir string) (*Data, error) {
 	zipPath := filepath.Join(dataDir, "sde.zip")
 	extractDir := filepath.Join(dataDir, "sde")
@@ -86,6 +107,39 @@ func Load(dataDir string) (*Data, error) {
 		}
 	}
 
+	// A parsed snapshot keyed by the SDE fingerprint skips the JSONL parse
+	// and graph build on every launch after the first.
+	cachePath := filepath.Join(dataDir, cacheFileName)
+	version, versionErr := sdeVersion(extractDir)
+	if versionErr != nil {
+		logger.Warn("SDE", fmt.Sprintf("Cannot fingerprint SDE, cache disabled: %v", versionErr))
+	} else if data, err := loadCache(cachePath, version); err == nil {
+		logger.Info("SDE", "Loaded from cache")
+		data.Universe.InitPathCache()
+		data.logStats()
+		return data, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		logger.Warn("SDE", fmt.Sprintf("Cache unusable, parsing SDE: %v", err))
+	}
+
+	data, err := parse(extractDir)
+	if err != nil {
+		return nil, err
+	}
+	if versionErr == nil {
+		if err := saveCache(cachePath, version, data); err != nil {
+			logger.Warn("SDE", fmt.Sprintf("Failed to write cache: %v", err))
+		}
+	}
+
+	// Initialize BFS path cache now that the universe graph is fully loaded.
+	data.Universe.InitPathCache()
+	data.logStats()
+	return data, nil
+}
+
+// parse reads the extracted SDE JSONL files into a new Data.
+func parse(extractDir string) (*Data, error) {
 	data := &Data{
 		Systems:      make(map[int32]*SolarSystem),
 		SystemByName: make(map[string]int32),
@@ -93,6 +147,8 @@ func Load(dataDir string) (*Data, error) {
 		RegionByName: make(map[string]int32),
 		Types:        make(map[int32]*ItemType),
 		Groups:       make(map[int32]*ItemGroup),
+		Categories:   make(map[int32]*ItemCategory),
+		MarketGroups: make(map[int32]*MarketGroup),
 		Stations:     make(map[int64]*Station),
 		Universe:     graph.NewUniverse(),
 	}
@@ -109,6 +165,10 @@ func Load(dataDir string) (*Data, error) {
 	if err := data.loadTypes(extractDir); err != nil {
 		return nil, err
 	}
+	logger.Info("SDE", "Loading market groups...")
+	if err := data.loadMarketGroups(extractDir); err != nil {
+		return nil, err
+	}
 	logger.Info("SDE", "Loading stations...")
 	if err := data.loadStations(extractDir); err != nil {
 		return nil, err
@@ -134,17 +194,16 @@ func Load(dataDir string) (*Data, error) {
 		return nil, fmt.Errorf("load industry: %w", err)
 	}
 	data.Industry = industry
+	return data, nil
+}
 
-	// Initialize BFS path cache now that the universe graph is fully loaded.
-	data.Universe.InitPathCache()
-
+func (d *Data) logStats() {
 	logger.Section("SDE Statistics")
-	logger.Stats("Regions", len(data.Regions))
-	logger.Stats("Systems", len(data.Systems))
-	logger.Stats("Item types", len(data.Types))
-	logger.Stats("Stations", len(data.Stations))
-	logger.Stats("Blueprints", len(data.Industry.Blueprints))
-	return data, nil
+	logger.Stats("Regions", len(d.Regions))
+	logger.Stats("Systems", len(d.Systems))
+	logger.Stats("Item types", len(d.Types))
+	logger.Stats("Stations", len(d.Stations))
+	logger.Stats("Blueprints", len(d.Industry.Blueprints))
 }
 
 // RegionNames returns a map of region ID to region name.
@@ -210,10 +269,25 @@ func (d *Data) loadSystems(dir string) error {
 }
 
 func (d *Data) loadTypes(dir string) error {
-	// First load groups to get category mapping and data-driven rig classification.
+	err := readJSONL(dir, "categories", func(raw json.RawMessage) error {
+		var c struct {
+			Key  int32             `json:"_key"`
+			Name map[string]string `json:"name"`
+		}
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return err
+		}
+		d.Categories[c.Key] = &ItemCategory{ID: c.Key, Name: strings.TrimSpace(c.Name["en"])}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("load categories: %w", err)
+	}
+
+	// Then load groups to get category mapping and data-driven rig classification.
 	groupCategories := make(map[int32]int32) // groupID -> categoryID
 	groupRig := make(map[int32]bool)         // groupID -> is rig group
-	err := readJSONL(dir, "groups", func(raw json.RawMessage) error {
+	err = readJSONL(dir, "groups", func(raw json.RawMessage) error {
 		var g struct {
 			Key        int32             `json:"_key"`
 			Name       map[string]string `json:"name"`
@@ -263,17 +337,94 @@ func (d *Data) loadTypes(dir string) error {
 			vol = t.Volume
 		}
 		d.Types[t.Key] = &ItemType{
-			ID:         t.Key,
-			Name:       name,
-			Volume:     vol,
-			GroupID:    t.GroupID,
-			CategoryID: groupCategories[t.GroupID],
-			IsRig:      groupRig[t.GroupID],
+			ID:            t.Key,
+			Name:          name,
+			Volume:        vol,
+			GroupID:       t.GroupID,
+			CategoryID:    groupCategories[t.GroupID],
+			IsRig:         groupRig[t.GroupID],
+			MarketGroupID: *t.MarketGroupID,
 		}
 		return nil
 	})
 }
 
+func (d *Data) loadMarketGroups(dir string) error {
+	return readJSONL(dir, "marketGroups", func(raw json.RawMessage) error {
+		var g struct {
+			Key           int32             `json:"_key"`
+			Name          map[string]string `json:"name"`
+			ParentGroupID int32             `json:"parentGroupID"`
+		}
+		if err := json.Unmarshal(raw, &g); err != nil {
+			return err
+		}
+		d.MarketGroups[g.Key] = &MarketGroup{
+			ID:       g.Key,
+			Name:     strings.TrimSpace(g.Name["en"]),
+			ParentID: g.ParentGroupID,
+		}
+		return nil
+	})
+}
+
+// TypesInMarketGroups returns every type listed under the given market groups
+// or any of their descendant groups.
+func (d *Data) TypesInMarketGroups(groupIDs []int32) map[int32]bool {
+	out := make(map[int32]bool)
+	if len(groupIDs) == 0 {
+		return out
+	}
+	roots := make(map[int32]bool, len(groupIDs))
+	for _, id := range groupIDs {
+		roots[id] = true
+	}
+	// Walk each type's group up to the tree root. Memoize per group; the
+	// depth guard stops malformed parent cycles.
+	inScope := make(map[int32]bool, len(d.MarketGroups))
+	var under func(id int32, depth int) bool
+	under = func(id int32, depth int) bool {
+		if id == 0 || depth > 32 {
+			return false
+		}
+		if v, ok := inScope[id]; ok {
+			return v
+		}
+		v := roots[id]
+		if !v {
+			if g, ok := d.MarketGroups[id]; ok {
+				v = under(g.ParentID, depth+1)
+			}
+		}
+		inScope[id] = v
+		return v
+	}
+	for id, t := range d.Types {
+		if under(t.MarketGroupID, 0) {
+			out[id] = true
+		}
+	}
+	return out
+}
+
+// MarketGroupPath returns the market groups from the tree root down to id.
+// Unknown groups end the walk; malformed parent cycles are cut off.
+func (d *Data) MarketGroupPath(id int32) []*MarketGroup {
+	var path []*MarketGroup
+	seen := make(map[int32]bool)
+	for id != 0 && !seen[id] && len(path) < 32 {
+		seen[id] = true
+		g, ok := d.MarketGroups[id]
+		if !ok {
+			break
+		}
+		path = append(path, g)
+		id = g.ParentID
+	}
+	slices.Reverse(path)
+	return path
+}
+
 func isRigGroupName(categoryID int32, groupName string) bool {
 	if categoryID != 7 {
 		return false
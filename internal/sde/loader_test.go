@@ -26,3 +26,57 @@ func TestIsRigGroupName(t *testing.T) {
 		})
 	}
 }
+
+func TestTypesInMarketGroups(t *testing.T) {
+	d := &Data{
+		MarketGroups: map[int32]*MarketGroup{
+			1: {ID: 1},
+			2: {ID: 2, ParentID: 1},
+			3: {ID: 3, ParentID: 2},
+			4: {ID: 4},
+			// Malformed cycle must not hang the walk.
+			5: {ID: 5, ParentID: 6},
+			6: {ID: 6, ParentID: 5},
+		},
+		Types: map[int32]*ItemType{
+			100: {ID: 100, MarketGroupID: 3},
+			101: {ID: 101, MarketGroupID: 2},
+			102: {ID: 102, MarketGroupID: 4},
+			103: {ID: 103, MarketGroupID: 5},
+			104: {ID: 104},
+		},
+	}
+
+	got := d.TypesInMarketGroups([]int32{1})
+	if len(got) != 2 || !got[100] || !got[101] {
+		t.Fatalf("TypesInMarketGroups(1) = %v, want {100, 101}", got)
+	}
+	if got := d.TypesInMarketGroups([]int32{3, 4}); len(got) != 2 || !got[100] || !got[102] {
+		t.Fatalf("TypesInMarketGroups(3, 4) = %v, want {100, 102}", got)
+	}
+	if got := d.TypesInMarketGroups(nil); len(got) != 0 {
+		t.Fatalf("TypesInMarketGroups(nil) = %v, want empty", got)
+	}
+}
+
+func TestMarketGroupPath(t *testing.T) {
+	d := &Data{
+		MarketGroups: map[int32]*MarketGroup{
+			1: {ID: 1, Name: "Ships"},
+			2: {ID: 2, Name: "Frigates", ParentID: 1},
+			3: {ID: 3, Name: "Standard Frigates", ParentID: 2},
+			5: {ID: 5, ParentID: 6},
+			6: {ID: 6, ParentID: 5},
+		},
+	}
+	path := d.MarketGroupPath(3)
+	if len(path) != 3 || path[0].ID != 1 || path[1].ID != 2 || path[2].ID != 3 {
+		t.Fatalf("MarketGroupPath(3) = %v, want root-first 1/2/3", path)
+	}
+	if got := d.MarketGroupPath(5); len(got) != 2 {
+		t.Fatalf("MarketGroupPath on a cycle = %d groups, want 2", len(got))
+	}
+	if got := d.MarketGroupPath(0); len(got) != 0 {
+		t.Fatalf("MarketGroupPath(0) = %v, want empty", got)
+	}
+}
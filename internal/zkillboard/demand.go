@@ -9,12 +9,23 @@ import (
 	"eve-flipper/internal/logger"
 )
 
+// DefaultDemandCacheTTL is how long region stats are reused before zKillboard
+// is queried again.
+const DefaultDemandCacheTTL = 30 * time.Minute
+
+// MinRefreshInterval is the minimum time between manual refreshes of the same
+// scope, so the refresh button can't hammer zKillboard.
+const MinRefreshInterval = 5 * time.Minute
+
 // DemandAnalyzer analyzes killmail data to predict market demand.
 type DemandAnalyzer struct {
 	client      *Client
 	cache       sync.Map // regionID -> *CachedRegionStats
-	cacheTTL    time.Duration
 	regionNames map[int32]string
+
+	mu          sync.RWMutex
+	cacheTTL    time.Duration
+	lastRefresh map[int32]time.Time // regionID (0 = all regions) -> last manual refresh
 }
 
 // CachedRegionStats holds cached region statistics.
@@ -53,22 +64,76 @@ type DemandItem struct {
 func NewDemandAnalyzer(regionNames map[int32]string) *DemandAnalyzer {
 	return &DemandAnalyzer{
 		client:      NewClient(),
-		cacheTTL:    30 * time.Minute,
+		cacheTTL:    DefaultDemandCacheTTL,
 		regionNames: regionNames,
+		lastRefresh: make(map[int32]time.Time),
 	}
 }
 
+// SetCacheTTL changes how long cached region stats stay fresh.
+// Non-positive values restore the default.
+func (d *DemandAnalyzer) SetCacheTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultDemandCacheTTL
+	}
+	d.mu.Lock()
+	d.cacheTTL = ttl
+	d.mu.Unlock()
+}
+
+// CacheTTL returns the current region stats TTL.
+func (d *DemandAnalyzer) CacheTTL() time.Duration {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.cacheTTL
+}
+
+func (d *DemandAnalyzer) isFresh(c *CachedRegionStats) bool {
+	return time.Since(c.UpdatedAt) < d.CacheTTL()
+}
+
+// BeginRefresh records a manual refresh of regionID (0 = all regions) if the
+// previous one is at least MinRefreshInterval old. It returns when the next
+// refresh of that scope is allowed; ok is false when this one is too early.
+// A full refresh also blocks single-region refreshes during its interval.
+func (d *DemandAnalyzer) BeginRefresh(regionID int32, now time.Time) (next time.Time, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	last := d.lastRefresh[regionID]
+	if regionID != 0 && d.lastRefresh[0].After(last) {
+		last = d.lastRefresh[0]
+	}
+	if !last.IsZero() && now.Sub(last) < MinRefreshInterval {
+		return last.Add(MinRefreshInterval), false
+	}
+	d.lastRefresh[regionID] = now
+	return now.Add(MinRefreshInterval), true
+}
+
 // SetRegionNames updates the region name mapping.
 func (d *DemandAnalyzer) SetRegionNames(names map[int32]string) {
 	d.regionNames = names
 }
 
 // ClearCache removes all cached region stats, forcing fresh API calls.
-func (d *DemandAnalyzer) ClearCache() {
+// Returns the number of regions removed.
+func (d *DemandAnalyzer) ClearCache() int {
+	n := 0
 	d.cache.Range(func(key, _ interface{}) bool {
 		d.cache.Delete(key)
+		n++
 		return true
 	})
+	return n
+}
+
+// InvalidateRegion removes one region's cached stats. Returns the number of
+// regions removed (0 or 1).
+func (d *DemandAnalyzer) InvalidateRegion(regionID int32) int {
+	if _, ok := d.cache.LoadAndDelete(regionID); ok {
+		return 1
+	}
+	return 0
 }
 
 // KnownSpaceRegions returns IDs of all known-space regions (excluding wormholes).
@@ -128,7 +193,7 @@ func (d *DemandAnalyzer) GetHotZones(limit int) ([]RegionHotZone, error) {
 				// Check cache first
 				if cached, ok := d.cache.Load(rid); ok {
 					c := cached.(*CachedRegionStats)
-					if time.Since(c.UpdatedAt) < d.cacheTTL {
+					if d.isFresh(c) {
 						results <- result{regionID: rid, stats: c.Stats}
 						return
 					}
@@ -194,7 +259,7 @@ func (d *DemandAnalyzer) GetSingleRegionStats(regionID int32) (*RegionHotZone, e
 	// Check cache first
 	if cached, ok := d.cache.Load(regionID); ok {
 		c := cached.(*CachedRegionStats)
-		if time.Since(c.UpdatedAt) < d.cacheTTL {
+		if d.isFresh(c) {
 			return d.analyzeRegion(regionID, c.Stats), nil
 		}
 	}
@@ -0,0 +1,145 @@
+package zkillboard
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EFTFit is a fitting parsed from the EFT text format used by the in-game
+// fitting window ("Copy to clipboard") and most fitting tools.
+type EFTFit struct {
+	ShipTypeID int32     `json:"ship_type_id"`
+	ShipName   string    `json:"ship_name"`
+	FitName    string    `json:"fit_name"`
+	Items      []EFTItem `json:"items"` // Ship hull first, then modules/charges/drones
+}
+
+// EFTItem is one distinct type in a fit with its total quantity.
+type EFTItem struct {
+	TypeID   int32  `json:"type_id"`
+	Name     string `json:"name"`
+	Quantity int64  `json:"quantity"`
+}
+
+// ParseEFT parses an EFT block. resolve maps an item name to its type ID.
+// Names that don't resolve are returned as warnings instead of failing the
+// parse; only a missing or unknown ship header is an error.
+func ParseEFT(text string, resolve func(name string) (int32, bool)) (*EFTFit, []string, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	warnings := []string{}
+
+	fit := &EFTFit{}
+	header := -1
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+			return nil, nil, fmt.Errorf("EFT fit must start with a [Ship, Fit name] header")
+		}
+		inner := strings.TrimSpace(line[1 : len(line)-1])
+		ship, name, _ := strings.Cut(inner, ",")
+		fit.ShipName = strings.TrimSpace(ship)
+		fit.FitName = strings.TrimSpace(name)
+		header = i
+		break
+	}
+	if header < 0 || fit.ShipName == "" {
+		return nil, nil, fmt.Errorf("EFT fit must start with a [Ship, Fit name] header")
+	}
+	shipID, ok := resolve(fit.ShipName)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown ship %q", fit.ShipName)
+	}
+	fit.ShipTypeID = shipID
+
+	quantities := map[int32]int64{shipID: 1}
+	names := map[int32]string{shipID: fit.ShipName}
+	order := []int32{shipID}
+	add := func(name string, qty int64) {
+		typeID, ok := resolve(name)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("unknown item %q", name))
+			return
+		}
+		if _, seen := quantities[typeID]; !seen {
+			order = append(order, typeID)
+			names[typeID] = name
+		}
+		quantities[typeID] += qty
+	}
+
+	for _, raw := range lines[header+1:] {
+		line := strings.TrimSpace(raw)
+		// Blank section separators and "[Empty High slot]" placeholders.
+		if line == "" || (strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]")) {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimSuffix(line, "/OFFLINE"))
+
+		// "Hobgoblin II x5" – drones, cargo and loose charges.
+		if name, qty, ok := splitEFTQuantity(line); ok {
+			add(name, qty)
+			continue
+		}
+		// "Light Neutron Blaster II, Void S" – module with a loaded charge.
+		module, charge, hasCharge := strings.Cut(line, ",")
+		add(strings.TrimSpace(module), 1)
+		if hasCharge && strings.TrimSpace(charge) != "" {
+			add(strings.TrimSpace(charge), 1)
+		}
+	}
+
+	for _, typeID := range order {
+		fit.Items = append(fit.Items, EFTItem{TypeID: typeID, Name: names[typeID], Quantity: quantities[typeID]})
+	}
+	return fit, warnings, nil
+}
+
+func splitEFTQuantity(line string) (string, int64, bool) {
+	i := strings.LastIndex(line, " x")
+	if i <= 0 {
+		return "", 0, false
+	}
+	qty, err := strconv.ParseInt(line[i+2:], 10, 64)
+	if err != nil || qty <= 0 {
+		return "", 0, false
+	}
+	return strings.TrimSpace(line[:i]), qty, true
+}
+
+// FitItemDemand is the regional loss-driven demand for one item of a fit.
+type FitItemDemand struct {
+	TypeID         int32   `json:"type_id"`
+	TypeName       string  `json:"type_name"`
+	Category       string  `json:"category,omitempty"`
+	QuantityInFit  int64   `json:"quantity_in_fit"`
+	EstDailyDemand float64 `json:"est_daily_demand"` // Units/day destroyed in the region
+	KillmailCount  int     `json:"killmail_count"`
+	FitsPerDay     float64 `json:"fits_per_day"` // EstDailyDemand / QuantityInFit
+}
+
+// FitDemand matches a fit's items against a region's fitting demand profile,
+// most demanded first. Items never seen on a loss have zero demand.
+func FitDemand(fit *EFTFit, profile *RegionDemandProfile) []FitItemDemand {
+	out := make([]FitItemDemand, 0, len(fit.Items))
+	for _, item := range fit.Items {
+		d := FitItemDemand{TypeID: item.TypeID, TypeName: item.Name, QuantityInFit: item.Quantity}
+		if profile != nil {
+			if p, ok := profile.Items[item.TypeID]; ok {
+				d.Category = p.Category
+				d.EstDailyDemand = p.EstDailyDemand
+				d.KillmailCount = p.KillmailCount
+			}
+		}
+		if item.Quantity > 0 {
+			d.FitsPerDay = d.EstDailyDemand / float64(item.Quantity)
+		}
+		out = append(out, d)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].FitsPerDay > out[j].FitsPerDay })
+	return out
+}
@@ -3,6 +3,7 @@ package zkillboard
 import (
 	"encoding/json"
 	"testing"
+	"time"
 )
 
 func TestNewClient_NonNil(t *testing.T) {
@@ -53,3 +54,131 @@ func TestKillmail_UnmarshalJSON(t *testing.T) {
 		t.Errorf("ZKB = %+v", k.ZKB)
 	}
 }
+
+func TestParseEFT(t *testing.T) {
+	ids := map[string]int32{
+		"Rifter":                           587,
+		"200mm AutoCannon II":              2889,
+		"EMP S":                            185,
+		"Damage Control II":                2048,
+		"Warrior II":                       2486,
+		"Small Projectile Burst Aerator I": 31656,
+	}
+	resolve := func(name string) (int32, bool) {
+		id, ok := ids[name]
+		return id, ok
+	}
+	text := "[Rifter, PvP]\r\n" +
+		"Damage Control II\r\n" +
+		"[Empty Low slot]\r\n" +
+		"\r\n" +
+		"200mm AutoCannon II, EMP S\r\n" +
+		"200mm AutoCannon II, EMP S /OFFLINE\r\n" +
+		"Mystery Module\r\n" +
+		"\r\n" +
+		"Small Projectile Burst Aerator I\r\n" +
+		"\r\n" +
+		"Warrior II x3\r\n"
+
+	fit, warnings, err := ParseEFT(text, resolve)
+	if err != nil {
+		t.Fatalf("ParseEFT: %v", err)
+	}
+	if fit.ShipTypeID != 587 || fit.FitName != "PvP" {
+		t.Fatalf("ship/fit = %d/%q", fit.ShipTypeID, fit.FitName)
+	}
+	qty := map[int32]int64{}
+	for _, item := range fit.Items {
+		qty[item.TypeID] = item.Quantity
+	}
+	want := map[int32]int64{587: 1, 2048: 1, 2889: 2, 185: 2, 31656: 1, 2486: 3}
+	for id, q := range want {
+		if qty[id] != q {
+			t.Errorf("quantity[%d] = %d, want %d", id, qty[id], q)
+		}
+	}
+	if len(qty) != len(want) {
+		t.Errorf("items = %+v", fit.Items)
+	}
+	if len(warnings) != 1 || warnings[0] != `unknown item "Mystery Module"` {
+		t.Errorf("warnings = %v", warnings)
+	}
+
+	if _, _, err := ParseEFT("[Unknown Hull, x]\n", resolve); err == nil {
+		t.Error("unknown ship: want error")
+	}
+	if _, _, err := ParseEFT("Damage Control II\n", resolve); err == nil {
+		t.Error("missing header: want error")
+	}
+}
+
+func TestFitDemand(t *testing.T) {
+	fit := &EFTFit{Items: []EFTItem{
+		{TypeID: 1, Name: "Hull", Quantity: 1},
+		{TypeID: 2, Name: "Drone", Quantity: 4},
+		{TypeID: 3, Name: "Unseen", Quantity: 1},
+	}}
+	profile := &RegionDemandProfile{Items: map[int32]*ItemDemandProfile{
+		1: {TypeID: 1, EstDailyDemand: 2, KillmailCount: 5},
+		2: {TypeID: 2, EstDailyDemand: 20, KillmailCount: 5},
+	}}
+	got := FitDemand(fit, profile)
+	if len(got) != 3 || got[0].TypeID != 2 || got[0].FitsPerDay != 5 {
+		t.Fatalf("FitDemand = %+v, want drone first with 5 fits/day", got)
+	}
+	if got[2].TypeID != 3 || got[2].EstDailyDemand != 0 {
+		t.Fatalf("unseen item = %+v, want zero demand", got[2])
+	}
+}
+
+func TestDemandAnalyzer_BeginRefresh(t *testing.T) {
+	d := NewDemandAnalyzer(nil)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	next, ok := d.BeginRefresh(0, now)
+	if !ok || !next.Equal(now.Add(MinRefreshInterval)) {
+		t.Fatalf("first full refresh = %v/%v, want allowed", next, ok)
+	}
+	// A full refresh also covers single regions for its interval.
+	if _, ok := d.BeginRefresh(10000002, now.Add(time.Minute)); ok {
+		t.Fatal("region refresh right after full refresh: want blocked")
+	}
+	if next, ok := d.BeginRefresh(0, now.Add(time.Minute)); ok || !next.Equal(now.Add(MinRefreshInterval)) {
+		t.Fatalf("repeat full refresh = %v/%v, want blocked until %v", next, ok, now.Add(MinRefreshInterval))
+	}
+	later := now.Add(MinRefreshInterval)
+	if _, ok := d.BeginRefresh(10000002, later); !ok {
+		t.Fatal("region refresh after interval: want allowed")
+	}
+	// Regions are rate limited independently of each other.
+	if _, ok := d.BeginRefresh(10000043, later); !ok {
+		t.Fatal("other region refresh: want allowed")
+	}
+}
+
+func TestDemandAnalyzer_CacheTTLAndInvalidate(t *testing.T) {
+	d := NewDemandAnalyzer(nil)
+	if d.CacheTTL() != DefaultDemandCacheTTL {
+		t.Fatalf("CacheTTL = %v, want default", d.CacheTTL())
+	}
+	d.SetCacheTTL(5 * time.Minute)
+	if d.CacheTTL() != 5*time.Minute {
+		t.Fatalf("CacheTTL = %v, want 5m", d.CacheTTL())
+	}
+	d.SetCacheTTL(0)
+	if d.CacheTTL() != DefaultDemandCacheTTL {
+		t.Fatalf("CacheTTL after 0 = %v, want default", d.CacheTTL())
+	}
+
+	d.cache.Store(int32(1), &CachedRegionStats{UpdatedAt: time.Now()})
+	d.cache.Store(int32(2), &CachedRegionStats{UpdatedAt: time.Now()})
+	if n := d.InvalidateRegion(1); n != 1 {
+		t.Fatalf("InvalidateRegion(1) = %d, want 1", n)
+	}
+	if n := d.InvalidateRegion(1); n != 0 {
+		t.Fatalf("InvalidateRegion(1) again = %d, want 0", n)
+	}
+	if n := d.ClearCache(); n != 1 {
+		t.Fatalf("ClearCache = %d, want 1", n)
+	}
+}
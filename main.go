@@ -6,10 +6,12 @@ import (
 	"flag"
 	"fmt"
 	"io/fs"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -92,7 +94,10 @@ func main() {
 
 	port := flag.Int("port", 13370, "HTTP server port")
 	host := flag.String("host", "127.0.0.1", "Host to bind to (use 0.0.0.0 to allow LAN/remote access)")
+	socket := flag.String("socket", "", "Listen on this Unix domain socket instead of host:port (for reverse proxies)")
+	debug := flag.Bool("debug", envBool("EVEFLIPPER_DEBUG"), "Enable debug logging (or set EVEFLIPPER_DEBUG=1)")
 	flag.Parse()
+	logger.SetDebug(*debug)
 
 	logger.Banner(version)
 
@@ -111,13 +116,15 @@ func main() {
 	// Migrate config.json → SQLite (if exists)
 	database.MigrateFromJSON()
 
-	// Cleanup old market history to prevent unbounded DB growth
-	database.CleanupOldHistory()
-
 	// Load config from SQLite
 	cfg := database.LoadConfig()
 
+	// Cleanup old scan and market history to prevent unbounded DB growth
+	database.SetHistoryRetention(cfg.HistoryRetentionDays, cfg.MarketHistoryRetentionDays)
+	database.CleanupOldHistory()
+
 	esiClient := esi.NewClient(database)
+	esiClient.SetMaxRetries(cfg.ESIMaxRetries)
 	esiClient.LoadEVERefStructures() // background fetch of public structure names
 
 	// ESI SSO config (from env vars or injected defaults for official builds).
@@ -141,6 +148,16 @@ func main() {
 	sessions := auth.NewSessionStore(database.SqlDB())
 
 	srv := api.NewServer(cfg, esiClient, database, ssoConfig, sessions)
+	if strings.TrimSpace(os.Getenv("EVEFLIPPER_API_KEY")) != "" {
+		logger.Info("Server", "API key required for /api/ requests")
+	} else if *socket != "" {
+		// A socket is usually published through a reverse proxy.
+		logger.Warn("Server", "Listening on a Unix socket without EVEFLIPPER_API_KEY: every endpoint is unauthenticated to whatever proxies it")
+	} else if *host != "127.0.0.1" && *host != "localhost" {
+		logger.Warn("Server", "Listening beyond localhost without EVEFLIPPER_API_KEY: every endpoint is unauthenticated")
+	}
+
+	srv.SetSDELoader(func() (*sde.Data, error) { return sde.Load(dataDir) })
 
 	// Load SDE in background
 	go func() {
@@ -156,30 +173,36 @@ func main() {
 	// Combine API + embedded frontend into a single handler
 	apiHandler := srv.Handler()
 	frontendContent, _ := fs.Sub(frontendFS, "frontend/dist")
-	fileServer := http.FileServer(http.FS(frontendContent))
+	// Static files with caching headers, falling back to index.html (SPA).
+	frontend := newFrontendHandler(frontendContent)
 
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// API routes
-		if strings.HasPrefix(r.URL.Path, "/api/") {
+		if strings.HasPrefix(r.URL.Path, "/api/") || r.URL.Path == "/metrics" {
 			apiHandler.ServeHTTP(w, r)
 			return
 		}
-		// Try static file, fall back to index.html (SPA)
-		path := strings.TrimPrefix(r.URL.Path, "/")
-		if path == "" {
-			path = "index.html"
-		}
-		if _, err := fs.Stat(frontendContent, path); err == nil {
-			fileServer.ServeHTTP(w, r)
-			return
-		}
-		// SPA fallback
-		r.URL.Path = "/"
-		fileServer.ServeHTTP(w, r)
+		frontend.ServeHTTP(w, r)
 	})
 
 	addr := fmt.Sprintf("%s:%d", *host, *port)
-	logger.Server(addr)
+	var listener net.Listener
+	if *socket != "" {
+		listener, err = listenUnixSocket(*socket)
+		if err == nil {
+			defer os.Remove(*socket)
+			logger.ServerSocket(*socket)
+		}
+	} else {
+		listener, err = net.Listen("tcp", addr)
+		if err == nil {
+			logger.Server(addr)
+		}
+	}
+	if err != nil {
+		logger.Error("Server", fmt.Sprintf("Failed: %v", err))
+		os.Exit(1)
+	}
 
 	httpServer := &http.Server{Addr: addr, Handler: handler}
 
@@ -197,16 +220,50 @@ func main() {
 		}
 	}()
 
-	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
 		logger.Error("Server", fmt.Sprintf("Failed: %v", err))
+		if *socket != "" {
+			os.Remove(*socket)
+		}
 		os.Exit(1)
 	}
 	logger.Info("Server", "Stopped")
 }
 
+// listenUnixSocket listens on path, replacing a socket file left behind by
+// an unclean exit. Anything at path that is not a socket is left alone. The
+// socket is made group-writable so a reverse proxy in the same group can
+// connect.
+func listenUnixSocket(path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0660); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("chmod socket: %w", err)
+	}
+	return ln, nil
+}
+
 func envOrDefault(key, defaultVal string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
 	}
 	return defaultVal
 }
+
+// envBool reports whether key holds a true value as understood by
+// strconv.ParseBool. Unset or unparseable values count as false.
+func envBool(key string) bool {
+	v, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(key)))
+	return err == nil && v
+}
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestEnvBool(t *testing.T) {
+	for _, tc := range []struct {
+		val  string
+		want bool
+	}{
+		{"", false},
+		{"0", false},
+		{"false", false},
+		{"nope", false},
+		{"1", true},
+		{"true", true},
+		{" TRUE ", true},
+	} {
+		t.Setenv("EVEFLIPPER_TEST_BOOL", tc.val)
+		if got := envBool("EVEFLIPPER_TEST_BOOL"); got != tc.want {
+			t.Errorf("envBool(%q) = %v, want %v", tc.val, got, tc.want)
+		}
+	}
+}